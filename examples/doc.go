@@ -0,0 +1,14 @@
+// Package examples holds end-to-end scenarios of the mem renderer, written as
+// testable examples: each one is documentation that go test runs and checks
+// against its expected output, so the scenarios double as integration tests
+// of features that are otherwise tested one at a time.
+//
+//   - Example_desiredStateStore: an operator keeping the desired state of its
+//     tenants in a renderer and applying only what changed.
+//   - Example_gitOpsExporter: one definition rendered per environment and
+//     written to a GitOps repository, with credentials redacted.
+//   - Example_fixturePipeline: generated fixtures pushed through a renderer
+//     under test and checked for labels, content hashes, and determinism.
+//   - Example_overlayComposition: a platform bundle with overlays merged
+//     with the renderer of the team owning the application.
+package examples
@@ -0,0 +1,136 @@
+package examples_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+)
+
+// Example_gitOpsExporter renders one application definition for every
+// environment and writes it to the directory layout of a GitOps repository,
+// one file per object. Credentials reach the renders, which an operator could
+// apply directly, but are redacted in everything written to the repository.
+func Example_gitOpsExporter() {
+	app := mem.Source{Objects: []unstructured.Unstructured{
+		{Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "web", "namespace": "app"},
+			"spec": map[string]any{
+				"replicas": int64(1),
+				"template": map[string]any{"spec": map[string]any{
+					"containers": []any{map[string]any{
+						"name":  "web",
+						"image": "web:1.0",
+						"env": []any{
+							map[string]any{"name": "LOG_LEVEL", "value": "debug"},
+							map[string]any{"name": "API_TOKEN", "value": "dev-token"},
+						},
+					}},
+				}},
+			},
+		}},
+		{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]any{"name": "db", "namespace": "app"},
+			"stringData": map[string]any{"password": "hunter2"},
+		}},
+	}}
+
+	matrix := mem.Matrix{
+		Sources: []mem.Source{app},
+		Environments: map[string]mem.EnvConfig{
+			"staging": {Namespace: "staging"},
+			"prod": {
+				Namespace: "prod",
+				Labels:    map[string]string{"tier": "critical"},
+				Patches: []mem.Patch{{
+					Target: mem.PatchTarget{Kind: "Deployment", Name: "web"},
+					Merge:  map[string]any{"spec": map[string]any{"replicas": int64(3)}},
+				}},
+			},
+		},
+		Options: []mem.RendererOption{mem.WithContentHash(false)},
+	}
+
+	outputs, err := matrix.Render(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	repository, err := os.MkdirTemp("", "gitops")
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() { _ = os.RemoveAll(repository) }()
+
+	err = mem.WriteMatrix(repository, outputs, mem.WithExportRedaction(
+		mem.SecretRedaction(),
+		mem.RedactionRule{EnvNames: `TOKEN$`},
+	))
+	if err != nil {
+		panic(err)
+	}
+
+	err = filepath.WalkDir(repository, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(repository, path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(name)
+
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(repository, "prod", "deployment.apps_prod_web.yaml"))
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Print(string(manifest))
+
+	// The renders keep the real values.
+	fmt.Println(outputs["prod"][1].Object["stringData"])
+
+	// Output:
+	// prod/deployment.apps_prod_web.yaml
+	// prod/secret_prod_db.yaml
+	// staging/deployment.apps_staging_web.yaml
+	// staging/secret_staging_db.yaml
+	// apiVersion: apps/v1
+	// kind: Deployment
+	// metadata:
+	//   labels:
+	//     tier: critical
+	//   name: web
+	//   namespace: prod
+	// spec:
+	//   replicas: 3
+	//   template:
+	//     spec:
+	//       containers:
+	//       - env:
+	//         - name: LOG_LEVEL
+	//           value: debug
+	//         - name: API_TOKEN
+	//           value: REDACTED
+	//         image: web:1.0
+	//         name: web
+	// map[password:hunter2]
+}
@@ -0,0 +1,107 @@
+package examples_test
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+)
+
+// tenant returns the objects an operator renders for a tenant: a ConfigMap
+// holding its quota and, for paying tenants, a dedicated worker.
+func tenant(name string, quota string, dedicated bool) mem.Source {
+	objects := []unstructured.Unstructured{{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "quota", "namespace": name},
+		"data":       map[string]any{"cpu": quota},
+	}}}
+
+	if dedicated {
+		objects = append(objects, unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "worker", "namespace": name},
+		}})
+	}
+
+	return mem.Source{Name: name, Objects: objects}
+}
+
+// Example_desiredStateStore keeps the desired state of an operator's tenants
+// in a renderer: each tenant is a named source, updated in a transaction when
+// its custom resource changes. Reconciles compare the render digest with the
+// one recorded in the status to skip unchanged states, and diff the new
+// render against the applied one to apply and prune only what changed.
+func Example_desiredStateStore() {
+	ctx := context.Background()
+
+	store, err := mem.New(
+		[]mem.Source{tenant("acme", "2", false), tenant("globex", "4", true)},
+		mem.WithEnsureNamespaces(true),
+		mem.WithNamespaceMetadata(map[string]string{"managed-by": "tenant-operator"}, nil),
+		mem.WithRenderCache(true),
+		// Shared by every renderer of the operator, so that a restart does
+		// not render all tenants at once.
+		mem.WithRenderSemaphore(mem.NewRenderSemaphore(4)),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	applied, err := store.Process(ctx, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	recorded := mem.Summarize(applied).RenderedHash
+	fmt.Println("applied", len(applied), "objects")
+
+	// Nothing changed since: the reconcile ends here.
+	digest, err := store.RenderDigest(ctx, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("unchanged:", digest == recorded)
+
+	// acme upgrades and globex leaves.
+	err = store.Begin().
+		Upsert(tenant("acme", "8", true)).
+		RemoveSource("globex").
+		Commit()
+	if err != nil {
+		panic(err)
+	}
+
+	desired, err := store.Process(ctx, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	diff := mem.Diff(applied, desired)
+	for _, key := range slices.Sorted(maps.Keys(diff.Added)) {
+		fmt.Println("create", key)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(diff.Changed)) {
+		fmt.Println("update", key)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(diff.Removed)) {
+		fmt.Println("delete", key)
+	}
+
+	// Output:
+	// applied 5 objects
+	// unchanged: true
+	// create apps/Deployment/acme/worker
+	// update /ConfigMap/acme/quota
+	// delete /ConfigMap/globex/quota
+	// delete /Namespace//globex
+	// delete apps/Deployment/globex/worker
+}
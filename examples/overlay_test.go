@@ -0,0 +1,114 @@
+package examples_test
+
+import (
+	"context"
+	"os"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+)
+
+// Example_overlayComposition composes an application from a platform bundle,
+// a base with kustomize-like overlays, and the renderer of the team owning
+// the application, which adds a sidecar to the platform's Deployment. The
+// renderers are built independently and merged: objects they share are
+// merged field by field, with containers merged by name as a strategic merge
+// patch would.
+func Example_overlayComposition() {
+	platform, err := mem.NewBundle(mem.Bundle{
+		Base: mem.Source{Objects: []unstructured.Unstructured{
+			{Object: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "api"},
+				"spec": map[string]any{
+					"replicas": int64(1),
+					"template": map[string]any{"spec": map[string]any{
+						"containers": []any{map[string]any{"name": "api", "image": "api:2.1"}},
+					}},
+				},
+			}},
+			{Object: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]any{"name": "api"},
+				"spec": map[string]any{
+					"ports": []any{map[string]any{"port": int64(80)}},
+				},
+			}},
+		}},
+		Overlays: []mem.Overlay{
+			{
+				Namespace:    "shop",
+				CommonLabels: map[string]string{"app.kubernetes.io/part-of": "shop"},
+			},
+			{
+				Patches: []mem.Patch{{
+					Target: mem.PatchTarget{Kind: "Deployment"},
+					Merge:  map[string]any{"spec": map[string]any{"replicas": int64(3)}},
+				}},
+			},
+		},
+	}, mem.WithContentHash(false))
+	if err != nil {
+		panic(err)
+	}
+
+	team, err := mem.New([]mem.Source{{Objects: []unstructured.Unstructured{{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "api", "namespace": "shop"},
+		"spec": map[string]any{
+			"template": map[string]any{"spec": map[string]any{
+				"containers": []any{map[string]any{"name": "metrics", "image": "exporter:0.9"}},
+			}},
+		},
+	}}}}}, mem.WithContentHash(false))
+	if err != nil {
+		panic(err)
+	}
+
+	application, err := mem.Merge(platform, team, mem.DuplicateMerge, mem.WithMergeKeys(mem.BuiltinMergeKeys()))
+	if err != nil {
+		panic(err)
+	}
+
+	objects, err := application.Process(context.Background(), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := mem.WriteManifests(os.Stdout, objects); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// apiVersion: apps/v1
+	// kind: Deployment
+	// metadata:
+	//   labels:
+	//     app.kubernetes.io/part-of: shop
+	//   name: api
+	//   namespace: shop
+	// spec:
+	//   replicas: 3
+	//   template:
+	//     spec:
+	//       containers:
+	//       - image: api:2.1
+	//         name: api
+	//       - image: exporter:0.9
+	//         name: metrics
+	// ---
+	// apiVersion: v1
+	// kind: Service
+	// metadata:
+	//   labels:
+	//     app.kubernetes.io/part-of: shop
+	//   name: api
+	//   namespace: shop
+	// spec:
+	//   ports:
+	//   - port: 80
+}
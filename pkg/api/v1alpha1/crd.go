@@ -0,0 +1,24 @@
+package v1alpha1
+
+import (
+	_ "embed"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed membundle-crd.yaml
+var crdManifest []byte
+
+// CustomResourceDefinition returns the CRD of MemBundle, for installation
+// alongside the controller that reconciles bundles. Its schema preserves
+// unknown fields: the spec is validated when Reconcile builds the renderer.
+func CustomResourceDefinition() unstructured.Unstructured {
+	var crd unstructured.Unstructured
+	if err := yaml.Unmarshal(crdManifest, &crd.Object); err != nil {
+		panic(fmt.Sprintf("v1alpha1.CustomResourceDefinition: %v", err))
+	}
+
+	return crd
+}
@@ -0,0 +1,118 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto copies the receiver into out.
+func (in *MemBundle) DeepCopyInto(out *MemBundle) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *MemBundle) DeepCopy() *MemBundle {
+	if in == nil {
+		return nil
+	}
+
+	out := new(MemBundle)
+	in.DeepCopyInto(out)
+
+	return out
+}
+
+// DeepCopyObject returns a deep copy of the receiver as a runtime.Object.
+func (in *MemBundle) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+
+	return nil
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *MemBundleSpec) DeepCopyInto(out *MemBundleSpec) {
+	*out = *in
+	in.RendererSpec.DeepCopyInto(&out.RendererSpec)
+
+	if in.Values != nil {
+		out.Values = runtime.DeepCopyJSON(in.Values)
+	}
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *MemBundleSpec) DeepCopy() *MemBundleSpec {
+	if in == nil {
+		return nil
+	}
+
+	out := new(MemBundleSpec)
+	in.DeepCopyInto(out)
+
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *MemBundleStatus) DeepCopyInto(out *MemBundleStatus) {
+	*out = *in
+	out.Rendered = in.Rendered.DeepCopy()
+
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *MemBundleStatus) DeepCopy() *MemBundleStatus {
+	if in == nil {
+		return nil
+	}
+
+	out := new(MemBundleStatus)
+	in.DeepCopyInto(out)
+
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *MemBundleList) DeepCopyInto(out *MemBundleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+
+	if in.Items != nil {
+		out.Items = make([]MemBundle, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *MemBundleList) DeepCopy() *MemBundleList {
+	if in == nil {
+		return nil
+	}
+
+	out := new(MemBundleList)
+	in.DeepCopyInto(out)
+
+	return out
+}
+
+// DeepCopyObject returns a deep copy of the receiver as a runtime.Object.
+func (in *MemBundleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+
+	return nil
+}
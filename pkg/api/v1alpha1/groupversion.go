@@ -0,0 +1,31 @@
+// Package v1alpha1 defines MemBundle, a custom resource whose spec is a
+// mem.RendererSpec, so platform teams can offer in-memory rendered bundles
+// as a cluster API. Reconcile renders a bundle and records the outcome in its
+// status; CustomResourceDefinition returns the CRD to install.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersion is the API group and version of MemBundle. The group is a
+// subdomain of k8s-manifest-kit.github.io, the one domain the project owns;
+// it is part of the name of every installed CRD, so it must not change.
+var GroupVersion = schema.GroupVersion{Group: "mem.k8s-manifest-kit.github.io", Version: "v1alpha1"}
+
+var (
+	// SchemeBuilder registers the types of this package.
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+	// AddToScheme adds the types of this package to a scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(GroupVersion, &MemBundle{}, &MemBundleList{})
+	metav1.AddToGroupVersion(scheme, GroupVersion)
+
+	return nil
+}
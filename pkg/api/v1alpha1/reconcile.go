@@ -0,0 +1,90 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+)
+
+// Reconcile renders bundle with the values of its spec and returns the
+// objects to apply. It records the outcome in the status of bundle, which
+// the caller writes back: the observed generation, ConditionReady, and, on
+// success, the summary of the rendered objects. A failed render keeps the
+// summary of the last successful one. opts are passed to mem.FromSpec, to add
+// the callbacks a spec cannot express.
+func Reconcile(
+	ctx context.Context,
+	bundle *MemBundle,
+	opts ...mem.RendererOption,
+) ([]unstructured.Unstructured, error) {
+	bundle.Status.ObservedGeneration = bundle.Generation
+
+	objects, err := render(ctx, bundle, opts)
+	if err != nil {
+		meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+			Type:               ConditionReady,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: bundle.Generation,
+			Reason:             ReasonRenderFailed,
+			Message:            conditionMessage(err.Error()),
+		})
+
+		return nil, err
+	}
+
+	summary := mem.Summarize(objects)
+	bundle.Status.Rendered = &summary
+
+	meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+		Type:               ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: bundle.Generation,
+		Reason:             ReasonRendered,
+		Message:            conditionMessage(summary.String()),
+	})
+
+	return objects, nil
+}
+
+// maxConditionMessage is the length limit of metav1.Condition messages.
+const maxConditionMessage = 32768
+
+// truncatedSuffix ends condition messages that were cut.
+const truncatedSuffix = "... (truncated)"
+
+// conditionMessage cuts message to maxConditionMessage bytes, at a rune
+// boundary, so that errors naming many objects, such as schema or policy
+// errors, do not get the status update rejected. Reconcile still returns the
+// whole error.
+func conditionMessage(message string) string {
+	if len(message) <= maxConditionMessage {
+		return message
+	}
+
+	cut := maxConditionMessage - len(truncatedSuffix)
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+
+	return message[:cut] + truncatedSuffix
+}
+
+func render(ctx context.Context, bundle *MemBundle, opts []mem.RendererOption) ([]unstructured.Unstructured, error) {
+	renderer, err := mem.FromSpec(bundle.Spec.RendererSpec, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec of MemBundle %s/%s: %w", bundle.Namespace, bundle.Name, err)
+	}
+
+	objects, err := renderer.Process(ctx, bundle.Spec.Values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render MemBundle %s/%s: %w", bundle.Namespace, bundle.Name, err)
+	}
+
+	return objects, nil
+}
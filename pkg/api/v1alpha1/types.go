@@ -0,0 +1,56 @@
+package v1alpha1
+
+import (
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+)
+
+const (
+	// ConditionReady reports whether the latest render of a bundle succeeded.
+	ConditionReady = "Ready"
+
+	// ReasonRendered and ReasonRenderFailed are the reasons of ConditionReady.
+	ReasonRendered     = "Rendered"
+	ReasonRenderFailed = "RenderFailed"
+)
+
+// MemBundle is a set of manifests rendered in memory from its spec.
+type MemBundle struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MemBundleSpec   `json:"spec,omitempty"`
+	Status MemBundleStatus `json:"status,omitempty"`
+}
+
+// MemBundleSpec describes the renderer of a bundle and the values it renders.
+type MemBundleSpec struct {
+	mem.RendererSpec `json:",inline"`
+
+	// Values are passed to the renderer on every render. They must be
+	// JSON-compatible, as in unstructured content.
+	Values types.Values `json:"values,omitempty"`
+}
+
+// MemBundleStatus is the outcome of the latest render of a bundle.
+type MemBundleStatus struct {
+	// ObservedGeneration is the generation of the bundle last rendered.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Rendered summarizes the objects of the latest successful render.
+	Rendered *mem.RenderSummary `json:"rendered,omitempty"`
+
+	// Conditions hold ConditionReady.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// MemBundleList is a list of MemBundles.
+type MemBundleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []MemBundle `json:"items"`
+}
@@ -0,0 +1,224 @@
+package v1alpha1_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+	"github.com/k8s-manifest-kit/renderer-mem/pkg/api/v1alpha1"
+
+	. "github.com/onsi/gomega"
+)
+
+const bundleYAML = `
+apiVersion: mem.k8s-manifest-kit.github.io/v1alpha1
+kind: MemBundle
+metadata:
+  name: app
+  namespace: platform
+  generation: 3
+spec:
+  sources:
+  - name: base
+    objects:
+    - apiVersion: v1
+      kind: Secret
+      metadata:
+        name: credentials
+        namespace: app
+    manifests:
+    - |
+      apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: config
+        namespace: app
+      data:
+        key: a
+  options:
+    contentHash: false
+  values:
+    env: dev
+`
+
+func decodeBundle(t *testing.T) *v1alpha1.MemBundle {
+	t.Helper()
+
+	var bundle v1alpha1.MemBundle
+	NewWithT(t).Expect(yaml.UnmarshalStrict([]byte(bundleYAML), &bundle)).To(Succeed())
+
+	return &bundle
+}
+
+func bundleWithStatus(t *testing.T) *v1alpha1.MemBundle {
+	t.Helper()
+
+	bundle := decodeBundle(t)
+	bundle.Status.Rendered = &mem.RenderSummary{Kinds: map[string]int64{"ConfigMap": 1}}
+	bundle.Status.Conditions = []metav1.Condition{{Type: v1alpha1.ConditionReady}}
+
+	return bundle
+}
+
+func TestScheme(t *testing.T) {
+
+	t.Run("should register MemBundle and MemBundleList", func(t *testing.T) {
+		g := NewWithT(t)
+
+		scheme := runtime.NewScheme()
+		g.Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		obj, err := scheme.New(v1alpha1.GroupVersion.WithKind("MemBundle"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj).To(BeAssignableToTypeOf(&v1alpha1.MemBundle{}))
+
+		obj, err = scheme.New(v1alpha1.GroupVersion.WithKind("MemBundleList"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj).To(BeAssignableToTypeOf(&v1alpha1.MemBundleList{}))
+	})
+
+	t.Run("should convert bundles from unstructured content", func(t *testing.T) {
+		g := NewWithT(t)
+
+		bundle := decodeBundle(t)
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(bundle)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var converted v1alpha1.MemBundle
+		g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(content, &converted)).To(Succeed())
+		g.Expect(converted.Spec).To(Equal(bundle.Spec))
+	})
+}
+
+func TestDeepCopy(t *testing.T) {
+
+	t.Run("should copy bundles without sharing content", func(t *testing.T) {
+		g := NewWithT(t)
+
+		bundle := bundleWithStatus(t)
+
+		list := &v1alpha1.MemBundleList{Items: []v1alpha1.MemBundle{*bundle}}
+		listCopy, ok := list.DeepCopyObject().(*v1alpha1.MemBundleList)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(listCopy).To(Equal(list))
+
+		bundleCopy := &listCopy.Items[0]
+		bundleCopy.Labels = map[string]string{"changed": "true"}
+		bundleCopy.Spec.Values["env"] = "prod"
+		bundleCopy.Spec.Sources[0].Manifests[0] = ""
+		bundleCopy.Status.Rendered.Kinds["ConfigMap"] = 2
+		bundleCopy.Status.Conditions[0].Type = "Changed"
+
+		g.Expect(bundle).To(Equal(bundleWithStatus(t)))
+	})
+}
+
+func TestReconcile(t *testing.T) {
+
+	t.Run("should render bundles and summarize them in their status", func(t *testing.T) {
+		g := NewWithT(t)
+
+		bundle := decodeBundle(t)
+
+		objects, err := v1alpha1.Reconcile(t.Context(), bundle)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[1].GetName()).To(Equal("config"))
+
+		g.Expect(bundle.Status.ObservedGeneration).To(Equal(int64(3)))
+		g.Expect(bundle.Status.Rendered.ObjectCount).To(Equal(int64(2)))
+		g.Expect(bundle.Status.Rendered.RenderedHash).To(Equal(mem.Summarize(objects).RenderedHash))
+
+		ready := meta.FindStatusCondition(bundle.Status.Conditions, v1alpha1.ConditionReady)
+		g.Expect(ready).ToNot(BeNil())
+		g.Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+		g.Expect(ready.Reason).To(Equal(v1alpha1.ReasonRendered))
+		g.Expect(ready.ObservedGeneration).To(Equal(int64(3)))
+	})
+
+	t.Run("should report failed renders and keep the last summary", func(t *testing.T) {
+		g := NewWithT(t)
+
+		bundle := decodeBundle(t)
+
+		_, err := v1alpha1.Reconcile(t.Context(), bundle)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		rendered := bundle.Status.Rendered
+
+		bundle.Generation = 4
+		bundle.Spec.Sources[0].Manifests = []string{"kind: ["}
+
+		_, err = v1alpha1.Reconcile(t.Context(), bundle)
+		g.Expect(err).To(MatchError(ContainSubstring("failed to render MemBundle platform/app")))
+		g.Expect(bundle.Status.ObservedGeneration).To(Equal(int64(4)))
+		g.Expect(bundle.Status.Rendered).To(Equal(rendered))
+
+		ready := meta.FindStatusCondition(bundle.Status.Conditions, v1alpha1.ConditionReady)
+		g.Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		g.Expect(ready.Reason).To(Equal(v1alpha1.ReasonRenderFailed))
+		g.Expect(ready.Message).To(Equal(err.Error()))
+	})
+
+	t.Run("should truncate long failure messages", func(t *testing.T) {
+		g := NewWithT(t)
+
+		bundle := decodeBundle(t)
+
+		long := errors.New(strings.Repeat("é", 20000))
+		_, err := v1alpha1.Reconcile(t.Context(), bundle, mem.WithTransformer(
+			func(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+				return obj, long
+			},
+		))
+		g.Expect(err).To(MatchError(long))
+
+		ready := meta.FindStatusCondition(bundle.Status.Conditions, v1alpha1.ConditionReady)
+		g.Expect(len(ready.Message)).To(BeNumerically("<=", 32768))
+		g.Expect(utf8.ValidString(ready.Message)).To(BeTrue())
+		g.Expect(ready.Message).To(HaveSuffix("... (truncated)"))
+		g.Expect(err.Error()).To(HavePrefix(strings.TrimSuffix(ready.Message, "... (truncated)")))
+	})
+
+	t.Run("should report invalid specs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		bundle := decodeBundle(t)
+		bundle.Spec.Options.DuplicatePolicy = "newest"
+
+		_, err := v1alpha1.Reconcile(t.Context(), bundle)
+		g.Expect(err).To(MatchError(mem.ErrInvalidDuplicatePolicy))
+		g.Expect(err).To(MatchError(ContainSubstring("invalid spec of MemBundle platform/app")))
+	})
+}
+
+func TestCustomResourceDefinition(t *testing.T) {
+
+	t.Run("should describe MemBundle", func(t *testing.T) {
+		g := NewWithT(t)
+
+		crd := v1alpha1.CustomResourceDefinition()
+		g.Expect(crd.GetKind()).To(Equal("CustomResourceDefinition"))
+		g.Expect(crd.GetName()).To(Equal("membundles." + v1alpha1.GroupVersion.Group))
+
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		g.Expect(group).To(Equal(v1alpha1.GroupVersion.Group))
+
+		kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+		g.Expect(kind).To(Equal("MemBundle"))
+
+		versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		g.Expect(versions).To(HaveLen(1))
+		g.Expect(versions[0]).To(HaveKeyWithValue("name", v1alpha1.GroupVersion.Version))
+	})
+}
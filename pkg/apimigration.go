@@ -0,0 +1,469 @@
+package mem
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// apiMigrations are the field migrations of the deprecated API versions
+// WithAPIMigration converts to their replacements. Kinds whose schema did not
+// change have no migrations.
+var apiMigrations = map[schema.GroupVersionKind][]GVKMigrateFunc{
+	{Group: "extensions", Version: "v1beta1", Kind: "Deployment"}:    {migrateWorkload},
+	{Group: "extensions", Version: "v1beta1", Kind: "DaemonSet"}:     {migrateWorkload, pinUpdateStrategy("OnDelete")},
+	{Group: "extensions", Version: "v1beta1", Kind: "ReplicaSet"}:    {migrateWorkload},
+	{Group: "extensions", Version: "v1beta1", Kind: "NetworkPolicy"}: nil,
+	{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}:       {migrateIngress},
+
+	{Group: "apps", Version: "v1beta1", Kind: "Deployment"}:  {migrateWorkload},
+	{Group: "apps", Version: "v1beta1", Kind: "StatefulSet"}: {migrateWorkload, pinUpdateStrategy("OnDelete")},
+	{Group: "apps", Version: "v1beta2", Kind: "Deployment"}:  {migrateWorkload},
+	{Group: "apps", Version: "v1beta2", Kind: "DaemonSet"}:   {migrateWorkload},
+	{Group: "apps", Version: "v1beta2", Kind: "ReplicaSet"}:  {migrateWorkload},
+	{Group: "apps", Version: "v1beta2", Kind: "StatefulSet"}: {migrateWorkload},
+
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}:      {migrateIngress},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "IngressClass"}: nil,
+
+	{Group: "batch", Version: "v1beta1", Kind: "CronJob"}: nil,
+
+	{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}: {migratePodDisruptionBudget},
+
+	{Group: "autoscaling", Version: "v2beta1", Kind: "HorizontalPodAutoscaler"}: {migrateHorizontalPodAutoscaler},
+	{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"}: nil,
+
+	{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}: {migrateCRD},
+
+	{
+		Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "MutatingWebhookConfiguration",
+	}: {migrateWebhooks},
+	{
+		Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration",
+	}: {migrateWebhooks},
+
+	{Group: "apiregistration.k8s.io", Version: "v1beta1", Kind: "APIService"}: nil,
+
+	{Group: "coordination.k8s.io", Version: "v1beta1", Kind: "Lease"}: nil,
+
+	{Group: "node.k8s.io", Version: "v1beta1", Kind: "RuntimeClass"}: nil,
+
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRole"}:        nil,
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRoleBinding"}: nil,
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "Role"}:               nil,
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "RoleBinding"}:        nil,
+
+	{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass"}: nil,
+
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIDriver"}:          nil,
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSINode"}:            nil,
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIStorageCapacity"}: nil,
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "StorageClass"}:       nil,
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "VolumeAttachment"}:   nil,
+}
+
+// MigratableAPIs returns the deprecated API versions WithAPIMigration
+// converts, with the versions they are converted to.
+func MigratableAPIs() map[schema.GroupVersionKind]schema.GroupVersionKind {
+	out := make(map[schema.GroupVersionKind]schema.GroupVersionKind, len(apiMigrations))
+	for from := range apiMigrations {
+		if deprecation, ok := DeprecatedAPI(from); ok {
+			out[from] = deprecation.Replacement
+		}
+	}
+
+	return out
+}
+
+// migrateAPI converts obj, rendered as from, to the replacement of from if
+// it is a deprecated API version WithAPIMigration knows.
+func (r *Renderer) migrateAPI(obj *unstructured.Unstructured, from schema.GroupVersionKind, trace *renderTrace) error {
+	migrate, ok := apiMigrations[from]
+	if !ok {
+		return nil
+	}
+
+	deprecation, ok := DeprecatedAPI(from)
+	if !ok {
+		return nil
+	}
+
+	return r.applyRewrite(obj, from, deprecation.Replacement, migrate, trace)
+}
+
+// migrateWorkload adapts workloads to apps/v1, which requires the selector
+// older versions defaulted to the labels of the pod template, and drops the
+// fields apps/v1 no longer has.
+func migrateWorkload(_ schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+	unstructured.RemoveNestedField(obj.Object, "spec", "rollbackTo")
+	unstructured.RemoveNestedField(obj.Object, "spec", "templateGeneration")
+
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "selector"); found {
+		return nil
+	}
+
+	labels, _, err := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return err
+	}
+
+	if len(labels) == 0 {
+		return errors.New("spec.selector is required and the pod template has no labels to derive it from")
+	}
+
+	return unstructured.SetNestedStringMap(obj.Object, labels, "spec", "selector", "matchLabels")
+}
+
+// pinUpdateStrategy sets the update strategy older versions defaulted to,
+// where apps/v1 defaults to RollingUpdate, so migrated workloads keep
+// rolling out as before.
+func pinUpdateStrategy(strategy string) GVKMigrateFunc {
+	return func(_ schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+		if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "updateStrategy", "type"); found {
+			return nil
+		}
+
+		return unstructured.SetNestedField(obj.Object, strategy, "spec", "updateStrategy", "type")
+	}
+}
+
+// migrateIngress adapts Ingresses to networking.k8s.io/v1: the default
+// backend moves to spec.defaultBackend, service backends name their service
+// and port in a service field, and paths require a pathType, which defaults
+// to the ImplementationSpecific matching of older versions.
+func migrateIngress(_ schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+	spec, ok := obj.Object["spec"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if backend, found := spec["backend"]; found {
+		delete(spec, "backend")
+		spec["defaultBackend"] = backend
+	}
+
+	if err := migrateIngressBackend(spec["defaultBackend"], "spec.defaultBackend"); err != nil {
+		return err
+	}
+
+	rules, _ := spec["rules"].([]any)
+	for i, rule := range rules {
+		ruleMap, _ := rule.(map[string]any)
+		http, _ := ruleMap["http"].(map[string]any)
+		paths, _ := http["paths"].([]any)
+
+		for j, path := range paths {
+			pathMap, ok := path.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			if _, found := pathMap["pathType"]; !found {
+				pathMap["pathType"] = "ImplementationSpecific"
+			}
+
+			field := fmt.Sprintf("spec.rules[%d].http.paths[%d].backend", i, j)
+			if err := migrateIngressBackend(pathMap["backend"], field); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateIngressBackend converts a serviceName and servicePort backend into
+// a service backend. Resource backends are kept.
+func migrateIngressBackend(backend any, field string) error {
+	backendMap, ok := backend.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	name, hasName := backendMap["serviceName"]
+	port, hasPort := backendMap["servicePort"]
+
+	if !hasName && !hasPort {
+		return nil
+	}
+
+	delete(backendMap, "serviceName")
+	delete(backendMap, "servicePort")
+
+	servicePort := map[string]any{}
+
+	switch p := port.(type) {
+	case string:
+		servicePort["name"] = p
+	default:
+		number, isNumber := numberValue(p)
+		if !isNumber {
+			return fmt.Errorf("%s.servicePort must be a number or a name", field)
+		}
+
+		servicePort["number"] = int64(number)
+	}
+
+	backendMap["service"] = map[string]any{"name": name, "port": servicePort}
+
+	return nil
+}
+
+// migratePodDisruptionBudget refuses PodDisruptionBudgets with an empty
+// selector, which selects no pods in policy/v1beta1 but every pod of the
+// namespace in policy/v1.
+func migratePodDisruptionBudget(_ schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+	selector, found, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if found && len(selector) == 0 {
+		return errors.New("an empty spec.selector selects no pods in policy/v1beta1 but all pods in policy/v1")
+	}
+
+	return nil
+}
+
+// migrateHorizontalPodAutoscaler converts the metrics of autoscaling/v2beta1,
+// which name their targets in flat fields, to the metric and target
+// structures of autoscaling/v2.
+func migrateHorizontalPodAutoscaler(_ schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+	metrics, _, err := unstructured.NestedSlice(obj.Object, "spec", "metrics")
+	if err != nil || len(metrics) == 0 {
+		return err
+	}
+
+	for i, metric := range metrics {
+		metricMap, ok := metric.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		metricType, _ := metricMap["type"].(string)
+		field := metricSourceFields[metricType]
+
+		source, ok := metricMap[field].(map[string]any)
+		if !ok {
+			return fmt.Errorf("spec.metrics[%d] has no source for type %q", i, metricType)
+		}
+
+		metricMap[field] = migrateMetricSource(metricType, source)
+	}
+
+	return unstructured.SetNestedSlice(obj.Object, metrics, "spec", "metrics")
+}
+
+// metricSourceFields are the fields holding the source of each metric type.
+var metricSourceFields = map[string]string{
+	"Resource":          "resource",
+	"ContainerResource": "containerResource",
+	"Pods":              "pods",
+	"Object":            "object",
+	"External":          "external",
+}
+
+// migrateMetricSource converts one autoscaling/v2beta1 metric source.
+func migrateMetricSource(metricType string, source map[string]any) map[string]any {
+	out := make(map[string]any)
+	target := make(map[string]any)
+
+	switch {
+	case source["targetAverageUtilization"] != nil:
+		target["type"] = "Utilization"
+		target["averageUtilization"] = source["targetAverageUtilization"]
+	case source["targetAverageValue"] != nil:
+		target["type"] = "AverageValue"
+		target["averageValue"] = source["targetAverageValue"]
+	case source["averageValue"] != nil:
+		target["type"] = "AverageValue"
+		target["averageValue"] = source["averageValue"]
+	case source["targetValue"] != nil:
+		target["type"] = "Value"
+		target["value"] = source["targetValue"]
+	}
+
+	out["target"] = target
+
+	switch metricType {
+	case "Resource", "ContainerResource":
+		out["name"] = source["name"]
+		if container, found := source["container"]; found {
+			out["container"] = container
+		}
+	default:
+		identifier := map[string]any{"name": source["metricName"]}
+
+		selector := source["selector"]
+		if metricType == "External" {
+			selector = source["metricSelector"]
+		}
+
+		if selector != nil {
+			identifier["selector"] = selector
+		}
+
+		out["metric"] = identifier
+
+		if metricType == "Object" {
+			out["describedObject"] = source["target"]
+		}
+	}
+
+	return out
+}
+
+// migrateCRD adapts CustomResourceDefinitions to apiextensions.k8s.io/v1,
+// which defines schemas, subresources, and printer columns per version,
+// requires a schema for every version, and nests the conversion webhook.
+// Pruning stays disabled, as by the preserveUnknownFields default of
+// v1beta1, unless the CRD opted into it.
+func migrateCRD(_ schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+	spec, ok := obj.Object["spec"].(map[string]any)
+	if !ok {
+		return errors.New("spec is required")
+	}
+
+	versions, _ := spec["versions"].([]any)
+	if len(versions) == 0 {
+		name, _ := spec["version"].(string)
+		if name == "" {
+			return errors.New("spec.version or spec.versions is required")
+		}
+
+		versions = []any{map[string]any{"name": name, "served": true, "storage": true}}
+	}
+
+	schemaRoot, _, _ := unstructured.NestedMap(spec, "validation", "openAPIV3Schema")
+	subresources := spec["subresources"]
+	columns := spec["additionalPrinterColumns"]
+	preserveUnknown, found := spec["preserveUnknownFields"].(bool)
+	preserveUnknown = preserveUnknown || !found
+
+	moved := []string{"version", "validation", "subresources", "additionalPrinterColumns", "preserveUnknownFields"}
+	for _, field := range moved {
+		delete(spec, field)
+	}
+
+	for i, v := range versions {
+		version, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("spec.versions[%d] must be an object", i)
+		}
+
+		migrateCRDVersion(version, schemaRoot, subresources, columns, preserveUnknown)
+	}
+
+	spec["versions"] = versions
+	migrateCRDConversion(spec)
+
+	return nil
+}
+
+// migrateCRDVersion moves the CRD-wide schema, subresources, and printer
+// columns into version unless it defines its own.
+func migrateCRDVersion(
+	version map[string]any,
+	schemaRoot map[string]any,
+	subresources any,
+	columns any,
+	preserveUnknown bool,
+) {
+	if _, found := version["schema"]; !found {
+		root := map[string]any{"type": "object"}
+		if schemaRoot != nil {
+			root = runtime.DeepCopyJSON(schemaRoot)
+		}
+
+		version["schema"] = map[string]any{"openAPIV3Schema": root}
+	}
+
+	if root, ok := version["schema"].(map[string]any)["openAPIV3Schema"].(map[string]any); ok && preserveUnknown {
+		root["x-kubernetes-preserve-unknown-fields"] = true
+	}
+
+	if _, found := version["subresources"]; !found && subresources != nil {
+		version["subresources"] = runtime.DeepCopyJSONValue(subresources)
+	}
+
+	if _, found := version["additionalPrinterColumns"]; !found && columns != nil {
+		version["additionalPrinterColumns"] = runtime.DeepCopyJSONValue(columns)
+	}
+
+	printerColumns, _ := version["additionalPrinterColumns"].([]any)
+	for _, column := range printerColumns {
+		if columnMap, ok := column.(map[string]any); ok {
+			if path, found := columnMap["JSONPath"]; found {
+				delete(columnMap, "JSONPath")
+				columnMap["jsonPath"] = path
+			}
+		}
+	}
+}
+
+// migrateCRDConversion nests the webhook settings of the conversion under
+// spec.conversion.webhook, keeping the review version of v1beta1.
+func migrateCRDConversion(spec map[string]any) {
+	conversion, ok := spec["conversion"].(map[string]any)
+	if !ok || conversion["strategy"] != "Webhook" {
+		return
+	}
+
+	webhook := map[string]any{"conversionReviewVersions": []any{"v1beta1"}}
+
+	if clientConfig, found := conversion["webhookClientConfig"]; found {
+		webhook["clientConfig"] = clientConfig
+	}
+
+	if reviewVersions, found := conversion["conversionReviewVersions"]; found {
+		webhook["conversionReviewVersions"] = reviewVersions
+	}
+
+	delete(conversion, "webhookClientConfig")
+	delete(conversion, "conversionReviewVersions")
+	conversion["webhook"] = webhook
+}
+
+// migrateWebhooks adapts webhook configurations to
+// admissionregistration.k8s.io/v1, pinning the v1beta1 defaults that v1
+// changed, and refuses webhooks whose side effects v1 no longer accepts.
+func migrateWebhooks(_ schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+	webhooks, _, err := unstructured.NestedSlice(obj.Object, "webhooks")
+	if err != nil {
+		return err
+	}
+
+	defaults := map[string]any{
+		"failurePolicy":           "Ignore",
+		"matchPolicy":             "Exact",
+		"timeoutSeconds":          int64(30),
+		"admissionReviewVersions": []any{"v1beta1"},
+	}
+
+	for i, webhook := range webhooks {
+		webhookMap, ok := webhook.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch sideEffects, _ := webhookMap["sideEffects"].(string); sideEffects {
+		case "None", "NoneOnDryRun":
+		default:
+			if sideEffects == "" {
+				sideEffects = "Unknown"
+			}
+
+			return fmt.Errorf(
+				"webhooks[%d]: sideEffects %s is not allowed in admissionregistration.k8s.io/v1, "+
+					"use None or NoneOnDryRun",
+				i, sideEffects)
+		}
+
+		for field, value := range defaults {
+			if _, found := webhookMap[field]; !found {
+				webhookMap[field] = runtime.DeepCopyJSONValue(value)
+			}
+		}
+	}
+
+	return unstructured.SetNestedSlice(obj.Object, webhooks, "webhooks")
+}
@@ -0,0 +1,304 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func migrate(t *testing.T, g Gomega, manifest string, opts ...mem.RendererOption) (*mem.Result, error) {
+	t.Helper()
+
+	data, err := yaml.YAMLToJSON([]byte(manifest))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var obj unstructured.Unstructured
+	g.Expect(obj.UnmarshalJSON(data)).To(Succeed())
+
+	renderer, err := mem.New(
+		[]mem.Source{{Objects: []unstructured.Unstructured{obj}}},
+		append([]mem.RendererOption{mem.WithAPIMigration(true)}, opts...)...,
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return renderer.ProcessResult(t.Context(), nil)
+}
+
+func TestAPIMigration(t *testing.T) {
+
+	t.Run("should migrate Ingress backends and paths", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := migrate(t, g, `
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  namespace: app
+spec:
+  backend:
+    serviceName: fallback
+    servicePort: http
+  rules:
+  - host: example.com
+    http:
+      paths:
+      - path: /
+        backend:
+          serviceName: web
+          servicePort: 80
+`)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		obj := result.View().DeepCopy()[0]
+		g.Expect(obj.GetAPIVersion()).To(Equal("networking.k8s.io/v1"))
+		g.Expect(obj.Object["spec"]).To(Equal(map[string]any{
+			"defaultBackend": map[string]any{
+				"service": map[string]any{"name": "fallback", "port": map[string]any{"name": "http"}},
+			},
+			"rules": []any{map[string]any{
+				"host": "example.com",
+				"http": map[string]any{"paths": []any{map[string]any{
+					"path":     "/",
+					"pathType": "ImplementationSpecific",
+					"backend": map[string]any{
+						"service": map[string]any{"name": "web", "port": map[string]any{"number": int64(80)}},
+					},
+				}}},
+			}},
+		}))
+		g.Expect(result.Migrations()).To(Equal([]mem.Migration{{
+			From:      schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"},
+			To:        schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+			Namespace: "app",
+			Name:      "web",
+		}}))
+	})
+
+	t.Run("should derive workload selectors and pin update strategies", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := migrate(t, g, `
+apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: agent
+  namespace: app
+spec:
+  templateGeneration: 3
+  template:
+    metadata:
+      labels:
+        app: agent
+`)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		obj := result.View().DeepCopy()[0]
+		g.Expect(obj.GetAPIVersion()).To(Equal("apps/v1"))
+		spec := obj.Object["spec"].(map[string]any)
+		g.Expect(spec["selector"]).To(Equal(map[string]any{"matchLabels": map[string]any{"app": "agent"}}))
+		g.Expect(spec["updateStrategy"]).To(Equal(map[string]any{"type": "OnDelete"}))
+		g.Expect(spec).ToNot(HaveKey("templateGeneration"))
+	})
+
+	t.Run("should convert autoscaling/v2beta1 metrics", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := migrate(t, g, `
+apiVersion: autoscaling/v2beta1
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+  namespace: app
+spec:
+  metrics:
+  - type: Resource
+    resource:
+      name: cpu
+      targetAverageUtilization: 80
+  - type: External
+    external:
+      metricName: queue_depth
+      metricSelector:
+        matchLabels:
+          queue: jobs
+      targetAverageValue: "30"
+`)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		obj := result.View().DeepCopy()[0]
+		g.Expect(obj.GetAPIVersion()).To(Equal("autoscaling/v2"))
+		g.Expect(obj.Object["spec"].(map[string]any)["metrics"]).To(Equal([]any{
+			map[string]any{"type": "Resource", "resource": map[string]any{
+				"name":   "cpu",
+				"target": map[string]any{"type": "Utilization", "averageUtilization": int64(80)},
+			}},
+			map[string]any{"type": "External", "external": map[string]any{
+				"metric": map[string]any{
+					"name":     "queue_depth",
+					"selector": map[string]any{"matchLabels": map[string]any{"queue": "jobs"}},
+				},
+				"target": map[string]any{"type": "AverageValue", "averageValue": "30"},
+			}},
+		}))
+	})
+
+	t.Run("should move CRD schemas and printer columns into versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := migrate(t, g, `
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  version: v1
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+  - name: Size
+    type: string
+    JSONPath: .spec.size
+`)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		obj := result.View().DeepCopy()[0]
+		g.Expect(obj.GetAPIVersion()).To(Equal("apiextensions.k8s.io/v1"))
+
+		spec := obj.Object["spec"].(map[string]any)
+		g.Expect(spec).ToNot(HaveKey("version"))
+		g.Expect(spec).ToNot(HaveKey("validation"))
+		g.Expect(spec["versions"]).To(Equal([]any{map[string]any{
+			"name":    "v1",
+			"served":  true,
+			"storage": true,
+			"schema": map[string]any{"openAPIV3Schema": map[string]any{
+				"type":                                 "object",
+				"properties":                           map[string]any{"spec": map[string]any{"type": "object"}},
+				"x-kubernetes-preserve-unknown-fields": true,
+			}},
+			"subresources": map[string]any{"status": map[string]any{}},
+			"additionalPrinterColumns": []any{map[string]any{
+				"name": "Size", "type": "string", "jsonPath": ".spec.size",
+			}},
+		}}))
+	})
+
+	t.Run("should pin webhook defaults and refuse unknown side effects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := migrate(t, g, `
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: policy
+webhooks:
+- name: policy.example.com
+  sideEffects: None
+`)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		webhook := result.View().DeepCopy()[0].Object["webhooks"].([]any)[0]
+		g.Expect(webhook).To(HaveKeyWithValue("failurePolicy", "Ignore"))
+		g.Expect(webhook).To(HaveKeyWithValue("matchPolicy", "Exact"))
+		g.Expect(webhook).To(HaveKeyWithValue("timeoutSeconds", int64(30)))
+		g.Expect(webhook).To(HaveKeyWithValue("admissionReviewVersions", []any{"v1beta1"}))
+
+		_, err = migrate(t, g, `
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: injector
+webhooks:
+- name: injector.example.com
+`)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("sideEffects Unknown is not allowed"))
+	})
+
+	t.Run("should refuse PodDisruptionBudgets whose meaning would change", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := migrate(t, g, `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  namespace: app
+spec:
+  minAvailable: 1
+  selector: {}
+`)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("empty spec.selector"))
+	})
+
+	t.Run("should let GVK rewrites take precedence", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cronJob := schema.GroupVersionKind{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+		custom := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Schedule"}
+
+		result, err := migrate(t, g, `
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: backup
+  namespace: app
+`, mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{cronJob: custom}))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().DeepCopy()[0].GroupVersionKind()).To(Equal(custom))
+	})
+
+	t.Run("should clear deprecation findings of migrated objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := migrate(t, g, `
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: backup
+  namespace: app
+`, mem.WithTargetKubeVersion("1.29"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().DeepCopy()[0].GetAPIVersion()).To(Equal("batch/v1"))
+		g.Expect(result.Warnings()).To(BeEmpty())
+	})
+}
+
+func TestMigratableAPIs(t *testing.T) {
+
+	t.Run("should map deprecated versions to current ones", func(t *testing.T) {
+		g := NewWithT(t)
+
+		apis := mem.MigratableAPIs()
+		g.Expect(apis).To(HaveKeyWithValue(
+			schema.GroupVersionKind{Group: "apps", Version: "v1beta2", Kind: "StatefulSet"},
+			schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+		))
+		g.Expect(apis).ToNot(HaveKey(
+			schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy"}))
+
+		for from, to := range apis {
+			_, deprecated := mem.DeprecatedAPI(to)
+			g.Expect(deprecated).To(BeFalse(), "%s migrates to deprecated %s", from, to)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	goruntime "runtime"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Object is a typed Kubernetes object. The client.Object of controller-runtime
+// and every k8s.io/api type satisfy it.
+type Object interface {
+	metav1.Object
+	runtime.Object
+}
+
+// BuilderFunc computes one desired object from render-time values, as an
+// operator's reconciler does.
+type BuilderFunc[T Object] func(ctx context.Context, values types.Values) (T, error)
+
+// SourceOf returns a Source whose objects are built by builders on every
+// Process call, in order, and converted with ToUnstructured. It formalizes the
+// pattern of an operator computing its desired objects in Go.
+//
+// Each builder must return an object with its TypeMeta set, as there is no
+// scheme to infer apiVersion and kind from; a nil object is an error. The
+// function name of the builder is recorded as the Builder of the object's
+// Provenance, and errors report the builder's index and name. Builders may be
+// called concurrently by renders sharing the renderer.
+//
+// Builders of different types go into separate sources, or share one as
+// SourceOf[Object].
+func SourceOf[T Object](builders ...BuilderFunc[T]) Source {
+	names := make([]string, len(builders))
+	for i, build := range builders {
+		names[i] = builderName(build)
+	}
+
+	return Source{
+		ObjectsFn: func(ctx context.Context, values types.Values) ([]unstructured.Unstructured, error) {
+			objects := make([]unstructured.Unstructured, 0, len(builders))
+
+			for i, build := range builders {
+				obj, err := runBuilder(ctx, values, build)
+				if err != nil {
+					return nil, fmt.Errorf("builder %d (%s): %w", i, names[i], err)
+				}
+
+				objects = append(objects, obj)
+			}
+
+			return objects, nil
+		},
+		builders: names,
+	}
+}
+
+// runBuilder builds a single object and converts it.
+func runBuilder[T Object](
+	ctx context.Context,
+	values types.Values,
+	build BuilderFunc[T],
+) (unstructured.Unstructured, error) {
+	if build == nil {
+		return unstructured.Unstructured{}, fmt.Errorf("%w: builder is nil", ErrObjectNil)
+	}
+
+	obj, err := build(ctx, values)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+
+	if value := reflect.ValueOf(obj); !value.IsValid() || (value.Kind() == reflect.Pointer && value.IsNil()) {
+		return unstructured.Unstructured{}, ErrObjectNil
+	}
+
+	u, err := ToUnstructured(obj)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+
+	if err := checkIdentity(u); err != nil {
+		return unstructured.Unstructured{}, fmt.Errorf("%T: %w", obj, err)
+	}
+
+	return u, nil
+}
+
+// builderName returns the function name of build, or "" if it is nil.
+func builderName[T Object](build BuilderFunc[T]) string {
+	if build == nil {
+		return ""
+	}
+
+	fn := goruntime.FuncForPC(reflect.ValueOf(build).Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	return fn.Name()
+}
+
+// builderOf returns the builder of the i-th object of the source, and whether
+// it has one.
+func (s Source) builderOf(i int) (string, bool) {
+	if i < 0 || i >= len(s.builders) || s.builders[i] == "" {
+		return "", false
+	}
+
+	return s.builders[i], true
+}
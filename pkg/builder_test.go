@@ -0,0 +1,124 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func buildConfig(_ context.Context, values pkgtypes.Values) (*corev1.ConfigMap, error) {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "app"},
+		Data:       map[string]string{"env": values["env"].(string)},
+	}, nil
+}
+
+func buildSettings(_ context.Context, _ pkgtypes.Values) (*corev1.ConfigMap, error) {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "app"},
+	}, nil
+}
+
+func TestSourceOf(t *testing.T) {
+
+	t.Run("should build objects from values on every render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.SourceOf(buildConfig, buildSettings)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for _, env := range []string{"dev", "prod"} {
+			objects, err := renderer.Process(t.Context(), pkgtypes.Values{"env": env})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(names(objects)).To(Equal([]string{"ConfigMap/config", "ConfigMap/settings"}))
+			g.Expect(objects[0].Object).To(HaveKeyWithValue("data", HaveKeyWithValue("env", env)))
+		}
+	})
+
+	t.Run("should record the builder of each object", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source := mem.SourceOf(buildConfig, buildSettings)
+		source.Name = "desired"
+		source.Objects = []unstructured.Unstructured{mem.MustUnstructured(configMapYAML)}
+
+		renderer, err := mem.New([]mem.Source{source})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), pkgtypes.Values{"env": "dev"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(Equal(3))
+
+		static, ok := result.Provenance(0)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(static.Builder).To(BeEmpty())
+
+		config, ok := result.Provenance(1)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(config.Name).To(Equal("desired"))
+		g.Expect(config.Builder).To(HaveSuffix(".buildConfig"))
+
+		settings, ok := result.Provenance(2)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(settings.Builder).To(HaveSuffix(".buildSettings"))
+	})
+
+	t.Run("should report the failing builder", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errBuild := errors.New("dependency not ready")
+
+		renderer, err := mem.New([]mem.Source{mem.SourceOf(
+			buildSettings,
+			func(context.Context, pkgtypes.Values) (*corev1.ConfigMap, error) {
+				return nil, errBuild
+			},
+		)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errBuild))
+		g.Expect(err).To(MatchError(ContainSubstring("builder 1 (")))
+	})
+
+	t.Run("should reject nil objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.SourceOf(
+			func(context.Context, pkgtypes.Values) (*corev1.ConfigMap, error) {
+				return nil, nil
+			},
+		)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrObjectNil))
+	})
+
+	t.Run("should reject objects without TypeMeta", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.SourceOf(
+			func(context.Context, pkgtypes.Values) (*corev1.ConfigMap, error) {
+				return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config"}}, nil
+			},
+		)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrMissingIdentity))
+		g.Expect(err).To(MatchError(ContainSubstring("*v1.ConfigMap")))
+	})
+}
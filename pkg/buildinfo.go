@@ -0,0 +1,89 @@
+package mem
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// AnnotationRenderTimestamp is the annotation key for the time an object
+	// was rendered, in UTC as RFC 3339, set by WithProvenanceAnnotations.
+	AnnotationRenderTimestamp = "manifests.k8s-manifests-kit/render.timestamp"
+
+	// AnnotationRendererVersion is the annotation key for the version of the
+	// renderer-mem module that rendered an object, set by
+	// WithProvenanceAnnotations.
+	AnnotationRendererVersion = "manifests.k8s-manifests-kit/render.renderer-version"
+
+	// AnnotationEngineVersion is the annotation key for the version of the
+	// engine module linked with the renderer that rendered an object, set by
+	// WithProvenanceAnnotations.
+	AnnotationEngineVersion = "manifests.k8s-manifests-kit/render.engine-version"
+)
+
+const (
+	rendererModule = "github.com/k8s-manifest-kit/renderer-mem"
+	engineModule   = "github.com/k8s-manifest-kit/engine"
+
+	// unknownVersion is recorded when the binary carries no build info, e.g.
+	// when it was not built in module mode.
+	unknownVersion = "unknown"
+)
+
+// moduleVersions reads the versions of renderer-mem and the engine from the
+// build info of the binary once. A module built from a working tree, such as
+// renderer-mem in its own tests, reports "(devel)"; replaced modules report
+// the version of their replacement.
+var moduleVersions = sync.OnceValues(func() (string, string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return unknownVersion, unknownVersion
+	}
+
+	return moduleVersion(info, rendererModule), moduleVersion(info, engineModule)
+})
+
+// moduleVersion returns the version of the module at path in info.
+func moduleVersion(info *debug.BuildInfo, path string) string {
+	if info.Main.Path == path {
+		return versionOf(&info.Main)
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			return versionOf(dep)
+		}
+	}
+
+	return unknownVersion
+}
+
+func versionOf(module *debug.Module) string {
+	if module.Replace != nil {
+		module = module.Replace
+	}
+
+	if module.Version == "" {
+		return "(devel)"
+	}
+
+	return module.Version
+}
+
+// stampBuildInfo sets the render timestamp, taken from now, and the module
+// version annotations on every object.
+func stampBuildInfo(objects []unstructured.Unstructured, now time.Time) {
+	timestamp := now.UTC().Format(time.RFC3339)
+	rendererVersion, engineVersion := moduleVersions()
+
+	for i := range objects {
+		k8s.SetAnnotation(&objects[i], AnnotationRenderTimestamp, timestamp)
+		k8s.SetAnnotation(&objects[i], AnnotationRendererVersion, rendererVersion)
+		k8s.SetAnnotation(&objects[i], AnnotationEngineVersion, engineVersion)
+	}
+}
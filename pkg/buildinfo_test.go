@@ -0,0 +1,88 @@
+package mem_test
+
+import (
+	"testing"
+	"time"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestProvenanceAnnotations(t *testing.T) {
+
+	t.Run("should stamp the render time and module versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(configMapYAML, multiDocYAML)},
+			mem.WithProvenanceAnnotations(true),
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		before := time.Now().Truncate(time.Second)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+
+		for _, obj := range objects {
+			annotations := obj.GetAnnotations()
+			g.Expect(annotations).To(HaveKeyWithValue(pkgtypes.AnnotationSourceType, "mem"))
+
+			timestamp, err := time.Parse(time.RFC3339, annotations[mem.AnnotationRenderTimestamp])
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(timestamp.Location()).To(Equal(time.UTC))
+			g.Expect(timestamp).To(BeTemporally(">=", before))
+			g.Expect(timestamp).To(BeTemporally("<=", time.Now()))
+
+			// Tests build renderer-mem from its working tree.
+			g.Expect(annotations).To(HaveKeyWithValue(mem.AnnotationRendererVersion, "(devel)"))
+			g.Expect(annotations[mem.AnnotationEngineVersion]).To(HavePrefix("v"))
+		}
+	})
+
+	t.Run("should not stamp by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationRenderTimestamp))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationRendererVersion))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationEngineVersion))
+	})
+
+	t.Run("should not affect content hashes or render digests", func(t *testing.T) {
+		g := NewWithT(t)
+
+		render := func(source mem.Source, opts ...mem.RendererOption) []unstructured.Unstructured {
+			renderer, err := mem.New([]mem.Source{source}, opts...)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			objects, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			return objects
+		}
+
+		plain := render(mem.MustSourceFromYAML(configMapYAML))
+		stamped := render(mem.MustSourceFromYAML(configMapYAML), mem.WithProvenanceAnnotations(true))
+
+		g.Expect(stamped[0].GetAnnotations()[pkgtypes.AnnotationContentHash]).
+			To(Equal(plain[0].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+		g.Expect(mem.VerifyContentHashes(stamped)).To(BeEmpty())
+		g.Expect(mem.Summarize(stamped).RenderedHash).To(Equal(mem.Summarize(plain).RenderedHash))
+
+		reingested := render(mem.Source{Objects: stamped})
+		g.Expect(reingested[0].GetAnnotations()[pkgtypes.AnnotationContentHash]).
+			To(Equal(plain[0].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+	})
+}
@@ -0,0 +1,234 @@
+package mem
+
+import (
+	"fmt"
+
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Bundle models a base set of objects plus ordered overlays with
+// kustomize-like semantics, for simple environment layering without
+// adopting kustomize.
+//
+// Overlays are applied in order. Each overlay sees the output of the previous
+// layer plus its own Objects, so a name prefix set by the second overlay also
+// applies to the objects added by the first one.
+type Bundle struct {
+	// Base holds the objects every overlay builds upon: its Objects and the
+	// objects decoded from its Manifests. Its Name, Labels, Annotations,
+	// Filters, Transformers, PostRenderers, Patches, and Deletions are kept on
+	// the compiled Source, so they apply to the objects of overlays too.
+	Base Source
+
+	// Overlays are applied on top of Base, in order.
+	Overlays []Overlay
+
+	// MergeKeys lets patches merge lists by key instead of replacing them,
+	// for example the containers of a Deployment or the lists of a CRD.
+	MergeKeys MergeKeys
+}
+
+// Overlay is a single layer of a Bundle. Within a layer, operations run in
+// field order: Objects are added, Patches applied, then Namespace, name
+// prefix/suffix, and common labels and annotations are set.
+type Overlay struct {
+	// Objects are additional objects introduced by this layer.
+	Objects []unstructured.Unstructured
+
+	// Patches are applied to matching objects, in order. Merge patches merge
+	// lists registered in MergeKeys by key.
+	Patches []Patch
+
+	// Namespace, if set, replaces the namespace of every object. Unlike
+	// kustomize, cluster-scoped kinds are not detected, so only use it on
+	// layers holding namespaced objects.
+	Namespace string
+
+	// NamePrefix is prepended to the name of every object.
+	NamePrefix string
+
+	// NameSuffix is appended to the name of every object.
+	NameSuffix string
+
+	// CommonLabels are added to every object's metadata. Selectors and pod
+	// templates are not modified.
+	CommonLabels map[string]string
+
+	// CommonAnnotations are added to every object's metadata.
+	CommonAnnotations map[string]string
+}
+
+// Compile resolves all overlays and returns a single Source holding the
+// resulting objects. Inputs are deep copied and never modified. A patch whose
+// target matches no object is an error, as in kustomize.
+//
+// Base.Manifests are decoded with the default YAML options, as overlays need
+// their objects; the objects added by overlays follow those of the base and
+// have no position. Base.Name, Patches, and Deletions are kept on the compiled
+// Source and take effect when it is rendered. Overlays cannot apply to objects
+// that only exist at render time, so a base with an ObjectsFn or a Stream is
+// rejected with ErrInvalidSource.
+func (b Bundle) Compile() (Source, error) {
+	return b.compile(&RendererOptions{})
+}
+
+// compile implements Compile, decoding and validating Base.Manifests with
+// opts.
+func (b Bundle) compile(opts *RendererOptions) (Source, error) {
+	if err := b.Base.Validate(); err != nil {
+		return Source{}, err
+	}
+
+	if b.Base.ObjectsFn != nil {
+		return Source{}, fmt.Errorf("%w: a Bundle base cannot generate objects with an ObjectsFn", ErrInvalidSource)
+	}
+
+	if b.Base.Stream != nil {
+		return Source{}, fmt.Errorf("%w: a Bundle base cannot receive objects from a Stream", ErrInvalidSource)
+	}
+
+	decoded, decodedPositions, err := decodeManifests(b.Base.Manifests, len(b.Base.Objects), opts)
+	if err != nil {
+		return Source{}, fmt.Errorf("invalid bundle base: %w", err)
+	}
+
+	objects := make([]unstructured.Unstructured, 0, len(b.Base.Objects)+len(decoded))
+	for i := range b.Base.Objects {
+		objects = append(objects, *b.Base.Objects[i].DeepCopy())
+	}
+
+	objects = append(objects, decoded...)
+
+	var positions []Position
+	if len(b.Base.Positions) > 0 || len(decodedPositions) > 0 {
+		positions = make([]Position, len(b.Base.Objects), len(objects))
+		copy(positions, b.Base.Positions)
+		positions = append(positions, decodedPositions...)
+	}
+
+	for i, overlay := range b.Overlays {
+		var err error
+
+		objects, err = overlay.apply(objects, b.MergeKeys)
+		if err != nil {
+			return Source{}, fmt.Errorf("invalid overlay at index %d: %w", i, err)
+		}
+	}
+
+	if positions != nil {
+		// Objects added by overlays get the zero Position.
+		positions = append(positions, make([]Position, len(objects)-len(positions))...)
+	}
+
+	deletions := make([]unstructured.Unstructured, 0, len(b.Base.Deletions))
+	for i := range b.Base.Deletions {
+		deletions = append(deletions, *b.Base.Deletions[i].DeepCopy())
+	}
+
+	return Source{
+		Name:             b.Base.Name,
+		Objects:          objects,
+		Positions:        positions,
+		Labels:           b.Base.Labels,
+		Annotations:      b.Base.Annotations,
+		OverrideMetadata: b.Base.OverrideMetadata,
+		Filters:          b.Base.Filters,
+		Transformers:     b.Base.Transformers,
+		PostRenderers:    b.Base.PostRenderers,
+		Patches:          b.Base.Patches,
+		Deletions:        deletions,
+	}, nil
+}
+
+// NewBundle compiles a Bundle and creates a renderer for it. Base.Manifests
+// are decoded with the YAML options of opts.
+func NewBundle(bundle Bundle, opts ...RendererOption) (*Renderer, error) {
+	var rendererOpts RendererOptions
+	for _, opt := range opts {
+		opt.ApplyTo(&rendererOpts)
+	}
+
+	source, err := bundle.compile(&rendererOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile bundle: %w", err)
+	}
+
+	return New([]Source{source}, opts...)
+}
+
+func (o Overlay) apply(objects []unstructured.Unstructured, keys MergeKeys) ([]unstructured.Unstructured, error) {
+	for i := range o.Objects {
+		objects = append(objects, *o.Objects[i].DeepCopy())
+	}
+
+	if _, err := applyPatches(objects, o.Patches, keys); err != nil {
+		return nil, err
+	}
+
+	for j := range objects {
+		obj := &objects[j]
+
+		if o.Namespace != "" {
+			obj.SetNamespace(o.Namespace)
+		}
+
+		if o.NamePrefix != "" || o.NameSuffix != "" {
+			obj.SetName(o.NamePrefix + obj.GetName() + o.NameSuffix)
+		}
+
+		if len(o.CommonLabels) > 0 {
+			k8s.SetLabels(obj, o.CommonLabels)
+		}
+
+		if len(o.CommonAnnotations) > 0 {
+			k8s.SetAnnotations(obj, o.CommonAnnotations)
+		}
+	}
+
+	return objects, nil
+}
+
+// mergePatch applies an RFC 7386 JSON merge patch to target and returns it.
+// Lists registered in keys are merged by key instead of being replaced.
+// Patch values are deep copied so the patch can be reused.
+func mergePatch(target map[string]any, patch map[string]any, keys map[string]string) map[string]any {
+	return mergePatchAt(target, patch, keys, "")
+}
+
+func mergePatchAt(target map[string]any, patch map[string]any, keys map[string]string, path string) map[string]any {
+	if target == nil {
+		target = make(map[string]any, len(patch))
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+
+			continue
+		}
+
+		childPath := joinFieldPath(path, key)
+
+		switch patchValue := value.(type) {
+		case map[string]any:
+			existing, _ := target[key].(map[string]any)
+			target[key] = mergePatchAt(existing, patchValue, keys, childPath)
+		case []any:
+			existing, isList := target[key].([]any)
+			mergeKey := keys[childPath]
+
+			if isList && mergeKey != "" {
+				target[key] = mergeKeyedList(existing, patchValue, mergeKey, keys, childPath)
+			} else {
+				target[key] = runtime.DeepCopyJSONValue(value)
+			}
+		default:
+			target[key] = runtime.DeepCopyJSONValue(value)
+		}
+	}
+
+	return target
+}
@@ -0,0 +1,255 @@
+package mem_test
+
+import (
+	"context"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const bundleDeploymentYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.0
+`
+
+func TestBundle(t *testing.T) {
+
+	t.Run("should apply overlays in order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		base := mem.MustSourceFromYAML(bundleDeploymentYAML, configMapYAML)
+
+		renderer, err := mem.NewBundle(mem.Bundle{
+			Base: base,
+			Overlays: []mem.Overlay{
+				{
+					NamePrefix:   "team-",
+					CommonLabels: map[string]string{"team": "a"},
+					Patches: []mem.Patch{{
+						Target: mem.PatchTarget{Kind: "Deployment", Name: "web"},
+						Merge:  map[string]any{"spec": map[string]any{"replicas": int64(3)}},
+					}},
+				},
+				{
+					Namespace:         "prod",
+					NameSuffix:        "-prod",
+					CommonAnnotations: map[string]string{"env": "prod"},
+					Patches: []mem.Patch{{
+						Target: mem.PatchTarget{Kind: "ConfigMap", Name: "team-test-config"},
+						Merge:  map[string]any{"data": map[string]any{"key": nil, "env": "prod"}},
+					}},
+				},
+			},
+		}, mem.WithContentHash(false))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "team-web-prod"`),
+			jqmatcher.Match(`.metadata.namespace == "prod"`),
+			jqmatcher.Match(`.metadata.labels == {"app": "web", "team": "a"}`),
+			jqmatcher.Match(`.metadata.annotations.env == "prod"`),
+			jqmatcher.Match(`.spec.replicas == 3`),
+			jqmatcher.Match(`.spec.template.spec.containers[0].image == "nginx:1.0"`),
+		))
+		g.Expect(objects[1].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "team-test-config-prod"`),
+			jqmatcher.Match(`.data == {"env": "prod"}`),
+		))
+
+		// The base is left untouched.
+		g.Expect(base.Objects[0].GetName()).To(Equal("web"))
+	})
+
+	t.Run("should apply later layers to objects added by earlier ones", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source, err := mem.Bundle{
+			Base: mem.MustSourceFromYAML(configMapYAML),
+			Overlays: []mem.Overlay{
+				{Objects: []unstructured.Unstructured{mem.MustUnstructured(bundleDeploymentYAML)}},
+				{NamePrefix: "x-"},
+			},
+		}.Compile()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(source.Objects)).To(Equal([]string{"ConfigMap/x-test-config", "Deployment/x-web"}))
+	})
+
+	t.Run("should fail when a patch matches nothing", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.NewBundle(mem.Bundle{
+			Base: mem.MustSourceFromYAML(configMapYAML),
+			Overlays: []mem.Overlay{{
+				Patches: []mem.Patch{{Target: mem.PatchTarget{Kind: "Secret"}, Merge: map[string]any{}}},
+			}},
+		})
+		g.Expect(err).To(MatchError(mem.ErrPatchTargetNotFound))
+		g.Expect(err).To(MatchError(ContainSubstring("overlay at index 0")))
+	})
+
+	t.Run("should not share patch values between objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		patch := mem.Patch{Merge: map[string]any{"spec": map[string]any{"list": []any{"a"}}}}
+
+		source, err := mem.Bundle{
+			Base:     mem.MustSourceFromYAML(configMapYAML, bundleDeploymentYAML),
+			Overlays: []mem.Overlay{{Patches: []mem.Patch{patch}}},
+		}.Compile()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		source.Objects[0].Object["spec"].(map[string]any)["list"].([]any)[0] = "changed"
+		g.Expect(source.Objects[1].Object["spec"]).To(HaveKeyWithValue("list", []any{"a"}))
+		g.Expect(patch.Merge["spec"]).To(HaveKeyWithValue("list", []any{"a"}))
+	})
+
+	t.Run("should decode base manifests", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.NewBundle(mem.Bundle{
+			Base: mem.Source{
+				Objects:   []unstructured.Unstructured{mem.MustUnstructured(configMapYAML)},
+				Manifests: []string{bundleDeploymentYAML},
+			},
+			Overlays: []mem.Overlay{{NamePrefix: "x-"}},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/x-test-config", "Deployment/x-web"}))
+	})
+
+	t.Run("should fail on invalid base manifests", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Bundle{Base: mem.Source{Manifests: []string{"metadata: [\n"}}}.Compile()
+		g.Expect(err).To(MatchError(ContainSubstring("invalid bundle base: invalid manifest at index 0")))
+	})
+
+	t.Run("should keep the base name", func(t *testing.T) {
+		g := NewWithT(t)
+
+		base := mem.MustSourceFromYAML(configMapYAML)
+		base.Name = "base"
+
+		source, err := mem.Bundle{Base: base}.Compile()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Name).To(Equal("base"))
+	})
+
+	t.Run("should keep base positions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		base := mem.MustSourceFromYAML(configMapYAML)
+		base.Manifests = []string{bundleDeploymentYAML}
+
+		source, err := mem.Bundle{
+			Base: base,
+			Overlays: []mem.Overlay{
+				{Objects: []unstructured.Unstructured{composeObject("v1", "Secret", "", "extra")}},
+			},
+		}.Compile()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(source.Objects)).To(Equal([]string{"ConfigMap/test-config", "Deployment/web", "Secret/extra"}))
+		g.Expect(source.Positions).To(Equal([]mem.Position{
+			base.Positions[0],
+			{Document: 0, Line: 1},
+			{},
+		}))
+	})
+
+	t.Run("should apply base patches at render time", func(t *testing.T) {
+		g := NewWithT(t)
+
+		base := mem.MustSourceFromYAML(configMapYAML)
+		base.Patches = []mem.Patch{{
+			Target: mem.PatchTarget{Kind: "Deployment"},
+			Merge:  map[string]any{"spec": map[string]any{"replicas": int64(2)}},
+		}}
+
+		renderer, err := mem.NewBundle(mem.Bundle{
+			Base:     base,
+			Overlays: []mem.Overlay{{Objects: []unstructured.Unstructured{mem.MustUnstructured(bundleDeploymentYAML)}}},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.spec.replicas == 2`))
+	})
+
+	t.Run("should keep base deletions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		base := mem.MustSourceFromYAML(configMapYAML)
+		base.Deletions = []unstructured.Unstructured{composeObject("v1", "Secret", "", "retired")}
+
+		renderer, err := mem.NewBundle(mem.Bundle{Base: base})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(result.Deletions())).To(Equal([]string{"Secret/retired"}))
+	})
+
+	t.Run("should reject a generated base", func(t *testing.T) {
+		g := NewWithT(t)
+
+		generate := func(context.Context, pkgtypes.Values) ([]unstructured.Unstructured, error) {
+			return nil, nil
+		}
+
+		_, err := mem.Bundle{Base: mem.Source{ObjectsFn: generate}}.Compile()
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+
+		_, err = mem.Bundle{Base: mem.Source{
+			ObjectsFn:   generate,
+			Fingerprint: func(context.Context, pkgtypes.Values) (string, error) { return "", nil },
+		}}.Compile()
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+	})
+
+	t.Run("should reject a fingerprint without a generator", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Bundle{Base: mem.Source{
+			Fingerprint: func(context.Context, pkgtypes.Values) (string, error) { return "", nil },
+		}}.Compile()
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+	})
+
+	t.Run("should reject a streamed base", func(t *testing.T) {
+		g := NewWithT(t)
+
+		stream := mem.NewStream(func(context.Context, bool) ([]unstructured.Unstructured, bool, error) {
+			return nil, true, nil
+		}, mem.StreamWaitForClose)
+
+		_, err := mem.NewBundle(mem.Bundle{Base: mem.Source{Stream: stream}})
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+	})
+}
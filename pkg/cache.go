@@ -0,0 +1,245 @@
+package mem
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"slices"
+	"sync"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+)
+
+// FingerprintFunc reports a value that changes whenever the output of the
+// ObjectsFn of a Source would change, without running it.
+type FingerprintFunc = func(ctx context.Context, values types.Values) (string, error)
+
+// renderCache holds the latest cacheable render of a renderer. Its epoch
+// advances on Invalidate, so renders that started before cannot store their
+// result afterwards.
+type renderCache struct {
+	mu    sync.Mutex
+	epoch uint64
+	entry *cacheEntry
+}
+
+// cacheEntry is a cached render: its key, the sources it rendered, and
+// whether its result tracks provenance.
+type cacheEntry struct {
+	key        string
+	inputs     []*sourceHolder
+	provenance bool
+	result     *Result
+}
+
+// newRenderCache returns a cache if opts enable WithRenderCache.
+func newRenderCache(opts RendererOptions) *renderCache {
+	if !opts.RenderCache {
+		return nil
+	}
+
+	return &renderCache{}
+}
+
+// lookup returns the cached result for key and inputs, or nil, and the epoch
+// a result rendered now must be stored with. The holders of inputs are
+// compared by identity: the entry keeps them alive, so a source added or
+// replaced since never matches.
+func (c *renderCache) lookup(key string, inputs []*sourceHolder, withProvenance bool) (*Result, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.entry
+	if entry == nil || entry.key != key || !slices.Equal(entry.inputs, inputs) ||
+		(withProvenance && !entry.provenance) {
+		return nil, c.epoch
+	}
+
+	return entry.result, c.epoch
+}
+
+// store caches entry unless the cache was invalidated since epoch.
+func (c *renderCache) store(epoch uint64, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.epoch == epoch {
+		c.entry = entry
+	}
+}
+
+func (c *renderCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	c.entry = nil
+}
+
+// Invalidate drops the render cached by WithRenderCache, so the next render
+// runs the whole pipeline again. The cache sees changes to values, to the
+// content of sources, and to the source set; call Invalidate when something
+// else a render depends on changed, such as the state read by a callback or
+// a PartialObjectResolver. It does nothing on renderers without a cache.
+func (r *Renderer) Invalidate() {
+	if r.cache != nil {
+		r.cache.invalidate()
+	}
+}
+
+// cacheKey digests values and the content of the sources of r, or returns
+// false if the render cannot be cached: values or content that are not JSON,
+// generator sources without a Fingerprint, and stream sources.
+func (r *Renderer) cacheKey(ctx context.Context, values types.Values, inputs []*sourceHolder) (string, bool, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", false, nil
+	}
+
+	digest := sha256.New()
+	digest.Write(data)
+
+	ok, err := r.digestSources(ctx, digest, values, inputs)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	return hex.EncodeToString(digest.Sum(nil)), true, nil
+}
+
+// digestSources writes the content of inputs, or of the parts of a merged
+// renderer, to digest.
+func (r *Renderer) digestSources(
+	ctx context.Context,
+	digest hash.Hash,
+	values types.Values,
+	inputs []*sourceHolder,
+) (bool, error) {
+	if r.merged != nil {
+		for i, part := range r.merged.parts {
+			ok, err := part.digestSources(ctx, digest, values, part.sources())
+			if err != nil {
+				return false, fmt.Errorf("merged renderer %d: %w", i, err)
+			}
+
+			if !ok {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	}
+
+	encoder := json.NewEncoder(digest)
+
+	for i, holder := range inputs {
+		ok, err := holder.digest(ctx, values, encoder)
+		if err != nil {
+			return false, fmt.Errorf("source %d: %w", i, err)
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// digest encodes the content of the source with encoder, using its
+// fingerprint in place of the objects its ObjectsFn would generate.
+func (h *sourceHolder) digest(ctx context.Context, values types.Values, encoder *json.Encoder) (bool, error) {
+	if h.Stream != nil || (h.ObjectsFn != nil && h.Fingerprint == nil) {
+		return false, nil
+	}
+
+	fingerprint := ""
+	if h.Fingerprint != nil {
+		var err error
+
+		fingerprint, err = h.Fingerprint(ctx, values)
+		if err != nil {
+			return false, fmt.Errorf("failed to fingerprint source %q: %w", h.Name, err)
+		}
+	}
+
+	objects := make([]map[string]any, len(h.Objects))
+	for i := range h.Objects {
+		objects[i] = h.Objects[i].Object
+	}
+
+	deletions := make([]map[string]any, len(h.Deletions))
+	for i := range h.Deletions {
+		deletions[i] = h.Deletions[i].Object
+	}
+
+	err := encoder.Encode(struct {
+		Name             string
+		Objects          []map[string]any
+		Fingerprint      string
+		Manifests        []string
+		Positions        []Position
+		Labels           map[string]string
+		Annotations      map[string]string
+		OverrideMetadata bool
+		Patches          []Patch
+		Deletions        []map[string]any
+	}{
+		h.Name, objects, fingerprint, h.Manifests, h.Positions,
+		h.Labels, h.Annotations, h.OverrideMetadata, h.Patches, deletions,
+	})
+
+	return err == nil, nil
+}
+
+// cached returns the cached result of the render of values, or nil and a
+// function storing the result of rendering it now, which is nil if the render
+// cannot be cached.
+func (r *Renderer) cached(
+	ctx context.Context,
+	values types.Values,
+	inputs []*sourceHolder,
+	withProvenance bool,
+) (*Result, func(*Result), error) {
+	if r.cache == nil {
+		return nil, nil, nil
+	}
+
+	key, ok, err := r.cacheKey(ctx, values, inputs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("render cache error in mem renderer: %w", err)
+	}
+
+	if !ok {
+		return nil, nil, nil
+	}
+
+	result, epoch := r.cache.lookup(key, inputs, withProvenance)
+	if result != nil {
+		return result, nil, nil
+	}
+
+	return nil, func(result *Result) {
+		r.cache.store(epoch, &cacheEntry{key: key, inputs: inputs, provenance: withProvenance, result: result})
+	}, nil
+}
+
+// copyResult returns a copy of result whose objects are copied like source
+// objects, so the copy may be handed to a caller who owns them.
+func (r *Renderer) copyResult(result *Result) *Result {
+	copied := *result
+	copied.objects = slices.Clone(result.objects)
+
+	for i := range copied.objects {
+		if !r.opts.NoDeepCopy {
+			copied.objects[i] = *copied.objects[i].DeepCopy()
+		} else {
+			copied.objects[i] = copyMetadata(copied.objects[i])
+		}
+	}
+
+	return &copied
+}
@@ -0,0 +1,202 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// countingTransformer counts the objects it transforms.
+func countingTransformer(count *atomic.Int64) pkgtypes.Transformer {
+	return func(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+		count.Add(1)
+
+		return obj, nil
+	}
+}
+
+func cachedConfig(value string) unstructured.Unstructured {
+	obj := composeObject("v1", "ConfigMap", "app", "config")
+	obj.Object["data"] = map[string]any{"key": value}
+
+	return obj
+}
+
+func TestRenderCache(t *testing.T) {
+
+	t.Run("should serve unchanged renders from the cache", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var count atomic.Int64
+
+		renderer, err := mem.New(
+			[]mem.Source{{Name: "app", Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+			mem.WithRenderCache(true),
+			mem.WithSourceAnnotations(true),
+			mem.WithTransformer(countingTransformer(&count)),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		first, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		second, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(second).To(Equal(first))
+		g.Expect(count.Load()).To(Equal(int64(1)))
+
+		// Process hands out copies, so callers cannot corrupt the cache.
+		second[0].Object["data"] = map[string]any{"key": "changed"}
+
+		third, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(third).To(Equal(first))
+
+		// Process does not track provenance, so ProcessResult renders once.
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Info().Cached).To(BeFalse())
+
+		result, err = renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Info().Cached).To(BeTrue())
+		g.Expect(result.View().DeepCopy()).To(Equal(first))
+
+		provenance, ok := result.Provenance(0)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Name).To(Equal("app"))
+
+		g.Expect(count.Load()).To(Equal(int64(2)))
+		g.Expect(renderer.Stats().Renders).To(Equal(uint64(5)))
+		g.Expect(renderer.Stats().CacheHits).To(Equal(uint64(3)))
+	})
+
+	t.Run("should render again when values or sources change", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var count atomic.Int64
+
+		sources := []mem.Source{{Name: "app", Objects: []unstructured.Unstructured{cachedConfig("a")}}}
+		renderer, err := mem.New(sources, mem.WithRenderCache(true), mem.WithTransformer(countingTransformer(&count)))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		render := func(values pkgtypes.Values) []unstructured.Unstructured {
+			objects, err := renderer.Process(t.Context(), values)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			return objects
+		}
+
+		render(nil)
+		render(pkgtypes.Values{"env": "dev"})
+		render(pkgtypes.Values{"env": "dev"})
+		g.Expect(count.Load()).To(Equal(int64(2)))
+
+		sources[0].Objects[0].Object["data"] = map[string]any{"key": "b"}
+		g.Expect(render(pkgtypes.Values{"env": "dev"})[0].Object["data"]).To(HaveKeyWithValue("key", "b"))
+		g.Expect(count.Load()).To(Equal(int64(3)))
+
+		replacement := mem.Source{Objects: []unstructured.Unstructured{cachedConfig("b")}}
+		g.Expect(renderer.ReplaceSource("app", replacement)).To(Succeed())
+		render(pkgtypes.Values{"env": "dev"})
+		g.Expect(count.Load()).To(Equal(int64(4)))
+
+		renderer.Invalidate()
+		render(pkgtypes.Values{"env": "dev"})
+		render(pkgtypes.Values{"env": "dev"})
+		g.Expect(count.Load()).To(Equal(int64(5)))
+	})
+
+	t.Run("should only cache generators with a fingerprint", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var generated atomic.Int64
+
+		generate := func(_ context.Context, _ pkgtypes.Values) ([]unstructured.Unstructured, error) {
+			generated.Add(1)
+
+			return []unstructured.Unstructured{cachedConfig("generated")}, nil
+		}
+
+		unfingerprinted, err := mem.New([]mem.Source{{ObjectsFn: generate}}, mem.WithRenderCache(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			_, err := unfingerprinted.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(generated.Load()).To(Equal(int64(2)))
+
+		version := "1"
+		fingerprinted, err := mem.New(
+			[]mem.Source{{
+				ObjectsFn: generate,
+				Fingerprint: func(_ context.Context, _ pkgtypes.Values) (string, error) {
+					return version, nil
+				},
+			}},
+			mem.WithRenderCache(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			_, err := fingerprinted.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(generated.Load()).To(Equal(int64(3)))
+
+		version = "2"
+
+		_, err = fingerprinted.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(generated.Load()).To(Equal(int64(4)))
+	})
+
+	t.Run("should fail on fingerprint errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errFingerprint := errors.New("unavailable")
+
+		renderer, err := mem.New(
+			[]mem.Source{{
+				ObjectsFn: func(context.Context, pkgtypes.Values) ([]unstructured.Unstructured, error) {
+					return nil, nil
+				},
+				Fingerprint: func(_ context.Context, _ pkgtypes.Values) (string, error) {
+					return "", errFingerprint
+				},
+			}},
+			mem.WithRenderCache(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errFingerprint))
+		g.Expect(renderer.Stats().Errors).To(Equal(uint64(1)))
+	})
+
+	t.Run("should not cache failed renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{}}, mem.WithRenderCache(true), mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).To(MatchError(mem.ErrEmptyRender))
+		}
+
+		g.Expect(renderer.Stats().CacheHits).To(BeZero())
+	})
+}
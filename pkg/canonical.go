@@ -0,0 +1,144 @@
+package mem
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+//nolint:gochecknoglobals
+var (
+	// canonicalTopLevelOrder lists the top-level keys emitted first, in this order.
+	canonicalTopLevelOrder = []string{"apiVersion", "kind", "metadata"}
+
+	// canonicalMetadataOrder lists the metadata keys emitted first, in this order.
+	canonicalMetadataOrder = []string{"name", "generateName", "namespace", "labels", "annotations"}
+)
+
+// CanonicalJSON encodes an object as JSON with a fully deterministic key order:
+// apiVersion, kind, and metadata come first, metadata starts with name,
+// generateName, namespace, labels, and annotations, and every other map
+// (including labels and annotations themselves) is emitted in sorted key order.
+//
+// The output does not depend on how the object was built or on the encoder used
+// downstream, which makes it suitable for golden files and GitOps repositories.
+// Fields selected by WithExportRedaction are masked in the output; obj is not
+// modified.
+func CanonicalJSON(obj unstructured.Unstructured, opts ...ExportOption) ([]byte, error) {
+	redactor, err := newRedactor(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	redacted, err := redactor.redact(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, redacted.Object, canonicalTopLevelOrder, 0); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", ObjectKeyOf(obj), err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// canonicalizeMetadata drops empty labels and annotations maps so that objects
+// differing only by "labels: {}" versus no labels at all render identically.
+func canonicalizeMetadata(obj *unstructured.Unstructured) {
+	metadata, ok := obj.Object["metadata"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	for _, key := range []string{"labels", "annotations"} {
+		if value, found := metadata[key]; found {
+			if m, isMap := value.(map[string]any); value == nil || (isMap && len(m) == 0) {
+				delete(metadata, key)
+			}
+		}
+	}
+}
+
+func writeCanonical(buf *bytes.Buffer, value any, leading []string, depth int) error {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := orderedKeys(v, leading)
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := writeJSON(buf, k); err != nil {
+				return err
+			}
+
+			buf.WriteByte(':')
+
+			var next []string
+			if depth == 0 && k == "metadata" {
+				next = canonicalMetadataOrder
+			}
+
+			if err := writeCanonical(buf, v[k], next, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := writeCanonical(buf, item, nil, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return writeJSON(buf, v)
+	}
+
+	return nil
+}
+
+func writeJSON(buf *bytes.Buffer, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+
+	buf.Write(data)
+
+	return nil
+}
+
+// orderedKeys returns the keys of m with the leading keys first (in the given
+// order, when present) followed by the remaining keys sorted.
+func orderedKeys(m map[string]any, leading []string) []string {
+	keys := make([]string, 0, len(m))
+
+	for _, k := range leading {
+		if _, ok := m[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+
+	rest := make([]string, 0, len(m)-len(keys))
+	for k := range m {
+		if !slices.Contains(leading, k) {
+			rest = append(rest, k)
+		}
+	}
+
+	slices.Sort(rest)
+
+	return append(keys, rest...)
+}
@@ -0,0 +1,147 @@
+package mem_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const deploymentYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: second
+  labels: {e: "5", d: "4", c: "3", b: "2", a: "1"}
+  annotations: {e: "5", d: "4", c: "3", b: "2", a: "1"}
+spec:
+  replicas: 3
+`
+
+func TestCanonicalJSON(t *testing.T) {
+
+	t.Run("should emit well-known keys first and the rest sorted", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"z": int64(1), "a": []any{map[string]any{"y": true, "b": nil}}},
+			"kind": "ConfigMap",
+			"metadata": map[string]any{
+				"uid":         "1",
+				"annotations": map[string]any{"b": "2", "a": "1"},
+				"labels":      map[string]any{"z": "z", "a": "a"},
+				"namespace":   "ns",
+				"name":        "test",
+			},
+			"apiVersion": "v1",
+			"data":       map[string]any{"k": "v"},
+		}}
+
+		data, err := mem.CanonicalJSON(obj)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(data)).To(Equal(
+			`{"apiVersion":"v1","kind":"ConfigMap",` +
+				`"metadata":{"name":"test","namespace":"ns","labels":{"a":"a","z":"z"},` +
+				`"annotations":{"a":"1","b":"2"},"uid":"1"},` +
+				`"data":{"k":"v"},"spec":{"a":[{"b":null,"y":true}],"z":1}}`))
+	})
+
+	t.Run("should only reorder metadata keys at the top level", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"metadata": map[string]any{"namespace": "b", "name": "a"}},
+		}}
+
+		data, err := mem.CanonicalJSON(obj)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(data)).To(Equal(`{"spec":{"metadata":{"name":"a","namespace":"b"}}}`))
+	})
+
+	t.Run("should be valid JSON equal to the input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := mem.MustUnstructured(configMapYAML)
+
+		data, err := mem.CanonicalJSON(obj)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		decoded := map[string]any{}
+		g.Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+		g.Expect(decoded).To(HaveKeyWithValue("kind", "ConfigMap"))
+		g.Expect(decoded["metadata"]).To(HaveKeyWithValue("name", "test-config"))
+	})
+
+	t.Run("should be stable across calls", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := mem.MustUnstructured(deploymentYAML)
+
+		first, err := mem.CanonicalJSON(obj)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 20 {
+			again, err := mem.CanonicalJSON(*obj.DeepCopy())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(again).To(Equal(first))
+		}
+	})
+}
+
+func TestCanonicalMetadata(t *testing.T) {
+
+	t.Run("should drop empty labels and annotations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":        "test",
+				"labels":      map[string]any{},
+				"annotations": nil,
+			},
+		}}
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{obj}}},
+			mem.WithCanonicalMetadata(true),
+			mem.WithContentHash(false),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object["metadata"]).To(Equal(map[string]any{"name": "test"}))
+	})
+
+	t.Run("should hash empty and absent metadata maps identically", func(t *testing.T) {
+		g := NewWithT(t)
+
+		withEmpty := unstructured.Unstructured{Object: map[string]any{
+			"kind":     "ConfigMap",
+			"metadata": map[string]any{"name": "test", "labels": map[string]any{}},
+		}}
+		without := unstructured.Unstructured{Object: map[string]any{
+			"kind":     "ConfigMap",
+			"metadata": map[string]any{"name": "test"},
+		}}
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{withEmpty, without}}},
+			mem.WithCanonicalMetadata(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()[pkgtypes.AnnotationContentHash]).To(
+			Equal(objects[1].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+	})
+}
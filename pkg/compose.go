@@ -0,0 +1,118 @@
+package mem
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Union returns the objects of all sets in order, keeping only the first
+// occurrence of each identity as defined by DefaultIdentity. The API version
+// is not part of the identity, so the same object served at two versions is
+// considered a duplicate.
+//
+// Like the other set operations, Union does not copy objects: the result shares
+// data with its inputs. The renderer deep copies objects when they are rendered.
+func Union(sets ...[]unstructured.Unstructured) []unstructured.Unstructured {
+	return UnionFunc(DefaultIdentity, sets...)
+}
+
+// UnionFunc is like Union but compares objects with the given identity function.
+func UnionFunc(identity IdentityFunc, sets ...[]unstructured.Unstructured) []unstructured.Unstructured {
+	identity = identityOrDefault(identity)
+	seen := make(map[string]struct{})
+	result := make([]unstructured.Unstructured, 0)
+
+	for _, set := range sets {
+		for _, obj := range set {
+			id := identity(obj)
+			if _, dup := seen[id]; dup {
+				continue
+			}
+
+			seen[id] = struct{}{}
+			result = append(result, obj)
+		}
+	}
+
+	return result
+}
+
+// Intersect returns the objects of set whose identity is present in every one of
+// others, preserving the order of set.
+func Intersect(set []unstructured.Unstructured, others ...[]unstructured.Unstructured) []unstructured.Unstructured {
+	return IntersectFunc(DefaultIdentity, set, others...)
+}
+
+// IntersectFunc is like Intersect but compares objects with the given identity function.
+func IntersectFunc(
+	identity IdentityFunc,
+	set []unstructured.Unstructured,
+	others ...[]unstructured.Unstructured,
+) []unstructured.Unstructured {
+	identity = identityOrDefault(identity)
+
+	indexes := make([]map[string]struct{}, len(others))
+	for i, other := range others {
+		indexes[i] = identitySet(identity, other)
+	}
+
+	return filterObjects(identity, set, func(id string) bool {
+		for _, index := range indexes {
+			if _, ok := index[id]; !ok {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// Subtract returns the objects of set whose identity is present in none of
+// others, preserving the order of set. This supports workflows such as
+// "render everything except what the base bundle already provides":
+//
+//	overlay := mem.Source{Objects: mem.Subtract(all.Objects, base.Objects)}
+func Subtract(set []unstructured.Unstructured, others ...[]unstructured.Unstructured) []unstructured.Unstructured {
+	return SubtractFunc(DefaultIdentity, set, others...)
+}
+
+// SubtractFunc is like Subtract but compares objects with the given identity function.
+func SubtractFunc(
+	identity IdentityFunc,
+	set []unstructured.Unstructured,
+	others ...[]unstructured.Unstructured,
+) []unstructured.Unstructured {
+	identity = identityOrDefault(identity)
+	index := identitySet(identity, others...)
+
+	return filterObjects(identity, set, func(id string) bool {
+		_, found := index[id]
+
+		return !found
+	})
+}
+
+func identitySet(identity IdentityFunc, sets ...[]unstructured.Unstructured) map[string]struct{} {
+	index := make(map[string]struct{})
+	for _, set := range sets {
+		for _, obj := range set {
+			index[identity(obj)] = struct{}{}
+		}
+	}
+
+	return index
+}
+
+func filterObjects(
+	identity IdentityFunc,
+	set []unstructured.Unstructured,
+	keep func(string) bool,
+) []unstructured.Unstructured {
+	result := make([]unstructured.Unstructured, 0, len(set))
+	for _, obj := range set {
+		if keep(identity(obj)) {
+			result = append(result, obj)
+		}
+	}
+
+	return result
+}
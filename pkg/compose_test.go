@@ -0,0 +1,139 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func composeObject(apiVersion string, kind string, namespace string, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]any{}}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+
+	return obj
+}
+
+func names(objects []unstructured.Unstructured) []string {
+	result := make([]string, len(objects))
+	for i := range objects {
+		result[i] = objects[i].GetKind() + "/" + objects[i].GetName()
+	}
+
+	return result
+}
+
+func TestUnion(t *testing.T) {
+
+	t.Run("should keep the first occurrence of each identity", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first := composeObject("v1", "ConfigMap", "default", "a")
+		first.SetLabels(map[string]string{"from": "first"})
+
+		result := mem.Union(
+			[]unstructured.Unstructured{first, composeObject("v1", "Secret", "default", "b")},
+			[]unstructured.Unstructured{
+				composeObject("v1", "ConfigMap", "default", "a"),
+				composeObject("v1", "ConfigMap", "default", "c"),
+			},
+		)
+
+		g.Expect(names(result)).To(Equal([]string{"ConfigMap/a", "Secret/b", "ConfigMap/c"}))
+		g.Expect(result[0].GetLabels()).To(HaveKeyWithValue("from", "first"))
+	})
+
+	t.Run("should ignore the API version", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result := mem.Union(
+			[]unstructured.Unstructured{composeObject("apps/v1", "Deployment", "ns", "d")},
+			[]unstructured.Unstructured{composeObject("apps/v1beta1", "Deployment", "ns", "d")},
+		)
+
+		g.Expect(result).To(HaveLen(1))
+	})
+
+	t.Run("should distinguish namespaces and groups", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result := mem.Union([]unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "a", "x"),
+			composeObject("v1", "ConfigMap", "b", "x"),
+			composeObject("example.com/v1", "ConfigMap", "a", "x"),
+		})
+
+		g.Expect(result).To(HaveLen(3))
+	})
+}
+
+func TestIntersect(t *testing.T) {
+
+	t.Run("should keep objects present in every other set", func(t *testing.T) {
+		g := NewWithT(t)
+
+		set := []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "", "a"),
+			composeObject("v1", "ConfigMap", "", "b"),
+			composeObject("v1", "ConfigMap", "", "c"),
+		}
+
+		a := composeObject("v1", "ConfigMap", "", "a")
+		c := composeObject("v1", "ConfigMap", "", "c")
+
+		result := mem.Intersect(set,
+			[]unstructured.Unstructured{c, a},
+			[]unstructured.Unstructured{a, c},
+		)
+
+		g.Expect(names(result)).To(Equal([]string{"ConfigMap/a", "ConfigMap/c"}))
+	})
+
+	t.Run("should return everything when there are no other sets", func(t *testing.T) {
+		g := NewWithT(t)
+
+		set := []unstructured.Unstructured{composeObject("v1", "ConfigMap", "", "a")}
+		g.Expect(mem.Intersect(set)).To(HaveLen(1))
+	})
+}
+
+func TestSubtract(t *testing.T) {
+
+	t.Run("should drop objects provided by the base", func(t *testing.T) {
+		g := NewWithT(t)
+
+		all := []unstructured.Unstructured{
+			composeObject("v1", "Namespace", "", "app"),
+			composeObject("v1", "ConfigMap", "app", "config"),
+			composeObject("apps/v1", "Deployment", "app", "web"),
+		}
+		base := []unstructured.Unstructured{composeObject("v1", "Namespace", "", "app")}
+
+		result := mem.Subtract(all, base)
+		g.Expect(names(result)).To(Equal([]string{"ConfigMap/config", "Deployment/web"}))
+	})
+
+	t.Run("should render the difference", func(t *testing.T) {
+		g := NewWithT(t)
+
+		all := []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "app", "a"),
+			composeObject("v1", "ConfigMap", "app", "b"),
+		}
+
+		renderer, err := mem.New([]mem.Source{{
+			Objects: mem.Subtract(all, all[:1]),
+		}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/b"}))
+	})
+}
@@ -0,0 +1,197 @@
+package mem
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types reported by RenderConditions.
+const (
+	// ConditionRendered reports whether the last render succeeded.
+	ConditionRendered = "Rendered"
+
+	// ConditionValidated reports whether the rendered objects passed the
+	// checks of the renderer. It is Unknown when the render failed for
+	// another reason, since the objects were never complete.
+	ConditionValidated = "Validated"
+
+	// ConditionDegraded reports whether the render failed or succeeded with
+	// warnings.
+	ConditionDegraded = "Degraded"
+)
+
+// Condition reasons reported by RenderConditions.
+const (
+	ReasonRenderSucceeded     = "RenderSucceeded"
+	ReasonRenderFailed        = "RenderFailed"
+	ReasonEmptyRender         = "EmptyRender"
+	ReasonNondeterministic    = "NondeterministicRender"
+	ReasonValidationSucceeded = "ValidationSucceeded"
+	ReasonValidationFailed    = "ValidationFailed"
+	ReasonRenderWarnings      = "RenderWarnings"
+	ReasonAsExpected          = "AsExpected"
+)
+
+const (
+	// maxConditionMessageLength is the longest condition message the API
+	// server accepts.
+	maxConditionMessageLength = 32768
+
+	// maxConditionWarnings is the number of warnings quoted in the message
+	// of a Degraded condition.
+	maxConditionWarnings = 3
+)
+
+// validationErrors are the errors that reject the content of objects rather
+// than report a failure to produce them.
+var validationErrors = []error{
+	ErrInvalidObject,
+	ErrSchemaViolation,
+	ErrPolicyViolation,
+	ErrGVKNotAllowed,
+	ErrNamespaceNotAllowed,
+	ErrLimitExceeded,
+	ErrRemovedAPI,
+	ErrObjectEmpty,
+	ErrMissingIdentity,
+	ErrMetadataOnlyObject,
+	ErrNonJSONValue,
+	ErrMaxDepthExceeded,
+	ErrDuplicateObject,
+	ErrInvalidCRD,
+	ErrInvalidConversion,
+	ErrUnknownPriorityClass,
+}
+
+// RenderConditions converts the outcome of a render, the Result and error
+// returned by ProcessResult or Job.Run, into the Rendered, Validated, and
+// Degraded conditions of a custom resource status, with generation as their
+// ObservedGeneration. result may be nil when err is not.
+//
+// A successful render is Rendered and Validated, with the object count and
+// the RenderedHash of Summarize in the message, and Degraded only if it
+// reported warnings. A failed render is not Rendered and is Degraded, with
+// the error as the message; it is not Validated if the error rejected object
+// content, and Unknown otherwise.
+//
+// All conditions carry the current time as LastTransitionTime; merge them
+// with SetRenderConditions or meta.SetStatusCondition, which keep the time of
+// conditions whose status did not change.
+func RenderConditions(result *Result, err error, generation int64) []metav1.Condition {
+	now := metav1.Now()
+
+	condition := func(
+		conditionType string,
+		status metav1.ConditionStatus,
+		reason string,
+		message string,
+	) metav1.Condition {
+		return metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			ObservedGeneration: generation,
+			LastTransitionTime: now,
+			Reason:             reason,
+			Message:            conditionMessage(message),
+		}
+	}
+
+	if err != nil {
+		reason := failureReason(err)
+
+		validated := condition(ConditionValidated, metav1.ConditionUnknown, reason, "the render did not complete")
+		if reason == ReasonValidationFailed {
+			validated = condition(ConditionValidated, metav1.ConditionFalse, reason, err.Error())
+		}
+
+		return []metav1.Condition{
+			condition(ConditionRendered, metav1.ConditionFalse, reason, err.Error()),
+			validated,
+			condition(ConditionDegraded, metav1.ConditionTrue, reason, err.Error()),
+		}
+	}
+
+	if result == nil {
+		result = NewResult(nil)
+	}
+
+	summary := Summarize(result.objects)
+
+	degraded := condition(ConditionDegraded, metav1.ConditionFalse, ReasonAsExpected, "the render reported no warnings")
+	if len(result.warnings) > 0 {
+		degraded = condition(ConditionDegraded, metav1.ConditionTrue, ReasonRenderWarnings,
+			warningsMessage(result.warnings))
+	}
+
+	return []metav1.Condition{
+		condition(ConditionRendered, metav1.ConditionTrue, ReasonRenderSucceeded,
+			fmt.Sprintf("rendered %d objects, %s", summary.ObjectCount, summary.RenderedHash)),
+		condition(ConditionValidated, metav1.ConditionTrue, ReasonValidationSucceeded,
+			fmt.Sprintf("%d objects passed validation", summary.ObjectCount)),
+		degraded,
+	}
+}
+
+// SetRenderConditions merges the conditions of RenderConditions into
+// conditions, typically &status.Conditions, and reports whether any of them
+// changed.
+func SetRenderConditions(conditions *[]metav1.Condition, result *Result, err error, generation int64) bool {
+	changed := false
+
+	for _, condition := range RenderConditions(result, err, generation) {
+		if meta.SetStatusCondition(conditions, condition) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// failureReason classifies a render error into a condition reason.
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrEmptyRender):
+		return ReasonEmptyRender
+	case errors.Is(err, ErrNondeterministicRender):
+		return ReasonNondeterministic
+	}
+
+	for _, target := range validationErrors {
+		if errors.Is(err, target) {
+			return ReasonValidationFailed
+		}
+	}
+
+	return ReasonRenderFailed
+}
+
+// warningsMessage lists the first warnings of a render, and how many more
+// there are.
+func warningsMessage(warnings []Warning) string {
+	messages := make([]string, 0, maxConditionWarnings)
+	for _, w := range warnings[:min(len(warnings), maxConditionWarnings)] {
+		messages = append(messages, w.Message)
+	}
+
+	message := fmt.Sprintf("%d warnings: %s", len(warnings), strings.Join(messages, "; "))
+	if more := len(warnings) - len(messages); more > 0 {
+		message += fmt.Sprintf("; and %d more", more)
+	}
+
+	return message
+}
+
+// conditionMessage truncates message to maxConditionMessageLength.
+func conditionMessage(message string) string {
+	if len(message) <= maxConditionMessageLength {
+		return message
+	}
+
+	const ellipsis = "..."
+
+	return strings.ToValidUTF8(message[:maxConditionMessageLength-len(ellipsis)], "") + ellipsis
+}
@@ -0,0 +1,132 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// statuses maps condition types to their status and reason.
+func statuses(conditions []metav1.Condition) map[string]string {
+	out := make(map[string]string, len(conditions))
+	for _, c := range conditions {
+		out[c.Type] = string(c.Status) + "/" + c.Reason
+	}
+
+	return out
+}
+
+func TestRenderConditions(t *testing.T) {
+
+	t.Run("should report a successful render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: configMaps(2)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		conditions := mem.RenderConditions(result, err, 4)
+
+		g.Expect(statuses(conditions)).To(Equal(map[string]string{
+			mem.ConditionRendered:  "True/" + mem.ReasonRenderSucceeded,
+			mem.ConditionValidated: "True/" + mem.ReasonValidationSucceeded,
+			mem.ConditionDegraded:  "False/" + mem.ReasonAsExpected,
+		}))
+
+		rendered := meta.FindStatusCondition(conditions, mem.ConditionRendered)
+		g.Expect(rendered.ObservedGeneration).To(Equal(int64(4)))
+		g.Expect(rendered.Message).To(Equal(
+			fmt.Sprintf("rendered 2 objects, %s", mem.Summarize(result.View().DeepCopy()).RenderedHash)))
+	})
+
+	t.Run("should report warnings as degraded", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(5)}},
+			mem.WithTransformer(func(
+				ctx context.Context,
+				obj unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				mem.Warnf(ctx, "%s looks odd", obj.GetName())
+
+				return obj, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		conditions := mem.RenderConditions(result, err, 1)
+
+		g.Expect(statuses(conditions)).To(HaveKeyWithValue(mem.ConditionRendered, "True/"+mem.ReasonRenderSucceeded))
+
+		degraded := meta.FindStatusCondition(conditions, mem.ConditionDegraded)
+		g.Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+		g.Expect(degraded.Reason).To(Equal(mem.ReasonRenderWarnings))
+		g.Expect(degraded.Message).To(Equal("5 warnings: cm-0 looks odd; cm-1 looks odd; cm-2 looks odd; and 2 more"))
+	})
+
+	t.Run("should classify failures", func(t *testing.T) {
+		g := NewWithT(t)
+
+		empty, err := mem.New(nil, mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = empty.ProcessResult(t.Context(), nil)
+		g.Expect(statuses(mem.RenderConditions(nil, err, 1))).To(Equal(map[string]string{
+			mem.ConditionRendered:  "False/" + mem.ReasonEmptyRender,
+			mem.ConditionValidated: "Unknown/" + mem.ReasonEmptyRender,
+			mem.ConditionDegraded:  "True/" + mem.ReasonEmptyRender,
+		}))
+
+		invalid := fmt.Errorf("rendering app: %w", mem.ErrMissingIdentity)
+		g.Expect(statuses(mem.RenderConditions(nil, invalid, 1))).To(Equal(map[string]string{
+			mem.ConditionRendered:  "False/" + mem.ReasonValidationFailed,
+			mem.ConditionValidated: "False/" + mem.ReasonValidationFailed,
+			mem.ConditionDegraded:  "True/" + mem.ReasonValidationFailed,
+		}))
+
+		conditions := mem.RenderConditions(nil, errors.New("source unavailable"), 1)
+		g.Expect(statuses(conditions)).To(HaveKeyWithValue(mem.ConditionRendered, "False/"+mem.ReasonRenderFailed))
+		g.Expect(meta.FindStatusCondition(conditions, mem.ConditionRendered).Message).To(Equal("source unavailable"))
+	})
+
+	t.Run("should truncate long messages", func(t *testing.T) {
+		g := NewWithT(t)
+
+		conditions := mem.RenderConditions(nil, errors.New(strings.Repeat("é", 20000)), 1)
+
+		message := meta.FindStatusCondition(conditions, mem.ConditionRendered).Message
+		g.Expect(len(message)).To(BeNumerically("<=", 32768))
+		g.Expect(message).To(HaveSuffix("..."))
+		g.Expect(strings.ToValidUTF8(message, "")).To(Equal(message))
+	})
+
+	t.Run("should merge into existing conditions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		existing := []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Ready"}}
+
+		g.Expect(mem.SetRenderConditions(&existing, mem.NewResult(configMaps(1)), nil, 2)).To(BeTrue())
+		g.Expect(existing).To(HaveLen(4))
+
+		transitioned := meta.FindStatusCondition(existing, mem.ConditionRendered).LastTransitionTime
+
+		g.Expect(mem.SetRenderConditions(&existing, mem.NewResult(configMaps(1)), nil, 2)).To(BeFalse())
+		g.Expect(meta.FindStatusCondition(existing, mem.ConditionRendered).LastTransitionTime).To(Equal(transitioned))
+
+		g.Expect(mem.SetRenderConditions(&existing, nil, errors.New("boom"), 3)).To(BeTrue())
+		g.Expect(meta.IsStatusConditionFalse(existing, mem.ConditionRendered)).To(BeTrue())
+		g.Expect(meta.IsStatusConditionTrue(existing, "Ready")).To(BeTrue())
+	})
+}
@@ -0,0 +1,176 @@
+package mem
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// conversionStrategyWebhook is the CRD conversion strategy that needs a
+// webhook to convert resources between versions.
+const conversionStrategyWebhook = "Webhook"
+
+// CRConverter converts the custom resources of one kind to a version of
+// their CustomResourceDefinition, as the API server would with the CRD's
+// conversion strategy.
+type CRConverter struct {
+	// Version is the version to convert to. Empty means the storage version
+	// of the CRD.
+	Version string
+
+	// Convert adapts the fields of each converted resource, standing in for
+	// the conversion webhook. It runs after the apiVersion of the resource was
+	// rewritten, and is required for CRDs with the Webhook strategy. Without
+	// it, fields are kept as they are, as with the None strategy.
+	Convert GVKMigrateFunc
+}
+
+// crVersionAlignment holds the converters registered by
+// WithCRVersionAlignment.
+type crVersionAlignment struct {
+	converters map[schema.GroupKind]CRConverter
+}
+
+// crVersionTarget is the version the custom resources of a rendered CRD are
+// converted to.
+type crVersionTarget struct {
+	crd     string
+	version string
+	webhook bool
+	convert GVKMigrateFunc
+}
+
+// alignCRVersions converts the custom resources of kinds defined by the
+// rendered CRDs to one version per kind, records the conversions in trace, and
+// refreshes the content hashes of the converted resources.
+func (r *Renderer) alignCRVersions(outputs [][]unstructured.Unstructured, trace *renderTrace) error {
+	targets, err := r.crVersionTargets(outputs)
+	if err != nil || len(targets) == 0 {
+		return err
+	}
+
+	for _, objects := range outputs {
+		converted := make([]int, 0)
+
+		for i := range objects {
+			obj := &objects[i]
+
+			from := obj.GroupVersionKind()
+			key := ObjectKeyOf(*obj)
+
+			target, ok := targets[from.GroupKind()]
+			if !ok || from.Version == target.version {
+				continue
+			}
+
+			if target.webhook && target.convert == nil {
+				return fmt.Errorf("%w: %s: CRD %s converts with a webhook and no converter is registered",
+					ErrInvalidConversion, key, target.crd)
+			}
+
+			to := schema.GroupVersionKind{Group: from.Group, Version: target.version, Kind: from.Kind}
+
+			obj.SetGroupVersionKind(to)
+
+			if target.convert != nil {
+				r.ownObject(obj)
+
+				if err := target.convert(from, obj); err != nil {
+					return fmt.Errorf("failed to convert %s to %s: %w", key, to.GroupVersion(), err)
+				}
+			}
+
+			converted = append(converted, i)
+			trace.migrations = append(trace.migrations, Migration{
+				From:      from,
+				To:        to,
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+			})
+		}
+
+		if err := r.opts.hasher().rehash(objects, converted); err != nil {
+			return fmt.Errorf("content hash error in mem renderer: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// crVersionTargets returns the conversion target of each kind defined by a
+// CRD of outputs. If several CRDs define a kind, the first one is used.
+func (r *Renderer) crVersionTargets(
+	outputs [][]unstructured.Unstructured,
+) (map[schema.GroupKind]crVersionTarget, error) {
+	targets := make(map[schema.GroupKind]crVersionTarget)
+
+	for _, objects := range outputs {
+		for i := range objects {
+			gk, ok := definedKind(objects[i])
+			if !ok {
+				continue
+			}
+
+			if _, defined := targets[gk]; defined {
+				continue
+			}
+
+			target, err := newCRVersionTarget(objects[i], r.opts.CRVersionAlignment.converters[gk])
+			if err != nil {
+				return nil, err
+			}
+
+			targets[gk] = target
+		}
+	}
+
+	return targets, nil
+}
+
+// newCRVersionTarget returns the version the resources defined by crd are
+// converted to with converter.
+func newCRVersionTarget(crd unstructured.Unstructured, converter CRConverter) (crVersionTarget, error) {
+	strategy, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "strategy")
+
+	target := crVersionTarget{
+		crd:     crd.GetName(),
+		version: converter.Version,
+		webhook: strategy == conversionStrategyWebhook,
+		convert: converter.Convert,
+	}
+
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return crVersionTarget{}, fmt.Errorf("%w: CRD %s: %w", ErrInvalidConversion, target.crd, err)
+	}
+
+	listed := make(map[string]struct{}, len(versions))
+	storage := ""
+
+	for _, version := range versions {
+		v, _ := version.(map[string]any)
+
+		name, _ := v["name"].(string)
+		if isStorage, _ := v["storage"].(bool); isStorage {
+			storage = name
+		}
+
+		listed[name] = struct{}{}
+	}
+
+	if target.version == "" {
+		target.version = storage
+	}
+
+	if target.version == "" {
+		return crVersionTarget{}, fmt.Errorf("%w: CRD %s has no storage version", ErrInvalidConversion, target.crd)
+	}
+
+	if _, ok := listed[target.version]; !ok {
+		return crVersionTarget{}, fmt.Errorf("%w: CRD %s has no version %q",
+			ErrInvalidConversion, target.crd, target.version)
+	}
+
+	return target, nil
+}
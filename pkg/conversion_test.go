@@ -0,0 +1,189 @@
+package mem_test
+
+import (
+	"errors"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+var (
+	widgetKind    = schema.GroupKind{Group: "example.com", Kind: "Widget"}
+	widgetV1      = widgetKind.WithVersion("v1")
+	widgetV1Beta1 = widgetKind.WithVersion("v1beta1")
+)
+
+// conversionCRD returns the CRD of Widget with the v1beta1 and v1 versions,
+// storing v1, and the given conversion strategy.
+func conversionCRD(strategy string) unstructured.Unstructured {
+	crd := scopeCRD("Widget", "Namespaced")
+	crd.Object["spec"].(map[string]any)["versions"] = []any{
+		map[string]any{"name": "v1beta1", "served": true, "storage": false},
+		map[string]any{"name": "v1", "served": true, "storage": true},
+	}
+
+	if strategy != "" {
+		crd.Object["spec"].(map[string]any)["conversion"] = map[string]any{"strategy": strategy}
+	}
+
+	return crd
+}
+
+func conversionObjects(strategy string) []unstructured.Unstructured {
+	return []unstructured.Unstructured{
+		conversionCRD(strategy),
+		composeObject("example.com/v1beta1", "Widget", "app", "first"),
+		composeObject("example.com/v1", "Widget", "app", "second"),
+		composeObject("other.io/v1alpha1", "Thing", "app", "thing"),
+	}
+}
+
+func TestCRVersionAlignment(t *testing.T) {
+
+	t.Run("should convert resources to the storage version of their CRD", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: conversionObjects("")}},
+			mem.WithCRVersionAlignment(nil),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects := result.View().DeepCopy()
+		g.Expect(objects[1].GroupVersionKind()).To(Equal(widgetV1))
+		g.Expect(objects[2].GroupVersionKind()).To(Equal(widgetV1))
+		g.Expect(objects[3].GetAPIVersion()).To(Equal("other.io/v1alpha1"))
+
+		g.Expect(result.Migrations()).To(Equal([]mem.Migration{{
+			From:      widgetV1Beta1,
+			To:        widgetV1,
+			Namespace: "app",
+			Name:      "first",
+		}}))
+
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should convert to the chosen version with the converter", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var froms []schema.GroupVersionKind
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: conversionObjects("Webhook")}},
+			mem.WithCRVersionAlignment(map[schema.GroupKind]mem.CRConverter{
+				widgetKind: {
+					Version: "v1beta1",
+					Convert: func(from schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+						froms = append(froms, from)
+
+						return unstructured.SetNestedField(obj.Object, from.Version, "spec", "convertedFrom")
+					},
+				},
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(froms).To(Equal([]schema.GroupVersionKind{widgetV1}))
+
+		g.Expect(objects[1].GroupVersionKind()).To(Equal(widgetV1Beta1))
+		g.Expect(objects[1].Object).ToNot(HaveKey("spec"))
+		g.Expect(objects[2].GroupVersionKind()).To(Equal(widgetV1Beta1))
+		g.Expect(objects[2].Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("convertedFrom", "v1")))
+	})
+
+	t.Run("should resolve resources rendered at two versions as duplicates", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				{Objects: []unstructured.Unstructured{conversionCRD(""), cachedConfig("a")}},
+				{Objects: []unstructured.Unstructured{composeObject("example.com/v1beta1", "Widget", "app", "first")}},
+				{Objects: []unstructured.Unstructured{composeObject("example.com/v1", "Widget", "app", "first")}},
+			},
+			mem.WithCRVersionAlignment(nil),
+			mem.WithDuplicatePolicy(mem.DuplicateError),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrDuplicateObject))
+	})
+
+	t.Run("should leave resources of kinds without a rendered CRD", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := conversionObjects("")[1:]
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: objects}},
+			mem.WithCRVersionAlignment(map[schema.GroupKind]mem.CRConverter{widgetKind: {Version: "v1"}}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		rendered, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for i := range rendered {
+			g.Expect(rendered[i].GetAPIVersion()).To(Equal(objects[i].GetAPIVersion()))
+			g.Expect(rendered[i].GetAnnotations()).To(HaveKey(pkgtypes.AnnotationContentHash))
+		}
+	})
+
+	t.Run("should fail on conversions the CRD cannot perform", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, tc := range []struct {
+			objects    []unstructured.Unstructured
+			converters map[schema.GroupKind]mem.CRConverter
+			message    string
+		}{
+			{conversionObjects("Webhook"), nil, "no converter is registered"},
+			{
+				conversionObjects(""),
+				map[schema.GroupKind]mem.CRConverter{widgetKind: {Version: "v2"}},
+				`no version "v2"`,
+			},
+			{[]unstructured.Unstructured{scopeCRD("Widget", "Namespaced")}, nil, "no storage version"},
+		} {
+			renderer, err := mem.New(
+				[]mem.Source{{Objects: tc.objects}},
+				mem.WithCRVersionAlignment(tc.converters),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, err = renderer.Process(t.Context(), nil)
+			g.Expect(err).To(MatchError(mem.ErrInvalidConversion))
+			g.Expect(err).To(MatchError(ContainSubstring(tc.message)))
+		}
+
+		errConvert := errors.New("unsupported field")
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: conversionObjects("Webhook")}},
+			mem.WithCRVersionAlignment(map[schema.GroupKind]mem.CRConverter{
+				widgetKind: {Convert: func(schema.GroupVersionKind, *unstructured.Unstructured) error {
+					return errConvert
+				}},
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errConvert))
+		g.Expect(err).To(MatchError(ContainSubstring(
+			"failed to convert example.com/v1beta1/Widget/app/first to example.com/v1")))
+	})
+}
@@ -0,0 +1,300 @@
+package mem
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ToUnstructured converts a typed object into an unstructured one without a scheme.
+//
+// The output is identical to runtime.DefaultUnstructuredConverter. ConfigMaps,
+// Secrets, Namespaces, Pods, and apps/v1 Deployments, StatefulSets, and
+// DaemonSets take a hand-written path that avoids reflection when they use the
+// fields most manifests do; objects that carry owner references, finalizers,
+// timestamps, managed fields, or status, and workloads whose pods use fields
+// such as probes, security contexts, or affinity, go through the default
+// converter at its speed, as does every other type. Unstructured inputs are
+// deep copied.
+//
+// As with the default converter, apiVersion and kind are emitted only if the object's
+// TypeMeta is populated.
+func ToUnstructured(obj runtime.Object) (unstructured.Unstructured, error) {
+	switch o := obj.(type) {
+	case nil:
+		return unstructured.Unstructured{}, ErrObjectNil
+	case *unstructured.Unstructured:
+		if o == nil {
+			return unstructured.Unstructured{}, ErrObjectNil
+		}
+
+		return *o.DeepCopy(), nil
+	case *corev1.ConfigMap:
+		if o == nil {
+			return unstructured.Unstructured{}, ErrObjectNil
+		}
+
+		if content, ok := configMapToUnstructured(o); ok {
+			return unstructured.Unstructured{Object: content}, nil
+		}
+	case *corev1.Secret:
+		if o == nil {
+			return unstructured.Unstructured{}, ErrObjectNil
+		}
+
+		if content, ok := secretToUnstructured(o); ok {
+			return unstructured.Unstructured{Object: content}, nil
+		}
+	case *corev1.Namespace:
+		if o == nil {
+			return unstructured.Unstructured{}, ErrObjectNil
+		}
+
+		if content, ok := namespaceToUnstructured(o); ok {
+			return unstructured.Unstructured{Object: content}, nil
+		}
+	case *corev1.Pod:
+		if o == nil {
+			return unstructured.Unstructured{}, ErrObjectNil
+		}
+
+		if content, ok := podToUnstructured(o); ok {
+			return unstructured.Unstructured{Object: content}, nil
+		}
+	case *appsv1.Deployment:
+		if o == nil {
+			return unstructured.Unstructured{}, ErrObjectNil
+		}
+
+		if content, ok := deploymentToUnstructured(o); ok {
+			return unstructured.Unstructured{Object: content}, nil
+		}
+	case *appsv1.StatefulSet:
+		if o == nil {
+			return unstructured.Unstructured{}, ErrObjectNil
+		}
+
+		if content, ok := statefulSetToUnstructured(o); ok {
+			return unstructured.Unstructured{Object: content}, nil
+		}
+	case *appsv1.DaemonSet:
+		if o == nil {
+			return unstructured.Unstructured{}, ErrObjectNil
+		}
+
+		if content, ok := daemonSetToUnstructured(o); ok {
+			return unstructured.Unstructured{Object: content}, nil
+		}
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return unstructured.Unstructured{}, fmt.Errorf("failed to convert %T to unstructured: %w", obj, err)
+	}
+
+	return unstructured.Unstructured{Object: content}, nil
+}
+
+// SourceFromObjects builds a Source from typed objects using ToUnstructured.
+func SourceFromObjects(objs ...runtime.Object) (Source, error) {
+	source := Source{
+		Objects: make([]unstructured.Unstructured, len(objs)),
+	}
+
+	for i, obj := range objs {
+		u, err := ToUnstructured(obj)
+		if err != nil {
+			return Source{}, fmt.Errorf("invalid object at index %d: %w", i, err)
+		}
+
+		source.Objects[i] = u
+	}
+
+	return source, nil
+}
+
+// SourceFromList builds a Source from the items of list. The items are not
+// copied; the renderer deep copies objects when they are rendered.
+func SourceFromList(list *unstructured.UnstructuredList) Source {
+	if list == nil {
+		return Source{}
+	}
+
+	return Source{Objects: list.Items}
+}
+
+// MustSourceFromObjects is like SourceFromObjects but panics on error.
+// It is intended for test fixtures.
+func MustSourceFromObjects(objs ...runtime.Object) Source {
+	source, err := SourceFromObjects(objs...)
+	if err != nil {
+		panic(fmt.Sprintf("mem.MustSourceFromObjects: %v", err))
+	}
+
+	return source
+}
+
+func configMapToUnstructured(cm *corev1.ConfigMap) (map[string]any, bool) {
+	content, ok := typeAndObjectMeta(cm.TypeMeta, &cm.ObjectMeta)
+	if !ok {
+		return nil, false
+	}
+
+	setStringMap(content, "data", cm.Data)
+	setBytesMap(content, "binaryData", cm.BinaryData)
+
+	if cm.Immutable != nil {
+		content["immutable"] = *cm.Immutable
+	}
+
+	return content, true
+}
+
+func secretToUnstructured(s *corev1.Secret) (map[string]any, bool) {
+	content, ok := typeAndObjectMeta(s.TypeMeta, &s.ObjectMeta)
+	if !ok {
+		return nil, false
+	}
+
+	setBytesMap(content, "data", s.Data)
+	setStringMap(content, "stringData", s.StringData)
+
+	if s.Type != "" {
+		content["type"] = string(s.Type)
+	}
+
+	if s.Immutable != nil {
+		content["immutable"] = *s.Immutable
+	}
+
+	return content, true
+}
+
+func namespaceToUnstructured(ns *corev1.Namespace) (map[string]any, bool) {
+	if len(ns.Status.Conditions) > 0 {
+		return nil, false
+	}
+
+	content, ok := typeAndObjectMeta(ns.TypeMeta, &ns.ObjectMeta)
+	if !ok {
+		return nil, false
+	}
+
+	spec := make(map[string]any)
+	if len(ns.Spec.Finalizers) > 0 {
+		finalizers := make([]any, len(ns.Spec.Finalizers))
+		for i, f := range ns.Spec.Finalizers {
+			finalizers[i] = string(f)
+		}
+
+		spec["finalizers"] = finalizers
+	}
+
+	status := make(map[string]any)
+	if ns.Status.Phase != "" {
+		status["phase"] = string(ns.Status.Phase)
+	}
+
+	content["spec"] = spec
+	content["status"] = status
+
+	return content, true
+}
+
+// typeAndObjectMeta converts TypeMeta and the commonly used ObjectMeta fields.
+// It reports false if the metadata uses any field it does not handle, in which
+// case the caller must fall back to the default converter.
+func typeAndObjectMeta(tm metav1.TypeMeta, om *metav1.ObjectMeta) (map[string]any, bool) {
+	meta, ok := objectMeta(om)
+	if !ok {
+		return nil, false
+	}
+
+	content := make(map[string]any, 4)
+	if tm.APIVersion != "" {
+		content["apiVersion"] = tm.APIVersion
+	}
+
+	if tm.Kind != "" {
+		content["kind"] = tm.Kind
+	}
+
+	content["metadata"] = meta
+
+	return content, true
+}
+
+// objectMeta converts the commonly used ObjectMeta fields, reporting false like
+// typeAndObjectMeta.
+func objectMeta(om *metav1.ObjectMeta) (map[string]any, bool) {
+	if !om.CreationTimestamp.IsZero() ||
+		om.DeletionTimestamp != nil ||
+		om.DeletionGracePeriodSeconds != nil ||
+		om.SelfLink != "" ||
+		len(om.OwnerReferences) > 0 ||
+		len(om.Finalizers) > 0 ||
+		len(om.ManagedFields) > 0 {
+		return nil, false
+	}
+
+	meta := make(map[string]any)
+	setString(meta, "name", om.Name)
+	setString(meta, "generateName", om.GenerateName)
+	setString(meta, "namespace", om.Namespace)
+	setString(meta, "uid", string(om.UID))
+	setString(meta, "resourceVersion", om.ResourceVersion)
+
+	if om.Generation != 0 {
+		meta["generation"] = om.Generation
+	}
+
+	setStringMap(meta, "labels", om.Labels)
+	setStringMap(meta, "annotations", om.Annotations)
+
+	return meta, true
+}
+
+func setString(target map[string]any, key string, value string) {
+	if value != "" {
+		target[key] = value
+	}
+}
+
+func setStringMap(target map[string]any, key string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+
+	out := make(map[string]any, len(values))
+	for k, v := range values {
+		out[k] = v
+	}
+
+	target[key] = out
+}
+
+// setBytesMap mirrors encoding/json, which the default converter follows:
+// byte slices become base64 strings and nil slices become nil.
+func setBytesMap(target map[string]any, key string, values map[string][]byte) {
+	if len(values) == 0 {
+		return
+	}
+
+	out := make(map[string]any, len(values))
+	for k, v := range values {
+		if v == nil {
+			out[k] = nil
+
+			continue
+		}
+
+		out[k] = base64.StdEncoding.EncodeToString(v)
+	}
+
+	target[key] = out
+}
@@ -0,0 +1,372 @@
+package mem_test
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func conversionFixtures() map[string]runtime.Object {
+	meta := metav1.ObjectMeta{
+		Name:            "test",
+		Namespace:       "default",
+		GenerateName:    "test-",
+		UID:             "1234",
+		ResourceVersion: "42",
+		Generation:      7,
+		Labels:          map[string]string{"app": "test"},
+		Annotations:     map[string]string{"note": "value"},
+	}
+
+	podSpec := conversionPodSpec()
+	selector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "web"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"front"}},
+			{Key: "canary", Operator: metav1.LabelSelectorOpDoesNotExist},
+		},
+	}
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec:       podSpec,
+	}
+
+	return map[string]runtime.Object{
+		"empty configmap": &corev1.ConfigMap{},
+		"configmap": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: meta,
+			Data:       map[string]string{"key": "value"},
+			BinaryData: map[string][]byte{"bin": {0, 1, 2}, "nil": nil},
+			Immutable:  ptr.To(false),
+		},
+		"configmap with empty maps": &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}},
+			Data:       map[string]string{},
+		},
+		"configmap with owner references": &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "owned",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: "p", UID: "u"}},
+				Finalizers:      []string{"f"},
+			},
+		},
+		"configmap with timestamp": &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "stamped",
+				CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+			},
+		},
+		"secret": &corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: meta,
+			Data:       map[string][]byte{"password": []byte("hunter2"), "empty": {}},
+			StringData: map[string]string{"user": "admin"},
+			Type:       corev1.SecretTypeOpaque,
+			Immutable:  ptr.To(true),
+		},
+		"namespace": &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{"team": "a"}},
+			Spec:       corev1.NamespaceSpec{Finalizers: []corev1.FinalizerName{corev1.FinalizerKubernetes}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		"namespace with conditions": &corev1.Namespace{
+			Status: corev1.NamespaceStatus{
+				Conditions: []corev1.NamespaceCondition{{Type: "X", Status: corev1.ConditionTrue}},
+			},
+		},
+		"empty pod": &corev1.Pod{},
+		"pod": &corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: meta,
+			Spec:       podSpec,
+		},
+		"pod with probes": &corev1.Pod{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:          "web",
+				LivenessProbe: &corev1.Probe{InitialDelaySeconds: 5},
+			}}},
+		},
+		"pod with status": &corev1.Pod{
+			Spec:   corev1.PodSpec{Containers: []corev1.Container{}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		"pod with env from field": &corev1.Pod{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "web",
+				Env: []corev1.EnvVar{{
+					Name:      "NODE",
+					ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}},
+				}},
+			}}},
+		},
+		"pod with host path volume": &corev1.Pod{
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         "host",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var"}},
+			}}},
+		},
+		"empty deployment": &appsv1.Deployment{},
+		"deployment": &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: meta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To[int32](3),
+				Selector: selector,
+				Template: template,
+				Strategy: appsv1.DeploymentStrategy{
+					Type: appsv1.RollingUpdateDeploymentStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateDeployment{
+						MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+						MaxSurge:       ptr.To(intstr.FromString("25%")),
+					},
+				},
+				MinReadySeconds:         10,
+				RevisionHistoryLimit:    ptr.To[int32](5),
+				Paused:                  true,
+				ProgressDeadlineSeconds: ptr.To[int32](600),
+			},
+		},
+		"deployment with affinity": &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Selector: selector,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Affinity: &corev1.Affinity{}}},
+			},
+		},
+		"deployment with status": &appsv1.Deployment{
+			Status: appsv1.DeploymentStatus{Replicas: 1},
+		},
+		"empty statefulset": &appsv1.StatefulSet{},
+		"statefulset": &appsv1.StatefulSet{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+			ObjectMeta: meta,
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:            ptr.To[int32](2),
+				Selector:            selector,
+				Template:            template,
+				ServiceName:         "db",
+				PodManagementPolicy: appsv1.ParallelPodManagement,
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+					Type: appsv1.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+						Partition:      ptr.To[int32](1),
+						MaxUnavailable: ptr.To(intstr.FromInt32(2)),
+					},
+				},
+				RevisionHistoryLimit: ptr.To[int32](3),
+				MinReadySeconds:      4,
+			},
+		},
+		"statefulset with volume claim templates": &appsv1.StatefulSet{
+			Spec: appsv1.StatefulSetSpec{
+				Selector:             selector,
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+			},
+		},
+		"empty daemonset": &appsv1.DaemonSet{},
+		"daemonset": &appsv1.DaemonSet{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+			ObjectMeta: meta,
+			Spec: appsv1.DaemonSetSpec{
+				Selector: selector,
+				Template: template,
+				UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
+					Type: appsv1.RollingUpdateDaemonSetStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+						MaxUnavailable: ptr.To(intstr.FromString("10%")),
+						MaxSurge:       ptr.To(intstr.FromInt32(0)),
+					},
+				},
+				MinReadySeconds:      1,
+				RevisionHistoryLimit: ptr.To[int32](2),
+			},
+		},
+		"daemonset with timestamped template": &appsv1.DaemonSet{
+			Spec: appsv1.DaemonSetSpec{
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+				}},
+			},
+		},
+	}
+}
+
+// conversionPodSpec uses every pod spec field that ToUnstructured converts
+// without the default converter.
+func conversionPodSpec() corev1.PodSpec {
+	container := corev1.Container{
+		Name:       "web",
+		Image:      "nginx:1.27",
+		Command:    []string{"nginx"},
+		Args:       []string{"-g", "daemon off;"},
+		WorkingDir: "/srv",
+		Ports: []corev1.ContainerPort{
+			{Name: "http", ContainerPort: 80, Protocol: corev1.ProtocolTCP},
+			{ContainerPort: 443, HostPort: 8443, HostIP: "0.0.0.0"},
+		},
+		Env: []corev1.EnvVar{{Name: "MODE", Value: "prod"}, {Name: "EMPTY"}},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "config", MountPath: "/etc/nginx", ReadOnly: true, SubPath: "nginx.conf"},
+			{Name: "cache", MountPath: "/cache", SubPathExpr: "$(POD)"},
+		},
+		TerminationMessagePath:   "/dev/termination-log",
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		ImagePullPolicy:          corev1.PullIfNotPresent,
+	}
+
+	return corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "nginx"},
+				Items:                []corev1.KeyToPath{{Key: "conf", Path: "nginx.conf", Mode: ptr.To[int32](0o400)}},
+				DefaultMode:          ptr.To[int32](0o644),
+				Optional:             ptr.To(false),
+			}}},
+			{Name: "certs", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: "tls",
+				Optional:   ptr.To(true),
+			}}},
+			{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium:    corev1.StorageMediumMemory,
+				SizeLimit: ptr.To(resource.MustParse("1Gi")),
+			}}},
+			{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: "data",
+				ReadOnly:  true,
+			}}},
+		},
+		InitContainers:                []corev1.Container{{Name: "init", Image: "busybox"}},
+		Containers:                    []corev1.Container{container},
+		RestartPolicy:                 corev1.RestartPolicyAlways,
+		TerminationGracePeriodSeconds: ptr.To[int64](30),
+		ActiveDeadlineSeconds:         ptr.To[int64](600),
+		DNSPolicy:                     corev1.DNSClusterFirst,
+		NodeSelector:                  map[string]string{"kubernetes.io/os": "linux"},
+		ServiceAccountName:            "web",
+		DeprecatedServiceAccount:      "web",
+		AutomountServiceAccountToken:  ptr.To(false),
+		NodeName:                      "node-1",
+		HostNetwork:                   true,
+		HostPID:                       true,
+		HostIPC:                       true,
+		ImagePullSecrets:              []corev1.LocalObjectReference{{Name: "registry"}},
+		Hostname:                      "web",
+		Subdomain:                     "svc",
+		SchedulerName:                 "default-scheduler",
+		Tolerations: []corev1.Toleration{{
+			Key:               "node.kubernetes.io/not-ready",
+			Operator:          corev1.TolerationOpExists,
+			Effect:            corev1.TaintEffectNoExecute,
+			TolerationSeconds: ptr.To[int64](300),
+		}, {Key: "dedicated", Value: "web"}},
+		PriorityClassName:  "high",
+		Priority:           ptr.To[int32](1000),
+		EnableServiceLinks: ptr.To(false),
+	}
+}
+
+func TestToUnstructured(t *testing.T) {
+
+	for name, obj := range conversionFixtures() {
+		t.Run("should match the default converter for "+name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			expected, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			actual, err := mem.ToUnstructured(obj)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(actual.Object).To(Equal(expected))
+		})
+	}
+
+	t.Run("should deep copy unstructured input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		in := &unstructured.Unstructured{Object: map[string]any{"kind": "X", "spec": map[string]any{"a": "b"}}}
+
+		out, err := mem.ToUnstructured(in)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		in.Object["spec"].(map[string]any)["a"] = "changed"
+		g.Expect(out.Object["spec"]).To(HaveKeyWithValue("a", "b"))
+	})
+
+	t.Run("should reject nil objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.ToUnstructured(nil)
+		g.Expect(err).To(MatchError(mem.ErrObjectNil))
+
+		_, err = mem.ToUnstructured((*corev1.ConfigMap)(nil))
+		g.Expect(err).To(MatchError(mem.ErrObjectNil))
+	})
+}
+
+func TestSourceFromObjects(t *testing.T) {
+
+	t.Run("should convert objects in order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		fixtures := conversionFixtures()
+		source, err := mem.SourceFromObjects(fixtures["configmap"], fixtures["pod"])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Objects).To(HaveLen(2))
+		g.Expect(source.Objects[0].GetKind()).To(Equal("ConfigMap"))
+		g.Expect(source.Objects[1].GetKind()).To(Equal("Pod"))
+	})
+
+	t.Run("should report the index of invalid objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.SourceFromObjects(&corev1.ConfigMap{}, nil)
+		g.Expect(err).To(MatchError(mem.ErrObjectNil))
+		g.Expect(err).To(MatchError(ContainSubstring("index 1")))
+
+		g.Expect(func() { mem.MustSourceFromObjects(nil) }).To(Panic())
+	})
+}
+
+func BenchmarkToUnstructured(b *testing.B) {
+	fixtures := conversionFixtures()
+
+	for _, name := range []string{"configmap", "secret", "namespace", "pod", "deployment", "statefulset", "daemonset"} {
+		obj := fixtures[name]
+
+		b.Run(name+"/default", func(b *testing.B) {
+			b.ReportAllocs()
+
+			for b.Loop() {
+				_, _ = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			}
+		})
+
+		b.Run(name+"/mem", func(b *testing.B) {
+			b.ReportAllocs()
+
+			for b.Loop() {
+				_, _ = mem.ToUnstructured(obj)
+			}
+		})
+	}
+}
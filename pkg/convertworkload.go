@@ -0,0 +1,605 @@
+package mem
+
+import (
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// onlyHandled reports whether v sets no field besides those that clear resets.
+// Converters check the fields they do not handle this way rather than by
+// listing them, so that fields added to the API types later make objects fall
+// back to the default converter instead of being dropped.
+func onlyHandled[T any](v T, clear func(*T)) bool {
+	clear(&v)
+
+	return reflect.ValueOf(&v).Elem().IsZero()
+}
+
+func podToUnstructured(p *corev1.Pod) (map[string]any, bool) {
+	if !reflect.ValueOf(&p.Status).Elem().IsZero() {
+		return nil, false
+	}
+
+	content, ok := typeAndObjectMeta(p.TypeMeta, &p.ObjectMeta)
+	if !ok {
+		return nil, false
+	}
+
+	spec, ok := podSpecToUnstructured(&p.Spec)
+	if !ok {
+		return nil, false
+	}
+
+	content["spec"] = spec
+	content["status"] = map[string]any{}
+
+	return content, true
+}
+
+func deploymentToUnstructured(d *appsv1.Deployment) (map[string]any, bool) {
+	if !reflect.ValueOf(&d.Status).Elem().IsZero() {
+		return nil, false
+	}
+
+	ok := onlyHandled(d.Spec, func(s *appsv1.DeploymentSpec) {
+		s.Replicas = nil
+		s.Selector = nil
+		s.Template = corev1.PodTemplateSpec{}
+		s.Strategy = appsv1.DeploymentStrategy{}
+		s.MinReadySeconds = 0
+		s.RevisionHistoryLimit = nil
+		s.Paused = false
+		s.ProgressDeadlineSeconds = nil
+	})
+	if !ok {
+		return nil, false
+	}
+
+	content, ok := typeAndObjectMeta(d.TypeMeta, &d.ObjectMeta)
+	if !ok {
+		return nil, false
+	}
+
+	template, ok := podTemplateToUnstructured(&d.Spec.Template)
+	if !ok {
+		return nil, false
+	}
+
+	strategy := make(map[string]any)
+	setString(strategy, "type", string(d.Spec.Strategy.Type))
+
+	if rolling := d.Spec.Strategy.RollingUpdate; rolling != nil {
+		update := make(map[string]any)
+		setIntOrString(update, "maxUnavailable", rolling.MaxUnavailable)
+		setIntOrString(update, "maxSurge", rolling.MaxSurge)
+		strategy["rollingUpdate"] = update
+	}
+
+	spec := map[string]any{
+		"selector": labelSelectorToUnstructured(d.Spec.Selector),
+		"template": template,
+		"strategy": strategy,
+	}
+	setInt32(spec, "replicas", d.Spec.Replicas)
+	setInt64(spec, "minReadySeconds", int64(d.Spec.MinReadySeconds))
+	setInt32(spec, "revisionHistoryLimit", d.Spec.RevisionHistoryLimit)
+	setInt32(spec, "progressDeadlineSeconds", d.Spec.ProgressDeadlineSeconds)
+
+	if d.Spec.Paused {
+		spec["paused"] = true
+	}
+
+	content["spec"] = spec
+	content["status"] = map[string]any{}
+
+	return content, true
+}
+
+func statefulSetToUnstructured(s *appsv1.StatefulSet) (map[string]any, bool) {
+	if !reflect.ValueOf(&s.Status).Elem().IsZero() {
+		return nil, false
+	}
+
+	ok := onlyHandled(s.Spec, func(s *appsv1.StatefulSetSpec) {
+		s.Replicas = nil
+		s.Selector = nil
+		s.Template = corev1.PodTemplateSpec{}
+		s.ServiceName = ""
+		s.PodManagementPolicy = ""
+		s.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{}
+		s.RevisionHistoryLimit = nil
+		s.MinReadySeconds = 0
+	})
+	if !ok {
+		return nil, false
+	}
+
+	content, ok := typeAndObjectMeta(s.TypeMeta, &s.ObjectMeta)
+	if !ok {
+		return nil, false
+	}
+
+	template, ok := podTemplateToUnstructured(&s.Spec.Template)
+	if !ok {
+		return nil, false
+	}
+
+	strategy := make(map[string]any)
+	setString(strategy, "type", string(s.Spec.UpdateStrategy.Type))
+
+	if rolling := s.Spec.UpdateStrategy.RollingUpdate; rolling != nil {
+		update := make(map[string]any)
+		setInt32(update, "partition", rolling.Partition)
+		setIntOrString(update, "maxUnavailable", rolling.MaxUnavailable)
+		strategy["rollingUpdate"] = update
+	}
+
+	spec := map[string]any{
+		"selector":       labelSelectorToUnstructured(s.Spec.Selector),
+		"template":       template,
+		"serviceName":    s.Spec.ServiceName,
+		"updateStrategy": strategy,
+	}
+	setInt32(spec, "replicas", s.Spec.Replicas)
+	setString(spec, "podManagementPolicy", string(s.Spec.PodManagementPolicy))
+	setInt32(spec, "revisionHistoryLimit", s.Spec.RevisionHistoryLimit)
+	setInt64(spec, "minReadySeconds", int64(s.Spec.MinReadySeconds))
+
+	content["spec"] = spec
+	// The replica counts of the status have no omitempty.
+	content["status"] = map[string]any{"replicas": int64(0), "availableReplicas": int64(0)}
+
+	return content, true
+}
+
+func daemonSetToUnstructured(d *appsv1.DaemonSet) (map[string]any, bool) {
+	if !reflect.ValueOf(&d.Status).Elem().IsZero() {
+		return nil, false
+	}
+
+	ok := onlyHandled(d.Spec, func(s *appsv1.DaemonSetSpec) {
+		s.Selector = nil
+		s.Template = corev1.PodTemplateSpec{}
+		s.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{}
+		s.MinReadySeconds = 0
+		s.RevisionHistoryLimit = nil
+	})
+	if !ok {
+		return nil, false
+	}
+
+	content, ok := typeAndObjectMeta(d.TypeMeta, &d.ObjectMeta)
+	if !ok {
+		return nil, false
+	}
+
+	template, ok := podTemplateToUnstructured(&d.Spec.Template)
+	if !ok {
+		return nil, false
+	}
+
+	strategy := make(map[string]any)
+	setString(strategy, "type", string(d.Spec.UpdateStrategy.Type))
+
+	if rolling := d.Spec.UpdateStrategy.RollingUpdate; rolling != nil {
+		update := make(map[string]any)
+		setIntOrString(update, "maxUnavailable", rolling.MaxUnavailable)
+		setIntOrString(update, "maxSurge", rolling.MaxSurge)
+		strategy["rollingUpdate"] = update
+	}
+
+	spec := map[string]any{
+		"selector":       labelSelectorToUnstructured(d.Spec.Selector),
+		"template":       template,
+		"updateStrategy": strategy,
+	}
+	setInt64(spec, "minReadySeconds", int64(d.Spec.MinReadySeconds))
+	setInt32(spec, "revisionHistoryLimit", d.Spec.RevisionHistoryLimit)
+
+	content["spec"] = spec
+	// The scheduling counts of the status have no omitempty.
+	content["status"] = map[string]any{
+		"currentNumberScheduled": int64(0),
+		"numberMisscheduled":     int64(0),
+		"desiredNumberScheduled": int64(0),
+		"numberReady":            int64(0),
+	}
+
+	return content, true
+}
+
+func podTemplateToUnstructured(t *corev1.PodTemplateSpec) (map[string]any, bool) {
+	meta, ok := objectMeta(&t.ObjectMeta)
+	if !ok {
+		return nil, false
+	}
+
+	spec, ok := podSpecToUnstructured(&t.Spec)
+	if !ok {
+		return nil, false
+	}
+
+	return map[string]any{"metadata": meta, "spec": spec}, true
+}
+
+// podSpecToUnstructured converts the pod spec fields that most manifests use.
+// Security contexts, affinity, probes, and the other fields it does not
+// handle make it report false.
+func podSpecToUnstructured(s *corev1.PodSpec) (map[string]any, bool) {
+	ok := onlyHandled(*s, func(s *corev1.PodSpec) {
+		s.Volumes = nil
+		s.InitContainers = nil
+		s.Containers = nil
+		s.RestartPolicy = ""
+		s.TerminationGracePeriodSeconds = nil
+		s.ActiveDeadlineSeconds = nil
+		s.DNSPolicy = ""
+		s.NodeSelector = nil
+		s.ServiceAccountName = ""
+		s.DeprecatedServiceAccount = ""
+		s.AutomountServiceAccountToken = nil
+		s.NodeName = ""
+		s.HostNetwork = false
+		s.HostPID = false
+		s.HostIPC = false
+		s.ImagePullSecrets = nil
+		s.Hostname = ""
+		s.Subdomain = ""
+		s.SchedulerName = ""
+		s.Tolerations = nil
+		s.PriorityClassName = ""
+		s.Priority = nil
+		s.EnableServiceLinks = nil
+	})
+	if !ok {
+		return nil, false
+	}
+
+	out := make(map[string]any)
+
+	if len(s.Volumes) > 0 {
+		volumes := make([]any, len(s.Volumes))
+		for i := range s.Volumes {
+			if volumes[i], ok = volumeToUnstructured(&s.Volumes[i]); !ok {
+				return nil, false
+			}
+		}
+
+		out["volumes"] = volumes
+	}
+
+	if len(s.InitContainers) > 0 {
+		if out["initContainers"], ok = containersToUnstructured(s.InitContainers); !ok {
+			return nil, false
+		}
+	}
+
+	// containers has no omitempty, so a nil list is kept as nil.
+	out["containers"] = nil
+	if s.Containers != nil {
+		if out["containers"], ok = containersToUnstructured(s.Containers); !ok {
+			return nil, false
+		}
+	}
+
+	setString(out, "restartPolicy", string(s.RestartPolicy))
+	setInt64Ptr(out, "terminationGracePeriodSeconds", s.TerminationGracePeriodSeconds)
+	setInt64Ptr(out, "activeDeadlineSeconds", s.ActiveDeadlineSeconds)
+	setString(out, "dnsPolicy", string(s.DNSPolicy))
+	setStringMap(out, "nodeSelector", s.NodeSelector)
+	setString(out, "serviceAccountName", s.ServiceAccountName)
+	setString(out, "serviceAccount", s.DeprecatedServiceAccount)
+	setBool(out, "automountServiceAccountToken", s.AutomountServiceAccountToken)
+	setString(out, "nodeName", s.NodeName)
+	setTrue(out, "hostNetwork", s.HostNetwork)
+	setTrue(out, "hostPID", s.HostPID)
+	setTrue(out, "hostIPC", s.HostIPC)
+
+	if len(s.ImagePullSecrets) > 0 {
+		secrets := make([]any, len(s.ImagePullSecrets))
+		for i, secret := range s.ImagePullSecrets {
+			ref := make(map[string]any)
+			setString(ref, "name", secret.Name)
+			secrets[i] = ref
+		}
+
+		out["imagePullSecrets"] = secrets
+	}
+
+	setString(out, "hostname", s.Hostname)
+	setString(out, "subdomain", s.Subdomain)
+	setString(out, "schedulerName", s.SchedulerName)
+
+	if len(s.Tolerations) > 0 {
+		tolerations := make([]any, len(s.Tolerations))
+		for i, t := range s.Tolerations {
+			toleration := make(map[string]any)
+			setString(toleration, "key", t.Key)
+			setString(toleration, "operator", string(t.Operator))
+			setString(toleration, "value", t.Value)
+			setString(toleration, "effect", string(t.Effect))
+			setInt64Ptr(toleration, "tolerationSeconds", t.TolerationSeconds)
+			tolerations[i] = toleration
+		}
+
+		out["tolerations"] = tolerations
+	}
+
+	setString(out, "priorityClassName", s.PriorityClassName)
+	setInt32(out, "priority", s.Priority)
+	setBool(out, "enableServiceLinks", s.EnableServiceLinks)
+
+	return out, true
+}
+
+func containersToUnstructured(containers []corev1.Container) ([]any, bool) {
+	out := make([]any, len(containers))
+	for i := range containers {
+		container, ok := containerToUnstructured(&containers[i])
+		if !ok {
+			return nil, false
+		}
+
+		out[i] = container
+	}
+
+	return out, true
+}
+
+func containerToUnstructured(c *corev1.Container) (map[string]any, bool) {
+	ok := onlyHandled(*c, func(c *corev1.Container) {
+		c.Name = ""
+		c.Image = ""
+		c.Command = nil
+		c.Args = nil
+		c.WorkingDir = ""
+		c.Ports = nil
+		c.Env = nil
+		c.Resources.Limits = nil
+		c.Resources.Requests = nil
+		c.VolumeMounts = nil
+		c.TerminationMessagePath = ""
+		c.TerminationMessagePolicy = ""
+		c.ImagePullPolicy = ""
+	})
+	if !ok {
+		return nil, false
+	}
+
+	out := map[string]any{"name": c.Name}
+	setString(out, "image", c.Image)
+	setStrings(out, "command", c.Command)
+	setStrings(out, "args", c.Args)
+	setString(out, "workingDir", c.WorkingDir)
+
+	if len(c.Ports) > 0 {
+		ports := make([]any, len(c.Ports))
+		for i, p := range c.Ports {
+			port := map[string]any{"containerPort": int64(p.ContainerPort)}
+			setString(port, "name", p.Name)
+			setInt64(port, "hostPort", int64(p.HostPort))
+			setString(port, "protocol", string(p.Protocol))
+			setString(port, "hostIP", p.HostIP)
+			ports[i] = port
+		}
+
+		out["ports"] = ports
+	}
+
+	if len(c.Env) > 0 {
+		env := make([]any, len(c.Env))
+		for i, e := range c.Env {
+			if e.ValueFrom != nil {
+				return nil, false
+			}
+
+			variable := map[string]any{"name": e.Name}
+			setString(variable, "value", e.Value)
+			env[i] = variable
+		}
+
+		out["env"] = env
+	}
+
+	// resources has omitempty, but the converter never omits structs.
+	resources := make(map[string]any)
+	setResourceList(resources, "limits", c.Resources.Limits)
+	setResourceList(resources, "requests", c.Resources.Requests)
+	out["resources"] = resources
+
+	if len(c.VolumeMounts) > 0 {
+		mounts := make([]any, len(c.VolumeMounts))
+		for i, m := range c.VolumeMounts {
+			if m.RecursiveReadOnly != nil || m.MountPropagation != nil {
+				return nil, false
+			}
+
+			mount := map[string]any{"name": m.Name, "mountPath": m.MountPath}
+			setTrue(mount, "readOnly", m.ReadOnly)
+			setString(mount, "subPath", m.SubPath)
+			setString(mount, "subPathExpr", m.SubPathExpr)
+			mounts[i] = mount
+		}
+
+		out["volumeMounts"] = mounts
+	}
+
+	setString(out, "terminationMessagePath", c.TerminationMessagePath)
+	setString(out, "terminationMessagePolicy", string(c.TerminationMessagePolicy))
+	setString(out, "imagePullPolicy", string(c.ImagePullPolicy))
+
+	return out, true
+}
+
+// volumeToUnstructured converts volumes backed by a ConfigMap, Secret,
+// emptyDir, or PersistentVolumeClaim.
+func volumeToUnstructured(v *corev1.Volume) (map[string]any, bool) {
+	ok := onlyHandled(v.VolumeSource, func(s *corev1.VolumeSource) {
+		s.ConfigMap = nil
+		s.Secret = nil
+		s.EmptyDir = nil
+		s.PersistentVolumeClaim = nil
+	})
+	if !ok {
+		return nil, false
+	}
+
+	out := map[string]any{"name": v.Name}
+
+	if cm := v.ConfigMap; cm != nil {
+		source := make(map[string]any)
+		setString(source, "name", cm.Name)
+		setKeyToPaths(source, cm.Items)
+		setInt32(source, "defaultMode", cm.DefaultMode)
+		setBool(source, "optional", cm.Optional)
+		out["configMap"] = source
+	}
+
+	if secret := v.Secret; secret != nil {
+		source := make(map[string]any)
+		setString(source, "secretName", secret.SecretName)
+		setKeyToPaths(source, secret.Items)
+		setInt32(source, "defaultMode", secret.DefaultMode)
+		setBool(source, "optional", secret.Optional)
+		out["secret"] = source
+	}
+
+	if dir := v.EmptyDir; dir != nil {
+		source := make(map[string]any)
+		setString(source, "medium", string(dir.Medium))
+
+		if dir.SizeLimit != nil {
+			source["sizeLimit"] = quantityString(*dir.SizeLimit)
+		}
+
+		out["emptyDir"] = source
+	}
+
+	if claim := v.PersistentVolumeClaim; claim != nil {
+		source := map[string]any{"claimName": claim.ClaimName}
+		setTrue(source, "readOnly", claim.ReadOnly)
+		out["persistentVolumeClaim"] = source
+	}
+
+	return out, true
+}
+
+// labelSelectorToUnstructured converts selectors, whose field has no
+// omitempty, so a nil selector is kept as nil.
+func labelSelectorToUnstructured(s *metav1.LabelSelector) any {
+	if s == nil {
+		return nil
+	}
+
+	out := make(map[string]any)
+	setStringMap(out, "matchLabels", s.MatchLabels)
+
+	if len(s.MatchExpressions) > 0 {
+		expressions := make([]any, len(s.MatchExpressions))
+		for i, e := range s.MatchExpressions {
+			expression := map[string]any{"key": e.Key, "operator": string(e.Operator)}
+			setStrings(expression, "values", e.Values)
+			expressions[i] = expression
+		}
+
+		out["matchExpressions"] = expressions
+	}
+
+	return out
+}
+
+func setKeyToPaths(target map[string]any, items []corev1.KeyToPath) {
+	if len(items) == 0 {
+		return
+	}
+
+	out := make([]any, len(items))
+	for i, item := range items {
+		path := map[string]any{"key": item.Key, "path": item.Path}
+		setInt32(path, "mode", item.Mode)
+		out[i] = path
+	}
+
+	target["items"] = out
+}
+
+func setResourceList(target map[string]any, key string, values corev1.ResourceList) {
+	if len(values) == 0 {
+		return
+	}
+
+	out := make(map[string]any, len(values))
+	for name, quantity := range values {
+		out[string(name)] = quantityString(quantity)
+	}
+
+	target[key] = out
+}
+
+// quantityString takes its argument by value because String caches its result
+// in the receiver.
+func quantityString(q resource.Quantity) string {
+	return q.String()
+}
+
+func setStrings(target map[string]any, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+
+	target[key] = out
+}
+
+// setIntOrString mirrors the JSON encoding of IntOrString, which the default
+// converter goes through.
+func setIntOrString(target map[string]any, key string, value *intstr.IntOrString) {
+	if value == nil {
+		return
+	}
+
+	if value.Type == intstr.String {
+		target[key] = value.StrVal
+	} else {
+		target[key] = int64(value.IntVal)
+	}
+}
+
+func setInt32(target map[string]any, key string, value *int32) {
+	if value != nil {
+		target[key] = int64(*value)
+	}
+}
+
+func setInt64Ptr(target map[string]any, key string, value *int64) {
+	if value != nil {
+		target[key] = *value
+	}
+}
+
+func setInt64(target map[string]any, key string, value int64) {
+	if value != 0 {
+		target[key] = value
+	}
+}
+
+func setBool(target map[string]any, key string, value *bool) {
+	if value != nil {
+		target[key] = *value
+	}
+}
+
+func setTrue(target map[string]any, key string, value bool) {
+	if value {
+		target[key] = true
+	}
+}
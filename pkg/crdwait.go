@@ -0,0 +1,117 @@
+package mem
+
+import (
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// AnnotationWaitForCRD is the annotation key, set by
+	// WithCRDWaitAnnotations, naming the rendered CustomResourceDefinition a
+	// custom resource depends on. Appliers must wait for that CRD to be
+	// Established before applying the resource.
+	AnnotationWaitForCRD = "manifests.k8s-manifests-kit/wait-for.crd"
+
+	// AnnotationWaitEstablished is the annotation key, set to "true" by
+	// WithCRDWaitAnnotations, on rendered CustomResourceDefinitions that
+	// rendered custom resources depend on.
+	AnnotationWaitEstablished = "manifests.k8s-manifests-kit/wait.established"
+)
+
+// CRDDependency links a rendered CustomResourceDefinition to the rendered
+// custom resources of the kind it defines.
+type CRDDependency struct {
+	// CRD is the index of the CustomResourceDefinition.
+	CRD int
+
+	// Name is the name of the CustomResourceDefinition.
+	Name string
+
+	// Resources are the indices of the custom resources, in order.
+	Resources []int
+}
+
+// CRDDependencies returns, in the order of the CustomResourceDefinitions,
+// which objects are custom resources of a kind defined by a
+// CustomResourceDefinition in the same set. Appliers apply each CRD, wait for
+// its Established condition, and only then apply its resources. CRDs without
+// resources in the set are not reported, and resources of kinds defined
+// elsewhere are assumed to be installed.
+func CRDDependencies(objects []unstructured.Unstructured) []CRDDependency {
+	definitions := make(map[schema.GroupKind]int)
+	dependencies := make([]CRDDependency, 0)
+
+	for i := range objects {
+		gk, ok := definedKind(objects[i])
+		if !ok {
+			continue
+		}
+
+		if _, defined := definitions[gk]; !defined {
+			definitions[gk] = len(dependencies)
+			dependencies = append(dependencies, CRDDependency{CRD: i, Name: objects[i].GetName()})
+		}
+	}
+
+	for i := range objects {
+		d, ok := definitions[objects[i].GroupVersionKind().GroupKind()]
+		if !ok || isCRD(objects[i]) {
+			continue
+		}
+
+		dependencies[d].Resources = append(dependencies[d].Resources, i)
+	}
+
+	result := dependencies[:0]
+	for _, dependency := range dependencies {
+		if len(dependency.Resources) > 0 {
+			result = append(result, dependency)
+		}
+	}
+
+	return result
+}
+
+// annotateCRDDependencies sets the wait annotations on the CRDs and custom
+// resources reported by CRDDependencies and returns their indices.
+func annotateCRDDependencies(objects []unstructured.Unstructured) []int {
+	changed := make([]int, 0)
+
+	for _, dependency := range CRDDependencies(objects) {
+		k8s.SetAnnotation(&objects[dependency.CRD], AnnotationWaitEstablished, "true")
+		changed = append(changed, dependency.CRD)
+
+		for _, i := range dependency.Resources {
+			k8s.SetAnnotation(&objects[i], AnnotationWaitForCRD, dependency.Name)
+			changed = append(changed, i)
+		}
+	}
+
+	return changed
+}
+
+// isCRD reports whether obj is a CustomResourceDefinition.
+func isCRD(obj unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+
+	return gvk.Kind == "CustomResourceDefinition" && gvk.Group == "apiextensions.k8s.io"
+}
+
+// definedKind returns the group and kind obj defines, if it is a
+// CustomResourceDefinition naming one.
+func definedKind(obj unstructured.Unstructured) (schema.GroupKind, bool) {
+	if !isCRD(obj) {
+		return schema.GroupKind{}, false
+	}
+
+	group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+
+	if kind == "" {
+		return schema.GroupKind{}, false
+	}
+
+	return schema.GroupKind{Group: group, Kind: kind}, true
+}
@@ -0,0 +1,85 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func crdWaitObjects() []unstructured.Unstructured {
+	return []unstructured.Unstructured{
+		composeObject("example.com/v1", "Widget", "app", "first"),
+		scopeCRD("Widget", "Namespaced"),
+		scopeCRD("Gadget", "Namespaced"),
+		composeObject("v1", "ConfigMap", "app", "config"),
+		composeObject("example.com/v1beta1", "Widget", "app", "second"),
+		composeObject("other.io/v1", "Thing", "app", "thing"),
+	}
+}
+
+func TestCRDDependencies(t *testing.T) {
+
+	t.Run("should link CRDs to their rendered resources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.CRDDependencies(crdWaitObjects())).To(Equal([]mem.CRDDependency{{
+			CRD:       1,
+			Name:      "Widgets.example.com",
+			Resources: []int{0, 4},
+		}}))
+	})
+
+	t.Run("should report nothing without CRDs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.CRDDependencies([]unstructured.Unstructured{
+			composeObject("example.com/v1", "Widget", "app", "first"),
+		})).To(BeEmpty())
+	})
+}
+
+func TestCRDWaitAnnotations(t *testing.T) {
+
+	t.Run("should annotate CRDs and dependent resources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: crdWaitObjects()}},
+			mem.WithCRDWaitAnnotations(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationWaitForCRD, "Widgets.example.com"))
+		g.Expect(objects[1].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationWaitEstablished, "true"))
+		g.Expect(objects[4].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationWaitForCRD, "Widgets.example.com"))
+
+		for _, i := range []int{2, 3, 5} {
+			g.Expect(objects[i].GetAnnotations()).ToNot(HaveKey(mem.AnnotationWaitForCRD))
+			g.Expect(objects[i].GetAnnotations()).ToNot(HaveKey(mem.AnnotationWaitEstablished))
+		}
+
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should not annotate by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: crdWaitObjects()}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for i := range objects {
+			g.Expect(objects[i].GetAnnotations()).ToNot(HaveKey(mem.AnnotationWaitForCRD))
+			g.Expect(objects[i].GetAnnotations()).ToNot(HaveKey(mem.AnnotationWaitEstablished))
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package mem
+
+import (
+	"maps"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// copyMetadata copies the top-level fields and the metadata of obj, the parts
+// every render writes, and shares the others with obj.
+func copyMetadata(obj unstructured.Unstructured) unstructured.Unstructured {
+	objCopy := maps.Clone(obj.Object)
+
+	if metadata, ok := objCopy["metadata"]; ok {
+		objCopy["metadata"] = runtime.DeepCopyJSONValue(metadata)
+	}
+
+	return unstructured.Unstructured{Object: objCopy}
+}
+
+// sharesContent reports whether the objects r renders may share content with
+// source objects, because r or one of its merged parts disables WithDeepCopy.
+func (r *Renderer) sharesContent() bool {
+	if r.opts.NoDeepCopy {
+		return true
+	}
+
+	return r.merged != nil && slices.ContainsFunc(r.merged.parts, (*Renderer).sharesContent)
+}
+
+// ownObject deep copies obj in place if it may share content with a source
+// object. Stages call it before writing outside the metadata of obj.
+func (r *Renderer) ownObject(obj *unstructured.Unstructured) {
+	if r.sharesContent() {
+		obj.Object = runtime.DeepCopyJSON(obj.Object)
+	}
+}
+
+// ownPatchTargets calls ownObject on the objects targeted by patches.
+func (r *Renderer) ownPatchTargets(objects []unstructured.Unstructured, patches []Patch) {
+	if r.sharesContent() {
+		deepCopyPatchTargets(objects, patches)
+	}
+}
+
+// deepCopyPatchTargets deep copies the objects targeted by patches in place.
+func deepCopyPatchTargets(objects []unstructured.Unstructured, patches []Patch) {
+	for i := range objects {
+		if slices.ContainsFunc(patches, func(p Patch) bool { return p.Target.Matches(objects[i]) }) {
+			objects[i].Object = runtime.DeepCopyJSON(objects[i].Object)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package mem_test
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func sharedSources() []mem.Source {
+	deployment := composeObject("apps/v1", "Deployment", "app", "web")
+	deployment.Object["spec"] = map[string]any{
+		"replicas": int64(3),
+		"template": map[string]any{"spec": map[string]any{"containers": []any{map[string]any{"name": "web"}}}},
+	}
+
+	cronJob := composeObject("batch/v1", "CronJob", "app", "nightly")
+	cronJob.Object["spec"] = map[string]any{"schedule": "@daily"}
+
+	budget := composeObject("policy/v1beta1", "PodDisruptionBudget", "app", "web")
+	budget.Object["spec"] = map[string]any{"minAvailable": int64(1)}
+
+	config := composeObject("v1", "ConfigMap", "app", "config")
+	config.Object["data"] = map[string]any{"key": "value"}
+
+	override := composeObject("v1", "ConfigMap", "app", "config")
+	override.Object["data"] = map[string]any{"other": "value"}
+
+	return []mem.Source{
+		{Objects: []unstructured.Unstructured{
+			deployment,
+			cronJob,
+			budget,
+			config,
+			webhookConfiguration("ValidatingWebhookConfiguration", "validate",
+				map[string]any{"name": "validate.example.com", "clientConfig": map[string]any{}}),
+		}},
+		{
+			Objects: []unstructured.Unstructured{override},
+			Patches: []mem.Patch{{
+				Target: mem.PatchTarget{Kind: "ConfigMap", Name: "config"},
+				Merge:  map[string]any{"data": map[string]any{"patched": "true"}},
+			}},
+		},
+	}
+}
+
+func TestWithDeepCopy(t *testing.T) {
+
+	opts := []mem.RendererOption{
+		mem.WithSourceAnnotations(true),
+		mem.WithDuplicatePolicy(mem.DuplicateMerge),
+		mem.WithServiceAccountWiring(mem.ServiceAccountWiring{ServiceAccountName: "runner"}),
+		mem.WithCABundlePlaceholder("cGxhY2Vob2xkZXI="),
+		mem.WithScaledToZero(),
+		mem.WithSuspendedCronJobs(),
+		mem.WithKindHandler(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			func(_ context.Context, obj *unstructured.Unstructured) error {
+				return unstructured.SetNestedField(obj.Object, "true", "data", "handled")
+			},
+		),
+		mem.WithGVKRewrite(
+			map[schema.GroupVersionKind]schema.GroupVersionKind{
+				{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}: {
+					Group: "policy", Version: "v1", Kind: "PodDisruptionBudget",
+				},
+			},
+			func(_ schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+				return unstructured.SetNestedField(obj.Object, "AlwaysAllow", "spec", "unhealthyPodEvictionPolicy")
+			},
+		),
+	}
+
+	t.Run("should render like a deep copying renderer", func(t *testing.T) {
+		g := NewWithT(t)
+
+		copying, err := mem.New(sharedSources(), opts...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		sharing, err := mem.New(sharedSources(), append(opts, mem.WithDeepCopy(false))...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		expected, err := copying.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 3 {
+			objects, err := sharing.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(objects).To(Equal(expected))
+		}
+	})
+
+	t.Run("should never modify sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		sources := sharedSources()
+		renderer, err := mem.New(sources, append(opts, mem.WithDeepCopy(false))...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		merged, err := mem.Merge(renderer, renderer, mem.DuplicateMerge, mem.WithKindHandler(
+			schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			func(_ context.Context, obj *unstructured.Unstructured) error {
+				return unstructured.SetNestedField(obj.Object, int64(7), "spec", "minReadySeconds")
+			},
+		))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = merged.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(sources).To(Equal(sharedSources()))
+	})
+
+	t.Run("should share unchanged content with sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config := composeObject("v1", "ConfigMap", "app", "config")
+		config.Object["data"] = map[string]any{"key": "value"}
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{config}}},
+			mem.WithDeepCopy(false),
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()).ToNot(BeEmpty())
+		g.Expect(config.GetAnnotations()).To(BeEmpty())
+
+		// Writing outside metadata breaks the caller's side of the contract,
+		// which is what makes the sharing visible.
+		objects[0].Object["data"].(map[string]any)["key"] = "changed"
+		g.Expect(config.Object["data"]).To(HaveKeyWithValue("key", "changed"))
+	})
+}
+
+func TestRendererOptionsDeepCopy(t *testing.T) {
+	g := NewWithT(t)
+
+	config := composeObject("v1", "ConfigMap", "app", "config")
+	config.Object["data"] = map[string]any{"key": "value"}
+
+	renderer, err := mem.New(
+		[]mem.Source{{Objects: []unstructured.Unstructured{config}}},
+		mem.RendererOptions{SourceAnnotations: true},
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	objects, err := renderer.Process(t.Context(), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(objects).To(HaveLen(1))
+
+	objects[0].Object["data"].(map[string]any)["key"] = "changed"
+	g.Expect(config.Object["data"]).To(HaveKeyWithValue("key", "value"))
+}
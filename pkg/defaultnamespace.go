@@ -0,0 +1,126 @@
+package mem
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// builtinScopes records whether each built-in kind is namespaced, so that
+// the scope of common kinds is known without API discovery.
+var builtinScopes = func() map[schema.GroupKind]bool {
+	namespaced := map[string][]string{
+		"": {
+			"Binding", "ConfigMap", "Endpoints", "Event", "LimitRange", "PersistentVolumeClaim", "Pod",
+			"PodTemplate", "ReplicationController", "ResourceQuota", "Secret", "Service", "ServiceAccount",
+		},
+		"apps":                      {"ControllerRevision", "DaemonSet", "Deployment", "ReplicaSet", "StatefulSet"},
+		"autoscaling":               {"HorizontalPodAutoscaler"},
+		"batch":                     {"CronJob", "Job"},
+		"coordination.k8s.io":       {"Lease"},
+		"discovery.k8s.io":          {"EndpointSlice"},
+		"events.k8s.io":             {"Event"},
+		"extensions":                {"DaemonSet", "Deployment", "Ingress", "NetworkPolicy", "ReplicaSet"},
+		"networking.k8s.io":         {"Ingress", "NetworkPolicy"},
+		"policy":                    {"PodDisruptionBudget"},
+		"rbac.authorization.k8s.io": {"Role", "RoleBinding"},
+		"authorization.k8s.io":      {"LocalSubjectAccessReview"},
+		"storage.k8s.io":            {"CSIStorageCapacity"},
+		"resource.k8s.io":           {"ResourceClaim", "ResourceClaimTemplate"},
+	}
+
+	clusterScoped := map[string][]string{
+		"": {"ComponentStatus", "Namespace", "Node", "PersistentVolume"},
+		"admissionregistration.k8s.io": {
+			"MutatingWebhookConfiguration",
+			"ValidatingAdmissionPolicy",
+			"ValidatingAdmissionPolicyBinding",
+			"ValidatingWebhookConfiguration",
+		},
+		"apiextensions.k8s.io":         {"CustomResourceDefinition"},
+		"apiregistration.k8s.io":       {"APIService"},
+		"authentication.k8s.io":        {"SelfSubjectReview", "TokenReview"},
+		"authorization.k8s.io":         {"SelfSubjectAccessReview", "SelfSubjectRulesReview", "SubjectAccessReview"},
+		"certificates.k8s.io":          {"CertificateSigningRequest", "ClusterTrustBundle"},
+		"extensions":                   {"PodSecurityPolicy"},
+		"flowcontrol.apiserver.k8s.io": {"FlowSchema", "PriorityLevelConfiguration"},
+		"networking.k8s.io":            {"IngressClass", "IPAddress", "ServiceCIDR"},
+		"node.k8s.io":                  {"RuntimeClass"},
+		"policy":                       {"PodSecurityPolicy"},
+		"rbac.authorization.k8s.io":    {"ClusterRole", "ClusterRoleBinding"},
+		"resource.k8s.io":              {"DeviceClass"},
+		"scheduling.k8s.io":            {"PriorityClass"},
+		"storage.k8s.io": {
+			"CSIDriver", "CSINode", "StorageClass", "VolumeAttachment", "VolumeAttributesClass",
+		},
+	}
+
+	scopes := make(map[schema.GroupKind]bool)
+
+	for group, kinds := range namespaced {
+		for _, kind := range kinds {
+			scopes[schema.GroupKind{Group: group, Kind: kind}] = true
+		}
+	}
+
+	for group, kinds := range clusterScoped {
+		for _, kind := range kinds {
+			scopes[schema.GroupKind{Group: group, Kind: kind}] = false
+		}
+	}
+
+	return scopes
+}()
+
+// renderedScopes returns whether each kind known without discovery is
+// namespaced: the built-in kinds and those defined by CustomResourceDefinitions
+// in objects.
+func renderedScopes(objects []unstructured.Unstructured) map[schema.GroupKind]bool {
+	scopes := crdScopes(objects)
+
+	for gk, isNamespaced := range builtinScopes {
+		scopes[gk] = isNamespaced
+	}
+
+	return scopes
+}
+
+// validateNamespaceName checks that namespace can name a namespace.
+func validateNamespaceName(namespace string) error {
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return fmt.Errorf("%q: %s", namespace, errs[0])
+	}
+
+	return nil
+}
+
+// applyDefaultNamespace places the namespaced objects that have no namespace
+// in the default namespace and returns their indices. Cluster-scoped objects
+// are left alone; a kind with no known scope is an error wrapping
+// ErrUnknownScope.
+func (r *Renderer) applyDefaultNamespace(objects []unstructured.Unstructured) ([]int, error) {
+	scopes := renderedScopes(objects)
+	defaulted := make([]int, 0)
+
+	for i := range objects {
+		if objects[i].GetNamespace() != "" {
+			continue
+		}
+
+		isNamespaced, err := namespacedKind(objects[i].GroupVersionKind(), scopes, r.opts.RESTMapper)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ObjectKeyOf(objects[i]), err)
+		}
+
+		if !isNamespaced {
+			continue
+		}
+
+		objects[i].SetNamespace(r.opts.DefaultNamespace)
+		defaulted = append(defaulted, i)
+	}
+
+	return defaulted, nil
+}
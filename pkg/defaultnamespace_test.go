@@ -0,0 +1,121 @@
+package mem_test
+
+import (
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDefaultNamespace(t *testing.T) {
+
+	render := func(
+		objects []unstructured.Unstructured,
+		opts ...mem.RendererOption,
+	) ([]unstructured.Unstructured, error) {
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return renderer.Process(t.Context(), nil)
+	}
+
+	namespaces := func(objects []unstructured.Unstructured) []string {
+		out := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			out = append(out, obj.GetNamespace())
+		}
+
+		return out
+	}
+
+	t.Run("should place namespaced objects without a namespace", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(
+			[]unstructured.Unstructured{
+				composeObject("apps/v1", "Deployment", "", "web"),
+				composeObject("v1", "ConfigMap", "other", "config"),
+				composeObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "reader"),
+				composeObject("v1", "Namespace", "", "other"),
+			},
+			mem.WithDefaultNamespace("team-a"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(namespaces(objects)).To(Equal([]string{"team-a", "other", "", ""}))
+	})
+
+	t.Run("should resolve custom kinds from rendered CRDs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(
+			[]unstructured.Unstructured{
+				scopeCRD("Widget", "Namespaced"),
+				scopeCRD("Gadget", "Cluster"),
+				composeObject("example.com/v1", "Widget", "", "w"),
+				composeObject("example.com/v1", "Gadget", "", "g"),
+			},
+			mem.WithDefaultNamespace("team-a"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(namespaces(objects)).To(Equal([]string{"", "", "team-a", ""}))
+	})
+
+	t.Run("should resolve other kinds with the REST mapper", func(t *testing.T) {
+		g := NewWithT(t)
+
+		widget := composeObject("example.com/v1", "Widget", "", "w")
+
+		_, err := render([]unstructured.Unstructured{widget}, mem.WithDefaultNamespace("team-a"))
+		g.Expect(err).To(MatchError(mem.ErrUnknownScope))
+		g.Expect(err.Error()).To(ContainSubstring("example.com/v1/Widget//w"))
+
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+			meta.RESTScopeNamespace)
+
+		objects, err := render(
+			[]unstructured.Unstructured{widget},
+			mem.WithDefaultNamespace("team-a"),
+			mem.WithRESTMapper(mapper),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(namespaces(objects)).To(Equal([]string{"team-a"}))
+	})
+
+	t.Run("should rehash defaulted objects and ensure their namespace", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(
+			[]unstructured.Unstructured{composeObject("v1", "ConfigMap", "", "config")},
+			mem.WithDefaultNamespace("team-a"),
+			mem.WithEnsureNamespaces(true),
+			mem.WithContentHash(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"Namespace/team-a", "ConfigMap/config"}))
+
+		expected, err := render(
+			[]unstructured.Unstructured{composeObject("v1", "ConfigMap", "team-a", "config")},
+			mem.WithContentHash(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[1].GetAnnotations()[pkgtypes.AnnotationContentHash]).To(
+			Equal(expected[0].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+	})
+
+	t.Run("should reject invalid namespaces", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithDefaultNamespace("Team_A"))
+		g.Expect(err).To(MatchError(mem.ErrInvalidNamespace))
+	})
+}
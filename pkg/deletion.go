@@ -0,0 +1,68 @@
+package mem
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AnnotationDelete is the annotation key, set to "true" by
+// WithDeletionMarkers, on the objects of a render that must be deleted rather
+// than applied.
+const AnnotationDelete = "manifests.k8s-manifests-kit/delete"
+
+// validateDeletion checks that the i-th deletion of a source identifies a
+// single object.
+func validateDeletion(i int, deletion unstructured.Unstructured) error {
+	if err := checkIdentity(deletion); err != nil {
+		return fmt.Errorf("deletion at index %d: %w", i, err)
+	}
+
+	if deletion.GetName() == "" {
+		return fmt.Errorf("deletion at index %d: %w: name is required", i, ErrMissingIdentity)
+	}
+
+	return nil
+}
+
+// applyDeletions replaces the deletions of trace with copies, one per
+// identity in source order, and removes the objects they identify from
+// objects.
+func (r *Renderer) applyDeletions(objects []unstructured.Unstructured, trace *renderTrace) []unstructured.Unstructured {
+	identity := identityOrDefault(r.opts.IdentityFunc)
+	deleted := make(map[string]struct{}, len(trace.deletions))
+	deletions := make([]unstructured.Unstructured, 0, len(trace.deletions))
+
+	for _, deletion := range trace.deletions {
+		id := identity(deletion)
+		if _, seen := deleted[id]; seen {
+			continue
+		}
+
+		deleted[id] = struct{}{}
+		deletions = append(deletions, *deletion.DeepCopy())
+	}
+
+	trace.deletions = deletions
+
+	return slices.DeleteFunc(objects, func(obj unstructured.Unstructured) bool {
+		_, ok := deleted[identity(obj)]
+
+		return ok
+	})
+}
+
+// deletionMarkers returns copies of deletions carrying AnnotationDelete.
+func deletionMarkers(deletions []unstructured.Unstructured) []unstructured.Unstructured {
+	markers := make([]unstructured.Unstructured, len(deletions))
+
+	for i := range deletions {
+		deletions[i].DeepCopyInto(&markers[i])
+		k8s.SetAnnotation(&markers[i], AnnotationDelete, "true")
+	}
+
+	return markers
+}
@@ -0,0 +1,90 @@
+package mem_test
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func deletionSources() []mem.Source {
+	return []mem.Source{
+		{Objects: []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "app", "kept"),
+			composeObject("v1", "ConfigMap", "app", "retired"),
+		}},
+		{Name: "cleanup", Deletions: []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "app", "retired"),
+			composeObject("apps/v1", "Deployment", "app", "legacy"),
+			composeObject("v1", "ConfigMap", "app", "retired"),
+		}},
+	}
+}
+
+func TestDeletions(t *testing.T) {
+
+	t.Run("should report deletions and remove deleted objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(deletionSources())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(result.View().DeepCopy())).To(Equal([]string{"ConfigMap/kept"}))
+
+		deletions := result.Deletions()
+		g.Expect(names(deletions)).To(Equal([]string{"ConfigMap/retired", "Deployment/legacy"}))
+
+		deletions[0].SetName("changed")
+		g.Expect(result.Deletions()[0].GetName()).To(Equal("retired"))
+	})
+
+	t.Run("should render deletion markers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		sources := deletionSources()
+		renderer, err := mem.New(sources, mem.WithDeletionMarkers(true), mem.WithGenerationAnnotation("3"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/kept", "ConfigMap/retired", "Deployment/legacy"}))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationDelete))
+		g.Expect(objects[1].GetAnnotations()).To(Equal(map[string]string{mem.AnnotationDelete: "true"}))
+		g.Expect(objects[2].GetAnnotations()).To(Equal(map[string]string{mem.AnnotationDelete: "true"}))
+
+		g.Expect(sources[1].Deletions[0].GetAnnotations()).To(BeEmpty())
+	})
+
+	t.Run("should ignore deletions of unselected sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(deletionSources(), mem.WithSourceSelector(
+			func(_ context.Context, source mem.Source) (bool, error) {
+				return source.Name != "cleanup", nil
+			},
+		))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(Equal(2))
+		g.Expect(result.Deletions()).To(BeEmpty())
+	})
+
+	t.Run("should reject deletions without a name", func(t *testing.T) {
+		g := NewWithT(t)
+
+		deletion := composeObject("v1", "Pod", "app", "")
+		deletion.SetGenerateName("worker-")
+
+		_, err := mem.New([]mem.Source{{Deletions: []unstructured.Unstructured{deletion}}})
+		g.Expect(err).To(MatchError(mem.ErrMissingIdentity))
+		g.Expect(err).To(MatchError(ContainSubstring("deletion at index 0")))
+	})
+}
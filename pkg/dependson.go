@@ -0,0 +1,234 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// AnnotationDependsOn is the annotation key of the dependencies of an
+	// object in the format of kpt and Config Sync: a comma-separated list of
+	// "<group>/namespaces/<namespace>/<kind>/<name>" references for namespaced
+	// objects and "<group>/<kind>/<name>" for cluster-scoped ones, with an
+	// empty group for the core group, e.g. "/namespaces/app/ConfigMap/config".
+	AnnotationDependsOn = "config.kubernetes.io/depends-on"
+
+	// AnnotationManifestDependsOn is the annotation key of the dependencies
+	// of an object as a comma-separated list of keys formatted by ObjectKeyOf,
+	// e.g. "v1/ConfigMap/app/config". The version of a key is not compared,
+	// so a dependency matches its object across API versions.
+	AnnotationManifestDependsOn = "manifests.k8s-manifests-kit/depends-on"
+)
+
+// dependencyKey identifies the object a dependency refers to, regardless of
+// its version.
+type dependencyKey struct {
+	schema.GroupKind
+
+	Namespace string
+	Name      string
+}
+
+func (k dependencyKey) String() string {
+	if k.Namespace == "" {
+		return k.GroupKind.String() + " " + k.Name
+	}
+
+	return k.GroupKind.String() + " " + k.Namespace + "/" + k.Name
+}
+
+func dependencyKeyOf(obj unstructured.Unstructured) dependencyKey {
+	return dependencyKey{
+		GroupKind: obj.GroupVersionKind().GroupKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+}
+
+// DependenciesOf returns the objects obj depends on according to its
+// AnnotationDependsOn and AnnotationManifestDependsOn annotations, as keys
+// without a version. It fails with ErrInvalidDependency if a reference is
+// malformed.
+func DependenciesOf(obj unstructured.Unstructured) ([]ObjectKey, error) {
+	annotations := obj.GetAnnotations()
+	dependencies := make([]ObjectKey, 0)
+
+	for _, ref := range splitReferences(annotations[AnnotationDependsOn]) {
+		key, err := parseDependsOnReference(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ObjectKeyOf(obj), err)
+		}
+
+		dependencies = append(dependencies, key)
+	}
+
+	for _, ref := range splitReferences(annotations[AnnotationManifestDependsOn]) {
+		key, err := ParseObjectKey(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w: %w", ObjectKeyOf(obj), ErrInvalidDependency, err)
+		}
+
+		key.Version = ""
+		dependencies = append(dependencies, key)
+	}
+
+	return dependencies, nil
+}
+
+func splitReferences(value string) []string {
+	refs := make([]string, 0)
+
+	for ref := range strings.SplitSeq(value, ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// parseDependsOnReference parses a reference of AnnotationDependsOn.
+func parseDependsOnReference(ref string) (ObjectKey, error) {
+	parts := strings.Split(ref, "/")
+
+	var key ObjectKey
+
+	switch {
+	case len(parts) == 3:
+		key = ObjectKey{GroupVersionKind: schema.GroupVersionKind{Group: parts[0], Kind: parts[1]}, Name: parts[2]}
+	case len(parts) == 5 && parts[1] == "namespaces":
+		key = ObjectKey{
+			GroupVersionKind: schema.GroupVersionKind{Group: parts[0], Kind: parts[3]},
+			Namespace:        parts[2],
+			Name:             parts[4],
+		}
+
+		if key.Namespace == "" {
+			return ObjectKey{}, fmt.Errorf("%w: %q has an empty namespace", ErrInvalidDependency, ref)
+		}
+	default:
+		return ObjectKey{}, fmt.Errorf("%w: %q is neither <group>/<kind>/<name> nor "+
+			"<group>/namespaces/<namespace>/<kind>/<name>", ErrInvalidDependency, ref)
+	}
+
+	if key.Kind == "" || key.Name == "" {
+		return ObjectKey{}, fmt.Errorf("%w: %q needs a kind and name", ErrInvalidDependency, ref)
+	}
+
+	return key, nil
+}
+
+// orderByDependencies reorders objects so that every object comes after the
+// objects it depends on. Objects keep their order otherwise: a dependency is
+// moved just before the first object depending on it. Dependencies on
+// objects outside the set are reported as warnings; cycles fail with
+// ErrDependencyCycle.
+func orderByDependencies(ctx context.Context, objects []unstructured.Unstructured) error {
+	indexes := make(map[dependencyKey][]int, len(objects))
+	for i := range objects {
+		key := dependencyKeyOf(objects[i])
+		indexes[key] = append(indexes[key], i)
+	}
+
+	edges := make([][]int, len(objects))
+
+	for i := range objects {
+		dependencies, err := DependenciesOf(objects[i])
+		if err != nil {
+			return err
+		}
+
+		for _, dependency := range dependencies {
+			key := dependencyKey{
+				GroupKind: dependency.GroupKind(),
+				Namespace: dependency.Namespace,
+				Name:      dependency.Name,
+			}
+
+			targets, ok := indexes[key]
+			if !ok {
+				Warnf(ctx, "%s depends on %s, which is not rendered", ObjectKeyOf(objects[i]), key)
+
+				continue
+			}
+
+			edges[i] = append(edges[i], targets...)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(objects))
+	order := make([]int, 0, len(objects))
+	path := make([]int, 0)
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return dependencyCycle(objects, path, i)
+		}
+
+		state[i] = visiting
+		path = append(path, i)
+
+		for _, dependency := range edges[i] {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = visited
+		order = append(order, i)
+
+		return nil
+	}
+
+	for i := range objects {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+
+	sorted := make([]unstructured.Unstructured, len(objects))
+	for i, j := range order {
+		sorted[i] = objects[j]
+	}
+
+	copy(objects, sorted)
+
+	return nil
+}
+
+// dependencyCycle reports the cycle closed by an edge from the end of path
+// back to object i.
+func dependencyCycle(objects []unstructured.Unstructured, path []int, i int) error {
+	start := 0
+	for k, j := range path {
+		if j == i {
+			start = k
+
+			break
+		}
+	}
+
+	keys := make([]string, 0, len(path)-start+1)
+	for _, j := range path[start:] {
+		keys = append(keys, ObjectKeyOf(objects[j]))
+	}
+
+	keys = append(keys, ObjectKeyOf(objects[i]))
+
+	return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(keys, " -> "))
+}
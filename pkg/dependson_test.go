@@ -0,0 +1,176 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func dependingObject(
+	apiVersion string,
+	kind string,
+	ns string,
+	name string,
+	key string,
+	refs string,
+) unstructured.Unstructured {
+	obj := composeObject(apiVersion, kind, ns, name)
+	obj.SetAnnotations(map[string]string{key: refs})
+
+	return obj
+}
+
+func TestDependencyOrdering(t *testing.T) {
+
+	t.Run("should order objects after their dependencies", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			dependingObject("apps/v1", "Deployment", "app", "api", mem.AnnotationDependsOn,
+				"/namespaces/app/ConfigMap/config, /namespaces/app/Secret/token"),
+			composeObject("v1", "Service", "app", "api"),
+			dependingObject("v1", "Secret", "app", "token", mem.AnnotationManifestDependsOn, "v1/Namespace//app"),
+			composeObject("v1", "ConfigMap", "app", "config"),
+			composeObject("v1", "Namespace", "", "app"),
+		}
+
+		g.Expect(renderOrdered(t, g, objects, mem.WithDependencyOrdering(true))).To(Equal([]string{
+			"ConfigMap/config",
+			"Namespace/app",
+			"Secret/token",
+			"Deployment/api",
+			"Service/api",
+		}))
+	})
+
+	t.Run("should match dependencies by group regardless of version", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			dependingObject("example.com/v1", "Widget", "app", "widget", mem.AnnotationManifestDependsOn,
+				"apiextensions.k8s.io/v1beta1/CustomResourceDefinition//widgets.example.com"),
+			dependingObject("v1", "ConfigMap", "app", "config", mem.AnnotationDependsOn,
+				"apps/namespaces/app/Deployment/api"),
+			composeObject("apps/v1", "Deployment", "app", "api"),
+			composeObject("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "widgets.example.com"),
+		}
+
+		g.Expect(renderOrdered(t, g, objects, mem.WithDependencyOrdering(true))).To(Equal([]string{
+			"CustomResourceDefinition/widgets.example.com",
+			"Widget/widget",
+			"Deployment/api",
+			"ConfigMap/config",
+		}))
+	})
+
+	t.Run("should win over kind ordering and webhooks last", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			composeObject("v1", "Namespace", "", "app"),
+			dependingObject("v1", "ConfigMap", "app", "config", mem.AnnotationDependsOn,
+				"admissionregistration.k8s.io/ValidatingWebhookConfiguration/validate"),
+			webhookConfiguration("ValidatingWebhookConfiguration", "validate"),
+		}
+
+		g.Expect(renderOrdered(t, g, objects,
+			mem.WithKindOrdering(mem.InstallOrder()),
+			mem.WithWebhooksLast(true),
+			mem.WithDependencyOrdering(true),
+		)).To(Equal([]string{"Namespace/app", "ValidatingWebhookConfiguration/validate", "ConfigMap/config"}))
+	})
+
+	t.Run("should warn about dependencies that are not rendered", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{
+				dependingObject("v1", "ConfigMap", "app", "config", mem.AnnotationDependsOn, "/Namespace/app"),
+			}}},
+			mem.WithDependencyOrdering(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Warnings()).To(ConsistOf(mem.Warning{
+			Message: "v1/ConfigMap/app/config depends on Namespace app, which is not rendered",
+		}))
+	})
+
+	t.Run("should fail on cycles", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{
+				composeObject("v1", "Namespace", "", "app"),
+				dependingObject("v1", "ConfigMap", "app", "a", mem.AnnotationDependsOn, "/namespaces/app/ConfigMap/b"),
+				dependingObject("v1", "ConfigMap", "app", "b", mem.AnnotationDependsOn, "/namespaces/app/ConfigMap/a"),
+			}}},
+			mem.WithDependencyOrdering(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrDependencyCycle))
+		g.Expect(err.Error()).To(ContainSubstring(
+			"v1/ConfigMap/app/a -> v1/ConfigMap/app/b -> v1/ConfigMap/app/a"))
+	})
+
+	t.Run("should fail on malformed references", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for key, ref := range map[string]string{
+			mem.AnnotationDependsOn:         "/ConfigMap",
+			mem.AnnotationManifestDependsOn: "ConfigMap/app/config",
+		} {
+			renderer, err := mem.New(
+				[]mem.Source{{Objects: []unstructured.Unstructured{
+					dependingObject("v1", "Secret", "app", "token", key, ref),
+				}}},
+				mem.WithDependencyOrdering(true),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, err = renderer.Process(t.Context(), nil)
+			g.Expect(err).To(MatchError(mem.ErrInvalidDependency))
+		}
+	})
+
+	t.Run("should ignore annotations by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			dependingObject("v1", "ConfigMap", "app", "a", mem.AnnotationDependsOn, "/namespaces/app/ConfigMap/a"),
+		}
+
+		g.Expect(renderOrdered(t, g, objects)).To(Equal([]string{"ConfigMap/a"}))
+	})
+}
+
+func TestDependenciesOf(t *testing.T) {
+
+	t.Run("should parse both annotations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := composeObject("apps/v1", "Deployment", "app", "api")
+		obj.SetAnnotations(map[string]string{
+			mem.AnnotationDependsOn:         "rbac.authorization.k8s.io/ClusterRole/reader",
+			mem.AnnotationManifestDependsOn: "v1/ConfigMap/app/config",
+		})
+
+		dependencies, err := mem.DependenciesOf(obj)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(dependencies).To(HaveLen(2))
+		g.Expect(dependencies[0].Group).To(Equal("rbac.authorization.k8s.io"))
+		g.Expect(dependencies[0].Kind).To(Equal("ClusterRole"))
+		g.Expect(dependencies[0].Name).To(Equal("reader"))
+		g.Expect(dependencies[1].Kind).To(Equal("ConfigMap"))
+		g.Expect(dependencies[1].Version).To(BeEmpty())
+		g.Expect(dependencies[1].Namespace).To(Equal("app"))
+	})
+}
@@ -0,0 +1,210 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// APIDeprecation records when Kubernetes deprecated and removed an API
+// version of a kind, following the Kubernetes deprecated API migration
+// guide.
+type APIDeprecation struct {
+	// GroupVersionKind is the deprecated API version of the kind.
+	GroupVersionKind schema.GroupVersionKind
+
+	// Deprecated is the Kubernetes minor version that deprecated it, e.g.
+	// "1.16".
+	Deprecated string
+
+	// Removed is the Kubernetes minor version that stopped serving it.
+	Removed string
+
+	// Replacement is the API version to migrate to, or the zero value if
+	// the kind was removed without one, like PodSecurityPolicy.
+	Replacement schema.GroupVersionKind
+}
+
+// deprecatedAPIs are the deprecated API versions of the built-in kinds.
+var deprecatedAPIs = func() []APIDeprecation {
+	type entry struct {
+		groupVersion string
+		kinds        []string
+		deprecated   string
+		removed      string
+		replacement  string
+	}
+
+	entries := []entry{
+		{"extensions/v1beta1", []string{"DaemonSet", "Deployment", "ReplicaSet"}, "1.9", "1.16", "apps/v1"},
+		{"extensions/v1beta1", []string{"NetworkPolicy"}, "1.9", "1.16", "networking.k8s.io/v1"},
+		{"extensions/v1beta1", []string{"PodSecurityPolicy"}, "1.11", "1.16", "policy/v1beta1"},
+		{"extensions/v1beta1", []string{"Ingress"}, "1.14", "1.22", "networking.k8s.io/v1"},
+		{"apps/v1beta1", []string{"ControllerRevision", "Deployment", "StatefulSet"}, "1.9", "1.16", "apps/v1"},
+		{
+			"apps/v1beta2",
+			[]string{"ControllerRevision", "DaemonSet", "Deployment", "ReplicaSet", "StatefulSet"},
+			"1.9", "1.16", "apps/v1",
+		},
+		{
+			"admissionregistration.k8s.io/v1beta1",
+			[]string{"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"},
+			"1.16", "1.22", "admissionregistration.k8s.io/v1",
+		},
+		{
+			"apiextensions.k8s.io/v1beta1", []string{"CustomResourceDefinition"},
+			"1.16", "1.22", "apiextensions.k8s.io/v1",
+		},
+		{"apiregistration.k8s.io/v1beta1", []string{"APIService"}, "1.19", "1.22", "apiregistration.k8s.io/v1"},
+		{"authentication.k8s.io/v1beta1", []string{"TokenReview"}, "1.19", "1.22", "authentication.k8s.io/v1"},
+		{
+			"authorization.k8s.io/v1beta1",
+			[]string{"LocalSubjectAccessReview", "SelfSubjectAccessReview", "SubjectAccessReview"},
+			"1.19", "1.22", "authorization.k8s.io/v1",
+		},
+		{
+			"certificates.k8s.io/v1beta1", []string{"CertificateSigningRequest"},
+			"1.19", "1.22", "certificates.k8s.io/v1",
+		},
+		{"coordination.k8s.io/v1beta1", []string{"Lease"}, "1.19", "1.22", "coordination.k8s.io/v1"},
+		{"networking.k8s.io/v1beta1", []string{"Ingress", "IngressClass"}, "1.19", "1.22", "networking.k8s.io/v1"},
+		{
+			"rbac.authorization.k8s.io/v1beta1",
+			[]string{"ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"},
+			"1.17", "1.22", "rbac.authorization.k8s.io/v1",
+		},
+		{"scheduling.k8s.io/v1beta1", []string{"PriorityClass"}, "1.14", "1.22", "scheduling.k8s.io/v1"},
+		{
+			"storage.k8s.io/v1beta1",
+			[]string{"CSIDriver", "CSINode", "StorageClass", "VolumeAttachment"},
+			"1.19", "1.22", "storage.k8s.io/v1",
+		},
+		{"batch/v1beta1", []string{"CronJob"}, "1.21", "1.25", "batch/v1"},
+		{"discovery.k8s.io/v1beta1", []string{"EndpointSlice"}, "1.21", "1.25", "discovery.k8s.io/v1"},
+		{"events.k8s.io/v1beta1", []string{"Event"}, "1.19", "1.25", "events.k8s.io/v1"},
+		{"autoscaling/v2beta1", []string{"HorizontalPodAutoscaler"}, "1.22", "1.25", "autoscaling/v2"},
+		{"policy/v1beta1", []string{"PodDisruptionBudget"}, "1.21", "1.25", "policy/v1"},
+		{"policy/v1beta1", []string{"PodSecurityPolicy"}, "1.21", "1.25", ""},
+		{"node.k8s.io/v1beta1", []string{"RuntimeClass"}, "1.20", "1.25", "node.k8s.io/v1"},
+		{"autoscaling/v2beta2", []string{"HorizontalPodAutoscaler"}, "1.23", "1.26", "autoscaling/v2"},
+		{
+			"flowcontrol.apiserver.k8s.io/v1beta1",
+			[]string{"FlowSchema", "PriorityLevelConfiguration"},
+			"1.23", "1.26", "flowcontrol.apiserver.k8s.io/v1",
+		},
+		{"storage.k8s.io/v1beta1", []string{"CSIStorageCapacity"}, "1.24", "1.27", "storage.k8s.io/v1"},
+		{
+			"flowcontrol.apiserver.k8s.io/v1beta2",
+			[]string{"FlowSchema", "PriorityLevelConfiguration"},
+			"1.26", "1.29", "flowcontrol.apiserver.k8s.io/v1",
+		},
+		{
+			"flowcontrol.apiserver.k8s.io/v1beta3",
+			[]string{"FlowSchema", "PriorityLevelConfiguration"},
+			"1.29", "1.32", "flowcontrol.apiserver.k8s.io/v1",
+		},
+	}
+
+	out := make([]APIDeprecation, 0)
+
+	for _, e := range entries {
+		gv := schema.FromAPIVersionAndKind(e.groupVersion, "").GroupVersion()
+
+		for _, kind := range e.kinds {
+			deprecation := APIDeprecation{
+				GroupVersionKind: gv.WithKind(kind),
+				Deprecated:       e.deprecated,
+				Removed:          e.removed,
+			}
+
+			if e.replacement != "" {
+				deprecation.Replacement = schema.FromAPIVersionAndKind(e.replacement, kind)
+			}
+
+			out = append(out, deprecation)
+		}
+	}
+
+	return out
+}()
+
+// DeprecatedAPIs returns the deprecated API versions of the built-in kinds
+// that WithTargetKubeVersion checks, in order of removal.
+func DeprecatedAPIs() []APIDeprecation {
+	return slices.Clone(deprecatedAPIs)
+}
+
+// DeprecatedAPI returns the deprecation of gvk, if its API version is
+// deprecated.
+func DeprecatedAPI(gvk schema.GroupVersionKind) (APIDeprecation, bool) {
+	i := slices.IndexFunc(deprecatedAPIs, func(d APIDeprecation) bool { return d.GroupVersionKind == gvk })
+	if i < 0 {
+		return APIDeprecation{}, false
+	}
+
+	return deprecatedAPIs[i], true
+}
+
+// parseKubeVersion parses a Kubernetes version such as "1.29" or "v1.29.3".
+func parseKubeVersion(s string) (*version.Version, error) {
+	v, err := version.ParseGeneric(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidKubeVersion, s, err)
+	}
+
+	return v, nil
+}
+
+// checkDeprecatedAPIs reports objects of API versions deprecated by the
+// target Kubernetes version as warnings, and fails with ErrRemovedAPI, naming
+// every object, if any use API versions it no longer serves.
+func (r *Renderer) checkDeprecatedAPIs(ctx context.Context, objects []unstructured.Unstructured) error {
+	target, err := parseKubeVersion(r.opts.TargetKubeVersion)
+	if err != nil {
+		return err
+	}
+
+	removed := make([]string, 0)
+
+	for i := range objects {
+		deprecation, ok := DeprecatedAPI(objects[i].GroupVersionKind())
+		if !ok {
+			continue
+		}
+
+		key := ObjectKeyOf(objects[i])
+
+		switch {
+		case target.AtLeast(version.MustParseGeneric(deprecation.Removed)):
+			removed = append(removed,
+				fmt.Sprintf("%s: %s", key, deprecation.describe("removed in", deprecation.Removed)))
+		case target.AtLeast(version.MustParseGeneric(deprecation.Deprecated)):
+			Warnf(ctx, "%s: %s", key, deprecation.describe("deprecated since", deprecation.Deprecated))
+		}
+	}
+
+	if len(removed) > 0 {
+		return fmt.Errorf("%w for Kubernetes %s: %s",
+			ErrRemovedAPI, r.opts.TargetKubeVersion, strings.Join(removed, "; "))
+	}
+
+	return nil
+}
+
+// describe explains the deprecation, e.g. "batch/v1beta1 CronJob is removed
+// in Kubernetes 1.25, use batch/v1".
+func (d APIDeprecation) describe(event string, release string) string {
+	gvk := d.GroupVersionKind
+	s := fmt.Sprintf("%s %s is %s Kubernetes %s", gvk.GroupVersion(), gvk.Kind, event, release)
+
+	if d.Replacement.Empty() {
+		return s + ", without replacement"
+	}
+
+	return s + ", use " + d.Replacement.GroupVersion().String()
+}
@@ -0,0 +1,99 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTargetKubeVersion(t *testing.T) {
+
+	render := func(t *testing.T, kubeVersion string, objects ...unstructured.Unstructured) (*mem.Result, error) {
+		t.Helper()
+
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, mem.WithTargetKubeVersion(kubeVersion))
+		if err != nil {
+			return nil, err
+		}
+
+		return renderer.ProcessResult(t.Context(), nil)
+	}
+
+	t.Run("should warn about deprecated API versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := render(t, "1.23",
+			composeObject("batch/v1beta1", "CronJob", "app", "backup"),
+			composeObject("apps/v1", "Deployment", "app", "web"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Warnings()).To(HaveLen(1))
+		g.Expect(result.Warnings()[0].Message).To(Equal(
+			"batch/v1beta1/CronJob/app/backup: " +
+				"batch/v1beta1 CronJob is deprecated since Kubernetes 1.21, use batch/v1"))
+	})
+
+	t.Run("should fail on removed API versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, "v1.29.3",
+			composeObject("batch/v1beta1", "CronJob", "app", "backup"),
+			composeObject("policy/v1beta1", "PodSecurityPolicy", "", "restricted"),
+			composeObject("flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", "", "workload"),
+		)
+		g.Expect(err).To(MatchError(mem.ErrRemovedAPI))
+		g.Expect(err.Error()).To(ContainSubstring("for Kubernetes v1.29.3"))
+		g.Expect(err.Error()).To(ContainSubstring(
+			"batch/v1beta1/CronJob/app/backup: batch/v1beta1 CronJob is removed in Kubernetes 1.25, use batch/v1"))
+		g.Expect(err.Error()).To(ContainSubstring(
+			"PodSecurityPolicy is removed in Kubernetes 1.25, without replacement"))
+		g.Expect(err.Error()).ToNot(ContainSubstring("FlowSchema/"))
+	})
+
+	t.Run("should accept API versions served by older targets", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := render(t, "1.20", composeObject("batch/v1beta1", "CronJob", "app", "backup"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Warnings()).To(BeEmpty())
+	})
+
+	t.Run("should reject invalid versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithTargetKubeVersion("latest"))
+		g.Expect(err).To(MatchError(mem.ErrInvalidKubeVersion))
+	})
+}
+
+func TestDeprecatedAPI(t *testing.T) {
+
+	t.Run("should look up deprecations by GVK", func(t *testing.T) {
+		g := NewWithT(t)
+
+		deprecation, ok := mem.DeprecatedAPI(
+			schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"})
+		g.Expect(ok).To(BeTrue())
+		g.Expect(deprecation.Removed).To(Equal("1.22"))
+		g.Expect(deprecation.Replacement).To(Equal(
+			schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}))
+
+		_, ok = mem.DeprecatedAPI(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("should hand out copies of the table", func(t *testing.T) {
+		g := NewWithT(t)
+
+		apis := mem.DeprecatedAPIs()
+		g.Expect(apis).ToNot(BeEmpty())
+
+		apis[0].Removed = "2.0"
+		g.Expect(mem.DeprecatedAPIs()[0].Removed).ToNot(Equal("2.0"))
+	})
+}
@@ -0,0 +1,116 @@
+package mem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// verifyDeterminism renders values again from inputs until they were rendered
+// as many times as WithDeterminismCheck asks for, and fails with
+// ErrNondeterministicRender if the final output of a render differs from that
+// of objects, the processed output of the first render. Outputs are compared
+// as CanonicalJSON, without the render timestamp of
+// WithProvenanceAnnotations, which differs by design. The repetitions report
+// their warnings to a collector of their own, which is discarded, so the
+// warnings of the render are reported once.
+func (r *Renderer) verifyDeterminism(
+	ctx context.Context,
+	values types.Values,
+	inputs []*sourceHolder,
+	objects []unstructured.Unstructured,
+) error {
+	n := r.opts.DeterminismCheck
+	if n < 2 {
+		return nil
+	}
+
+	ctx = collectWarnings(ctx, &warningCollector{})
+
+	first, err := r.canonicalOutput(ctx, objects)
+	if err != nil {
+		return fmt.Errorf("determinism check error in mem renderer: %w", err)
+	}
+
+	for i := 2; i <= n; i++ {
+		again, err := r.process(ctx, values, &renderTrace{inputs: inputs})
+		if err != nil {
+			return fmt.Errorf("determinism check error in mem renderer: render %d of %d: %w", i, n, err)
+		}
+
+		output, err := r.canonicalOutput(ctx, again)
+		if err != nil {
+			return fmt.Errorf("determinism check error in mem renderer: render %d of %d: %w", i, n, err)
+		}
+
+		if divergence := diverge(first, output); divergence != "" {
+			return fmt.Errorf("%w: render %d of %d differs from the first: %s",
+				ErrNondeterministicRender, i, n, divergence)
+		}
+	}
+
+	return nil
+}
+
+// canonicalObject is an object of a render encoded for comparison.
+type canonicalObject struct {
+	key  string
+	data []byte
+}
+
+// canonicalOutput runs the final pass on a copy of objects and encodes the
+// result.
+func (r *Renderer) canonicalOutput(
+	ctx context.Context,
+	objects []unstructured.Unstructured,
+) ([]canonicalObject, error) {
+	copied := make([]unstructured.Unstructured, len(objects))
+	for i := range objects {
+		objects[i].DeepCopyInto(&copied[i])
+	}
+
+	stamped, _, err := r.stamp(ctx, copied, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]canonicalObject, len(stamped))
+
+	for i := range stamped {
+		unstructured.RemoveNestedField(stamped[i].Object, "metadata", "annotations", AnnotationRenderTimestamp)
+
+		data, err := CanonicalJSON(stamped[i])
+		if err != nil {
+			return nil, err
+		}
+
+		output[i] = canonicalObject{key: ObjectKeyOf(stamped[i]), data: data}
+	}
+
+	return output, nil
+}
+
+// diverge describes the first difference between two encoded renders, or
+// returns an empty string if they are identical.
+func diverge(first []canonicalObject, other []canonicalObject) string {
+	for i := range min(len(first), len(other)) {
+		if first[i].key != other[i].key {
+			return fmt.Sprintf("object %d is %s instead of %s", i, other[i].key, first[i].key)
+		}
+
+		if !bytes.Equal(first[i].data, other[i].data) {
+			return fmt.Sprintf("object %d (%s) has different content", i, first[i].key)
+		}
+	}
+
+	if len(first) != len(other) {
+		return fmt.Sprintf("%d objects instead of %d", len(other), len(first))
+	}
+
+	return ""
+}
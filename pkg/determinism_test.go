@@ -0,0 +1,149 @@
+package mem_test
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// counting returns an ObjectsFn generating a ConfigMap whose data changes on
+// every call, unless stable is set, and the number of calls made so far.
+func counting(
+	stable bool,
+) (func(context.Context, pkgtypes.Values) ([]unstructured.Unstructured, error), *atomic.Int64) {
+	var calls atomic.Int64
+
+	return func(context.Context, pkgtypes.Values) ([]unstructured.Unstructured, error) {
+		n := calls.Add(1)
+
+		obj := composeObject("v1", "ConfigMap", "default", "generated")
+		if !stable {
+			obj.Object["data"] = map[string]any{"call": strconv.FormatInt(n, 10)}
+		}
+
+		return []unstructured.Unstructured{obj}, nil
+	}, &calls
+}
+
+func TestDeterminismCheck(t *testing.T) {
+
+	t.Run("should pass deterministic renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		generate, calls := counting(true)
+
+		renderer, err := mem.New(
+			[]mem.Source{{ObjectsFn: generate}, {Objects: configMaps(2)}},
+			mem.WithDeterminismCheck(3),
+			mem.WithProvenanceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/generated", "ConfigMap/cm-0", "ConfigMap/cm-1"}))
+		g.Expect(calls.Load()).To(Equal(int64(3)))
+		g.Expect(renderer.Stats().Renders).To(Equal(uint64(1)))
+	})
+
+	t.Run("should fail renders whose repetitions differ", func(t *testing.T) {
+		g := NewWithT(t)
+
+		generate, calls := counting(false)
+
+		renderer, err := mem.New([]mem.Source{{ObjectsFn: generate}}, mem.WithDeterminismCheck(3))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrNondeterministicRender))
+		g.Expect(err.Error()).To(ContainSubstring("render 2 of 3"))
+		g.Expect(err.Error()).To(ContainSubstring("object 0 (v1/ConfigMap/default/generated) has different content"))
+		g.Expect(calls.Load()).To(Equal(int64(2)))
+	})
+
+	t.Run("should detect nondeterminism in the final pass", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var calls atomic.Int64
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(1)}},
+			mem.WithDeterminismCheck(2),
+			mem.WithKindHandler(
+				schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+				func(_ context.Context, obj *unstructured.Unstructured) error {
+					obj.SetLabels(map[string]string{"call": strconv.FormatInt(calls.Add(1), 10)})
+
+					return nil
+				},
+			),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrNondeterministicRender))
+	})
+
+	t.Run("should report warnings once", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{
+				dependingObject("v1", "ConfigMap", "app", "config", mem.AnnotationDependsOn, "/Namespace/app"),
+			}}},
+			mem.WithDependencyOrdering(true),
+			mem.WithDeterminismCheck(3),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Warnings()).To(HaveLen(1))
+	})
+
+	t.Run("should render once when disabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, n := range []int{0, 1} {
+			generate, calls := counting(false)
+
+			renderer, err := mem.New([]mem.Source{{ObjectsFn: generate}}, mem.WithDeterminismCheck(n))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, err = renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(calls.Load()).To(Equal(int64(1)))
+		}
+	})
+
+	t.Run("should check streams and jobs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		generate, _ := counting(false)
+
+		renderer, err := mem.New([]mem.Source{{ObjectsFn: generate}}, mem.WithDeterminismCheck(2))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var streamErr error
+		for _, err := range renderer.ProcessStream(t.Context(), nil) {
+			if err != nil {
+				streamErr = err
+			}
+		}
+
+		g.Expect(streamErr).To(MatchError(mem.ErrNondeterministicRender))
+
+		_, err = renderer.NewJob(nil).Run(t.Context())
+		g.Expect(err).To(MatchError(mem.ErrNondeterministicRender))
+	})
+}
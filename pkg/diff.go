@@ -0,0 +1,101 @@
+package mem
+
+import (
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RenderDiff is the difference between two renders, with objects keyed by
+// their identity. It is the input of incremental applies, which only send Added
+// and Changed objects, and of pruning, which deletes Removed objects.
+type RenderDiff struct {
+	// Added holds the objects only the new render has.
+	Added map[string]unstructured.Unstructured
+
+	// Removed holds the objects only the previous render has.
+	Removed map[string]unstructured.Unstructured
+
+	// Changed holds the objects both renders have with different content.
+	Changed map[string]ObjectChange
+}
+
+// ObjectChange is an object whose content differs between two renders.
+type ObjectChange struct {
+	Before unstructured.Unstructured
+	After  unstructured.Unstructured
+}
+
+// Empty reports whether the renders hold the same objects with the same
+// content.
+func (d RenderDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the objects of a previous render with those of a new one.
+// Objects are matched by DefaultIdentity, so an object whose API version
+// changed is reported as changed, not as removed and added, and pruning never
+// deletes an object that was only migrated. Content is compared by the content
+// hash annotations when both objects carry one, so diffing the output of a
+// renderer with content hashes enabled does not rehash objects; like
+// VerifyContentHashes, it trusts the annotation to match the content. Other
+// objects are hashed, whatever algorithm their annotation names. Objects
+// in the diff share content with before and after. If a render holds several
+// objects with the same identity, the last one is compared.
+func Diff(before, after []unstructured.Unstructured) RenderDiff {
+	return DiffFunc(DefaultIdentity, before, after)
+}
+
+// DiffFunc is like Diff but matches objects with the given identity function,
+// such as the one the renderers were configured with by WithIdentityFunc.
+func DiffFunc(identity IdentityFunc, before, after []unstructured.Unstructured) RenderDiff {
+	identity = identityOrDefault(identity)
+	diff := RenderDiff{
+		Added:   make(map[string]unstructured.Unstructured),
+		Removed: make(map[string]unstructured.Unstructured),
+		Changed: make(map[string]ObjectChange),
+	}
+
+	previous := make(map[string]int, len(before))
+	for i := range before {
+		previous[identity(before[i])] = i
+	}
+
+	current := make(map[string]int, len(after))
+	for i := range after {
+		current[identity(after[i])] = i
+	}
+
+	for key, i := range current {
+		j, ok := previous[key]
+		if !ok {
+			diff.Added[key] = after[i]
+
+			continue
+		}
+
+		if !sameContent(&before[j], &after[i]) {
+			diff.Changed[key] = ObjectChange{Before: before[j], After: after[i]}
+		}
+	}
+
+	for key, j := range previous {
+		if _, ok := current[key]; !ok {
+			diff.Removed[key] = before[j]
+		}
+	}
+
+	return diff
+}
+
+// sameContent reports whether a and b have the same content hash.
+func sameContent(a *unstructured.Unstructured, b *unstructured.Unstructured) bool {
+	hashA, okA := a.GetAnnotations()[types.AnnotationContentHash]
+	hashB, okB := b.GetAnnotations()[types.AnnotationContentHash]
+
+	if okA && okB {
+		return hashA == hashB
+	}
+
+	return contentHashOf(a) == contentHashOf(b)
+}
@@ -0,0 +1,118 @@
+package mem_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiff(t *testing.T) {
+
+	t.Run("should report added, removed, and changed objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		before := []unstructured.Unstructured{
+			cachedConfig("a"),
+			composeObject("apps/v1", "Deployment", "app", "web"),
+			composeObject("v1", "Service", "app", "old"),
+		}
+		after := []unstructured.Unstructured{
+			composeObject("v1", "Service", "app", "new"),
+			composeObject("apps/v1", "Deployment", "app", "web"),
+			cachedConfig("b"),
+		}
+
+		diff := mem.Diff(before, after)
+		g.Expect(diff.Empty()).To(BeFalse())
+		g.Expect(slices.Collect(maps.Keys(diff.Added))).To(ConsistOf("/Service/app/new"))
+		g.Expect(slices.Collect(maps.Keys(diff.Removed))).To(ConsistOf("/Service/app/old"))
+		g.Expect(diff.Changed).To(HaveLen(1))
+
+		change := diff.Changed["/ConfigMap/app/config"]
+		g.Expect(change.Before.Object["data"]).To(HaveKeyWithValue("key", "a"))
+		g.Expect(change.After.Object["data"]).To(HaveKeyWithValue("key", "b"))
+	})
+
+	t.Run("should report version changes as changed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		diff := mem.Diff(
+			[]unstructured.Unstructured{composeObject("policy/v1beta1", "PodDisruptionBudget", "app", "web")},
+			[]unstructured.Unstructured{composeObject("policy/v1", "PodDisruptionBudget", "app", "web")},
+		)
+		g.Expect(diff.Added).To(BeEmpty())
+		g.Expect(diff.Removed).To(BeEmpty())
+		g.Expect(diff.Changed).To(HaveKey("policy/PodDisruptionBudget/app/web"))
+	})
+
+	t.Run("should match objects with a custom identity", func(t *testing.T) {
+		g := NewWithT(t)
+
+		tenant := func(obj unstructured.Unstructured) string {
+			return obj.GetLabels()["tenant"] + "/" + mem.DefaultIdentity(obj)
+		}
+
+		acme := composeObject("v1", "Service", "app", "web")
+		acme.SetLabels(map[string]string{"tenant": "acme"})
+
+		globex := composeObject("v1", "Service", "app", "web")
+		globex.SetLabels(map[string]string{"tenant": "globex"})
+
+		diff := mem.DiffFunc(tenant,
+			[]unstructured.Unstructured{acme, globex},
+			[]unstructured.Unstructured{acme},
+		)
+		g.Expect(diff.Added).To(BeEmpty())
+		g.Expect(diff.Changed).To(BeEmpty())
+		g.Expect(slices.Collect(maps.Keys(diff.Removed))).To(ConsistOf("globex//Service/app/web"))
+
+		g.Expect(mem.Diff([]unstructured.Unstructured{acme, globex}, []unstructured.Unstructured{acme}).Changed).
+			To(HaveLen(1))
+	})
+
+	t.Run("should compare renders by their content hash annotations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+			mem.WithContentHash(true),
+			mem.WithGenerationAnnotation("7"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		before, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		after, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(mem.Diff(before, after).Empty()).To(BeTrue())
+
+		// Objects without the annotation are hashed, matching the annotation
+		// of their unchanged counterparts.
+		unhashed := cachedConfig("a")
+		g.Expect(mem.Diff(before, []unstructured.Unstructured{unhashed}).Empty()).To(BeTrue())
+
+		// The annotation is trusted, so a stale one hides content changes.
+		stale := after[0].DeepCopy()
+		stale.Object["data"] = map[string]any{"key": "b"}
+		g.Expect(mem.Diff(before, []unstructured.Unstructured{*stale}).Empty()).To(BeTrue())
+
+		annotations := stale.Object["metadata"].(map[string]any)["annotations"].(map[string]any)
+		delete(annotations, pkgtypes.AnnotationContentHash)
+		g.Expect(mem.Diff(before, []unstructured.Unstructured{*stale}).Changed).To(HaveLen(1))
+	})
+
+	t.Run("should report nothing between empty renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.Diff(nil, nil).Empty()).To(BeTrue())
+	})
+}
@@ -0,0 +1,39 @@
+package mem
+
+import (
+	"context"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AnnotationRenderDigest is the annotation key for the digest of the whole
+// rendered set an object belongs to, set by WithRenderDigestAnnotation.
+const AnnotationRenderDigest = "manifests.k8s-manifests-kit/render.digest"
+
+// RenderDigest renders values and returns a digest of the rendered set: the
+// RenderedHash of its Summarize projection. It does not depend on object
+// order and changes whenever an object is added, removed, or modified, so
+// controllers can compare it with the digest of their last apply to skip
+// unchanged renders.
+func (r *Renderer) RenderDigest(ctx context.Context, values types.Values) (string, error) {
+	result, err := r.ProcessResult(ctx, values)
+	if err != nil {
+		return "", err
+	}
+
+	return renderedHash(result.objects), nil
+}
+
+// stampRenderDigest sets the render digest annotation on every object. The
+// annotation is excluded from content hashes, so it does not feed into the
+// digest it records.
+func stampRenderDigest(objects []unstructured.Unstructured) {
+	digest := renderedHash(objects)
+
+	for i := range objects {
+		k8s.SetAnnotation(&objects[i], AnnotationRenderDigest, digest)
+	}
+}
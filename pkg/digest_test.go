@@ -0,0 +1,75 @@
+package mem_test
+
+import (
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRenderDigest(t *testing.T) {
+
+	objects := []unstructured.Unstructured{cachedConfig("a"), composeObject("apps/v1", "Deployment", "app", "web")}
+
+	t.Run("should digest the rendered set regardless of order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Name: "app", Objects: objects}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		reversed := slices.Clone(objects)
+		slices.Reverse(reversed)
+
+		reversedRenderer, err := mem.New([]mem.Source{{Objects: reversed}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		digest, err := renderer.RenderDigest(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(digest).To(HavePrefix("sha256:"))
+		g.Expect(reversedRenderer.RenderDigest(t.Context(), nil)).To(Equal(digest))
+
+		rendered, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(mem.Summarize(rendered).RenderedHash).To(Equal(digest))
+
+		replacement := mem.Source{Objects: []unstructured.Unstructured{cachedConfig("b")}}
+		g.Expect(renderer.ReplaceSource("app", replacement)).To(Succeed())
+		g.Expect(renderer.RenderDigest(t.Context(), nil)).ToNot(Equal(digest))
+	})
+
+	t.Run("should stamp the digest without changing content hashes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		plain, err := mem.New([]mem.Source{{Objects: objects}}, mem.WithContentHash(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		stamping, err := mem.New(
+			[]mem.Source{{Objects: objects}},
+			mem.WithContentHash(true),
+			mem.WithRenderDigestAnnotation(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		digest, err := stamping.RenderDigest(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(plain.RenderDigest(t.Context(), nil)).To(Equal(digest))
+
+		unstamped, err := plain.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		stamped, err := stamping.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for i := range stamped {
+			g.Expect(stamped[i].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationRenderDigest, digest))
+		}
+
+		g.Expect(mem.VerifyContentHashes(stamped)).To(BeEmpty())
+		g.Expect(mem.Diff(unstamped, stamped).Empty()).To(BeTrue())
+		g.Expect(unstamped[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationRenderDigest))
+	})
+}
@@ -0,0 +1,142 @@
+package mem
+
+import (
+	"fmt"
+	"maps"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// EmptyRenderError is returned by Process when WithFailOnEmpty is enabled and
+// the render produced no objects. Its counts tell where the objects went
+// missing: no source selected, selected sources without objects, or objects
+// dropped by the renderer's filters, transformers, and post-renderers.
+type EmptyRenderError struct {
+	// Sources is the number of sources (or merged renderers) of the renderer.
+	Sources int
+
+	// Selected is the number of sources accepted by the source selectors.
+	Selected int
+
+	// Collected is the number of objects the selected sources produced,
+	// before the renderer-level filters, transformers, and post-renderers ran.
+	Collected int
+}
+
+func (e *EmptyRenderError) Error() string {
+	switch {
+	case e.Sources == 0:
+		return fmt.Sprintf("%s: renderer has no sources", ErrEmptyRender)
+	case e.Selected == 0:
+		return fmt.Sprintf("%s: source selectors skipped all %d sources", ErrEmptyRender, e.Sources)
+	case e.Collected == 0:
+		return fmt.Sprintf("%s: %d of %d selected sources produced no objects", ErrEmptyRender, e.Selected, e.Sources)
+	default:
+		return fmt.Sprintf(
+			"%s: renderer filters, transformers, or post-renderers dropped all %d objects from %d of %d sources",
+			ErrEmptyRender, e.Collected, e.Selected, e.Sources)
+	}
+}
+
+func (e *EmptyRenderError) Unwrap() error {
+	return ErrEmptyRender
+}
+
+// renderTrace collects what a render learns along the way: the counts
+// reported by EmptyRenderError and RenderInfo, and, if provenance is non-nil,
+// the source of every object by the key recordProvenance tagged it with.
+type renderTrace struct {
+	// inputs are the sources of the renderer when the render started.
+	inputs []*sourceHolder
+
+	sources   int
+	selected  int
+	collected int
+
+	provenance map[string]Provenance
+
+	// migrations are the objects rewritten by WithGVKRewrite, in render order.
+	migrations []Migration
+
+	// patches are the patches of the selected sources, in source order.
+	patches []sourcePatches
+
+	// deletions are the deletions of the selected sources, in source order;
+	// collect reduces them to copies, one per identity.
+	deletions []unstructured.Unstructured
+}
+
+// traceMark is the extent of a trace at some point of a render.
+type traceMark struct {
+	selected   int
+	migrations int
+	patches    int
+	deletions  int
+}
+
+// mark returns the extent of t, for rewinding it to.
+func (t *renderTrace) mark() traceMark {
+	return traceMark{
+		selected:   t.selected,
+		migrations: len(t.migrations),
+		patches:    len(t.patches),
+		deletions:  len(t.deletions),
+	}
+}
+
+// rewind drops what t recorded after m was taken, except provenance, which is
+// keyed by object and recorded again by a repeated render.
+func (t *renderTrace) rewind(m traceMark) {
+	t.selected = m.selected
+	t.migrations = t.migrations[:m.migrations]
+	t.patches = t.patches[:m.patches]
+	t.deletions = t.deletions[:m.deletions]
+}
+
+// fork returns an empty trace for rendering some of t's inputs on another
+// goroutine. It records provenance if t does.
+func (t *renderTrace) fork() *renderTrace {
+	sub := &renderTrace{inputs: t.inputs}
+	if t.provenance != nil {
+		sub.provenance = make(map[string]Provenance)
+	}
+
+	return sub
+}
+
+// join adds what sub recorded to t, as if t had recorded it.
+func (t *renderTrace) join(sub *renderTrace) {
+	t.selected += sub.selected
+	t.collected += sub.collected
+	t.migrations = append(t.migrations, sub.migrations...)
+	t.patches = append(t.patches, sub.patches...)
+	t.deletions = append(t.deletions, sub.deletions...)
+
+	maps.Copy(t.provenance, sub.provenance)
+}
+
+// provenanceOf returns the provenance of the objects with the given keys, as
+// returned by stamp, or nil if t does not record provenance.
+func (t *renderTrace) provenanceOf(keys []string) []*Provenance {
+	if t.provenance == nil {
+		return nil
+	}
+
+	provenance := make([]*Provenance, len(keys))
+
+	for i, key := range keys {
+		if p, ok := t.provenance[key]; ok {
+			provenance[i] = &p
+		}
+	}
+
+	return provenance
+}
+
+func (t renderTrace) emptyError() error {
+	return &EmptyRenderError{
+		Sources:   t.sources,
+		Selected:  t.selected,
+		Collected: t.collected,
+	}
+}
@@ -0,0 +1,125 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/gomega"
+)
+
+func dropAll(_ context.Context, _ unstructured.Unstructured) (bool, error) {
+	return false, nil
+}
+
+func skipAll(_ context.Context, _ mem.Source) (bool, error) {
+	return false, nil
+}
+
+func TestFailOnEmpty(t *testing.T) {
+
+	emptyError := func(t *testing.T, g *WithT, sources []mem.Source, opts ...mem.RendererOption) *mem.EmptyRenderError {
+		t.Helper()
+
+		renderer, err := mem.New(sources, append(opts, mem.WithFailOnEmpty(true))...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(objects).To(BeNil())
+		g.Expect(errors.Is(err, mem.ErrEmptyRender)).To(BeTrue())
+
+		var emptyErr *mem.EmptyRenderError
+		g.Expect(errors.As(err, &emptyErr)).To(BeTrue())
+
+		return emptyErr
+	}
+
+	t.Run("should return empty output without the option", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(BeEmpty())
+	})
+
+	t.Run("should not fail when objects are rendered", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)}, mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+
+	t.Run("should report a renderer without sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		emptyErr := emptyError(t, g, nil)
+		g.Expect(*emptyErr).To(Equal(mem.EmptyRenderError{}))
+		g.Expect(emptyErr.Error()).To(ContainSubstring("no sources"))
+	})
+
+	t.Run("should report sources skipped by selectors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		emptyErr := emptyError(t, g,
+			[]mem.Source{mem.MustSourceFromYAML(configMapYAML), mem.MustSourceFromYAML(multiDocYAML)},
+			mem.WithSourceSelector(skipAll))
+		g.Expect(*emptyErr).To(Equal(mem.EmptyRenderError{Sources: 2}))
+		g.Expect(emptyErr.Error()).To(ContainSubstring("source selectors skipped all 2 sources"))
+	})
+
+	t.Run("should report empty sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		emptyErr := emptyError(t, g, []mem.Source{{}})
+		g.Expect(*emptyErr).To(Equal(mem.EmptyRenderError{Sources: 1, Selected: 1}))
+	})
+
+	t.Run("should report objects dropped by filters", func(t *testing.T) {
+		g := NewWithT(t)
+
+		emptyErr := emptyError(t, g, []mem.Source{mem.MustSourceFromYAML(multiDocYAML)}, mem.WithFilter(dropAll))
+		g.Expect(*emptyErr).To(Equal(mem.EmptyRenderError{Sources: 1, Selected: 1, Collected: 2}))
+		g.Expect(emptyErr.Error()).To(ContainSubstring("dropped all 2 objects"))
+	})
+
+	t.Run("should apply to merged renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		a, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		b, err := mem.New([]mem.Source{mem.MustSourceFromYAML(multiDocYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		merged, err := mem.Merge(a, b, mem.DuplicateKeepAll, mem.WithFilter(dropAll), mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = merged.Process(t.Context(), nil)
+
+		var emptyErr *mem.EmptyRenderError
+		g.Expect(errors.As(err, &emptyErr)).To(BeTrue())
+		g.Expect(*emptyErr).To(Equal(mem.EmptyRenderError{Sources: 2, Selected: 2, Collected: 3}))
+	})
+
+	t.Run("should count empty renders as errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{}}, mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(renderer.Stats().Errors).To(BeEquivalentTo(1))
+	})
+}
@@ -0,0 +1,233 @@
+package mem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/yaml"
+)
+
+// WriteManifests writes objects to w as the multi-document YAML stream that
+// kubectl apply -f - and kubectl diff -f - read: one document per object, in
+// order, separated by "---" lines, with keys in sorted order as kubectl get
+// -o yaml prints them. Null values in metadata (such as the
+// creationTimestamp: null of converted typed objects), empty labels and
+// annotations, and null top-level fields are left out, as they only add
+// noise to diffs. Writing the same objects twice produces identical bytes;
+// no objects write nothing.
+//
+// Every object needs apiVersion, kind, and a name, since kubectl cannot apply
+// objects with only a generateName. The objects are not modified; fields
+// selected by WithExportRedaction are masked in the written copies only.
+func WriteManifests(w io.Writer, objects []unstructured.Unstructured, opts ...ExportOption) error {
+	redactor, err := newRedactor(opts)
+	if err != nil {
+		return err
+	}
+
+	for i := range objects {
+		obj, err := redactor.redact(&objects[i])
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+
+		data, err := manifestYAML(*obj)
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+
+		if i > 0 {
+			data = append([]byte(documentSeparator+"\n"), data...)
+		}
+
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write object %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// manifestYAML encodes obj as a single document of WriteManifests.
+func manifestYAML(obj unstructured.Unstructured) ([]byte, error) {
+	cleaned, err := exportedCopy(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(cleaned.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", ObjectKeyOf(obj), err)
+	}
+
+	return data, nil
+}
+
+// manifestJSON encodes obj as an object of WriteJSON and WriteNDJSON.
+func manifestJSON(obj unstructured.Unstructured) ([]byte, error) {
+	cleaned, err := exportedCopy(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return CanonicalJSON(*cleaned)
+}
+
+// exportedCopy checks that obj can be applied and returns a copy without the
+// null and empty fields that WriteManifests leaves out.
+func exportedCopy(obj unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if err := checkIdentity(obj); err != nil {
+		return nil, err
+	}
+
+	if obj.GetName() == "" {
+		return nil, fmt.Errorf("%w: name is required for kubectl apply", ErrMissingIdentity)
+	}
+
+	cleaned := obj.DeepCopy()
+	dropNulls(cleaned.Object, false)
+
+	if metadata, ok := cleaned.Object["metadata"].(map[string]any); ok {
+		dropNulls(metadata, true)
+	}
+
+	canonicalizeMetadata(cleaned)
+
+	return cleaned, nil
+}
+
+// WriteJSON writes objects to w as a v1 List, the form kubectl get -o json
+// prints and kubectl apply -f - reads, indented by two spaces and followed by
+// a newline. Objects are cleaned and checked as by WriteManifests and encoded
+// with the key order of CanonicalJSON, so writing the same objects twice
+// produces identical bytes; no objects write an empty List.
+func WriteJSON(w io.Writer, objects []unstructured.Unstructured, opts ...ExportOption) error {
+	redactor, err := newRedactor(opts)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"apiVersion":"v1","kind":"List","items":[`)
+
+	for i := range objects {
+		obj, err := redactor.redact(&objects[i])
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+
+		data, err := manifestJSON(*obj)
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		buf.Write(data)
+	}
+
+	buf.WriteString("]}")
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return fmt.Errorf("failed to indent objects: %w", err)
+	}
+
+	indented.WriteByte('\n')
+
+	if _, err := w.Write(indented.Bytes()); err != nil {
+		return fmt.Errorf("failed to write objects: %w", err)
+	}
+
+	return nil
+}
+
+// WriteNDJSON writes objects to w as newline-delimited JSON, one compact
+// object per line, for line-oriented tools and log pipelines. Objects are
+// cleaned, checked, and encoded as by WriteJSON; no objects write nothing.
+func WriteNDJSON(w io.Writer, objects []unstructured.Unstructured, opts ...ExportOption) error {
+	redactor, err := newRedactor(opts)
+	if err != nil {
+		return err
+	}
+
+	for i := range objects {
+		obj, err := redactor.redact(&objects[i])
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+
+		data, err := manifestJSON(*obj)
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write object %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// WriteYAML renders like Process and writes the objects to w with
+// WriteManifests. Nothing is written if the render fails.
+func (r *Renderer) WriteYAML(ctx context.Context, values types.Values, w io.Writer, opts ...ExportOption) error {
+	return r.write(ctx, values, w, opts, WriteManifests)
+}
+
+// WriteJSON renders like Process and writes the objects to w with the
+// package-level WriteJSON. Nothing is written if the render fails.
+func (r *Renderer) WriteJSON(ctx context.Context, values types.Values, w io.Writer, opts ...ExportOption) error {
+	return r.write(ctx, values, w, opts, WriteJSON)
+}
+
+// WriteNDJSON renders like Process and writes the objects to w with the
+// package-level WriteNDJSON. Nothing is written if the render fails.
+func (r *Renderer) WriteNDJSON(ctx context.Context, values types.Values, w io.Writer, opts ...ExportOption) error {
+	return r.write(ctx, values, w, opts, WriteNDJSON)
+}
+
+func (r *Renderer) write(
+	ctx context.Context,
+	values types.Values,
+	w io.Writer,
+	opts []ExportOption,
+	writeObjects func(io.Writer, []unstructured.Unstructured, ...ExportOption) error,
+) error {
+	objects, err := r.Process(ctx, values)
+	if err != nil {
+		return err
+	}
+
+	if err := writeObjects(w, objects, opts...); err != nil {
+		return fmt.Errorf("export error in mem renderer: %w", err)
+	}
+
+	return nil
+}
+
+// dropNulls removes the null values of m, and of its nested maps if
+// recursive is set.
+func dropNulls(m map[string]any, recursive bool) {
+	for key, value := range m {
+		switch v := value.(type) {
+		case nil:
+			delete(m, key)
+		case map[string]any:
+			if recursive {
+				dropNulls(v, true)
+			}
+		}
+	}
+}
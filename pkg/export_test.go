@@ -0,0 +1,232 @@
+package mem_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// decodeAsKubectl decodes a manifest stream the way kubectl's resource
+// builder does for -f -.
+func decodeAsKubectl(g Gomega, data []byte) []unstructured.Unstructured {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	objects := make([]unstructured.Unstructured, 0)
+
+	for {
+		var obj unstructured.Unstructured
+
+		err := decoder.Decode(&obj.Object)
+		if errors.Is(err, io.EOF) {
+			return objects
+		}
+
+		g.Expect(err).ToNot(HaveOccurred())
+
+		if len(obj.Object) > 0 {
+			objects = append(objects, obj)
+		}
+	}
+}
+
+func TestWriteManifests(t *testing.T) {
+
+	typed, err := mem.ToUnstructured(&corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "app", Labels: map[string]string{}},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configMap := composeObject("v1", "ConfigMap", "app", "config")
+	configMap.Object["data"] = map[string]any{
+		"script":  "echo start\n---\necho end\n",
+		"enabled": "yes",
+		"port":    "8080",
+	}
+
+	t.Run("should write a stream kubectl decodes back", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteManifests(&buf, []unstructured.Unstructured{typed, configMap})).To(Succeed())
+
+		objects := decodeAsKubectl(g, buf.Bytes())
+		g.Expect(names(objects)).To(Equal([]string{"Service/web", "ConfigMap/config"}))
+		g.Expect(objects[1].Object["data"]).To(Equal(configMap.Object["data"]))
+		g.Expect(strings.Count(buf.String(), "\n---\n")).To(Equal(1))
+		g.Expect(buf.String()).To(HavePrefix("apiVersion: v1\n"))
+	})
+
+	t.Run("should leave out null metadata noise", func(t *testing.T) {
+		g := NewWithT(t)
+
+		noisy := composeObject("v1", "Secret", "app", "noisy")
+		noisy.Object["status"] = nil
+		noisy.Object["metadata"].(map[string]any)["creationTimestamp"] = nil
+		noisy.Object["metadata"].(map[string]any)["annotations"] = map[string]any{}
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteManifests(&buf, []unstructured.Unstructured{typed, noisy})).To(Succeed())
+		g.Expect(buf.String()).ToNot(ContainSubstring("null"))
+		g.Expect(buf.String()).ToNot(ContainSubstring("labels"))
+		g.Expect(buf.String()).ToNot(ContainSubstring("annotations"))
+
+		g.Expect(noisy.Object).To(HaveKeyWithValue("status", BeNil()))
+		g.Expect(noisy.Object).To(HaveKeyWithValue("metadata", HaveKeyWithValue("creationTimestamp", BeNil())))
+	})
+
+	t.Run("should write identical bytes for identical objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var first, second bytes.Buffer
+		g.Expect(mem.WriteManifests(&first, []unstructured.Unstructured{typed, configMap})).To(Succeed())
+		g.Expect(mem.WriteManifests(&second, []unstructured.Unstructured{typed, configMap})).To(Succeed())
+		g.Expect(first.Bytes()).To(Equal(second.Bytes()))
+	})
+
+	t.Run("should write nothing for no objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteManifests(&buf, nil)).To(Succeed())
+		g.Expect(buf.Len()).To(BeZero())
+	})
+
+	t.Run("should reject objects kubectl cannot apply", func(t *testing.T) {
+		g := NewWithT(t)
+
+		generated := composeObject("v1", "Pod", "app", "")
+		generated.SetGenerateName("worker-")
+
+		err := mem.WriteManifests(io.Discard, []unstructured.Unstructured{configMap, generated})
+		g.Expect(err).To(MatchError(mem.ErrMissingIdentity))
+		g.Expect(err).To(MatchError(ContainSubstring("object 1")))
+	})
+}
+
+func TestWriteJSON(t *testing.T) {
+
+	objects := []unstructured.Unstructured{
+		composeObject("v1", "ConfigMap", "app", "config"),
+		composeObject("apps/v1", "Deployment", "app", "web"),
+	}
+
+	t.Run("should write a List kubectl decodes back", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteJSON(&buf, objects)).To(Succeed())
+		g.Expect(buf.String()).To(HavePrefix("{\n  \"apiVersion\": \"v1\",\n  \"kind\": \"List\",\n  \"items\": [\n"))
+		g.Expect(buf.String()).To(HaveSuffix("]\n}\n"))
+
+		decoded := decodeAsKubectl(g, buf.Bytes())
+		g.Expect(decoded).To(HaveLen(1))
+
+		list, err := decoded[0].ToList()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(list.Items)).To(Equal([]string{"ConfigMap/config", "Deployment/web"}))
+	})
+
+	t.Run("should write one object per line as NDJSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteNDJSON(&buf, objects)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		g.Expect(lines).To(Equal([]string{
+			`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config","namespace":"app"}}`,
+			`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"app"}}`,
+		}))
+		g.Expect(names(decodeAsKubectl(g, buf.Bytes()))).To(Equal([]string{"ConfigMap/config", "Deployment/web"}))
+	})
+
+	t.Run("should write empty output for no objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var list bytes.Buffer
+		g.Expect(mem.WriteJSON(&list, nil)).To(Succeed())
+		g.Expect(list.String()).To(Equal("{\n  \"apiVersion\": \"v1\",\n  \"kind\": \"List\",\n  \"items\": []\n}\n"))
+
+		var lines bytes.Buffer
+		g.Expect(mem.WriteNDJSON(&lines, nil)).To(Succeed())
+		g.Expect(lines.Len()).To(BeZero())
+	})
+
+	t.Run("should redact and reject like WriteManifests", func(t *testing.T) {
+		g := NewWithT(t)
+
+		secret := composeObject("v1", "Secret", "app", "token")
+		secret.Object["data"] = map[string]any{"token": "c2VjcmV0"}
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteNDJSON(&buf, []unstructured.Unstructured{secret},
+			mem.WithExportRedaction(mem.SecretRedaction()))).To(Succeed())
+		g.Expect(buf.String()).To(ContainSubstring(`"token":"` + mem.RedactedValue + `"`))
+
+		unnamed := composeObject("v1", "ConfigMap", "app", "")
+		objects := []unstructured.Unstructured{unnamed}
+		g.Expect(mem.WriteJSON(io.Discard, objects)).To(MatchError(mem.ErrMissingIdentity))
+		g.Expect(mem.WriteNDJSON(io.Discard, objects)).To(MatchError(mem.ErrMissingIdentity))
+	})
+}
+
+func TestRendererWrite(t *testing.T) {
+
+	t.Run("should render and write each format", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: configMaps(2)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for _, write := range []struct {
+			rendered func(io.Writer) error
+			direct   func(io.Writer) error
+		}{
+			{
+				rendered: func(w io.Writer) error { return renderer.WriteYAML(t.Context(), nil, w) },
+				direct:   func(w io.Writer) error { return mem.WriteManifests(w, objects) },
+			},
+			{
+				rendered: func(w io.Writer) error { return renderer.WriteJSON(t.Context(), nil, w) },
+				direct:   func(w io.Writer) error { return mem.WriteJSON(w, objects) },
+			},
+			{
+				rendered: func(w io.Writer) error { return renderer.WriteNDJSON(t.Context(), nil, w) },
+				direct:   func(w io.Writer) error { return mem.WriteNDJSON(w, objects) },
+			},
+		} {
+			var rendered, direct bytes.Buffer
+			g.Expect(write.rendered(&rendered)).To(Succeed())
+			g.Expect(write.direct(&direct)).To(Succeed())
+			g.Expect(rendered.String()).To(Equal(direct.String()))
+		}
+	})
+
+	t.Run("should write nothing when the render fails", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(nil, mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		g.Expect(renderer.WriteJSON(t.Context(), nil, &buf)).To(MatchError(mem.ErrEmptyRender))
+		g.Expect(buf.Len()).To(BeZero())
+	})
+}
@@ -0,0 +1,271 @@
+// Package fixtures synthesizes large, realistic sets of Kubernetes objects for
+// load-testing renderers and downstream pipelines. Each generated application
+// consists of a Deployment, a Service selecting it, and a ConfigMap it
+// consumes, with sizes drawn from configurable distributions. Generation is
+// deterministic for a given Config, so benchmarks are reproducible.
+package fixtures
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+)
+
+const (
+	// ObjectsPerApp is the number of objects generated for each application.
+	ObjectsPerApp = 3
+
+	// maxValueLen is the size of the largest ConfigMap value; larger payloads
+	// are split across several keys, as real configuration usually is.
+	maxValueLen = 1024
+)
+
+// Distribution draws sizes for generated objects.
+type Distribution interface {
+	// Sample returns a non-negative size using r as the source of randomness.
+	Sample(r *rand.Rand) int
+}
+
+type fixed int
+
+func (f fixed) Sample(*rand.Rand) int {
+	return int(f)
+}
+
+// Fixed returns a distribution that always yields n.
+func Fixed(n int) Distribution {
+	return fixed(max(n, 0))
+}
+
+type uniform struct {
+	low  int
+	high int
+}
+
+func (u uniform) Sample(r *rand.Rand) int {
+	return u.low + r.IntN(u.high-u.low+1)
+}
+
+// Uniform returns a distribution yielding sizes in [low, high] with equal probability.
+func Uniform(low int, high int) Distribution {
+	low = max(low, 0)
+
+	return uniform{low: low, high: max(high, low)}
+}
+
+type skewed struct {
+	low   int
+	high  int
+	alpha float64
+}
+
+func (s skewed) Sample(r *rand.Rand) int {
+	// Inverse transform sampling of a bounded Pareto distribution.
+	low, high := float64(s.low), float64(s.high)
+	u := r.Float64()
+	la, ha := math.Pow(low, s.alpha), math.Pow(high, s.alpha)
+	x := math.Pow(-(u*ha-u*la-ha)/(ha*la), -1/s.alpha)
+
+	return min(max(int(x), s.low), s.high)
+}
+
+// Skewed returns a heavy-tailed distribution over [low, high]: most samples are
+// close to low and a few approach high, like object sizes in real clusters.
+// low is raised to 1 if needed.
+func Skewed(low int, high int) Distribution {
+	low = max(low, 1)
+
+	return skewed{low: low, high: max(high, low), alpha: 1.16}
+}
+
+// Config describes the object set to generate. Zero fields take the defaults
+// documented on each field.
+type Config struct {
+	// Apps is the number of applications. Default: 100.
+	Apps int
+
+	// Namespaces is the number of namespaces applications are spread over,
+	// round-robin. Default: 1.
+	Namespaces int
+
+	// Containers is the number of containers per Deployment. Default: Uniform(1, 3).
+	Containers Distribution
+
+	// EnvVars is the number of environment variables per container. Default: Skewed(2, 50).
+	EnvVars Distribution
+
+	// ConfigMapBytes is the payload size of each ConfigMap. Default: Skewed(256, 256 KiB).
+	ConfigMapBytes Distribution
+
+	// Seed seeds the generator. The same Config always yields the same objects.
+	Seed uint64
+}
+
+func (c Config) withDefaults() Config {
+	if c.Apps == 0 {
+		c.Apps = 100
+	}
+
+	if c.Namespaces == 0 {
+		c.Namespaces = 1
+	}
+
+	if c.Containers == nil {
+		c.Containers = Uniform(1, 3)
+	}
+
+	if c.EnvVars == nil {
+		c.EnvVars = Skewed(2, 50)
+	}
+
+	if c.ConfigMapBytes == nil {
+		c.ConfigMapBytes = Skewed(256, 256<<10)
+	}
+
+	return c
+}
+
+// Generate returns Apps*ObjectsPerApp objects: for every application, its
+// ConfigMap, Deployment, and Service, in that order.
+func Generate(config Config) []unstructured.Unstructured {
+	config = config.withDefaults()
+
+	//nolint:gosec // Fixtures need reproducibility, not cryptographic randomness.
+	r := rand.New(rand.NewPCG(config.Seed, config.Seed^0x9e3779b97f4a7c15))
+
+	objects := make([]unstructured.Unstructured, 0, config.Apps*ObjectsPerApp)
+
+	for i := range config.Apps {
+		app := appSpec{
+			name:      fmt.Sprintf("app-%05d", i),
+			namespace: fmt.Sprintf("fixtures-%03d", i%config.Namespaces),
+		}
+
+		objects = append(objects,
+			app.configMap(r, config.ConfigMapBytes.Sample(r)),
+			app.deployment(r, config.Containers.Sample(r), config.EnvVars),
+			app.service(),
+		)
+	}
+
+	return objects
+}
+
+// Source returns the generated objects as a mem.Source.
+func Source(config Config) mem.Source {
+	return mem.Source{Objects: Generate(config)}
+}
+
+type appSpec struct {
+	name      string
+	namespace string
+}
+
+func (a appSpec) metadata(name string) map[string]any {
+	return map[string]any{
+		"name":      name,
+		"namespace": a.namespace,
+		"labels": map[string]any{
+			"app.kubernetes.io/name":       a.name,
+			"app.kubernetes.io/managed-by": "fixtures",
+		},
+	}
+}
+
+func (a appSpec) selector() map[string]any {
+	return map[string]any{"app.kubernetes.io/name": a.name}
+}
+
+func (a appSpec) configMap(r *rand.Rand, size int) unstructured.Unstructured {
+	data := make(map[string]any)
+
+	for key := 0; size > 0; key++ {
+		n := min(size, maxValueLen)
+		data[fmt.Sprintf("config-%03d", key)] = randomText(r, n)
+		size -= n
+	}
+
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   a.metadata(a.name + "-config"),
+		"data":       data,
+	}}
+}
+
+func (a appSpec) deployment(r *rand.Rand, containers int, envVars Distribution) unstructured.Unstructured {
+	specs := make([]any, 0, containers)
+
+	for c := range max(containers, 1) {
+		env := make([]any, 0)
+		for e := range envVars.Sample(r) {
+			env = append(env, map[string]any{
+				"name":  fmt.Sprintf("VAR_%03d", e),
+				"value": randomText(r, 8+r.IntN(56)),
+			})
+		}
+
+		specs = append(specs, map[string]any{
+			"name":  fmt.Sprintf("container-%d", c),
+			"image": fmt.Sprintf("registry.example.com/%s/container-%d:1.%d.%d", a.name, c, r.IntN(20), r.IntN(100)),
+			"ports": []any{map[string]any{"name": "http", "containerPort": int64(8080 + c)}},
+			"env":   env,
+			"envFrom": []any{map[string]any{
+				"configMapRef": map[string]any{"name": a.name + "-config"},
+			}},
+			"resources": map[string]any{
+				"requests": map[string]any{"cpu": "100m", "memory": "128Mi"},
+				"limits":   map[string]any{"cpu": "500m", "memory": "512Mi"},
+			},
+		})
+	}
+
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   a.metadata(a.name),
+		"spec": map[string]any{
+			"replicas": int64(1 + r.IntN(5)),
+			"selector": map[string]any{"matchLabels": a.selector()},
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": a.selector()},
+				"spec":     map[string]any{"containers": specs},
+			},
+		},
+	}}
+}
+
+func (a appSpec) service() unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   a.metadata(a.name),
+		"spec": map[string]any{
+			"selector": a.selector(),
+			"ports": []any{map[string]any{
+				"name":       "http",
+				"port":       int64(80),
+				"targetPort": "http",
+			}},
+		},
+	}}
+}
+
+const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789 =-_"
+
+func randomText(r *rand.Rand, n int) string {
+	var b strings.Builder
+
+	b.Grow(n)
+
+	for range n {
+		b.WriteByte(alphabet[r.IntN(len(alphabet))])
+	}
+
+	return b.String()
+}
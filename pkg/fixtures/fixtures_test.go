@@ -0,0 +1,128 @@
+package fixtures_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+	"github.com/k8s-manifest-kit/renderer-mem/pkg/fixtures"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerate(t *testing.T) {
+
+	t.Run("should generate an application set per app", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := fixtures.Generate(fixtures.Config{Apps: 10, Namespaces: 3})
+		g.Expect(objects).To(HaveLen(10 * fixtures.ObjectsPerApp))
+
+		namespaces := make(map[string]struct{})
+		for i := 0; i < len(objects); i += fixtures.ObjectsPerApp {
+			g.Expect(objects[i].GetKind()).To(Equal("ConfigMap"))
+			g.Expect(objects[i+1].GetKind()).To(Equal("Deployment"))
+			g.Expect(objects[i+2].GetKind()).To(Equal("Service"))
+
+			namespaces[objects[i].GetNamespace()] = struct{}{}
+		}
+
+		g.Expect(namespaces).To(HaveLen(3))
+	})
+
+	t.Run("should be deterministic for a seed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(fixtures.Generate(fixtures.Config{Apps: 5, Seed: 7})).
+			To(Equal(fixtures.Generate(fixtures.Config{Apps: 5, Seed: 7})))
+		g.Expect(fixtures.Generate(fixtures.Config{Apps: 5, Seed: 7})).
+			ToNot(Equal(fixtures.Generate(fixtures.Config{Apps: 5, Seed: 8})))
+	})
+
+	t.Run("should honor size distributions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := fixtures.Generate(fixtures.Config{
+			Apps:           4,
+			Containers:     fixtures.Fixed(2),
+			EnvVars:        fixtures.Fixed(5),
+			ConfigMapBytes: fixtures.Fixed(3000),
+		})
+
+		for i := 0; i < len(objects); i += fixtures.ObjectsPerApp {
+			data, _, _ := unstructured.NestedStringMap(objects[i].Object, "data")
+
+			size := 0
+			for _, v := range data {
+				size += len(v)
+			}
+
+			g.Expect(size).To(Equal(3000))
+
+			containers, _, _ := unstructured.NestedSlice(objects[i+1].Object, "spec", "template", "spec", "containers")
+			g.Expect(containers).To(HaveLen(2))
+			g.Expect(containers[0]).To(HaveKeyWithValue("env", HaveLen(5)))
+		}
+	})
+
+	t.Run("should render through the mem renderer", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{fixtures.Source(fixtures.Config{Apps: 20})})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(20 * fixtures.ObjectsPerApp))
+	})
+}
+
+func TestDistributions(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+
+	t.Run("should stay within bounds", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, d := range []fixtures.Distribution{fixtures.Uniform(3, 9), fixtures.Skewed(3, 9)} {
+			for range 1000 {
+				g.Expect(d.Sample(r)).To(BeNumerically(">=", 3))
+				g.Expect(d.Sample(r)).To(BeNumerically("<=", 9))
+			}
+		}
+	})
+
+	t.Run("should skew towards the lower bound", func(t *testing.T) {
+		g := NewWithT(t)
+
+		d := fixtures.Skewed(100, 100_000)
+
+		small := 0
+		for range 1000 {
+			if d.Sample(r) < 1000 {
+				small++
+			}
+		}
+
+		g.Expect(small).To(BeNumerically(">", 800))
+	})
+}
+
+func BenchmarkProcess(b *testing.B) {
+	for _, apps := range []int{100, 1000} {
+		renderer, err := mem.New([]mem.Source{fixtures.Source(fixtures.Config{Apps: apps})})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("apps=%d", apps), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := renderer.Process(b.Context(), nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
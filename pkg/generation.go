@@ -0,0 +1,36 @@
+package mem
+
+import (
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AnnotationGeneration is the annotation key for the reconcile generation that
+// rendered an object, set by WithGenerationAnnotation.
+const AnnotationGeneration = "manifests.k8s-manifests-kit/render.generation"
+
+// StaleObjects returns the objects of live that were rendered by a generation
+// other than generation, i.e. that carry AnnotationGeneration with a different
+// value. Objects without the annotation are not owned by a generation-stamped
+// render and are never returned. After applying the output of generation, the
+// result is the set of objects to prune.
+func StaleObjects(live []unstructured.Unstructured, generation string) []unstructured.Unstructured {
+	stale := make([]unstructured.Unstructured, 0)
+
+	for i := range live {
+		value, ok := live[i].GetAnnotations()[AnnotationGeneration]
+		if ok && value != generation {
+			stale = append(stale, live[i])
+		}
+	}
+
+	return stale
+}
+
+// stampGeneration sets the generation annotation on every object.
+func stampGeneration(objects []unstructured.Unstructured, generation string) {
+	for i := range objects {
+		k8s.SetAnnotation(&objects[i], AnnotationGeneration, generation)
+	}
+}
@@ -0,0 +1,86 @@
+package mem_test
+
+import (
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/filter/meta/gvk"
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func renderGeneration(
+	t *testing.T,
+	g Gomega,
+	generation string,
+	source mem.Source,
+	opts ...mem.RendererOption,
+) []unstructured.Unstructured {
+	t.Helper()
+
+	renderer, err := mem.New([]mem.Source{source}, append(opts, mem.WithGenerationAnnotation(generation))...)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	objects, err := renderer.Process(t.Context(), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return objects
+}
+
+func TestGenerationAnnotation(t *testing.T) {
+
+	t.Run("should stamp every object", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := renderGeneration(t, g, "7", mem.MustSourceFromYAML(configMapYAML, multiDocYAML))
+		g.Expect(objects).To(HaveLen(3))
+
+		for _, obj := range objects {
+			g.Expect(obj.GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationGeneration, "7"))
+		}
+	})
+
+	t.Run("should not stamp without a generation", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := renderGeneration(t, g, "", mem.MustSourceFromYAML(configMapYAML))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationGeneration))
+	})
+
+	t.Run("should not affect content hashes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first := renderGeneration(t, g, "1", mem.MustSourceFromYAML(configMapYAML))
+		second := renderGeneration(t, g, "2", mem.MustSourceFromYAML(configMapYAML))
+
+		g.Expect(second[0].GetAnnotations()[pkgtypes.AnnotationContentHash]).
+			To(Equal(first[0].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+		g.Expect(mem.VerifyContentHashes(second)).To(BeEmpty())
+
+		reingested := renderGeneration(t, g, "3", mem.Source{Objects: second})
+		g.Expect(reingested[0].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationGeneration, "3"))
+		g.Expect(reingested[0].GetAnnotations()[pkgtypes.AnnotationContentHash]).
+			To(Equal(first[0].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+	})
+
+	t.Run("should find objects of older generations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		previous := renderGeneration(t, g, "1", mem.MustSourceFromYAML(configMapYAML, multiDocYAML))
+		current := renderGeneration(t, g, "2", mem.MustSourceFromYAML(configMapYAML, multiDocYAML),
+			mem.WithFilter(gvk.Filter(corev1.SchemeGroupVersion.WithKind("ConfigMap"))))
+
+		unowned := composeObject("v1", "Secret", "default", "unowned")
+
+		// The live state after applying generation 2 on top of generation 1.
+		live := mem.Union(current, previous, []unstructured.Unstructured{unowned})
+
+		stale := mem.StaleObjects(live, "2")
+		g.Expect(names(stale)).To(Equal([]string{"Deployment/second"}))
+	})
+}
@@ -0,0 +1,65 @@
+package mem
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// UnattributedGroup is the key ProcessGrouped uses for objects it cannot
+// attribute to a source.
+const UnattributedGroup = ""
+
+// GroupKey returns the key ProcessGrouped files objects of provenance under:
+// the name of the source, or its index in decimal if it is unnamed. Naming
+// sources with numbers therefore makes keys ambiguous.
+func GroupKey(provenance Provenance) string {
+	if provenance.Name != "" {
+		return provenance.Name
+	}
+
+	return strconv.Itoa(provenance.Source)
+}
+
+// ProcessGrouped renders like Process and returns the objects grouped by the
+// source that produced them, keyed by GroupKey, so callers can route the
+// output of different sources to different destinations, such as clusters or
+// apply waves, while the sources share the renderer-level pipeline. Objects
+// keep their output order within each group, and sources that produced no
+// objects have no group.
+//
+// Objects are attributed as by Result.Provenance, each to its own source even
+// if several sources emit the same identity: those added by renderer-level
+// post-renderers or the final pass (e.g. ensured namespaces), and those of
+// merged renderers are filed under UnattributedGroup.
+func (r *Renderer) ProcessGrouped(
+	ctx context.Context,
+	values types.Values,
+) (map[string][]unstructured.Unstructured, error) {
+	result, err := r.render(ctx, values, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Results of a render with provenance are shared with the render cache,
+	// while the groups are handed over to the caller.
+	if r.opts.RenderCache {
+		result = r.copyResult(result)
+	}
+
+	groups := make(map[string][]unstructured.Unstructured)
+
+	for i := range result.objects {
+		key := UnattributedGroup
+		if provenance, ok := result.Provenance(i); ok {
+			key = GroupKey(provenance)
+		}
+
+		groups[key] = append(groups[key], result.objects[i])
+	}
+
+	return groups, nil
+}
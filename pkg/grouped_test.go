@@ -0,0 +1,155 @@
+package mem_test
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestProcessGrouped(t *testing.T) {
+
+	t.Run("should group objects by source name or index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				{Name: "platform", Objects: configMaps(2)},
+				{Objects: []unstructured.Unstructured{composeObject("v1", "Secret", "default", "token")}},
+				{Name: "empty"},
+			},
+			mem.WithTransformer(func(
+				_ context.Context,
+				obj unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				obj.SetLabels(map[string]string{"shared": "pipeline"})
+
+				return obj, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		groups, err := renderer.ProcessGrouped(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(groups).To(HaveLen(2))
+		g.Expect(names(groups["platform"])).To(Equal([]string{"ConfigMap/cm-0", "ConfigMap/cm-1"}))
+		g.Expect(names(groups["1"])).To(Equal([]string{"Secret/token"}))
+		g.Expect(groups["1"][0].GetLabels()).To(HaveKeyWithValue("shared", "pipeline"))
+	})
+
+	t.Run("should file unattributed objects separately", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Name: "apps", Objects: configMaps(1)}},
+			mem.WithEnsureNamespaces(true),
+			mem.WithPostRenderer(func(
+				_ context.Context,
+				objects []unstructured.Unstructured,
+			) ([]unstructured.Unstructured, error) {
+				return append(objects, composeObject("v1", "Secret", "default", "added")), nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		groups, err := renderer.ProcessGrouped(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(groups["apps"])).To(Equal([]string{"ConfigMap/cm-0"}))
+		g.Expect(names(groups[mem.UnattributedGroup])).To(ConsistOf("Secret/added"))
+	})
+
+	t.Run("should attribute objects sharing an identity to their own source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		shared := func(cluster string) unstructured.Unstructured {
+			obj := composeObject("v1", "ConfigMap", "ns", "shared")
+			obj.Object["data"] = map[string]any{"cluster": cluster}
+
+			return obj
+		}
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				{Name: "cluster-a", Objects: []unstructured.Unstructured{shared("a")}},
+				{Name: "cluster-b", Objects: []unstructured.Unstructured{shared("b")}},
+			},
+			mem.WithDuplicatePolicy(mem.DuplicateKeepAll),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		groups, err := renderer.ProcessGrouped(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(groups).To(HaveLen(2))
+		g.Expect(groups["cluster-a"]).To(HaveLen(1))
+		g.Expect(groups["cluster-a"][0].Object["data"]).To(HaveKeyWithValue("cluster", "a"))
+		g.Expect(groups["cluster-b"]).To(HaveLen(1))
+		g.Expect(groups["cluster-b"][0].Object["data"]).To(HaveKeyWithValue("cluster", "b"))
+		g.Expect(groups["cluster-a"][0].GetAnnotations()).
+			ToNot(HaveKey(HavePrefix("manifests.k8s-manifests-kit/internal")))
+	})
+
+	t.Run("should attribute objects renamed by the chain", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Name: "apps", Objects: configMaps(1)}},
+			mem.WithTransformer(func(
+				_ context.Context,
+				obj unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				obj.SetName("renamed")
+
+				return obj, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		groups, err := renderer.ProcessGrouped(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(groups["apps"])).To(Equal([]string{"ConfigMap/renamed"}))
+	})
+
+	t.Run("should hand over copies of cached renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Name: "apps", Objects: []unstructured.Unstructured{cachedConfig("v1")}}},
+			mem.WithRenderCache(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		first, err := renderer.ProcessGrouped(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		first["apps"][0].SetName("changed")
+
+		second, err := renderer.ProcessGrouped(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(second["apps"])).To(Equal([]string{"ConfigMap/config"}))
+		g.Expect(renderer.Stats().CacheHits).To(Equal(uint64(1)))
+	})
+
+	t.Run("should return errors of the render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(nil, mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.ProcessGrouped(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrEmptyRender))
+	})
+}
+
+func TestGroupKey(t *testing.T) {
+
+	t.Run("should prefer names over indexes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.GroupKey(mem.Provenance{Source: 3, Name: "apps"})).To(Equal("apps"))
+		g.Expect(mem.GroupKey(mem.Provenance{Source: 3})).To(Equal("3"))
+	})
+}
@@ -0,0 +1,45 @@
+package mem
+
+import (
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// matchesGVK reports whether gvk is one of patterns, whose empty versions
+// match every version of their group and kind.
+func matchesGVK(patterns []schema.GroupVersionKind, gvk schema.GroupVersionKind) bool {
+	return slices.ContainsFunc(patterns, func(pattern schema.GroupVersionKind) bool {
+		return pattern.Group == gvk.Group &&
+			pattern.Kind == gvk.Kind &&
+			(pattern.Version == "" || pattern.Version == gvk.Version)
+	})
+}
+
+// validateGVKPatterns rejects allowlist and denylist entries without a kind.
+func validateGVKPatterns(patterns []schema.GroupVersionKind) error {
+	for _, pattern := range patterns {
+		if pattern.Kind == "" {
+			return fmt.Errorf("%w: %s: kind is required", ErrInvalidGVKPolicy, pattern)
+		}
+	}
+
+	return nil
+}
+
+// admitGVK checks the kind of obj, the k-th object of source, the index-th
+// input, against the renderer's GVK allowlist and denylist.
+func (r *Renderer) admitGVK(obj *unstructured.Unstructured, index int, source Source, k int) error {
+	gvk := obj.GroupVersionKind()
+
+	switch {
+	case matchesGVK(r.opts.DeniedGVKs, gvk):
+		return fmt.Errorf("%w: %s: %s is denied", ErrGVKNotAllowed, objectLocation(obj, index, source, k), gvk)
+	case len(r.opts.AllowedGVKs) > 0 && !matchesGVK(r.opts.AllowedGVKs, gvk):
+		return fmt.Errorf("%w: %s: %s is not allowed", ErrGVKNotAllowed, objectLocation(obj, index, source, k), gvk)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+package mem_test
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGVKPolicy(t *testing.T) {
+
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	deployment := schema.GroupVersionKind{Group: "apps", Kind: "Deployment"}
+	binding := schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}
+
+	render := func(
+		t *testing.T,
+		objects []unstructured.Unstructured,
+		opts ...mem.RendererOption,
+	) ([]unstructured.Unstructured, error) {
+		t.Helper()
+
+		renderer, err := mem.New([]mem.Source{{Objects: configMaps(1)}, {Name: "tenant", Objects: objects}}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return renderer.Process(t.Context(), nil)
+	}
+
+	t.Run("should render allowed kinds of any version", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(t,
+			[]unstructured.Unstructured{composeObject("apps/v1", "Deployment", "app", "web")},
+			mem.WithAllowedGVKs(configMap),
+			mem.WithAllowedGVKs(deployment),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/cm-0", "Deployment/web"}))
+	})
+
+	t.Run("should fail on kinds that are not allowed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t,
+			[]unstructured.Unstructured{composeObject("v1", "Secret", "app", "token")},
+			mem.WithAllowedGVKs(configMap, deployment),
+		)
+		g.Expect(err).To(MatchError(mem.ErrGVKNotAllowed))
+		g.Expect(err.Error()).To(ContainSubstring(
+			"source 1 (tenant) object 0 (v1/Secret/app/token): /v1, Kind=Secret is not allowed"))
+	})
+
+	t.Run("should fail on denied kinds even if allowed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t,
+			[]unstructured.Unstructured{
+				composeObject("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", "", "admin"),
+			},
+			mem.WithAllowedGVKs(configMap, binding),
+			mem.WithDeniedGVKs(binding),
+		)
+		g.Expect(err).To(MatchError(mem.ErrGVKNotAllowed))
+		g.Expect(err.Error()).To(ContainSubstring("is denied"))
+	})
+
+	t.Run("should check kinds after GVK rewrites", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(t,
+			[]unstructured.Unstructured{composeObject("extensions/v1beta1", "Deployment", "app", "web")},
+			mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{
+				{Group: "extensions", Version: "v1beta1", Kind: "Deployment"}: {
+					Group: "apps", Version: "v1", Kind: "Deployment",
+				},
+			}),
+			mem.WithAllowedGVKs(configMap, deployment),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[1].GetAPIVersion()).To(Equal("apps/v1"))
+	})
+
+	t.Run("should reject entries without a kind", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithDeniedGVKs(schema.GroupVersionKind{Group: "apps"}))
+		g.Expect(err).To(MatchError(mem.ErrInvalidGVKPolicy))
+	})
+
+	t.Run("should read lists from specs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		spec := mem.RendererSpec{
+			Sources: []mem.SourceSpec{{
+				Objects: []unstructured.Unstructured{composeObject("v1", "Secret", "app", "token")},
+			}},
+			Options: mem.RendererOptionsSpec{DeniedGVKs: []metav1.GroupVersionKind{{Version: "v1", Kind: "Secret"}}},
+		}
+
+		renderer, err := mem.FromSpec(spec)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrGVKNotAllowed))
+	})
+}
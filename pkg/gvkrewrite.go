@@ -0,0 +1,108 @@
+package mem
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GVKMigrateFunc adapts the fields of obj to its new schema. It runs after
+// the apiVersion and kind of obj were rewritten from from, and may modify obj
+// in place.
+type GVKMigrateFunc func(from schema.GroupVersionKind, obj *unstructured.Unstructured) error
+
+// Migration records an object rewritten by WithGVKRewrite or migrated by
+// WithAPIMigration.
+type Migration struct {
+	// From is the group, version, and kind the object was rendered from.
+	From schema.GroupVersionKind
+
+	// To is the group, version, and kind the object was rewritten to.
+	To schema.GroupVersionKind
+
+	// Namespace and Name identify the object.
+	Namespace string
+	Name      string
+}
+
+func (m Migration) String() string {
+	name := m.Name
+	if m.Namespace != "" {
+		name = m.Namespace + "/" + name
+	}
+
+	return fmt.Sprintf("%s %s migrated to %s", m.From, name, m.To)
+}
+
+// gvkRewrite is a set of rewrites registered by one WithGVKRewrite call,
+// with the migrations that run on the objects it rewrites.
+type gvkRewrite struct {
+	rewrites map[schema.GroupVersionKind]schema.GroupVersionKind
+	migrate  []GVKMigrateFunc
+}
+
+func (w gvkRewrite) validate() error {
+	for from, to := range w.rewrites {
+		if from.Version == "" || from.Kind == "" || to.Version == "" || to.Kind == "" {
+			return fmt.Errorf("%w: %s to %s: version and kind are required", ErrInvalidGVKRewrite, from, to)
+		}
+	}
+
+	return nil
+}
+
+// rewriteGVK rewrites obj with the first registered rewrite matching its
+// group, version, and kind, or else, with WithAPIMigration, migrates it from
+// a deprecated API version, runs the rewrite's migrations, and records the
+// migration in trace. Rewrites are not chained.
+func (r *Renderer) rewriteGVK(obj *unstructured.Unstructured, trace *renderTrace) error {
+	from := obj.GroupVersionKind()
+
+	for _, rewrite := range r.opts.GVKRewrites {
+		to, ok := rewrite.rewrites[from]
+		if !ok {
+			continue
+		}
+
+		return r.applyRewrite(obj, from, to, rewrite.migrate, trace)
+	}
+
+	if r.opts.APIMigration {
+		return r.migrateAPI(obj, from, trace)
+	}
+
+	return nil
+}
+
+// applyRewrite sets the group, version, and kind of obj, rendered as from,
+// to to, runs migrate on it, and records the migration in trace.
+func (r *Renderer) applyRewrite(
+	obj *unstructured.Unstructured,
+	from schema.GroupVersionKind,
+	to schema.GroupVersionKind,
+	migrate []GVKMigrateFunc,
+	trace *renderTrace,
+) error {
+	key := ObjectKeyOf(*obj)
+	obj.SetGroupVersionKind(to)
+
+	if len(migrate) > 0 {
+		r.ownObject(obj)
+	}
+
+	for _, m := range migrate {
+		if err := m(from, obj); err != nil {
+			return fmt.Errorf("failed to migrate %s to %s: %w", key, to.GroupVersion(), err)
+		}
+	}
+
+	trace.migrations = append(trace.migrations, Migration{
+		From:      from,
+		To:        to,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	})
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const pdbV1Beta1YAML = `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  namespace: app
+spec:
+  minAvailable: 1
+`
+
+var (
+	pdbV1Beta1 = schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}
+	pdbV1      = schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}
+)
+
+func TestGVKRewrite(t *testing.T) {
+
+	t.Run("should rewrite matching objects and report them", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{
+				mem.MustUnstructured(pdbV1Beta1YAML),
+				mem.MustUnstructured(configMapYAML),
+			}}},
+			mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{pdbV1Beta1: pdbV1}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects := result.View().DeepCopy()
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[0].GroupVersionKind()).To(Equal(pdbV1))
+		g.Expect(objects[0].Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("minAvailable", int64(1))))
+		g.Expect(objects[1].GetKind()).To(Equal("ConfigMap"))
+
+		g.Expect(result.Migrations()).To(Equal([]mem.Migration{{
+			From:      pdbV1Beta1,
+			To:        pdbV1,
+			Namespace: "app",
+			Name:      "web",
+		}}))
+		g.Expect(result.Migrations()[0].String()).To(Equal(
+			"policy/v1beta1, Kind=PodDisruptionBudget app/web migrated to policy/v1, Kind=PodDisruptionBudget"))
+	})
+
+	t.Run("should run migrations on rewritten objects only", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var froms []schema.GroupVersionKind
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{
+				mem.MustUnstructured(pdbV1Beta1YAML),
+				mem.MustUnstructured(configMapYAML),
+			}}},
+			mem.WithGVKRewrite(
+				map[schema.GroupVersionKind]schema.GroupVersionKind{pdbV1Beta1: pdbV1},
+				func(from schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+					froms = append(froms, from)
+
+					return unstructured.SetNestedField(obj.Object, "IfHealthyBudget",
+						"spec", "unhealthyPodEvictionPolicy")
+				},
+			),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(froms).To(Equal([]schema.GroupVersionKind{pdbV1Beta1}))
+
+		policy, _, _ := unstructured.NestedString(objects[0].Object, "spec", "unhealthyPodEvictionPolicy")
+		g.Expect(policy).To(Equal("IfHealthyBudget"))
+	})
+
+	t.Run("should let transformers see the new version", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var seen []string
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{mem.MustUnstructured(pdbV1Beta1YAML)}}},
+			mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{pdbV1Beta1: pdbV1}),
+			mem.WithTransformer(func(
+				_ context.Context,
+				obj unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				seen = append(seen, obj.GetAPIVersion())
+
+				return obj, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(seen).To(Equal([]string{"policy/v1"}))
+	})
+
+	t.Run("should not chain rewrites", func(t *testing.T) {
+		g := NewWithT(t)
+
+		pdbV2 := schema.GroupVersionKind{Group: "policy", Version: "v2", Kind: "PodDisruptionBudget"}
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{mem.MustUnstructured(pdbV1Beta1YAML)}}},
+			mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{pdbV1Beta1: pdbV1}),
+			mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{pdbV1: pdbV2}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().At(0).GroupVersionKind()).To(Equal(pdbV1))
+		g.Expect(result.Migrations()).To(HaveLen(1))
+	})
+
+	t.Run("should fail when a migration fails", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errMigration := errors.New("unsupported field")
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{mem.MustUnstructured(pdbV1Beta1YAML)}}},
+			mem.WithGVKRewrite(
+				map[schema.GroupVersionKind]schema.GroupVersionKind{pdbV1Beta1: pdbV1},
+				func(schema.GroupVersionKind, *unstructured.Unstructured) error {
+					return errMigration
+				},
+			),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errMigration))
+		g.Expect(err).To(MatchError(ContainSubstring(
+			"failed to migrate policy/v1beta1/PodDisruptionBudget/app/web to policy/v1")))
+	})
+
+	t.Run("should report migrations of jobs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{mem.MustUnstructured(pdbV1Beta1YAML)}}},
+			mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{pdbV1Beta1: pdbV1}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.NewJob(nil).Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Migrations()).To(HaveLen(1))
+	})
+
+	t.Run("should reject rewrites without a version or kind", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{
+			pdbV1Beta1: {Group: "policy", Kind: "PodDisruptionBudget"},
+		}))
+		g.Expect(err).To(MatchError(mem.ErrInvalidGVKRewrite))
+	})
+}
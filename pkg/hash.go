@@ -0,0 +1,438 @@
+package mem
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+	"lukechampine.com/blake3"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/dump"
+)
+
+// HashAlgorithm names a built-in content hash algorithm. Content hashes are
+// formatted "<algorithm>:<hex digest>", so the algorithm of a recorded hash
+// can always be told from the hash itself.
+type HashAlgorithm string
+
+const (
+	// HashSHA256 is the default algorithm.
+	HashSHA256 HashAlgorithm = "sha256"
+
+	// HashSHA512 trades speed for a longer digest.
+	HashSHA512 HashAlgorithm = "sha512"
+
+	// HashFNV64 is a fast non-cryptographic hash (FNV-1a), for change
+	// detection where hashes need not resist tampering.
+	HashFNV64 HashAlgorithm = "fnv64"
+
+	// HashBLAKE3 is a fast cryptographic hash with a 256-bit digest.
+	HashBLAKE3 HashAlgorithm = "blake3"
+)
+
+func (a HashAlgorithm) validate() error {
+	switch a {
+	case HashSHA256, HashSHA512, HashFNV64, HashBLAKE3:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidHashAlgorithm, a)
+	}
+}
+
+// ContentHashStage selects where in the pipeline content hashes are computed.
+type ContentHashStage string
+
+const (
+	// ContentHashPreChain hashes objects at the end of the source stage,
+	// before the source and renderer-level filters, transformers, and
+	// post-renderers; stages that change hashed objects later refresh their
+	// hashes, but the chains do not. This is the default.
+	ContentHashPreChain ContentHashStage = "pre-chain"
+
+	// ContentHashPostChain hashes every object in the final pass, after all
+	// chains, so the hash describes the emitted content.
+	ContentHashPostChain ContentHashStage = "post-chain"
+)
+
+func (s ContentHashStage) validate() error {
+	switch s {
+	case ContentHashPreChain, ContentHashPostChain:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidContentHashStage, s)
+	}
+}
+
+// sum hashes the content of obj as is.
+func (a HashAlgorithm) sum(obj *unstructured.Unstructured) string {
+	var hasher hash.Hash
+
+	switch a {
+	case HashSHA512:
+		hasher = sha512.New()
+	case HashFNV64:
+		hasher = fnv.New64a()
+	case HashBLAKE3:
+		hasher = blake3.New(32, nil)
+	default:
+		return k8s.ContentHash(obj)
+	}
+
+	_, _ = fmt.Fprintf(hasher, "%v", dump.ForHash(obj))
+
+	return string(a) + ":" + hex.EncodeToString(hasher.Sum(nil))
+}
+
+// ContentHasher computes the content hash of an object, for WithContentHasher.
+// It receives the object without the fields excluded from content hashes,
+// including those of WithContentHashIgnore, and must not modify it.
+type ContentHasher = func(obj *unstructured.Unstructured) (string, error)
+
+// contentHasher computes the content hashes of a renderer, with its
+// ContentHasher if set and its HashAlgorithm otherwise, ignoring the field
+// paths of WithContentHashIgnore.
+type contentHasher struct {
+	algorithm     HashAlgorithm
+	custom        ContentHasher
+	ignore        []string
+	redactSecrets bool
+}
+
+// hasher returns the content hasher selected by opts.
+func (opts *RendererOptions) hasher() contentHasher {
+	return contentHasher{
+		algorithm:     opts.ContentHashAlgorithm,
+		custom:        opts.ContentHasher,
+		ignore:        opts.ContentHashIgnore,
+		redactSecrets: opts.SecretRedaction,
+	}
+}
+
+// hash returns the content hash of obj.
+func (h contentHasher) hash(obj *unstructured.Unstructured) (string, error) {
+	content := hashableContent(obj)
+
+	if slices.ContainsFunc(h.ignore, func(path string) bool { return hasFieldPath(content.Object, path) }) {
+		if content == obj {
+			content = obj.DeepCopy()
+		}
+
+		for _, path := range h.ignore {
+			removeFieldPath(content.Object, path)
+		}
+	}
+
+	if h.redactSecrets && isSecret(*content) {
+		if content == obj {
+			content = obj.DeepCopy()
+		}
+
+		digestSecretData(content.Object)
+	}
+
+	if h.custom == nil {
+		return h.algorithm.sum(content), nil
+	}
+
+	sum, err := h.custom(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", ObjectKeyOf(*obj), err)
+	}
+
+	return sum, nil
+}
+
+// set sets the content hash annotation of obj.
+func (h contentHasher) set(obj *unstructured.Unstructured) error {
+	sum, err := h.hash(obj)
+	if err != nil {
+		return err
+	}
+
+	k8s.SetAnnotation(obj, types.AnnotationContentHash, sum)
+
+	return nil
+}
+
+// setAll sets the content hash annotation of every object of objects.
+func (h contentHasher) setAll(objects []unstructured.Unstructured) error {
+	for i := range objects {
+		if err := h.set(&objects[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rehash refreshes the content hash of the given objects that carry one.
+func (h contentHasher) rehash(objects []unstructured.Unstructured, indices []int) error {
+	for _, i := range indices {
+		if _, hashed := objects[i].GetAnnotations()[types.AnnotationContentHash]; !hashed {
+			continue
+		}
+
+		if err := h.set(&objects[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HashMismatch reports an object whose content hash annotation does not match
+// its content.
+type HashMismatch struct {
+	// Index is the position of the object in the verified slice.
+	Index int
+
+	// Kind, Namespace, and Name identify the object.
+	Kind      string
+	Namespace string
+	Name      string
+
+	// Expected is the hash recorded in the annotation.
+	Expected string
+
+	// Actual is the hash of the object's current content.
+	Actual string
+}
+
+// VerifyContentHashes recomputes the content hash of every object carrying the
+// content hash annotation, with the algorithm the annotation names, and
+// returns the objects whose annotation does not match, in order. Objects
+// without the annotation, or whose hash was computed by a ContentHasher
+// rather than a HashAlgorithm, are not checked. An empty result means no
+// object was modified since its hash was recorded. Renderer.VerifyContentHashes
+// verifies hashes the way a renderer computes them instead, including custom
+// hashers and ignored fields.
+//
+// The renderer records hashes before renderer-level filters, transformers,
+// and post-renderers run, so objects changed by those report a mismatch too;
+// verify output rendered without them, or re-stamp hashes after them.
+func VerifyContentHashes(objects []unstructured.Unstructured) []HashMismatch {
+	mismatches, _ := verifyContentHashes(objects, func(
+		obj *unstructured.Unstructured,
+		expected string,
+	) (string, bool, error) {
+		algorithm, _, _ := strings.Cut(expected, ":")
+		if HashAlgorithm(algorithm).validate() != nil {
+			return "", false, nil
+		}
+
+		return HashAlgorithm(algorithm).sum(hashableContent(obj)), true, nil
+	})
+
+	return mismatches
+}
+
+// VerifyContentHashes is like the package function VerifyContentHashes, but
+// recomputes hashes as r computes them, with its algorithm or ContentHasher
+// and without the fields of WithContentHashIgnore. It fails if the hasher
+// does.
+func (r *Renderer) VerifyContentHashes(objects []unstructured.Unstructured) ([]HashMismatch, error) {
+	hasher := r.opts.hasher()
+
+	return verifyContentHashes(objects, func(obj *unstructured.Unstructured, _ string) (string, bool, error) {
+		actual, err := hasher.hash(obj)
+
+		return actual, true, err
+	})
+}
+
+// verifyContentHashes reports the objects whose content hash annotation does
+// not match the hash recomputed by hash, which may decline to check an object.
+func verifyContentHashes(
+	objects []unstructured.Unstructured,
+	hash func(obj *unstructured.Unstructured, expected string) (string, bool, error),
+) ([]HashMismatch, error) {
+	mismatches := make([]HashMismatch, 0)
+
+	for i := range objects {
+		expected, ok := objects[i].GetAnnotations()[types.AnnotationContentHash]
+		if !ok {
+			continue
+		}
+
+		actual, checked, err := hash(&objects[i], expected)
+		if err != nil {
+			return nil, err
+		}
+
+		if checked && actual != expected {
+			mismatches = append(mismatches, HashMismatch{
+				Index:     i,
+				Kind:      objects[i].GetKind(),
+				Namespace: objects[i].GetNamespace(),
+				Name:      objects[i].GetName(),
+				Expected:  expected,
+				Actual:    actual,
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// hashOf returns the content hash annotation of obj, or computes it if obj
+// does not carry one.
+func hashOf(obj *unstructured.Unstructured) string {
+	if hash, ok := obj.GetAnnotations()[types.AnnotationContentHash]; ok {
+		return hash
+	}
+
+	return contentHashOf(obj)
+}
+
+// contentHashOf hashes obj with HashSHA256, the algorithm of hashes that
+// describe objects outside a renderer.
+func contentHashOf(obj *unstructured.Unstructured) string {
+	return HashSHA256.sum(hashableContent(obj))
+}
+
+// unhashedAnnotations are the annotations that describe a render rather than
+// content: the content hash itself, so a hash carried by a re-ingested object
+// does not feed into the new one, the render generation and digest, the
+// source position and indexes, the build info, and the provenance tag.
+var unhashedAnnotations = []string{
+	types.AnnotationContentHash,
+	AnnotationGeneration,
+	AnnotationRenderDigest,
+	AnnotationSourcePosition,
+	AnnotationSourceIndex,
+	AnnotationObjectIndex,
+	AnnotationRenderTimestamp,
+	AnnotationRendererVersion,
+	AnnotationEngineVersion,
+	annotationProvenanceKey,
+}
+
+// hashableContent returns obj without the fields that describe a render or
+// the server rather than content, or obj itself if it has none of them: the
+// unhashedAnnotations and managedFields.
+func hashableContent(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	annotations := obj.GetAnnotations()
+
+	annotated := slices.ContainsFunc(unhashedAnnotations, func(key string) bool {
+		_, ok := annotations[key]
+
+		return ok
+	})
+	_, managed, _ := unstructured.NestedFieldNoCopy(obj.Object, "metadata", "managedFields")
+
+	if !annotated && !managed {
+		return obj
+	}
+
+	objCopy := obj.DeepCopy()
+	unstructured.RemoveNestedField(objCopy.Object, "metadata", "managedFields")
+
+	if annotated {
+		annotations = objCopy.GetAnnotations()
+
+		for _, key := range unhashedAnnotations {
+			delete(annotations, key)
+		}
+
+		if len(annotations) == 0 {
+			// Stamping added the annotations map; drop it again so the content
+			// matches what was originally hashed.
+			annotations = nil
+		}
+
+		objCopy.SetAnnotations(annotations)
+	}
+
+	return objCopy
+}
+
+// secretDataFields are the fields of a Secret that hold its payload.
+var secretDataFields = []string{"data", "stringData"}
+
+// digestSecretData replaces every value of the payload fields of the Secret
+// content with its SHA-256 digest, so the payload itself is never hashed.
+func digestSecretData(content map[string]any) {
+	for _, field := range secretDataFields {
+		values, ok := content[field].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		for key, value := range values {
+			data, ok := value.(string)
+			if !ok {
+				encoded, _ := json.Marshal(value)
+				data = string(encoded)
+			}
+
+			sum := sha256.Sum256([]byte(data))
+			values[key] = "sha256:" + hex.EncodeToString(sum[:])
+		}
+	}
+}
+
+// isSecret reports whether obj is a core Secret.
+func isSecret(obj unstructured.Unstructured) bool {
+	return obj.GetKind() == "Secret" && obj.GetAPIVersion() == "v1"
+}
+
+// validateFieldPath checks a dot-separated field path.
+func validateFieldPath(path string) error {
+	if path == "" || slices.Contains(strings.Split(path, "."), "") {
+		return fmt.Errorf("%w: %q", ErrInvalidFieldPath, path)
+	}
+
+	return nil
+}
+
+// hasFieldPath reports whether content has the field at the dot-separated
+// path. Map keys may contain dots themselves, e.g. the annotation key in
+// "metadata.annotations.example.com/owner"; every split of path is tried.
+func hasFieldPath(content map[string]any, path string) bool {
+	if _, ok := content[path]; ok {
+		return true
+	}
+
+	for i := range len(path) {
+		if path[i] != '.' {
+			continue
+		}
+
+		if child, ok := content[path[:i]].(map[string]any); ok && hasFieldPath(child, path[i+1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeFieldPath removes the field at path from content, as found by
+// hasFieldPath.
+func removeFieldPath(content map[string]any, path string) {
+	if _, ok := content[path]; ok {
+		delete(content, path)
+
+		return
+	}
+
+	for i := range len(path) {
+		if path[i] != '.' {
+			continue
+		}
+
+		if child, ok := content[path[:i]].(map[string]any); ok && hasFieldPath(child, path[i+1:]) {
+			removeFieldPath(child, path[i+1:])
+
+			return
+		}
+	}
+}
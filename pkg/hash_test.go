@@ -0,0 +1,427 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/transformer/meta/labels"
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestVerifyContentHashes(t *testing.T) {
+
+	render := func(
+		t *testing.T,
+		g Gomega,
+		objects []unstructured.Unstructured,
+		opts ...mem.RendererOption,
+	) []unstructured.Unstructured {
+		t.Helper()
+
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, opts...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return result
+	}
+
+	t.Run("should accept untouched output", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(t, g, mem.MustSourceFromYAML(configMapYAML, multiDocYAML).Objects,
+			mem.WithSourceAnnotations(true))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should report modified objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(t, g, mem.MustSourceFromYAML(configMapYAML, multiDocYAML).Objects)
+		objects[1].SetLabels(map[string]string{"tampered": "true"})
+
+		mismatches := mem.VerifyContentHashes(objects)
+		g.Expect(mismatches).To(HaveLen(1))
+		g.Expect(mismatches[0].Index).To(Equal(1))
+		g.Expect(mismatches[0].Name).To(Equal("first"))
+		g.Expect(mismatches[0].Expected).To(Equal(objects[1].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+		g.Expect(mismatches[0].Actual).ToNot(Equal(mismatches[0].Expected))
+	})
+
+	t.Run("should skip objects without a hash", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(t, g, mem.MustSourceFromYAML(configMapYAML).Objects, mem.WithContentHash(false))
+		objects[0].SetName("changed")
+
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should report changes made by renderer transformers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(t, g, mem.MustSourceFromYAML(configMapYAML).Objects,
+			mem.WithTransformer(labels.Set(map[string]string{"env": "prod"})))
+
+		g.Expect(mem.VerifyContentHashes(objects)).To(HaveLen(1))
+	})
+
+	t.Run("should verify re-ingested objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first := render(t, g, mem.MustSourceFromYAML(configMapYAML).Objects)
+		second := render(t, g, first)
+
+		g.Expect(mem.VerifyContentHashes(second)).To(BeEmpty())
+		g.Expect(second[0].GetAnnotations()[pkgtypes.AnnotationContentHash]).
+			To(Equal(first[0].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+	})
+}
+
+func TestContentHashAlgorithm(t *testing.T) {
+
+	sources := func() []mem.Source {
+		return []mem.Source{
+			{Objects: []unstructured.Unstructured{cachedConfig("a")}},
+			{Objects: []unstructured.Unstructured{cachedConfig("b")}},
+		}
+	}
+
+	render := func(t *testing.T, g Gomega, opts ...mem.RendererOption) []unstructured.Unstructured {
+		t.Helper()
+
+		renderer, err := mem.New(sources(), append(opts, mem.WithEnsureNamespaces(true), mem.WithOverlayMerge())...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"Namespace/app", "ConfigMap/config"}))
+
+		return objects
+	}
+
+	t.Run("should hash with the selected algorithm", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, g, mem.WithContentHashAlgorithm(mem.HashSHA256))).To(Equal(render(t, g)))
+
+		lengths := map[mem.HashAlgorithm]int{
+			mem.HashSHA256: 64,
+			mem.HashSHA512: 128,
+			mem.HashFNV64:  16,
+			mem.HashBLAKE3: 64,
+		}
+
+		for algorithm, length := range lengths {
+			objects := render(t, g, mem.WithContentHashAlgorithm(algorithm))
+
+			for i := range objects {
+				hash := objects[i].GetAnnotations()[pkgtypes.AnnotationContentHash]
+				g.Expect(hash).To(MatchRegexp("^%s:[0-9a-f]{%d}$", algorithm, length))
+			}
+
+			g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+
+			objects[1].Object["data"] = map[string]any{"key": "changed"}
+			g.Expect(mem.VerifyContentHashes(objects)).To(HaveLen(1))
+		}
+	})
+
+	t.Run("should hash with a custom hasher", func(t *testing.T) {
+		g := NewWithT(t)
+
+		hasher := func(obj *unstructured.Unstructured) (string, error) {
+			if _, hashed := obj.GetAnnotations()[pkgtypes.AnnotationContentHash]; hashed {
+				return "", errors.New("hashed the hash annotation")
+			}
+
+			return "kind:" + obj.GetKind(), nil
+		}
+
+		objects := render(t, g, mem.WithContentHasher(hasher))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationContentHash, "kind:Namespace"))
+		g.Expect(objects[1].GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationContentHash, "kind:ConfigMap"))
+
+		// Hashes of custom hashers cannot be recomputed.
+		objects[1].Object["data"] = map[string]any{"key": "changed"}
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should fail renders on hasher errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errHash := errors.New("hash unavailable")
+
+		renderer, err := mem.New(sources(), mem.WithContentHasher(func(_ *unstructured.Unstructured) (string, error) {
+			return "", errHash
+		}))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errHash))
+		g.Expect(err).To(MatchError(ContainSubstring("failed to hash v1/ConfigMap/app/config")))
+	})
+
+	t.Run("should reject unknown algorithms", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithContentHashAlgorithm("md5"))
+		g.Expect(err).To(MatchError(mem.ErrInvalidHashAlgorithm))
+	})
+}
+
+func TestContentHashIgnore(t *testing.T) {
+
+	withStatus := func(phase string) unstructured.Unstructured {
+		obj := cachedConfig("a")
+		obj.Object["status"] = map[string]any{"phase": phase}
+
+		return obj
+	}
+
+	hashes := func(t *testing.T, g Gomega, objects []unstructured.Unstructured, opts ...mem.RendererOption) []string {
+		t.Helper()
+
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, opts...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		rendered, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result := make([]string, len(rendered))
+		for i := range rendered {
+			result[i] = rendered[i].GetAnnotations()[pkgtypes.AnnotationContentHash]
+		}
+
+		return result
+	}
+
+	t.Run("should leave ignored fields out of hashes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ignored := hashes(t, g,
+			[]unstructured.Unstructured{withStatus("Pending"), withStatus("Ready")},
+			mem.WithContentHashIgnore("status"))
+		g.Expect(ignored[0]).To(Equal(ignored[1]))
+		g.Expect(ignored[0]).To(Equal(hashes(t, g, []unstructured.Unstructured{cachedConfig("a")})[0]))
+
+		hashed := hashes(t, g, []unstructured.Unstructured{withStatus("Pending"), withStatus("Ready")})
+		g.Expect(hashed[0]).ToNot(Equal(hashed[1]))
+	})
+
+	t.Run("should keep annotations added by other options out of hashes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		owned := cachedConfig("a")
+		owned.SetAnnotations(map[string]string{"example.com/owner": "team-a", "keep": "true"})
+
+		plain := hashes(t, g, []unstructured.Unstructured{owned}, mem.WithContentHashIgnore("metadata.annotations"))
+		annotated := hashes(t, g, []unstructured.Unstructured{owned},
+			mem.WithContentHashIgnore("metadata.annotations"), mem.WithSourceAnnotations(true))
+		g.Expect(annotated).To(Equal(plain))
+
+		reowned := owned.DeepCopy()
+		reowned.SetAnnotations(map[string]string{"example.com/owner": "team-b", "keep": "true"})
+
+		ignoreOwner := mem.WithContentHashIgnore("metadata.annotations.example.com/owner")
+		g.Expect(hashes(t, g, []unstructured.Unstructured{*reowned}, ignoreOwner)).
+			To(Equal(hashes(t, g, []unstructured.Unstructured{owned}, ignoreOwner)))
+	})
+
+	t.Run("should verify hashes as the renderer computes them", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{withStatus("Pending")}}},
+			mem.WithContentHashIgnore("status"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects[0].Object["status"] = map[string]any{"phase": "Ready"}
+		g.Expect(renderer.VerifyContentHashes(objects)).To(BeEmpty())
+
+		// The package function does not know about ignored fields.
+		g.Expect(mem.VerifyContentHashes(objects)).To(HaveLen(1))
+
+		objects[0].Object["data"] = map[string]any{"key": "changed"}
+		g.Expect(renderer.VerifyContentHashes(objects)).To(HaveLen(1))
+	})
+
+	t.Run("should reject paths with empty segments", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, path := range []string{"", "status.", ".status", "metadata..labels"} {
+			_, err := mem.New(nil, mem.WithContentHashIgnore(path))
+			g.Expect(err).To(MatchError(mem.ErrInvalidFieldPath), path)
+		}
+	})
+}
+
+func TestSecretRedaction(t *testing.T) {
+
+	secret := func(password string) unstructured.Unstructured {
+		obj := composeObject("v1", "Secret", "app", "credentials")
+		obj.Object["data"] = map[string]any{"password": password}
+		obj.Object["stringData"] = map[string]any{"user": "admin"}
+
+		return obj
+	}
+
+	render := func(
+		t *testing.T,
+		g Gomega,
+		objects []unstructured.Unstructured,
+		opts ...mem.RendererOption,
+	) []unstructured.Unstructured {
+		t.Helper()
+
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, opts...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		rendered, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return rendered
+	}
+
+	hashOf := func(obj unstructured.Unstructured) string {
+		return obj.GetAnnotations()[pkgtypes.AnnotationContentHash]
+	}
+
+	t.Run("should hand custom hashers digests instead of the payload", func(t *testing.T) {
+		g := NewWithT(t)
+
+		seen := make([]map[string]any, 0)
+		hasher := func(obj *unstructured.Unstructured) (string, error) {
+			data, _, _ := unstructured.NestedMap(obj.Object, "data")
+			seen = append(seen, data)
+
+			return "custom:" + obj.GetName(), nil
+		}
+
+		render(t, g, []unstructured.Unstructured{secret("c2VjcmV0")},
+			mem.WithContentHasher(hasher), mem.WithSecretRedaction(true))
+		g.Expect(seen).To(HaveLen(1))
+		g.Expect(seen[0]["password"]).To(HavePrefix("sha256:"))
+		g.Expect(seen[0]["password"]).ToNot(ContainSubstring("c2VjcmV0"))
+	})
+
+	t.Run("should still change hashes when a value changes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		redacted := render(t, g, []unstructured.Unstructured{secret("YQ=="), secret("Yg==")},
+			mem.WithSecretRedaction(true))
+		g.Expect(hashOf(redacted[0])).ToNot(Equal(hashOf(redacted[1])))
+
+		plain := render(t, g, []unstructured.Unstructured{secret("YQ==")})
+		g.Expect(hashOf(redacted[0])).ToNot(Equal(hashOf(plain[0])))
+	})
+
+	t.Run("should hash other objects as before", func(t *testing.T) {
+		g := NewWithT(t)
+
+		redacted := render(t, g, []unstructured.Unstructured{cachedConfig("a")}, mem.WithSecretRedaction(true))
+		plain := render(t, g, []unstructured.Unstructured{cachedConfig("a")})
+		g.Expect(hashOf(redacted[0])).To(Equal(hashOf(plain[0])))
+	})
+
+	t.Run("should verify hashes as the renderer computes them", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{secret("YQ==")}}},
+			mem.WithSecretRedaction(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object["data"]).To(HaveKeyWithValue("password", "YQ=="))
+		g.Expect(renderer.VerifyContentHashes(objects)).To(BeEmpty())
+
+		objects[0].Object["data"] = map[string]any{"password": "Yg=="}
+		g.Expect(renderer.VerifyContentHashes(objects)).To(HaveLen(1))
+	})
+}
+
+func TestContentHashStage(t *testing.T) {
+
+	setLabels := mem.WithTransformer(labels.Set(map[string]string{"env": "prod"}))
+
+	t.Run("should hash the emitted content after the chains", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var seen []string
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+			mem.WithContentHashStage(mem.ContentHashPostChain),
+			mem.WithTransformer(func(
+				_ context.Context,
+				obj unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				seen = append(seen, obj.GetAnnotations()[pkgtypes.AnnotationContentHash])
+
+				return obj, nil
+			}),
+			setLabels,
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(seen).To(Equal([]string{""}))
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("env", "prod"))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKey(pkgtypes.AnnotationContentHash))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should hash before the chains by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, opts := range [][]mem.RendererOption{
+			{setLabels},
+			{setLabels, mem.WithContentHashStage(mem.ContentHashPreChain)},
+		} {
+			renderer, err := mem.New([]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig("a")}}}, opts...)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			objects, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(mem.VerifyContentHashes(objects)).To(HaveLen(1))
+		}
+	})
+
+	t.Run("should not hash when content hashes are disabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+			mem.WithContentHash(false),
+			mem.WithContentHashStage(mem.ContentHashPostChain),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(pkgtypes.AnnotationContentHash))
+	})
+
+	t.Run("should reject unknown stages", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithContentHashStage("post-render"))
+		g.Expect(err).To(MatchError(mem.ErrInvalidContentHashStage))
+	})
+}
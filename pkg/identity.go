@@ -0,0 +1,33 @@
+package mem
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// IdentityFunc returns the identity of an object. Objects with equal
+// identities are considered the same object by set operations, duplicate
+// resolution, and any other feature that matches objects against each other.
+//
+// Custom identities matter for fan-out scenarios where names intentionally
+// collide, for example when the same object is rendered once per tenant and
+// a tenant label tells the copies apart.
+type IdentityFunc func(obj unstructured.Unstructured) string
+
+// DefaultIdentity identifies an object by group, kind, namespace, and name,
+// formatted as "group/kind/namespace/name" (empty parts stay empty, e.g.
+// "/ConfigMap/default/config"). The API version is deliberately not part of
+// the identity: the same object served at two versions is the same object.
+func DefaultIdentity(obj unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+
+	return gvk.Group + "/" + gvk.Kind + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// identityOrDefault returns f, or DefaultIdentity if f is nil.
+func identityOrDefault(f IdentityFunc) IdentityFunc {
+	if f == nil {
+		return DefaultIdentity
+	}
+
+	return f
+}
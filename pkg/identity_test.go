@@ -0,0 +1,98 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func tenantIdentity(obj unstructured.Unstructured) string {
+	return mem.DefaultIdentity(obj) + "@" + obj.GetLabels()["tenant"]
+}
+
+func tenantObject(name string, tenant string) unstructured.Unstructured {
+	obj := composeObject("v1", "ConfigMap", "default", name)
+	obj.SetLabels(map[string]string{"tenant": tenant})
+
+	return obj
+}
+
+func TestDefaultIdentity(t *testing.T) {
+
+	t.Run("should format group, kind, namespace, and name", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.DefaultIdentity(composeObject("apps/v1", "Deployment", "ns", "d"))).
+			To(Equal("apps/Deployment/ns/d"))
+		g.Expect(mem.DefaultIdentity(composeObject("v1", "Namespace", "", "ns"))).To(Equal("/Namespace//ns"))
+	})
+
+	t.Run("should ignore the API version", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.DefaultIdentity(composeObject("apps/v1", "Deployment", "ns", "d"))).
+			To(Equal(mem.DefaultIdentity(composeObject("apps/v1beta1", "Deployment", "ns", "d"))))
+	})
+}
+
+func TestIdentityFunc(t *testing.T) {
+
+	a1 := tenantObject("config", "a")
+	b1 := tenantObject("config", "b")
+	a2 := tenantObject("config", "a")
+
+	t.Run("should be used by set operations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.Union([]unstructured.Unstructured{a1, b1, a2})).To(HaveLen(1))
+		g.Expect(mem.UnionFunc(tenantIdentity, []unstructured.Unstructured{a1, b1, a2})).To(HaveLen(2))
+
+		set := []unstructured.Unstructured{a1, b1}
+		g.Expect(mem.IntersectFunc(tenantIdentity, set, []unstructured.Unstructured{a2})).
+			To(Equal([]unstructured.Unstructured{a1}))
+		g.Expect(mem.SubtractFunc(tenantIdentity, set, []unstructured.Unstructured{a2})).
+			To(Equal([]unstructured.Unstructured{b1}))
+	})
+
+	t.Run("should fall back to the default identity when nil", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.UnionFunc(nil, []unstructured.Unstructured{a1, b1})).To(HaveLen(1))
+	})
+
+	t.Run("should be used by Merge to detect conflicts", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mergeAndProcess(t, mergeFixture(g, "a", a1), mergeFixture(g, "b", b1))
+		g.Expect(err).To(MatchError(mem.ErrDuplicateObject))
+
+		objects, err := mergeAndProcess(t, mergeFixture(g, "a", a1), mergeFixture(g, "b", b1),
+			mem.WithIdentityFunc(tenantIdentity))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+
+		_, err = mergeAndProcess(t, mergeFixture(g, "a", a1), mergeFixture(g, "b", a2),
+			mem.WithIdentityFunc(tenantIdentity))
+		g.Expect(err).To(MatchError(ContainSubstring("/ConfigMap/default/config@a")))
+	})
+}
+
+func mergeAndProcess(
+	t *testing.T,
+	a *mem.Renderer,
+	b *mem.Renderer,
+	opts ...mem.RendererOption,
+) ([]unstructured.Unstructured, error) {
+	t.Helper()
+
+	merged, err := mem.Merge(a, b, mem.DuplicateError, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return merged.Process(t.Context(), nil)
+}
@@ -0,0 +1,361 @@
+package mem
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+	"github.com/k8s-manifest-kit/pkg/util"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DefaultJobBatchSize is the number of source objects a Job renders per step
+// unless WithBatchSize says otherwise.
+const DefaultJobBatchSize = 1000
+
+// JobOption is a generic option for JobOptions.
+type JobOption = util.Option[JobOptions]
+
+// JobOptions configures how a Job slices a render.
+type JobOptions struct {
+	// BatchSize is the maximum number of source objects rendered per step.
+	// Zero or less means DefaultJobBatchSize.
+	BatchSize int
+
+	// Budget, if positive, ends a step once it has run that long, even if
+	// the batch is not complete.
+	Budget time.Duration
+
+	// Yield is called by Run between steps. Nil means runtime.Gosched.
+	Yield func(ctx context.Context) error
+}
+
+// ApplyTo applies the job options to the target configuration.
+func (opts JobOptions) ApplyTo(target *JobOptions) {
+	target.BatchSize = opts.BatchSize
+	target.Budget = opts.Budget
+	target.Yield = opts.Yield
+}
+
+// WithBatchSize sets the maximum number of source objects a Job renders per step.
+func WithBatchSize(size int) JobOption {
+	return util.FunctionalOption[JobOptions](func(opts *JobOptions) {
+		opts.BatchSize = size
+	})
+}
+
+// WithJobBudget limits how long a single step of a Job may run.
+func WithJobBudget(budget time.Duration) JobOption {
+	return util.FunctionalOption[JobOptions](func(opts *JobOptions) {
+		opts.Budget = budget
+	})
+}
+
+// WithYield sets the function Run calls between steps, e.g. to wait for a
+// rate limiter or to report progress. An error from yield stops Run.
+func WithYield(yield func(ctx context.Context) error) JobOption {
+	return util.FunctionalOption[JobOptions](func(opts *JobOptions) {
+		opts.Yield = yield
+	})
+}
+
+// Job is a render split into steps, for stores so large that a monolithic
+// Process call would block its caller, typically a controller's reconcile
+// loop, for seconds. Each step renders a bounded batch of source objects and
+// returns; the render resumes where it stopped on the next step.
+//
+// Only the source stage is sliced. The renderer-level filters, transformers,
+// and post-renderers operate on the whole output at once, so they run, with
+// the final pass, in the last step; so does the whole render of a merged
+// renderer.
+//
+// A Job is not safe for concurrent use. The renderer may keep serving other
+// renders meanwhile; a job counts in Stats once, when it completes.
+type Job struct {
+	r      *Renderer
+	values types.Values
+	opts   JobOptions
+
+	trace    *renderTrace
+	warnings *warningCollector
+	clock    renderClock
+
+	// next is the index of the next input to prepare.
+	next int
+
+	// current is the source being rendered, nil between sources.
+	current *jobSource
+
+	// outputs holds the output of each source completed so far.
+	outputs [][]unstructured.Unstructured
+
+	done   bool
+	result *Result
+	err    error
+}
+
+// jobSource tracks the progress of a Job through one source.
+type jobSource struct {
+	index  int
+	source Source
+
+	// k is the index of the next object to render.
+	k int
+
+	// rendered holds the objects rendered so far.
+	rendered []unstructured.Unstructured
+}
+
+// NewJob prepares a render of r that is performed step by step with
+// Job.Step or Job.Run. Nothing is rendered until the first step.
+func (r *Renderer) NewJob(values types.Values, opts ...JobOption) *Job {
+	jobOpts := JobOptions{}
+	for _, opt := range opts {
+		opt.ApplyTo(&jobOpts)
+	}
+
+	if jobOpts.BatchSize <= 0 {
+		jobOpts.BatchSize = DefaultJobBatchSize
+	}
+
+	return &Job{
+		r:        r,
+		values:   values,
+		opts:     jobOpts,
+		trace:    r.newTrace(true),
+		warnings: &warningCollector{},
+		outputs:  make([][]unstructured.Unstructured, 0),
+	}
+}
+
+// Step advances the render by one step: it renders source objects until the
+// batch is complete, the step's budget is used up, or the deadline of ctx has
+// passed, whichever comes first, and reports whether the render is complete.
+//
+// If ctx is cancelled, Step returns its error without failing the job, which
+// resumes with the next step, also when the cancellation surfaces from a
+// source, e.g. one whose ObjectsFn fetches with ctx: the interrupted work is
+// redone. With WithRenderSemaphore, each step holds the
+// semaphore while it runs, so waiting for it does not use up the budget. Any
+// other error fails the job: Step and Result then keep returning it.
+func (j *Job) Step(ctx context.Context) (bool, error) {
+	if j.done {
+		return true, j.err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	ctx, release, err := j.r.admit(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	now := time.Now()
+	if j.clock.start.IsZero() {
+		j.clock.start = now
+	}
+
+	j.clock.resumed = now
+
+	defer func() {
+		if !j.done {
+			j.clock.prior += time.Since(j.clock.resumed)
+		}
+	}()
+
+	ctx = collectWarnings(ctx, j.warnings)
+
+	deadline, limited := ctx.Deadline()
+	if j.opts.Budget > 0 && (!limited || now.Add(j.opts.Budget).Before(deadline)) {
+		deadline, limited = now.Add(j.opts.Budget), true
+	}
+
+	defer guardInputs(j.trace.inputs)()
+
+	// Every step does some work, however small its budget.
+	for rendered, first := 0, true; ; first = false {
+		if !first && (rendered >= j.opts.BatchSize || (limited && !time.Now().Before(deadline))) {
+			return false, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		if j.r.merged != nil || (j.current == nil && j.next == len(j.trace.inputs)) {
+			j.finish(ctx)
+
+			return true, j.err
+		}
+
+		advanced, err := j.advance(ctx)
+		if err != nil {
+			// Cancellation may surface from within the source stage, e.g.
+			// from a source whose objects are fetched with ctx.
+			if interrupted(ctx, err) {
+				return false, err
+			}
+
+			j.fail(ctx, err)
+
+			return true, err
+		}
+
+		rendered += advanced
+	}
+}
+
+// advance performs the next unit of work of the source stage: preparing a
+// source, rendering one of its objects, or completing it. It returns the
+// number of objects rendered. Work interrupted by the cancellation of ctx is
+// undone, so that the next step performs it again.
+func (j *Job) advance(ctx context.Context) (int, error) {
+	mark := j.trace.mark()
+
+	if j.current == nil {
+		index := j.next
+
+		source, selected, err := j.r.prepareSource(ctx, j.values, index, j.trace)
+		if interrupted(ctx, err) {
+			j.trace.rewind(mark)
+
+			return 0, err
+		}
+
+		j.next++
+
+		if err != nil || !selected {
+			return 0, err
+		}
+
+		j.current = &jobSource{
+			index:    index,
+			source:   source,
+			rendered: make([]unstructured.Unstructured, 0, len(source.Objects)),
+		}
+
+		return 0, nil
+	}
+
+	current := j.current
+
+	if current.k == len(current.source.Objects) {
+		rendered, err := j.r.finishSource(ctx, current.source, current.rendered)
+		if err != nil {
+			return 0, err
+		}
+
+		j.outputs = append(j.outputs, rendered)
+		j.current = nil
+
+		return 0, nil
+	}
+
+	source := current.source
+
+	rendered, err := j.r.renderObject(
+		ctx, current.index, source, current.k, source.Objects[current.k], current.rendered, j.trace)
+	if err != nil {
+		if interrupted(ctx, err) {
+			j.trace.rewind(mark)
+		}
+
+		return 0, err
+	}
+
+	current.rendered = rendered
+	current.k++
+
+	return 1, nil
+}
+
+// finish runs the unsliced remainder of the render and completes it.
+func (j *Job) finish(ctx context.Context) {
+	var (
+		objects []unstructured.Unstructured
+		err     error
+	)
+
+	if j.r.merged != nil {
+		objects, err = j.r.processMerged(ctx, j.values, j.trace)
+	} else {
+		j.trace.sources = len(j.trace.inputs)
+
+		objects, err = j.r.collect(j.outputs, j.trace)
+		if err == nil {
+			objects, err = j.r.applyChain(ctx, objects)
+		}
+	}
+
+	if err == nil {
+		// Repeated renders are not time-sliced.
+		err = j.r.verifyDeterminism(ctx, j.values, j.trace.inputs, objects)
+	}
+
+	j.done = true
+	j.result, j.err = j.r.complete(ctx, objects, err, j.trace, j.warnings, j.clock)
+	j.release()
+}
+
+// interrupted reports whether err is caused by the cancellation of ctx.
+func interrupted(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
+
+// fail ends the job with err, counting it as a failed render.
+func (j *Job) fail(ctx context.Context, err error) {
+	j.done = true
+	j.result, j.err = j.r.complete(ctx, nil, err, j.trace, j.warnings, j.clock)
+	j.release()
+}
+
+// release drops the intermediate state of a completed job.
+func (j *Job) release() {
+	j.current = nil
+	j.outputs = nil
+}
+
+// Run steps the job until the render is complete, calling the yield function
+// (runtime.Gosched by default) between steps so other goroutines get to run,
+// and returns its result. Like Step, it returns the error of a cancelled ctx
+// without failing the job, which can be run again to resume.
+func (j *Job) Run(ctx context.Context) (*Result, error) {
+	for {
+		done, err := j.Step(ctx)
+		if done || err != nil {
+			return j.result, err
+		}
+
+		if j.opts.Yield == nil {
+			runtime.Gosched()
+
+			continue
+		}
+
+		if err := j.opts.Yield(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Done reports whether the render is complete, successfully or not.
+func (j *Job) Done() bool {
+	return j.done
+}
+
+// Result returns the result of the completed render, or ErrJobNotDone while
+// steps remain.
+func (j *Job) Result() (*Result, error) {
+	if !j.done {
+		return nil, ErrJobNotDone
+	}
+
+	return j.result, j.err
+}
@@ -0,0 +1,314 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/gomega"
+)
+
+func configMaps(n int) []unstructured.Unstructured {
+	objects := make([]unstructured.Unstructured, n)
+	for i := range objects {
+		objects[i] = mem.MustUnstructured(fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm-%d\n", i))
+	}
+
+	return objects
+}
+
+func TestJob(t *testing.T) {
+
+	t.Run("should render in batches like Process", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(5)}, {}, mem.MustSourceFromYAML(multiDocYAML)},
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		expected, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil, mem.WithBatchSize(2))
+
+		steps := 0
+		for !job.Done() {
+			_, err := job.Step(t.Context())
+			g.Expect(err).ToNot(HaveOccurred())
+
+			steps++
+		}
+
+		// 7 objects in batches of 2, the last step also runs the chain.
+		g.Expect(steps).To(Equal(4))
+
+		result, err := job.Result()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().DeepCopy()).To(Equal(expected))
+		g.Expect(result.Info()).To(And(
+			HaveField("Sources", 3),
+			HaveField("Selected", 3),
+			HaveField("Collected", 7),
+		))
+
+		provenance, ok := result.Provenance(6)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Source).To(Equal(2))
+
+		g.Expect(renderer.Stats().Renders).To(BeEquivalentTo(2))
+	})
+
+	t.Run("should not report results before completion", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: configMaps(3)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil, mem.WithBatchSize(1))
+
+		done, err := job.Step(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(done).To(BeFalse())
+
+		_, err = job.Result()
+		g.Expect(errors.Is(err, mem.ErrJobNotDone)).To(BeTrue())
+		g.Expect(renderer.Stats().Renders).To(BeZero())
+	})
+
+	t.Run("should resume after cancellation", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(t.Context())
+
+		seen := 0
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(4)}},
+			mem.WithFilter(func(_ context.Context, _ unstructured.Unstructured) (bool, error) {
+				seen++
+
+				return true, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil, mem.WithBatchSize(2))
+
+		_, err = job.Step(ctx)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		cancel()
+
+		done, err := job.Step(ctx)
+		g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		g.Expect(done).To(BeFalse())
+		g.Expect(job.Done()).To(BeFalse())
+
+		result, err := job.Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(result.View().DeepCopy())).To(Equal([]string{
+			"ConfigMap/cm-0", "ConfigMap/cm-1", "ConfigMap/cm-2", "ConfigMap/cm-3",
+		}))
+		g.Expect(seen).To(Equal(4))
+		g.Expect(renderer.Stats()).To(And(
+			HaveField("Renders", BeEquivalentTo(1)),
+			HaveField("Errors", BeZero()),
+		))
+	})
+
+	t.Run("should resume after cancellation during a fetch", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(t.Context())
+
+		fetches := 0
+		renderer, err := mem.New([]mem.Source{
+			{Objects: configMaps(1)},
+			{ObjectsFn: func(ctx context.Context, _ pkgtypes.Values) ([]unstructured.Unstructured, error) {
+				fetches++
+				if fetches == 1 {
+					cancel()
+					<-ctx.Done()
+
+					return nil, fmt.Errorf("fetch failed: %w", ctx.Err())
+				}
+
+				return []unstructured.Unstructured{mem.MustUnstructured(
+					"apiVersion: v1\nkind: Secret\nmetadata:\n  name: fetched\n")}, nil
+			}},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil)
+
+		done, err := job.Step(ctx)
+		g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		g.Expect(done).To(BeFalse())
+		g.Expect(job.Done()).To(BeFalse())
+
+		result, err := job.Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(result.View().DeepCopy())).To(Equal([]string{"ConfigMap/cm-0", "Secret/fetched"}))
+		g.Expect(fetches).To(Equal(2))
+		g.Expect(result.Info().Selected).To(Equal(2))
+		g.Expect(renderer.Stats().Errors).To(BeZero())
+	})
+
+	t.Run("should end steps when the budget is used up", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: configMaps(3)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil, mem.WithJobBudget(time.Nanosecond))
+
+		steps := 0
+		for !job.Done() {
+			_, err := job.Step(t.Context())
+			g.Expect(err).ToNot(HaveOccurred())
+
+			steps++
+		}
+
+		// Every step still does one unit of work: preparing the source, one
+		// object each, completing the source, and the final pass.
+		g.Expect(steps).To(Equal(6))
+	})
+
+	t.Run("should end steps at the context deadline", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: configMaps(3)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil)
+
+		ctx, cancel := context.WithDeadline(t.Context(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		done, err := job.Step(ctx)
+		g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		g.Expect(done).To(BeFalse())
+
+		result, err := job.Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(Equal(3))
+	})
+
+	t.Run("should yield between steps", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: configMaps(5)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		yields := 0
+		job := renderer.NewJob(nil, mem.WithBatchSize(2), mem.WithYield(func(_ context.Context) error {
+			yields++
+
+			return nil
+		}))
+
+		result, err := job.Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(Equal(5))
+		g.Expect(yields).To(Equal(2))
+
+		stop := errors.New("stop")
+		job = renderer.NewJob(nil, mem.WithBatchSize(2), mem.WithYield(func(_ context.Context) error {
+			return stop
+		}))
+
+		_, err = job.Run(t.Context())
+		g.Expect(errors.Is(err, stop)).To(BeTrue())
+		g.Expect(job.Done()).To(BeFalse())
+	})
+
+	t.Run("should fail the job on errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		boom := errors.New("boom")
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(3)}},
+			mem.WithTransformer(func(
+				_ context.Context,
+				_ unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				return unstructured.Unstructured{}, boom
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil)
+
+		_, err = job.Run(t.Context())
+		g.Expect(errors.Is(err, boom)).To(BeTrue())
+		g.Expect(job.Done()).To(BeTrue())
+
+		done, err := job.Step(t.Context())
+		g.Expect(done).To(BeTrue())
+		g.Expect(errors.Is(err, boom)).To(BeTrue())
+
+		_, err = job.Result()
+		g.Expect(errors.Is(err, boom)).To(BeTrue())
+		g.Expect(renderer.Stats().Errors).To(BeEquivalentTo(1))
+	})
+
+	t.Run("should collect warnings across steps", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(2)}},
+			mem.WithSourceSelector(func(ctx context.Context, _ mem.Source) (bool, error) {
+				mem.Warnf(ctx, "selected")
+
+				return true, nil
+			}),
+			mem.WithFilter(func(ctx context.Context, obj unstructured.Unstructured) (bool, error) {
+				mem.Warnf(ctx, "kept %s", obj.GetName())
+
+				return true, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.NewJob(nil, mem.WithBatchSize(1)).Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Warnings()).To(Equal([]mem.Warning{
+			{Message: "selected"},
+			{Message: "kept cm-0"},
+			{Message: "kept cm-1"},
+		}))
+	})
+
+	t.Run("should render merged renderers in one step", func(t *testing.T) {
+		g := NewWithT(t)
+
+		a, err := mem.New([]mem.Source{{Objects: configMaps(2)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		b, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		merged, err := mem.Merge(a, b, mem.DuplicateError)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := merged.NewJob(nil, mem.WithBatchSize(1))
+
+		done, err := job.Step(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(done).To(BeTrue())
+
+		result, err := job.Result()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(Equal(3))
+	})
+}
@@ -0,0 +1,63 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KindHandler handles one rendered object of the kind it was registered for.
+// It may modify the object in place; an error fails the render.
+type KindHandler func(ctx context.Context, obj *unstructured.Unstructured) error
+
+type kindHandler struct {
+	gvk     schema.GroupVersionKind
+	handler KindHandler
+}
+
+func (h kindHandler) matches(gvk schema.GroupVersionKind) bool {
+	return h.gvk.Group == gvk.Group &&
+		h.gvk.Kind == gvk.Kind &&
+		(h.gvk.Version == "" || h.gvk.Version == gvk.Version)
+}
+
+// applyKindHandlers runs the matching handlers on every object, in
+// registration order, and returns the indices of the handled objects.
+func (r *Renderer) applyKindHandlers(ctx context.Context, objects []unstructured.Unstructured) ([]int, error) {
+	handlers := r.opts.KindHandlers
+	if len(handlers) == 0 {
+		return nil, nil
+	}
+
+	handled := make([]int, 0)
+
+	for i := range objects {
+		obj := &objects[i]
+		gvk := obj.GroupVersionKind()
+		matched := false
+
+		for _, h := range handlers {
+			if !h.matches(gvk) {
+				continue
+			}
+
+			if !matched {
+				r.ownObject(obj)
+			}
+
+			if err := h.handler(ctx, obj); err != nil {
+				return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+			}
+
+			matched = true
+		}
+
+		if matched {
+			handled = append(handled, i)
+		}
+	}
+
+	return handled, nil
+}
@@ -0,0 +1,128 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWithKindHandler(t *testing.T) {
+
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	configMapGK := schema.GroupVersionKind{Kind: "ConfigMap"}
+
+	label := func(key string, value string) mem.KindHandler {
+		return func(_ context.Context, obj *unstructured.Unstructured) error {
+			labels := obj.GetLabels()
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+
+			labels[key] = value
+			obj.SetLabels(labels)
+
+			return nil
+		}
+	}
+
+	t.Run("should handle objects of the registered kinds only", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(multiDocYAML)},
+			mem.WithKindHandler(deploymentGVK, label("handled", "deployment")),
+			mem.WithKindHandler(configMapGK, label("handled", "configmap")),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("handled", "configmap"))
+		g.Expect(objects[1].GetLabels()).To(HaveKeyWithValue("handled", "deployment"))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should match versions exactly when set", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(multiDocYAML)},
+			mem.WithKindHandler(schema.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"},
+				label("handled", "true")),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[1].GetLabels()).ToNot(HaveKey("handled"))
+	})
+
+	t.Run("should run handlers in registration order after transformers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(configMapYAML)},
+			mem.WithKindHandler(configMapGK, label("order", "first")),
+			mem.WithKindHandler(configMapGK, label("order", "second")),
+			mem.WithTransformer(func(
+				_ context.Context,
+				obj unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				obj.SetLabels(map[string]string{"order": "transformer"})
+
+				return obj, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("order", "second"))
+	})
+
+	t.Run("should fail the render on handler errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errInvalid := errors.New("invalid")
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(multiDocYAML)},
+			mem.WithKindHandler(deploymentGVK, func(context.Context, *unstructured.Unstructured) error {
+				return errInvalid
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, errInvalid)).To(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("apps/v1/Deployment//second"))
+		g.Expect(objects).To(BeNil())
+	})
+
+	t.Run("should rehash handled objects after sanitization", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(configMapYAML)},
+			mem.WithSanitizer(mem.DefaultSanitizer()),
+			mem.WithKindHandler(configMapGK, func(_ context.Context, obj *unstructured.Unstructured) error {
+				obj.Object["data"] = map[string]any{"count": 3}
+
+				return nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object["data"]).To(Equal(map[string]any{"count": int64(3)}))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+}
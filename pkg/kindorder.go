@@ -0,0 +1,138 @@
+package mem
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// OtherKinds stands for every kind a KindOrder does not list.
+const OtherKinds = "*"
+
+// KindOrder lists kinds in the order WithKindOrdering emits their objects.
+// Kinds are matched by name alone, regardless of their group, as in Helm.
+// Objects of kinds the order does not list are placed where it lists
+// OtherKinds, or last if it does not.
+type KindOrder []string
+
+// installOrder is the order of InstallOrder: cluster-wide prerequisites,
+// configuration and RBAC, workloads, routing, then custom resources, and
+// admission webhooks last, once the services they call exist.
+var installOrder = KindOrder{
+	"Namespace",
+	"CustomResourceDefinition",
+	"PriorityClass",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+	OtherKinds,
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// InstallOrder returns the order in which to apply objects, modeled on the
+// install order of Helm: Namespaces and CustomResourceDefinitions first, then
+// ServiceAccounts, configuration, and RBAC before the workloads using them,
+// custom resources once their definitions exist, and webhook configurations
+// last.
+func InstallOrder() KindOrder {
+	return slices.Clone(installOrder)
+}
+
+// UninstallOrder returns the reverse of InstallOrder, the order in which to
+// delete objects: webhooks first, so they do not block the deletions, then
+// custom resources before their definitions, and Namespaces last.
+func UninstallOrder() KindOrder {
+	order := InstallOrder()
+	slices.Reverse(order)
+
+	return order
+}
+
+func (o KindOrder) validate() error {
+	seen := make(map[string]struct{}, len(o))
+
+	for i, kind := range o {
+		if kind == "" {
+			return fmt.Errorf("%w: empty kind at index %d", ErrInvalidKindOrder, i)
+		}
+
+		if _, ok := seen[kind]; ok {
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidKindOrder, kind)
+		}
+
+		seen[kind] = struct{}{}
+	}
+
+	return nil
+}
+
+// sort orders objects by the rank of their kind, keeping the relative order
+// of objects of the same rank.
+func (o KindOrder) sort(objects []unstructured.Unstructured) {
+	ranks := make(map[string]int, len(o))
+	for i, kind := range o {
+		ranks[kind] = i
+	}
+
+	others, ok := ranks[OtherKinds]
+	if !ok {
+		others = len(o)
+	}
+
+	rank := func(obj unstructured.Unstructured) int {
+		if r, ok := ranks[obj.GetKind()]; ok {
+			return r
+		}
+
+		return others
+	}
+
+	slices.SortStableFunc(objects, func(a unstructured.Unstructured, b unstructured.Unstructured) int {
+		return cmp.Compare(rank(a), rank(b))
+	})
+}
+
+// sortByKey orders objects by group, version, kind, namespace, and name,
+// keeping the relative order of objects with the same key.
+func sortByKey(objects []unstructured.Unstructured) {
+	slices.SortStableFunc(objects, func(a unstructured.Unstructured, b unstructured.Unstructured) int {
+		ga, gb := a.GroupVersionKind(), b.GroupVersionKind()
+
+		return cmp.Or(
+			strings.Compare(ga.Group, gb.Group),
+			strings.Compare(ga.Version, gb.Version),
+			strings.Compare(ga.Kind, gb.Kind),
+			strings.Compare(a.GetNamespace(), b.GetNamespace()),
+			strings.Compare(a.GetName(), b.GetName()),
+		)
+	})
+}
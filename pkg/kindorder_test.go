@@ -0,0 +1,199 @@
+package mem_test
+
+import (
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func unorderedObjects() []unstructured.Unstructured {
+	return []unstructured.Unstructured{
+		webhookConfiguration("ValidatingWebhookConfiguration", "validate"),
+		composeObject("apps/v1", "Deployment", "app", "api"),
+		composeObject("example.com/v1", "Widget", "app", "widget"),
+		composeObject("rbac.authorization.k8s.io/v1", "RoleBinding", "app", "api"),
+		composeObject("v1", "ConfigMap", "app", "b"),
+		composeObject("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "widgets.example.com"),
+		composeObject("v1", "ServiceAccount", "app", "api"),
+		composeObject("v1", "ConfigMap", "app", "a"),
+		composeObject("v1", "Namespace", "", "app"),
+	}
+}
+
+func renderOrdered(t *testing.T, g Gomega, objects []unstructured.Unstructured, opts ...mem.RendererOption) []string {
+	t.Helper()
+
+	renderer, err := mem.New([]mem.Source{{Objects: objects}}, opts...)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	rendered, err := renderer.Process(t.Context(), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return names(rendered)
+}
+
+func TestKindOrdering(t *testing.T) {
+
+	t.Run("should sort in install order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(renderOrdered(t, g, unorderedObjects(), mem.WithKindOrdering(mem.InstallOrder()))).To(Equal([]string{
+			"Namespace/app",
+			"CustomResourceDefinition/widgets.example.com",
+			"ServiceAccount/api",
+			"ConfigMap/b",
+			"ConfigMap/a",
+			"RoleBinding/api",
+			"Deployment/api",
+			"Widget/widget",
+			"ValidatingWebhookConfiguration/validate",
+		}))
+	})
+
+	t.Run("should sort in uninstall order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(renderOrdered(t, g, unorderedObjects(), mem.WithKindOrdering(mem.UninstallOrder()))).To(Equal([]string{
+			"ValidatingWebhookConfiguration/validate",
+			"Widget/widget",
+			"Deployment/api",
+			"RoleBinding/api",
+			"ConfigMap/b",
+			"ConfigMap/a",
+			"ServiceAccount/api",
+			"CustomResourceDefinition/widgets.example.com",
+			"Namespace/app",
+		}))
+	})
+
+	t.Run("should place unlisted kinds at OtherKinds or last", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := unorderedObjects()[1:5]
+
+		order := mem.KindOrder{"ConfigMap", mem.OtherKinds, "Deployment"}
+
+		g.Expect(renderOrdered(t, g, objects, mem.WithKindOrdering(order))).
+			To(Equal([]string{"ConfigMap/b", "Widget/widget", "RoleBinding/api", "Deployment/api"}))
+
+		g.Expect(renderOrdered(t, g, objects, mem.WithKindOrdering(mem.KindOrder{"Widget"}))).
+			To(Equal([]string{"Widget/widget", "Deployment/api", "RoleBinding/api", "ConfigMap/b"}))
+	})
+
+	t.Run("should sort ensured namespaces and keep webhooks last", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			composeObject("apps/v1", "Deployment", "app", "api"),
+			webhookConfiguration("MutatingWebhookConfiguration", "mutate"),
+			composeObject("example.com/v1", "Widget", "app", "widget"),
+		}
+
+		g.Expect(renderOrdered(t, g, objects,
+			mem.WithEnsureNamespaces(true),
+			mem.WithKindOrdering(mem.KindOrder{"Deployment", mem.OtherKinds, "Namespace"}),
+			mem.WithWebhooksLast(true),
+		)).To(Equal([]string{
+			"Deployment/api", "Widget/widget", "Namespace/app", "MutatingWebhookConfiguration/mutate",
+		}))
+	})
+
+	t.Run("should keep the order by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(renderOrdered(t, g, unorderedObjects())).To(Equal(names(unorderedObjects())))
+		g.Expect(renderOrdered(t, g, unorderedObjects(), mem.WithKindOrdering(nil))).
+			To(Equal(names(unorderedObjects())))
+	})
+
+	t.Run("should reject invalid orders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithKindOrdering(mem.KindOrder{"Service", ""}))
+		g.Expect(err).To(MatchError(mem.ErrInvalidKindOrder))
+
+		_, err = mem.New(nil, mem.WithKindOrdering(mem.KindOrder{mem.OtherKinds, "Service", mem.OtherKinds}))
+		g.Expect(err).To(MatchError(mem.ErrInvalidKindOrder))
+	})
+
+	t.Run("should return independent orders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		order := mem.InstallOrder()
+		order[0] = "Job"
+
+		g.Expect(mem.InstallOrder()[0]).To(Equal("Namespace"))
+		g.Expect(mem.UninstallOrder()[len(order)-1]).To(Equal("Namespace"))
+	})
+}
+
+func TestStableSort(t *testing.T) {
+
+	t.Run("should sort by GVK, namespace, and name", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "b", "config"),
+			composeObject("apps/v1", "Deployment", "app", "api"),
+			composeObject("v1", "ConfigMap", "a", "z"),
+			composeObject("v1", "ConfigMap", "a", "config"),
+			composeObject("v1", "Namespace", "", "app"),
+			composeObject("apps/v1", "DaemonSet", "app", "agent"),
+		}
+
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, mem.WithStableSort(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		rendered, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		keys := make([]string, 0, len(rendered))
+		for _, obj := range rendered {
+			keys = append(keys, mem.ObjectKeyOf(obj))
+		}
+
+		g.Expect(keys).To(Equal([]string{
+			"v1/ConfigMap/a/config",
+			"v1/ConfigMap/a/z",
+			"v1/ConfigMap/b/config",
+			"v1/Namespace//app",
+			"apps/v1/DaemonSet/app/agent",
+			"apps/v1/Deployment/app/api",
+		}))
+	})
+
+	t.Run("should not depend on source order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		forward := renderOrdered(t, g, unorderedObjects(), mem.WithStableSort(true))
+
+		reversed := unorderedObjects()
+		slices.Reverse(reversed)
+
+		g.Expect(renderOrdered(t, g, reversed, mem.WithStableSort(true))).To(Equal(forward))
+	})
+
+	t.Run("should keep sorted order within kind ranks", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(renderOrdered(t, g, unorderedObjects(),
+			mem.WithStableSort(true),
+			mem.WithKindOrdering(mem.InstallOrder()),
+		)).To(Equal([]string{
+			"Namespace/app",
+			"CustomResourceDefinition/widgets.example.com",
+			"ServiceAccount/api",
+			"ConfigMap/a",
+			"ConfigMap/b",
+			"RoleBinding/api",
+			"Deployment/api",
+			"Widget/widget",
+			"ValidatingWebhookConfiguration/validate",
+		}))
+	})
+}
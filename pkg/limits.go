@@ -0,0 +1,53 @@
+package mem
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MaxEtcdObjectBytes is the largest object etcd stores by default, 1.5 MiB;
+// the API server rejects larger objects at apply time.
+const MaxEtcdObjectBytes = 1536 << 10
+
+func validateLimits(maxObjects int, maxObjectBytes int) error {
+	if maxObjects < 0 || maxObjectBytes < 0 {
+		return fmt.Errorf("%w: limits must not be negative, got %d objects and %d bytes",
+			ErrInvalidLimits, maxObjects, maxObjectBytes)
+	}
+
+	return nil
+}
+
+// checkObjectCount fails with ErrLimitExceeded once source, the index-th
+// input, has emitted count objects, more than MaxObjects.
+func (r *Renderer) checkObjectCount(count int, index int, source Source) error {
+	if r.opts.MaxObjects > 0 && count > r.opts.MaxObjects {
+		return fmt.Errorf("%w: %s emits more than %d objects",
+			ErrLimitExceeded, sourceLocation(index, source), r.opts.MaxObjects)
+	}
+
+	return nil
+}
+
+// checkObjectSize fails with ErrLimitExceeded if the JSON serialization of
+// obj, the k-th object of source, the index-th input, is larger than
+// MaxObjectBytes.
+func (r *Renderer) checkObjectSize(obj *unstructured.Unstructured, index int, source Source, k int) error {
+	if r.opts.MaxObjectBytes <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return fmt.Errorf("failed to measure %s: %w", objectLocation(obj, index, source, k), err)
+	}
+
+	if len(data) > r.opts.MaxObjectBytes {
+		return fmt.Errorf("%w: %s is %d bytes, more than the limit of %d",
+			ErrLimitExceeded, objectLocation(obj, index, source, k), len(data), r.opts.MaxObjectBytes)
+	}
+
+	return nil
+}
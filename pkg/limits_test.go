@@ -0,0 +1,85 @@
+package mem_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLimits(t *testing.T) {
+
+	render := func(sources []mem.Source, opts ...mem.RendererOption) ([]unstructured.Unstructured, error) {
+		renderer, err := mem.New(sources, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return renderer.Process(t.Context(), nil)
+	}
+
+	t.Run("should render within the limits", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(
+			[]mem.Source{{Objects: configMaps(3)}, {Objects: configMaps(3)}},
+			mem.WithLimits(3, mem.MaxEtcdObjectBytes),
+			mem.WithDuplicatePolicy(mem.DuplicateKeepAll),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(6))
+	})
+
+	t.Run("should fail when a source emits too many objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render([]mem.Source{{Name: "bulk", Objects: configMaps(4)}}, mem.WithLimits(3, 0))
+		g.Expect(err).To(MatchError(mem.ErrLimitExceeded))
+		g.Expect(err.Error()).To(ContainSubstring("source 0 (bulk) emits more than 3 objects"))
+	})
+
+	t.Run("should count the items of expanded lists", func(t *testing.T) {
+		g := NewWithT(t)
+
+		list := unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items": []any{
+				configMaps(1)[0].Object,
+				composeObject("v1", "ConfigMap", "", "cm-1").Object,
+			},
+		}}
+
+		_, err := render([]mem.Source{{Objects: []unstructured.Unstructured{list}}},
+			mem.WithListExpansion(true), mem.WithLimits(1, 0))
+		g.Expect(err).To(MatchError(mem.ErrLimitExceeded))
+	})
+
+	t.Run("should fail on objects larger than the size limit", func(t *testing.T) {
+		g := NewWithT(t)
+
+		large := composeObject("v1", "ConfigMap", "app", "large")
+		large.Object["data"] = map[string]any{"blob": strings.Repeat("x", mem.MaxEtcdObjectBytes)}
+
+		_, err := render(
+			[]mem.Source{{Objects: []unstructured.Unstructured{
+				composeObject("v1", "ConfigMap", "app", "small"), large,
+			}}},
+			mem.WithLimits(0, mem.MaxEtcdObjectBytes),
+		)
+		g.Expect(err).To(MatchError(mem.ErrLimitExceeded))
+		g.Expect(err.Error()).To(ContainSubstring("source 0 object 1 (v1/ConfigMap/app/large) is"))
+		g.Expect(err.Error()).To(ContainSubstring("more than the limit of 1572864"))
+	})
+
+	t.Run("should reject negative limits", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithLimits(-1, 0))
+		g.Expect(err).To(MatchError(mem.ErrInvalidLimits))
+	})
+}
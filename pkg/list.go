@@ -0,0 +1,57 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// isList reports whether obj is a list of objects, such as a v1 List or a
+// typed list like ConfigMapList. Metadata-only lists are not, as they are
+// resolved rather than expanded.
+func isList(obj unstructured.Unstructured) bool {
+	return strings.HasSuffix(obj.GetKind(), "List") && obj.IsList() && !IsMetadataOnly(obj)
+}
+
+// appendListItems appends the items of list to objects as appendObject does,
+// so items are copied, resolved, and expanded again if they are lists. Items
+// of typed lists without apiVersion and kind, as the API server returns them,
+// get the list's apiVersion and item kind.
+func (r *Renderer) appendListItems(
+	ctx context.Context,
+	objects []unstructured.Unstructured,
+	list unstructured.Unstructured,
+) ([]unstructured.Unstructured, error) {
+	items, _, _ := unstructured.NestedFieldNoCopy(list.Object, "items")
+	itemKind := strings.TrimSuffix(list.GetKind(), "List")
+
+	for i, item := range items.([]any) {
+		content, ok := item.(map[string]any)
+		if !ok || len(content) == 0 {
+			return nil, fmt.Errorf("%w: item %d of %s", ErrObjectEmpty, i, ObjectKeyOf(list))
+		}
+
+		obj := unstructured.Unstructured{Object: content}
+
+		if itemKind != "" && obj.GetKind() == "" {
+			obj.Object = maps.Clone(content)
+			obj.SetKind(itemKind)
+
+			if obj.GetAPIVersion() == "" {
+				obj.SetAPIVersion(list.GetAPIVersion())
+			}
+		}
+
+		var err error
+
+		objects, err = r.appendObject(ctx, objects, obj)
+		if err != nil {
+			return nil, fmt.Errorf("item %d of %s: %w", i, ObjectKeyOf(list), err)
+		}
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,116 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func listObject(kind string, items ...any) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"items":      items,
+	}}
+}
+
+func TestListExpansion(t *testing.T) {
+
+	configMap := composeObject("v1", "ConfigMap", "app", "first")
+	nested := listObject("List", composeObject("v1", "Secret", "app", "nested").Object)
+	list := listObject("List", configMap.Object, nested.Object)
+
+	t.Run("should render the items of lists", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				{Objects: []unstructured.Unstructured{composeObject("v1", "Service", "app", "web")}},
+				{Name: "listed", Objects: []unstructured.Unstructured{list}},
+			},
+			mem.WithListExpansion(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects := result.View().DeepCopy()
+		g.Expect(names(objects)).To(Equal([]string{"Service/web", "ConfigMap/first", "Secret/nested"}))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+
+		for i := 1; i < 3; i++ {
+			provenance, ok := result.Provenance(i)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(provenance.Source).To(Equal(1))
+			g.Expect(provenance.Name).To(Equal("listed"))
+		}
+
+		items, _, _ := unstructured.NestedSlice(list.Object, "items")
+		g.Expect(items).To(HaveLen(2))
+		g.Expect(items[0]).To(HaveKeyWithValue("metadata", Not(HaveKey("annotations"))))
+	})
+
+	t.Run("should infer the kind of typed list items", func(t *testing.T) {
+		g := NewWithT(t)
+
+		item := map[string]any{"metadata": map[string]any{"name": "bare", "namespace": "app"}}
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{listObject("ConfigMapList", item)}}},
+			mem.WithListExpansion(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetAPIVersion()).To(Equal("v1"))
+		g.Expect(objects[0].GetKind()).To(Equal("ConfigMap"))
+		g.Expect(item).ToNot(HaveKey("kind"))
+	})
+
+	t.Run("should reject empty items", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{
+				listObject("List", configMap.Object, map[string]any{}),
+			}}},
+			mem.WithListExpansion(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrObjectEmpty))
+		g.Expect(err).To(MatchError(ContainSubstring("item 1 of v1/List//")))
+	})
+
+	t.Run("should keep lists when disabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: []unstructured.Unstructured{list}}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetKind()).To(Equal("List"))
+	})
+}
+
+func TestSourceFromList(t *testing.T) {
+
+	t.Run("should use the items of the list", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source := mem.SourceFromList(&unstructured.UnstructuredList{
+			Items: []unstructured.Unstructured{composeObject("v1", "ConfigMap", "app", "first")},
+		})
+		g.Expect(source.Objects).To(HaveLen(1))
+		g.Expect(mem.SourceFromList(nil).Objects).To(BeEmpty())
+	})
+}
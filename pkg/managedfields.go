@@ -0,0 +1,173 @@
+package mem
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ignoredApplyFields are the top-level fields the API server does not track in
+// managedFields: they identify the object or are reset on write.
+//
+//nolint:gochecknoglobals
+var ignoredApplyFields = map[string]struct{}{
+	"apiVersion": {},
+	"kind":       {},
+	"status":     {},
+}
+
+// ignoredApplyMetadata are the metadata fields the API server does not track
+// in managedFields.
+//
+//nolint:gochecknoglobals
+var ignoredApplyMetadata = map[string]struct{}{
+	"name":                       {},
+	"namespace":                  {},
+	"uid":                        {},
+	"resourceVersion":            {},
+	"generation":                 {},
+	"creationTimestamp":          {},
+	"deletionTimestamp":          {},
+	"deletionGracePeriodSeconds": {},
+	"managedFields":              {},
+	"selfLink":                   {},
+}
+
+// ManagedFieldsEntry predicts the managedFields entry the API server records
+// when manager server-side applies obj, so diff tooling can detect ownership
+// conflicts with other managers before applying.
+//
+// Without the OpenAPI schema the prediction is structural: maps are granular,
+// lists registered in keys are tracked item by item (as "k:" entries, like
+// lists with a patch merge key or list-type map), and every other list is
+// atomic. Status is not tracked, as for resources with a status subresource.
+// The entry carries no timestamp, so output stays deterministic.
+func ManagedFieldsEntry(
+	obj unstructured.Unstructured,
+	manager string,
+	keys MergeKeys,
+) (metav1.ManagedFieldsEntry, error) {
+	set := make(map[string]any)
+
+	for field, value := range obj.Object {
+		if _, ignored := ignoredApplyFields[field]; ignored {
+			continue
+		}
+
+		if field == "metadata" {
+			metadata, _ := value.(map[string]any)
+
+			fields, err := metadataFieldSet(metadata)
+			if err != nil {
+				return metav1.ManagedFieldsEntry{}, err
+			}
+
+			if len(fields) > 0 {
+				set["f:metadata"] = fields
+			}
+
+			continue
+		}
+
+		fields, err := fieldSet(value, keys[obj.GroupVersionKind().GroupKind()], field)
+		if err != nil {
+			return metav1.ManagedFieldsEntry{}, fmt.Errorf("%s: %w", ObjectKeyOf(obj), err)
+		}
+
+		set["f:"+field] = fields
+	}
+
+	raw, err := json.Marshal(set)
+	if err != nil {
+		return metav1.ManagedFieldsEntry{}, fmt.Errorf("failed to encode field set: %w", err)
+	}
+
+	return metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  metav1.ManagedFieldsOperationApply,
+		APIVersion: obj.GetAPIVersion(),
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: raw},
+	}, nil
+}
+
+func metadataFieldSet(metadata map[string]any) (map[string]any, error) {
+	set := make(map[string]any)
+
+	for field, value := range metadata {
+		if _, ignored := ignoredApplyMetadata[field]; ignored {
+			continue
+		}
+
+		fields, err := fieldSet(value, nil, "metadata."+field)
+		if err != nil {
+			return nil, err
+		}
+
+		set["f:"+field] = fields
+	}
+
+	return set, nil
+}
+
+// fieldSet returns the FieldsV1 encoding of the fields set by value, found at
+// path. Scalars, empty maps, and lists without a merge key are leaves.
+func fieldSet(value any, keys map[string]string, path string) (map[string]any, error) {
+	set := make(map[string]any)
+
+	switch v := value.(type) {
+	case map[string]any:
+		for field, child := range v {
+			fields, err := fieldSet(child, keys, joinFieldPath(path, field))
+			if err != nil {
+				return nil, err
+			}
+
+			set["f:"+field] = fields
+		}
+	case []any:
+		key := keys[path]
+		if key == "" {
+			break
+		}
+
+		for i, item := range v {
+			itemMap, ok := item.(map[string]any)
+			if !ok || itemMap[key] == nil {
+				return nil, fmt.Errorf("%w: item %d of %s has no %q", ErrMissingMergeKey, i, path, key)
+			}
+
+			keyJSON, err := json.Marshal(map[string]any{key: itemMap[key]})
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode key of item %d of %s: %w", i, path, err)
+			}
+
+			fields, err := fieldSet(itemMap, keys, path)
+			if err != nil {
+				return nil, err
+			}
+
+			fields["."] = map[string]any{}
+			set["k:"+string(keyJSON)] = fields
+		}
+	}
+
+	return set, nil
+}
+
+// stampManagedFields replaces the managedFields of every object with the entry
+// predicted for manager.
+func stampManagedFields(objects []unstructured.Unstructured, manager string, keys MergeKeys) error {
+	for i := range objects {
+		entry, err := ManagedFieldsEntry(objects[i], manager, keys)
+		if err != nil {
+			return fmt.Errorf("failed to compute managed fields: %w", err)
+		}
+
+		objects[i].SetManagedFields([]metav1.ManagedFieldsEntry{entry})
+	}
+
+	return nil
+}
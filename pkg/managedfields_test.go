@@ -0,0 +1,185 @@
+package mem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	. "github.com/onsi/gomega"
+)
+
+const managedDeploymentYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  labels:
+    app: web
+  managedFields:
+  - manager: kubectl
+    operation: Update
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx
+        args: ["--port", "80"]
+status:
+  replicas: 2
+`
+
+//nolint:gochecknoglobals
+var deploymentKeys = mem.MergeKeys{
+	schema.GroupKind{Group: "apps", Kind: "Deployment"}: {"spec.template.spec.containers": "name"},
+}
+
+func fieldsOf(g *WithT, entry metav1.ManagedFieldsEntry) map[string]any {
+	fields := make(map[string]any)
+	g.Expect(json.Unmarshal(entry.FieldsV1.Raw, &fields)).To(Succeed())
+
+	return fields
+}
+
+func TestManagedFieldsEntry(t *testing.T) {
+
+	t.Run("should track the applied fields", func(t *testing.T) {
+		g := NewWithT(t)
+
+		entry, err := mem.ManagedFieldsEntry(mem.MustUnstructured(managedDeploymentYAML), "my-operator", deploymentKeys)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(entry.Manager).To(Equal("my-operator"))
+		g.Expect(entry.Operation).To(Equal(metav1.ManagedFieldsOperationApply))
+		g.Expect(entry.APIVersion).To(Equal("apps/v1"))
+		g.Expect(entry.FieldsType).To(Equal("FieldsV1"))
+		g.Expect(entry.Time).To(BeNil())
+
+		g.Expect(fieldsOf(g, entry)).To(Equal(map[string]any{
+			"f:metadata": map[string]any{
+				"f:labels": map[string]any{"f:app": map[string]any{}},
+			},
+			"f:spec": map[string]any{
+				"f:replicas": map[string]any{},
+				"f:template": map[string]any{
+					"f:spec": map[string]any{
+						"f:containers": map[string]any{
+							`k:{"name":"app"}`: map[string]any{
+								".":       map[string]any{},
+								"f:name":  map[string]any{},
+								"f:image": map[string]any{},
+								"f:args":  map[string]any{},
+							},
+						},
+					},
+				},
+			},
+		}))
+	})
+
+	t.Run("should treat lists without a merge key as atomic", func(t *testing.T) {
+		g := NewWithT(t)
+
+		entry, err := mem.ManagedFieldsEntry(mem.MustUnstructured(managedDeploymentYAML), "my-operator", nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		spec, _ := fieldsOf(g, entry)["f:spec"].(map[string]any)
+		template, _ := spec["f:template"].(map[string]any)
+		g.Expect(template).To(Equal(map[string]any{
+			"f:spec": map[string]any{"f:containers": map[string]any{}},
+		}))
+	})
+
+	t.Run("should reject keyed list items without their key", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := mem.MustUnstructured(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+`)
+
+		_, err := mem.ManagedFieldsEntry(obj, "my-operator", deploymentKeys)
+		g.Expect(errors.Is(err, mem.ErrMissingMergeKey)).To(BeTrue())
+	})
+}
+
+func TestWithFieldManager(t *testing.T) {
+
+	t.Run("should replace managed fields of rendered objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(managedDeploymentYAML)},
+			mem.WithFieldManager("my-operator"),
+			mem.WithMergeKeys(deploymentKeys),
+			mem.WithGenerationAnnotation("7"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+
+		managed := objects[0].GetManagedFields()
+		g.Expect(managed).To(HaveLen(1))
+		g.Expect(managed[0].Manager).To(Equal("my-operator"))
+
+		metadata, _ := fieldsOf(g, managed[0])["f:metadata"].(map[string]any)
+		g.Expect(metadata).To(HaveKey("f:annotations"))
+
+		annotations, _ := metadata["f:annotations"].(map[string]any)
+		g.Expect(annotations).To(HaveKey("f:" + mem.AnnotationGeneration))
+	})
+
+	t.Run("should keep content hashes verifiable", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(managedDeploymentYAML)},
+			mem.WithFieldManager("my-operator"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should fail the render on invalid keyed lists", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+`)},
+			mem.WithFieldManager("my-operator"),
+			mem.WithMergeKeys(deploymentKeys),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrMissingMergeKey)).To(BeTrue())
+		g.Expect(objects).To(BeNil())
+	})
+}
@@ -0,0 +1,42 @@
+package mem
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// decodeManifests decodes the Manifests of a source whose first offset
+// objects come from elsewhere. Positions record the index of the manifest as
+// their Input.
+func decodeManifests(
+	manifests []string,
+	offset int,
+	opts *RendererOptions,
+) ([]unstructured.Unstructured, []Position, error) {
+	decoder := NewYAMLDecoder(opts.YAMLOptions...)
+
+	objects := make([]unstructured.Unstructured, 0, len(manifests))
+	positions := make([]Position, 0, len(manifests))
+
+	for i, manifest := range manifests {
+		decoded, decodedPositions, err := decoder.decode(manifest)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid manifest at index %d: %w", i, err)
+		}
+
+		for j := range decoded {
+			decodedPositions[j].Input = i
+
+			if err := validateObject(offset+len(objects), decoded[j], opts); err != nil {
+				return nil, nil, &PositionError{Position: decodedPositions[j], Err: err}
+			}
+
+			objects = append(objects, decoded[j])
+		}
+
+		positions = append(positions, decodedPositions...)
+	}
+
+	return objects, positions, nil
+}
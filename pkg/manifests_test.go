@@ -0,0 +1,142 @@
+package mem_test
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestManifests(t *testing.T) {
+
+	t.Run("should decode manifests at render time", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured, 1)
+		ch <- mem.MustUnstructured("apiVersion: v1\nkind: Secret\nmetadata:\n  name: streamed\n")
+		close(ch)
+
+		renderer, err := mem.New([]mem.Source{{
+			Objects:   configMaps(1),
+			Manifests: []string{multiDocYAML, configMapYAML},
+			Stream:    mem.NewChannelStream(ch, mem.StreamWaitForClose),
+		}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(result.View().DeepCopy())).To(Equal([]string{
+			"ConfigMap/cm-0",
+			"ConfigMap/first",
+			"Deployment/second",
+			"ConfigMap/test-config",
+			"Secret/streamed",
+		}))
+
+		provenance, ok := result.Provenance(0)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Position).To(BeNil())
+
+		provenance, ok = result.Provenance(2)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Position).To(Equal(&mem.Position{Document: 2, Line: 9}))
+
+		provenance, ok = result.Provenance(3)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Position).To(HaveField("Input", 1))
+	})
+
+	t.Run("should annotate decoded objects with their position", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Manifests: []string{configMapYAML, multiDocYAML}}},
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+
+		position, ok, err := mem.SourcePosition(objects[1])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ok).To(BeTrue())
+		g.Expect(position).To(Equal(mem.Position{Input: 1, Line: 1}))
+	})
+
+	t.Run("should report the document and line of syntax errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// Construction does not decode manifests.
+		renderer, err := mem.New([]mem.Source{{
+			Manifests: []string{configMapYAML, "apiVersion: v1\nkind: ConfigMap\n---\nmetadata: [\n"},
+		}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring(
+			"invalid source at index 0: invalid manifest at index 1: document 1, line 4")))
+
+		var yamlErr *mem.YAMLError
+		g.Expect(errors.As(err, &yamlErr)).To(BeTrue())
+		g.Expect(yamlErr.Document).To(Equal(1))
+	})
+
+	t.Run("should validate decoded objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{
+			Objects: configMaps(2),
+			Manifests: []string{
+				configMapYAML,
+				"apiVersion: meta.k8s.io/v1\nkind: PartialObjectMetadata\nmetadata:\n  name: partial\n",
+			},
+		}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrMetadataOnlyObject)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring("at index 3")))
+
+		var positionErr *mem.PositionError
+		g.Expect(errors.As(err, &positionErr)).To(BeTrue())
+		g.Expect(positionErr.Position).To(Equal(mem.Position{Input: 1, Line: 1}))
+	})
+
+	t.Run("should decode with the renderer's YAML options", func(t *testing.T) {
+		g := NewWithT(t)
+
+		duplicated := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  name: b\n"
+
+		renderer, err := mem.New([]mem.Source{{Manifests: []string{duplicated}}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		renderer, err = mem.New(
+			[]mem.Source{{Manifests: []string{duplicated}}},
+			mem.WithYAMLOptions(mem.WithStrictYAML(true)),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+
+		var yamlErr *mem.YAMLError
+		g.Expect(errors.As(err, &yamlErr)).To(BeTrue())
+
+		renderer, err = mem.New(
+			[]mem.Source{{Manifests: []string{configMapYAML}}},
+			mem.WithYAMLOptions(mem.WithYAMLLimits(mem.YAMLLimits{MaxInputSize: 8})),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrYAMLLimitExceeded)).To(BeTrue())
+	})
+}
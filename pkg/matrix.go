@@ -0,0 +1,224 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	matrixDirPerm  = 0o755
+	matrixFilePerm = 0o644
+)
+
+// EnvConfig describes how the base sources of a Matrix are adapted for one environment.
+type EnvConfig struct {
+	// Namespace, if set, replaces the namespace of every object.
+	Namespace string
+
+	// Labels are added to every object's metadata.
+	Labels map[string]string
+
+	// Annotations are added to every object's metadata.
+	Annotations map[string]string
+
+	// Patches are applied to matching objects. As with overlays, a patch
+	// matching no object is an error.
+	Patches []Patch
+
+	// Values are passed to Process as render-time values. Generator sources
+	// receive them through Source.ObjectsFn and Source.Fingerprint; filters,
+	// transformers, and post-renderers can read them through
+	// EnvironmentFromContext.
+	Values types.Values
+}
+
+// Matrix renders one set of base sources across several environments,
+// supporting monorepo-style manifest generation from a single in-memory definition.
+type Matrix struct {
+	// Sources are the base sources shared by every environment.
+	Sources []Source
+
+	// Environments maps environment names to their configuration.
+	Environments map[string]EnvConfig
+
+	// Options configure the renderer used for every environment.
+	Options []RendererOption
+
+	// MergeKeys lets environment patches merge lists by key instead of
+	// replacing them, as for Bundle.
+	MergeKeys MergeKeys
+}
+
+type environmentKey struct{}
+
+type environment struct {
+	name   string
+	config EnvConfig
+}
+
+// EnvironmentFromContext returns the environment being rendered by Matrix.Render.
+// It reports false outside of a matrix render.
+func EnvironmentFromContext(ctx context.Context) (string, EnvConfig, bool) {
+	env, ok := ctx.Value(environmentKey{}).(environment)
+
+	return env.name, env.config, ok
+}
+
+// Render renders every environment and returns the objects keyed by environment name.
+// Environments are rendered in name order; the first failure aborts the render.
+// Environment-specific changes are applied after the renderer's own filters,
+// transformers, and post-renderers, and content hashes are recomputed afterwards
+// so they describe the final objects.
+func (m Matrix) Render(ctx context.Context) (map[string][]unstructured.Unstructured, error) {
+	results := make(map[string][]unstructured.Unstructured, len(m.Environments))
+
+	for _, name := range slices.Sorted(maps.Keys(m.Environments)) {
+		config := m.Environments[name]
+
+		opts := slices.Clone(m.Options)
+		opts = append(opts, WithPostRenderer(config.postRenderer(m.MergeKeys, m.options())))
+
+		renderer, err := New(m.Sources, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create renderer for environment %q: %w", name, err)
+		}
+
+		envCtx := context.WithValue(ctx, environmentKey{}, environment{name: name, config: config})
+
+		objects, err := renderer.Process(envCtx, config.Values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render environment %q: %w", name, err)
+		}
+
+		results[name] = objects
+	}
+
+	return results, nil
+}
+
+// options returns the renderer options of the matrix.
+func (m Matrix) options() RendererOptions {
+	var opts RendererOptions
+	for _, opt := range m.Options {
+		opt.ApplyTo(&opts)
+	}
+
+	return opts
+}
+
+// postRenderer applies c to the rendered objects. Under WithDeepCopy(false)
+// in opts, the objects share their content with the sources, so the targets
+// of patches are deep copied first, as the renderer does for source patches;
+// the namespace, labels, and annotations are metadata, which every render copies.
+func (c EnvConfig) postRenderer(keys MergeKeys, opts RendererOptions) types.PostRenderer {
+	hasher := opts.hasher()
+	overlay := Overlay{
+		Patches:           c.Patches,
+		Namespace:         c.Namespace,
+		CommonLabels:      c.Labels,
+		CommonAnnotations: c.Annotations,
+	}
+
+	return func(_ context.Context, objects []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+		if opts.NoDeepCopy {
+			deepCopyPatchTargets(objects, overlay.Patches)
+		}
+
+		objects, err := overlay.apply(objects, keys)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range objects {
+			if _, hashed := objects[i].GetAnnotations()[types.AnnotationContentHash]; !hashed {
+				continue
+			}
+
+			if err := hasher.set(&objects[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		return objects, nil
+	}
+}
+
+// WriteMatrix writes the output of Matrix.Render to dir, one subdirectory per
+// environment and one YAML file per object, named
+// <kind>[.<group>]_[<namespace>_]<name>.yaml in lower case. Existing files with
+// the same names are overwritten; other files are left untouched. Fields
+// selected by WithExportRedaction are masked in the written files only.
+func WriteMatrix(dir string, outputs map[string][]unstructured.Unstructured, opts ...ExportOption) error {
+	redactor, err := newRedactor(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, env := range slices.Sorted(maps.Keys(outputs)) {
+		if env == "" || env == "." || env == ".." || strings.ContainsAny(env, `/\`) {
+			return fmt.Errorf("%w: %q", ErrInvalidEnvironmentName, env)
+		}
+
+		envDir := filepath.Join(dir, env)
+		if err := os.MkdirAll(envDir, matrixDirPerm); err != nil {
+			return fmt.Errorf("failed to create directory for environment %q: %w", env, err)
+		}
+
+		written := make(map[string]struct{}, len(outputs[env]))
+
+		for i := range outputs[env] {
+			obj := &outputs[env][i]
+
+			name := manifestFileName(obj)
+			if _, dup := written[name]; dup {
+				return fmt.Errorf("%w: %s in environment %q", ErrDuplicateObject, name, env)
+			}
+
+			written[name] = struct{}{}
+
+			redacted, err := redactor.redact(obj)
+			if err != nil {
+				return fmt.Errorf("%w in environment %q", err, env)
+			}
+
+			data, err := yaml.Marshal(redacted.Object)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s in environment %q: %w", name, env, err)
+			}
+
+			if err := os.WriteFile(filepath.Join(envDir, name), data, matrixFilePerm); err != nil {
+				return fmt.Errorf("failed to write %s in environment %q: %w", name, env, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func manifestFileName(obj *unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+
+	kind := gvk.Kind
+	if gvk.Group != "" {
+		kind += "." + gvk.Group
+	}
+
+	parts := []string{kind}
+	if ns := obj.GetNamespace(); ns != "" {
+		parts = append(parts, ns)
+	}
+
+	parts = append(parts, obj.GetName())
+
+	return strings.ToLower(strings.Join(parts, "_")) + ".yaml"
+}
@@ -0,0 +1,221 @@
+package mem_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMatrix(t *testing.T) {
+
+	base := []mem.Source{mem.MustSourceFromYAML(bundleDeploymentYAML, configMapYAML)}
+
+	t.Run("should render every environment", func(t *testing.T) {
+		g := NewWithT(t)
+
+		outputs, err := mem.Matrix{
+			Sources: base,
+			Environments: map[string]mem.EnvConfig{
+				"dev": {Namespace: "dev", Labels: map[string]string{"env": "dev"}},
+				"prod": {
+					Namespace:   "prod",
+					Labels:      map[string]string{"env": "prod"},
+					Annotations: map[string]string{"tier": "critical"},
+					Patches: []mem.Patch{{
+						Target: mem.PatchTarget{Kind: "Deployment"},
+						Merge:  map[string]any{"spec": map[string]any{"replicas": int64(5)}},
+					}},
+				},
+			},
+		}.Render(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(outputs).To(HaveLen(2))
+
+		g.Expect(outputs["dev"][0].Object).To(And(
+			jqmatcher.Match(`.metadata.namespace == "dev"`),
+			jqmatcher.Match(`.metadata.labels.env == "dev"`),
+			jqmatcher.Match(`.spec.replicas == 1`),
+		))
+		g.Expect(outputs["prod"][0].Object).To(And(
+			jqmatcher.Match(`.metadata.namespace == "prod"`),
+			jqmatcher.Match(`.metadata.annotations.tier == "critical"`),
+			jqmatcher.Match(`.spec.replicas == 5`),
+		))
+
+		// The base is shared, not modified.
+		g.Expect(base[0].Objects[0].GetNamespace()).To(BeEmpty())
+	})
+
+	t.Run("should not modify sources without deep copies", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config := composeObject("v1", "ConfigMap", "app", "config")
+		config.Object["data"] = map[string]any{"k": "base"}
+		sources := []mem.Source{{Objects: []unstructured.Unstructured{config}}}
+
+		patch := func(key string, value string) []mem.Patch {
+			return []mem.Patch{{
+				Target: mem.PatchTarget{Kind: "ConfigMap"},
+				Merge:  map[string]any{"data": map[string]any{key: value}},
+			}}
+		}
+
+		outputs, err := mem.Matrix{
+			Sources: sources,
+			Environments: map[string]mem.EnvConfig{
+				"dev":  {Namespace: "dev", Labels: map[string]string{"env": "dev"}, Patches: patch("k", "dev")},
+				"prod": {Namespace: "prod", Labels: map[string]string{"env": "prod"}, Patches: patch("extra", "prod")},
+			},
+			Options: []mem.RendererOption{mem.WithDeepCopy(false)},
+		}.Render(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(outputs["dev"][0].Object["data"]).To(Equal(map[string]any{"k": "dev"}))
+		g.Expect(outputs["prod"][0].Object["data"]).To(Equal(map[string]any{"k": "base", "extra": "prod"}))
+		g.Expect(sources[0].Objects[0].Object["data"]).To(Equal(map[string]any{"k": "base"}))
+		g.Expect(sources[0].Objects[0].GetNamespace()).To(Equal("app"))
+		g.Expect(sources[0].Objects[0].GetLabels()).To(BeEmpty())
+	})
+
+	t.Run("should hash the final objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		outputs, err := mem.Matrix{
+			Sources: base,
+			Environments: map[string]mem.EnvConfig{
+				"a": {Namespace: "a"},
+				"b": {Namespace: "b"},
+			},
+		}.Render(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		hashA := outputs["a"][0].GetAnnotations()[pkgtypes.AnnotationContentHash]
+		hashB := outputs["b"][0].GetAnnotations()[pkgtypes.AnnotationContentHash]
+		g.Expect(hashA).ToNot(BeEmpty())
+		g.Expect(hashA).ToNot(Equal(hashB))
+
+		// Same result as rendering the final object directly.
+		direct, err := mem.New([]mem.Source{{Objects: []unstructured.Unstructured{stripHash(outputs["a"][0])}}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := direct.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()[pkgtypes.AnnotationContentHash]).To(Equal(hashA))
+	})
+
+	t.Run("should expose the environment to transformers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		outputs, err := mem.Matrix{
+			Sources: base,
+			Environments: map[string]mem.EnvConfig{
+				"staging": {Values: pkgtypes.Values{"replicas": int64(2)}},
+			},
+			Options: []mem.RendererOption{
+				mem.WithTransformer(func(
+					ctx context.Context,
+					obj unstructured.Unstructured,
+				) (unstructured.Unstructured, error) {
+					name, config, ok := mem.EnvironmentFromContext(ctx)
+					if ok && obj.GetKind() == "Deployment" {
+						obj.SetLabels(map[string]string{"env": name})
+						err := unstructured.SetNestedField(obj.Object, config.Values["replicas"], "spec", "replicas")
+
+						return obj, err
+					}
+
+					return obj, nil
+				}),
+			},
+		}.Render(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(outputs["staging"][0].Object).To(And(
+			jqmatcher.Match(`.metadata.labels.env == "staging"`),
+			jqmatcher.Match(`.spec.replicas == 2`),
+		))
+
+		_, _, ok := mem.EnvironmentFromContext(t.Context())
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("should name the failing environment", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Matrix{
+			Sources: base,
+			Environments: map[string]mem.EnvConfig{
+				"broken": {Patches: []mem.Patch{{Target: mem.PatchTarget{Kind: "Secret"}}}},
+			},
+		}.Render(t.Context())
+		g.Expect(err).To(MatchError(mem.ErrPatchTargetNotFound))
+		g.Expect(err).To(MatchError(ContainSubstring(`environment "broken"`)))
+	})
+}
+
+func TestWriteMatrix(t *testing.T) {
+
+	t.Run("should write one directory per environment", func(t *testing.T) {
+		g := NewWithT(t)
+		dir := t.TempDir()
+
+		outputs, err := mem.Matrix{
+			Sources: []mem.Source{mem.MustSourceFromYAML(bundleDeploymentYAML, configMapYAML)},
+			Environments: map[string]mem.EnvConfig{
+				"dev":  {Namespace: "dev"},
+				"prod": {Namespace: "prod"},
+			},
+			Options: []mem.RendererOption{mem.WithContentHash(false)},
+		}.Render(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(mem.WriteMatrix(dir, outputs)).To(Succeed())
+
+		data, err := os.ReadFile(filepath.Join(dir, "prod", "deployment.apps_prod_web.yaml"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		obj, err := mem.Unstructured(string(data))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.Object).To(Equal(outputs["prod"][0].Object))
+
+		g.Expect(filepath.Join(dir, "dev", "configmap_dev_test-config.yaml")).To(BeAnExistingFile())
+	})
+
+	t.Run("should reject unsafe environment names", func(t *testing.T) {
+		g := NewWithT(t)
+
+		err := mem.WriteMatrix(t.TempDir(), map[string][]unstructured.Unstructured{"../escape": nil})
+		g.Expect(err).To(MatchError(mem.ErrInvalidEnvironmentName))
+	})
+
+	t.Run("should reject objects mapping to the same file", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := mem.MustUnstructured(configMapYAML)
+		err := mem.WriteMatrix(t.TempDir(), map[string][]unstructured.Unstructured{"dev": {obj, obj}})
+		g.Expect(err).To(MatchError(mem.ErrDuplicateObject))
+	})
+}
+
+func stripHash(obj unstructured.Unstructured) unstructured.Unstructured {
+	out := obj.DeepCopy()
+	annotations := out.GetAnnotations()
+	delete(annotations, pkgtypes.AnnotationContentHash)
+
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	out.SetAnnotations(annotations)
+
+	return *out
+}
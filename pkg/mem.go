@@ -5,9 +5,14 @@ package mem
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/k8s-manifest-kit/engine/pkg/pipeline"
 	"github.com/k8s-manifest-kit/engine/pkg/types"
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -16,15 +21,88 @@ const rendererType = "mem"
 
 // Source represents the input for a memory-based rendering operation.
 type Source struct {
+	// Name optionally identifies the source to RemoveSource, ReplaceSource,
+	// and transactions. Names must be unique among the sources of a renderer;
+	// unnamed sources cannot be removed or replaced.
+	Name string
+
 	// Objects contains pre-constructed Kubernetes manifests to pass through.
 	// Useful for testing, composition, or when objects are already in memory.
 	Objects []unstructured.Unstructured
 
+	// ObjectsFn, if set, generates further objects from the render-time values
+	// on every Process call. They are rendered after Objects and before
+	// Manifests, and are validated as they are generated. Renderers may be
+	// shared across goroutines, so ObjectsFn must be safe for concurrent use.
+	ObjectsFn ObjectsFunc
+
+	// Fingerprint, if set, returns a value that changes whenever the output
+	// of ObjectsFn would change, without running it; it may cover state the
+	// source's callbacks read too. WithRenderCache digests it in place of
+	// the generated objects, and never caches renders of a source with an
+	// ObjectsFn but no Fingerprint.
+	Fingerprint FingerprintFunc
+
+	// Manifests holds YAML or JSON strings, each possibly with several
+	// documents, decoded on every Process call. Their objects are rendered
+	// after Objects; decoding errors report the index of the manifest and the
+	// document and line at fault, and positions record the index of the
+	// manifest as their Input. Decode hot paths once with SourceFromYAML instead.
+	Manifests []string
+
+	// Stream, if set, supplies further objects that arrive over time. They are
+	// rendered after Objects, generated objects, and Manifests.
+	Stream *Stream
+
+	// Positions optionally locates the document each object was decoded from:
+	// Positions[i] belongs to Objects[i], and the zero Position means unknown.
+	// Decoding helpers such as SourceFromYAML fill it in; errors about an
+	// object then carry its position as a *PositionError.
+	Positions []Position
+
+	// Labels and Annotations are merged onto every object of the source as
+	// it is rendered, before any filter, transformer, or post-renderer sees
+	// it. Keys the object already sets are kept unless OverrideMetadata is
+	// set.
+	Labels           map[string]string
+	Annotations      map[string]string
+	OverrideMetadata bool
+
+	// Filters and Transformers are source-specific, applied to this source's
+	// output like the renderer-level ones, but before combining with other
+	// sources. They run before PostRenderers.
+	Filters      []types.Filter
+	Transformers []types.Transformer
+
 	// PostRenderers are source-specific post-renderers applied to this source's output
 	// before combining with other sources.
 	PostRenderers []types.PostRenderer
+
+	// Patches are applied to the objects of all selected sources once they
+	// are combined, before the renderer-level filters, transformers, and
+	// post-renderers, so a source can patch the output of others without
+	// emitting objects itself. Patches of earlier sources apply first, and a
+	// patch matching no object fails the render, as in a Bundle. Merge
+	// patches use the keys registered with WithMergeKeys.
+	Patches []Patch
+
+	// Deletions mark objects that must not exist, by identity: objects
+	// carrying only apiVersion, kind, name, and namespace are enough. Objects
+	// of any selected source with the identity of a deletion are removed from
+	// the output, after patches, and the deletions are reported by
+	// Result.Deletions, or rendered with AnnotationDelete if
+	// WithDeletionMarkers is enabled. They skip every filter, transformer,
+	// and post-renderer.
+	Deletions []unstructured.Unstructured
+
+	// builders names the builder of each object ObjectsFn generates, for
+	// sources created by SourceOf. sourceObjects aligns it with Objects.
+	builders []string
 }
 
+// ObjectsFunc generates the objects of a Source from render-time values.
+type ObjectsFunc = func(ctx context.Context, values types.Values) ([]unstructured.Unstructured, error)
+
 // SourceSelector decides whether a Source should be rendered.
 // It receives the context and the source, and returns true to include
 // the source or false to skip it. Evaluated before rendering.
@@ -33,8 +111,24 @@ type SourceSelector = func(ctx context.Context, source Source) (bool, error)
 // Renderer handles memory-based rendering operations.
 // It implements types.Renderer for objects that are already in memory.
 type Renderer struct {
+	// mu guards inputs, which mutations replace rather than modify, so every
+	// render keeps the source list it started with.
+	mu     sync.RWMutex
 	inputs []*sourceHolder
 	opts   RendererOptions
+
+	// frozen is set on renderers returned by Freeze, whose sources cannot be
+	// changed.
+	frozen bool
+
+	// merged is set on renderers built by Merge, which render their parts
+	// instead of inputs.
+	merged *mergedParts
+
+	// cache is set if WithRenderCache is enabled.
+	cache *renderCache
+
+	stats renderStats
 }
 
 // New creates a new memory-based renderer with the given inputs and options.
@@ -49,13 +143,30 @@ func New(inputs []Source, opts ...RendererOption) (*Renderer, error) {
 		opt.ApplyTo(&rendererOpts)
 	}
 
+	if err := rendererOpts.validate(); err != nil {
+		return nil, err
+	}
+
 	// Wrap sources in holders and validate
 	holders := make([]*sourceHolder, len(inputs))
 	for i := range inputs {
+		if err := checkSourceName(holders[:i], inputs[i].Name); err != nil {
+			return nil, fmt.Errorf("invalid source at index %d: %w", i, err)
+		}
+
+		if err := inputs[i].Validate(); err != nil {
+			return nil, fmt.Errorf("invalid source at index %d: %w", i, err)
+		}
+
 		holders[i] = &sourceHolder{
 			Source: inputs[i],
 		}
-		if err := holders[i].Validate(); err != nil {
+
+		if rendererOpts.LazyValidation {
+			continue
+		}
+
+		if err := holders[i].Validate(&rendererOpts); err != nil {
 			return nil, fmt.Errorf("invalid source at index %d: %w", i, err)
 		}
 	}
@@ -63,62 +174,456 @@ func New(inputs []Source, opts ...RendererOption) (*Renderer, error) {
 	r := &Renderer{
 		inputs: holders,
 		opts:   rendererOpts,
+		cache:  newRenderCache(rendererOpts),
 	}
 
 	return r, nil
 }
 
 // Process implements types.Renderer by returning the objects that were provided during construction.
-// Render-time values are ignored by the memory renderer as objects are already constructed.
-func (r *Renderer) Process(ctx context.Context, _ types.Values) ([]unstructured.Unstructured, error) {
-	allObjects := make([]unstructured.Unstructured, 0)
+// Render-time values are only passed to the ObjectsFn of sources that have one;
+// all other objects are already constructed.
+//
+// With deep copying on, the default, Process never mutates the objects held by its
+// sources, regardless of the other options: every object is deep copied before
+// annotations, hashing, or any post-renderer touches it. Under WithDeepCopy(false), only
+// the renderer's own stages keep that promise, and callbacks must not modify objects
+// outside their metadata. Building with the memdebug tag turns the guarantee into a
+// runtime assertion.
+func (r *Renderer) Process(ctx context.Context, values types.Values) ([]unstructured.Unstructured, error) {
+	result, err := r.render(ctx, values, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.objects, nil
+}
+
+// renderOnce runs a full render, or serves it from the render cache, and
+// records it in the renderer's stats. Provenance is only tracked when
+// requested, as Process does not expose it.
+func (r *Renderer) renderOnce(ctx context.Context, values types.Values, withProvenance bool) (*Result, error) {
+	clock := startClock()
+
+	ctx, warnings := withWarnings(ctx)
+	trace := r.newTrace(withProvenance)
+
+	cached, store, err := r.cached(ctx, values, trace.inputs, withProvenance)
+	if cached != nil {
+		return r.cacheHit(cached, withProvenance, clock), nil
+	}
+
+	var objects []unstructured.Unstructured
+	if err == nil {
+		var release func()
+
+		ctx, release, err = r.admit(ctx)
+		if err == nil {
+			defer release()
+
+			objects, err = r.process(ctx, values, trace)
+			if err == nil {
+				err = r.verifyDeterminism(ctx, values, trace.inputs, objects)
+			}
+		}
+	}
+
+	result, err := r.complete(ctx, objects, err, trace, warnings, clock)
+	if err != nil || store == nil {
+		return result, err
+	}
+
+	// Process hands its objects over to the caller, so the cache keeps a
+	// copy; the objects of a Result are read-only.
+	if withProvenance {
+		store(result)
+	} else {
+		store(r.copyResult(result))
+	}
+
+	return result, nil
+}
+
+// cacheHit serves a render from the cached result and records it in the
+// renderer's stats.
+func (r *Renderer) cacheHit(cached *Result, withProvenance bool, clock renderClock) *Result {
+	result := cached
+	if !withProvenance {
+		result = r.copyResult(cached)
+	} else {
+		copied := *cached
+		result = &copied
+	}
+
+	result.info.Cached = true
+	result.info.Duration = clock.elapsed()
+
+	r.stats.recordCacheHit(clock.start, result.info.Duration, len(result.objects))
+
+	return result
+}
+
+// newTrace returns the trace of a new render.
+func (r *Renderer) newTrace(withProvenance bool) *renderTrace {
+	trace := &renderTrace{inputs: r.sources()}
+	if withProvenance && r.merged == nil {
+		trace.provenance = make(map[string]Provenance)
+	}
+
+	return trace
+}
+
+// complete finishes a render that processed objects, or failed with err:
+// it enforces WithFailOnEmpty, runs the final pass, records the render in the
+// renderer's stats, and builds its Result.
+func (r *Renderer) complete(
+	ctx context.Context,
+	objects []unstructured.Unstructured,
+	err error,
+	trace *renderTrace,
+	warnings *warningCollector,
+	clock renderClock,
+) (*Result, error) {
+	if err == nil && len(objects) == 0 && r.opts.FailOnEmpty {
+		err = trace.emptyError()
+	}
+
+	var keys []string
+
+	if err == nil {
+		objects, keys, err = r.stamp(ctx, objects, clock.start)
+	}
+
+	if err == nil && r.opts.DeletionMarkers {
+		objects = append(objects, deletionMarkers(trace.deletions)...)
+	}
+
+	if err != nil {
+		objects = nil
+	}
+
+	duration := clock.elapsed()
+
+	r.stats.record(clock.start, duration, len(objects), err)
 
-	for _, holder := range r.inputs {
-		selected, err := pipeline.ApplySourceSelectors(ctx, holder.Source, r.opts.SourceSelectors)
+	if err != nil {
+		return nil, r.nameError(err)
+	}
+
+	return &Result{
+		objects:  objects,
+		warnings: warnings.list(),
+		info: RenderInfo{
+			Renderer:  r.Name(),
+			Duration:  duration,
+			Sources:   trace.sources,
+			Selected:  trace.selected,
+			Collected: trace.collected,
+		},
+		provenance: trace.provenanceOf(keys),
+		migrations: trace.migrations,
+		deletions:  trace.deletions,
+	}, nil
+}
+
+func (r *Renderer) process(
+	ctx context.Context,
+	values types.Values,
+	trace *renderTrace,
+) ([]unstructured.Unstructured, error) {
+	if r.merged != nil {
+		return r.processMerged(ctx, values, trace)
+	}
+
+	defer guardInputs(trace.inputs)()
+
+	outputs, err := r.renderInputs(ctx, values, trace)
+	if err != nil {
+		return nil, err
+	}
+
+	allObjects, err := r.collect(outputs, trace)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.applyChain(ctx, allObjects)
+}
+
+// collect combines the outputs of the rendered sources, resolving duplicates
+// with the renderer's DuplicatePolicy, and applies the sources' patches and
+// deletions.
+func (r *Renderer) collect(
+	outputs [][]unstructured.Unstructured,
+	trace *renderTrace,
+) ([]unstructured.Unstructured, error) {
+	keys := r.opts.MergeKeys
+	if r.opts.OverlayMerge {
+		keys = combineMergeKeys(builtinMergeKeys(), keys)
+	}
+
+	if r.opts.CRVersionAlignment != nil {
+		if err := r.alignCRVersions(outputs, trace); err != nil {
+			return nil, fmt.Errorf("conversion error in mem renderer: %w", err)
+		}
+	}
+
+	objects, err := resolveDuplicates(
+		outputs, r.opts.DuplicatePolicy, identityOrDefault(r.opts.IdentityFunc), keys, r.opts.hasher(), "sources")
+	if err != nil {
+		return nil, fmt.Errorf("duplicate error in mem renderer: %w", err)
+	}
+
+	for _, patches := range trace.patches {
+		r.ownPatchTargets(objects, patches.patches)
+
+		patched, err := applyPatches(objects, patches.patches, r.opts.MergeKeys)
 		if err != nil {
-			return nil, fmt.Errorf("source selector error in mem renderer: %w", err)
+			return nil, fmt.Errorf("patch error in mem renderer: source %d: %w", patches.index, err)
 		}
 
-		if !selected {
-			continue
+		if err := r.opts.hasher().rehash(objects, patched); err != nil {
+			return nil, fmt.Errorf("content hash error in mem renderer: %w", err)
 		}
+	}
 
-		sourceObjects := make([]unstructured.Unstructured, 0, len(holder.Objects))
+	if len(trace.deletions) > 0 {
+		objects = r.applyDeletions(objects, trace)
+	}
 
-		for _, obj := range holder.Objects {
-			objCopy := obj.DeepCopy()
+	trace.collected = len(objects)
 
-			if r.opts.SourceAnnotations {
-				annotations := objCopy.GetAnnotations()
-				if annotations == nil {
-					annotations = make(map[string]string)
-				}
+	return objects, nil
+}
 
-				annotations[types.AnnotationSourceType] = rendererType
+// prepareSource decides whether the index-th input is rendered and, if so,
+// validates it when validation is lazy and returns the source to render, with
+// the objects generated for values, decoded from its manifests, and received
+// from its stream.
+func (r *Renderer) prepareSource(
+	ctx context.Context,
+	values types.Values,
+	index int,
+	trace *renderTrace,
+) (Source, bool, error) {
+	holder := trace.inputs[index]
 
-				objCopy.SetAnnotations(annotations)
-			}
+	selected, err := pipeline.ApplySourceSelectors(ctx, holder.Source, r.opts.SourceSelectors)
+	if err != nil {
+		return Source{}, false, fmt.Errorf("source selector error in mem renderer: %w", err)
+	}
+
+	if !selected {
+		return Source{}, false, nil
+	}
+
+	trace.selected++
 
-			sourceObjects = append(sourceObjects, *objCopy)
+	if len(holder.Patches) > 0 {
+		trace.patches = append(trace.patches, sourcePatches{index: index, patches: holder.Patches})
+	}
+
+	trace.deletions = append(trace.deletions, holder.Deletions...)
+
+	if r.opts.LazyValidation {
+		if err := holder.ValidateOnce(&r.opts); err != nil {
+			return Source{}, false, fmt.Errorf("invalid source at index %d: %w", index, err)
+		}
+	}
+
+	source, err := holder.sourceObjects(ctx, values, &r.opts)
+	if err != nil {
+		return Source{}, false, fmt.Errorf("invalid source at index %d: %w", index, err)
+	}
+
+	holder.warn(ctx, index)
+
+	return source, true, nil
+}
+
+// renderSource runs the source stage on objects, which belong to source, the
+// index-th input:
+// copying and resolving, source annotations, canonical metadata, content
+// hashes, and the source's post-renderers.
+func (r *Renderer) renderSource(
+	ctx context.Context,
+	index int,
+	source Source,
+	objects []unstructured.Unstructured,
+	trace *renderTrace,
+) ([]unstructured.Unstructured, error) {
+	sourceObjects := make([]unstructured.Unstructured, 0, len(objects))
+
+	for k, obj := range objects {
+		var err error
+
+		sourceObjects, err = r.renderObject(ctx, index, source, k, obj, sourceObjects, trace)
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	return r.finishSource(ctx, source, sourceObjects)
+}
 
-		if r.opts.ContentHash {
-			for i := range sourceObjects {
-				types.SetContentHash(&sourceObjects[i])
+// renderObject runs the per-object part of the source stage on obj, the k-th
+// object of source, and appends the result to sourceObjects.
+func (r *Renderer) renderObject(
+	ctx context.Context,
+	index int,
+	source Source,
+	k int,
+	obj unstructured.Unstructured,
+	sourceObjects []unstructured.Unstructured,
+	trace *renderTrace,
+) ([]unstructured.Unstructured, error) {
+	start := len(sourceObjects)
+
+	sourceObjects, err := r.appendObject(ctx, sourceObjects, obj)
+	if err != nil {
+		return nil, fmt.Errorf("source object error in mem renderer: %w", source.atPosition(k, err))
+	}
+
+	if err := r.checkObjectCount(len(sourceObjects), index, source); err != nil {
+		return nil, fmt.Errorf("limit error in mem renderer: %w", source.atPosition(k, err))
+	}
+
+	for j := start; j < len(sourceObjects); j++ {
+		objCopy := &sourceObjects[j]
+
+		if err := r.rewriteGVK(objCopy, trace); err != nil {
+			return nil, fmt.Errorf("GVK rewrite error in mem renderer: %w", source.atPosition(k, err))
+		}
+
+		source.applyMetadata(objCopy)
+
+		if r.opts.SourceAnnotations {
+			if err := annotateSource(objCopy, r.opts.Name, source, index, k); err != nil {
+				return nil, fmt.Errorf("source annotation error in mem renderer: %w", source.atPosition(k, err))
 			}
 		}
 
-		sourceObjects, err = pipeline.ApplyPostRenderers(ctx, sourceObjects, holder.PostRenderers)
-		if err != nil {
-			return nil, fmt.Errorf("source post-renderer error in mem renderer: %w", err)
+		if r.opts.CanonicalMetadata {
+			canonicalizeMetadata(objCopy)
+		}
+
+		if err := r.validateStructure(ctx, objCopy, index, source, k); err != nil {
+			return nil, fmt.Errorf("validation error in mem renderer: %w", source.atPosition(k, err))
+		}
+
+		if err := r.admitGVK(objCopy, index, source, k); err != nil {
+			return nil, fmt.Errorf("GVK policy error in mem renderer: %w", source.atPosition(k, err))
+		}
+
+		if err := r.checkObjectSize(objCopy, index, source, k); err != nil {
+			return nil, fmt.Errorf("limit error in mem renderer: %w", source.atPosition(k, err))
+		}
+	}
+
+	if trace.provenance != nil {
+		r.recordProvenance(trace, sourceObjects[start:], index, source, k)
+	}
+
+	return sourceObjects, nil
+}
+
+// finishSource completes the source stage of source once all its objects
+// are rendered: content hashes, then the source's filters, transformers, and
+// post-renderers.
+func (r *Renderer) finishSource(
+	ctx context.Context,
+	source Source,
+	sourceObjects []unstructured.Unstructured,
+) ([]unstructured.Unstructured, error) {
+	if r.opts.ContentHash && r.opts.ContentHashStage != ContentHashPostChain {
+		if err := r.opts.hasher().setAll(sourceObjects); err != nil {
+			return nil, fmt.Errorf("content hash error in mem renderer: %w", err)
+		}
+	}
+
+	chain := types.BuildPostRendererChain(source.Filters, source.Transformers, source.PostRenderers)
+
+	sourceObjects, err := pipeline.ApplyPostRenderers(ctx, sourceObjects, chain)
+	if err != nil {
+		return nil, fmt.Errorf("source chain error in mem renderer: %w", err)
+	}
+
+	return sourceObjects, nil
+}
+
+// annotationProvenanceKey tags rendered objects with the key of their
+// provenance until the final pass takes it off, so provenance follows each
+// object through the chain whatever its identity.
+const annotationProvenanceKey = "manifests.k8s-manifests-kit/internal.provenance"
+
+// recordProvenance records that objects came from the k-th object of the
+// index-th source, and tags them with its key.
+func (r *Renderer) recordProvenance(
+	trace *renderTrace,
+	objects []unstructured.Unstructured,
+	index int,
+	source Source,
+	k int,
+) {
+	provenance := Provenance{Source: index, Name: source.Name}
+	if position, ok := source.positionOf(k); ok {
+		provenance.Position = &position
+	}
+
+	if builder, ok := source.builderOf(k); ok {
+		provenance.Builder = builder
+	}
+
+	key := strconv.Itoa(index) + "/" + strconv.Itoa(k)
+	trace.provenance[key] = provenance
+
+	for i := range objects {
+		k8s.SetAnnotation(&objects[i], annotationProvenanceKey, key)
+	}
+}
+
+// takeProvenanceKeys removes the provenance keys recordProvenance tagged
+// objects with and returns them in object order, with an empty key for
+// untagged objects. Annotation maps left empty are removed, as they were
+// added by the tag.
+func takeProvenanceKeys(objects []unstructured.Unstructured) []string {
+	keys := make([]string, len(objects))
+
+	for i := range objects {
+		key, found, _ := unstructured.NestedString(
+			objects[i].Object, "metadata", "annotations", annotationProvenanceKey)
+		if !found {
+			continue
 		}
 
-		allObjects = append(allObjects, sourceObjects...)
+		keys[i] = key
+
+		annotations := objects[i].GetAnnotations()
+		delete(annotations, annotationProvenanceKey)
+
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+
+		objects[i].SetAnnotations(annotations)
 	}
 
-	chain := types.BuildPostRendererChain(r.opts.Filters, r.opts.Transformers, r.opts.PostRenderers)
+	return keys
+}
 
-	result, err := pipeline.ApplyPostRenderers(ctx, allObjects, chain)
+// applyChain runs the renderer-level filters, transformers, and post-renderers.
+func (r *Renderer) applyChain(
+	ctx context.Context,
+	objects []unstructured.Unstructured,
+) ([]unstructured.Unstructured, error) {
+	chain := types.BuildPostRendererChain(r.opts.Filters, nil, nil)
+	for i, t := range r.opts.Transformers {
+		chain = append(chain, r.transformerStage(i, t))
+	}
+
+	chain = append(chain, r.opts.PostRenderers...)
+
+	result, err := pipeline.ApplyPostRenderers(ctx, objects, chain)
 	if err != nil {
 		return nil, fmt.Errorf("renderer post-renderer error in mem renderer: %w", err)
 	}
@@ -126,7 +631,252 @@ func (r *Renderer) Process(ctx context.Context, _ types.Values) ([]unstructured.
 	return result, nil
 }
 
-// Name returns the renderer type identifier.
+// applyMetadata merges the source's Labels and Annotations onto obj.
+func (s Source) applyMetadata(obj *unstructured.Unstructured) {
+	if len(s.Labels) > 0 {
+		obj.SetLabels(mergeMetadata(obj.GetLabels(), s.Labels, s.OverrideMetadata))
+	}
+
+	if len(s.Annotations) > 0 {
+		obj.SetAnnotations(mergeMetadata(obj.GetAnnotations(), s.Annotations, s.OverrideMetadata))
+	}
+}
+
+// mergeMetadata adds entries to current, replacing existing keys only if
+// override is set.
+func mergeMetadata(current map[string]string, entries map[string]string, override bool) map[string]string {
+	if current == nil {
+		current = make(map[string]string, len(entries))
+	}
+
+	for k, v := range entries {
+		if _, ok := current[k]; ok && !override {
+			continue
+		}
+
+		current[k] = v
+	}
+
+	return current
+}
+
+// annotateSource adds the source annotations to obj, the k-th object of
+// source, the index-th input. Name and position annotations carried over from
+// an earlier render are removed when the source does not record them, as they
+// would be stale.
+func annotateSource(obj *unstructured.Unstructured, name string, source Source, index int, k int) error {
+	if err := appendSourceHop(obj, name); err != nil {
+		return err
+	}
+
+	setSourceIndex(obj, index, k)
+
+	if source.Name != "" {
+		k8s.SetAnnotation(obj, AnnotationSourceName, source.Name)
+	} else {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", AnnotationSourceName)
+	}
+
+	if position, ok := source.positionOf(k); ok {
+		return setSourcePosition(obj, position)
+	}
+
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", AnnotationSourcePosition)
+
+	return nil
+}
+
+// stamp runs the final pass over the rendered objects: default and ensured
+// namespaces, kind and webhook ordering, service account wiring, scheduling classes,
+// spread policy, CRD wait annotations, CA bundles, kind handlers,
+// sanitization, and the render-level metadata that must describe the final
+// objects, i.e. the generation and render digest annotations, then the
+// managed fields that include them. It also returns the provenance keys of
+// the final objects, in order, as taken by takeProvenanceKeys once the objects
+// are in their final order. now is the render timestamp, taken once per render
+// so that objects stamped in several calls agree on it.
+func (r *Renderer) stamp(
+	ctx context.Context,
+	objects []unstructured.Unstructured,
+	now time.Time,
+) ([]unstructured.Unstructured, []string, error) {
+	if r.opts.DefaultNamespace != "" {
+		defaulted, err := r.applyDefaultNamespace(objects)
+		if err == nil {
+			err = r.opts.hasher().rehash(objects, defaulted)
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("default namespace error in mem renderer: %w", err)
+		}
+	}
+
+	if r.opts.EnsureNamespaces {
+		var err error
+
+		objects, err = r.ensureNamespaces(objects)
+		if err != nil {
+			return nil, nil, fmt.Errorf("namespace error in mem renderer: %w", err)
+		}
+	}
+
+	if r.opts.StableSort {
+		sortByKey(objects)
+	}
+
+	if len(r.opts.KindOrder) > 0 {
+		r.opts.KindOrder.sort(objects)
+	}
+
+	if r.opts.WebhooksLast {
+		orderWebhooksLast(objects)
+	}
+
+	if r.opts.DependencyOrdering {
+		if err := orderByDependencies(ctx, objects); err != nil {
+			return nil, nil, fmt.Errorf("dependency error in mem renderer: %w", err)
+		}
+	}
+
+	keys := takeProvenanceKeys(objects)
+
+	changed, err := r.applyWorkloadPolicies(ctx, objects)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.opts.CRDWaitAnnotations {
+		changed = append(changed, annotateCRDDependencies(objects)...)
+	}
+
+	if r.opts.CABundles != nil {
+		injected, err := r.injectCABundles(ctx, objects)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CA bundle error in mem renderer: %w", err)
+		}
+
+		changed = append(changed, injected...)
+	}
+
+	handled, err := r.applyKindHandlers(ctx, objects)
+	if err != nil {
+		return nil, nil, fmt.Errorf("kind handler error in mem renderer: %w", err)
+	}
+
+	if r.opts.Sanitizer != nil {
+		for i := range objects {
+			if err := r.opts.Sanitizer.sanitizeInPlace(&objects[i]); err != nil {
+				return nil, nil, fmt.Errorf("sanitizer error in mem renderer for %s: %w",
+					ObjectKeyOf(objects[i]), err)
+			}
+		}
+	}
+
+	// Hashes are refreshed after sanitization, which handlers may depend on
+	// to turn the values they set into JSON-compatible ones.
+	if r.opts.ContentHash && r.opts.ContentHashStage == ContentHashPostChain {
+		err = r.opts.hasher().setAll(objects)
+	} else {
+		err = r.opts.hasher().rehash(objects, append(changed, handled...))
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("content hash error in mem renderer: %w", err)
+	}
+
+	if r.opts.Generation != "" {
+		stampGeneration(objects, r.opts.Generation)
+	}
+
+	if r.opts.RenderDigest {
+		stampRenderDigest(objects)
+	}
+
+	if r.opts.ProvenanceAnnotations {
+		stampBuildInfo(objects, now)
+	}
+
+	if r.opts.FieldManager != "" {
+		if err := stampManagedFields(objects, r.opts.FieldManager, r.opts.MergeKeys); err != nil {
+			return nil, nil, fmt.Errorf("field manager error in mem renderer: %w", err)
+		}
+	}
+
+	if r.opts.TargetKubeVersion != "" {
+		if err := r.checkDeprecatedAPIs(ctx, objects); err != nil {
+			return nil, nil, fmt.Errorf("API deprecation error in mem renderer: %w", err)
+		}
+	}
+
+	if len(r.opts.SchemaValidators) > 0 {
+		if err := r.validateSchemas(ctx, objects); err != nil {
+			return nil, nil, fmt.Errorf("schema validation error in mem renderer: %w", err)
+		}
+	}
+
+	if len(r.opts.Policies) > 0 {
+		if err := r.enforcePolicies(ctx, objects); err != nil {
+			return nil, nil, fmt.Errorf("policy error in mem renderer: %w", err)
+		}
+	}
+
+	if r.opts.NamespacePolicy != nil {
+		if err := r.enforceNamespacePolicy(objects); err != nil {
+			return nil, nil, fmt.Errorf("namespace policy error in mem renderer: %w", err)
+		}
+	}
+
+	return objects, keys, nil
+}
+
+// Freeze returns a read-only snapshot of the renderer capturing its current
+// source set and options. The snapshot shares sources and objects with r
+// (structural sharing), so it is cheap to take; it renders the same view for
+// its whole lifetime, which lets a reconcile loop render a consistent state
+// even if the live renderer later changes.
+//
+// Streams are snapshotted: the frozen renderer keeps the objects received so
+// far and stops pulling. The parts of a renderer built by Merge are frozen
+// too. The sources of a frozen renderer cannot be changed.
+func (r *Renderer) Freeze() *Renderer {
+	inputs := slices.Clone(r.sources())
+
+	for i, holder := range inputs {
+		if holder.Stream == nil {
+			continue
+		}
+
+		source := holder.Source
+		source.Stream = holder.Stream.freeze()
+
+		inputs[i] = &sourceHolder{Source: source}
+	}
+
+	return &Renderer{
+		inputs: inputs,
+		opts:   r.opts,
+		frozen: true,
+		merged: r.merged.freeze(),
+		cache:  newRenderCache(r.opts),
+	}
+}
+
+// Name returns the name set by WithName, or the renderer type identifier,
+// "mem", if there is none.
 func (r *Renderer) Name() string {
+	if r.opts.Name != "" {
+		return r.opts.Name
+	}
+
 	return rendererType
 }
+
+// nameError prefixes err with the name set by WithName, if any, so that
+// engines hosting several mem renderers can tell which one failed.
+func (r *Renderer) nameError(err error) error {
+	if r.opts.Name == "" {
+		return err
+	}
+
+	return fmt.Errorf("renderer %s: %w", r.opts.Name, err)
+}
@@ -0,0 +1,74 @@
+package mem
+
+// Concurrency classifies the guarantees a part of the mem API offers to
+// concurrent callers. The constants below are the authoritative description
+// of the renderer's concurrency model; the stress tests in
+// mem_stress_test.go exercise each of them under the race detector.
+type Concurrency string
+
+const (
+	// ConcurrencySafe means the operation may be used from any number of
+	// goroutines at once without external synchronization.
+	ConcurrencySafe Concurrency = "safe"
+
+	// ConcurrencyHandOff means the value is read, never written, by the
+	// renderer once handed over. The caller may keep reading it, but must not
+	// mutate it while a Process call may be running.
+	ConcurrencyHandOff Concurrency = "hand-off"
+
+	// ConcurrencyOwned means the value belongs exclusively to the caller that
+	// received it; the renderer keeps no reference to it.
+	ConcurrencyOwned Concurrency = "owned"
+)
+
+const (
+	// RendererConcurrency covers Renderer.Process, Renderer.Name, and the
+	// source mutation methods. A single Renderer may be shared across
+	// goroutines: its options are fixed at construction, its sources change
+	// only by atomic replacement of the whole list, so every render sees the
+	// sources as they were when it started, and every Process call works on
+	// its own copies: deep copies by default, copies of the top-level fields
+	// and metadata under WithDeepCopy(false).
+	RendererConcurrency = ConcurrencySafe
+
+	// OptionConcurrency covers RendererOption and RendererOptions values. They
+	// may be reused across concurrent New calls; New copies what it needs.
+	OptionConcurrency = ConcurrencySafe
+
+	// SourceConcurrency covers the objects held by a Source passed to New,
+	// AddSource, ReplaceSource, or a SourceTx.
+	// They are not copied at construction time, so mutating them while a
+	// Process call may be running is a data race.
+	SourceConcurrency = ConcurrencyHandOff
+
+	// StreamConcurrency covers Stream values, which may back sources of
+	// several renderers processed at once. Their pull function is never
+	// called concurrently.
+	StreamConcurrency = ConcurrencySafe
+
+	// ResultConcurrency covers the objects returned by Process. Each call
+	// returns fresh copies the caller may mutate freely. Under
+	// WithDeepCopy(false), only their top-level fields and metadata are the
+	// caller's own; the rest is shared with the sources and other results and
+	// must not be modified.
+	ResultConcurrency = ConcurrencyOwned
+
+	// ViewConcurrency covers Result, View, and ObjectView. Any number of
+	// consumers may read a shared result at once; accessors return copies.
+	ViewConcurrency = ConcurrencySafe
+
+	// JobConcurrency covers Job values. A job belongs to the caller that
+	// created it and must be stepped from one goroutine at a time, while its
+	// renderer keeps serving other renders and jobs.
+	JobConcurrency = ConcurrencyOwned
+
+	// CallbackConcurrency covers user-supplied filters, transformers,
+	// post-renderers, source selectors, and ObjectsFn. They are invoked from
+	// whichever goroutine calls Process, so they must themselves be safe for
+	// concurrent use when the renderer is shared. Within a single render,
+	// WithConcurrency runs the source stage of several sources at once, so
+	// selectors, ObjectsFn, and source-level filters, transformers, and
+	// post-renderers may then run concurrently even when the renderer is not
+	// shared; renderer-level callbacks run on one goroutine at a time.
+	CallbackConcurrency = ConcurrencySafe
+)
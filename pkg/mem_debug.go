@@ -0,0 +1,72 @@
+//go:build memdebug
+
+package mem
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// guardInputs snapshots the source objects and returns a function that panics
+// if any of them changed in the meantime. It is compiled in only with the
+// memdebug build tag, so the cost of the extra deep copies is never paid in
+// production builds.
+func guardInputs(holders []*sourceHolder) func() {
+	snapshots := make([][]unstructured.Unstructured, len(holders))
+	for i, holder := range holders {
+		snapshots[i] = make([]unstructured.Unstructured, len(holder.Objects))
+		for j := range holder.Objects {
+			snapshots[i][j].Object, _ = snapshotValue(holder.Objects[j].Object).(map[string]any)
+		}
+	}
+
+	return func() {
+		for i, holder := range holders {
+			if len(holder.Objects) != len(snapshots[i]) {
+				panic(fmt.Sprintf("mem renderer mutated source %d: object count changed from %d to %d",
+					i, len(snapshots[i]), len(holder.Objects)))
+			}
+
+			for j := range holder.Objects {
+				if !reflect.DeepEqual(holder.Objects[j].Object, snapshots[i][j].Object) {
+					panic(fmt.Sprintf("mem renderer mutated source %d object %d (%s)",
+						i, j, ObjectKeyOf(snapshots[i][j])))
+				}
+			}
+		}
+	}
+}
+
+// snapshotValue deep copies maps and lists like runtime.DeepCopyJSONValue, but
+// keeps any other value as it is instead of panicking, so sources holding
+// values a Sanitizer converts can be guarded too.
+func snapshotValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		if value == nil {
+			return value
+		}
+
+		result := make(map[string]any, len(value))
+		for key, child := range value {
+			result[key] = snapshotValue(child)
+		}
+
+		return result
+	case []any:
+		if value == nil {
+			return value
+		}
+
+		result := make([]any, len(value))
+		for i, child := range value {
+			result[i] = snapshotValue(child)
+		}
+
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,84 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/transformer/meta/labels"
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+	"github.com/k8s-manifest-kit/renderer-mem/pkg/memtest"
+)
+
+const (
+	fuzzSourceAnnotations byte = 1 << iota
+	fuzzNoContentHash
+	fuzzTransformer
+	fuzzPostRenderer
+)
+
+func FuzzProcess(f *testing.F) {
+	for _, seed := range memtest.Seeds() {
+		for flags := range fuzzPostRenderer << 1 {
+			f.Add(seed, flags)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, flags byte) {
+		obj := memtest.Object(data)
+
+		opts := []mem.RendererOption{
+			mem.WithSourceAnnotations(flags&fuzzSourceAnnotations != 0),
+			mem.WithContentHash(flags&fuzzNoContentHash == 0),
+		}
+
+		if flags&fuzzTransformer != 0 {
+			opts = append(opts, mem.WithTransformer(labels.Set(map[string]string{"fuzz": "true"})))
+		}
+
+		if flags&fuzzPostRenderer != 0 {
+			opts = append(opts, mem.WithPostRenderer(func(
+				_ context.Context,
+				objects []unstructured.Unstructured,
+			) ([]unstructured.Unstructured, error) {
+				return objects, nil
+			}))
+		}
+
+		renderer, err := mem.New([]mem.Source{{Objects: []unstructured.Unstructured{obj}}}, opts...)
+		if err != nil {
+			if !errors.Is(err, mem.ErrObjectEmpty) && !errors.Is(err, mem.ErrMetadataOnlyObject) {
+				t.Fatalf("unexpected construction error: %v", err)
+			}
+
+			return
+		}
+
+		first, err := renderer.Process(t.Context(), nil)
+		if err != nil {
+			return
+		}
+
+		second, err := renderer.Process(t.Context(), nil)
+		if err != nil {
+			t.Fatalf("second Process failed after first succeeded: %v", err)
+		}
+
+		if len(first) != len(second) {
+			t.Fatalf("object count changed between renders: %d != %d", len(first), len(second))
+		}
+
+		for i := range first {
+			h1 := first[i].GetAnnotations()[pkgtypes.AnnotationContentHash]
+			h2 := second[i].GetAnnotations()[pkgtypes.AnnotationContentHash]
+
+			if h1 != h2 {
+				t.Fatalf("content hash is not stable: %q != %q", h1, h2)
+			}
+		}
+	})
+}
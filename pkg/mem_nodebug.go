@@ -0,0 +1,8 @@
+//go:build !memdebug
+
+package mem
+
+// guardInputs is a no-op outside of memdebug builds. See mem_debug.go.
+func guardInputs(_ []*sourceHolder) func() {
+	return func() {}
+}
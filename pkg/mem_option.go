@@ -1,8 +1,14 @@
 package mem
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/k8s-manifest-kit/engine/pkg/types"
 	"github.com/k8s-manifest-kit/pkg/util"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // RendererOption is a generic option for RendererOptions.
@@ -28,16 +34,282 @@ type RendererOptions struct {
 	// ContentHash enables automatic addition of a SHA-256 content hash annotation.
 	// Default: true (enabled).
 	ContentHash bool
+
+	// ContentHashAlgorithm selects the algorithm of content hashes. Empty
+	// means HashSHA256.
+	ContentHashAlgorithm HashAlgorithm
+
+	// ContentHasher, if set, computes content hashes instead of
+	// ContentHashAlgorithm.
+	ContentHasher ContentHasher
+
+	// ContentHashIgnore holds dot-separated paths of fields left out of
+	// content hashes.
+	ContentHashIgnore []string
+
+	// SecretRedaction hashes the payload of Secrets as per-key digests.
+	SecretRedaction bool
+
+	// ContentHashStage selects where content hashes are computed. Empty
+	// means ContentHashPreChain.
+	ContentHashStage ContentHashStage
+
+	// CanonicalMetadata enables normalization of object metadata before hashing,
+	// removing empty labels and annotations maps.
+	CanonicalMetadata bool
+
+	// IdentityFunc defines object identity for features that match objects
+	// against each other. Nil means DefaultIdentity.
+	IdentityFunc IdentityFunc
+
+	// LazyValidation defers source validation from New to the first Process
+	// call that selects the source.
+	LazyValidation bool
+
+	// PartialObjectResolver resolves PartialObjectMetadata and Table objects
+	// to full objects during Process. Without it such objects are rejected.
+	PartialObjectResolver PartialObjectResolver
+
+	// Generation, if set, is stamped on every rendered object as AnnotationGeneration.
+	Generation string
+
+	// RenderDigest stamps every rendered object with AnnotationRenderDigest.
+	RenderDigest bool
+
+	// ProvenanceAnnotations stamps every rendered object with the render
+	// timestamp and the versions of renderer-mem and the engine.
+	ProvenanceAnnotations bool
+
+	// FieldManager, if set, replaces the managedFields of every rendered object
+	// with the entry server-side apply would record for this manager.
+	FieldManager string
+
+	// DeterminismCheck is the number of times each render is repeated and
+	// compared; values below 2 disable the check.
+	DeterminismCheck int
+
+	// FailOnEmpty makes Process fail with an EmptyRenderError when it would
+	// return no objects.
+	FailOnEmpty bool
+
+	// KindHandlers run on rendered objects of specific kinds in the final pass.
+	KindHandlers []kindHandler
+
+	// TargetKubeVersion, if set, is the Kubernetes version whose deprecated
+	// and removed APIs the output is checked for.
+	TargetKubeVersion string
+
+	// SchemaValidators check the output against the schemas of its kinds in
+	// the final pass.
+	SchemaValidators []SchemaValidator
+
+	// Policies are the CEL rules the output must satisfy.
+	Policies []policyRule
+
+	// Sanitizer, if set, makes the content of source objects and of the final
+	// output JSON-safe.
+	Sanitizer *Sanitizer
+
+	// YAMLOptions configure the decoder of Source.Manifests.
+	YAMLOptions []YAMLOption
+
+	// EmptyObjectPolicy decides what happens to objects that renderer-level
+	// transformers empty or strip of their identity. Empty means EmptyObjectKeep.
+	EmptyObjectPolicy EmptyObjectPolicy
+
+	// AllowedGVKs, if set, are the only kinds source objects may have.
+	AllowedGVKs []schema.GroupVersionKind
+
+	// DeniedGVKs are kinds source objects must not have.
+	DeniedGVKs []schema.GroupVersionKind
+
+	// Validation selects how structurally malformed source objects are
+	// treated. Empty means ValidationOff.
+	Validation ValidationMode
+
+	// MaxObjects and MaxObjectBytes bound the number of objects a source may
+	// emit and the serialized size of each. Zero means no limit.
+	MaxObjects     int
+	MaxObjectBytes int
+
+	// DefaultNamespace, if set, is the namespace of rendered namespaced
+	// objects that do not set one.
+	DefaultNamespace string
+
+	// RESTMapper, if set, resolves the scope of kinds that are neither
+	// built in nor defined by a rendered CustomResourceDefinition.
+	RESTMapper meta.RESTMapper
+
+	// NamespacePolicy, if set, restricts the namespaces of rendered objects.
+	NamespacePolicy *NamespacePolicy
+
+	// EnsureNamespaces prepends a Namespace object for every namespace that
+	// rendered objects are placed in but do not define.
+	EnsureNamespaces bool
+
+	// NamespaceLabels and NamespaceAnnotations are set on the Namespace
+	// objects generated by EnsureNamespaces.
+	NamespaceLabels      map[string]string
+	NamespaceAnnotations map[string]string
+
+	// ServiceAccountWiring, if set, wires rendered workloads to a
+	// ServiceAccount and ServiceAccounts to image pull secrets.
+	ServiceAccountWiring *ServiceAccountWiring
+
+	// SchedulingClasses, if set, are injected into the pod specs of selected
+	// workloads.
+	SchedulingClasses *schedulingClasses
+
+	// SpreadPolicy, if set, is enforced on the topology spread constraints of
+	// rendered workloads.
+	SpreadPolicy *SpreadPolicy
+
+	// Capabilities describes the target cluster to checks of the render.
+	Capabilities Capabilities
+
+	// DuplicatePolicy resolves objects that several sources produce with the
+	// same identity. Empty means DuplicateKeepAll.
+	DuplicatePolicy DuplicatePolicy
+
+	// ListExpansion renders the items of List objects instead of the lists.
+	ListExpansion bool
+
+	// Middlewares wrap every Process and ProcessResult call, the first
+	// registered outermost.
+	Middlewares []Middleware
+
+	// RenderCache reuses the result of the latest render while values and
+	// sources are unchanged.
+	RenderCache bool
+
+	// Name identifies the renderer instead of its type; empty means "mem".
+	Name string
+
+	// NoDeepCopy copies only the top-level fields and metadata of source
+	// objects instead of the objects entirely. It is an opt-out so that the
+	// zero value, and any RendererOptions passed as an option, keep copying.
+	NoDeepCopy bool
+
+	// CRDWaitAnnotations marks rendered CRDs and the custom resources that
+	// depend on them.
+	CRDWaitAnnotations bool
+
+	// DeletionMarkers renders the deletions of the sources with
+	// AnnotationDelete after the other objects.
+	DeletionMarkers bool
+
+	// Concurrency is the number of sources rendered at once; values below 2
+	// render sources one after another.
+	Concurrency int
+
+	// RenderSemaphore, if set, bounds the renders running at once across
+	// the renderers sharing it.
+	RenderSemaphore RenderSemaphore
+
+	// StableSort sorts the output by GVK, namespace, and name.
+	StableSort bool
+
+	// KindOrder, if set, sorts the output by kind.
+	KindOrder KindOrder
+
+	// WebhooksLast moves webhook configurations to the end of the output.
+	WebhooksLast bool
+
+	// DependencyOrdering sorts the output by the depends-on annotations.
+	DependencyOrdering bool
+
+	// CABundles, if set, injects CA bundles into the webhooks of webhook
+	// configurations.
+	CABundles *caBundleInjection
+
+	// OverlayMerge adds BuiltinMergeKeys to MergeKeys when duplicates are
+	// merged.
+	OverlayMerge bool
+
+	// GVKRewrites migrate objects to other API versions or kinds, in the
+	// order they were registered.
+	GVKRewrites []gvkRewrite
+
+	// APIMigration converts deprecated API versions of built-in kinds to
+	// their replacements.
+	APIMigration bool
+
+	// CRVersionAlignment, if set, converts the custom resources of rendered
+	// CRDs to one version per kind.
+	CRVersionAlignment *crVersionAlignment
+
+	// MergeKeys registers the keyed lists of each kind, which managed fields
+	// track item by item.
+	MergeKeys MergeKeys
 }
 
 // ApplyTo applies the renderer options to the target configuration.
+// Slices are cloned so that a RendererOptions value can be shared by
+// concurrent New calls without later options appending into its backing arrays.
 func (opts RendererOptions) ApplyTo(target *RendererOptions) {
-	target.Filters = opts.Filters
-	target.Transformers = opts.Transformers
+	target.Filters = slices.Clone(opts.Filters)
+	target.Transformers = slices.Clone(opts.Transformers)
 	target.PostRenderers = append(target.PostRenderers, opts.PostRenderers...)
 	target.SourceSelectors = append(target.SourceSelectors, opts.SourceSelectors...)
 	target.SourceAnnotations = opts.SourceAnnotations
 	target.ContentHash = opts.ContentHash
+	target.ContentHashAlgorithm = opts.ContentHashAlgorithm
+	target.ContentHasher = opts.ContentHasher
+	target.ContentHashIgnore = slices.Clone(opts.ContentHashIgnore)
+	target.SecretRedaction = opts.SecretRedaction
+	target.ContentHashStage = opts.ContentHashStage
+	target.CanonicalMetadata = opts.CanonicalMetadata
+	target.IdentityFunc = opts.IdentityFunc
+	target.LazyValidation = opts.LazyValidation
+	target.PartialObjectResolver = opts.PartialObjectResolver
+	target.Generation = opts.Generation
+	target.RenderDigest = opts.RenderDigest
+	target.ProvenanceAnnotations = opts.ProvenanceAnnotations
+	target.FieldManager = opts.FieldManager
+	target.FailOnEmpty = opts.FailOnEmpty
+	target.DeterminismCheck = opts.DeterminismCheck
+	target.KindHandlers = append(target.KindHandlers, opts.KindHandlers...)
+	target.TargetKubeVersion = opts.TargetKubeVersion
+	target.SchemaValidators = append(target.SchemaValidators, opts.SchemaValidators...)
+	target.Policies = append(target.Policies, opts.Policies...)
+	target.Sanitizer = opts.Sanitizer
+	target.MergeKeys = opts.MergeKeys
+	target.EmptyObjectPolicy = opts.EmptyObjectPolicy
+	target.Validation = opts.Validation
+	target.MaxObjects = opts.MaxObjects
+	target.MaxObjectBytes = opts.MaxObjectBytes
+	target.AllowedGVKs = append(target.AllowedGVKs, opts.AllowedGVKs...)
+	target.DeniedGVKs = append(target.DeniedGVKs, opts.DeniedGVKs...)
+	target.YAMLOptions = append(target.YAMLOptions, opts.YAMLOptions...)
+	target.DefaultNamespace = opts.DefaultNamespace
+	target.RESTMapper = opts.RESTMapper
+	target.NamespacePolicy = opts.NamespacePolicy
+	target.EnsureNamespaces = opts.EnsureNamespaces
+	target.NamespaceLabels = opts.NamespaceLabels
+	target.NamespaceAnnotations = opts.NamespaceAnnotations
+	target.ServiceAccountWiring = opts.ServiceAccountWiring
+	target.SchedulingClasses = opts.SchedulingClasses
+	target.Capabilities = opts.Capabilities
+	target.SpreadPolicy = opts.SpreadPolicy
+	target.DuplicatePolicy = opts.DuplicatePolicy
+	target.OverlayMerge = opts.OverlayMerge
+	target.CRDWaitAnnotations = opts.CRDWaitAnnotations
+	target.ListExpansion = opts.ListExpansion
+	target.NoDeepCopy = opts.NoDeepCopy
+	target.Name = opts.Name
+	target.RenderCache = opts.RenderCache
+	target.Middlewares = append(target.Middlewares, opts.Middlewares...)
+	target.StableSort = opts.StableSort
+	target.KindOrder = slices.Clone(opts.KindOrder)
+	target.WebhooksLast = opts.WebhooksLast
+	target.DependencyOrdering = opts.DependencyOrdering
+	target.Concurrency = opts.Concurrency
+	target.RenderSemaphore = opts.RenderSemaphore
+	target.DeletionMarkers = opts.DeletionMarkers
+	target.CABundles = opts.CABundles
+	target.GVKRewrites = append(target.GVKRewrites, opts.GVKRewrites...)
+	target.APIMigration = opts.APIMigration
+	target.CRVersionAlignment = opts.CRVersionAlignment
 }
 
 // WithFilter adds a renderer-specific filter to this Mem renderer's processing chain.
@@ -77,9 +349,807 @@ func WithSourceAnnotations(enabled bool) RendererOption {
 	})
 }
 
-// WithContentHash enables or disables automatic addition of a SHA-256 content hash annotation.
+// WithContentHash enables or disables automatic addition of a content hash
+// annotation, SHA-256 unless WithContentHashAlgorithm or WithContentHasher
+// select another digest.
 func WithContentHash(enabled bool) RendererOption {
 	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
 		opts.ContentHash = enabled
 	})
 }
+
+// WithContentHashAlgorithm selects the algorithm of content hashes, e.g.
+// HashSHA512 where FIPS policies require it, HashBLAKE3 for a faster
+// cryptographic hash, or HashFNV64 where speed matters more than tamper
+// resistance. Hashes name their algorithm, so
+// VerifyContentHashes checks them without being told.
+func WithContentHashAlgorithm(algorithm HashAlgorithm) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ContentHashAlgorithm = algorithm
+	})
+}
+
+// WithContentHasher computes content hashes with hasher instead of a built-in
+// algorithm, for digests the package does not provide. hasher receives
+// objects without the fields excluded from content hashes; its errors fail
+// the render. Hashes it returns should name their algorithm like built-in
+// ones ("<algorithm>:<digest>"); VerifyContentHashes skips those it cannot
+// recompute.
+func WithContentHasher(hasher ContentHasher) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ContentHasher = hasher
+	})
+}
+
+// WithContentHashIgnore leaves the fields at the given dot-separated paths
+// out of content hashes, e.g. "status", "metadata.managedFields", or
+// "metadata.annotations" so that annotations added by later options or by
+// other tools do not change hashes. Paths do not descend into lists; map keys
+// containing dots are matched as well, e.g.
+// "metadata.annotations.example.com/owner". Calls accumulate. Hashes computed
+// this way are verified by Renderer.VerifyContentHashes.
+func WithContentHashIgnore(paths ...string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ContentHashIgnore = append(opts.ContentHashIgnore, paths...)
+	})
+}
+
+// WithSecretRedaction makes content hashes of core Secrets cover a SHA-256
+// digest of each value of data and stringData instead of the value itself, so
+// the payload never reaches the hash algorithm or a ContentHasher and hashes
+// still change whenever a value does. Other objects hash as before. Hashes
+// computed this way are verified by Renderer.VerifyContentHashes. The
+// renderer has no logging or tracing of its own: its errors, warnings, and
+// the memdebug input guard name objects by key and never print their content.
+func WithSecretRedaction(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.SecretRedaction = enabled
+	})
+}
+
+// WithContentHashStage selects where content hashes are computed. By default
+// (ContentHashPreChain) objects are hashed before the source and
+// renderer-level filters, transformers, and post-renderers, so a transformer
+// that changes an object leaves its hash describing the content before the
+// change. ContentHashPostChain hashes every object in the final pass instead,
+// after all chains, workload policies, and kind handlers, so the annotation
+// describes the emitted content; chains then see objects without hashes.
+func WithContentHashStage(stage ContentHashStage) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ContentHashStage = stage
+	})
+}
+
+// WithCanonicalMetadata enables or disables metadata normalization.
+// When enabled, empty labels and annotations maps are removed before the content
+// hash is computed, so that objects differing only by an empty map render (and
+// hash) identically. Use CanonicalJSON to export objects with a deterministic
+// key order.
+func WithCanonicalMetadata(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.CanonicalMetadata = enabled
+	})
+}
+
+// WithIdentityFunc sets the function used to decide whether two objects are the
+// same object, replacing DefaultIdentity (group, kind, namespace, name).
+func WithIdentityFunc(f IdentityFunc) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.IdentityFunc = f
+	})
+}
+
+// WithLazyValidation enables or disables deferred source validation.
+// When enabled, New does not inspect source objects; each source is validated
+// the first time Process selects it, and an invalid source fails that and
+// every later Process call with the same error New would have returned.
+// Sources skipped by source selectors are never validated.
+func WithLazyValidation(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.LazyValidation = enabled
+	})
+}
+
+// WithPartialObjectResolver sets the resolver for metadata-only objects.
+// Sources fed from metadata-only watches or Table responses hold
+// PartialObjectMetadata or Table objects; by default these fail validation with
+// ErrMetadataOnlyObject instead of being rendered as unusable stubs. With a
+// resolver, each such object is replaced during Process by the objects the
+// resolver returns. The resolver must be safe for concurrent use.
+func WithPartialObjectResolver(resolver PartialObjectResolver) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.PartialObjectResolver = resolver
+	})
+}
+
+// WithGenerationAnnotation stamps every rendered object with AnnotationGeneration
+// set to generation, typically the generation of the custom resource being
+// reconciled (strconv.FormatInt(obj.GetGeneration(), 10)). Appliers can then
+// prune the objects left over by older generations with StaleObjects.
+//
+// The annotation is added after all post-renderers, so filters cannot drop it,
+// and it is excluded from content hashes, so a new generation alone does not
+// change them. An empty generation disables stamping.
+func WithGenerationAnnotation(generation string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Generation = generation
+	})
+}
+
+// WithRenderDigestAnnotation stamps every rendered object with
+// AnnotationRenderDigest set to the digest RenderDigest returns for the render,
+// so a GitOps controller can tell from any applied object whether the rendered
+// set changed. Like the generation annotation, it is added after all
+// post-renderers and excluded from content hashes.
+func WithRenderDigestAnnotation(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.RenderDigest = enabled
+	})
+}
+
+// WithProvenanceAnnotations stamps every rendered object with when and by
+// what it was produced: AnnotationRenderTimestamp, the time of the render in
+// UTC as RFC 3339, and AnnotationRendererVersion and AnnotationEngineVersion,
+// the module versions of renderer-mem and the engine the binary was built
+// with. They complement the source annotations, which record where objects
+// come from. Like the generation annotation, they are added after all
+// post-renderers and excluded from content hashes and render digests, so
+// renders of the same inputs still compare equal except for these values.
+// Cached renders keep the timestamp of the render that produced them.
+func WithProvenanceAnnotations(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ProvenanceAnnotations = enabled
+	})
+}
+
+// WithFieldManager simulates server-side apply by manager: every rendered
+// object gets the managedFields entry the API server would record for it (see
+// ManagedFieldsEntry), replacing any managedFields it had. Diff tooling can
+// compare these entries with the live ones to predict ownership conflicts.
+//
+// Managed fields are computed last, after the generation annotation, and are
+// excluded from content hashes. An empty manager disables the simulation.
+func WithFieldManager(manager string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.FieldManager = manager
+	})
+}
+
+// WithMergeKeys registers keyed lists, such as the containers of a Deployment,
+// so WithFieldManager tracks their items by key instead of treating the lists
+// as atomic, and DuplicateMerge merges them by key instead of replacing them.
+// MergeKeysFromCRDs builds them for custom resources.
+func WithMergeKeys(keys MergeKeys) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.MergeKeys = keys
+	})
+}
+
+// WithFailOnEmpty makes a render that produces zero objects fail instead of
+// silently returning nothing, which is usually the symptom of an overly
+// aggressive filter or a wrong source selector. The returned *EmptyRenderError
+// (matching ErrEmptyRender) reports how many sources were selected and how
+// many objects the renderer-level filters, transformers, and post-renderers
+// dropped. For merged renderers, the parts count as sources.
+func WithFailOnEmpty(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.FailOnEmpty = enabled
+	})
+}
+
+// WithDeterminismCheck repeats every render until it ran n times in all and
+// fails it with ErrNondeterministicRender if the outputs differ, compared
+// byte for byte as CanonicalJSON after the final pass. It catches generators,
+// transformers, or handlers whose output depends on map iteration order, the
+// time, or other state before they produce spurious GitOps diffs. It
+// multiplies the cost of renders, so enable it in tests and debug builds.
+//
+// The render timestamp of WithProvenanceAnnotations is ignored. Renders
+// served by WithRenderCache are not repeated, a render counts once in
+// Stats, and sources whose Stream receives objects in between renders are
+// reported as nondeterministic. Every repetition runs the whole pipeline
+// again, including ObjectsFn, kind handlers, and post-renderers, so they must
+// tolerate being called n times per render; the warnings of repetitions are
+// discarded. Values of n below 2 disable the check.
+func WithDeterminismCheck(n int) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DeterminismCheck = n
+	})
+}
+
+// WithSanitizer makes rendered content JSON-safe according to sanitizer (see
+// DefaultSanitizer). Source objects are sanitized while they are copied, so
+// hand-built objects holding int, float32, time.Time, or []byte values can be
+// rendered instead of panicking in DeepCopy; the final output is checked
+// again after all post-renderers, which may introduce such values too.
+// Failures wrap ErrNonJSONValue or ErrMaxDepthExceeded.
+func WithSanitizer(sanitizer Sanitizer) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Sanitizer = &sanitizer
+	})
+}
+
+// WithKindHandler registers handler for rendered objects of kind gvk, a
+// lighter-weight alternative to a transformer doing its own GVK matching,
+// e.g. to compute annotations for Services or to validate CRDs structurally.
+// An empty version matches every version of the group and kind.
+//
+// Handlers run in the final pass, after all filters, transformers, and
+// post-renderers but before sanitization, the generation annotation, and
+// managed fields; several handlers for the same kind run in registration
+// order. The content hash of a handled object is recomputed, so it describes
+// the handled content.
+func WithKindHandler(gvk schema.GroupVersionKind, handler KindHandler) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.KindHandlers = append(opts.KindHandlers, kindHandler{gvk: gvk, handler: handler})
+	})
+}
+
+// WithEmptyObjectPolicy sets what happens when a renderer-level transformer
+// returns an empty object or strips its apiVersion, kind, or name: keep it
+// (EmptyObjectKeep, the default), drop it with a Warning (EmptyObjectDrop), or
+// fail the render (EmptyObjectError). The policy is enforced after each
+// transformer, so later transformers never see a dropped object. Unknown
+// policies fail New with ErrInvalidEmptyObjectPolicy.
+func WithEmptyObjectPolicy(policy EmptyObjectPolicy) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.EmptyObjectPolicy = policy
+	})
+}
+
+// WithValidation checks every source object in the source stage, once
+// source metadata is applied, for the structural problems that otherwise
+// only surface when it is applied: an empty apiVersion, kind, or
+// metadata.name, a name that is not a DNS-1123 subdomain (RBAC objects follow
+// the looser path segment rules), a namespace that is not a DNS-1123 label,
+// invalid label keys or values or annotation keys, and labels or annotations
+// that are null or hold non-string values. ValidationStrict fails the render
+// with an error wrapping ErrInvalidObject, ValidationLenient reports a Warning
+// instead, and ValidationOff, the default, skips the checks. Errors and
+// warnings name the source by index and name and the object by index and
+// key. Objects produced by renderer-level transformers and post-renderers are
+// not checked. Unknown modes fail New with ErrInvalidValidationMode.
+func WithValidation(mode ValidationMode) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Validation = mode
+	})
+}
+
+// WithLimits fails the render in the source stage, before later stages spend
+// time on it, as soon as a source emits more than maxObjects objects (items
+// of expanded Lists count individually) or an object whose JSON serialization,
+// once source metadata and annotations are applied, is larger than
+// maxObjectBytes, so oversized objects such as ConfigMaps stuffed with data
+// fail at render time rather than at apply time. MaxEtcdObjectBytes is the
+// limit the API server enforces. Errors wrap ErrLimitExceeded and name the
+// source and object; zero disables a limit, and negative limits fail New with
+// ErrInvalidLimits.
+func WithLimits(maxObjects int, maxObjectBytes int) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.MaxObjects = maxObjects
+		opts.MaxObjectBytes = maxObjectBytes
+	})
+}
+
+// WithTargetKubeVersion checks the output for API versions that Kubernetes
+// version kubeVersion, e.g. "1.29" or "v1.29.3", deprecates or no longer
+// serves, as listed by DeprecatedAPIs; objects built from old typed structs
+// often carry such stale apiVersions. Objects of deprecated API versions are
+// reported as Warnings naming the replacement, and objects of removed ones
+// fail the render with an error wrapping ErrRemovedAPI that names all of
+// them. The check runs in the final pass, after every stage that may change
+// API versions. Versions that do not parse fail New with
+// ErrInvalidKubeVersion; an empty version disables the check.
+func WithTargetKubeVersion(kubeVersion string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.TargetKubeVersion = kubeVersion
+	})
+}
+
+// WithSchemaValidation checks every rendered object against the schema of its
+// kind with validator, e.g. the OpenAPI schemas of a Kubernetes release or
+// the CRD schemas of CRDSchemaValidator, catching typos and wrongly typed
+// fields before the output reaches a cluster. Validation runs at the end of
+// the final pass, on the objects as returned, and reports the violations of
+// all objects at once in a *SchemaError wrapping ErrSchemaViolation; an
+// error of validator itself fails the render naming the object. Validators
+// accumulate across calls and run in registration order.
+func WithSchemaValidation(validator SchemaValidator) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.SchemaValidators = append(opts.SchemaValidators, validator)
+	})
+}
+
+// WithAllowedGVKs restricts the kinds of source objects to gvks: a source
+// object of any other kind fails the render with an error wrapping
+// ErrGVKNotAllowed that names the source by index and name and the object by
+// index and key, instead of being dropped silently as by a gvk filter. An
+// empty version allows every version of the group and kind. The check runs
+// in the source stage, after WithGVKRewrite migrations, so objects added by
+// transformers and post-renderers are not checked. Allowed kinds accumulate
+// across calls, and entries without a kind fail New with ErrInvalidGVKPolicy.
+func WithAllowedGVKs(gvks ...schema.GroupVersionKind) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.AllowedGVKs = append(opts.AllowedGVKs, gvks...)
+	})
+}
+
+// WithDeniedGVKs fails the render, like WithAllowedGVKs, on source objects
+// of any of gvks, e.g. to keep tenants from shipping ClusterRoleBindings or
+// webhook configurations. Denied kinds win over allowed ones, and accumulate
+// across calls.
+func WithDeniedGVKs(gvks ...schema.GroupVersionKind) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DeniedGVKs = append(opts.DeniedGVKs, gvks...)
+	})
+}
+
+// WithPolicy makes every render check its output against rules, CEL
+// expressions that must evaluate to true, e.g. to block images tagged
+// latest or containers without resource limits before they are applied:
+//
+//	object.kind != 'Deployment' ||
+//	  object.spec.template.spec.containers.all(c, !c.image.endsWith(':latest'))
+//
+// A rule referencing PolicyObjectVariable (object) is evaluated once per
+// rendered object; other rules are evaluated once over PolicyObjectsVariable
+// (objects), the whole output, e.g. objects.exists(o, o.kind ==
+// 'NetworkPolicy'). Objects are plain JSON, so rules guard optional fields
+// with has(). Rules run at the end of the final pass, and the violations of
+// all rules fail the render together in a *PolicyError wrapping
+// ErrPolicyViolation, naming each rule and object; a rule that cannot be
+// evaluated for an object, or that evaluates to anything but a bool, is
+// violated. Rules accumulate across calls, and rules that do not compile
+// fail New with ErrInvalidPolicy.
+func WithPolicy(rules ...string) RendererOption {
+	compiled := make([]policyRule, 0, len(rules))
+	for _, rule := range rules {
+		compiled = append(compiled, compilePolicy(rule))
+	}
+
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Policies = append(opts.Policies, compiled...)
+	})
+}
+
+// WithYAMLOptions configures how Source.Manifests are decoded, e.g. with
+// WithStrictYAML or WithYAMLLimits. Options accumulate across calls.
+func WithYAMLOptions(opts ...YAMLOption) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(rendererOpts *RendererOptions) {
+		rendererOpts.YAMLOptions = append(rendererOpts.YAMLOptions, opts...)
+	})
+}
+
+// WithDefaultNamespace makes the final pass place rendered namespaced objects
+// that do not set a namespace in namespace, as kubectl apply -n would. The
+// scope of built-in kinds and of kinds defined by rendered
+// CustomResourceDefinitions is known; other kinds are looked up with the
+// mapper set by WithRESTMapper, and without one a render containing them
+// fails with ErrUnknownScope rather than guessing. Cluster-scoped objects are
+// left alone, and defaulted objects have their content hash recomputed.
+// Namespaces are defaulted first, so WithEnsureNamespaces covers namespace.
+func WithDefaultNamespace(namespace string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DefaultNamespace = namespace
+	})
+}
+
+// WithRESTMapper sets the mapper, typically backed by API discovery, that
+// resolves the scope of kinds the renderer does not know, such as custom
+// resources whose CustomResourceDefinition is not rendered.
+func WithRESTMapper(mapper meta.RESTMapper) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.RESTMapper = mapper
+	})
+}
+
+// WithNamespacePolicy makes the final pass check where the rendered objects
+// are placed: with policy.RequireNamespace, namespaced objects must set a
+// namespace (scopes are resolved as for WithDefaultNamespace, which fills it
+// in first), and with policy.AllowedNamespaces, objects may only be placed in
+// and Namespace objects may only define the listed namespaces. Violations fail
+// the render with ErrNamespaceNotAllowed, naming every offending object. The
+// allowed namespaces are copied.
+func WithNamespacePolicy(policy NamespacePolicy) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		policy.AllowedNamespaces = slices.Clone(policy.AllowedNamespaces)
+		opts.NamespacePolicy = &policy
+	})
+}
+
+// WithEnsureNamespaces makes the final pass prepend a Namespace object, in name
+// order, for every namespace that rendered objects are placed in but that the
+// render does not define, so bundles need not maintain their namespace
+// manifests separately. The built-in namespaces (default, kube-system,
+// kube-public, and kube-node-lease) are never generated. Generated namespaces
+// go through the rest of the final pass like any other object; set their
+// metadata with WithNamespaceMetadata.
+func WithEnsureNamespaces(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.EnsureNamespaces = enabled
+	})
+}
+
+// WithNamespaceMetadata sets the labels and annotations of the Namespace
+// objects generated by WithEnsureNamespaces. The maps are copied.
+func WithNamespaceMetadata(labels map[string]string, annotations map[string]string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.NamespaceLabels = maps.Clone(labels)
+		opts.NamespaceAnnotations = maps.Clone(annotations)
+	})
+}
+
+// WithServiceAccountWiring makes the final pass wire rendered workloads (Pods
+// and the pod templates of Deployments, StatefulSets, DaemonSets, ReplicaSets,
+// ReplicationControllers, Jobs, and CronJobs) to wiring.ServiceAccountName and
+// attach wiring.ImagePullSecrets to rendered ServiceAccounts, replacing the
+// two transformers this otherwise takes. Workloads that already reference a
+// service account keep it unless wiring.Override is set. Changed objects have
+// their content hash recomputed.
+func WithServiceAccountWiring(wiring ServiceAccountWiring) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		wiring.ImagePullSecrets = slices.Clone(wiring.ImagePullSecrets)
+		opts.ServiceAccountWiring = &wiring
+	})
+}
+
+// WithSchedulingClasses makes the final pass set priorityClassName and
+// runtimeClassName on the pod specs of the workloads selector accepts (all
+// workloads if selector is nil) that do not set them yet; empty names are
+// not injected. The render then fails with ErrUnknownPriorityClass if any
+// workload references a priority class that is neither rendered as a
+// PriorityClass nor listed in the cluster's capabilities (WithCapabilities).
+func WithSchedulingClasses(priorityClassName string, runtimeClassName string, selector types.Filter) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.SchedulingClasses = &schedulingClasses{
+			priorityClassName: priorityClassName,
+			runtimeClassName:  runtimeClassName,
+			selector:          selector,
+		}
+	})
+}
+
+// WithCapabilities describes what the target cluster provides, e.g. the
+// priority classes WithSchedulingClasses may reference without rendering them.
+func WithCapabilities(capabilities Capabilities) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		capabilities.PriorityClasses = slices.Clone(capabilities.PriorityClasses)
+		opts.Capabilities = capabilities
+	})
+}
+
+// WithSpreadPolicy enforces policy on the topology spread constraints of the
+// rendered workloads it selects, except DaemonSets, so spread is governed
+// centrally rather than by every bundle. For each of policy.TopologyKeys,
+// existing constraints get the policy's maxSkew and whenUnsatisfiable, and a
+// missing constraint is added, selecting the workload's pods by their labels;
+// constraints on other keys are kept. Workloads whose pods have no labels are
+// left alone with a Warning. Invalid policies fail New with
+// ErrInvalidSpreadPolicy.
+func WithSpreadPolicy(policy SpreadPolicy) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		policy.TopologyKeys = slices.Clone(policy.TopologyKeys)
+		opts.SpreadPolicy = &policy
+	})
+}
+
+// WithDeploymentsAsStatefulSets adds the DeploymentToStatefulSet transformer,
+// e.g. to migrate a bundle to stable pod identities.
+func WithDeploymentsAsStatefulSets() RendererOption {
+	return WithTransformer(DeploymentToStatefulSet())
+}
+
+// WithSuspendedCronJobs adds the SuspendCronJobs transformer, e.g. to freeze
+// an environment.
+func WithSuspendedCronJobs() RendererOption {
+	return WithTransformer(SuspendCronJobs())
+}
+
+// WithScaledToZero adds the ScaleToZero transformer, e.g. to freeze an
+// environment or drain it before a migration.
+func WithScaledToZero() RendererOption {
+	return WithTransformer(ScaleToZero())
+}
+
+// WithDuplicatePolicy sets how Process resolves objects with the same
+// identity (see WithIdentityFunc) produced by different sources: fail with
+// ErrDuplicateObject (DuplicateError), keep the first or last occurrence
+// (DuplicateKeepFirst, DuplicateKeepLast), merge them (DuplicateMerge), or
+// emit them all (DuplicateKeepAll, the default). Duplicates are detected
+// after each source's own filters, transformers, and post-renderers, and
+// before the renderer-level chain; duplicates within one source are left as
+// they are. Unknown policies fail New with ErrInvalidDuplicatePolicy.
+func WithDuplicatePolicy(policy DuplicatePolicy) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DuplicatePolicy = policy
+	})
+}
+
+// WithListExpansion enables or disables the expansion of list objects, such
+// as v1 Lists or typed lists like ConfigMapList, into their items. Expanded
+// items replace the list in the source, so filters, transformers, and content
+// hashes apply to each resource instead of the opaque list; nested lists are
+// expanded too. Items of typed lists that lack apiVersion and kind get the
+// list's apiVersion and item kind. Metadata-only lists are resolved by the
+// PartialObjectResolver instead.
+func WithListExpansion(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ListExpansion = enabled
+	})
+}
+
+// WithMiddleware wraps the renderer's Process and ProcessResult calls with m.
+// Middlewares registered first run outermost, so they see what later ones
+// do; the render cache and Stats sit inside them all, so renders rejected by
+// a middleware are not counted. ProcessStream, ProcessEach, and Jobs do not
+// run middlewares.
+func WithMiddleware(m Middleware) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Middlewares = append(opts.Middlewares, m)
+	})
+}
+
+// WithRenderCache enables or disables caching of the latest render. A render
+// whose values and source content digest like the cached one, with the same
+// sources, returns the cached objects, already annotated, hashed, and
+// stamped, instead of running the pipeline; RenderInfo.Cached and
+// Stats.CacheHits report it. The digest covers the objects, manifests,
+// metadata, patches, and deletions of each source, and the Fingerprint of
+// generator sources; renders of generator sources without one and of stream
+// sources are never cached. Renderer options are fixed, and filters,
+// transformers, post-renderers, selectors, and resolvers are assumed to
+// depend only on their input: call Invalidate when anything else they read
+// changes. Process returns copies of the cached objects, ProcessResult shares
+// them read-only; ProcessStream and Jobs do not use the cache.
+func WithRenderCache(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.RenderCache = enabled
+	})
+}
+
+// WithName names the renderer, e.g. "mem/platform-base", so engines hosting
+// several mem renderers can tell them apart. Name returns it instead of "mem",
+// RenderInfo reports it, render errors are prefixed with it, and source
+// annotations record it in AnnotationSourceRenderer and the source chain. The
+// source type annotation stays "mem". An empty name restores the default.
+func WithName(name string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Name = name
+	})
+}
+
+// WithDeepCopy enables or disables deep copying of source objects, which is
+// the default. When disabled, Process copies only the top-level fields and
+// metadata of each object, which every render writes, and the rendered
+// objects share everything else, such as specs and data, with the sources,
+// so read-mostly pipelines over large static sources stop paying for a full
+// copy per render. Stages of the renderer that write elsewhere (patches,
+// duplicate merging, GVK migrations, workload policies, CA bundle injection,
+// and kind handlers) deep copy the objects they change first, so the
+// renderer still never modifies sources. In exchange, the caller guarantees
+// that sources and rendered objects are not modified outside their metadata,
+// by the caller or by its filters, transformers, and post-renderers; the
+// built-in transformers comply. A Sanitizer copies objects anyway.
+func WithDeepCopy(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.NoDeepCopy = !enabled
+	})
+}
+
+// WithDeletionMarkers appends the deletions of the selected sources to the
+// output, after the final pass, as copies annotated with AnnotationDelete, so
+// consumers of Process see desired absence next to desired presence. Markers
+// carry only what the deletions do and get no content hash.
+func WithDeletionMarkers(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DeletionMarkers = enabled
+	})
+}
+
+// WithConcurrency renders up to n sources at once: their selectors, objects
+// functions, copies, annotations, content hashes, and source-level filters,
+// transformers, and post-renderers run on a pool of n goroutines. Outputs are
+// collected in source order, so the render is the same as a sequential one,
+// including which error is returned when several sources fail; only the
+// order of warnings may differ. Callbacks of different sources may then run
+// concurrently within one render. Values below 2 render sources one after
+// another, which is the default. ProcessStream and Jobs always render
+// sources one after another.
+func WithConcurrency(n int) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Concurrency = n
+	})
+}
+
+// WithRenderSemaphore makes every render wait for a slot of semaphore before
+// it starts, and hold it until it completes, so that the renderers sharing
+// semaphore (see NewRenderSemaphore) run a bounded number of renders at once
+// instead of all at the same time during mass reconciles. Renders served from
+// the render cache do not wait. A render counts once however many sources it
+// renders concurrently (WithConcurrency); a stream holds its slot until it is
+// drained or abandoned, and a Job for each of its steps. Renders nested in a
+// render holding the same semaphore, such as the parts of a merged renderer
+// or renders started by its callbacks with the render's context, do not wait
+// again. If ctx is done before a slot frees up, the render fails with the
+// error of ctx.
+func WithRenderSemaphore(semaphore RenderSemaphore) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.RenderSemaphore = semaphore
+	})
+}
+
+// WithStableSort sorts the output by group, version, kind, namespace, and
+// name, so that its order no longer depends on the order of sources, of the
+// objects within them, or of whatever generated them, and GitOps diffs only
+// show content changes. Objects with the same key, kept by DuplicateKeepAll,
+// keep their relative order. The sort runs first among the orderings of the
+// final pass: WithKindOrdering, WithWebhooksLast, and WithDependencyOrdering
+// then reorder the sorted output, keeping it sorted within their ranks.
+func WithStableSort(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.StableSort = enabled
+	})
+}
+
+// WithKindOrdering sorts the output by kind in order, for consumers applying
+// it in output order: InstallOrder, UninstallOrder, or a custom KindOrder.
+// Objects of the same kind, and of the kinds sharing the place of
+// OtherKinds, keep their relative order. The sort runs in the final pass,
+// after ensured namespaces are added and before WithWebhooksLast, which
+// therefore still moves webhook configurations to the very end. An empty
+// order disables sorting.
+func WithKindOrdering(order KindOrder) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.KindOrder = order
+	})
+}
+
+// WithWebhooksLast moves ValidatingWebhookConfigurations and
+// MutatingWebhookConfigurations to the end of the output, keeping the order
+// of the other objects and of the webhook configurations among themselves.
+// Applying in output order then registers webhooks only once the services
+// and certificates they call are in place, instead of blocking the rest of
+// the rollout on an unreachable webhook.
+func WithWebhooksLast(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.WebhooksLast = enabled
+	})
+}
+
+// WithDependencyOrdering orders the output so that every object follows the
+// objects it depends on, as declared by AnnotationDependsOn, the annotation of
+// kpt and Config Sync, or AnnotationManifestDependsOn. Objects keep their
+// order otherwise: a dependency moves just before the first object depending
+// on it. The sort runs in the final pass after WithKindOrdering and
+// WithWebhooksLast, so declared dependencies win over both. Dependencies on
+// objects that are not rendered are reported as warnings, malformed
+// references fail the render with ErrInvalidDependency, and cycles with
+// ErrDependencyCycle.
+func WithDependencyOrdering(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DependencyOrdering = enabled
+	})
+}
+
+// WithCABundleResolver makes the final pass set the clientConfig.caBundle of
+// every webhook in the rendered webhook configurations to the base64 encoding
+// of the bundle resolver returns for it. Webhooks for which it returns nil
+// keep their caBundle, or get the placeholder of WithCABundlePlaceholder if
+// they have none. Changed objects have their content hash recomputed.
+func WithCABundleResolver(resolver CABundleResolver) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		injection := caBundleInjection{resolver: resolver}
+		if opts.CABundles != nil {
+			injection.placeholder = opts.CABundles.placeholder
+		}
+
+		opts.CABundles = &injection
+	})
+}
+
+// WithCABundlePlaceholder makes the final pass set the clientConfig.caBundle
+// of the webhooks without one, and without a bundle from the resolver of
+// WithCABundleResolver, to placeholder verbatim, for deployment tooling to
+// substitute once the CA is known. Changed objects have their content hash
+// recomputed.
+func WithCABundlePlaceholder(placeholder string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		injection := caBundleInjection{placeholder: placeholder}
+		if opts.CABundles != nil {
+			injection.resolver = opts.CABundles.resolver
+		}
+
+		opts.CABundles = &injection
+	})
+}
+
+// WithCRDWaitAnnotations marks rendered CustomResourceDefinitions and the
+// rendered custom resources of their kinds, so appliers know which resources
+// to hold back until which CRD is Established: CRDs get
+// AnnotationWaitEstablished and resources get AnnotationWaitForCRD with the
+// CRD's name. The marks are set in the final pass, after workload policies,
+// so they cover every rendered object. CRDDependencies returns the same
+// information without annotating.
+func WithCRDWaitAnnotations() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.CRDWaitAnnotations = true
+	})
+}
+
+// WithOverlayMerge makes later sources patch the objects of earlier sources
+// that share their identity instead of being appended, to model a base and
+// its environment overlays as plain sources. It sets the DuplicateMerge
+// policy and merges the keyed lists of built-in kinds, such as containers by
+// name, as a strategic merge patch would (see BuiltinMergeKeys); keys
+// registered with WithMergeKeys take precedence. Patch directives such as
+// $patch are not supported: a nil value deletes a field, as in a JSON merge
+// patch. For a plain JSON merge, where unregistered lists are replaced, use
+// WithDuplicatePolicy(DuplicateMerge) instead.
+func WithOverlayMerge() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DuplicatePolicy = DuplicateMerge
+		opts.OverlayMerge = true
+	})
+}
+
+// WithGVKRewrite migrates objects between API versions or kinds while they are
+// rendered, for example policy/v1beta1 PodDisruptionBudgets to policy/v1.
+// Every object whose group, version, and kind is a key of rewrites gets the
+// mapped one, then each migrate function runs on it to adapt fields that
+// differ between the two schemas; without them, fields are kept as they are.
+//
+// Rewrites happen right after objects are copied, so annotations, content
+// hashes, and all filters, transformers, and post-renderers see the new
+// version. They are not chained: an object is rewritten at most once, by the
+// first registered rewrite matching it. Result.Migrations reports the
+// rewritten objects.
+func WithGVKRewrite(
+	rewrites map[schema.GroupVersionKind]schema.GroupVersionKind,
+	migrate ...GVKMigrateFunc,
+) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.GVKRewrites = append(opts.GVKRewrites, gvkRewrite{
+			rewrites: maps.Clone(rewrites),
+			migrate:  slices.Clone(migrate),
+		})
+	})
+}
+
+// WithAPIMigration converts objects of deprecated API versions of built-in
+// kinds to their current versions while they are rendered, like a
+// WithGVKRewrite covering MigratableAPIs, e.g. extensions/v1beta1 Ingresses
+// to networking.k8s.io/v1. Fields that moved are migrated per kind: Ingress
+// backends and path types, the selectors workloads used to default, the
+// metrics of autoscaling/v2beta1 HorizontalPodAutoscalers, the per-version
+// schemas of CustomResourceDefinitions, and the defaults v1 changed for
+// webhooks. Update strategies that apps/v1 defaults differently are pinned,
+// while other changed defaults, such as the revision history limit of
+// Deployments, take their new values. Objects that cannot be converted
+// without changing their meaning, like PodDisruptionBudgets with an empty
+// selector or webhooks with side effects, fail the render. Rewrites
+// registered with WithGVKRewrite take precedence, and Result.Migrations
+// reports the migrated objects.
+func WithAPIMigration(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.APIMigration = enabled
+	})
+}
+
+// WithCRVersionAlignment converts custom resources rendered at several
+// versions of the same CustomResourceDefinition to a single version, so the
+// output is version-consistent, as the API server stores it. Only kinds
+// defined by a CRD in the render are aligned: their resources are converted
+// to the version of the kind's converter, or to the storage version of the
+// CRD, and the converter's Convert function adapts their fields. CRDs with the
+// Webhook conversion strategy need a converter with a Convert function;
+// others only get their apiVersion rewritten, as with the None strategy.
+//
+// Conversion happens after sources are rendered and before duplicates are
+// resolved, so the same object rendered at two versions is one duplicate.
+// Result.Migrations reports the converted resources. Merged renderers only
+// align the resources of each of their parts.
+func WithCRVersionAlignment(converters map[schema.GroupKind]CRConverter) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.CRVersionAlignment = &crVersionAlignment{converters: maps.Clone(converters)}
+	})
+}
@@ -0,0 +1,234 @@
+package mem_test
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/filter/meta/gvk"
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+	"pgregory.net/rapid"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// The properties below form an executable specification of the renderer
+// semantics. Each one is checked against randomly generated sources and
+// option sets; rapid shrinks any counterexample to a minimal one.
+
+//nolint:gochecknoglobals
+var propertyKinds = []schema.GroupVersionKind{
+	{Version: "v1", Kind: "ConfigMap"},
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "Service"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+}
+
+func genObject() *rapid.Generator[unstructured.Unstructured] {
+	return rapid.Custom(func(t *rapid.T) unstructured.Unstructured {
+		gvkValue := rapid.SampledFrom(propertyKinds).Draw(t, "gvk")
+
+		obj := unstructured.Unstructured{Object: map[string]any{}}
+		obj.SetGroupVersionKind(gvkValue)
+		obj.SetName(rapid.StringMatching(`[a-z]([a-z0-9-]{0,14}[a-z0-9])?`).Draw(t, "name"))
+		obj.SetNamespace(rapid.SampledFrom([]string{"", "default", "kube-system"}).Draw(t, "namespace"))
+
+		objLabels := rapid.MapOf(
+			rapid.StringMatching(`[a-z]{1,8}`),
+			rapid.StringMatching(`[a-z0-9]{0,8}`),
+		).Draw(t, "labels")
+		if len(objLabels) > 0 {
+			obj.SetLabels(objLabels)
+		}
+
+		data := rapid.MapOf(rapid.StringMatching(`[a-z]{1,8}`), rapid.String()).Draw(t, "data")
+		for k, v := range data {
+			_ = unstructured.SetNestedField(obj.Object, v, "data", k)
+		}
+
+		return obj
+	})
+}
+
+func genSource() *rapid.Generator[mem.Source] {
+	return rapid.Custom(func(t *rapid.T) mem.Source {
+		return mem.Source{Objects: rapid.SliceOfN(genObject(), 0, 8).Draw(t, "objects")}
+	})
+}
+
+func genOptions() *rapid.Generator[[]mem.RendererOption] {
+	return rapid.Custom(func(t *rapid.T) []mem.RendererOption {
+		opts := []mem.RendererOption{
+			mem.WithSourceAnnotations(rapid.Bool().Draw(t, "sourceAnnotations")),
+			mem.WithContentHash(rapid.Bool().Draw(t, "contentHash")),
+		}
+
+		for _, k := range rapid.SliceOfNDistinct(rapid.SampledFrom(propertyKinds), 0, 2, gvkID).Draw(t, "filterKinds") {
+			opts = append(opts, mem.WithFilter(gvk.Filter(k)))
+		}
+
+		return opts
+	})
+}
+
+func gvkID(k schema.GroupVersionKind) string {
+	return k.String()
+}
+
+func renderSources(t *rapid.T, sources []mem.Source, opts []mem.RendererOption) []unstructured.Unstructured {
+	renderer, err := mem.New(sources, opts...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	objects, err := renderer.Process(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	return objects
+}
+
+// withoutSourceIndexes removes the source and object index annotations, which
+// record where sources sit among the inputs of a renderer and are therefore
+// expected to change when sources are reordered or rendered apart.
+func withoutSourceIndexes(objects []unstructured.Unstructured) []unstructured.Unstructured {
+	for i := range objects {
+		unstructured.RemoveNestedField(objects[i].Object, "metadata", "annotations", mem.AnnotationSourceIndex)
+		unstructured.RemoveNestedField(objects[i].Object, "metadata", "annotations", mem.AnnotationObjectIndex)
+	}
+
+	return objects
+}
+
+// sortByContent orders objects by a hash of their full content, which gives a
+// total order even when several objects share the same identity.
+func sortByContent(objects []unstructured.Unstructured) {
+	slices.SortFunc(objects, func(a unstructured.Unstructured, b unstructured.Unstructured) int {
+		return cmp.Compare(k8s.ContentHash(&a), k8s.ContentHash(&b))
+	})
+}
+
+func TestProperties(t *testing.T) {
+
+	t.Run("output count never exceeds input count", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			sources := rapid.SliceOfN(genSource(), 0, 4).Draw(t, "sources")
+			opts := genOptions().Draw(t, "opts")
+
+			total := 0
+			for _, s := range sources {
+				total += len(s.Objects)
+			}
+
+			if got := len(renderSources(t, sources, opts)); got > total {
+				t.Fatalf("rendered %d objects from %d inputs", got, total)
+			}
+		})
+	})
+
+	t.Run("without filters every input is emitted in order", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			sources := rapid.SliceOfN(genSource(), 0, 4).Draw(t, "sources")
+
+			objects := renderSources(t, sources, []mem.RendererOption{mem.WithContentHash(false)})
+
+			expected := make([]unstructured.Unstructured, 0, len(objects))
+			for _, s := range sources {
+				expected = append(expected, s.Objects...)
+			}
+
+			NewWithT(t).Expect(objects).To(Equal(expected))
+		})
+	})
+
+	t.Run("content hash is independent of key insertion order", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			obj := genObject().Draw(t, "object")
+
+			keys := make([]string, 0, len(obj.Object))
+			for k := range obj.Object {
+				keys = append(keys, k)
+			}
+
+			slices.Sort(keys)
+			permuted := rapid.Permutation(keys).Draw(t, "order")
+
+			reordered := unstructured.Unstructured{Object: make(map[string]any, len(obj.Object))}
+			for _, k := range permuted {
+				reordered.Object[k] = obj.Object[k]
+			}
+
+			a := renderSources(t, []mem.Source{{Objects: []unstructured.Unstructured{obj}}}, nil)
+			b := renderSources(t, []mem.Source{{Objects: []unstructured.Unstructured{reordered}}}, nil)
+
+			ha := a[0].GetAnnotations()[pkgtypes.AnnotationContentHash]
+			hb := b[0].GetAnnotations()[pkgtypes.AnnotationContentHash]
+			if ha == "" || ha != hb {
+				t.Fatalf("hash changed under key reordering: %q != %q", ha, hb)
+			}
+		})
+	})
+
+	t.Run("Process is idempotent", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			sources := rapid.SliceOfN(genSource(), 0, 4).Draw(t, "sources")
+			opts := genOptions().Draw(t, "opts")
+
+			renderer, err := mem.New(sources, opts...)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+
+			first, err := renderer.Process(t.Context(), nil)
+			if err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+
+			second, err := renderer.Process(t.Context(), nil)
+			if err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+
+			NewWithT(t).Expect(second).To(Equal(first))
+		})
+	})
+
+	t.Run("independent sources commute", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			a := genSource().Draw(t, "a")
+			b := genSource().Draw(t, "b")
+			opts := genOptions().Draw(t, "opts")
+
+			ab := withoutSourceIndexes(renderSources(t, []mem.Source{a, b}, opts))
+			ba := withoutSourceIndexes(renderSources(t, []mem.Source{b, a}, opts))
+
+			sortByContent(ab)
+			sortByContent(ba)
+
+			NewWithT(t).Expect(ba).To(Equal(ab))
+		})
+	})
+
+	t.Run("rendering sources together equals rendering them apart", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			sources := rapid.SliceOfN(genSource(), 0, 4).Draw(t, "sources")
+			opts := genOptions().Draw(t, "opts")
+
+			together := renderSources(t, sources, opts)
+
+			apart := make([]unstructured.Unstructured, 0, len(together))
+			for _, s := range sources {
+				apart = append(apart, renderSources(t, []mem.Source{s}, opts)...)
+			}
+
+			NewWithT(t).Expect(withoutSourceIndexes(apart)).To(Equal(withoutSourceIndexes(together)))
+		})
+	})
+}
@@ -0,0 +1,147 @@
+package mem_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/filter/meta/gvk"
+	"github.com/k8s-manifest-kit/engine/pkg/transformer/meta/labels"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// mutateInPlace is a post-renderer that deliberately scribbles over the objects
+// it receives, simulating a careless user post-renderer.
+func mutateInPlace(_ context.Context, objects []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	for i := range objects {
+		objects[i].Object["scribbled"] = true
+		objects[i].SetName(objects[i].GetName() + "-mutated")
+
+		if spec, ok := objects[i].Object["spec"].(map[string]any); ok {
+			spec["scribbled"] = true
+		}
+	}
+
+	return objects, nil
+}
+
+// mutatingTransformer writes into the nested maps of the object it receives
+// instead of returning a fresh copy.
+func mutatingTransformer(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+	obj.SetLabels(map[string]string{"transformed": "true"})
+
+	if data, ok := obj.Object["data"].(map[string]any); ok {
+		data["transformed"] = "true"
+	}
+
+	return obj, nil
+}
+
+func TestProcessDoesNotMutateInputs(t *testing.T) {
+	toggles := []struct {
+		name string
+		opt  mem.RendererOption
+	}{
+		{"source-annotations", mem.WithSourceAnnotations(true)},
+		{"no-content-hash", mem.WithContentHash(false)},
+		{"filter", mem.WithFilter(gvk.Filter(corev1.SchemeGroupVersion.WithKind("ConfigMap")))},
+		{"labels-transformer", mem.WithTransformer(labels.Set(map[string]string{"env": "test"}))},
+		{"mutating-transformer", mem.WithTransformer(mutatingTransformer)},
+		{"mutating-post-renderer", mem.WithPostRenderer(mutateInPlace)},
+	}
+
+	for mask := range 1 << (len(toggles) + 1) {
+		opts := make([]mem.RendererOption, 0, len(toggles))
+		name := ""
+
+		for i, toggle := range toggles {
+			if mask&(1<<i) != 0 {
+				opts = append(opts, toggle.opt)
+				name += toggle.name + "+"
+			}
+		}
+
+		withSourcePostRenderer := mask&(1<<len(toggles)) != 0
+		if withSourcePostRenderer {
+			name += "source-post-renderer+"
+		}
+
+		t.Run(fmt.Sprintf("%02d/%s", mask, name), func(t *testing.T) {
+			g := NewWithT(t)
+
+			objects := purityFixtures(g)
+			expected := make([]unstructured.Unstructured, len(objects))
+			for i := range objects {
+				objects[i].DeepCopyInto(&expected[i])
+			}
+
+			source := mem.Source{Objects: objects}
+			if withSourcePostRenderer {
+				source.PostRenderers = append(source.PostRenderers, mutateInPlace)
+			}
+
+			renderer, err := mem.New([]mem.Source{source}, opts...)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			for range 2 {
+				_, err = renderer.Process(t.Context(), nil)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(objects).To(Equal(expected))
+			}
+		})
+	}
+}
+
+func TestProcessOutputIsIndependent(t *testing.T) {
+
+	t.Run("mutating one result should not affect the next", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: purityFixtures(g)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		first, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for i := range first {
+			first[i].SetName("changed")
+		}
+
+		second, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(second[0].GetName()).To(Equal("test-pod"))
+		g.Expect(second[1].GetName()).To(Equal("test-config"))
+	})
+}
+
+func purityFixtures(g Gomega) []unstructured.Unstructured {
+	pod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Labels: map[string]string{"app": "test"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest"}},
+		},
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Annotations: map[string]string{"note": "keep"}},
+		Data:       map[string]string{"key": "value"},
+	}
+
+	unstrPod, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	unstrCM, err := runtime.DefaultUnstructuredConverter.ToUnstructured(configMap)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return []unstructured.Unstructured{{Object: unstrPod}, {Object: unstrCM}}
+}
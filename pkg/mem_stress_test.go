@@ -0,0 +1,284 @@
+package mem_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/filter/meta/gvk"
+	"github.com/k8s-manifest-kit/engine/pkg/transformer/meta/labels"
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// These tests exercise the guarantees documented by the Concurrency constants.
+// They are only meaningful under the race detector: make test/race.
+
+func stressLevel(t *testing.T) (int, int) {
+	t.Helper()
+
+	if testing.Short() {
+		return 4, 10
+	}
+
+	return 16, 100
+}
+
+func TestStressSharedRenderer(t *testing.T) {
+	g := NewWithT(t)
+	workers, iterations := stressLevel(t)
+
+	g.Expect(mem.RendererConcurrency).To(Equal(mem.ConcurrencySafe))
+	g.Expect(mem.ResultConcurrency).To(Equal(mem.ConcurrencyOwned))
+
+	source := mem.Source{
+		Objects: purityFixtures(g),
+		PostRenderers: []pkgtypes.PostRenderer{
+			func(_ context.Context, objects []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+				for i := range objects {
+					objects[i].SetNamespace("stress")
+				}
+
+				return objects, nil
+			},
+		},
+	}
+
+	renderer, err := mem.New(
+		[]mem.Source{source, source},
+		mem.WithSourceAnnotations(true),
+		mem.WithFilter(gvk.Filter(corev1.SchemeGroupVersion.WithKind("ConfigMap"))),
+		mem.WithTransformer(labels.Set(map[string]string{"stress": "true"})),
+		mem.WithSourceSelector(func(_ context.Context, s mem.Source) (bool, error) {
+			return len(s.Objects) > 0, nil
+		}),
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for range iterations {
+				objects, err := renderer.Process(t.Context(), nil)
+				if err != nil {
+					t.Errorf("Process failed: %v", err)
+
+					return
+				}
+
+				if len(objects) != 2 {
+					t.Errorf("expected 2 objects, got %d", len(objects))
+
+					return
+				}
+
+				// Results are owned by the caller and may be mutated freely.
+				for i := range objects {
+					objects[i].SetName("mine")
+					objects[i].Object["data"] = nil
+				}
+
+				_ = renderer.Name()
+			}
+		})
+	}
+
+	// Hand-off sources may still be read by the caller while renders run.
+	wg.Go(func() {
+		for range iterations {
+			for i := range source.Objects {
+				_ = source.Objects[i].GetName()
+				_ = source.Objects[i].GetLabels()
+			}
+		}
+	})
+
+	wg.Wait()
+}
+
+func TestStressSharedRendererJobs(t *testing.T) {
+	g := NewWithT(t)
+	workers, iterations := stressLevel(t)
+
+	g.Expect(mem.JobConcurrency).To(Equal(mem.ConcurrencyOwned))
+
+	renderer, err := mem.New(
+		[]mem.Source{{Objects: purityFixtures(g)}, {Objects: purityFixtures(g)}},
+		mem.WithSourceAnnotations(true),
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	expected, err := renderer.Process(t.Context(), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for range iterations {
+				result, err := renderer.NewJob(nil, mem.WithBatchSize(1)).Run(t.Context())
+				if err != nil {
+					t.Errorf("job failed: %v", err)
+
+					return
+				}
+
+				if result.View().Len() != len(expected) {
+					t.Errorf("expected %d objects, got %d", len(expected), result.View().Len())
+
+					return
+				}
+			}
+		})
+
+		wg.Go(func() {
+			for range iterations {
+				if _, err := renderer.Process(t.Context(), nil); err != nil {
+					t.Errorf("Process failed: %v", err)
+
+					return
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+}
+
+func TestStressSharedOptions(t *testing.T) {
+	g := NewWithT(t)
+	workers, iterations := stressLevel(t)
+
+	g.Expect(mem.OptionConcurrency).To(Equal(mem.ConcurrencySafe))
+
+	keep := func(_ context.Context, _ unstructured.Unstructured) (bool, error) {
+		return true, nil
+	}
+
+	// Spare capacity makes any append into the shared backing array visible
+	// to the race detector.
+	shared := mem.RendererOptions{
+		Filters:     make([]pkgtypes.Filter, 1, 16),
+		ContentHash: true,
+	}
+	shared.Filters[0] = keep
+
+	objects := purityFixtures(g)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for range iterations {
+				renderer, err := mem.New(
+					[]mem.Source{{Objects: objects}},
+					shared,
+					mem.WithFilter(keep),
+					mem.WithTransformer(labels.Set(map[string]string{"x": "y"})),
+				)
+				if err != nil {
+					t.Errorf("New failed: %v", err)
+
+					return
+				}
+
+				if _, err := renderer.Process(t.Context(), nil); err != nil {
+					t.Errorf("Process failed: %v", err)
+
+					return
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+
+	g.Expect(shared.Filters).To(HaveLen(1))
+}
+
+func TestStressSharedEngine(t *testing.T) {
+	g := NewWithT(t)
+	workers, iterations := stressLevel(t)
+
+	e, err := mem.NewEngine(mem.Source{Objects: purityFixtures(g)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for range iterations {
+				objects, err := e.Render(t.Context())
+				if err != nil {
+					t.Errorf("Render failed: %v", err)
+
+					return
+				}
+
+				if len(objects) != 2 {
+					t.Errorf("expected 2 objects, got %d", len(objects))
+
+					return
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+}
+
+func TestStressSourceMutation(t *testing.T) {
+	g := NewWithT(t)
+	workers, iterations := stressLevel(t)
+
+	renderer, err := mem.New([]mem.Source{{Name: "base", Objects: purityFixtures(g)}})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	extra := purityFixtures(g)
+	want := len(extra)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for range iterations {
+				objects, err := renderer.Process(t.Context(), nil)
+				if err != nil {
+					t.Errorf("Process failed: %v", err)
+
+					return
+				}
+
+				// The extra source is added and removed in one transaction
+				// each, so renders see it either whole or not at all.
+				if len(objects) != want && len(objects) != 2*want {
+					t.Errorf("expected %d or %d objects, got %d", want, 2*want, len(objects))
+
+					return
+				}
+			}
+		})
+	}
+
+	wg.Go(func() {
+		for range iterations {
+			if err := renderer.Begin().
+				Upsert(mem.Source{Name: "extra", Objects: extra}).
+				Commit(); err != nil {
+				t.Errorf("Commit failed: %v", err)
+
+				return
+			}
+
+			if err := renderer.RemoveSource("extra"); err != nil {
+				t.Errorf("RemoveSource failed: %v", err)
+
+				return
+			}
+		}
+	})
+
+	wg.Wait()
+}
@@ -1,27 +1,462 @@
 package mem
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var (
 	// ErrObjectEmpty is returned when an object is empty or has nil internal data.
 	ErrObjectEmpty = errors.New("object is empty or has nil internal data")
+
+	// ErrPatchTargetNotFound is returned when an overlay patch matches no object.
+	ErrPatchTargetNotFound = errors.New("patch target not found")
+
+	// ErrInvalidPatch is returned for a malformed patch.
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrPatchFailed is returned when a JSON patch operation cannot be applied.
+	ErrPatchFailed = errors.New("patch failed")
+
+	// ErrInvalidEnvironmentName is returned when an environment name cannot be used as a directory name.
+	ErrInvalidEnvironmentName = errors.New("invalid environment name")
+
+	// ErrDuplicateObject is returned when two objects share the same identity where identities must be unique.
+	ErrDuplicateObject = errors.New("duplicate object")
+
+	// ErrRendererNil is returned when a nil renderer is passed where one is required.
+	ErrRendererNil = errors.New("renderer is nil")
+
+	// ErrInvalidDuplicatePolicy is returned for an unknown DuplicatePolicy.
+	ErrInvalidDuplicatePolicy = errors.New("invalid duplicate policy")
+
+	// ErrInvalidSourceChain is returned when an object carries a malformed source chain annotation.
+	ErrInvalidSourceChain = errors.New("invalid source chain")
+
+	// ErrInvalidCRD is returned when an object passed as a CustomResourceDefinition is not a valid one.
+	ErrInvalidCRD = errors.New("invalid custom resource definition")
+
+	// ErrUnsupportedEncoding is returned when YAML input is not valid UTF-8.
+	ErrUnsupportedEncoding = errors.New("unsupported encoding")
+
+	// ErrTabIndentation is returned when YAML input is indented with tabs.
+	ErrTabIndentation = errors.New("tab indentation")
+
+	// ErrYAMLLimitExceeded is returned when YAML input exceeds a decoding limit, which may indicate a YAML bomb.
+	ErrYAMLLimitExceeded = errors.New("YAML limit exceeded")
+
+	// ErrMetadataOnlyObject is returned for PartialObjectMetadata and Table objects that cannot be
+	// rendered as they are.
+	ErrMetadataOnlyObject = errors.New("metadata-only object")
+
+	// ErrInsecureURL is returned when a URL source does not use HTTPS.
+	ErrInsecureURL = errors.New("insecure URL")
+
+	// ErrUnexpectedStatus is returned when fetching a URL source fails with an HTTP error status.
+	ErrUnexpectedStatus = errors.New("unexpected HTTP status")
+
+	// ErrChecksumMismatch is returned when fetched content does not match its pinned checksum.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrStreamPull is returned when a Stream fails to pull objects.
+	ErrStreamPull = errors.New("stream pull failed")
+
+	// ErrMissingMergeKey is returned when an item of a keyed list lacks its merge key.
+	ErrMissingMergeKey = errors.New("list item is missing its merge key")
+
+	// ErrInvalidSourcePosition is returned when an object carries a malformed source position annotation.
+	ErrInvalidSourcePosition = errors.New("invalid source position")
+
+	// ErrInvalidSourceIndex is returned when an object carries a malformed source or object index annotation.
+	ErrInvalidSourceIndex = errors.New("invalid source index")
+
+	// ErrEmptyRender is returned when a render produces no objects and WithFailOnEmpty is enabled.
+	ErrEmptyRender = errors.New("render produced no objects")
+
+	// ErrNondeterministicRender is returned when WithDeterminismCheck finds renders of the same inputs that differ.
+	ErrNondeterministicRender = errors.New("nondeterministic render")
+
+	// ErrNonJSONValue is returned when object content holds a value that is not JSON-compatible.
+	ErrNonJSONValue = errors.New("value is not JSON-compatible")
+
+	// ErrMaxDepthExceeded is returned when object content is nested deeper than allowed.
+	ErrMaxDepthExceeded = errors.New("maximum object depth exceeded")
+
+	// ErrInvalidSanitizePolicy is returned for an unknown SanitizePolicy.
+	ErrInvalidSanitizePolicy = errors.New("invalid sanitize policy")
+
+	// ErrInvalidStage is returned for an unknown or unsupported pipeline Stage.
+	ErrInvalidStage = errors.New("invalid pipeline stage")
+
+	// ErrInvalidSnapshot is returned when a cluster snapshot archive is malformed or incomplete.
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+	// ErrSnapshotHashMismatch is returned when the content of a cluster snapshot does not match its recorded hashes.
+	ErrSnapshotHashMismatch = errors.New("snapshot hash mismatch")
+
+	// ErrJobNotDone is returned when the result of a Job is requested before its render is complete.
+	ErrJobNotDone = errors.New("job is not done")
+
+	// ErrMissingIdentity is returned when an object lacks the fields that identify it.
+	ErrMissingIdentity = errors.New("object is missing identity fields")
+
+	// ErrInvalidEmptyObjectPolicy is returned for an unknown EmptyObjectPolicy.
+	ErrInvalidEmptyObjectPolicy = errors.New("invalid empty object policy")
+
+	// ErrInvalidObject is returned when WithValidation(ValidationStrict) finds a malformed object.
+	ErrInvalidObject = errors.New("invalid object")
+
+	// ErrInvalidValidationMode is returned for an unknown ValidationMode.
+	ErrInvalidValidationMode = errors.New("invalid validation mode")
+
+	// ErrGVKNotAllowed is returned when a source object has a kind WithAllowedGVKs or WithDeniedGVKs rejects.
+	ErrGVKNotAllowed = errors.New("GVK not allowed")
+
+	// ErrInvalidGVKPolicy is returned when a WithAllowedGVKs or WithDeniedGVKs entry has no kind.
+	ErrInvalidGVKPolicy = errors.New("invalid GVK policy")
+
+	// ErrPolicyViolation is wrapped by the PolicyError of a render whose output violates WithPolicy rules.
+	ErrPolicyViolation = errors.New("policy violation")
+
+	// ErrInvalidPolicy is returned when a WithPolicy rule does not compile.
+	ErrInvalidPolicy = errors.New("invalid policy")
+
+	// ErrRemovedAPI is returned when the output uses API versions the WithTargetKubeVersion version no longer serves.
+	ErrRemovedAPI = errors.New("removed API version")
+
+	// ErrInvalidKubeVersion is returned when a Kubernetes version does not parse.
+	ErrInvalidKubeVersion = errors.New("invalid Kubernetes version")
+
+	// ErrSchemaViolation is wrapped by the SchemaError of a render whose output does not match its schemas.
+	ErrSchemaViolation = errors.New("schema violation")
+
+	// ErrSourceNotFound is returned when no source of a renderer has the given name.
+	ErrSourceNotFound = errors.New("source not found")
+
+	// ErrDuplicateSource is returned when two sources of a renderer would share a name.
+	ErrDuplicateSource = errors.New("duplicate source name")
+
+	// ErrInvalidSource is returned when the fields of a Source contradict each other.
+	ErrInvalidSource = errors.New("invalid source")
+
+	// ErrReadOnlyRenderer is returned when changing the sources of a frozen or merged renderer.
+	ErrReadOnlyRenderer = errors.New("renderer is read-only")
+
+	// ErrTransactionDone is returned when a source transaction is committed again.
+	ErrTransactionDone = errors.New("transaction already committed")
+
+	// ErrUnknownPriorityClass is returned when a workload references a priority
+	// class that is neither rendered nor known to the cluster.
+	ErrUnknownPriorityClass = errors.New("unknown priority class")
+
+	// ErrInvalidSpreadPolicy is returned for a SpreadPolicy that cannot be enforced.
+	ErrInvalidSpreadPolicy = errors.New("invalid spread policy")
+
+	// ErrInvalidKindOrder is returned when a KindOrder lists an empty kind or a kind twice.
+	ErrInvalidKindOrder = errors.New("invalid kind order")
+
+	// ErrInvalidDependency is returned for a malformed depends-on reference.
+	ErrInvalidDependency = errors.New("invalid dependency")
+
+	// ErrDependencyCycle is returned when objects depend on each other in a cycle.
+	ErrDependencyCycle = errors.New("dependency cycle")
+
+	// ErrInvalidGVKRewrite is returned for a GVK rewrite missing a version or kind.
+	ErrInvalidGVKRewrite = errors.New("invalid GVK rewrite")
+
+	// ErrInvalidConversion is returned when custom resources cannot be converted to the version chosen for their CRD.
+	ErrInvalidConversion = errors.New("invalid custom resource conversion")
+
+	// ErrInvalidCABundle is returned for CA bundle injection without a resolver
+	// or placeholder, or into malformed webhooks.
+	ErrInvalidCABundle = errors.New("invalid CA bundle injection")
+
+	// ErrInvalidHashAlgorithm is returned for a HashAlgorithm that is not built in.
+	ErrInvalidHashAlgorithm = errors.New("invalid hash algorithm")
+
+	// ErrInvalidContentHashStage is returned for a ContentHashStage that is not defined.
+	ErrInvalidContentHashStage = errors.New("invalid content hash stage")
+
+	// ErrInvalidFieldPath is returned for a field path with empty segments.
+	ErrInvalidFieldPath = errors.New("invalid field path")
+
+	// ErrInvalidRedactionRule is returned for a RedactionRule without fields to mask or with an
+	// invalid path or pattern.
+	ErrInvalidRedactionRule = errors.New("invalid redaction rule")
+
+	// ErrInvalidObjectKey is returned when a string is not a key formatted by ObjectKeyOf.
+	ErrInvalidObjectKey = errors.New("invalid object key")
+
+	// ErrUnknownScope is returned when the scope of a kind cannot be determined.
+	ErrUnknownScope = errors.New("unknown resource scope")
+
+	// ErrInvalidNamespace is returned for a default namespace that is not a valid namespace name.
+	ErrInvalidNamespace = errors.New("invalid namespace")
+
+	// ErrNamespaceNotAllowed is returned when rendered objects break the namespace policy.
+	ErrNamespaceNotAllowed = errors.New("namespace not allowed")
+
+	// ErrInvalidNamespacePolicy is returned for a NamespacePolicy allowing an invalid namespace name.
+	ErrInvalidNamespacePolicy = errors.New("invalid namespace policy")
+
+	// ErrLimitExceeded is returned when a source emits more objects, or an object is larger, than WithLimits allows.
+	ErrLimitExceeded = errors.New("render limit exceeded")
+
+	// ErrInvalidLimits is returned for negative WithLimits limits.
+	ErrInvalidLimits = errors.New("invalid render limits")
+
+	// ErrObjectNil is returned when a nil typed object is passed for conversion.
+	ErrObjectNil = errors.New("object is nil")
+
+	// ErrNoDocuments is returned when YAML input contains no documents.
+	ErrNoDocuments = errors.New("no YAML documents found")
+
+	// ErrMultipleDocuments is returned when a single document is expected but YAML input contains several.
+	ErrMultipleDocuments = errors.New("expected a single YAML document")
 )
 
+// validate checks the options that New and Merge cannot accept.
+func (opts *RendererOptions) validate() error {
+	if opts.Sanitizer != nil {
+		if err := opts.Sanitizer.validate(); err != nil {
+			return err
+		}
+	}
+
+	if opts.SpreadPolicy != nil {
+		if err := opts.SpreadPolicy.validate(); err != nil {
+			return err
+		}
+	}
+
+	if opts.NamespacePolicy != nil {
+		if err := opts.NamespacePolicy.validate(); err != nil {
+			return err
+		}
+	}
+
+	if opts.CABundles != nil {
+		if err := opts.CABundles.validate(); err != nil {
+			return err
+		}
+	}
+
+	if opts.TargetKubeVersion != "" {
+		if _, err := parseKubeVersion(opts.TargetKubeVersion); err != nil {
+			return err
+		}
+	}
+
+	if err := validateLimits(opts.MaxObjects, opts.MaxObjectBytes); err != nil {
+		return err
+	}
+
+	if opts.DefaultNamespace != "" {
+		if err := validateNamespaceName(opts.DefaultNamespace); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidNamespace, err)
+		}
+	}
+
+	if err := validateGVKPatterns(opts.AllowedGVKs); err != nil {
+		return err
+	}
+
+	if err := validateGVKPatterns(opts.DeniedGVKs); err != nil {
+		return err
+	}
+
+	for _, rule := range opts.Policies {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, rewrite := range opts.GVKRewrites {
+		if err := rewrite.validate(); err != nil {
+			return err
+		}
+	}
+
+	if opts.ContentHashAlgorithm != "" {
+		if err := opts.ContentHashAlgorithm.validate(); err != nil {
+			return err
+		}
+	}
+
+	if opts.ContentHashStage != "" {
+		if err := opts.ContentHashStage.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range opts.ContentHashIgnore {
+		if err := validateFieldPath(path); err != nil {
+			return err
+		}
+	}
+
+	if opts.DuplicatePolicy != "" {
+		if err := opts.DuplicatePolicy.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := opts.KindOrder.validate(); err != nil {
+		return err
+	}
+
+	if err := opts.Validation.validate(); err != nil {
+		return err
+	}
+
+	return opts.EmptyObjectPolicy.validate()
+}
+
 // sourceHolder wraps a Source with internal state for consistency with other renderers.
 type sourceHolder struct {
 	Source
+
+	// validateOnce guards validateErr, which caches the result of deferred
+	// validation when the renderer was built with WithLazyValidation.
+	validateOnce sync.Once
+	validateErr  error
 }
 
-// Validate checks if the Source configuration is valid.
-func (h *sourceHolder) Validate() error {
+// Validate checks if the Source configuration is valid. Metadata-only objects
+// are only accepted if they can be resolved.
+func (h *sourceHolder) Validate(opts *RendererOptions) error {
 	for i := range h.Objects {
-		if len(h.Objects[i].Object) == 0 {
-			return fmt.Errorf("%w at index %d", ErrObjectEmpty, i)
+		if err := validateObject(i, h.Objects[i], opts); err != nil {
+			return h.atPosition(i, err)
+		}
+	}
+
+	for i, patch := range h.Patches {
+		if err := patch.validate(); err != nil {
+			return fmt.Errorf("patch at index %d: %w", i, err)
+		}
+	}
+
+	for i := range h.Deletions {
+		if err := validateDeletion(i, h.Deletions[i]); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
+
+// ValidateOnce validates the source on first use and returns the cached result afterwards.
+func (h *sourceHolder) ValidateOnce(opts *RendererOptions) error {
+	h.validateOnce.Do(func() {
+		h.validateErr = h.Validate(opts)
+	})
+
+	return h.validateErr
+}
+
+// sourceObjects returns the source the holder renders: a copy of its Source
+// whose Objects are followed by the objects its ObjectsFn generates for values,
+// those decoded from its Manifests, and those received from its stream, with
+// Positions aligned to them. All but the static Objects are validated on
+// every call, as they were not available when the renderer was built.
+func (h *sourceHolder) sourceObjects(
+	ctx context.Context,
+	values types.Values,
+	opts *RendererOptions,
+) (Source, error) {
+	if h.ObjectsFn == nil && len(h.Manifests) == 0 && h.Stream == nil {
+		return h.Source, nil
+	}
+
+	source := h.Source
+	source.Objects = slices.Clone(h.Objects)
+
+	if h.ObjectsFn != nil {
+		generated, err := h.ObjectsFn(ctx, values)
+		if err != nil {
+			return Source{}, fmt.Errorf("objects function failed: %w", err)
+		}
+
+		for i := range generated {
+			if err := validateObject(len(source.Objects)+i, generated[i], opts); err != nil {
+				return Source{}, fmt.Errorf("invalid generated object: %w", err)
+			}
+		}
+
+		if len(h.builders) == len(generated) {
+			source.builders = make([]string, len(source.Objects), len(source.Objects)+len(generated))
+			source.builders = append(source.builders, h.builders...)
+		}
+
+		source.Objects = append(source.Objects, generated...)
+	}
+
+	offset := len(source.Objects)
+
+	decoded, positions, err := decodeManifests(h.Manifests, offset, opts)
+	if err != nil {
+		return Source{}, err
+	}
+
+	source.Objects = append(source.Objects, decoded...)
+
+	if h.Stream != nil {
+		streamed, err := h.Stream.objects(ctx)
+		if err != nil {
+			return Source{}, err
+		}
+
+		for i := range streamed {
+			if err := validateObject(len(source.Objects)+i, streamed[i], opts); err != nil {
+				return Source{}, err
+			}
+		}
+
+		source.Objects = append(source.Objects, streamed...)
+	}
+
+	if len(positions) > 0 {
+		// Objects without a known position get the zero Position.
+		source.Positions = make([]Position, offset, offset+len(positions))
+		copy(source.Positions, h.Positions)
+		source.Positions = append(source.Positions, positions...)
+	}
+
+	return source, nil
+}
+
+// validateObject checks a single source object; i is its index in the source.
+func validateObject(i int, obj unstructured.Unstructured, opts *RendererOptions) error {
+	if len(obj.Object) == 0 {
+		return fmt.Errorf("%w at index %d", ErrObjectEmpty, i)
+	}
+
+	if opts.PartialObjectResolver == nil && IsMetadataOnly(obj) {
+		return fmt.Errorf("%w at index %d (%s): configure WithPartialObjectResolver to fetch full objects",
+			ErrMetadataOnlyObject, i, obj.GetKind())
+	}
+
+	return nil
+}
+
+// warn reports source problems that do not fail the render: positions that do
+// not line up with the objects, and incomplete snapshot streams.
+func (h *sourceHolder) warn(ctx context.Context, index int) {
+	if len(h.Positions) > 0 && len(h.Positions) != len(h.Objects) {
+		Warnf(ctx, "source %d has %d positions for %d objects; objects beyond the positions have none",
+			index, len(h.Positions), len(h.Objects))
+	}
+
+	if h.Stream != nil && !h.Stream.Done() {
+		Warnf(ctx, "source %d: stream is not complete; rendered the %d objects received so far",
+			index, h.Stream.Len())
+	}
+}
@@ -1,6 +1,7 @@
 package mem_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/k8s-manifest-kit/engine/pkg/filter/meta/gvk"
@@ -196,6 +197,29 @@ func TestMetricsIntegration(t *testing.T) {
 		g.Expect(err).ToNot(HaveOccurred())
 		g.Expect(renderer.Name()).To(Equal("mem"))
 	})
+
+	t.Run("should identify renderers named by WithName", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{composeObject("v1", "ConfigMap", "app", "config")}}},
+			mem.WithName("mem/platform-base"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Name()).To(Equal("mem/platform-base"))
+		g.Expect(renderer.Freeze().Name()).To(Equal("mem/platform-base"))
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Info().Renderer).To(Equal("mem/platform-base"))
+
+		failing, err := mem.New([]mem.Source{{}}, mem.WithName("mem/platform-base"), mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = failing.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrEmptyRender))
+		g.Expect(err).To(MatchError(HavePrefix("renderer mem/platform-base: ")))
+	})
 }
 
 func TestSourceAnnotations(t *testing.T) {
@@ -413,3 +437,188 @@ func TestContentHash(t *testing.T) {
 		g.Expect(hash1).ShouldNot(Equal(hash2))
 	})
 }
+
+func TestFreeze(t *testing.T) {
+
+	t.Run("should render the same objects as the live renderer", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(configMapYAML)},
+			mem.WithSourceAnnotations(true),
+			mem.WithTransformer(labels.Set(map[string]string{"frozen": "true"})),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		snapshot := renderer.Freeze()
+		g.Expect(snapshot).ToNot(BeIdenticalTo(renderer))
+		g.Expect(snapshot.Name()).To(Equal(renderer.Name()))
+
+		live, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		frozen, err := snapshot.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(frozen).To(Equal(live))
+		g.Expect(frozen[0].GetLabels()).To(HaveKeyWithValue("frozen", "true"))
+	})
+}
+
+func TestLazyValidation(t *testing.T) {
+	valid := mem.MustSourceFromYAML(configMapYAML)
+	invalid := mem.Source{Objects: []unstructured.Unstructured{{}}}
+
+	t.Run("should validate eagerly by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New([]mem.Source{valid, invalid})
+		g.Expect(err).To(MatchError(mem.ErrObjectEmpty))
+		g.Expect(err).To(MatchError(ContainSubstring("invalid source at index 1")))
+	})
+
+	t.Run("should defer validation to Process", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{valid, invalid}, mem.WithLazyValidation(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			_, err = renderer.Process(t.Context(), nil)
+			g.Expect(err).To(MatchError(mem.ErrObjectEmpty))
+			g.Expect(err).To(MatchError(ContainSubstring("invalid source at index 1")))
+		}
+	})
+
+	t.Run("should not validate sources that are never selected", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{valid, invalid},
+			mem.WithLazyValidation(true),
+			mem.WithSourceSelector(func(_ context.Context, source mem.Source) (bool, error) {
+				return len(source.Objects[0].Object) > 0, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+}
+
+func TestSourceFiltersAndTransformers(t *testing.T) {
+
+	t.Run("should scope filters and transformers to their source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		scoped := mem.MustSourceFromYAML(multiDocYAML)
+		scoped.Filters = []pkgtypes.Filter{gvk.Filter(corev1.SchemeGroupVersion.WithKind("ConfigMap"))}
+		scoped.Transformers = []pkgtypes.Transformer{labels.Set(map[string]string{"scoped": "true"})}
+
+		renderer, err := mem.New([]mem.Source{scoped, mem.MustSourceFromYAML(multiDocYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/first", "ConfigMap/first", "Deployment/second"}))
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("scoped", "true"))
+		g.Expect(objects[1].GetLabels()).ToNot(HaveKey("scoped"))
+	})
+
+	t.Run("should run before the source's post-renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		seen := make([]string, 0)
+		source := mem.MustSourceFromYAML(multiDocYAML)
+		source.Filters = []pkgtypes.Filter{gvk.Filter(corev1.SchemeGroupVersion.WithKind("ConfigMap"))}
+		source.PostRenderers = []pkgtypes.PostRenderer{
+			func(_ context.Context, objects []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+				for i := range objects {
+					seen = append(seen, objects[i].GetName())
+				}
+
+				return objects, nil
+			},
+		}
+
+		renderer, err := mem.New([]mem.Source{source})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(seen).To(Equal([]string{"first"}))
+	})
+}
+
+func TestSourceMetadata(t *testing.T) {
+
+	source := func(override bool) mem.Source {
+		source := mem.MustSourceFromYAML(configMapYAML)
+		source.Labels = map[string]string{"app": "injected", "team": "platform"}
+		source.Annotations = map[string]string{"owner": "platform@example.com"}
+		source.OverrideMetadata = override
+
+		return source
+	}
+
+	t.Run("should add labels and annotations without overwriting", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{source(false), mem.MustSourceFromYAML(multiDocYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetLabels()).To(Equal(map[string]string{"app": "test-app", "team": "platform"}))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue("owner", "platform@example.com"))
+		g.Expect(objects[1].GetLabels()).To(BeEmpty())
+	})
+
+	t.Run("should overwrite existing keys on request", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{source(true)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("app", "injected"))
+	})
+
+	t.Run("should not modify the source objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		input := source(true)
+		renderer, err := mem.New([]mem.Source{input})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(input.Objects[0].GetLabels()).To(Equal(map[string]string{"app": "test-app"}))
+	})
+
+	t.Run("should be visible to source filters and hashes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		plain, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		labelled := source(false)
+		labelled.Filters = []pkgtypes.Filter{func(_ context.Context, obj unstructured.Unstructured) (bool, error) {
+			return obj.GetLabels()["team"] == "platform", nil
+		}}
+
+		renderer, err := mem.New([]mem.Source{labelled})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		before, err := plain.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		after, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(after).To(HaveLen(1))
+		g.Expect(after[0].GetAnnotations()[pkgtypes.AnnotationContentHash]).
+			ToNot(Equal(before[0].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+	})
+}
@@ -0,0 +1,312 @@
+// Package memtest provides helpers for fuzzing renderers with arbitrary
+// unstructured content. It turns opaque fuzzer bytes into JSON-compatible
+// object trees (and back), so that any renderer consuming
+// unstructured.Unstructured values can share the same corpus.
+package memtest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// MaxDepth is the maximum nesting depth produced by Decode.
+	// Containers below this depth decode as nil.
+	MaxDepth = 8
+
+	// MaxEntries is the maximum number of entries in a decoded map or slice.
+	MaxEntries = 32
+
+	// MaxStringLen is the maximum length of a decoded string.
+	MaxStringLen = math.MaxUint8
+)
+
+const (
+	tagNil byte = iota
+	tagFalse
+	tagTrue
+	tagInt
+	tagFloat
+	tagString
+	tagNumber
+	tagSlice
+	tagMap
+	tagCount
+)
+
+// Decode deterministically converts arbitrary bytes into a map[string]any whose
+// leaves are limited to the types unstructured.Unstructured supports (nil, bool,
+// int64, float64, string, json.Number, []any, map[string]any). Every input is
+// valid: exhausted input reads as zero bytes, so the result is always defined.
+//
+// The decoded values deliberately include the awkward corners of the JSON
+// model: binary strings, extreme and non-finite numbers, arbitrary precision
+// numbers, and nils in any position.
+func Decode(data []byte) map[string]any {
+	r := reader{data: data}
+
+	return r.readMap(0)
+}
+
+// Object is Decode wrapped in an unstructured.Unstructured.
+func Object(data []byte) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: Decode(data)}
+}
+
+// Encode is the inverse of Decode for values within its domain: Decode(Encode(m))
+// equals m as long as m only contains supported types and stays within MaxDepth,
+// MaxEntries, and MaxStringLen. Values outside that domain are truncated or
+// encoded as nil, which makes Encode suitable for turning realistic fixtures
+// into seed corpus entries.
+func Encode(obj map[string]any) []byte {
+	w := writer{}
+	w.writeMap(obj, 0)
+
+	return w.data
+}
+
+// Seeds returns a small corpus of encoded objects covering realistic manifests
+// as well as malformed metadata and extreme values.
+func Seeds() [][]byte {
+	fixtures := []map[string]any{
+		{},
+		{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":        "seed",
+				"namespace":   "default",
+				"labels":      map[string]any{"app": "seed"},
+				"annotations": map[string]any{"note": "seed"},
+			},
+			"data": map[string]any{"key": "value"},
+		},
+		{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]any{"name": "seed-pod"},
+			"spec": map[string]any{
+				"containers": []any{
+					map[string]any{"name": "app", "image": "nginx:latest"},
+				},
+			},
+		},
+		{
+			"kind":     "Weird",
+			"metadata": "not-a-map",
+		},
+		{
+			"metadata": map[string]any{
+				"labels":      []any{"not", "a", "map"},
+				"annotations": map[string]any{"nil": nil},
+			},
+		},
+		{
+			"binary":  "\x00\xff\xfe\x01",
+			"max":     int64(math.MaxInt64),
+			"min":     int64(math.MinInt64),
+			"huge":    math.MaxFloat64,
+			"inf":     math.Inf(1),
+			"precise": json.Number("123456789012345678901234567890"),
+			"nested":  []any{nil, []any{nil}, map[string]any{"": nil}},
+		},
+	}
+
+	seeds := make([][]byte, 0, len(fixtures))
+	for _, fixture := range fixtures {
+		seeds = append(seeds, Encode(fixture))
+	}
+
+	return seeds
+}
+
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) next() byte {
+	if r.pos >= len(r.data) {
+		return 0
+	}
+
+	b := r.data[r.pos]
+	r.pos++
+
+	return b
+}
+
+func (r *reader) uint64() uint64 {
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = r.next()
+	}
+
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+func (r *reader) bytes() []byte {
+	n := int(r.next())
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = r.next()
+	}
+
+	return out
+}
+
+func (r *reader) readMap(depth int) map[string]any {
+	n := int(r.next()) % (MaxEntries + 1)
+	out := make(map[string]any, n)
+
+	for range n {
+		key := string(r.bytes())
+		out[key] = r.readValue(depth + 1)
+	}
+
+	return out
+}
+
+func (r *reader) readValue(depth int) any {
+	switch r.next() % tagCount {
+	case tagFalse:
+		return false
+	case tagTrue:
+		return true
+	case tagInt:
+		return int64(r.uint64()) //nolint:gosec // wrapping is the point: it reaches the extremes
+	case tagFloat:
+		return math.Float64frombits(r.uint64())
+	case tagString:
+		return string(r.bytes())
+	case tagNumber:
+		digits := r.bytes()
+		for i := range digits {
+			digits[i] = '0' + digits[i]%10
+		}
+
+		if len(digits) == 0 {
+			return json.Number("0")
+		}
+
+		return json.Number(digits)
+	case tagSlice:
+		if depth >= MaxDepth {
+			return nil
+		}
+
+		n := int(r.next()) % (MaxEntries + 1)
+		out := make([]any, n)
+		for i := range out {
+			out[i] = r.readValue(depth + 1)
+		}
+
+		return out
+	case tagMap:
+		if depth >= MaxDepth {
+			return nil
+		}
+
+		return r.readMap(depth)
+	default:
+		return nil
+	}
+}
+
+type writer struct {
+	data []byte
+}
+
+func (w *writer) bytes(b []byte) {
+	if len(b) > MaxStringLen {
+		b = b[:MaxStringLen]
+	}
+
+	w.data = append(w.data, byte(len(b)))
+	w.data = append(w.data, b...)
+}
+
+func (w *writer) uint64(v uint64) {
+	w.data = binary.BigEndian.AppendUint64(w.data, v)
+}
+
+func (w *writer) writeMap(m map[string]any, depth int) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	if len(keys) > MaxEntries {
+		keys = keys[:MaxEntries]
+	}
+
+	w.data = append(w.data, byte(len(keys)))
+	for _, k := range keys {
+		w.bytes([]byte(k))
+		w.writeValue(m[k], depth+1)
+	}
+}
+
+func (w *writer) writeValue(v any, depth int) {
+	switch val := v.(type) {
+	case bool:
+		if val {
+			w.data = append(w.data, tagTrue)
+		} else {
+			w.data = append(w.data, tagFalse)
+		}
+	case int64:
+		w.data = append(w.data, tagInt)
+		w.uint64(uint64(val)) //nolint:gosec // round-trips through the int64 conversion in readValue
+	case int:
+		w.writeValue(int64(val), depth)
+	case float64:
+		w.data = append(w.data, tagFloat)
+		w.uint64(math.Float64bits(val))
+	case string:
+		w.data = append(w.data, tagString)
+		w.bytes([]byte(val))
+	case json.Number:
+		digits := make([]byte, 0, len(val))
+		for i := range len(val) {
+			if val[i] >= '0' && val[i] <= '9' {
+				digits = append(digits, val[i]-'0')
+			}
+		}
+
+		w.data = append(w.data, tagNumber)
+		w.bytes(digits)
+	case []any:
+		if depth >= MaxDepth {
+			w.data = append(w.data, tagNil)
+
+			return
+		}
+
+		if len(val) > MaxEntries {
+			val = val[:MaxEntries]
+		}
+
+		w.data = append(w.data, tagSlice, byte(len(val)))
+		for _, item := range val {
+			w.writeValue(item, depth+1)
+		}
+	case map[string]any:
+		if depth >= MaxDepth {
+			w.data = append(w.data, tagNil)
+
+			return
+		}
+
+		w.data = append(w.data, tagMap)
+		w.writeMap(val, depth)
+	default:
+		w.data = append(w.data, tagNil)
+	}
+}
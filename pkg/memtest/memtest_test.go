@@ -0,0 +1,73 @@
+package memtest_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/k8s-manifest-kit/renderer-mem/pkg/memtest"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestEncodeDecode(t *testing.T) {
+
+	t.Run("should round trip supported values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "test", "labels": map[string]any{"app": "x"}},
+			"flags":      []any{true, false, nil},
+			"int":        int64(math.MinInt64),
+			"float":      1.5,
+			"number":     json.Number("98765432109876543210"),
+			"binary":     "\x00\xff",
+		}
+
+		g.Expect(memtest.Decode(memtest.Encode(obj))).To(Equal(obj))
+	})
+
+	t.Run("should decode every seed back to an object", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, seed := range memtest.Seeds() {
+			g.Expect(memtest.Encode(memtest.Decode(seed))).To(Equal(seed))
+		}
+	})
+
+	t.Run("should be deterministic", func(t *testing.T) {
+		g := NewWithT(t)
+
+		data := []byte{3, 1, 'a', 8, 2, 1, 'b', 7, 2, 2, 5, 3, 'x', 'y', 'z'}
+		g.Expect(memtest.Decode(data)).To(Equal(memtest.Decode(data)))
+	})
+
+	t.Run("should produce deep-copyable objects from arbitrary bytes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for i := range 256 {
+			data := []byte{byte(i), byte(i * 7), byte(i * 13), 7, byte(i), 8, 8, 8, 8, 8, 8, 8, 8, 8, 8}
+			obj := memtest.Object(data)
+			g.Expect(func() { obj.DeepCopy() }).ToNot(Panic())
+		}
+	})
+
+	t.Run("should bound nesting depth", func(t *testing.T) {
+		g := NewWithT(t)
+
+		data := make([]byte, 0, 2*(memtest.MaxDepth+4))
+		for range memtest.MaxDepth + 4 {
+			data = append(data, 1, 0, 8)
+		}
+
+		depth := 0
+		for current := memtest.Decode(data); current != nil; depth++ {
+			next, _ := current[""].(map[string]any)
+			current = next
+		}
+
+		g.Expect(depth).To(BeNumerically("<=", memtest.MaxDepth))
+	})
+}
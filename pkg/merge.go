@@ -0,0 +1,275 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DuplicatePolicy decides what happens when several objects share the same
+// identity (group, kind, namespace, name).
+type DuplicatePolicy string
+
+const (
+	// DuplicateError fails the render.
+	DuplicateError DuplicatePolicy = "error"
+
+	// DuplicateKeepFirst keeps the first object and drops later ones.
+	DuplicateKeepFirst DuplicatePolicy = "keep-first"
+
+	// DuplicateKeepLast keeps the last object, at the position of the last occurrence.
+	DuplicateKeepLast DuplicatePolicy = "keep-last"
+
+	// DuplicateKeepAll keeps every object.
+	DuplicateKeepAll DuplicatePolicy = "keep-all"
+
+	// DuplicateMerge merges every occurrence into the first, in order, as
+	// JSON merge patches: later values win, and lists are replaced unless
+	// their merge key is registered with WithMergeKeys.
+	DuplicateMerge DuplicatePolicy = "merge"
+)
+
+func (p DuplicatePolicy) validate() error {
+	switch p {
+	case DuplicateError, DuplicateKeepFirst, DuplicateKeepLast, DuplicateKeepAll, DuplicateMerge:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidDuplicatePolicy, p)
+	}
+}
+
+// Merge combines two independently built renderers into a new one.
+//
+// Each renderer keeps its own sources and its full option chain (selectors,
+// annotations, hashing, filters, transformers, post-renderers), which apply
+// only to its own objects. The outputs of a and b are then combined in that
+// order, conflicts between them are resolved with policy, and the optional
+// opts (filters, transformers, and post-renderers) are applied to the
+// combined result. Source-level options in opts are ignored, since the merged
+// renderer owns no sources of its own.
+//
+// Only conflicts between a and b are subject to policy; duplicates produced by
+// a single renderer are left as they are. Conflicts are detected with the
+// identity set by WithIdentityFunc in opts, DefaultIdentity otherwise.
+// Merged renderers can be merged again.
+//
+// a and b stay live: sources later added to, removed from, or replaced in
+// either of them apply to the next render, and their Stream sources keep
+// pulling. Pass a.Freeze() and b.Freeze() to merge a fixed view instead.
+func Merge(a *Renderer, b *Renderer, policy DuplicatePolicy, opts ...RendererOption) (*Renderer, error) {
+	if a == nil || b == nil {
+		return nil, ErrRendererNil
+	}
+
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+
+	var rendererOpts RendererOptions
+	for _, opt := range opts {
+		opt.ApplyTo(&rendererOpts)
+	}
+
+	if err := rendererOpts.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Renderer{
+		opts: rendererOpts,
+		merged: &mergedParts{
+			parts:  []*Renderer{a, b},
+			policy: policy,
+		},
+		cache: newRenderCache(rendererOpts),
+	}, nil
+}
+
+// mergedParts holds the components of a renderer built by Merge.
+type mergedParts struct {
+	parts  []*Renderer
+	policy DuplicatePolicy
+}
+
+// freeze returns a copy of m whose parts are frozen, or nil if m is nil.
+func (m *mergedParts) freeze() *mergedParts {
+	if m == nil {
+		return nil
+	}
+
+	parts := make([]*Renderer, len(m.parts))
+	for i, part := range m.parts {
+		parts[i] = part.Freeze()
+	}
+
+	return &mergedParts{parts: parts, policy: m.policy}
+}
+
+func (r *Renderer) processMerged(
+	ctx context.Context,
+	values types.Values,
+	trace *renderTrace,
+) ([]unstructured.Unstructured, error) {
+	outputs := make([][]unstructured.Unstructured, len(r.merged.parts))
+	for i, part := range r.merged.parts {
+		objects, err := part.Process(ctx, values)
+		if err != nil {
+			return nil, fmt.Errorf("merged renderer %d: %w", i, err)
+		}
+
+		outputs[i] = objects
+	}
+
+	combined, err := resolveDuplicates(
+		outputs, r.merged.policy, identityOrDefault(r.opts.IdentityFunc), r.opts.MergeKeys, r.opts.hasher(),
+		"merged renderers")
+	if err != nil {
+		return nil, err
+	}
+
+	trace.sources = len(r.merged.parts)
+	trace.selected = len(r.merged.parts)
+	trace.collected = len(combined)
+
+	return r.applyChain(ctx, combined)
+}
+
+// resolveDuplicates concatenates groups of objects, applying policy to
+// identities that appear in more than one group; groups names the groups in
+// errors. Objects are owned by the caller and may be modified when merged,
+// and merged objects carrying a content hash are rehashed with hasher.
+func resolveDuplicates(
+	groups [][]unstructured.Unstructured,
+	policy DuplicatePolicy,
+	identity IdentityFunc,
+	keys MergeKeys,
+	hasher contentHasher,
+	name string,
+) ([]unstructured.Unstructured, error) {
+	total := 0
+	for _, group := range groups {
+		total += len(group)
+	}
+
+	combined := make([]unstructured.Unstructured, 0, total)
+	if policy == "" || policy == DuplicateKeepAll {
+		for _, group := range groups {
+			combined = append(combined, group...)
+		}
+
+		return combined, nil
+	}
+
+	// firstGroup and lastGroup record, for each identity, the first and last
+	// group it appears in.
+	firstGroup := make(map[string]int)
+	lastGroup := make(map[string]int)
+
+	for g, group := range groups {
+		for _, obj := range group {
+			id := identity(obj)
+			if _, seen := firstGroup[id]; !seen {
+				firstGroup[id] = g
+			}
+
+			lastGroup[id] = g
+		}
+	}
+
+	var merged map[string]*unstructured.Unstructured
+	if policy == DuplicateMerge {
+		var err error
+
+		merged, err = mergeDuplicates(groups, identity, keys, hasher, firstGroup, lastGroup)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for g, group := range groups {
+		for _, obj := range group {
+			id := identity(obj)
+			if firstGroup[id] == lastGroup[id] {
+				combined = append(combined, obj)
+
+				continue
+			}
+
+			switch policy {
+			case DuplicateError:
+				key := ObjectKeyOf(obj)
+				if id != DefaultIdentity(obj) {
+					key += " (identity " + id + ")"
+				}
+
+				return nil, fmt.Errorf("%w: %s produced by %s %d and %d",
+					ErrDuplicateObject, key, name, firstGroup[id], lastGroup[id])
+			case DuplicateKeepFirst:
+				if g == firstGroup[id] {
+					combined = append(combined, obj)
+				}
+			case DuplicateKeepLast:
+				if g == lastGroup[id] {
+					combined = append(combined, obj)
+				}
+			case DuplicateMerge:
+				if result, ok := merged[id]; ok {
+					combined = append(combined, *result)
+					delete(merged, id)
+				}
+			}
+		}
+	}
+
+	return combined, nil
+}
+
+// mergeDuplicates merges, for each identity that appears in more than one
+// group, all its occurrences into the first one.
+func mergeDuplicates(
+	groups [][]unstructured.Unstructured,
+	identity IdentityFunc,
+	keys MergeKeys,
+	hasher contentHasher,
+	firstGroup map[string]int,
+	lastGroup map[string]int,
+) (map[string]*unstructured.Unstructured, error) {
+	merged := make(map[string]*unstructured.Unstructured)
+
+	for _, group := range groups {
+		for i := range group {
+			id := identity(group[i])
+			if firstGroup[id] == lastGroup[id] {
+				continue
+			}
+
+			result, ok := merged[id]
+			if !ok {
+				// The first occurrence is copied, as it may share content
+				// with a source object.
+				merged[id] = group[i].DeepCopy()
+
+				continue
+			}
+
+			result.Object = mergePatch(result.Object, group[i].Object, keys[result.GroupVersionKind().GroupKind()])
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(merged)) {
+		result := merged[id]
+		if _, hashed := result.GetAnnotations()[types.AnnotationContentHash]; !hashed {
+			continue
+		}
+
+		if err := hasher.set(result); err != nil {
+			return nil, err
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,450 @@
+package mem_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/filter/meta/gvk"
+	"github.com/k8s-manifest-kit/engine/pkg/transformer/meta/labels"
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func mergeFixture(g Gomega, owner string, objects ...unstructured.Unstructured) *mem.Renderer {
+	renderer, err := mem.New(
+		[]mem.Source{{Objects: objects}},
+		mem.WithTransformer(labels.Set(map[string]string{"owner": owner})),
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return renderer
+}
+
+func TestMerge(t *testing.T) {
+
+	shared := composeObject("v1", "ConfigMap", "default", "shared")
+	onlyA := composeObject("v1", "ConfigMap", "default", "only-a")
+	onlyB := composeObject("v1", "Secret", "default", "only-b")
+
+	t.Run("should keep each renderer's option chain scoped to its objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		a := mergeFixture(g, "a", onlyA)
+		b, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{onlyB}}},
+			mem.WithContentHash(false),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		merged, err := mem.Merge(a, b, mem.DuplicateError)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := merged.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/only-a", "Secret/only-b"}))
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("owner", "a"))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKey(pkgtypes.AnnotationContentHash))
+		g.Expect(objects[1].GetLabels()).ToNot(HaveKey("owner"))
+		g.Expect(objects[1].GetAnnotations()).ToNot(HaveKey(pkgtypes.AnnotationContentHash))
+	})
+
+	t.Run("should apply its own options to the combined output", func(t *testing.T) {
+		g := NewWithT(t)
+
+		merged, err := mem.Merge(
+			mergeFixture(g, "a", onlyA),
+			mergeFixture(g, "b", onlyB),
+			mem.DuplicateError,
+			mem.WithFilter(gvk.Filter(corev1.SchemeGroupVersion.WithKind("Secret"))),
+			mem.WithTransformer(labels.Set(map[string]string{"merged": "true"})),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := merged.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"Secret/only-b"}))
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("merged", "true"))
+	})
+
+	policies := []struct {
+		policy mem.DuplicatePolicy
+		names  []string
+		owner  string
+		err    error
+	}{
+		{policy: mem.DuplicateError, err: mem.ErrDuplicateObject},
+		{
+			policy: mem.DuplicateKeepFirst,
+			names:  []string{"ConfigMap/shared", "ConfigMap/only-a", "Secret/only-b"},
+			owner:  "a",
+		},
+		{
+			policy: mem.DuplicateKeepLast,
+			names:  []string{"ConfigMap/only-a", "Secret/only-b", "ConfigMap/shared"},
+			owner:  "b",
+		},
+		{
+			policy: mem.DuplicateKeepAll,
+			names:  []string{"ConfigMap/shared", "ConfigMap/only-a", "Secret/only-b", "ConfigMap/shared"},
+			owner:  "a",
+		},
+	}
+
+	for _, tt := range policies {
+		t.Run("should resolve conflicts with "+string(tt.policy), func(t *testing.T) {
+			g := NewWithT(t)
+
+			merged, err := mem.Merge(
+				mergeFixture(g, "a", shared, onlyA),
+				mergeFixture(g, "b", onlyB, shared),
+				tt.policy,
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			objects, err := merged.Process(t.Context(), nil)
+			if tt.err != nil {
+				g.Expect(err).To(MatchError(tt.err))
+
+				return
+			}
+
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(names(objects)).To(Equal(tt.names))
+
+			for _, obj := range objects {
+				if obj.GetName() == "shared" {
+					g.Expect(obj.GetLabels()).To(HaveKeyWithValue("owner", tt.owner))
+
+					break
+				}
+			}
+		})
+	}
+
+	t.Run("should leave duplicates within one renderer alone", func(t *testing.T) {
+		g := NewWithT(t)
+
+		merged, err := mem.Merge(
+			mergeFixture(g, "a", shared, shared),
+			mergeFixture(g, "b", onlyB),
+			mem.DuplicateError,
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := merged.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+	})
+
+	t.Run("should support nested merges", func(t *testing.T) {
+		g := NewWithT(t)
+
+		inner, err := mem.Merge(mergeFixture(g, "a", onlyA), mergeFixture(g, "b", onlyB), mem.DuplicateError)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		outer, err := mem.Merge(inner, mergeFixture(g, "c", onlyA), mem.DuplicateKeepLast)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := outer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"Secret/only-b", "ConfigMap/only-a"}))
+		g.Expect(objects[1].GetLabels()).To(HaveKeyWithValue("owner", "c"))
+	})
+
+	t.Run("should render source changes made after the merge", func(t *testing.T) {
+		g := NewWithT(t)
+
+		a := mergeFixture(g, "a", onlyA)
+
+		merged, err := mem.Merge(a, mergeFixture(g, "b", onlyB), mem.DuplicateError)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		frozen := merged.Freeze()
+
+		g.Expect(a.AddSource(mem.Source{Objects: []unstructured.Unstructured{shared}})).To(Succeed())
+
+		objects, err := merged.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/only-a", "ConfigMap/shared", "Secret/only-b"}))
+
+		objects, err = frozen.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/only-a", "Secret/only-b"}))
+	})
+
+	t.Run("should keep pulling streams of merged renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured, 2)
+
+		a, err := mem.New([]mem.Source{{Stream: mem.NewChannelStream(ch, mem.StreamSnapshot)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		merged, err := mem.Merge(a, mergeFixture(g, "b", onlyB), mem.DuplicateError)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := merged.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"Secret/only-b"}))
+
+		ch <- onlyA
+
+		objects, err = merged.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/only-a", "Secret/only-b"}))
+	})
+
+	t.Run("should validate arguments", func(t *testing.T) {
+		g := NewWithT(t)
+
+		a := mergeFixture(g, "a", onlyA)
+
+		_, err := mem.Merge(a, nil, mem.DuplicateError)
+		g.Expect(err).To(MatchError(mem.ErrRendererNil))
+
+		_, err = mem.Merge(a, a, "whatever")
+		g.Expect(err).To(MatchError(mem.ErrInvalidDuplicatePolicy))
+	})
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+
+	base := mem.MustSourceFromYAML(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    tier: base
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: only-base
+`)
+
+	override := mem.MustSourceFromYAML(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    env: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: sidecar:1
+`)
+
+	render := func(g *WithT, opts ...mem.RendererOption) []unstructured.Unstructured {
+		renderer, err := mem.New([]mem.Source{base, override}, opts...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return objects
+	}
+
+	replicas := func(g *WithT, obj unstructured.Unstructured) int64 {
+		value, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return value
+	}
+
+	t.Run("should keep all duplicates by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(names(render(g))).To(Equal([]string{"Deployment/web", "ConfigMap/only-base", "Deployment/web"}))
+	})
+
+	t.Run("should fail on duplicates", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{base, override}, mem.WithDuplicatePolicy(mem.DuplicateError))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrDuplicateObject)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring("apps/v1/Deployment//web produced by sources 0 and 1")))
+	})
+
+	t.Run("should keep the first or last occurrence", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(g, mem.WithDuplicatePolicy(mem.DuplicateKeepFirst))
+		g.Expect(names(objects)).To(Equal([]string{"Deployment/web", "ConfigMap/only-base"}))
+		g.Expect(replicas(g, objects[0])).To(Equal(int64(1)))
+
+		objects = render(g, mem.WithDuplicatePolicy(mem.DuplicateKeepLast))
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/only-base", "Deployment/web"}))
+		g.Expect(replicas(g, objects[1])).To(Equal(int64(3)))
+	})
+
+	t.Run("should merge duplicates into the first occurrence", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(g, mem.WithDuplicatePolicy(mem.DuplicateMerge))
+		g.Expect(names(objects)).To(Equal([]string{"Deployment/web", "ConfigMap/only-base"}))
+		g.Expect(replicas(g, objects[0])).To(Equal(int64(3)))
+		g.Expect(objects[0].GetLabels()).To(Equal(map[string]string{"tier": "base", "env": "prod"}))
+
+		containers, _, err := unstructured.NestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(containers).To(HaveLen(1))
+
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should merge keyed lists by key", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(g,
+			mem.WithDuplicatePolicy(mem.DuplicateMerge),
+			mem.WithMergeKeys(mem.MergeKeys{
+				{Group: "apps", Kind: "Deployment"}: {"spec.template.spec.containers": "name"},
+			}),
+		)
+
+		containers, _, err := unstructured.NestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(containers).To(HaveLen(2))
+	})
+
+	t.Run("should apply to jobs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{base, override}, mem.WithDuplicatePolicy(mem.DuplicateKeepLast))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.NewJob(nil, mem.WithBatchSize(1)).Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(result.View().DeepCopy())).To(Equal([]string{"ConfigMap/only-base", "Deployment/web"}))
+	})
+
+	t.Run("should reject unknown policies", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithDuplicatePolicy("newest"))
+		g.Expect(errors.Is(err, mem.ErrInvalidDuplicatePolicy)).To(BeTrue())
+	})
+}
+
+func TestOverlayMerge(t *testing.T) {
+
+	overlayYAML := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    env: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:2.0
+      - name: sidecar
+        image: envoy
+`
+
+	t.Run("should merge later sources onto earlier ones", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				mem.MustSourceFromYAML(bundleDeploymentYAML, configMapYAML),
+				mem.MustSourceFromYAML(overlayYAML),
+			},
+			mem.WithOverlayMerge(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+
+		deployment := objects[0]
+		g.Expect(deployment.GetLabels()).To(Equal(map[string]string{"app": "web", "env": "prod"}))
+
+		replicas, _, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+		g.Expect(replicas).To(Equal(int64(3)))
+
+		containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+		g.Expect(containers).To(Equal([]any{
+			map[string]any{"name": "web", "image": "nginx:2.0"},
+			map[string]any{"name": "sidecar", "image": "envoy"},
+		}))
+	})
+
+	t.Run("should prefer registered merge keys", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				mem.MustSourceFromYAML(bundleDeploymentYAML),
+				mem.MustSourceFromYAML(overlayYAML),
+			},
+			mem.WithOverlayMerge(),
+			mem.WithMergeKeys(mem.MergeKeys{
+				{Group: "apps", Kind: "Deployment"}: {"spec.template.spec.containers": "image"},
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		containers, _, _ := unstructured.NestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+		g.Expect(containers).To(HaveLen(3))
+	})
+
+	t.Run("should replace lists with a plain JSON merge", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				mem.MustSourceFromYAML(bundleDeploymentYAML),
+				mem.MustSourceFromYAML(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: envoy
+`),
+			},
+			mem.WithDuplicatePolicy(mem.DuplicateMerge),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		containers, _, _ := unstructured.NestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+		g.Expect(containers).To(Equal([]any{map[string]any{"name": "sidecar", "image": "envoy"}}))
+	})
+}
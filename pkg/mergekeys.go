@@ -0,0 +1,276 @@
+package mem
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MergeKeys registers, per group and kind, the lists that patches merge item
+// by item instead of replacing. Each entry maps a dot-separated field path,
+// with list levels omitted (e.g. "spec.template.spec.containers.ports"), to
+// the field identifying the items of that list (e.g. "containerPort").
+//
+// With a merge key, patch list items are merged into the target item with the
+// same key value, or appended if there is none; items without the key are
+// appended. Lists without a registered key keep JSON merge patch semantics
+// and are replaced.
+type MergeKeys map[schema.GroupKind]map[string]string
+
+// MergeKeysFromCRDs reads list merge keys from the OpenAPI v3 schemas of the
+// given CustomResourceDefinitions. A list contributes a key if its schema sets
+// x-kubernetes-patch-merge-key, or x-kubernetes-list-type "map" with a single
+// x-kubernetes-list-map-keys entry. Keys from all versions of a CRD are
+// combined, since patch targets do not consider the API version.
+func MergeKeysFromCRDs(crds ...unstructured.Unstructured) (MergeKeys, error) {
+	keys := make(MergeKeys)
+
+	for i := range crds {
+		crd := crds[i]
+
+		if crd.GetKind() != "CustomResourceDefinition" {
+			return nil, fmt.Errorf("%w: object at index %d has kind %q", ErrInvalidCRD, i, crd.GetKind())
+		}
+
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+
+		if kind == "" {
+			return nil, fmt.Errorf("%w: %s has no spec.names.kind", ErrInvalidCRD, crd.GetName())
+		}
+
+		versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidCRD, crd.GetName(), err)
+		}
+
+		gk := schema.GroupKind{Group: group, Kind: kind}
+		paths := keys[gk]
+		if paths == nil {
+			paths = make(map[string]string)
+		}
+
+		for _, version := range versions {
+			versionMap, ok := version.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			openAPISchema, _, _ := unstructured.NestedMap(versionMap, "schema", "openAPIV3Schema")
+			collectMergeKeys(openAPISchema, "", paths)
+		}
+
+		if len(paths) > 0 {
+			keys[gk] = paths
+		}
+	}
+
+	return keys, nil
+}
+
+// collectMergeKeys walks an OpenAPI v3 schema and records the merge key of
+// every keyed list under path.
+func collectMergeKeys(node map[string]any, path string, paths map[string]string) {
+	properties, _ := node["properties"].(map[string]any)
+
+	for name, property := range properties {
+		propertyMap, ok := property.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		childPath := joinFieldPath(path, name)
+
+		if propertyMap["type"] == "array" {
+			if key := listMergeKey(propertyMap); key != "" {
+				paths[childPath] = key
+			}
+
+			items, _ := propertyMap["items"].(map[string]any)
+			collectMergeKeys(items, childPath, paths)
+
+			continue
+		}
+
+		collectMergeKeys(propertyMap, childPath, paths)
+	}
+}
+
+func listMergeKey(property map[string]any) string {
+	if key, ok := property["x-kubernetes-patch-merge-key"].(string); ok {
+		return key
+	}
+
+	if property["x-kubernetes-list-type"] != "map" {
+		return ""
+	}
+
+	mapKeys, _ := property["x-kubernetes-list-map-keys"].([]any)
+	if len(mapKeys) != 1 {
+		return ""
+	}
+
+	key, _ := mapKeys[0].(string)
+
+	return key
+}
+
+func joinFieldPath(path string, field string) string {
+	if path == "" {
+		return field
+	}
+
+	return path + "." + field
+}
+
+// mergeKeyedList merges the items of patch into target by the value of key.
+// Matched items are merged with mergePatch, so nested keyed lists below path
+// are merged as well.
+func mergeKeyedList(target []any, patch []any, key string, keys map[string]string, path string) []any {
+	for _, item := range patch {
+		itemMap, isMap := item.(map[string]any)
+		if !isMap || itemMap[key] == nil {
+			target = append(target, runtime.DeepCopyJSONValue(item))
+
+			continue
+		}
+
+		merged := false
+
+		for i := range target {
+			existing, ok := target[i].(map[string]any)
+			if !ok || !reflect.DeepEqual(existing[key], itemMap[key]) {
+				continue
+			}
+
+			target[i] = mergePatchAt(existing, itemMap, keys, path)
+			merged = true
+
+			break
+		}
+
+		if !merged {
+			target = append(target, runtime.DeepCopyJSONValue(item))
+		}
+	}
+
+	return target
+}
+
+// builtinMergeKeyTypes are the built-in kinds whose strategic merge keys
+// BuiltinMergeKeys reports.
+var builtinMergeKeyTypes = map[schema.GroupKind]reflect.Type{
+	{Group: "", Kind: "Pod"}:                   reflect.TypeFor[corev1.Pod](),
+	{Group: "", Kind: "PodTemplate"}:           reflect.TypeFor[corev1.PodTemplate](),
+	{Group: "", Kind: "ReplicationController"}: reflect.TypeFor[corev1.ReplicationController](),
+	{Group: "", Kind: "Service"}:               reflect.TypeFor[corev1.Service](),
+	{Group: "apps", Kind: "Deployment"}:        reflect.TypeFor[appsv1.Deployment](),
+	{Group: "apps", Kind: "StatefulSet"}:       reflect.TypeFor[appsv1.StatefulSet](),
+	{Group: "apps", Kind: "DaemonSet"}:         reflect.TypeFor[appsv1.DaemonSet](),
+	{Group: "apps", Kind: "ReplicaSet"}:        reflect.TypeFor[appsv1.ReplicaSet](),
+	{Group: "batch", Kind: "Job"}:              reflect.TypeFor[batchv1.Job](),
+	{Group: "batch", Kind: "CronJob"}:          reflect.TypeFor[batchv1.CronJob](),
+}
+
+// builtinMergeKeys computes the keys reported by BuiltinMergeKeys once.
+var builtinMergeKeys = sync.OnceValue(func() MergeKeys {
+	keys := make(MergeKeys, len(builtinMergeKeyTypes))
+
+	for gk, t := range builtinMergeKeyTypes {
+		paths := make(map[string]string)
+		collectStructMergeKeys(t, "", paths, make(map[reflect.Type]bool))
+		keys[gk] = paths
+	}
+
+	return keys
+})
+
+// BuiltinMergeKeys returns the strategic merge keys of the built-in workload
+// kinds, Pods, PodTemplates, and Services, such as the name of containers or
+// the containerPort of their ports. They are read from the patchMergeKey tags
+// of the k8s.io/api types, so keyed lists merge as kubectl patch would merge
+// them. The result is a new map the caller may modify.
+func BuiltinMergeKeys() MergeKeys {
+	keys := make(MergeKeys, len(builtinMergeKeyTypes))
+	for gk, paths := range builtinMergeKeys() {
+		keys[gk] = maps.Clone(paths)
+	}
+
+	return keys
+}
+
+// combineMergeKeys returns the keys of base and overrides, preferring
+// overrides for paths registered in both. Neither input is modified.
+func combineMergeKeys(base MergeKeys, overrides MergeKeys) MergeKeys {
+	if len(overrides) == 0 {
+		return base
+	}
+
+	combined := make(MergeKeys, len(base)+len(overrides))
+	for gk, paths := range base {
+		combined[gk] = paths
+	}
+
+	for gk, paths := range overrides {
+		if existing, ok := combined[gk]; ok {
+			merged := maps.Clone(existing)
+			maps.Copy(merged, paths)
+			paths = merged
+		}
+
+		combined[gk] = paths
+	}
+
+	return combined
+}
+
+// collectStructMergeKeys walks the JSON fields of t and records the
+// patchMergeKey of every keyed list under path. visiting guards against
+// recursive types.
+func collectStructMergeKeys(t reflect.Type, path string, paths map[string]string, visiting map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || visiting[t] {
+		return
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && (field.Anonymous || strings.Contains(options, "inline")) {
+			collectStructMergeKeys(field.Type, path, paths, visiting)
+
+			continue
+		}
+
+		childPath := joinFieldPath(path, name)
+
+		if key := field.Tag.Get("patchMergeKey"); key != "" && field.Type.Kind() == reflect.Slice {
+			paths[childPath] = key
+		}
+
+		collectStructMergeKeys(field.Type, childPath, paths, visiting)
+	}
+}
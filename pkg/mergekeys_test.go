@@ -0,0 +1,219 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const widgetCRDYAML = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              parts:
+                type: array
+                x-kubernetes-list-type: map
+                x-kubernetes-list-map-keys: [id]
+                items:
+                  type: object
+                  properties:
+                    id:
+                      type: string
+                    options:
+                      type: array
+                      x-kubernetes-patch-merge-key: name
+                      items:
+                        type: object
+              tags:
+                type: array
+                items:
+                  type: string
+`
+
+const widgetYAML = `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+spec:
+  parts:
+  - id: a
+    size: 1
+    options:
+    - name: color
+      value: red
+  - id: b
+    size: 2
+  tags: [x]
+`
+
+func TestMergeKeysFromCRDs(t *testing.T) {
+
+	t.Run("should read merge keys from the schema", func(t *testing.T) {
+		g := NewWithT(t)
+
+		keys, err := mem.MergeKeysFromCRDs(mem.MustUnstructured(widgetCRDYAML))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(keys).To(Equal(mem.MergeKeys{
+			{Group: "example.com", Kind: "Widget"}: {
+				"spec.parts":         "id",
+				"spec.parts.options": "name",
+			},
+		}))
+	})
+
+	t.Run("should reject objects that are not CRDs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.MergeKeysFromCRDs(mem.MustUnstructured(configMapYAML))
+		g.Expect(err).To(MatchError(mem.ErrInvalidCRD))
+	})
+}
+
+func TestMergeKeys(t *testing.T) {
+
+	compile := func(g Gomega, keys mem.MergeKeys, base string, merge map[string]any) unstructured.Unstructured {
+		source, err := mem.Bundle{
+			Base:      mem.MustSourceFromYAML(base),
+			Overlays:  []mem.Overlay{{Patches: []mem.Patch{{Merge: merge}}}},
+			MergeKeys: keys,
+		}.Compile()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return source.Objects[0]
+	}
+
+	widgetPatch := map[string]any{"spec": map[string]any{
+		"parts": []any{
+			map[string]any{
+				"id": "a", "size": int64(3),
+				"options": []any{map[string]any{"name": "shape", "value": "round"}},
+			},
+			map[string]any{"id": "c", "size": int64(4)},
+		},
+		"tags": []any{"y"},
+	}}
+
+	t.Run("should replace lists without merge keys", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := compile(g, nil, widgetYAML, widgetPatch)
+
+		parts, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parts")
+		g.Expect(parts).To(HaveLen(2))
+		g.Expect(parts[1]).To(HaveKeyWithValue("id", "c"))
+	})
+
+	t.Run("should merge keyed lists of custom resources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		keys, err := mem.MergeKeysFromCRDs(mem.MustUnstructured(widgetCRDYAML))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		obj := compile(g, keys, widgetYAML, widgetPatch)
+
+		parts, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parts")
+		g.Expect(parts).To(HaveLen(3))
+		g.Expect(parts[0]).To(HaveKeyWithValue("size", int64(3)))
+		g.Expect(parts[0]).To(HaveKeyWithValue("options", []any{
+			map[string]any{"name": "color", "value": "red"},
+			map[string]any{"name": "shape", "value": "round"},
+		}))
+		g.Expect(parts[1]).To(HaveKeyWithValue("size", int64(2)))
+		g.Expect(parts[2]).To(HaveKeyWithValue("id", "c"))
+
+		tags, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "tags")
+		g.Expect(tags).To(Equal([]string{"y"}))
+	})
+
+	t.Run("should merge registered lists of built-in kinds", func(t *testing.T) {
+		g := NewWithT(t)
+
+		keys := mem.MergeKeys{
+			schema.GroupKind{Group: "apps", Kind: "Deployment"}: {"spec.template.spec.containers": "name"},
+		}
+
+		obj := compile(g, keys, bundleDeploymentYAML, map[string]any{
+			"spec": map[string]any{"template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "web", "image": "nginx:2.0"},
+				map[string]any{"name": "sidecar", "image": "envoy"},
+			}}}},
+		})
+
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		g.Expect(containers).To(Equal([]any{
+			map[string]any{"name": "web", "image": "nginx:2.0"},
+			map[string]any{"name": "sidecar", "image": "envoy"},
+		}))
+	})
+
+	t.Run("should apply to matrix environments", func(t *testing.T) {
+		g := NewWithT(t)
+
+		keys, err := mem.MergeKeysFromCRDs(mem.MustUnstructured(widgetCRDYAML))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		results, err := mem.Matrix{
+			Sources:      []mem.Source{mem.MustSourceFromYAML(widgetYAML)},
+			Environments: map[string]mem.EnvConfig{"prod": {Patches: []mem.Patch{{Merge: widgetPatch}}}},
+			MergeKeys:    keys,
+		}.Render(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		parts, _, _ := unstructured.NestedSlice(results["prod"][0].Object, "spec", "parts")
+		g.Expect(parts).To(HaveLen(3))
+	})
+}
+
+func TestBuiltinMergeKeys(t *testing.T) {
+
+	t.Run("should read strategic merge keys of built-in kinds", func(t *testing.T) {
+		g := NewWithT(t)
+
+		keys := mem.BuiltinMergeKeys()
+
+		g.Expect(keys[schema.GroupKind{Group: "apps", Kind: "Deployment"}]).To(And(
+			HaveKeyWithValue("spec.template.spec.containers", "name"),
+			HaveKeyWithValue("spec.template.spec.containers.ports", "containerPort"),
+			HaveKeyWithValue("spec.template.spec.containers.env", "name"),
+			HaveKeyWithValue("spec.template.spec.volumes", "name"),
+		))
+		g.Expect(keys[schema.GroupKind{Kind: "Service"}]).To(HaveKeyWithValue("spec.ports", "port"))
+		g.Expect(keys[schema.GroupKind{Group: "batch", Kind: "CronJob"}]).To(
+			HaveKeyWithValue("spec.jobTemplate.spec.template.spec.containers", "name"))
+	})
+
+	t.Run("should return a copy", func(t *testing.T) {
+		g := NewWithT(t)
+
+		gk := schema.GroupKind{Kind: "Service"}
+
+		keys := mem.BuiltinMergeKeys()
+		keys[gk]["spec.ports"] = "name"
+
+		g.Expect(mem.BuiltinMergeKeys()[gk]).To(HaveKeyWithValue("spec.ports", "port"))
+	})
+}
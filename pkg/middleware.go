@@ -0,0 +1,41 @@
+package mem
+
+import (
+	"context"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+)
+
+// ProcessFunc renders values into a Result. It is the call a Middleware wraps.
+type ProcessFunc func(ctx context.Context, values types.Values) (*Result, error)
+
+// Middleware wraps the ProcessFunc of a renderer to layer a cross-cutting
+// concern, such as locking, quotas, instrumentation, or fault injection, over
+// whole renders. The returned ProcessFunc usually calls next, running code
+// around it or changing ctx or values; it may also return without calling
+// next, e.g. to reject a render. A nil Result with a nil error is an empty
+// render.
+type Middleware func(next ProcessFunc) ProcessFunc
+
+// render runs a full render through the middlewares of r, the first
+// registered outermost.
+func (r *Renderer) render(ctx context.Context, values types.Values, withProvenance bool) (*Result, error) {
+	process := ProcessFunc(func(ctx context.Context, values types.Values) (*Result, error) {
+		return r.renderOnce(ctx, values, withProvenance)
+	})
+
+	for i := len(r.opts.Middlewares) - 1; i >= 0; i-- {
+		process = r.opts.Middlewares[i](process)
+	}
+
+	result, err := process(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		result = NewResult(nil)
+	}
+
+	return result, nil
+}
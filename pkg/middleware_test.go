@@ -0,0 +1,152 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// tracing returns a middleware appending name to calls before and after
+// the render it wraps.
+func tracing(name string, calls *[]string) mem.Middleware {
+	return func(next mem.ProcessFunc) mem.ProcessFunc {
+		return func(ctx context.Context, values pkgtypes.Values) (*mem.Result, error) {
+			*calls = append(*calls, name+" before")
+			result, err := next(ctx, values)
+			*calls = append(*calls, name+" after")
+
+			return result, err
+		}
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+
+	sources := []mem.Source{{Objects: []unstructured.Unstructured{composeObject("v1", "ConfigMap", "app", "config")}}}
+
+	t.Run("should wrap renders, the first registered outermost", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var calls []string
+
+		renderer, err := mem.New(sources,
+			mem.WithMiddleware(tracing("outer", &calls)),
+			mem.WithMiddleware(tracing("inner", &calls)),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/config"}))
+		g.Expect(calls).To(Equal([]string{"outer before", "inner before", "inner after", "outer after"}))
+
+		calls = nil
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(Equal(1))
+		g.Expect(calls).To(HaveLen(4))
+	})
+
+	t.Run("should pass values changed by a middleware", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var seen pkgtypes.Values
+
+		renderer, err := mem.New(
+			[]mem.Source{{ObjectsFn: func(
+				_ context.Context,
+				values pkgtypes.Values,
+			) ([]unstructured.Unstructured, error) {
+				seen = values
+
+				return nil, nil
+			}}},
+			mem.WithMiddleware(func(next mem.ProcessFunc) mem.ProcessFunc {
+				return func(ctx context.Context, _ pkgtypes.Values) (*mem.Result, error) {
+					return next(ctx, pkgtypes.Values{"injected": true})
+				}
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), pkgtypes.Values{"injected": false})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(seen).To(Equal(pkgtypes.Values{"injected": true}))
+	})
+
+	t.Run("should let middlewares reject renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errQuota := errors.New("quota exceeded")
+
+		renderer, err := mem.New(sources, mem.WithMiddleware(func(_ mem.ProcessFunc) mem.ProcessFunc {
+			return func(_ context.Context, _ pkgtypes.Values) (*mem.Result, error) {
+				return nil, errQuota
+			}
+		}))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errQuota))
+
+		_, err = renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).To(MatchError(errQuota))
+
+		g.Expect(renderer.Stats().Renders).To(BeZero())
+	})
+
+	t.Run("should treat a nil result as an empty render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(sources, mem.WithMiddleware(func(_ mem.ProcessFunc) mem.ProcessFunc {
+			return func(_ context.Context, _ pkgtypes.Values) (*mem.Result, error) {
+				return nil, nil
+			}
+		}))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(BeEmpty())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(BeZero())
+	})
+
+	t.Run("should see renders served by the cache", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var cached []bool
+
+		record := mem.WithMiddleware(func(next mem.ProcessFunc) mem.ProcessFunc {
+			return func(ctx context.Context, values pkgtypes.Values) (*mem.Result, error) {
+				result, err := next(ctx, values)
+				if err == nil {
+					cached = append(cached, result.Info().Cached)
+				}
+
+				return result, err
+			}
+		})
+
+		renderer, err := mem.New(sources, mem.WithRenderCache(true), record)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(cached).To(Equal([]bool{false, true}))
+	})
+}
@@ -0,0 +1,94 @@
+package mem
+
+import (
+	"maps"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// builtinNamespaces exist in every cluster and are never generated, so that
+// pruning a render cannot delete them.
+var builtinNamespaces = map[string]struct{}{
+	"default":         {},
+	"kube-system":     {},
+	"kube-public":     {},
+	"kube-node-lease": {},
+}
+
+// ensureNamespaces prepends a Namespace object, in name order, for every
+// namespace that objects are placed in but do not define.
+func (r *Renderer) ensureNamespaces(objects []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	defined := make(map[string]struct{})
+	missing := make(map[string]struct{})
+
+	for i := range objects {
+		if isNamespace(objects[i]) {
+			defined[objects[i].GetName()] = struct{}{}
+		}
+	}
+
+	for i := range objects {
+		namespace := objects[i].GetNamespace()
+		if namespace == "" {
+			continue
+		}
+
+		if _, ok := defined[namespace]; ok {
+			continue
+		}
+
+		if _, ok := builtinNamespaces[namespace]; ok {
+			continue
+		}
+
+		missing[namespace] = struct{}{}
+	}
+
+	if len(missing) == 0 {
+		return objects, nil
+	}
+
+	ensured := make([]unstructured.Unstructured, 0, len(missing)+len(objects))
+
+	for _, name := range slices.Sorted(maps.Keys(missing)) {
+		namespace, err := r.newNamespace(name)
+		if err != nil {
+			return nil, err
+		}
+
+		ensured = append(ensured, namespace)
+	}
+
+	return append(ensured, objects...), nil
+}
+
+// newNamespace builds the Namespace name with the configured labels and
+// annotations.
+func (r *Renderer) newNamespace(name string) (unstructured.Unstructured, error) {
+	namespace := unstructured.Unstructured{}
+	namespace.SetAPIVersion("v1")
+	namespace.SetKind("Namespace")
+	namespace.SetName(name)
+
+	if len(r.opts.NamespaceLabels) > 0 {
+		namespace.SetLabels(r.opts.NamespaceLabels)
+	}
+
+	if len(r.opts.NamespaceAnnotations) > 0 {
+		namespace.SetAnnotations(r.opts.NamespaceAnnotations)
+	}
+
+	if r.opts.ContentHash {
+		if err := r.opts.hasher().set(&namespace); err != nil {
+			return unstructured.Unstructured{}, err
+		}
+	}
+
+	return namespace, nil
+}
+
+// isNamespace reports whether obj is a core Namespace.
+func isNamespace(obj unstructured.Unstructured) bool {
+	return obj.GetKind() == "Namespace" && obj.GetAPIVersion() == "v1"
+}
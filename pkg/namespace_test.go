@@ -0,0 +1,130 @@
+package mem_test
+
+import (
+	"context"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const namespacedYAML = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: team-b
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: team-a
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-c
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: team-c
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: defaults
+  namespace: default
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+`
+
+func TestEnsureNamespaces(t *testing.T) {
+
+	t.Run("should prepend missing namespaces in name order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(namespacedYAML)},
+			mem.WithEnsureNamespaces(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{
+			"Namespace/team-a",
+			"Namespace/team-b",
+			"ConfigMap/app-config",
+			"Deployment/app",
+			"Namespace/team-c",
+			"Service/app",
+			"ConfigMap/defaults",
+			"ClusterRole/reader",
+		}))
+		g.Expect(objects[0].GetAPIVersion()).To(Equal("v1"))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKey(pkgtypes.AnnotationContentHash))
+	})
+
+	t.Run("should be disabled by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(namespacedYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(6))
+	})
+
+	t.Run("should set the configured metadata", func(t *testing.T) {
+		g := NewWithT(t)
+
+		labels := map[string]string{"team": "platform"}
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(namespacedYAML)},
+			mem.WithEnsureNamespaces(true),
+			mem.WithNamespaceMetadata(labels, map[string]string{"owner": "platform@example.com"}),
+			mem.WithGenerationAnnotation("7"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		labels["team"] = "changed"
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetLabels()).To(Equal(map[string]string{"team": "platform"}))
+		g.Expect(objects[0].GetAnnotations()).To(And(
+			HaveKeyWithValue("owner", "platform@example.com"),
+			HaveKeyWithValue(mem.AnnotationGeneration, "7"),
+		))
+	})
+
+	t.Run("should only consider namespaces left by the chain", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(namespacedYAML)},
+			mem.WithEnsureNamespaces(true),
+			mem.WithFilter(func(_ context.Context, obj unstructured.Unstructured) (bool, error) {
+				return obj.GetNamespace() != "team-a", nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)[0]).To(Equal("Namespace/team-b"))
+		g.Expect(names(objects)).ToNot(ContainElement("Namespace/team-a"))
+	})
+}
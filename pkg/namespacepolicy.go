@@ -0,0 +1,81 @@
+package mem
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// NamespacePolicy restricts where rendered objects are placed, for platforms
+// that enforce tenant boundaries at render time, see WithNamespacePolicy.
+type NamespacePolicy struct {
+	// RequireNamespace rejects namespaced objects that set no namespace, and
+	// so would land in whichever namespace the applier defaults to.
+	RequireNamespace bool
+
+	// AllowedNamespaces, if set, are the only namespaces objects may be
+	// placed in or define as Namespace objects.
+	AllowedNamespaces []string
+}
+
+func (p NamespacePolicy) validate() error {
+	for _, namespace := range p.AllowedNamespaces {
+		if err := validateNamespaceName(namespace); err != nil {
+			return fmt.Errorf("%w: allowed namespace %w", ErrInvalidNamespacePolicy, err)
+		}
+	}
+
+	return nil
+}
+
+// enforceNamespacePolicy fails with ErrNamespaceNotAllowed, naming every
+// offending object, if objects break the namespace policy.
+func (r *Renderer) enforceNamespacePolicy(objects []unstructured.Unstructured) error {
+	policy := r.opts.NamespacePolicy
+	violations := make([]string, 0)
+
+	var scopes map[schema.GroupKind]bool
+
+	for i := range objects {
+		key := ObjectKeyOf(objects[i])
+		namespace := objects[i].GetNamespace()
+
+		if isNamespace(objects[i]) {
+			namespace = objects[i].GetName()
+		}
+
+		if namespace == "" {
+			if !policy.RequireNamespace {
+				continue
+			}
+
+			if scopes == nil {
+				scopes = renderedScopes(objects)
+			}
+
+			isNamespaced, err := namespacedKind(objects[i].GroupVersionKind(), scopes, r.opts.RESTMapper)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+
+			if isNamespaced {
+				violations = append(violations, key+": no namespace set")
+			}
+
+			continue
+		}
+
+		if len(policy.AllowedNamespaces) > 0 && !slices.Contains(policy.AllowedNamespaces, namespace) {
+			violations = append(violations, fmt.Sprintf("%s: namespace %q is not allowed", key, namespace))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %s", ErrNamespaceNotAllowed, strings.Join(violations, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNamespacePolicy(t *testing.T) {
+
+	render := func(
+		objects []unstructured.Unstructured,
+		opts ...mem.RendererOption,
+	) ([]unstructured.Unstructured, error) {
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return renderer.Process(t.Context(), nil)
+	}
+
+	t.Run("should reject namespaced objects without a namespace", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(
+			[]unstructured.Unstructured{
+				composeObject("apps/v1", "Deployment", "", "web"),
+				composeObject("v1", "ConfigMap", "app", "config"),
+				composeObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "reader"),
+				scopeCRD("Widget", "Namespaced"),
+				composeObject("example.com/v1", "Widget", "", "w"),
+			},
+			mem.WithNamespacePolicy(mem.NamespacePolicy{RequireNamespace: true}),
+		)
+		g.Expect(err).To(MatchError(mem.ErrNamespaceNotAllowed))
+		g.Expect(err.Error()).To(ContainSubstring(
+			"apps/v1/Deployment//web: no namespace set; example.com/v1/Widget//w: no namespace set"))
+		g.Expect(err.Error()).ToNot(ContainSubstring("ClusterRole"))
+	})
+
+	t.Run("should accept objects placed by the default namespace", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(
+			[]unstructured.Unstructured{composeObject("apps/v1", "Deployment", "", "web")},
+			mem.WithDefaultNamespace("app"),
+			mem.WithNamespacePolicy(mem.NamespacePolicy{RequireNamespace: true, AllowedNamespaces: []string{"app"}}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetNamespace()).To(Equal("app"))
+	})
+
+	t.Run("should reject objects outside the allowed namespaces", func(t *testing.T) {
+		g := NewWithT(t)
+
+		policy := mem.NamespacePolicy{AllowedNamespaces: []string{"team-a", "team-b"}}
+
+		objects, err := render(
+			[]unstructured.Unstructured{
+				composeObject("v1", "Namespace", "", "team-a"),
+				composeObject("v1", "ConfigMap", "team-b", "config"),
+				composeObject("apps/v1", "Deployment", "", "web"),
+			},
+			mem.WithNamespacePolicy(policy),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+
+		_, err = render(
+			[]unstructured.Unstructured{
+				composeObject("v1", "Namespace", "", "kube-system"),
+				composeObject("v1", "ConfigMap", "team-b", "config"),
+				composeObject("v1", "Secret", "team-c", "token"),
+			},
+			mem.WithNamespacePolicy(policy),
+		)
+		g.Expect(err).To(MatchError(mem.ErrNamespaceNotAllowed))
+		g.Expect(err.Error()).To(ContainSubstring(
+			`v1/Namespace//kube-system: namespace "kube-system" is not allowed; ` +
+				`v1/Secret/team-c/token: namespace "team-c" is not allowed`))
+	})
+
+	t.Run("should reject invalid allowed namespaces", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithNamespacePolicy(mem.NamespacePolicy{AllowedNamespaces: []string{""}}))
+		g.Expect(err).To(MatchError(mem.ErrInvalidNamespacePolicy))
+	})
+}
@@ -0,0 +1,73 @@
+package mem
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ObjectKey identifies an object by API version, kind, namespace, and name.
+type ObjectKey struct {
+	schema.GroupVersionKind
+
+	Namespace string
+	Name      string
+}
+
+// String formats the key as "<apiVersion>/<kind>/<namespace>/<name>", e.g.
+// "apps/v1/Deployment/app/web", "v1/ConfigMap/app/config", or
+// "v1/Namespace//app" for cluster-scoped objects. ParseObjectKey reads it
+// back.
+func (k ObjectKey) String() string {
+	return k.GroupVersion().String() + "/" + k.Kind + "/" + k.Namespace + "/" + k.Name
+}
+
+// ObjectKeyOf returns the canonical string identifying obj, formatted as
+// described by ObjectKey.String. Errors, inventories, and lookups use it
+// whenever they name an object, so consumers never format identities
+// themselves. Unlike DefaultIdentity, it includes the API version.
+func ObjectKeyOf(obj unstructured.Unstructured) string {
+	return ObjectKey{
+		GroupVersionKind: obj.GroupVersionKind(),
+		Namespace:        obj.GetNamespace(),
+		Name:             obj.GetName(),
+	}.String()
+}
+
+// ParseObjectKey parses a key formatted by ObjectKeyOf. Kinds, namespaces,
+// and names cannot contain slashes, so the key has four parts for core
+// objects and five for the others. Version, kind, and name are required.
+func ParseObjectKey(key string) (ObjectKey, error) {
+	parts := strings.Split(key, "/")
+
+	var parsed ObjectKey
+
+	switch len(parts) {
+	case 4:
+		parsed = ObjectKey{
+			GroupVersionKind: schema.GroupVersionKind{Version: parts[0], Kind: parts[1]},
+			Namespace:        parts[2],
+			Name:             parts[3],
+		}
+	case 5:
+		parsed = ObjectKey{
+			GroupVersionKind: schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]},
+			Namespace:        parts[3],
+			Name:             parts[4],
+		}
+
+		if parsed.Group == "" {
+			return ObjectKey{}, fmt.Errorf("%w: %q has an empty group", ErrInvalidObjectKey, key)
+		}
+	default:
+		return ObjectKey{}, fmt.Errorf("%w: %q has %d parts instead of 4 or 5", ErrInvalidObjectKey, key, len(parts))
+	}
+
+	if parsed.Version == "" || parsed.Kind == "" || parsed.Name == "" {
+		return ObjectKey{}, fmt.Errorf("%w: %q needs a version, kind, and name", ErrInvalidObjectKey, key)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,90 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestObjectKeyOf(t *testing.T) {
+
+	t.Run("should format API version, kind, namespace, and name", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := composeObject("apps/v1", "Deployment", "app", "web")
+		g.Expect(mem.ObjectKeyOf(obj)).To(Equal("apps/v1/Deployment/app/web"))
+		g.Expect(mem.ObjectKeyOf(composeObject("v1", "ConfigMap", "app", "config"))).
+			To(Equal("v1/ConfigMap/app/config"))
+		g.Expect(mem.ObjectKeyOf(composeObject("v1", "Namespace", "", "app"))).To(Equal("v1/Namespace//app"))
+	})
+
+	t.Run("should distinguish API versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.ObjectKeyOf(composeObject("apps/v1", "Deployment", "app", "web"))).
+			ToNot(Equal(mem.ObjectKeyOf(composeObject("apps/v1beta1", "Deployment", "app", "web"))))
+	})
+}
+
+func TestParseObjectKey(t *testing.T) {
+
+	t.Run("should read back keys formatted by ObjectKeyOf", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, obj := range []struct {
+			apiVersion, kind, namespace, name string
+		}{
+			{"apps/v1", "Deployment", "app", "web"},
+			{"v1", "ConfigMap", "app", "config"},
+			{"v1", "Namespace", "", "app"},
+			{"rbac.authorization.k8s.io/v1", "ClusterRole", "", "reader"},
+		} {
+			key, err := mem.ParseObjectKey(
+				mem.ObjectKeyOf(composeObject(obj.apiVersion, obj.kind, obj.namespace, obj.name)))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(key.GroupVersion().String()).To(Equal(obj.apiVersion))
+			g.Expect(key.Kind).To(Equal(obj.kind))
+			g.Expect(key.Namespace).To(Equal(obj.namespace))
+			g.Expect(key.Name).To(Equal(obj.name))
+		}
+	})
+
+	t.Run("should parse core and grouped keys", func(t *testing.T) {
+		g := NewWithT(t)
+
+		key, err := mem.ParseObjectKey("v1/Namespace//app")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(key).To(Equal(mem.ObjectKey{
+			GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Name:             "app",
+		}))
+		g.Expect(key.String()).To(Equal("v1/Namespace//app"))
+
+		key, err = mem.ParseObjectKey("apps/v1/Deployment/app/web")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(key.GroupVersionKind).
+			To(Equal(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}))
+		g.Expect(key.String()).To(Equal("apps/v1/Deployment/app/web"))
+	})
+
+	t.Run("should reject malformed keys", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, key := range []string{
+			"",
+			"Deployment/web",
+			"apps/v1/Deployment/app/web/extra",
+			"/v1/Deployment/app/web",
+			"v1//app/config",
+			"/ConfigMap/app/config",
+			"v1/ConfigMap/app/",
+		} {
+			_, err := mem.ParseObjectKey(key)
+			g.Expect(err).To(MatchError(mem.ErrInvalidObjectKey), key)
+		}
+	})
+}
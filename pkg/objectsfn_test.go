@@ -0,0 +1,128 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// perEnvironment generates one ConfigMap for each value of the "envs" key.
+func perEnvironment(_ context.Context, values pkgtypes.Values) ([]unstructured.Unstructured, error) {
+	envs, _ := values["envs"].([]string)
+	objects := make([]unstructured.Unstructured, 0, len(envs))
+
+	for _, env := range envs {
+		obj := unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetName("config-" + env)
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func TestObjectsFn(t *testing.T) {
+
+	t.Run("should generate objects from render-time values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{ObjectsFn: perEnvironment}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), pkgtypes.Values{"envs": []string{"dev", "prod"}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/config-dev", "ConfigMap/config-prod"}))
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(BeEmpty())
+	})
+
+	t.Run("should render generated objects between objects and manifests", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{
+			Objects:   configMaps(1),
+			ObjectsFn: perEnvironment,
+			Manifests: []string{multiDocYAML},
+		}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), pkgtypes.Values{"envs": []string{"dev"}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(result.View().DeepCopy())).To(Equal([]string{
+			"ConfigMap/cm-0",
+			"ConfigMap/config-dev",
+			"ConfigMap/first",
+			"Deployment/second",
+		}))
+
+		provenance, ok := result.Provenance(1)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Position).To(BeNil())
+
+		provenance, ok = result.Provenance(2)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Position).ToNot(BeNil())
+	})
+
+	t.Run("should fail the render on generator errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errBoom := errors.New("boom")
+		renderer, err := mem.New([]mem.Source{
+			{Objects: configMaps(1)},
+			{ObjectsFn: func(context.Context, pkgtypes.Values) ([]unstructured.Unstructured, error) {
+				return nil, errBoom
+			}},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, errBoom)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring("invalid source at index 1: objects function failed")))
+	})
+
+	t.Run("should validate generated objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{
+			Objects: configMaps(2),
+			ObjectsFn: func(context.Context, pkgtypes.Values) ([]unstructured.Unstructured, error) {
+				return []unstructured.Unstructured{{}}, nil
+			},
+		}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrObjectEmpty)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring("at index 2")))
+	})
+
+	t.Run("should pass values to generators in jobs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{ObjectsFn: perEnvironment}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		envs := make([]string, 5)
+		for i := range envs {
+			envs[i] = fmt.Sprintf("env-%d", i)
+		}
+
+		job := renderer.NewJob(pkgtypes.Values{"envs": envs}, mem.WithBatchSize(2))
+		result, err := job.Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().DeepCopy()).To(HaveLen(5))
+	})
+}
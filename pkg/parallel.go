@@ -0,0 +1,138 @@
+package mem
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// renderedInput is the outcome of the source stage of one input.
+type renderedInput struct {
+	objects  []unstructured.Unstructured
+	selected bool
+	trace    *renderTrace
+	err      error
+}
+
+// renderInputs runs the source stage of every input of trace and returns the
+// outputs of the selected ones, in source order. With WithConcurrency, up to
+// that many sources are rendered at once.
+func (r *Renderer) renderInputs(
+	ctx context.Context,
+	values types.Values,
+	trace *renderTrace,
+) ([][]unstructured.Unstructured, error) {
+	trace.sources = len(trace.inputs)
+
+	if r.opts.Concurrency > 1 && len(trace.inputs) > 1 {
+		return r.renderInputsConcurrently(ctx, values, trace)
+	}
+
+	outputs := make([][]unstructured.Unstructured, 0, len(trace.inputs))
+
+	for i := range trace.inputs {
+		objects, selected, err := r.renderInput(ctx, values, i, trace)
+		if err != nil {
+			return nil, err
+		}
+
+		if selected {
+			outputs = append(outputs, objects)
+		}
+	}
+
+	return outputs, nil
+}
+
+// renderInputsConcurrently renders the inputs of trace on a pool of workers.
+// Each source records into a trace of its own, and the traces and outputs are
+// joined in source order, so the render is the one a sequential render would
+// produce. Once a source fails, only sources before it are still started, so
+// the error returned is the one of the first failing source, as in a
+// sequential render.
+func (r *Renderer) renderInputsConcurrently(
+	ctx context.Context,
+	values types.Values,
+	trace *renderTrace,
+) ([][]unstructured.Unstructured, error) {
+	results := make([]renderedInput, len(trace.inputs))
+	work := make(chan int)
+
+	var firstFailed atomic.Int64
+	firstFailed.Store(int64(len(trace.inputs)))
+
+	var wg sync.WaitGroup
+
+	for range min(r.opts.Concurrency, len(trace.inputs)) {
+		wg.Go(func() {
+			for i := range work {
+				if int64(i) > firstFailed.Load() {
+					continue
+				}
+
+				sub := trace.fork()
+				objects, selected, err := r.renderInput(ctx, values, i, sub)
+				results[i] = renderedInput{objects: objects, selected: selected, trace: sub, err: err}
+
+				for err != nil {
+					failed := firstFailed.Load()
+					if int64(i) >= failed || firstFailed.CompareAndSwap(failed, int64(i)) {
+						break
+					}
+				}
+			}
+		})
+	}
+
+	for i := range trace.inputs {
+		work <- i
+	}
+
+	close(work)
+	wg.Wait()
+
+	outputs := make([][]unstructured.Unstructured, 0, len(results))
+
+	for _, result := range results {
+		if result.err != nil {
+			return nil, result.err
+		}
+
+		if result.trace == nil {
+			continue
+		}
+
+		trace.join(result.trace)
+
+		if result.selected {
+			outputs = append(outputs, result.objects)
+		}
+	}
+
+	return outputs, nil
+}
+
+// renderInput runs the source stage of the index-th input: it prepares the
+// source and, if it is selected, renders its objects.
+func (r *Renderer) renderInput(
+	ctx context.Context,
+	values types.Values,
+	index int,
+	trace *renderTrace,
+) ([]unstructured.Unstructured, bool, error) {
+	source, selected, err := r.prepareSource(ctx, values, index, trace)
+	if err != nil || !selected {
+		return nil, false, err
+	}
+
+	objects, err := r.renderSource(ctx, index, source, source.Objects, trace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return objects, true, nil
+}
@@ -0,0 +1,138 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func parallelSources() []mem.Source {
+	sources := make([]mem.Source, 0, 20)
+
+	for i := range 20 {
+		source := mem.Source{
+			Name: fmt.Sprintf("source-%d", i),
+			Objects: []unstructured.Unstructured{
+				composeObject("v1", "ConfigMap", "app", fmt.Sprintf("config-%d", i)),
+				composeObject("policy/v1beta1", "PodDisruptionBudget", "app", fmt.Sprintf("pdb-%d", i)),
+			},
+		}
+
+		if i%5 == 0 {
+			source.Patches = []mem.Patch{{
+				Target: mem.PatchTarget{Kind: "ConfigMap", Name: fmt.Sprintf("config-%d", i)},
+				Merge:  map[string]any{"data": map[string]any{"patched": "true"}},
+			}}
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources
+}
+
+func TestConcurrency(t *testing.T) {
+
+	opts := []mem.RendererOption{
+		mem.WithSourceAnnotations(true),
+		mem.WithSourceSelector(func(_ context.Context, source mem.Source) (bool, error) {
+			return source.Name != "source-3", nil
+		}),
+		mem.WithGVKRewrite(map[schema.GroupVersionKind]schema.GroupVersionKind{
+			{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}: {
+				Group: "policy", Version: "v1", Kind: "PodDisruptionBudget",
+			},
+		}),
+	}
+
+	t.Run("should render like a sequential render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		sequential, err := mem.New(parallelSources(), opts...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		concurrent, err := mem.New(parallelSources(), append(opts, mem.WithConcurrency(4))...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		expected, err := sequential.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 10 {
+			result, err := concurrent.ProcessResult(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result.View().DeepCopy()).To(Equal(expected.View().DeepCopy()))
+			g.Expect(result.Migrations()).To(Equal(expected.Migrations()))
+			g.Expect(result.Info().Selected).To(Equal(19))
+			g.Expect(result.Info().Collected).To(Equal(expected.Info().Collected))
+
+			for i := range result.View().Len() {
+				provenance, _ := result.Provenance(i)
+				expectedProvenance, _ := expected.Provenance(i)
+				g.Expect(provenance).To(Equal(expectedProvenance))
+			}
+		}
+	})
+
+	t.Run("should render sources at the same time", func(t *testing.T) {
+		g := NewWithT(t)
+
+		started := make(chan struct{}, 2)
+		waitForOther := func(_ context.Context, _ pkgtypes.Values) ([]unstructured.Unstructured, error) {
+			started <- struct{}{}
+
+			for deadline := time.After(5 * time.Second); len(started) < 2; {
+				select {
+				case <-deadline:
+					return nil, errors.New("sources were rendered one after another")
+				case <-time.After(time.Millisecond):
+				}
+			}
+
+			return nil, nil
+		}
+
+		renderer, err := mem.New(
+			[]mem.Source{{ObjectsFn: waitForOther}, {ObjectsFn: waitForOther}},
+			mem.WithConcurrency(2),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("should return the error of the first failing source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errFirst := errors.New("first")
+		errLater := errors.New("later")
+
+		sources := parallelSources()
+		sources[2].ObjectsFn = func(_ context.Context, _ pkgtypes.Values) ([]unstructured.Unstructured, error) {
+			time.Sleep(10 * time.Millisecond)
+
+			return nil, errFirst
+		}
+		sources[7].ObjectsFn = func(_ context.Context, _ pkgtypes.Values) ([]unstructured.Unstructured, error) {
+			return nil, errLater
+		}
+
+		renderer, err := mem.New(sources, mem.WithConcurrency(8))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errFirst))
+		g.Expect(err).To(MatchError(ContainSubstring("invalid source at index 2")))
+	})
+}
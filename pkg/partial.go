@@ -0,0 +1,97 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const metaGroup = "meta.k8s.io"
+
+// PartialObjectResolver resolves a metadata-only object, a PartialObjectMetadata,
+// PartialObjectMetadataList, or Table as returned by metadata-only watches and
+// table responses, to the full objects it describes, typically by fetching
+// them from the API server. It receives a copy of the object and may return
+// any number of objects, none of which may be metadata-only.
+type PartialObjectResolver func(ctx context.Context, obj unstructured.Unstructured) ([]unstructured.Unstructured, error)
+
+// IsMetadataOnly reports whether obj is a PartialObjectMetadata,
+// PartialObjectMetadataList, or Table. Such objects only describe other
+// objects and cannot be applied.
+func IsMetadataOnly(obj unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != metaGroup {
+		return false
+	}
+
+	switch gvk.Kind {
+	case "PartialObjectMetadata", "PartialObjectMetadataList", "Table":
+		return true
+	default:
+		return false
+	}
+}
+
+// appendObject appends a deep copy of obj to objects, resolving it first if it
+// is metadata-only, or appending its items instead if it is a list and
+// WithListExpansion is enabled. Resolved objects are deep copied too, so
+// resolvers may return shared or cached objects. Copies are sanitized if a
+// Sanitizer is configured.
+func (r *Renderer) appendObject(
+	ctx context.Context,
+	objects []unstructured.Unstructured,
+	obj unstructured.Unstructured,
+) ([]unstructured.Unstructured, error) {
+	if r.opts.ListExpansion && isList(obj) {
+		return r.appendListItems(ctx, objects, obj)
+	}
+
+	objCopy, err := r.copyObject(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsMetadataOnly(obj) || r.opts.PartialObjectResolver == nil {
+		return append(objects, objCopy), nil
+	}
+
+	resolved, err := r.opts.PartialObjectResolver(ctx, objCopy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ObjectKeyOf(obj), err)
+	}
+
+	for i := range resolved {
+		if len(resolved[i].Object) == 0 {
+			return nil, fmt.Errorf("%w: resolved from %s", ErrObjectEmpty, ObjectKeyOf(obj))
+		}
+
+		if IsMetadataOnly(resolved[i]) {
+			return nil, fmt.Errorf("%w: resolver returned %s for %s",
+				ErrMetadataOnlyObject, resolved[i].GetKind(), ObjectKeyOf(obj))
+		}
+
+		resolvedCopy, err := r.copyObject(resolved[i])
+		if err != nil {
+			return nil, fmt.Errorf("resolved from %s: %w", ObjectKeyOf(obj), err)
+		}
+
+		objects = append(objects, resolvedCopy)
+	}
+
+	return objects, nil
+}
+
+// copyObject deep copies obj, sanitizing it if a Sanitizer is configured. If
+// WithDeepCopy is disabled, only the top-level fields and metadata of
+// unsanitized objects are copied.
+func (r *Renderer) copyObject(obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+	switch {
+	case r.opts.Sanitizer != nil:
+		return r.opts.Sanitizer.Sanitize(obj)
+	case r.opts.NoDeepCopy:
+		return copyMetadata(obj), nil
+	default:
+		return *obj.DeepCopy(), nil
+	}
+}
@@ -0,0 +1,160 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const partialConfigMapYAML = `
+apiVersion: meta.k8s.io/v1
+kind: PartialObjectMetadata
+metadata:
+  name: test-config
+  namespace: default
+`
+
+const tableYAML = `
+apiVersion: meta.k8s.io/v1
+kind: Table
+columnDefinitions:
+- name: Name
+  type: string
+rows:
+- cells: [first]
+- cells: [second]
+`
+
+var errResolve = errors.New("resolve failure") //nolint:gochecknoglobals
+
+// fetchFromCells is a resolver standing in for API server lookups: it builds
+// a ConfigMap for every name it finds in the stub.
+func fetchFromCells(_ context.Context, obj unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	names := []string{obj.GetName()}
+
+	if obj.GetKind() == "Table" {
+		names = names[:0]
+
+		rows, _, _ := unstructured.NestedSlice(obj.Object, "rows")
+		for _, row := range rows {
+			cells, _ := row.(map[string]any)["cells"].([]any)
+			names = append(names, cells[0].(string))
+		}
+	}
+
+	result := make([]unstructured.Unstructured, 0, len(names))
+	for _, name := range names {
+		result = append(result, composeObject("v1", "ConfigMap", "default", name))
+	}
+
+	return result, nil
+}
+
+func TestMetadataOnlyObjects(t *testing.T) {
+
+	t.Run("should detect metadata-only kinds", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.IsMetadataOnly(mem.MustUnstructured(partialConfigMapYAML))).To(BeTrue())
+		g.Expect(mem.IsMetadataOnly(mem.MustUnstructured(tableYAML))).To(BeTrue())
+		g.Expect(mem.IsMetadataOnly(mem.MustUnstructured(configMapYAML))).To(BeFalse())
+		g.Expect(mem.IsMetadataOnly(composeObject("example.com/v1", "Table", "", "furniture"))).To(BeFalse())
+	})
+
+	t.Run("should reject metadata-only objects without a resolver", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML, partialConfigMapYAML)})
+		g.Expect(err).To(MatchError(mem.ErrMetadataOnlyObject))
+		g.Expect(err).To(MatchError(ContainSubstring("at index 1 (PartialObjectMetadata)")))
+	})
+
+	t.Run("should resolve metadata-only objects in place", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(partialConfigMapYAML, tableYAML, configMapYAML)},
+			mem.WithPartialObjectResolver(fetchFromCells),
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{
+			"ConfigMap/test-config", "ConfigMap/first", "ConfigMap/second", "ConfigMap/test-config",
+		}))
+
+		for _, obj := range objects {
+			g.Expect(obj.GetAnnotations()).To(HaveKeyWithValue(types.AnnotationSourceType, "mem"))
+		}
+	})
+
+	t.Run("should report resolver failures", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(partialConfigMapYAML)},
+			mem.WithPartialObjectResolver(func(
+				context.Context,
+				unstructured.Unstructured,
+			) ([]unstructured.Unstructured, error) {
+				return nil, errResolve
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errResolve))
+	})
+
+	t.Run("should reject resolvers returning metadata-only objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(partialConfigMapYAML)},
+			mem.WithPartialObjectResolver(func(
+				_ context.Context,
+				obj unstructured.Unstructured,
+			) ([]unstructured.Unstructured, error) {
+				return []unstructured.Unstructured{obj}, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrMetadataOnlyObject))
+	})
+
+	t.Run("should not let resolvers share objects with the output", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cached := composeObject("v1", "ConfigMap", "default", "cached")
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(partialConfigMapYAML)},
+			mem.WithPartialObjectResolver(func(
+				context.Context,
+				unstructured.Unstructured,
+			) ([]unstructured.Unstructured, error) {
+				return []unstructured.Unstructured{cached}, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects[0].SetName("changed")
+		g.Expect(cached.GetName()).To(Equal("cached"))
+		g.Expect(cached.GetAnnotations()).To(BeEmpty())
+	})
+}
@@ -0,0 +1,494 @@
+package mem
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Patch modifies the objects matched by Target. Exactly one of Merge,
+// Strategic, and JSON must be set.
+type Patch struct {
+	// Target selects the objects to patch.
+	Target PatchTarget `json:"target"`
+
+	// Merge is a JSON merge patch (RFC 7386) merged into each matching
+	// object: maps are merged recursively, nil values delete the
+	// corresponding key, lists registered in MergeKeys are merged by key, and
+	// any other value replaces the existing one. Values must be
+	// JSON-compatible, as in unstructured content.
+	Merge map[string]any `json:"merge,omitempty"`
+
+	// Strategic is merged like Merge, but the keyed lists of built-in kinds
+	// (BuiltinMergeKeys) are merged by key as well, as a strategic merge
+	// patch would. Patch directives such as $patch are not supported and are
+	// rejected with ErrInvalidPatch, in Merge patches too.
+	Strategic map[string]any `json:"strategic,omitempty"`
+
+	// JSON is a JSON patch (RFC 6902) applied to each matching object.
+	JSON []JSONPatchOperation `json:"json,omitempty"`
+}
+
+// JSONPatchOperation is a single JSON patch operation. Paths are JSON
+// pointers (RFC 6901) into the object and must not be empty.
+type JSONPatchOperation struct {
+	// Op is one of add, remove, replace, move, copy, and test.
+	Op string `json:"op"`
+
+	// Path is the location the operation applies to.
+	Path string `json:"path"`
+
+	// From is the source location of move and copy.
+	From string `json:"from,omitempty"`
+
+	// Value is the value of add, replace, and test. It must be
+	// JSON-compatible, as in unstructured content.
+	Value any `json:"value,omitempty"`
+}
+
+// PatchTarget selects objects by identity. Empty fields match anything; the
+// API version is not considered.
+type PatchTarget struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Matches reports whether obj is selected by the target.
+func (t PatchTarget) Matches(obj unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+
+	return (t.Group == "" || t.Group == gvk.Group) &&
+		(t.Kind == "" || t.Kind == gvk.Kind) &&
+		(t.Namespace == "" || t.Namespace == obj.GetNamespace()) &&
+		(t.Name == "" || t.Name == obj.GetName())
+}
+
+func (p Patch) validate() error {
+	set := 0
+	for _, isSet := range []bool{p.Merge != nil, p.Strategic != nil, p.JSON != nil} {
+		if isSet {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("%w: exactly one of Merge, Strategic, and JSON must be set", ErrInvalidPatch)
+	}
+
+	if path, ok := findDirective(p.Merge, ""); ok {
+		return fmt.Errorf("%w: unsupported directive %s", ErrInvalidPatch, path)
+	}
+
+	if path, ok := findDirective(p.Strategic, ""); ok {
+		return fmt.Errorf("%w: unsupported directive %s", ErrInvalidPatch, path)
+	}
+
+	for i, op := range p.JSON {
+		if err := op.validate(); err != nil {
+			return fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// patchDirectives are the strategic merge patch directives, which would be
+// merged as plain data; $setElementOrder is followed by "/<field>".
+var patchDirectives = []string{"$patch", "$retainKeys", "$deleteFromPrimitiveList", "$setElementOrder"}
+
+// findDirective returns the path of the first patch directive in value, found
+// at any depth, maps in lists included. Other keys starting with "$", such as
+// the $ref of a JSON schema, are plain data.
+func findDirective(value any, path string) (string, bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			isDirective := slices.ContainsFunc(patchDirectives, func(directive string) bool {
+				return key == directive || strings.HasPrefix(key, directive+"/")
+			})
+			if isDirective {
+				return path + "/" + key, true
+			}
+
+			if found, ok := findDirective(value[key], path+"/"+key); ok {
+				return found, true
+			}
+		}
+	case []any:
+		for i, item := range value {
+			if found, ok := findDirective(item, path+"/"+strconv.Itoa(i)); ok {
+				return found, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// apply patches obj in place; keys are the merge keys of Merge patches.
+func (p Patch) apply(obj *unstructured.Unstructured, keys MergeKeys) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	gk := obj.GroupVersionKind().GroupKind()
+
+	switch {
+	case p.Merge != nil:
+		obj.Object = mergePatch(obj.Object, p.Merge, keys[gk])
+	case p.Strategic != nil:
+		strategicKeys := maps.Clone(builtinMergeKeys()[gk])
+		if strategicKeys == nil {
+			strategicKeys = make(map[string]string, len(keys[gk]))
+		}
+
+		maps.Copy(strategicKeys, keys[gk])
+
+		obj.Object = mergePatch(obj.Object, p.Strategic, strategicKeys)
+	default:
+		for i, op := range p.JSON {
+			if err := op.apply(obj.Object); err != nil {
+				return fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sourcePatches are the patches of the index-th source.
+type sourcePatches struct {
+	index   int
+	patches []Patch
+}
+
+// applyPatches applies each patch to the objects it targets, in order, and
+// returns the indices of the patched objects. A patch matching no object is
+// an error wrapping ErrPatchTargetNotFound.
+func applyPatches(objects []unstructured.Unstructured, patches []Patch, keys MergeKeys) ([]int, error) {
+	patched := make(map[int]struct{})
+
+	for i, patch := range patches {
+		matched := false
+
+		for j := range objects {
+			if !patch.Target.Matches(objects[j]) {
+				continue
+			}
+
+			matched = true
+			patched[j] = struct{}{}
+
+			if err := patch.apply(&objects[j], keys); err != nil {
+				return nil, fmt.Errorf("patch at index %d: %w", i, err)
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("%w: patch at index %d (%+v)", ErrPatchTargetNotFound, i, patch.Target)
+		}
+	}
+
+	return slices.Sorted(maps.Keys(patched)), nil
+}
+
+func (o JSONPatchOperation) validate() error {
+	switch o.Op {
+	case "add", "remove", "replace", "test":
+	case "move", "copy":
+		if _, err := parseJSONPointer(o.From); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, o.Op)
+	}
+
+	if _, err := parseJSONPointer(o.Path); err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+
+	return nil
+}
+
+// apply runs the operation on root, which must be valid.
+func (o JSONPatchOperation) apply(root map[string]any) error {
+	path, _ := parseJSONPointer(o.Path)
+
+	switch o.Op {
+	case "add":
+		return jsonPatchAt(root, path, func(container any, token string) (any, error) {
+			return jsonAdd(container, token, runtime.DeepCopyJSONValue(o.Value))
+		})
+	case "remove":
+		return jsonPatchAt(root, path, func(container any, token string) (any, error) {
+			updated, _, err := jsonRemove(container, token)
+
+			return updated, err
+		})
+	case "replace":
+		return jsonPatchAt(root, path, func(container any, token string) (any, error) {
+			if _, err := jsonChild(container, token); err != nil {
+				return nil, err
+			}
+
+			return jsonSet(container, token, runtime.DeepCopyJSONValue(o.Value))
+		})
+	case "test":
+		current, err := jsonGet(root, path)
+		if err != nil {
+			return err
+		}
+
+		if !jsonEqual(current, o.Value) {
+			return fmt.Errorf("%w: test failed", ErrPatchFailed)
+		}
+
+		return nil
+	default:
+		from, _ := parseJSONPointer(o.From)
+
+		value, err := jsonGet(root, from)
+		if err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+
+		if o.Op == "move" {
+			if strings.HasPrefix(o.Path, o.From+"/") {
+				return fmt.Errorf("%w: cannot move %s into itself", ErrPatchFailed, o.From)
+			}
+
+			if err := jsonPatchAt(root, from, func(container any, token string) (any, error) {
+				updated, _, err := jsonRemove(container, token)
+
+				return updated, err
+			}); err != nil {
+				return err
+			}
+		} else {
+			value = runtime.DeepCopyJSONValue(value)
+		}
+
+		return jsonPatchAt(root, path, func(container any, token string) (any, error) {
+			return jsonAdd(container, token, value)
+		})
+	}
+}
+
+// parseJSONPointer splits a non-empty JSON pointer into unescaped tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid JSON pointer %q", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// jsonPatchAt runs op on the container holding the last token of path and
+// stores the container op returns in place of the old one, since appending
+// to or removing from a list creates a new slice.
+func jsonPatchAt(root map[string]any, path []string, op func(container any, token string) (any, error)) error {
+	_, err := jsonPatchNode(root, path, op)
+
+	return err
+}
+
+func jsonPatchNode(node any, path []string, op func(container any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return op(node, path[0])
+	}
+
+	child, err := jsonChild(node, path[0])
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := jsonPatchNode(child, path[1:], op)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonSet(node, path[0], updated)
+}
+
+// jsonGet returns the value at path.
+func jsonGet(root map[string]any, path []string) (any, error) {
+	var node any = root
+
+	for _, token := range path {
+		child, err := jsonChild(node, token)
+		if err != nil {
+			return nil, err
+		}
+
+		node = child
+	}
+
+	return node, nil
+}
+
+// jsonChild returns the member token of a map or list.
+func jsonChild(container any, token string) (any, error) {
+	switch c := container.(type) {
+	case map[string]any:
+		value, ok := c[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q not found", ErrPatchFailed, token)
+		}
+
+		return value, nil
+	case []any:
+		index, err := jsonIndex(token, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		return c[index], nil
+	default:
+		return nil, fmt.Errorf("%w: %q is not in a map or list", ErrPatchFailed, token)
+	}
+}
+
+// jsonSet replaces the member token of a map or list and returns the
+// container.
+func jsonSet(container any, token string, value any) (any, error) {
+	switch c := container.(type) {
+	case map[string]any:
+		c[token] = value
+
+		return c, nil
+	case []any:
+		index, err := jsonIndex(token, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		c[index] = value
+
+		return c, nil
+	default:
+		return nil, fmt.Errorf("%w: %q is not in a map or list", ErrPatchFailed, token)
+	}
+}
+
+// jsonAdd adds value as the member token of a map, or inserts it into a list
+// before index token ("-" appends), and returns the container.
+func jsonAdd(container any, token string, value any) (any, error) {
+	list, isList := container.([]any)
+	if !isList {
+		return jsonSet(container, token, value)
+	}
+
+	if token == "-" {
+		return append(list, value), nil
+	}
+
+	index, err := jsonIndex(token, len(list))
+	if err != nil {
+		return nil, err
+	}
+
+	list = append(list, nil)
+	copy(list[index+1:], list[index:])
+	list[index] = value
+
+	return list, nil
+}
+
+// jsonRemove removes the member token of a map or list and returns the
+// container and the removed value.
+func jsonRemove(container any, token string) (any, any, error) {
+	value, err := jsonChild(container, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch c := container.(type) {
+	case map[string]any:
+		delete(c, token)
+
+		return c, value, nil
+	default:
+		list, _ := c.([]any)
+		index, _ := jsonIndex(token, len(list)-1)
+
+		return append(list[:index], list[index+1:]...), value, nil
+	}
+}
+
+// jsonIndex parses a list index no greater than limit.
+func jsonIndex(token string, limit int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > limit || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid list index %q", ErrPatchFailed, token)
+	}
+
+	return index, nil
+}
+
+// jsonEqual compares JSON values, treating numbers of different Go types as
+// equal when their values are.
+func jsonEqual(a any, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if an, ok := jsonNumber(a); ok {
+		bn, ok := jsonNumber(b)
+
+		return ok && an == bn
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func jsonNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,262 @@
+package mem_test
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func patchDeployment(g Gomega, patches ...mem.Patch) unstructured.Unstructured {
+	source, err := mem.Bundle{
+		Base:     mem.MustSourceFromYAML(bundleDeploymentYAML),
+		Overlays: []mem.Overlay{{Patches: patches}},
+	}.Compile()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(source.Objects).To(HaveLen(1))
+
+	return source.Objects[0]
+}
+
+func TestJSONPatch(t *testing.T) {
+
+	target := mem.PatchTarget{Kind: "Deployment", Name: "web"}
+
+	t.Run("should apply operations in order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := patchDeployment(g, mem.Patch{Target: target, JSON: []mem.JSONPatchOperation{
+			{Op: "test", Path: "/spec/replicas", Value: 1},
+			{Op: "replace", Path: "/spec/replicas", Value: int64(2)},
+			{
+				Op:    "add",
+				Path:  "/spec/template/spec/containers/-",
+				Value: map[string]any{"name": "sidecar", "image": "envoy"},
+			},
+			{Op: "add", Path: "/spec/template/spec/containers/0/args", Value: []any{"--debug"}},
+			{Op: "copy", From: "/metadata/labels", Path: "/spec/template/metadata"},
+			{Op: "move", From: "/metadata/labels/app", Path: "/metadata/labels/app.kubernetes.io~1name"},
+			{Op: "remove", Path: "/spec/template/spec/containers/1/image"},
+		}})
+
+		g.Expect(obj.Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("replicas", int64(2))))
+		g.Expect(obj.GetLabels()).To(Equal(map[string]string{"app.kubernetes.io/name": "web"}))
+
+		templateMetadata, _, _ := unstructured.NestedMap(obj.Object, "spec", "template", "metadata")
+		g.Expect(templateMetadata).To(Equal(map[string]any{"app": "web"}))
+
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		g.Expect(containers).To(Equal([]any{
+			map[string]any{"name": "web", "image": "nginx:1.0", "args": []any{"--debug"}},
+			map[string]any{"name": "sidecar"},
+		}))
+	})
+
+	t.Run("should insert into lists by index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := patchDeployment(g, mem.Patch{Target: target, JSON: []mem.JSONPatchOperation{
+			{Op: "add", Path: "/spec/template/spec/containers/0", Value: map[string]any{"name": "init"}},
+		}})
+
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		g.Expect(containers).To(HaveLen(2))
+		g.Expect(containers[0]).To(HaveKeyWithValue("name", "init"))
+	})
+
+	t.Run("should fail operations on missing locations", func(t *testing.T) {
+		tests := map[string]mem.JSONPatchOperation{
+			"remove missing key":   {Op: "remove", Path: "/spec/paused"},
+			"replace missing key":  {Op: "replace", Path: "/spec/paused", Value: true},
+			"add below missing":    {Op: "add", Path: "/spec/strategy/type", Value: "Recreate"},
+			"index out of range":   {Op: "add", Path: "/spec/template/spec/containers/2", Value: map[string]any{}},
+			"failed test":          {Op: "test", Path: "/spec/replicas", Value: int64(3)},
+			"move into itself":     {Op: "move", From: "/spec", Path: "/spec/template/spec"},
+			"non-numeric index":    {Op: "remove", Path: "/spec/template/spec/containers/first"},
+			"copy from missing":    {Op: "copy", From: "/status", Path: "/spec/status"},
+			"leading zero index":   {Op: "remove", Path: "/spec/template/spec/containers/00"},
+			"child of scalar":      {Op: "add", Path: "/spec/replicas/count", Value: int64(1)},
+			"append to non-list":   {Op: "add", Path: "/spec/replicas/-", Value: int64(1)},
+			"remove from scalar":   {Op: "remove", Path: "/metadata/name/x"},
+			"test of missing path": {Op: "test", Path: "/spec/paused", Value: true},
+		}
+
+		for name, op := range tests {
+			t.Run(name, func(t *testing.T) {
+				g := NewWithT(t)
+
+				_, err := mem.Bundle{
+					Base: mem.MustSourceFromYAML(bundleDeploymentYAML),
+					Overlays: []mem.Overlay{{
+						Patches: []mem.Patch{{Target: target, JSON: []mem.JSONPatchOperation{op}}},
+					}},
+				}.Compile()
+				g.Expect(err).To(MatchError(mem.ErrPatchFailed))
+			})
+		}
+	})
+
+	t.Run("should reject malformed patches", func(t *testing.T) {
+		tests := map[string]mem.Patch{
+			"no patch":          {Target: target},
+			"two patches":       {Target: target, Merge: map[string]any{}, Strategic: map[string]any{}},
+			"unknown operation": {Target: target, JSON: []mem.JSONPatchOperation{{Op: "patch", Path: "/spec"}}},
+			"relative path":     {Target: target, JSON: []mem.JSONPatchOperation{{Op: "remove", Path: "spec"}}},
+			"empty path":        {Target: target, JSON: []mem.JSONPatchOperation{{Op: "remove"}}},
+			"missing from":      {Target: target, JSON: []mem.JSONPatchOperation{{Op: "move", Path: "/spec/x"}}},
+			"patch directive": {Target: target, Strategic: map[string]any{
+				"spec": map[string]any{"containers": []any{map[string]any{"name": "app", "$patch": "delete"}}},
+			}},
+			"retain keys directive": {Target: target, Strategic: map[string]any{
+				"spec": map[string]any{"$retainKeys": []any{"x"}},
+			}},
+			"element order directive": {Target: target, Merge: map[string]any{"$setElementOrder/containers": []any{}}},
+		}
+
+		for name, patch := range tests {
+			t.Run(name, func(t *testing.T) {
+				g := NewWithT(t)
+
+				_, err := mem.New([]mem.Source{{Patches: []mem.Patch{patch}}})
+				g.Expect(err).To(MatchError(mem.ErrInvalidPatch))
+			})
+		}
+	})
+}
+
+func TestStrategicPatch(t *testing.T) {
+
+	t.Run("should merge built-in keyed lists", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := patchDeployment(g, mem.Patch{
+			Target: mem.PatchTarget{Kind: "Deployment"},
+			Strategic: map[string]any{"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+				"containers": []any{map[string]any{"name": "web", "image": "nginx:2.0"}},
+			}}}},
+		})
+
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		g.Expect(containers).To(Equal([]any{map[string]any{"name": "web", "image": "nginx:2.0"}}))
+	})
+
+	t.Run("should replace lists with a merge patch", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := patchDeployment(g, mem.Patch{
+			Target: mem.PatchTarget{Kind: "Deployment"},
+			Merge: map[string]any{"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+				"containers": []any{map[string]any{"name": "sidecar"}},
+			}}}},
+		})
+
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		g.Expect(containers).To(Equal([]any{map[string]any{"name": "sidecar"}}))
+	})
+
+	t.Run("should merge other keys starting with $ as data", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := patchDeployment(g, mem.Patch{
+			Target:    mem.PatchTarget{Kind: "Deployment"},
+			Strategic: map[string]any{"metadata": map[string]any{"labels": map[string]any{"$ref": "value"}}},
+		})
+
+		g.Expect(obj.GetLabels()).To(HaveKeyWithValue("$ref", "value"))
+	})
+}
+
+func TestSourcePatches(t *testing.T) {
+
+	patchSource := mem.Source{Name: "prod", Patches: []mem.Patch{
+		{
+			Target:    mem.PatchTarget{Kind: "Deployment", Name: "web"},
+			Strategic: map[string]any{"spec": map[string]any{"replicas": int64(3)}},
+		},
+		{
+			Target: mem.PatchTarget{Kind: "ConfigMap"},
+			JSON:   []mem.JSONPatchOperation{{Op: "add", Path: "/data/env", Value: "prod"}},
+		},
+	}}
+
+	t.Run("should patch the objects of other sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				patchSource,
+				mem.MustSourceFromYAML(bundleDeploymentYAML),
+				mem.MustSourceFromYAML(configMapYAML),
+			},
+			mem.WithContentHash(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"Deployment/web", "ConfigMap/test-config"}))
+		g.Expect(objects[0].Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("replicas", int64(3))))
+		g.Expect(objects[1].Object).To(HaveKeyWithValue("data", HaveKeyWithValue("env", "prod")))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should run before the renderer-level chain", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{patchSource, mem.MustSourceFromYAML(bundleDeploymentYAML, configMapYAML)},
+			mem.WithTransformer(mem.ScaleToZero()),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("replicas", int64(0))))
+	})
+
+	t.Run("should skip the patches of unselected sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{patchSource, mem.MustSourceFromYAML(bundleDeploymentYAML)},
+			mem.WithSourceSelector(func(_ context.Context, source mem.Source) (bool, error) {
+				return source.Name != "prod", nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("replicas", int64(1))))
+	})
+
+	t.Run("should fail when a patch matches no object", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{patchSource, mem.MustSourceFromYAML(bundleDeploymentYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrPatchTargetNotFound))
+		g.Expect(err).To(MatchError(ContainSubstring("source 0: patch target not found: patch at index 1")))
+	})
+
+	t.Run("should patch in jobs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{
+			patchSource,
+			mem.MustSourceFromYAML(bundleDeploymentYAML, configMapYAML),
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.NewJob(nil, mem.WithBatchSize(1)).Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		replicas, _ := result.View().At(0).NestedField("spec", "replicas")
+		g.Expect(replicas).To(Equal(int64(3)))
+	})
+}
@@ -0,0 +1,204 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// PolicyObjectVariable is the CEL variable holding the object a policy
+	// rule checks.
+	PolicyObjectVariable = "object"
+
+	// PolicyObjectsVariable is the CEL variable holding the whole rendered
+	// output, in output order.
+	PolicyObjectsVariable = "objects"
+
+	// policyInterruptFrequency is the number of comprehension iterations
+	// between checks for a canceled context.
+	policyInterruptFrequency = 100
+)
+
+// PolicyViolation is a policy rule that a render does not satisfy.
+type PolicyViolation struct {
+	// Rule is the CEL expression of the rule.
+	Rule string `json:"rule"`
+
+	// Object is the key of the violating object, as formatted by
+	// ObjectKeyOf, or empty for rules over the whole set.
+	Object string `json:"object,omitempty"`
+
+	// Message is set if the rule could not be evaluated, e.g. because it
+	// selects a field the object lacks.
+	Message string `json:"message,omitempty"`
+}
+
+func (v PolicyViolation) String() string {
+	s := fmt.Sprintf("rule %q", v.Rule)
+	if v.Object != "" {
+		s += " violated by " + v.Object
+	} else {
+		s += " violated"
+	}
+
+	if v.Message != "" {
+		s += ": " + v.Message
+	}
+
+	return s
+}
+
+// PolicyError reports the policy violations of a render.
+type PolicyError struct {
+	// Violations are the violations of all rules, in registration order and,
+	// for each rule, in output order.
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.String())
+	}
+
+	return fmt.Sprintf("%s: %s", ErrPolicyViolation, strings.Join(messages, "; "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// policyRule is a compiled WithPolicy expression.
+type policyRule struct {
+	expression string
+	program    cel.Program
+
+	// perObject is set for rules referencing PolicyObjectVariable, which are
+	// evaluated once per object rather than once per render.
+	perObject bool
+
+	// err is the compilation error, reported by validate.
+	err error
+}
+
+func (p policyRule) validate() error {
+	if p.err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrInvalidPolicy, p.expression, p.err)
+	}
+
+	return nil
+}
+
+// policyEnv is the CEL environment of policy rules: the standard library and
+// the string extensions, with the rendered output as dynamically typed JSON.
+var policyEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		ext.Strings(),
+		cel.Variable(PolicyObjectVariable, cel.DynType),
+		cel.Variable(PolicyObjectsVariable, cel.ListType(cel.DynType)),
+	)
+})
+
+// compilePolicy compiles expression, recording any error in the rule.
+func compilePolicy(expression string) policyRule {
+	rule := policyRule{expression: expression}
+
+	env, err := policyEnv()
+	if err != nil {
+		rule.err = err
+
+		return rule
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		rule.err = issues.Err()
+
+		return rule
+	}
+
+	if output := ast.OutputType(); !output.IsExactType(cel.BoolType) && !output.IsExactType(cel.DynType) {
+		rule.err = fmt.Errorf("evaluates to %s, not bool", output)
+
+		return rule
+	}
+
+	for _, reference := range ast.NativeRep().ReferenceMap() {
+		if reference.Name == PolicyObjectVariable {
+			rule.perObject = true
+		}
+	}
+
+	rule.program, rule.err = env.Program(ast, cel.InterruptCheckFrequency(policyInterruptFrequency))
+
+	return rule
+}
+
+// enforcePolicies evaluates the renderer's policy rules over objects and
+// fails with a PolicyError listing every violation.
+func (r *Renderer) enforcePolicies(ctx context.Context, objects []unstructured.Unstructured) error {
+	set := make([]any, len(objects))
+	for i := range objects {
+		set[i] = objects[i].Object
+	}
+
+	violations := make([]PolicyViolation, 0)
+
+	for _, rule := range r.opts.Policies {
+		if !rule.perObject {
+			if v, ok := rule.evaluate(ctx, map[string]any{PolicyObjectsVariable: set}); !ok {
+				violations = append(violations, v)
+			}
+
+			continue
+		}
+
+		for i := range objects {
+			v, ok := rule.evaluate(ctx, map[string]any{PolicyObjectVariable: set[i], PolicyObjectsVariable: set})
+			if !ok {
+				v.Object = ObjectKeyOf(objects[i])
+				violations = append(violations, v)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// evaluate runs the rule over vars and reports whether it holds, and the
+// violation if not. Rules that fail to evaluate or evaluate to anything but
+// true are violated.
+func (p policyRule) evaluate(ctx context.Context, vars map[string]any) (PolicyViolation, bool) {
+	violation := PolicyViolation{Rule: p.expression}
+
+	out, _, err := p.program.ContextEval(ctx, vars)
+	if err != nil {
+		violation.Message = err.Error()
+
+		return violation, false
+	}
+
+	holds, isBool := out.Value().(bool)
+	if !isBool {
+		violation.Message = fmt.Sprintf("evaluated to %s, not bool", out.Type().TypeName())
+
+		return violation, false
+	}
+
+	return violation, holds
+}
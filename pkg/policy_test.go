@@ -0,0 +1,128 @@
+package mem_test
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	noLatestImages = `object.kind != 'Deployment' ||
+		object.spec.template.spec.containers.all(c, !c.image.endsWith(':latest'))`
+
+	limitsRequired = `object.kind != 'Deployment' ||
+		object.spec.template.spec.containers.all(c, has(c.resources) && has(c.resources.limits))`
+)
+
+func policyDeployment(name string, image string, limits bool) unstructured.Unstructured {
+	container := map[string]any{"name": "app", "image": image}
+	if limits {
+		container["resources"] = map[string]any{"limits": map[string]any{"memory": "128Mi"}}
+	}
+
+	deployment := composeObject("apps/v1", "Deployment", "app", name)
+	deployment.Object["spec"] = map[string]any{
+		"template": map[string]any{
+			"spec": map[string]any{"containers": []any{container}},
+		},
+	}
+
+	return deployment
+}
+
+func TestPolicy(t *testing.T) {
+
+	render := func(
+		objects []unstructured.Unstructured,
+		opts ...mem.RendererOption,
+	) ([]unstructured.Unstructured, error) {
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return renderer.Process(t.Context(), nil)
+	}
+
+	t.Run("should render output satisfying every rule", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(
+			[]unstructured.Unstructured{
+				policyDeployment("web", "web:1.2.3", true),
+				composeObject("v1", "ConfigMap", "app", "config"),
+			},
+			mem.WithPolicy(noLatestImages, limitsRequired),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+	})
+
+	t.Run("should report every violation by rule and object", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(
+			[]unstructured.Unstructured{
+				policyDeployment("web", "web:latest", true),
+				policyDeployment("worker", "worker:latest", false),
+			},
+			mem.WithPolicy(noLatestImages),
+			mem.WithPolicy(limitsRequired),
+		)
+		g.Expect(err).To(MatchError(mem.ErrPolicyViolation))
+
+		var policyErr *mem.PolicyError
+		g.Expect(errors.As(err, &policyErr)).To(BeTrue())
+		g.Expect(policyErr.Violations).To(Equal([]mem.PolicyViolation{
+			{Rule: noLatestImages, Object: "apps/v1/Deployment/app/web"},
+			{Rule: noLatestImages, Object: "apps/v1/Deployment/app/worker"},
+			{Rule: limitsRequired, Object: "apps/v1/Deployment/app/worker"},
+		}))
+		g.Expect(err.Error()).To(ContainSubstring("violated by apps/v1/Deployment/app/worker"))
+	})
+
+	t.Run("should evaluate rules without object over the whole set", func(t *testing.T) {
+		g := NewWithT(t)
+
+		rule := `objects.exists(o, o.kind == 'NetworkPolicy')`
+
+		_, err := render(configMaps(2), mem.WithPolicy(rule))
+		g.Expect(err).To(MatchError(mem.ErrPolicyViolation))
+		g.Expect(err.Error()).To(ContainSubstring(`rule "objects.exists(o, o.kind == 'NetworkPolicy')" violated`))
+
+		objects, err := render(
+			append(configMaps(2), composeObject("networking.k8s.io/v1", "NetworkPolicy", "app", "deny-all")),
+			mem.WithPolicy(rule),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+	})
+
+	t.Run("should treat rules that cannot be evaluated as violated", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(configMaps(1), mem.WithPolicy(`object.spec.replicas > 1`))
+
+		var policyErr *mem.PolicyError
+		g.Expect(errors.As(err, &policyErr)).To(BeTrue())
+		g.Expect(policyErr.Violations).To(HaveLen(1))
+		g.Expect(policyErr.Violations[0].Object).To(Equal("v1/ConfigMap//cm-0"))
+		g.Expect(policyErr.Violations[0].Message).To(ContainSubstring("spec"))
+	})
+
+	t.Run("should reject rules that do not compile", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithPolicy(`object.kind ==`))
+		g.Expect(err).To(MatchError(mem.ErrInvalidPolicy))
+
+		_, err = mem.New(nil, mem.WithPolicy(`size(objects)`))
+		g.Expect(err).To(MatchError(mem.ErrInvalidPolicy))
+		g.Expect(err.Error()).To(ContainSubstring("not bool"))
+	})
+}
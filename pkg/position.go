@@ -0,0 +1,107 @@
+package mem
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AnnotationSourcePosition is the annotation key for the position of the
+// document an object was decoded from, as JSON. It is written alongside the
+// other source annotations for objects whose Source records positions.
+const AnnotationSourcePosition = "manifests.k8s-manifests-kit/source.position"
+
+// Position locates the YAML or JSON document an object was decoded from.
+type Position struct {
+	// File is the file or URL the document was read from; empty for strings
+	// passed to YAMLDecoder.Source.
+	File string `json:"file,omitempty"`
+
+	// Input is the index of the string passed to YAMLDecoder.Source.
+	Input int `json:"input,omitempty"`
+
+	// Document is the 0-based index of the document within its input.
+	Document int `json:"document"`
+
+	// Line is the 1-based line of the input the document starts at.
+	Line int `json:"line"`
+}
+
+func (p Position) String() string {
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d (document %d)", p.File, p.Line, p.Document)
+	}
+
+	return fmt.Sprintf("input %d, line %d (document %d)", p.Input, p.Line, p.Document)
+}
+
+// PositionError is an error about an object whose source position is known.
+// Use errors.As to trace a rendering failure back to the document that
+// produced the object.
+type PositionError struct {
+	Position Position
+	Err      error
+}
+
+func (e *PositionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Err)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// SourcePosition returns the position recorded in the AnnotationSourcePosition
+// annotation of obj, and whether there is one. Validators running on rendered
+// output use it to report findings against the original document.
+func SourcePosition(obj unstructured.Unstructured) (Position, bool, error) {
+	raw, ok := obj.GetAnnotations()[AnnotationSourcePosition]
+	if !ok {
+		return Position{}, false, nil
+	}
+
+	var position Position
+	if err := json.Unmarshal([]byte(raw), &position); err != nil {
+		return Position{}, false, fmt.Errorf("%w: %w", ErrInvalidSourcePosition, err)
+	}
+
+	return position, true, nil
+}
+
+// positionOf returns the position of the i-th object of the source, if known.
+func (s Source) positionOf(i int) (Position, bool) {
+	if i < 0 || i >= len(s.Positions) || i >= len(s.Objects) || s.Positions[i] == (Position{}) {
+		return Position{}, false
+	}
+
+	return s.Positions[i], true
+}
+
+// atPosition wraps err in a PositionError if the i-th object of the source has
+// a known position.
+func (s Source) atPosition(i int, err error) error {
+	if position, ok := s.positionOf(i); ok {
+		return &PositionError{Position: position, Err: err}
+	}
+
+	return err
+}
+
+// setSourcePosition records position in the AnnotationSourcePosition annotation.
+func setSourcePosition(obj *unstructured.Unstructured, position Position) error {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return fmt.Errorf("unable to encode source position: %w", err)
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[AnnotationSourcePosition] = string(data)
+	obj.SetAnnotations(annotations)
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/gomega"
+)
+
+const positionedYAML = `# leading comment
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: meta.k8s.io/v1
+kind: PartialObjectMetadata
+metadata:
+  name: second
+`
+
+func TestSourcePositions(t *testing.T) {
+
+	t.Run("should record where each object was decoded from", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source, err := mem.SourceFromYAML(configMapYAML, positionedYAML)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Positions).To(Equal([]mem.Position{
+			{Input: 0, Document: 0, Line: 1},
+			{Input: 1, Document: 0, Line: 1},
+			{Input: 1, Document: 1, Line: 7},
+		}))
+	})
+
+	t.Run("should record the file of each object", func(t *testing.T) {
+		g := NewWithT(t)
+
+		path := filepath.Join(t.TempDir(), "manifests.yaml")
+		g.Expect(os.WriteFile(path, []byte(positionedYAML), 0o600)).To(Succeed())
+
+		source, err := mem.SourceFromFile(path)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Positions).To(HaveLen(2))
+		g.Expect(source.Positions[1]).To(Equal(mem.Position{File: path, Document: 1, Line: 7}))
+		g.Expect(source.Positions[1].String()).To(Equal(path + ":7 (document 1)"))
+	})
+
+	t.Run("should name the file in decoding errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		path := filepath.Join(t.TempDir(), "broken.yaml")
+		g.Expect(os.WriteFile(path, []byte("a: [\n"), 0o600)).To(Succeed())
+
+		_, err := mem.SourceFromFile(path)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring(path))
+
+		var yamlErr *mem.YAMLError
+		g.Expect(errors.As(err, &yamlErr)).To(BeTrue())
+	})
+
+	t.Run("should locate validation failures", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New([]mem.Source{mem.MustSourceFromYAML(positionedYAML)})
+		g.Expect(errors.Is(err, mem.ErrMetadataOnlyObject)).To(BeTrue())
+
+		var posErr *mem.PositionError
+		g.Expect(errors.As(err, &posErr)).To(BeTrue())
+		g.Expect(posErr.Position).To(Equal(mem.Position{Document: 1, Line: 7}))
+		g.Expect(err.Error()).To(ContainSubstring("input 0, line 7 (document 1)"))
+	})
+
+	t.Run("should locate resolver failures", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errGone := errors.New("gone")
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(positionedYAML)},
+			mem.WithPartialObjectResolver(func(
+				context.Context,
+				unstructured.Unstructured,
+			) ([]unstructured.Unstructured, error) {
+				return nil, errGone
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, errGone)).To(BeTrue())
+
+		var posErr *mem.PositionError
+		g.Expect(errors.As(err, &posErr)).To(BeTrue())
+		g.Expect(posErr.Position.Line).To(Equal(7))
+	})
+
+	t.Run("should annotate positions with source annotations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source := mem.MustSourceFromYAML(configMapYAML, positionedYAML)
+		source.Objects = source.Objects[:2]
+
+		renderer, err := mem.New([]mem.Source{source}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+
+		position, ok, err := mem.SourcePosition(objects[1])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ok).To(BeTrue())
+		g.Expect(position).To(Equal(mem.Position{Input: 1, Document: 0, Line: 1}))
+
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+
+		plain, err := mem.New([]mem.Source{{Objects: source.Objects}}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		unpositioned, err := plain.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(unpositioned[1].GetAnnotations()).ToNot(HaveKey(mem.AnnotationSourcePosition))
+		g.Expect(unpositioned[1].GetAnnotations()[pkgtypes.AnnotationContentHash]).
+			To(Equal(objects[1].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+	})
+
+	t.Run("should drop stale positions of re-ingested objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := first.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()).To(HaveKey(mem.AnnotationSourcePosition))
+
+		second, err := mem.New([]mem.Source{{Objects: objects}}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err = second.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationSourcePosition))
+	})
+
+	t.Run("should reject malformed position annotations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := mem.MustUnstructured(configMapYAML)
+		obj.SetAnnotations(map[string]string{mem.AnnotationSourcePosition: "{"})
+
+		_, _, err := mem.SourcePosition(obj)
+		g.Expect(errors.Is(err, mem.ErrInvalidSourcePosition)).To(BeTrue())
+	})
+}
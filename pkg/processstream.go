@@ -0,0 +1,192 @@
+package mem
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ProcessStream renders like Process but yields the rendered objects one at a
+// time, so callers rendering tens of thousands of objects need not hold the
+// whole result. Sources are rendered one after another, and each object of a
+// source runs through the renderer-level filters and transformers and the
+// final pass on its own, then is yielded; only the objects of the source being
+// rendered are held at once. Breaking out of the loop stops the render.
+//
+// Stages that need the whole set cannot stream: renderer-level post-renderers,
+// duplicate policies other than DuplicateKeepAll, source patches and deletions,
+// WithDefaultNamespace, WithEnsureNamespaces, WithCRDWaitAnnotations,
+// WithStableSort, WithKindOrdering, WithWebhooksLast, WithDependencyOrdering,
+// WithSchedulingClasses, WithRenderDigestAnnotation, WithCRVersionAlignment,
+// WithDeterminismCheck, WithSchemaValidation, WithPolicy, WithNamespacePolicy,
+// and merged renderers. With any of them, the render completes as in Process
+// before the first object is yielded. The objects are the same either way, but
+// renderer-level filters and transformers may run before later sources are
+// rendered.
+//
+// An error is yielded once, with the zero object, and ends the sequence.
+// Objects yielded before it are part of a failed render, so callers applying
+// them as they arrive must handle a partial result. Like Process,
+// ProcessStream records the render in Stats, and discards warnings.
+func (r *Renderer) ProcessStream(ctx context.Context, values types.Values) iter.Seq2[unstructured.Unstructured, error] {
+	return func(yield func(unstructured.Unstructured, error) bool) {
+		clock := startClock()
+
+		ctx, release, err := r.admit(ctx)
+		if err != nil {
+			r.stats.record(clock.start, clock.elapsed(), 0, err)
+			yield(unstructured.Unstructured{}, r.nameError(err))
+
+			return
+		}
+
+		defer release()
+
+		ctx, warnings := withWarnings(ctx)
+		trace := r.newTrace(false)
+
+		if !r.streamable(trace.inputs) {
+			objects, err := r.process(ctx, values, trace)
+			if err == nil {
+				err = r.verifyDeterminism(ctx, values, trace.inputs, objects)
+			}
+
+			result, err := r.complete(ctx, objects, err, trace, warnings, clock)
+			if err != nil {
+				yield(unstructured.Unstructured{}, err)
+
+				return
+			}
+
+			for _, obj := range result.objects {
+				if !yield(obj, nil) {
+					return
+				}
+			}
+
+			return
+		}
+
+		count, err := r.stream(ctx, values, trace, clock.start, yield)
+		if err != nil {
+			count = 0
+		}
+
+		r.stats.record(clock.start, clock.elapsed(), count, err)
+
+		if err != nil {
+			yield(unstructured.Unstructured{}, r.nameError(err))
+		}
+	}
+}
+
+// ProcessEach renders like ProcessStream and calls fn with each rendered
+// object, in order, as soon as it is complete. It stops at the first error,
+// either of the render or returned by fn, and returns it unwrapped. Objects
+// passed to fn before an error belong to a failed render, as in ProcessStream.
+func (r *Renderer) ProcessEach(
+	ctx context.Context,
+	values types.Values,
+	fn func(obj unstructured.Unstructured) error,
+) error {
+	for obj, err := range r.ProcessStream(ctx, values) {
+		if err != nil {
+			return err
+		}
+
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// streamable reports whether a render of inputs can stream, i.e. no stage
+// needs the whole set of rendered objects.
+func (r *Renderer) streamable(inputs []*sourceHolder) bool {
+	if r.merged != nil || len(r.opts.PostRenderers) > 0 ||
+		r.opts.DefaultNamespace != "" || r.opts.EnsureNamespaces || r.opts.CRDWaitAnnotations ||
+		r.opts.StableSort || len(r.opts.KindOrder) > 0 || r.opts.WebhooksLast || r.opts.DependencyOrdering ||
+		r.opts.SchedulingClasses != nil || r.opts.RenderDigest ||
+		r.opts.CRVersionAlignment != nil || r.opts.DeterminismCheck > 1 ||
+		len(r.opts.SchemaValidators) > 0 || len(r.opts.Policies) > 0 || r.opts.NamespacePolicy != nil {
+		return false
+	}
+
+	if r.opts.DuplicatePolicy != "" && r.opts.DuplicatePolicy != DuplicateKeepAll {
+		return false
+	}
+
+	for _, holder := range inputs {
+		if len(holder.Patches) > 0 || len(holder.Deletions) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stream renders the sources of trace one after another and yields each
+// rendered object once it passed the renderer-level chain and the final pass,
+// which then run on one object at a time, stamping every object with now as
+// the render timestamp. It returns the number of objects yielded, stopping
+// early when yield returns false.
+func (r *Renderer) stream(
+	ctx context.Context,
+	values types.Values,
+	trace *renderTrace,
+	now time.Time,
+	yield func(unstructured.Unstructured, error) bool,
+) (int, error) {
+	defer guardInputs(trace.inputs)()
+
+	trace.sources = len(trace.inputs)
+	count := 0
+
+	for i := range trace.inputs {
+		sourceObjects, selected, err := r.renderInput(ctx, values, i, trace)
+		if err != nil {
+			return count, err
+		}
+
+		if !selected {
+			continue
+		}
+
+		trace.collected += len(sourceObjects)
+
+		for j := range sourceObjects {
+			objects, err := r.applyChain(ctx, sourceObjects[j:j+1])
+			if err != nil {
+				return count, err
+			}
+
+			objects, _, err = r.stamp(ctx, objects, now)
+			if err != nil {
+				return count, err
+			}
+
+			for _, obj := range objects {
+				count++
+
+				if !yield(obj, nil) {
+					return count, nil
+				}
+			}
+
+			// The object is yielded and no longer needed by the render.
+			sourceObjects[j] = unstructured.Unstructured{}
+		}
+	}
+
+	if count == 0 && r.opts.FailOnEmpty {
+		return 0, trace.emptyError()
+	}
+
+	return count, nil
+}
@@ -0,0 +1,299 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func streamSources() []mem.Source {
+	return []mem.Source{
+		{Objects: []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "app", "first"),
+			composeObject("v1", "Secret", "app", "skipped"),
+		}},
+		{Objects: []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "other", "second"),
+		}},
+	}
+}
+
+func collectStream(ctx context.Context, renderer *mem.Renderer) ([]unstructured.Unstructured, error) {
+	objects := make([]unstructured.Unstructured, 0)
+
+	for obj, err := range renderer.ProcessStream(ctx, nil) {
+		if err != nil {
+			return objects, err
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func TestProcessStreamWholeSetOptions(t *testing.T) {
+
+	deployment := mem.MustUnstructured(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: app
+spec:
+  template:
+    spec:
+      priorityClassName: hi
+`)
+	priorityClass := mem.MustUnstructured(
+		"apiVersion: scheduling.k8s.io/v1\nkind: PriorityClass\nmetadata:\n  name: hi\n")
+
+	sources := []mem.Source{
+		{Objects: []unstructured.Unstructured{deployment, composeObject("v1", "ConfigMap", "app", "b")}},
+		{Objects: []unstructured.Unstructured{priorityClass, composeObject("v1", "ConfigMap", "app", "a")}},
+	}
+
+	tests := map[string]mem.RendererOption{
+		"default namespace":    mem.WithDefaultNamespace("app"),
+		"ensure namespaces":    mem.WithEnsureNamespaces(true),
+		"CRD wait annotations": mem.WithCRDWaitAnnotations(),
+		"stable sort":          mem.WithStableSort(true),
+		"kind ordering":        mem.WithKindOrdering(mem.InstallOrder()),
+		"webhooks last":        mem.WithWebhooksLast(true),
+		"dependency ordering":  mem.WithDependencyOrdering(true),
+		"scheduling classes":   mem.WithSchedulingClasses("hi", "", nil),
+		"render digest":        mem.WithRenderDigestAnnotation(true),
+		"namespace policy":     mem.WithNamespacePolicy(mem.NamespacePolicy{RequireNamespace: true}),
+		"duplicate policy":     mem.WithDuplicatePolicy(mem.DuplicateKeepFirst),
+	}
+
+	for name, opt := range tests {
+		t.Run("should stream the objects of Process with "+name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			renderer, err := mem.New(sources, opt)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			expected, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			objects, err := collectStream(t.Context(), renderer)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(objects).To(Equal(expected))
+		})
+	}
+}
+
+func TestProcessStream(t *testing.T) {
+
+	opts := []mem.RendererOption{
+		mem.WithFilter(func(_ context.Context, obj unstructured.Unstructured) (bool, error) {
+			return obj.GetKind() != "Secret", nil
+		}),
+		mem.WithTransformer(func(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+			obj.SetLabels(map[string]string{"streamed": "true"})
+
+			return obj, nil
+		}),
+		mem.WithContentHash(true),
+		mem.WithGenerationAnnotation("7"),
+	}
+
+	t.Run("should yield the objects of Process", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(streamSources(), opts...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		expected, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := collectStream(t.Context(), renderer)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(Equal(expected))
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/first", "ConfigMap/second"}))
+
+		stats := renderer.Stats()
+		g.Expect(stats.Renders).To(Equal(uint64(2)))
+		g.Expect(stats.Objects).To(Equal(uint64(4)))
+	})
+
+	t.Run("should render later sources only when needed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		calls := 0
+		sources := append(streamSources(), mem.Source{
+			ObjectsFn: func(_ context.Context, _ pkgtypes.Values) ([]unstructured.Unstructured, error) {
+				calls++
+
+				return []unstructured.Unstructured{composeObject("v1", "ConfigMap", "app", "generated")}, nil
+			},
+		})
+
+		renderer, err := mem.New(sources)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for obj, err := range renderer.ProcessStream(t.Context(), nil) {
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(obj.GetName()).To(Equal("first"))
+
+			break
+		}
+
+		g.Expect(calls).To(Equal(0))
+	})
+
+	t.Run("should render the whole set when a stage needs it", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(streamSources(), append(opts, mem.WithEnsureNamespaces(true))...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		expected, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := collectStream(t.Context(), renderer)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(Equal(expected))
+		g.Expect(names(objects)).To(HaveLen(4))
+	})
+
+	t.Run("should yield errors once", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errBroken := errors.New("broken")
+		renderer, err := mem.New(streamSources(), mem.WithTransformer(
+			func(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+				if obj.GetName() == "second" {
+					return obj, errBroken
+				}
+
+				return obj, nil
+			},
+		))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		yielded := 0
+		failures := 0
+
+		for obj, err := range renderer.ProcessStream(t.Context(), nil) {
+			if err != nil {
+				g.Expect(err).To(MatchError(errBroken))
+				g.Expect(obj.Object).To(BeNil())
+
+				failures++
+
+				continue
+			}
+
+			yielded++
+		}
+
+		g.Expect(yielded).To(Equal(2))
+		g.Expect(failures).To(Equal(1))
+		g.Expect(renderer.Stats().Errors).To(Equal(uint64(1)))
+	})
+
+	t.Run("should stamp every object with the same render time", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// Rendering the second object in a later second than the first makes
+		// a timestamp taken per object visible.
+		renderer, err := mem.New(streamSources(), mem.WithProvenanceAnnotations(true), mem.WithTransformer(
+			func(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+				if obj.GetName() == "second" {
+					time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+				}
+
+				return obj, nil
+			},
+		))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := collectStream(t.Context(), renderer)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+
+		timestamp := objects[0].GetAnnotations()[mem.AnnotationRenderTimestamp]
+		g.Expect(timestamp).ToNot(BeEmpty())
+
+		for _, obj := range objects {
+			g.Expect(obj.GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationRenderTimestamp, timestamp))
+		}
+	})
+
+	t.Run("should fail empty renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{composeObject("v1", "Secret", "app", "skipped")}}},
+			append(opts, mem.WithFailOnEmpty(true))...,
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = collectStream(t.Context(), renderer)
+		g.Expect(err).To(MatchError(mem.ErrEmptyRender))
+	})
+}
+
+func TestProcessEach(t *testing.T) {
+
+	t.Run("should call fn with each object", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(streamSources())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects := make([]unstructured.Unstructured, 0)
+		g.Expect(renderer.ProcessEach(t.Context(), nil, func(obj unstructured.Unstructured) error {
+			objects = append(objects, obj)
+
+			return nil
+		})).To(Succeed())
+
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/first", "Secret/skipped", "ConfigMap/second"}))
+	})
+
+	t.Run("should stop at the first error of fn", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(streamSources())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		errApply := errors.New("apply failed")
+		calls := 0
+
+		err = renderer.ProcessEach(t.Context(), nil, func(_ unstructured.Unstructured) error {
+			calls++
+
+			return errApply
+		})
+		g.Expect(err).To(Equal(errApply))
+		g.Expect(calls).To(Equal(1))
+	})
+
+	t.Run("should return render errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(streamSources(), mem.WithFailOnEmpty(true), mem.WithFilter(
+			func(_ context.Context, _ unstructured.Unstructured) (bool, error) {
+				return false, nil
+			},
+		))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = renderer.ProcessEach(t.Context(), nil, func(_ unstructured.Unstructured) error {
+			return nil
+		})
+		g.Expect(err).To(MatchError(mem.ErrEmptyRender))
+	})
+}
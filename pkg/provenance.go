@@ -0,0 +1,108 @@
+package mem
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AnnotationSourceChain is the annotation key for the ordered list of renderers
+// an object passed through. It is only written when a rendered object is fed
+// back into a mem Source, i.e. when the object already carries provenance.
+const AnnotationSourceChain = "manifests.k8s-manifests-kit/source.chain"
+
+// AnnotationSourceRenderer is the annotation key for the name set by WithName
+// on the mem renderer that annotated an object.
+const AnnotationSourceRenderer = "manifests.k8s-manifests-kit/source.renderer"
+
+// SourceHop describes one renderer an object passed through.
+type SourceHop struct {
+	// Type is the renderer type, as in the source.type annotation.
+	Type string `json:"type"`
+
+	// Name is the name of a mem renderer set by WithName, if any.
+	Name string `json:"name,omitempty"`
+
+	// Path is the source path or chart identifier, if the renderer recorded one.
+	Path string `json:"path,omitempty"`
+
+	// File is the specific template file, if the renderer recorded one.
+	File string `json:"file,omitempty"`
+}
+
+// SourceChain returns the provenance chain of obj, oldest hop first.
+// Objects annotated by a single renderer yield a one-hop chain; objects
+// without source annotations yield an empty chain.
+func SourceChain(obj unstructured.Unstructured) ([]SourceHop, error) {
+	return sourceChainOf(obj.GetAnnotations())
+}
+
+// sourceChainOf reads the chain annotation and completes it with the hop
+// described by the single-hop source annotations, unless that hop is already
+// the last one recorded.
+func sourceChainOf(annotations map[string]string) ([]SourceHop, error) {
+	chain := make([]SourceHop, 0)
+
+	if raw, ok := annotations[AnnotationSourceChain]; ok {
+		if err := json.Unmarshal([]byte(raw), &chain); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSourceChain, err)
+		}
+	}
+
+	current := SourceHop{
+		Type: annotations[types.AnnotationSourceType],
+		Name: annotations[AnnotationSourceRenderer],
+		Path: annotations[types.AnnotationSourcePath],
+		File: annotations[types.AnnotationSourceFile],
+	}
+
+	if current.Type != "" && (len(chain) == 0 || chain[len(chain)-1] != current) {
+		chain = append(chain, current)
+	}
+
+	return chain, nil
+}
+
+// appendSourceHop records the renderer named name (empty if it has no name
+// set by WithName) as the latest hop of the object's provenance. Objects
+// without prior provenance only get the source type and name, as before;
+// re-ingested objects additionally get their chain extended, and the path and
+// file annotations of the previous hop are removed since they do not describe
+// a mem source.
+func appendSourceHop(obj *unstructured.Unstructured, name string) error {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	chain, err := sourceChainOf(annotations)
+	if err != nil {
+		return err
+	}
+
+	if len(chain) > 0 {
+		data, err := json.Marshal(append(chain, SourceHop{Type: rendererType, Name: name}))
+		if err != nil {
+			return fmt.Errorf("unable to encode source chain: %w", err)
+		}
+
+		annotations[AnnotationSourceChain] = string(data)
+		delete(annotations, types.AnnotationSourcePath)
+		delete(annotations, types.AnnotationSourceFile)
+	}
+
+	annotations[types.AnnotationSourceType] = rendererType
+
+	if name != "" {
+		annotations[AnnotationSourceRenderer] = name
+	} else {
+		delete(annotations, AnnotationSourceRenderer)
+	}
+
+	obj.SetAnnotations(annotations)
+
+	return nil
+}
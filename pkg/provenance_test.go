@@ -0,0 +1,155 @@
+package mem_test
+
+import (
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func renderAnnotated(t *testing.T, g Gomega, objects ...unstructured.Unstructured) []unstructured.Unstructured {
+	t.Helper()
+
+	renderer, err := mem.New([]mem.Source{{Objects: objects}}, mem.WithSourceAnnotations(true))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	result, err := renderer.Process(t.Context(), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return result
+}
+
+func TestSourceChain(t *testing.T) {
+
+	t.Run("should not add a chain to objects without provenance", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := renderAnnotated(t, g, composeObject("v1", "ConfigMap", "default", "a"))
+
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(types.AnnotationSourceType, "mem"))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationSourceChain))
+
+		chain, err := mem.SourceChain(objects[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(chain).To(Equal([]mem.SourceHop{{Type: "mem"}}))
+	})
+
+	t.Run("should append a hop when rendered objects are re-ingested", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first := renderAnnotated(t, g, composeObject("v1", "ConfigMap", "default", "a"))
+		second := renderAnnotated(t, g, first...)
+		third := renderAnnotated(t, g, second...)
+
+		chain, err := mem.SourceChain(third[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(chain).To(Equal([]mem.SourceHop{{Type: "mem"}, {Type: "mem"}, {Type: "mem"}}))
+	})
+
+	t.Run("should preserve the path and file of other renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := composeObject("v1", "ConfigMap", "default", "a")
+		obj.SetAnnotations(map[string]string{
+			types.AnnotationSourceType: "helm",
+			types.AnnotationSourcePath: "oci://registry/chart",
+			types.AnnotationSourceFile: "templates/cm.yaml",
+		})
+
+		objects := renderAnnotated(t, g, obj)
+
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(types.AnnotationSourceType, "mem"))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(types.AnnotationSourcePath))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(types.AnnotationSourceFile))
+
+		chain, err := mem.SourceChain(objects[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(chain).To(Equal([]mem.SourceHop{
+			{Type: "helm", Path: "oci://registry/chart", File: "templates/cm.yaml"},
+			{Type: "mem"},
+		}))
+	})
+
+	t.Run("should record hops of renderers that ignore the chain", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := renderAnnotated(t, g, renderAnnotated(t, g, composeObject("v1", "ConfigMap", "default", "a"))...)
+
+		annotations := objects[0].GetAnnotations()
+		annotations[types.AnnotationSourceType] = "yaml"
+		annotations[types.AnnotationSourcePath] = "manifests"
+		objects[0].SetAnnotations(annotations)
+
+		chain, err := mem.SourceChain(renderAnnotated(t, g, objects...)[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(chain).To(Equal([]mem.SourceHop{
+			{Type: "mem"}, {Type: "mem"}, {Type: "yaml", Path: "manifests"}, {Type: "mem"},
+		}))
+	})
+
+	t.Run("should leave provenance alone without source annotations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := composeObject("v1", "ConfigMap", "default", "a")
+		obj.SetAnnotations(map[string]string{types.AnnotationSourceType: "helm"})
+
+		renderer, err := mem.New([]mem.Source{{Objects: []unstructured.Unstructured{obj}}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(types.AnnotationSourceType, "helm"))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationSourceChain))
+	})
+
+	t.Run("should record the names of named renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first := renderAnnotated(t, g, composeObject("v1", "ConfigMap", "default", "a"))
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: first}},
+			mem.WithSourceAnnotations(true),
+			mem.WithName("mem/platform-base"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		second, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(second[0].GetAnnotations()).To(HaveKeyWithValue(types.AnnotationSourceType, "mem"))
+		g.Expect(second[0].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationSourceRenderer, "mem/platform-base"))
+
+		third := renderAnnotated(t, g, second...)
+		g.Expect(third[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationSourceRenderer))
+
+		chain, err := mem.SourceChain(third[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(chain).To(Equal([]mem.SourceHop{
+			{Type: "mem"}, {Type: "mem", Name: "mem/platform-base"}, {Type: "mem"},
+		}))
+	})
+
+	t.Run("should reject a malformed chain", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := composeObject("v1", "ConfigMap", "default", "a")
+		obj.SetAnnotations(map[string]string{mem.AnnotationSourceChain: "not json"})
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{obj}}},
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(mem.ErrInvalidSourceChain))
+
+		_, err = mem.SourceChain(obj)
+		g.Expect(err).To(MatchError(mem.ErrInvalidSourceChain))
+	})
+}
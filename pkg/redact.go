@@ -0,0 +1,237 @@
+package mem
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/k8s-manifest-kit/pkg/util"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RedactedValue replaces the values masked by redaction rules.
+const RedactedValue = "REDACTED"
+
+// ExportOption is a generic option for ExportOptions.
+type ExportOption = util.Option[ExportOptions]
+
+// ExportOptions configures how WriteManifests, WriteMatrix, WriteSnapshot,
+// and CanonicalJSON write objects.
+type ExportOptions struct {
+	// Redactions mask sensitive fields of the written objects.
+	Redactions []RedactionRule
+}
+
+// ApplyTo applies the export options to the target configuration.
+func (opts ExportOptions) ApplyTo(target *ExportOptions) {
+	target.Redactions = append(target.Redactions, opts.Redactions...)
+}
+
+// WithExportRedaction masks the fields selected by rules in everything the
+// exporters write, so that Secret data or credentials passed as environment
+// variables do not end up in files, archives, or logs. Only the written copies
+// are masked: the objects passed to the exporters, and the renders they come
+// from, keep their real values. Calling it several times accumulates rules.
+func WithExportRedaction(rules ...RedactionRule) ExportOption {
+	return util.FunctionalOption[ExportOptions](func(opts *ExportOptions) {
+		opts.Redactions = append(opts.Redactions, rules...)
+	})
+}
+
+// RedactionRule selects fields to mask in exported objects.
+type RedactionRule struct {
+	// Target selects the objects the rule applies to; the zero target
+	// selects every object.
+	Target PatchTarget `json:"target,omitempty"`
+
+	// Paths are the dot-separated paths of the fields to mask, e.g. "data"
+	// or "spec.password"; like those of WithContentHashIgnore, map keys may
+	// contain dots. The values of a map are masked one by one, so exports
+	// still show which keys a Secret has; other values are replaced whole.
+	Paths []string `json:"paths,omitempty"`
+
+	// EnvNames, if set, is a regular expression matched against the names of
+	// the environment variables of workload containers, init containers, and
+	// ephemeral containers; the values of those it matches are masked.
+	// Variables set with valueFrom only reference their source and are kept.
+	EnvNames string `json:"envNames,omitempty"`
+}
+
+// SecretRedaction returns a rule masking the data and stringData of Secrets.
+func SecretRedaction() RedactionRule {
+	return RedactionRule{
+		Target: PatchTarget{Kind: "Secret"},
+		Paths:  []string{"data", "stringData"},
+	}
+}
+
+// redactor applies validated redaction rules.
+type redactor struct {
+	rules    []RedactionRule
+	envNames []*regexp.Regexp
+}
+
+// newRedactor validates the redaction rules of opts. It returns nil if there
+// are none.
+func newRedactor(opts []ExportOption) (*redactor, error) {
+	var exportOpts ExportOptions
+	for _, opt := range opts {
+		opt.ApplyTo(&exportOpts)
+	}
+
+	if len(exportOpts.Redactions) == 0 {
+		return nil, nil
+	}
+
+	r := &redactor{
+		rules:    exportOpts.Redactions,
+		envNames: make([]*regexp.Regexp, len(exportOpts.Redactions)),
+	}
+
+	for i, rule := range exportOpts.Redactions {
+		if len(rule.Paths) == 0 && rule.EnvNames == "" {
+			return nil, fmt.Errorf("%w at index %d: no paths or env names", ErrInvalidRedactionRule, i)
+		}
+
+		for _, path := range rule.Paths {
+			if err := validateFieldPath(path); err != nil {
+				return nil, fmt.Errorf("%w at index %d: %w", ErrInvalidRedactionRule, i, err)
+			}
+		}
+
+		if rule.EnvNames == "" {
+			continue
+		}
+
+		pattern, err := regexp.Compile(rule.EnvNames)
+		if err != nil {
+			return nil, fmt.Errorf("%w at index %d: %w", ErrInvalidRedactionRule, i, err)
+		}
+
+		r.envNames[i] = pattern
+	}
+
+	return r, nil
+}
+
+// redact returns obj with the fields selected by the rules masked. obj is not
+// modified: it is returned as is if no rule applies, and copied otherwise.
+func (r *redactor) redact(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if r == nil {
+		return obj, nil
+	}
+
+	redacted := obj
+
+	for i, rule := range r.rules {
+		if !rule.Target.Matches(*obj) {
+			continue
+		}
+
+		if redacted == obj {
+			redacted = obj.DeepCopy()
+		}
+
+		for _, path := range rule.Paths {
+			maskFieldPath(redacted.Object, path)
+		}
+
+		if r.envNames[i] != nil {
+			if err := maskEnv(redacted, r.envNames[i]); err != nil {
+				return nil, fmt.Errorf("failed to redact %s: %w", ObjectKeyOf(*obj), err)
+			}
+		}
+	}
+
+	return redacted, nil
+}
+
+// redactAll returns objects with the fields selected by the rules masked,
+// sharing the objects no rule applies to.
+func (r *redactor) redactAll(objects []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	if r == nil {
+		return objects, nil
+	}
+
+	redacted := make([]unstructured.Unstructured, len(objects))
+	for i := range objects {
+		obj, err := r.redact(&objects[i])
+		if err != nil {
+			return nil, err
+		}
+
+		redacted[i] = *obj
+	}
+
+	return redacted, nil
+}
+
+// maskFieldPath masks the field at path in content, as found by hasFieldPath.
+func maskFieldPath(content map[string]any, path string) {
+	if value, ok := content[path]; ok {
+		content[path] = masked(value)
+
+		return
+	}
+
+	for i := range len(path) {
+		if path[i] != '.' {
+			continue
+		}
+
+		if child, ok := content[path[:i]].(map[string]any); ok && hasFieldPath(child, path[i+1:]) {
+			maskFieldPath(child, path[i+1:])
+
+			return
+		}
+	}
+}
+
+// masked returns value with its content replaced by RedactedValue. Null
+// values are kept, since they hold nothing to hide.
+func masked(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		for key, item := range v {
+			if item != nil {
+				v[key] = RedactedValue
+			}
+		}
+
+		return v
+	default:
+		return RedactedValue
+	}
+}
+
+// maskEnv masks the values of the environment variables of the containers of
+// the workload obj whose names match pattern.
+func maskEnv(obj *unstructured.Unstructured, pattern *regexp.Regexp) error {
+	podSpec, err := lookupPodSpec(obj)
+	if err != nil || podSpec == nil {
+		return err
+	}
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _ := podSpec[field].([]any)
+		for _, container := range containers {
+			c, _ := container.(map[string]any)
+			env, _ := c["env"].([]any)
+
+			for _, variable := range env {
+				v, _ := variable.(map[string]any)
+				if name, _ := v["name"].(string); !pattern.MatchString(name) {
+					continue
+				}
+
+				if _, ok := v["value"]; ok {
+					v["value"] = RedactedValue
+				}
+			}
+		}
+	}
+
+	return nil
+}
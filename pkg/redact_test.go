@@ -0,0 +1,178 @@
+package mem_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func redactionSecret() unstructured.Unstructured {
+	secret := composeObject("v1", "Secret", "app", "credentials")
+	secret.Object["data"] = map[string]any{"password": "c2VjcmV0", "empty": nil}
+	secret.Object["stringData"] = map[string]any{"token": "t0ken"}
+
+	return secret
+}
+
+func redactionDeployment() unstructured.Unstructured {
+	deployment := composeObject("apps/v1", "Deployment", "app", "web")
+	deployment.Object["spec"] = map[string]any{
+		"template": map[string]any{
+			"spec": map[string]any{
+				"initContainers": []any{map[string]any{
+					"name": "migrate",
+					"env":  []any{map[string]any{"name": "DB_PASSWORD", "value": "hunter2"}},
+				}},
+				"containers": []any{map[string]any{
+					"name": "web",
+					"env": []any{
+						map[string]any{"name": "API_TOKEN", "value": "t0ken"},
+						map[string]any{"name": "LOG_LEVEL", "value": "debug"},
+						map[string]any{"name": "DB_PASSWORD", "valueFrom": map[string]any{
+							"secretKeyRef": map[string]any{"name": "credentials", "key": "password"},
+						}},
+					},
+				}},
+			},
+		},
+	}
+
+	return deployment
+}
+
+func containerEnv(g Gomega, obj map[string]any, field string) []any {
+	containers, _, err := unstructured.NestedSlice(obj, "spec", "template", "spec", field)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(containers).To(HaveLen(1))
+
+	env, _, err := unstructured.NestedSlice(containers[0].(map[string]any), "env")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return env
+}
+
+func TestWithExportRedaction(t *testing.T) {
+
+	envRule := mem.RedactionRule{EnvNames: `(PASSWORD|TOKEN)$`}
+
+	t.Run("should mask Secret values in written manifests only", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{redactionSecret(), cachedConfig("a")}
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteManifests(&buf, objects, mem.WithExportRedaction(mem.SecretRedaction()))).To(Succeed())
+
+		written := decodeAsKubectl(g, buf.Bytes())
+		g.Expect(written).To(HaveLen(2))
+		g.Expect(written[0].Object["data"]).To(HaveKeyWithValue("password", mem.RedactedValue))
+		g.Expect(written[0].Object["stringData"]).To(HaveKeyWithValue("token", mem.RedactedValue))
+		g.Expect(written[1].Object["data"]).To(HaveKeyWithValue("key", "a"))
+
+		g.Expect(objects[0]).To(Equal(redactionSecret()))
+	})
+
+	t.Run("should mask matching environment variables of workloads", func(t *testing.T) {
+		g := NewWithT(t)
+
+		deployment := redactionDeployment()
+
+		data, err := mem.CanonicalJSON(deployment, mem.WithExportRedaction(envRule))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var written map[string]any
+		g.Expect(yaml.Unmarshal(data, &written)).To(Succeed())
+
+		g.Expect(containerEnv(g, written, "initContainers")).To(ConsistOf(
+			map[string]any{"name": "DB_PASSWORD", "value": mem.RedactedValue},
+		))
+		g.Expect(containerEnv(g, written, "containers")).To(ConsistOf(
+			map[string]any{"name": "API_TOKEN", "value": mem.RedactedValue},
+			map[string]any{"name": "LOG_LEVEL", "value": "debug"},
+			HaveKeyWithValue("valueFrom", HaveKey("secretKeyRef")),
+		))
+
+		g.Expect(deployment).To(Equal(redactionDeployment()))
+	})
+
+	t.Run("should mask fields by path within the target", func(t *testing.T) {
+		g := NewWithT(t)
+
+		configMap := cachedConfig("a")
+		configMap.SetAnnotations(map[string]string{"example.com/token": "t0ken"})
+
+		rule := mem.RedactionRule{
+			Target: mem.PatchTarget{Kind: "ConfigMap", Name: "config"},
+			Paths:  []string{"metadata.annotations.example.com/token", "data.missing"},
+		}
+
+		data, err := mem.CanonicalJSON(configMap, mem.WithExportRedaction(rule))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(data)).To(ContainSubstring(`"example.com/token":"REDACTED"`))
+		g.Expect(string(data)).To(ContainSubstring(`"data":{"key":"a"}`))
+
+		rule.Target.Name = "other"
+
+		data, err = mem.CanonicalJSON(configMap, mem.WithExportRedaction(rule))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(data)).To(ContainSubstring(`"example.com/token":"t0ken"`))
+	})
+
+	t.Run("should write snapshots that verify with masked values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{redactionSecret(), redactionDeployment()}
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteSnapshot(&buf, objects, mem.WithExportRedaction(mem.SecretRedaction(), envRule))).
+			To(Succeed())
+
+		source, err := mem.SourceFromSnapshot(&buf)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Objects[0].Object["data"]).
+			To(Equal(map[string]any{"password": mem.RedactedValue, "empty": nil}))
+		g.Expect(containerEnv(g, source.Objects[1].Object, "initContainers")).To(ConsistOf(
+			HaveKeyWithValue("value", mem.RedactedValue),
+		))
+
+		g.Expect(objects).To(Equal([]unstructured.Unstructured{redactionSecret(), redactionDeployment()}))
+	})
+
+	t.Run("should mask matrix files", func(t *testing.T) {
+		g := NewWithT(t)
+
+		dir := t.TempDir()
+		outputs := map[string][]unstructured.Unstructured{"prod": {redactionSecret()}}
+
+		g.Expect(mem.WriteMatrix(dir, outputs, mem.WithExportRedaction(mem.SecretRedaction()))).To(Succeed())
+
+		data, err := os.ReadFile(filepath.Join(dir, "prod", "secret_app_credentials.yaml"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(data)).To(ContainSubstring("password: " + mem.RedactedValue))
+		g.Expect(string(data)).ToNot(ContainSubstring("c2VjcmV0"))
+	})
+
+	t.Run("should reject invalid rules", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, rule := range []mem.RedactionRule{
+			{Target: mem.PatchTarget{Kind: "Secret"}},
+			{Paths: []string{"data..key"}},
+			{EnvNames: "("},
+		} {
+			var buf bytes.Buffer
+			err := mem.WriteManifests(&buf, []unstructured.Unstructured{redactionSecret()},
+				mem.WithExportRedaction(rule))
+			g.Expect(err).To(MatchError(mem.ErrInvalidRedactionRule))
+			g.Expect(buf.Len()).To(BeZero())
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package mem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Result is the rich outcome of a render: the objects together with the
+// warnings, per-render information, and provenance that Process drops.
+//
+// A Result holds one copy of the objects for consumers that only read them,
+// such as an applier, a differ, and an audit log handling the same render.
+// Handing each of them a View avoids deep copying the whole slice per
+// consumer; a consumer that needs to modify an object copies just that one.
+type Result struct {
+	objects  []unstructured.Unstructured
+	warnings []Warning
+	info     RenderInfo
+
+	provenance []*Provenance
+	migrations []Migration
+	deletions  []unstructured.Unstructured
+}
+
+// RenderInfo describes a single render.
+type RenderInfo struct {
+	// Renderer is the name of the renderer, as returned by Renderer.Name.
+	Renderer string
+
+	// Duration is how long the render took.
+	Duration time.Duration
+
+	// Sources is the number of sources (or merged renderers) of the renderer.
+	Sources int
+
+	// Selected is the number of sources accepted by the source selectors.
+	Selected int
+
+	// Collected is the number of objects the selected sources produced,
+	// before the renderer-level filters, transformers, and post-renderers ran.
+	Collected int
+
+	// Cached reports that the render was served by WithRenderCache; the
+	// other counts are those of the cached render.
+	Cached bool
+}
+
+// Provenance tells which source produced a rendered object.
+type Provenance struct {
+	// Source is the index of the source in the renderer's inputs.
+	Source int
+
+	// Name is the name of the source, if it has one.
+	Name string
+
+	// Position locates the document the object was decoded from, if the
+	// source records positions.
+	Position *Position
+
+	// Builder is the function that built the object, for sources created by
+	// SourceOf.
+	Builder string
+}
+
+// NewResult wraps objects in a Result without warnings, information, or
+// provenance. The Result takes ownership of objects: the caller must not
+// modify them afterwards.
+func NewResult(objects []unstructured.Unstructured) *Result {
+	return &Result{objects: objects}
+}
+
+// ProcessResult renders like Process and returns a rich Result. It is the
+// richer counterpart of Process, which is a thin wrapper around the same
+// render returning only the objects; both record the render in Stats.
+func (r *Renderer) ProcessResult(ctx context.Context, values types.Values) (*Result, error) {
+	return r.render(ctx, values, true)
+}
+
+// Warnings returns the warnings reported during the render, in order.
+func (r *Result) Warnings() []Warning {
+	return slices.Clone(r.warnings)
+}
+
+// Deletions returns copies of the deletions of the selected sources, one per
+// identity, in source order. Deletions of the parts of a merged renderer are
+// not reported.
+func (r *Result) Deletions() []unstructured.Unstructured {
+	deletions := make([]unstructured.Unstructured, len(r.deletions))
+	for i := range r.deletions {
+		r.deletions[i].DeepCopyInto(&deletions[i])
+	}
+
+	return deletions
+}
+
+// Migrations returns the objects rewritten by WithGVKRewrite, in render
+// order. Objects rewritten by the parts of a merged renderer are not reported.
+func (r *Result) Migrations() []Migration {
+	return slices.Clone(r.migrations)
+}
+
+// Info returns information about the render.
+func (r *Result) Info() RenderInfo {
+	return r.info
+}
+
+// Provenance returns which source produced the i-th object. Provenance is
+// tracked per object rather than by identity, so objects sharing an identity
+// are each credited to their own source, and renamed objects keep theirs. It
+// reports false for objects added by post-renderers or the final pass, objects
+// whose annotations a transformer replaced wholesale, deletion markers, and
+// objects of merged renderers.
+func (r *Result) Provenance(i int) (Provenance, bool) {
+	if i < 0 || i >= len(r.provenance) || r.provenance[i] == nil {
+		return Provenance{}, false
+	}
+
+	return *r.provenance[i], true
+}
+
+// View returns a read-only view of the result. Views are cheap and safe for
+// concurrent use.
+func (r *Result) View() View {
+	return View{objects: r.objects}
+}
+
+// View gives read-only access to the objects of a Result.
+type View struct {
+	objects []unstructured.Unstructured
+}
+
+// Len returns the number of objects.
+func (v View) Len() int {
+	return len(v.objects)
+}
+
+// At returns the object at index i. It panics if i is out of range.
+func (v View) At(i int) ObjectView {
+	return ObjectView{obj: &v.objects[i]}
+}
+
+// All iterates over the objects in order.
+func (v View) All() iter.Seq2[int, ObjectView] {
+	return func(yield func(int, ObjectView) bool) {
+		for i := range v.objects {
+			if !yield(i, v.At(i)) {
+				return
+			}
+		}
+	}
+}
+
+// DeepCopy returns a copy of all objects the caller owns.
+func (v View) DeepCopy() []unstructured.Unstructured {
+	objects := make([]unstructured.Unstructured, len(v.objects))
+	for i := range v.objects {
+		v.objects[i].DeepCopyInto(&objects[i])
+	}
+
+	return objects
+}
+
+// ObjectView gives read-only access to one object of a Result. Accessors
+// return copies, so nothing obtained from them aliases the shared object.
+type ObjectView struct {
+	obj *unstructured.Unstructured
+}
+
+// GetAPIVersion returns the object's API version.
+func (o ObjectView) GetAPIVersion() string {
+	return o.obj.GetAPIVersion()
+}
+
+// GetKind returns the object's kind.
+func (o ObjectView) GetKind() string {
+	return o.obj.GetKind()
+}
+
+// GroupVersionKind returns the object's group, version, and kind.
+func (o ObjectView) GroupVersionKind() schema.GroupVersionKind {
+	return o.obj.GroupVersionKind()
+}
+
+// GetNamespace returns the object's namespace.
+func (o ObjectView) GetNamespace() string {
+	return o.obj.GetNamespace()
+}
+
+// GetName returns the object's name.
+func (o ObjectView) GetName() string {
+	return o.obj.GetName()
+}
+
+// GetLabels returns a copy of the object's labels.
+func (o ObjectView) GetLabels() map[string]string {
+	return o.obj.GetLabels()
+}
+
+// GetAnnotations returns a copy of the object's annotations.
+func (o ObjectView) GetAnnotations() map[string]string {
+	return o.obj.GetAnnotations()
+}
+
+// NestedField returns a copy of the value at the path given by fields, and
+// whether it was found.
+func (o ObjectView) NestedField(fields ...string) (any, bool) {
+	value, found, err := unstructured.NestedFieldNoCopy(o.obj.Object, fields...)
+	if err != nil || !found {
+		return nil, false
+	}
+
+	return runtime.DeepCopyJSONValue(value), true
+}
+
+// DeepCopy returns a copy of the object the caller owns.
+func (o ObjectView) DeepCopy() unstructured.Unstructured {
+	return *o.obj.DeepCopy()
+}
+
+// MarshalJSON encodes the object without copying it.
+func (o ObjectView) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(o.obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", ObjectKeyOf(*o.obj), err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,261 @@
+package mem_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestResultView(t *testing.T) {
+
+	render := func(t *testing.T, g *WithT) *mem.Result {
+		t.Helper()
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(multiDocYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return result
+	}
+
+	t.Run("should expose the rendered objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		view := render(t, g).View()
+		g.Expect(view.Len()).To(Equal(2))
+		g.Expect(view.At(0).GetKind()).To(Equal("ConfigMap"))
+		g.Expect(view.At(1).GroupVersionKind().Group).To(Equal("apps"))
+		g.Expect(view.At(1).GetName()).To(Equal("second"))
+
+		replicas, found := view.At(1).NestedField("spec", "replicas")
+		g.Expect(found).To(BeTrue())
+		g.Expect(replicas).To(BeEquivalentTo(3))
+
+		_, found = view.At(1).NestedField("spec", "missing")
+		g.Expect(found).To(BeFalse())
+
+		var kinds []string
+		for _, obj := range view.All() {
+			kinds = append(kinds, obj.GetKind())
+		}
+
+		g.Expect(kinds).To(Equal([]string{"ConfigMap", "Deployment"}))
+	})
+
+	t.Run("should not let accessors modify the shared objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result := render(t, g)
+		view := result.View()
+
+		view.At(0).GetAnnotations()["injected"] = "true"
+
+		spec, _ := view.At(1).NestedField("spec")
+		spec.(map[string]any)["replicas"] = int64(10)
+
+		copied := view.At(1).DeepCopy()
+		copied.SetName("changed")
+
+		all := view.DeepCopy()
+		all[0].SetName("changed")
+
+		other := result.View()
+		g.Expect(other.At(0).GetAnnotations()).ToNot(HaveKey("injected"))
+		g.Expect(other.At(0).GetName()).To(Equal("first"))
+		g.Expect(other.At(1).GetName()).To(Equal("second"))
+
+		replicas, _ := other.At(1).NestedField("spec", "replicas")
+		g.Expect(replicas).To(BeEquivalentTo(3))
+	})
+
+	t.Run("should encode objects as JSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		view := render(t, g).View()
+
+		data, err := json.Marshal(view.At(0))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		decoded := make(map[string]any)
+		g.Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+		g.Expect(decoded).To(HaveKeyWithValue("kind", "ConfigMap"))
+	})
+
+	t.Run("should serve concurrent consumers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result := render(t, g)
+
+		var wg sync.WaitGroup
+
+		for range 8 {
+			wg.Go(func() {
+				view := result.View()
+				for _, obj := range view.All() {
+					_ = obj.GetAnnotations()
+					_, _ = obj.NestedField("metadata")
+					_, _ = json.Marshal(obj)
+				}
+			})
+		}
+
+		wg.Wait()
+		g.Expect(mem.ViewConcurrency).To(Equal(mem.ConcurrencySafe))
+	})
+}
+
+func TestProcessResult(t *testing.T) {
+
+	t.Run("should carry the same objects as Process", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(multiDocYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().DeepCopy()).To(Equal(objects))
+		g.Expect(renderer.Stats().Renders).To(BeEquivalentTo(2))
+	})
+
+	t.Run("should report render information", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(multiDocYAML), mem.MustSourceFromYAML(configMapYAML)},
+			mem.WithFilter(func(_ context.Context, obj unstructured.Unstructured) (bool, error) {
+				return obj.GetKind() != "Deployment", nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(Equal(2))
+
+		info := result.Info()
+		g.Expect(info.Sources).To(Equal(2))
+		g.Expect(info.Selected).To(Equal(2))
+		g.Expect(info.Collected).To(Equal(3))
+		g.Expect(info.Duration).To(BeNumerically(">", 0))
+	})
+
+	t.Run("should collect warnings from callbacks and sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured, 1)
+		ch <- mem.MustUnstructured(configMapYAML)
+
+		source := mem.MustSourceFromYAML(multiDocYAML)
+		source.Positions = source.Positions[:1]
+
+		renderer, err := mem.New(
+			[]mem.Source{source, {Stream: mem.NewChannelStream(ch, mem.StreamSnapshot)}},
+			mem.WithTransformer(func(
+				ctx context.Context,
+				obj unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				mem.Warnf(ctx, "%s %s looks odd", obj.GetKind(), obj.GetName())
+
+				return obj, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		messages := make([]string, 0)
+		for _, w := range result.Warnings() {
+			messages = append(messages, w.Message)
+		}
+
+		g.Expect(messages).To(Equal([]string{
+			"source 0 has 1 positions for 2 objects; objects beyond the positions have none",
+			"source 1: stream is not complete; rendered the 1 objects received so far",
+			"ConfigMap first looks odd",
+			"Deployment second looks odd",
+			"ConfigMap test-config looks odd",
+		}))
+	})
+
+	t.Run("should collect warnings of merged renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		warn := mem.WithTransformer(func(
+			ctx context.Context,
+			obj unstructured.Unstructured,
+		) (unstructured.Unstructured, error) {
+			mem.Warnf(ctx, "part object %s", obj.GetName())
+
+			return obj, nil
+		})
+
+		a, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)}, warn)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		b, err := mem.New([]mem.Source{mem.MustSourceFromYAML(multiDocYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		merged, err := mem.Merge(a, b, mem.DuplicateKeepAll)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := merged.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Warnings()).To(Equal([]mem.Warning{{Message: "part object test-config"}}))
+
+		_, ok := result.Provenance(0)
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("should trace objects back to their source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				{Objects: []unstructured.Unstructured{mem.MustUnstructured(configMapYAML)}},
+				mem.MustSourceFromYAML(multiDocYAML),
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		provenance, ok := result.Provenance(0)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance).To(Equal(mem.Provenance{Source: 0}))
+
+		provenance, ok = result.Provenance(2)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Source).To(Equal(1))
+		g.Expect(provenance.Position).To(Equal(&mem.Position{Document: 2, Line: 9}))
+
+		_, ok = result.Provenance(3)
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("should not return results for failed renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{}}, mem.WithFailOnEmpty(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrEmptyRender)).To(BeTrue())
+		g.Expect(result).To(BeNil())
+	})
+}
@@ -0,0 +1,247 @@
+package mem
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DefaultMaxObjectDepth is the default maximum nesting depth of object content.
+const DefaultMaxObjectDepth = 100
+
+// SanitizePolicy decides what a Sanitizer does with values that are not
+// JSON-compatible.
+type SanitizePolicy string
+
+const (
+	// SanitizeConvert converts values to their JSON-compatible equivalent:
+	// integers to int64, float32 to float64, time.Time and metav1.Time to
+	// RFC 3339 strings, and anything else (typed maps and slices, []byte,
+	// structs) to what encoding/json would produce for it.
+	SanitizeConvert SanitizePolicy = "convert"
+
+	// SanitizeReject fails on the first value that is not JSON-compatible.
+	SanitizeReject SanitizePolicy = "reject"
+)
+
+// Sanitizer makes unstructured content JSON-safe. Hand-built maps often hold
+// int, float32, time.Time, or []byte values, which deep copying, hashing, and
+// serializers reject or mangle much later than where they were introduced.
+//
+// JSON-compatible values are the ones of decoded JSON: string, bool, int64,
+// float64, json.Number, nil, map[string]any, and []any. NaN and infinite
+// numbers are never accepted, as JSON cannot represent them.
+type Sanitizer struct {
+	// Policy decides whether values are converted or rejected.
+	Policy SanitizePolicy
+
+	// MaxDepth bounds the nesting depth of maps and lists, which also stops
+	// cyclic structures. 0 means unlimited, so only use it for content known
+	// to be acyclic.
+	MaxDepth int
+}
+
+// DefaultSanitizer returns a Sanitizer converting values, with DefaultMaxObjectDepth.
+func DefaultSanitizer() Sanitizer {
+	return Sanitizer{
+		Policy:   SanitizeConvert,
+		MaxDepth: DefaultMaxObjectDepth,
+	}
+}
+
+// Sanitize returns a JSON-safe deep copy of obj. obj itself is not modified,
+// so it may hold values DeepCopy would panic on.
+func (s Sanitizer) Sanitize(obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+	content, err := s.value(obj.Object, "", 0, true)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+
+	result, _ := content.(map[string]any)
+
+	return unstructured.Unstructured{Object: result}, nil
+}
+
+// sanitizeInPlace makes obj JSON-safe, reusing its maps and lists.
+func (s Sanitizer) sanitizeInPlace(obj *unstructured.Unstructured) error {
+	content, err := s.value(obj.Object, "", 0, false)
+	if err != nil {
+		return err
+	}
+
+	obj.Object, _ = content.(map[string]any)
+
+	return nil
+}
+
+func (s Sanitizer) validate() error {
+	switch s.Policy {
+	case SanitizeConvert, SanitizeReject:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidSanitizePolicy, s.Policy)
+	}
+}
+
+// value sanitizes v found at path. With clone, maps and lists are copied;
+// otherwise they are updated in place.
+func (s Sanitizer) value(v any, path string, depth int, clone bool) (any, error) {
+	switch value := v.(type) {
+	case nil, string, bool, int64, json.Number:
+		return value, nil
+	case float64:
+		return checkFloat(value, path)
+	case map[string]any:
+		return s.mapValue(value, path, depth, clone)
+	case []any:
+		return s.listValue(value, path, depth, clone)
+	}
+
+	if s.Policy == SanitizeReject {
+		return nil, fmt.Errorf("%w: %s has type %T", ErrNonJSONValue, displayPath(path), v)
+	}
+
+	return s.convert(v, path, depth)
+}
+
+func (s Sanitizer) mapValue(value map[string]any, path string, depth int, clone bool) (any, error) {
+	if s.MaxDepth > 0 && depth >= s.MaxDepth {
+		return nil, fmt.Errorf("%w: %s is nested deeper than %d", ErrMaxDepthExceeded, displayPath(path), s.MaxDepth)
+	}
+
+	result := value
+	if clone {
+		result = make(map[string]any, len(value))
+	}
+
+	for key, child := range value {
+		converted, err := s.value(child, joinFieldPath(path, key), depth+1, clone)
+		if err != nil {
+			return nil, err
+		}
+
+		result[key] = converted
+	}
+
+	return result, nil
+}
+
+func (s Sanitizer) listValue(value []any, path string, depth int, clone bool) (any, error) {
+	if s.MaxDepth > 0 && depth >= s.MaxDepth {
+		return nil, fmt.Errorf("%w: %s is nested deeper than %d", ErrMaxDepthExceeded, displayPath(path), s.MaxDepth)
+	}
+
+	result := value
+	if clone {
+		result = make([]any, len(value))
+	}
+
+	for i, child := range value {
+		converted, err := s.value(child, path+"["+strconv.Itoa(i)+"]", depth+1, clone)
+		if err != nil {
+			return nil, err
+		}
+
+		result[i] = converted
+	}
+
+	return result, nil
+}
+
+// convert turns a non-JSON value into its JSON-compatible equivalent. The
+// result is always freshly allocated.
+func (s Sanitizer) convert(v any, path string, depth int) (any, error) {
+	switch value := v.(type) {
+	case int:
+		return int64(value), nil
+	case int8:
+		return int64(value), nil
+	case int16:
+		return int64(value), nil
+	case int32:
+		return int64(value), nil
+	case uint8:
+		return int64(value), nil
+	case uint16:
+		return int64(value), nil
+	case uint32:
+		return int64(value), nil
+	case uint:
+		return convertUnsigned(uint64(value), path)
+	case uint64:
+		return convertUnsigned(value, path)
+	case float32:
+		// Go through the shortest decimal representation, so 0.1 stays 0.1
+		// instead of becoming 0.10000000149011612.
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'g', -1, 32), 64)
+
+		return checkFloat(f, path)
+	case time.Time:
+		return value.UTC().Format(time.RFC3339), nil
+	case metav1.Time:
+		return value.UTC().Format(time.RFC3339), nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s (%T): %w", ErrNonJSONValue, displayPath(path), v, err)
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: %s (%T): %w", ErrNonJSONValue, displayPath(path), v, err)
+	}
+
+	// Decoded numbers are float64; restore integers as int64, as the API
+	// machinery does.
+	return s.value(integersOf(decoded), path, depth, false)
+}
+
+func convertUnsigned(value uint64, path string) (any, error) {
+	if value > math.MaxInt64 {
+		return nil, fmt.Errorf("%w: %s overflows int64", ErrNonJSONValue, displayPath(path))
+	}
+
+	return int64(value), nil
+}
+
+func checkFloat(value float64, path string) (any, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("%w: %s is %v", ErrNonJSONValue, displayPath(path), value)
+	}
+
+	return value, nil
+}
+
+// integersOf replaces whole float64 values within int64 range by int64.
+func integersOf(v any) any {
+	switch value := v.(type) {
+	case float64:
+		if value == math.Trunc(value) && value >= math.MinInt64 && value < math.MaxInt64 {
+			return int64(value)
+		}
+	case map[string]any:
+		for key, child := range value {
+			value[key] = integersOf(child)
+		}
+	case []any:
+		for i, child := range value {
+			value[i] = integersOf(child)
+		}
+	}
+
+	return v
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "object"
+	}
+
+	return path
+}
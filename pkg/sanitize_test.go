@@ -0,0 +1,182 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/gomega"
+)
+
+type sanitizePort struct {
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+func handBuilt() unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":   "hand-built",
+			"labels": map[string]string{"app": "web"},
+		},
+		"data": map[string]any{
+			"replicas":  3,
+			"ratio":     float32(0.1),
+			"created":   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			"updated":   metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+			"payload":   []byte("hi"),
+			"ports":     []sanitizePort{{Name: "http", Port: 80}},
+			"untouched": []any{"a", int64(1), 1.5, true, nil},
+		},
+	}}
+}
+
+func TestSanitizer(t *testing.T) {
+
+	t.Run("should convert values to JSON-compatible ones", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := mem.DefaultSanitizer().Sanitize(handBuilt())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(obj.GetLabels()).To(Equal(map[string]string{"app": "web"}))
+		g.Expect(obj.Object["data"]).To(Equal(map[string]any{
+			"replicas":  int64(3),
+			"ratio":     0.1,
+			"created":   "2026-01-02T03:04:05Z",
+			"updated":   "2026-01-02T03:04:05Z",
+			"payload":   "aGk=",
+			"ports":     []any{map[string]any{"name": "http", "port": int64(80)}},
+			"untouched": []any{"a", int64(1), 1.5, true, nil},
+		}))
+
+		g.Expect(func() { obj.DeepCopy() }).ToNot(Panic())
+	})
+
+	t.Run("should not modify the input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		input := handBuilt()
+
+		_, err := mem.DefaultSanitizer().Sanitize(input)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(input.Object).To(Equal(handBuilt().Object))
+	})
+
+	t.Run("should reject non-JSON values with the reject policy", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"items": []any{int64(1), 2}},
+		}}
+
+		_, err := mem.Sanitizer{Policy: mem.SanitizeReject}.Sanitize(obj)
+		g.Expect(errors.Is(err, mem.ErrNonJSONValue)).To(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("spec.items[1] has type int"))
+	})
+
+	t.Run("should reject numbers JSON cannot represent", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, value := range []any{math.NaN(), math.Inf(1), uint64(math.MaxUint64)} {
+			obj := unstructured.Unstructured{Object: map[string]any{"value": value}}
+
+			_, err := mem.DefaultSanitizer().Sanitize(obj)
+			g.Expect(errors.Is(err, mem.ErrNonJSONValue)).To(BeTrue(), "value %v", value)
+		}
+	})
+
+	t.Run("should enforce the maximum depth", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cyclic := map[string]any{}
+		cyclic["self"] = cyclic
+
+		_, err := mem.DefaultSanitizer().Sanitize(unstructured.Unstructured{Object: cyclic})
+		g.Expect(errors.Is(err, mem.ErrMaxDepthExceeded)).To(BeTrue())
+
+		nested := map[string]any{"a": map[string]any{"b": map[string]any{}}}
+
+		_, err = mem.Sanitizer{Policy: mem.SanitizeConvert, MaxDepth: 2}.
+			Sanitize(unstructured.Unstructured{Object: nested})
+		g.Expect(errors.Is(err, mem.ErrMaxDepthExceeded)).To(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("a.b"))
+
+		_, err = mem.Sanitizer{Policy: mem.SanitizeConvert, MaxDepth: 3}.
+			Sanitize(unstructured.Unstructured{Object: nested})
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+}
+
+func TestWithSanitizer(t *testing.T) {
+
+	t.Run("should render hand-built objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{handBuilt()}}},
+			mem.WithSanitizer(mem.DefaultSanitizer()),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object["data"]).To(HaveKeyWithValue("replicas", int64(3)))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should sanitize values added by post-renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(configMapYAML)},
+			mem.WithSanitizer(mem.DefaultSanitizer()),
+			mem.WithTransformer(func(
+				_ context.Context,
+				obj unstructured.Unstructured,
+			) (unstructured.Unstructured, error) {
+				obj.Object["data"] = map[string]any{"count": 7}
+
+				return obj, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object["data"]).To(Equal(map[string]any{"count": int64(7)}))
+	})
+
+	t.Run("should locate rejected source objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source := mem.MustSourceFromYAML(configMapYAML)
+		source.Objects[0].Object["data"] = map[string]any{"count": 7}
+
+		renderer, err := mem.New([]mem.Source{source}, mem.WithSanitizer(mem.Sanitizer{Policy: mem.SanitizeReject}))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrNonJSONValue)).To(BeTrue())
+
+		var posErr *mem.PositionError
+		g.Expect(errors.As(err, &posErr)).To(BeTrue())
+	})
+
+	t.Run("should reject unknown policies", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithSanitizer(mem.Sanitizer{Policy: "fix"}))
+		g.Expect(errors.Is(err, mem.ErrInvalidSanitizePolicy)).To(BeTrue())
+	})
+}
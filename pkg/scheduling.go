@@ -0,0 +1,129 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// builtinPriorityClasses exist in every cluster.
+var builtinPriorityClasses = []string{"system-cluster-critical", "system-node-critical"}
+
+// Capabilities describes what the target cluster provides beyond the render,
+// for checks that would otherwise reject references to cluster objects.
+type Capabilities struct {
+	// PriorityClasses are the names of the PriorityClasses defined in the
+	// cluster. The built-in system-cluster-critical and system-node-critical
+	// classes are always known.
+	PriorityClasses []string
+}
+
+// schedulingClasses configures WithSchedulingClasses.
+type schedulingClasses struct {
+	priorityClassName string
+	runtimeClassName  string
+	selector          types.Filter
+}
+
+// injectSchedulingClasses sets the priority and runtime classes of the
+// selected workloads that do not set them yet, then checks that every
+// priority class a workload references is known. It returns the indices of
+// the objects it changed.
+func (r *Renderer) injectSchedulingClasses(ctx context.Context, objects []unstructured.Unstructured) ([]int, error) {
+	classes := r.opts.SchedulingClasses
+	changed := make([]int, 0)
+
+	for i := range objects {
+		obj := &objects[i]
+		if !isWorkload(obj) {
+			continue
+		}
+
+		if classes.selector != nil {
+			selected, err := classes.selector(ctx, *obj)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+			}
+
+			if !selected {
+				continue
+			}
+		}
+
+		podSpec, err := podSpecOf(obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+		}
+
+		modified := setDefault(podSpec, "priorityClassName", classes.priorityClassName)
+		modified = setDefault(podSpec, "runtimeClassName", classes.runtimeClassName) || modified
+
+		if modified {
+			changed = append(changed, i)
+		}
+	}
+
+	return changed, r.checkPriorityClasses(objects)
+}
+
+// checkPriorityClasses fails with ErrUnknownPriorityClass if a workload
+// references a priority class that is neither rendered nor known to the
+// cluster.
+func (r *Renderer) checkPriorityClasses(objects []unstructured.Unstructured) error {
+	known := make(map[string]struct{})
+
+	for _, name := range builtinPriorityClasses {
+		known[name] = struct{}{}
+	}
+
+	for _, name := range r.opts.Capabilities.PriorityClasses {
+		known[name] = struct{}{}
+	}
+
+	for i := range objects {
+		gvk := objects[i].GroupVersionKind()
+		if gvk.Group == "scheduling.k8s.io" && gvk.Kind == "PriorityClass" {
+			known[objects[i].GetName()] = struct{}{}
+		}
+	}
+
+	for i := range objects {
+		obj := &objects[i]
+
+		podSpec, err := lookupPodSpec(obj)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+		}
+
+		name, _ := podSpec["priorityClassName"].(string)
+		if name == "" {
+			continue
+		}
+
+		if _, ok := known[name]; !ok {
+			return fmt.Errorf("%w: %q, referenced by %s, is neither rendered nor a cluster capability",
+				ErrUnknownPriorityClass, name, ObjectKeyOf(*obj))
+		}
+	}
+
+	return nil
+}
+
+// setDefault sets spec[key] to value unless value is empty or spec already
+// sets key, and reports whether it did.
+func setDefault(spec map[string]any, key string, value string) bool {
+	if value == "" {
+		return false
+	}
+
+	if current, _ := spec[key].(string); current != "" {
+		return false
+	}
+
+	spec[key] = value
+
+	return true
+}
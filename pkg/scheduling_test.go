@@ -0,0 +1,109 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const priorityClassYAML = `
+apiVersion: scheduling.k8s.io/v1
+kind: PriorityClass
+metadata:
+  name: batch-low
+value: 100
+`
+
+// podSpecField returns a string field of the pod template of a Deployment.
+func podSpecField(g *WithT, obj unstructured.Unstructured, field string) string {
+	value, _, err := unstructured.NestedString(obj.Object, "spec", "template", "spec", field)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return value
+}
+
+func TestSchedulingClasses(t *testing.T) {
+
+	deployment := func(name string, priorityClass string) string {
+		manifest := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: " + name + "\n"
+		if priorityClass != "" {
+			manifest += "spec:\n  template:\n    spec:\n      priorityClassName: " + priorityClass + "\n"
+		}
+
+		return manifest
+	}
+
+	t.Run("should inject classes into selected workloads", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Manifests: []string{
+				deployment("web", ""),
+				deployment("batch", ""),
+				deployment("custom", "batch-low"),
+				priorityClassYAML,
+				configMapYAML,
+			}}},
+			mem.WithSchedulingClasses("batch-low", "gvisor", func(
+				_ context.Context,
+				obj unstructured.Unstructured,
+			) (bool, error) {
+				return obj.GetName() != "web", nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).ToNot(HaveKey("spec"))
+		g.Expect(podSpecField(g, objects[1], "priorityClassName")).To(Equal("batch-low"))
+		g.Expect(podSpecField(g, objects[1], "runtimeClassName")).To(Equal("gvisor"))
+		g.Expect(podSpecField(g, objects[2], "runtimeClassName")).To(Equal("gvisor"))
+		g.Expect(objects[4].Object).ToNot(HaveKey("spec"))
+	})
+
+	t.Run("should keep classes workloads already set", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Manifests: []string{deployment("web", "system-node-critical")}}},
+			mem.WithSchedulingClasses("batch-low", "", nil),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(podSpecField(g, objects[0], "priorityClassName")).To(Equal("system-node-critical"))
+		g.Expect(podSpecField(g, objects[0], "runtimeClassName")).To(BeEmpty())
+	})
+
+	t.Run("should reject unknown priority classes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Manifests: []string{deployment("web", ""), deployment("api", "high")}}},
+			mem.WithSchedulingClasses("batch-low", "", nil),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrUnknownPriorityClass)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring(`"batch-low", referenced by apps/v1/Deployment//web`)))
+
+		renderer, err = mem.New(
+			[]mem.Source{{Manifests: []string{deployment("web", ""), deployment("api", "high")}}},
+			mem.WithSchedulingClasses("batch-low", "", nil),
+			mem.WithCapabilities(mem.Capabilities{PriorityClasses: []string{"batch-low", "high"}}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+}
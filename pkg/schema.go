@@ -0,0 +1,485 @@
+package mem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SchemaViolation is a field of a rendered object that does not match the
+// schema of its kind.
+type SchemaViolation struct {
+	// Object is the key of the object, as formatted by ObjectKeyOf. The
+	// renderer fills it in; validators may leave it empty.
+	Object string `json:"object,omitempty"`
+
+	// Path is the JSON path of the field, e.g. ".spec.ports[0].port", or
+	// empty for the object itself.
+	Path string `json:"path"`
+
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+func (v SchemaViolation) String() string {
+	field := v.Path
+	if field == "" {
+		field = "."
+	}
+
+	if v.Object == "" {
+		return field + ": " + v.Message
+	}
+
+	return v.Object + " " + field + ": " + v.Message
+}
+
+// SchemaValidator validates rendered objects against the schemas of their
+// kinds, such as the OpenAPI schemas of a Kubernetes release (e.g. backed by
+// kubeconform or kube-openapi) or the schemas of CustomResourceDefinitions.
+// It returns no violations for kinds it has no schema for, and an error only
+// if it cannot validate at all.
+type SchemaValidator interface {
+	ValidateSchema(ctx context.Context, obj unstructured.Unstructured) ([]SchemaViolation, error)
+}
+
+// SchemaValidatorFunc adapts a function to a SchemaValidator.
+type SchemaValidatorFunc func(ctx context.Context, obj unstructured.Unstructured) ([]SchemaViolation, error)
+
+// ValidateSchema calls f.
+func (f SchemaValidatorFunc) ValidateSchema(
+	ctx context.Context,
+	obj unstructured.Unstructured,
+) ([]SchemaViolation, error) {
+	return f(ctx, obj)
+}
+
+// SchemaError reports the schema violations of a render.
+type SchemaError struct {
+	// Violations are the violations of all objects, in output order.
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.String())
+	}
+
+	return fmt.Sprintf("%s: %s", ErrSchemaViolation, strings.Join(messages, "; "))
+}
+
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+// validateSchemas runs the renderer's schema validators over objects and
+// fails with a SchemaError listing every violation.
+func (r *Renderer) validateSchemas(ctx context.Context, objects []unstructured.Unstructured) error {
+	violations := make([]SchemaViolation, 0)
+
+	for i := range objects {
+		key := ObjectKeyOf(objects[i])
+
+		for _, validator := range r.opts.SchemaValidators {
+			found, err := validator.ValidateSchema(ctx, objects[i])
+			if err != nil {
+				return fmt.Errorf("failed to validate %s: %w", key, err)
+			}
+
+			for _, v := range found {
+				v.Object = key
+				violations = append(violations, v)
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+
+	return nil
+}
+
+// CRDSchemaValidator returns a SchemaValidator checking custom resources
+// against the openAPIV3Schema of the version they are rendered at, as
+// defined by crds. Other objects, including custom resources of versions
+// without a schema, are not checked.
+//
+// Schemas are interpreted as the structural schemas the API server enforces:
+// type, properties, additionalProperties, required, items, enum, nullable,
+// the length, size, and range bounds, pattern, and the
+// x-kubernetes-preserve-unknown-fields, x-kubernetes-int-or-string, and
+// x-kubernetes-embedded-resource extensions. Fields not declared by a schema
+// that does not preserve unknown fields are reported, as kubectl's strict
+// field validation rejects them; apiVersion, kind, and metadata are always
+// allowed at the root. Other keywords, such as formats, the logical
+// junctors, and CEL validation rules, are ignored. Malformed CRDs fail with
+// ErrInvalidCRD.
+func CRDSchemaValidator(crds ...unstructured.Unstructured) (SchemaValidator, error) {
+	schemas := make(map[schema.GroupVersionKind]*structuralSchema)
+
+	for _, crd := range crds {
+		gk, ok := definedKind(crd)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s does not define a kind", ErrInvalidCRD, ObjectKeyOf(crd))
+		}
+
+		versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		if err != nil {
+			return nil, fmt.Errorf("%w: CRD %s: %w", ErrInvalidCRD, crd.GetName(), err)
+		}
+
+		for _, version := range versions {
+			v, _ := version.(map[string]any)
+			name, _ := v["name"].(string)
+
+			raw, found, err := unstructured.NestedMap(v, "schema", "openAPIV3Schema")
+			if err != nil || !found {
+				continue
+			}
+
+			parsed, err := parseStructuralSchema(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: CRD %s version %s: %w", ErrInvalidCRD, crd.GetName(), name, err)
+			}
+
+			schemas[gk.WithVersion(name)] = parsed
+		}
+	}
+
+	return SchemaValidatorFunc(func(_ context.Context, obj unstructured.Unstructured) ([]SchemaViolation, error) {
+		s, ok := schemas[obj.GroupVersionKind()]
+		if !ok {
+			return nil, nil
+		}
+
+		violations := make([]SchemaViolation, 0)
+		s.validate("", obj.Object, true, &violations)
+
+		return violations, nil
+	}), nil
+}
+
+// structuralSchema is the subset of a CRD schema CRDSchemaValidator checks.
+type structuralSchema struct {
+	Type                  string                       `json:"type"`
+	Properties            map[string]*structuralSchema `json:"properties"`
+	AdditionalProperties  *additionalProperties        `json:"additionalProperties"`
+	Required              []string                     `json:"required"`
+	Items                 *structuralSchema            `json:"items"`
+	Enum                  []any                        `json:"enum"`
+	Nullable              bool                         `json:"nullable"`
+	Pattern               string                       `json:"pattern"`
+	MinLength             *int64                       `json:"minLength"`
+	MaxLength             *int64                       `json:"maxLength"`
+	MinItems              *int64                       `json:"minItems"`
+	MaxItems              *int64                       `json:"maxItems"`
+	MinProperties         *int64                       `json:"minProperties"`
+	MaxProperties         *int64                       `json:"maxProperties"`
+	Minimum               *float64                     `json:"minimum"`
+	Maximum               *float64                     `json:"maximum"`
+	ExclusiveMinimum      bool                         `json:"exclusiveMinimum"`
+	ExclusiveMaximum      bool                         `json:"exclusiveMaximum"`
+	PreserveUnknownFields bool                         `json:"x-kubernetes-preserve-unknown-fields"`
+	IntOrString           bool                         `json:"x-kubernetes-int-or-string"`
+	EmbeddedResource      bool                         `json:"x-kubernetes-embedded-resource"`
+
+	pattern *regexp.Regexp
+}
+
+// additionalProperties is a schema, or a boolean allowing or forbidding
+// undeclared properties.
+type additionalProperties struct {
+	Allowed bool
+	Schema  *structuralSchema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+
+	a.Allowed = true
+
+	return json.Unmarshal(data, &a.Schema)
+}
+
+func parseStructuralSchema(raw map[string]any) (*structuralSchema, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var s structuralSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// compile compiles the patterns of s and its nested schemas.
+func (s *structuralSchema) compile() error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+
+		s.pattern = pattern
+	}
+
+	for _, property := range s.Properties {
+		if err := property.compile(); err != nil {
+			return err
+		}
+	}
+
+	if s.AdditionalProperties != nil {
+		if err := s.AdditionalProperties.Schema.compile(); err != nil {
+			return err
+		}
+	}
+
+	return s.Items.compile()
+}
+
+// validate appends the violations of value, found at path, to violations.
+// root is set for the object itself, whose apiVersion, kind, and metadata
+// need not be declared.
+func (s *structuralSchema) validate(path string, value any, root bool, violations *[]SchemaViolation) {
+	report := func(format string, args ...any) {
+		*violations = append(*violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		if !s.Nullable && s.Type != "" {
+			report("must not be null")
+		}
+
+		return
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool { return equalJSON(allowed, value) }) {
+		report("must be one of %s", formatEnum(s.Enum))
+	}
+
+	if s.IntOrString {
+		switch value.(type) {
+		case string, int64, int, int32:
+		default:
+			if f, ok := value.(float64); !ok || f != math.Trunc(f) {
+				report("must be an integer or a string")
+			}
+		}
+
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			report("must be an object, not %s", jsonType(value))
+
+			return
+		}
+
+		s.validateObject(path, object, root, report, violations)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			report("must be an array, not %s", jsonType(value))
+
+			return
+		}
+
+		if s.MinItems != nil && int64(len(items)) < *s.MinItems {
+			report("must have at least %d items", *s.MinItems)
+		}
+
+		if s.MaxItems != nil && int64(len(items)) > *s.MaxItems {
+			report("must have at most %d items", *s.MaxItems)
+		}
+
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validate(path+"["+strconv.Itoa(i)+"]", item, false, violations)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			report("must be a string, not %s", jsonType(value))
+
+			return
+		}
+
+		length := int64(utf8.RuneCountInString(str))
+		if s.MinLength != nil && length < *s.MinLength {
+			report("must be at least %d characters long", *s.MinLength)
+		}
+
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("must be at most %d characters long", *s.MaxLength)
+		}
+
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			report("must match %q", s.Pattern)
+		}
+	case "integer", "number":
+		number, ok := numberValue(value)
+		if !ok || (s.Type == "integer" && number != math.Trunc(number)) {
+			article := map[string]string{"integer": "an integer", "number": "a number"}[s.Type]
+			report("must be %s, not %s", article, jsonType(value))
+
+			return
+		}
+
+		s.validateRange(number, report)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			report("must be a boolean, not %s", jsonType(value))
+		}
+	}
+}
+
+func (s *structuralSchema) validateObject(
+	path string,
+	object map[string]any,
+	root bool,
+	report func(format string, args ...any),
+	violations *[]SchemaViolation,
+) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			report("missing required field %q", name)
+		}
+	}
+
+	if s.MinProperties != nil && int64(len(object)) < *s.MinProperties {
+		report("must have at least %d properties", *s.MinProperties)
+	}
+
+	if s.MaxProperties != nil && int64(len(object)) > *s.MaxProperties {
+		report("must have at most %d properties", *s.MaxProperties)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(object)) {
+		field := path + "." + name
+
+		if property, ok := s.Properties[name]; ok {
+			property.validate(field, object[name], false, violations)
+
+			continue
+		}
+
+		switch {
+		case (root || s.EmbeddedResource) && (name == "apiVersion" || name == "kind" || name == "metadata"):
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			s.AdditionalProperties.Schema.validate(field, object[name], false, violations)
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Allowed, s.PreserveUnknownFields:
+		default:
+			*violations = append(*violations, SchemaViolation{Path: field, Message: "unknown field"})
+		}
+	}
+}
+
+func (s *structuralSchema) validateRange(number float64, report func(format string, args ...any)) {
+	if s.Minimum != nil {
+		if s.ExclusiveMinimum && number <= *s.Minimum {
+			report("must be greater than %v", *s.Minimum)
+		} else if number < *s.Minimum {
+			report("must be greater than or equal to %v", *s.Minimum)
+		}
+	}
+
+	if s.Maximum != nil {
+		if s.ExclusiveMaximum && number >= *s.Maximum {
+			report("must be less than %v", *s.Maximum)
+		} else if number > *s.Maximum {
+			report("must be less than or equal to %v", *s.Maximum)
+		}
+	}
+}
+
+// numberValue returns value as a float64 if it is a JSON number.
+func numberValue(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// jsonType names the JSON type of value for messages.
+func jsonType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		if _, ok := numberValue(value); ok {
+			return "number"
+		}
+
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// equalJSON compares JSON values, treating numbers of different Go types as
+// equal if they have the same value.
+func equalJSON(a any, b any) bool {
+	na, aNumber := numberValue(a)
+	nb, bNumber := numberValue(b)
+
+	if aNumber || bNumber {
+		return aNumber && bNumber && na == nb
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func formatEnum(values []any) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		data, _ := json.Marshal(value)
+		formatted = append(formatted, string(data))
+	}
+
+	return strings.Join(formatted, ", ")
+}
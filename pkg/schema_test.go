@@ -0,0 +1,202 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const schemaCRDYAML = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [size]
+            properties:
+              size:
+                type: string
+                enum: [small, large]
+              replicas:
+                type: integer
+                minimum: 1
+              port:
+                x-kubernetes-int-or-string: true
+              tags:
+                type: array
+                maxItems: 2
+                items:
+                  type: string
+                  pattern: "^[a-z]+$"
+              settings:
+                type: object
+                additionalProperties:
+                  type: string
+              extra:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true
+  - name: v2
+    served: true
+    storage: false
+`
+
+func widgetCRD(t *testing.T) unstructured.Unstructured {
+	t.Helper()
+
+	var crd unstructured.Unstructured
+	if err := yaml.Unmarshal([]byte(schemaCRDYAML), &crd.Object); err != nil {
+		t.Fatal(err)
+	}
+
+	return crd
+}
+
+func widget(version string, spec map[string]any) unstructured.Unstructured {
+	obj := composeObject("example.com/"+version, "Widget", "app", "widget")
+	obj.Object["spec"] = spec
+
+	return obj
+}
+
+func TestSchemaValidation(t *testing.T) {
+
+	render := func(t *testing.T, objects ...unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+		t.Helper()
+
+		validator, err := mem.CRDSchemaValidator(widgetCRD(t))
+		if err != nil {
+			return nil, err
+		}
+
+		renderer, err := mem.New([]mem.Source{{Objects: objects}}, mem.WithSchemaValidation(validator))
+		if err != nil {
+			return nil, err
+		}
+
+		return renderer.Process(t.Context(), nil)
+	}
+
+	t.Run("should accept custom resources matching their schema", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(t,
+			widget("v1", map[string]any{
+				"size":     "small",
+				"replicas": int64(2),
+				"port":     "http",
+				"tags":     []any{"web"},
+				"settings": map[string]any{"mode": "fast"},
+				"extra":    map[string]any{"anything": []any{int64(1)}},
+			}),
+			composeObject("v1", "ConfigMap", "app", "config"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+	})
+
+	t.Run("should report every violation with its path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, widget("v1", map[string]any{
+			"size":     "medium",
+			"replicas": int64(0),
+			"port":     true,
+			"tags":     []any{"web", "API", "db"},
+			"settings": map[string]any{"mode": int64(1)},
+			"colour":   "red",
+		}))
+		g.Expect(err).To(MatchError(mem.ErrSchemaViolation))
+
+		var schemaErr *mem.SchemaError
+		g.Expect(errors.As(err, &schemaErr)).To(BeTrue())
+
+		violation := func(path string, message string) mem.SchemaViolation {
+			return mem.SchemaViolation{Object: "example.com/v1/Widget/app/widget", Path: path, Message: message}
+		}
+
+		g.Expect(schemaErr.Violations).To(ConsistOf(
+			violation(".spec.colour", "unknown field"),
+			violation(".spec.port", "must be an integer or a string"),
+			violation(".spec.replicas", "must be greater than or equal to 1"),
+			violation(".spec.settings.mode", "must be a string, not number"),
+			violation(".spec.size", `must be one of "small", "large"`),
+			violation(".spec.tags", "must have at most 2 items"),
+			violation(".spec.tags[1]", `must match "^[a-z]+$"`),
+		))
+		g.Expect(err.Error()).To(ContainSubstring("example.com/v1/Widget/app/widget .spec.colour: unknown field"))
+	})
+
+	t.Run("should report missing required fields", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, widget("v1", map[string]any{}))
+		g.Expect(err).To(MatchError(mem.ErrSchemaViolation))
+		g.Expect(err.Error()).To(ContainSubstring(`.spec: missing required field "size"`))
+	})
+
+	t.Run("should skip kinds and versions without a schema", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := render(t,
+			widget("v2", map[string]any{"colour": "red"}),
+			composeObject("example.com/v1", "Gadget", "app", "gadget"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+	})
+
+	t.Run("should fail on errors of validators", func(t *testing.T) {
+		g := NewWithT(t)
+
+		failure := errors.New("schemas unavailable")
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(1)}},
+			mem.WithSchemaValidation(mem.SchemaValidatorFunc(
+				func(context.Context, unstructured.Unstructured) ([]mem.SchemaViolation, error) {
+					return nil, failure
+				})),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(failure))
+		g.Expect(err.Error()).To(ContainSubstring("failed to validate v1/ConfigMap//cm-0"))
+	})
+
+	t.Run("should reject CRDs with invalid patterns", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var crd unstructured.Unstructured
+		invalid := strings.Replace(schemaCRDYAML, `"^[a-z]+$"`, `"("`, 1)
+		g.Expect(yaml.Unmarshal([]byte(invalid), &crd.Object)).To(Succeed())
+
+		_, err := mem.CRDSchemaValidator(crd)
+		g.Expect(err).To(MatchError(mem.ErrInvalidCRD))
+
+		_, err = mem.CRDSchemaValidator(composeObject("v1", "ConfigMap", "app", "config"))
+		g.Expect(err).To(MatchError(mem.ErrInvalidCRD))
+	})
+}
@@ -0,0 +1,96 @@
+package mem
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SplitByScope partitions objects into cluster-scoped and namespaced sets,
+// preserving their order, so that appliers can handle cluster-scoped objects
+// with different credentials or apply them first.
+//
+// The scope of each kind is looked up with mapper, which is typically backed
+// by API discovery. Kinds defined by CustomResourceDefinitions in objects are
+// resolved from the CRD's spec.scope first, since they cannot be discovered
+// before the CRD is installed; mapper may be nil if every kind is defined this
+// way. A kind with no known scope is an error wrapping ErrUnknownScope.
+//
+// Like the set operations, SplitByScope does not copy objects: the results
+// share data with objects.
+func SplitByScope(
+	objects []unstructured.Unstructured,
+	mapper meta.RESTMapper,
+) ([]unstructured.Unstructured, []unstructured.Unstructured, error) {
+	scopes := crdScopes(objects)
+	clusterScoped := make([]unstructured.Unstructured, 0)
+	namespaced := make([]unstructured.Unstructured, 0)
+
+	for i := range objects {
+		gvk := objects[i].GroupVersionKind()
+
+		isNamespaced, err := namespacedKind(gvk, scopes, mapper)
+		if err != nil {
+			return nil, nil, fmt.Errorf("object at index %d (%s): %w", i, ObjectKeyOf(objects[i]), err)
+		}
+
+		if isNamespaced {
+			namespaced = append(namespaced, objects[i])
+		} else {
+			clusterScoped = append(clusterScoped, objects[i])
+		}
+	}
+
+	return clusterScoped, namespaced, nil
+}
+
+// namespacedKind reports whether gvk is namespaced, looking it up in scopes
+// before asking mapper.
+func namespacedKind(
+	gvk schema.GroupVersionKind,
+	scopes map[schema.GroupKind]bool,
+	mapper meta.RESTMapper,
+) (bool, error) {
+	if isNamespaced, ok := scopes[gvk.GroupKind()]; ok {
+		return isNamespaced, nil
+	}
+
+	if mapper == nil {
+		return false, fmt.Errorf("%w: %s", ErrUnknownScope, gvk)
+	}
+
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s: %w", ErrUnknownScope, gvk, err)
+	}
+
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// crdScopes returns whether each kind defined by a CustomResourceDefinition in
+// objects is namespaced, along with the cluster-scoped CRD kind itself. CRDs
+// without a kind or a scope are ignored.
+func crdScopes(objects []unstructured.Unstructured) map[schema.GroupKind]bool {
+	scopes := make(map[schema.GroupKind]bool)
+
+	for i := range objects {
+		if !isCRD(objects[i]) {
+			continue
+		}
+
+		scopes[objects[i].GroupVersionKind().GroupKind()] = false
+
+		gk, ok := definedKind(objects[i])
+		scope, _, _ := unstructured.NestedString(objects[i].Object, "spec", "scope")
+
+		if !ok || scope == "" {
+			continue
+		}
+
+		scopes[gk] = scope == "Namespaced"
+	}
+
+	return scopes
+}
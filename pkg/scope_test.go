@@ -0,0 +1,107 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func scopeMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(
+		schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+		meta.RESTScopeRoot,
+	)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	return mapper
+}
+
+func scopeCRD(kind string, scope string) unstructured.Unstructured {
+	crd := composeObject("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", kind+"s.example.com")
+	crd.Object["spec"] = map[string]any{
+		"group": "example.com",
+		"scope": scope,
+		"names": map[string]any{"kind": kind},
+	}
+
+	return crd
+}
+
+func TestSplitByScope(t *testing.T) {
+
+	t.Run("should partition objects by scope preserving order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "app", "config"),
+			composeObject("v1", "Namespace", "", "app"),
+			composeObject("apps/v1", "Deployment", "app", "web"),
+			composeObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "reader"),
+		}
+
+		clusterScoped, namespaced, err := mem.SplitByScope(objects, scopeMapper())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(clusterScoped)).To(Equal([]string{"Namespace/app", "ClusterRole/reader"}))
+		g.Expect(names(namespaced)).To(Equal([]string{"ConfigMap/config", "Deployment/web"}))
+	})
+
+	t.Run("should resolve kinds defined by CRDs in the set", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			scopeCRD("Widget", "Namespaced"),
+			scopeCRD("Cluster", "Cluster"),
+			composeObject("example.com/v1", "Widget", "app", "widget"),
+			composeObject("example.com/v1", "Cluster", "", "main"),
+		}
+
+		clusterScoped, namespaced, err := mem.SplitByScope(objects, scopeMapper())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(clusterScoped)).To(Equal([]string{
+			"CustomResourceDefinition/Widgets.example.com",
+			"CustomResourceDefinition/Clusters.example.com",
+			"Cluster/main",
+		}))
+		g.Expect(names(namespaced)).To(Equal([]string{"Widget/widget"}))
+	})
+
+	t.Run("should fail for kinds unknown to the mapper", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := []unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "app", "config"),
+			composeObject("example.com/v1", "Gadget", "app", "gadget"),
+		}
+
+		_, _, err := mem.SplitByScope(objects, scopeMapper())
+		g.Expect(err).To(MatchError(mem.ErrUnknownScope))
+		g.Expect(err).To(MatchError(ContainSubstring("object at index 1")))
+	})
+
+	t.Run("should fail without a mapper for kinds not defined by CRDs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, _, err := mem.SplitByScope([]unstructured.Unstructured{
+			composeObject("v1", "ConfigMap", "app", "config"),
+		}, nil)
+		g.Expect(err).To(MatchError(mem.ErrUnknownScope))
+	})
+
+	t.Run("should return empty sets for no objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		clusterScoped, namespaced, err := mem.SplitByScope(nil, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(clusterScoped).To(BeEmpty())
+		g.Expect(namespaced).To(BeEmpty())
+	})
+}
@@ -0,0 +1,72 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+)
+
+// RenderSemaphore bounds how many renders run at once. A single semaphore
+// can be shared by any number of renderers, including renderers of other
+// kinds that accept one, to bound the render work of a whole process, e.g.
+// when a controller reconciles many objects after a restart.
+//
+// Implementations must be safe for concurrent use and comparable, such as
+// pointers, since renders nested in a render holding the semaphore are
+// recognized by it.
+type RenderSemaphore interface {
+	// Acquire blocks until a render may start or ctx is done, in which case
+	// it returns the error of ctx.
+	Acquire(ctx context.Context) error
+
+	// Release ends a render started by a successful Acquire.
+	Release()
+}
+
+// NewRenderSemaphore returns a RenderSemaphore admitting up to n renders at
+// once. Values of n below 1 admit one render at a time.
+func NewRenderSemaphore(n int) RenderSemaphore {
+	return &renderSemaphore{slots: make(chan struct{}, max(n, 1))}
+}
+
+// renderSemaphore is the RenderSemaphore of NewRenderSemaphore.
+type renderSemaphore struct {
+	slots chan struct{}
+}
+
+func (s *renderSemaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *renderSemaphore) Release() {
+	<-s.slots
+}
+
+// admittedKey is the context key of the semaphore a render holds.
+type admittedKey struct{}
+
+// admit waits for the render semaphore of r, if it has one, and returns the
+// context of the admitted render and the function ending it. Renders nested
+// in a render holding the same semaphore, such as the parts of a merged
+// renderer, are admitted without waiting, as they would otherwise wait for
+// their caller.
+func (r *Renderer) admit(ctx context.Context) (context.Context, func(), error) {
+	semaphore := r.opts.RenderSemaphore
+	if semaphore == nil {
+		return ctx, func() {}, nil
+	}
+
+	if held, ok := ctx.Value(admittedKey{}).(RenderSemaphore); ok && held == semaphore {
+		return ctx, func() {}, nil
+	}
+
+	if err := semaphore.Acquire(ctx); err != nil {
+		return ctx, nil, fmt.Errorf("render semaphore error in mem renderer: %w", err)
+	}
+
+	return context.WithValue(ctx, admittedKey{}, semaphore), semaphore.Release, nil
+}
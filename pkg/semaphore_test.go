@@ -0,0 +1,192 @@
+package mem_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// concurrencyProbe records the highest number of renders running its
+// transformer at once.
+type concurrencyProbe struct {
+	running atomic.Int64
+	peak    atomic.Int64
+}
+
+func (p *concurrencyProbe) option() mem.RendererOption {
+	return mem.WithTransformer(func(
+		_ context.Context,
+		obj unstructured.Unstructured,
+	) (unstructured.Unstructured, error) {
+		running := p.running.Add(1)
+		defer p.running.Add(-1)
+
+		for {
+			peak := p.peak.Load()
+			if running <= peak || p.peak.CompareAndSwap(peak, running) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+
+		return obj, nil
+	})
+}
+
+func TestRenderSemaphore(t *testing.T) {
+
+	t.Run("should bound renders across the renderers sharing it", func(t *testing.T) {
+		g := NewWithT(t)
+
+		semaphore := mem.NewRenderSemaphore(2)
+		probe := &concurrencyProbe{}
+
+		renderers := make([]*mem.Renderer, 3)
+		for i := range renderers {
+			renderer, err := mem.New(
+				[]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+				mem.WithRenderSemaphore(semaphore),
+				probe.option(),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			renderers[i] = renderer
+		}
+
+		var wg sync.WaitGroup
+
+		for i := range 12 {
+			wg.Go(func() {
+				_, err := renderers[i%len(renderers)].Process(t.Context(), nil)
+				g.Expect(err).ToNot(HaveOccurred())
+			})
+		}
+
+		wg.Wait()
+
+		g.Expect(probe.peak.Load()).To(BeNumerically("<=", 2))
+	})
+
+	t.Run("should not wait again in nested renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		semaphore := mem.NewRenderSemaphore(1)
+
+		parts := make([]*mem.Renderer, 2)
+		for i, value := range []string{"a", "b"} {
+			part, err := mem.New(
+				[]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig(value)}}},
+				mem.WithRenderSemaphore(semaphore),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			parts[i] = part
+		}
+
+		merged, err := mem.Merge(parts[0], parts[1], mem.DuplicateKeepLast, mem.WithRenderSemaphore(semaphore))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+
+		objects, err := merged.Process(ctx, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object["data"]).To(HaveKeyWithValue("key", "b"))
+	})
+
+	t.Run("should fail renders whose context ends while waiting", func(t *testing.T) {
+		g := NewWithT(t)
+
+		semaphore := mem.NewRenderSemaphore(1)
+		g.Expect(semaphore.Acquire(t.Context())).To(Succeed())
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+			mem.WithRenderSemaphore(semaphore),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = renderer.Process(ctx, nil)
+		g.Expect(err).To(MatchError(context.DeadlineExceeded))
+		g.Expect(err).To(MatchError(ContainSubstring("render semaphore error")))
+
+		for _, err := range renderer.ProcessStream(ctx, nil) {
+			g.Expect(err).To(MatchError(context.DeadlineExceeded))
+		}
+
+		g.Expect(renderer.Stats().Renders).To(Equal(uint64(2)))
+		g.Expect(renderer.Stats().Errors).To(Equal(uint64(2)))
+
+		semaphore.Release()
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("should serve cached renders without waiting", func(t *testing.T) {
+		g := NewWithT(t)
+
+		semaphore := mem.NewRenderSemaphore(1)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+			mem.WithRenderSemaphore(semaphore),
+			mem.WithRenderCache(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(semaphore.Acquire(t.Context())).To(Succeed())
+		defer semaphore.Release()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		result, err := renderer.ProcessResult(ctx, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Info().Cached).To(BeTrue())
+	})
+
+	t.Run("should hold the semaphore for each step of a job", func(t *testing.T) {
+		g := NewWithT(t)
+
+		semaphore := mem.NewRenderSemaphore(1)
+		g.Expect(semaphore.Acquire(t.Context())).To(Succeed())
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+			mem.WithRenderSemaphore(semaphore),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		done, err := job.Step(ctx)
+		g.Expect(err).To(MatchError(context.DeadlineExceeded))
+		g.Expect(done).To(BeFalse())
+
+		semaphore.Release()
+
+		result, err := job.Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().DeepCopy()).To(HaveLen(1))
+	})
+}
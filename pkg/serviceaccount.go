@@ -0,0 +1,116 @@
+package mem
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ServiceAccountWiring configures WithServiceAccountWiring.
+type ServiceAccountWiring struct {
+	// ServiceAccountName, if set, is referenced by the pod spec of every
+	// rendered workload that does not reference a service account yet.
+	ServiceAccountName string
+
+	// Override makes ServiceAccountName replace the service accounts that
+	// workloads already reference.
+	Override bool
+
+	// ImagePullSecrets are attached to every rendered ServiceAccount, after
+	// the ones it already lists, which are kept.
+	ImagePullSecrets []string
+}
+
+// wireServiceAccounts applies wiring to objects and returns the indices of
+// the objects it changed.
+func wireServiceAccounts(objects []unstructured.Unstructured, wiring ServiceAccountWiring) ([]int, error) {
+	changed := make([]int, 0)
+
+	for i := range objects {
+		obj := &objects[i]
+		gk := obj.GroupVersionKind().GroupKind()
+
+		var (
+			modified bool
+			err      error
+		)
+
+		switch {
+		case isWorkload(obj) && wiring.ServiceAccountName != "":
+			modified, err = wiring.wirePodSpec(obj)
+		case gk == schema.GroupKind{Kind: "ServiceAccount"} && len(wiring.ImagePullSecrets) > 0:
+			modified, err = wiring.attachImagePullSecrets(obj)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+		}
+
+		if modified {
+			changed = append(changed, i)
+		}
+	}
+
+	return changed, nil
+}
+
+// wirePodSpec sets the service account of the pod spec of the workload obj.
+func (w ServiceAccountWiring) wirePodSpec(obj *unstructured.Unstructured) (bool, error) {
+	podSpec, err := podSpecOf(obj)
+	if err != nil {
+		return false, err
+	}
+
+	current, _ := podSpec["serviceAccountName"].(string)
+	if current == "" {
+		// serviceAccount is the deprecated alias of serviceAccountName.
+		current, _ = podSpec["serviceAccount"].(string)
+	}
+
+	if current == w.ServiceAccountName || (current != "" && !w.Override) {
+		return false, nil
+	}
+
+	podSpec["serviceAccountName"] = w.ServiceAccountName
+	delete(podSpec, "serviceAccount")
+
+	return true, nil
+}
+
+// attachImagePullSecrets appends the image pull secrets that the
+// ServiceAccount obj does not list yet.
+func (w ServiceAccountWiring) attachImagePullSecrets(obj *unstructured.Unstructured) (bool, error) {
+	secrets, _, err := unstructured.NestedSlice(obj.Object, "imagePullSecrets")
+	if err != nil {
+		return false, err
+	}
+
+	listed := make(map[string]struct{}, len(secrets))
+
+	for _, secret := range secrets {
+		if ref, ok := secret.(map[string]any); ok {
+			if name, ok := ref["name"].(string); ok {
+				listed[name] = struct{}{}
+			}
+		}
+	}
+
+	attached := false
+
+	for _, name := range w.ImagePullSecrets {
+		if _, ok := listed[name]; ok {
+			continue
+		}
+
+		listed[name] = struct{}{}
+		secrets = append(secrets, map[string]any{"name": name})
+		attached = true
+	}
+
+	if !attached {
+		return false, nil
+	}
+
+	return true, unstructured.SetNestedSlice(obj.Object, secrets, "imagePullSecrets")
+}
@@ -0,0 +1,159 @@
+package mem_test
+
+import (
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const workloadsYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:1
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          serviceAccount: backup
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  serviceAccountName: debug
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: app
+imagePullSecrets:
+- name: registry
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+
+// serviceAccountOf returns the service account referenced at path.
+func serviceAccountOf(g *WithT, obj unstructured.Unstructured, path ...string) string {
+	name, _, err := unstructured.NestedString(obj.Object, append(path, "serviceAccountName")...)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return name
+}
+
+func TestServiceAccountWiring(t *testing.T) {
+
+	t.Run("should wire workloads without a service account", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(workloadsYAML)},
+			mem.WithServiceAccountWiring(mem.ServiceAccountWiring{ServiceAccountName: "app"}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(serviceAccountOf(g, objects[0], "spec", "template", "spec")).To(Equal("app"))
+		g.Expect(serviceAccountOf(g, objects[1], "spec", "jobTemplate", "spec", "template", "spec")).To(BeEmpty())
+		g.Expect(serviceAccountOf(g, objects[2], "spec")).To(Equal("debug"))
+		g.Expect(objects[4].Object).ToNot(HaveKey("spec"))
+	})
+
+	t.Run("should override referenced service accounts", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(workloadsYAML)},
+			mem.WithServiceAccountWiring(mem.ServiceAccountWiring{ServiceAccountName: "app", Override: true}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		path := []string{"spec", "jobTemplate", "spec", "template", "spec"}
+		g.Expect(serviceAccountOf(g, objects[1], path...)).To(Equal("app"))
+		g.Expect(unstructured.NestedFieldNoCopy(objects[1].Object, append(path, "serviceAccount")...)).To(BeNil())
+		g.Expect(serviceAccountOf(g, objects[2], "spec")).To(Equal("app"))
+	})
+
+	t.Run("should attach image pull secrets to service accounts", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(workloadsYAML)},
+			mem.WithServiceAccountWiring(mem.ServiceAccountWiring{ImagePullSecrets: []string{"registry", "mirror"}}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[3].Object["imagePullSecrets"]).To(Equal([]any{
+			map[string]any{"name": "registry"},
+			map[string]any{"name": "mirror"},
+		}))
+		g.Expect(serviceAccountOf(g, objects[0], "spec", "template", "spec")).To(BeEmpty())
+	})
+
+	t.Run("should refresh the content hash of wired objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		plain, err := mem.New([]mem.Source{mem.MustSourceFromYAML(workloadsYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		wired, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(workloadsYAML)},
+			mem.WithServiceAccountWiring(mem.ServiceAccountWiring{ServiceAccountName: "app"}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		before, err := plain.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		after, err := wired.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		hash := func(obj unstructured.Unstructured) string {
+			return obj.GetAnnotations()[pkgtypes.AnnotationContentHash]
+		}
+
+		g.Expect(hash(after[0])).ToNot(Equal(hash(before[0])))
+		g.Expect(hash(after[4])).To(Equal(hash(before[4])))
+	})
+
+	t.Run("should fail on malformed pod specs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML("apiVersion: v1\nkind: Pod\nmetadata:\n  name: bad\nspec: [1]\n")},
+			mem.WithServiceAccountWiring(mem.ServiceAccountWiring{ServiceAccountName: "app"}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("service account wiring error in mem renderer: v1/Pod//bad")))
+	})
+}
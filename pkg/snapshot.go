@@ -0,0 +1,314 @@
+package mem
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SnapshotVersion is the archive format version written by WriteSnapshot.
+	SnapshotVersion = 1
+
+	snapshotManifestFile = "snapshot.json"
+	snapshotObjectsDir   = "objects/"
+	snapshotFilePerm     = 0o644
+)
+
+// Snapshot describes the content of a cluster snapshot archive.
+type Snapshot struct {
+	// Version is the archive format version.
+	Version int `json:"version"`
+
+	// Summary summarizes the whole object set; its RenderedHash is verified
+	// on import.
+	Summary RenderSummary `json:"summary"`
+
+	// Inventory lists the objects of the snapshot, in render order.
+	Inventory []SnapshotEntry `json:"inventory"`
+}
+
+// SnapshotEntry is the inventory record of one object of a snapshot.
+type SnapshotEntry struct {
+	// File is the path of the object within the archive.
+	File string `json:"file"`
+
+	// APIVersion, Kind, Namespace, and Name identify the object.
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+
+	// Hash is the content hash of the object, as computed for the content
+	// hash annotation.
+	Hash string `json:"hash"`
+}
+
+// Key returns the ObjectKeyOf of the object recorded by e.
+func (e SnapshotEntry) Key() string {
+	return ObjectKey{
+		GroupVersionKind: schema.FromAPIVersionAndKind(e.APIVersion, e.Kind),
+		Namespace:        e.Namespace,
+		Name:             e.Name,
+	}.String()
+}
+
+// WriteSnapshot writes objects, typically the full output of a render, to w
+// as a cluster snapshot: a gzip-compressed tar archive holding a
+// snapshot.json inventory followed by one YAML file per object under
+// objects/, prefixed with its index so objects sharing an identity are kept.
+// ReadSnapshot restores the objects, for backups or to clone an environment.
+//
+// The archive only depends on the objects: writing the same objects twice
+// produces identical bytes. Fields selected by WithExportRedaction are masked
+// before anything is written, and the inventory and summary hashes are
+// computed over the masked objects, so the archive still verifies on import;
+// restoring it yields the masked values, not the real ones.
+func WriteSnapshot(w io.Writer, objects []unstructured.Unstructured, opts ...ExportOption) error {
+	redactor, err := newRedactor(opts)
+	if err != nil {
+		return err
+	}
+
+	objects, err = redactor.redactAll(objects)
+	if err != nil {
+		return err
+	}
+
+	snapshot := Snapshot{
+		Version:   SnapshotVersion,
+		Summary:   Summarize(objects),
+		Inventory: make([]SnapshotEntry, 0, len(objects)),
+	}
+
+	files := make([][]byte, 0, len(objects))
+
+	for i := range objects {
+		obj := &objects[i]
+
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return fmt.Errorf("failed to encode object at index %d (%s): %w", i, ObjectKeyOf(*obj), err)
+		}
+
+		files = append(files, data)
+		snapshot.Inventory = append(snapshot.Inventory, SnapshotEntry{
+			File:       fmt.Sprintf("%s%04d_%s", snapshotObjectsDir, i, manifestFileName(obj)),
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			Hash:       contentHashOf(obj),
+		})
+	}
+
+	manifest, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", snapshotManifestFile, err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeSnapshotFile(tw, snapshotManifestFile, manifest); err != nil {
+		return err
+	}
+
+	for i, entry := range snapshot.Inventory {
+		if err := writeSnapshotFile(tw, entry.File, files[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func writeSnapshotFile(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     snapshotFilePerm,
+		Size:     int64(len(data)),
+		ModTime:  time.Unix(0, 0),
+		Format:   tar.FormatPAX,
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// ReadSnapshot reads a cluster snapshot written by WriteSnapshot and returns
+// a Source holding its objects in their original order, together with the
+// snapshot's description. Positions record the file of each object within
+// the archive.
+//
+// The archive is verified before anything is returned: every inventory entry
+// must have its file and every file an entry (ErrInvalidSnapshot), each object
+// must match the identity and content hash recorded for it, and the set must
+// match the summary hash (ErrSnapshotHashMismatch). Files are decoded with
+// NewYAMLDecoder(opts...), so the decoder's YAMLLimits also bound the size of
+// every file in the archive.
+func ReadSnapshot(r io.Reader, opts ...YAMLOption) (Source, Snapshot, error) {
+	decoder := NewYAMLDecoder(opts...)
+
+	files, err := readSnapshotFiles(r, decoder.opts.Limits.MaxInputSize)
+	if err != nil {
+		return Source{}, Snapshot{}, err
+	}
+
+	manifest, ok := files[snapshotManifestFile]
+	if !ok {
+		return Source{}, Snapshot{}, fmt.Errorf("%w: %s not found", ErrInvalidSnapshot, snapshotManifestFile)
+	}
+
+	delete(files, snapshotManifestFile)
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(manifest, &snapshot); err != nil {
+		return Source{}, Snapshot{}, fmt.Errorf("%w: failed to decode %s: %w",
+			ErrInvalidSnapshot, snapshotManifestFile, err)
+	}
+
+	if snapshot.Version != SnapshotVersion {
+		return Source{}, Snapshot{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, snapshot.Version)
+	}
+
+	source := Source{
+		Objects:   make([]unstructured.Unstructured, 0, len(snapshot.Inventory)),
+		Positions: make([]Position, 0, len(snapshot.Inventory)),
+	}
+
+	for _, entry := range snapshot.Inventory {
+		data, ok := files[entry.File]
+		if !ok {
+			return Source{}, Snapshot{}, fmt.Errorf("%w: %s not found", ErrInvalidSnapshot, entry.File)
+		}
+
+		delete(files, entry.File)
+
+		obj, position, err := decoder.snapshotObject(entry, data)
+		if err != nil {
+			return Source{}, Snapshot{}, err
+		}
+
+		source.Objects = append(source.Objects, obj)
+		source.Positions = append(source.Positions, position)
+	}
+
+	if len(files) > 0 {
+		return Source{}, Snapshot{}, fmt.Errorf("%w: %s is not in the inventory",
+			ErrInvalidSnapshot, strings.Join(slices.Sorted(maps.Keys(files)), ", "))
+	}
+
+	if hash := Summarize(source.Objects).RenderedHash; hash != snapshot.Summary.RenderedHash {
+		return Source{}, Snapshot{}, fmt.Errorf("%w: object set hashes to %s, expected %s",
+			ErrSnapshotHashMismatch, hash, snapshot.Summary.RenderedHash)
+	}
+
+	return source, snapshot, nil
+}
+
+// SourceFromSnapshot is like ReadSnapshot, for callers that only need the objects.
+func SourceFromSnapshot(r io.Reader, opts ...YAMLOption) (Source, error) {
+	source, _, err := ReadSnapshot(r, opts...)
+
+	return source, err
+}
+
+// readSnapshotFiles reads the regular files of a snapshot archive, each at
+// most limit bytes long.
+func readSnapshotFiles(r io.Reader, limit int) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+
+	defer func() { _ = gz.Close() }()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%w: %s is not a regular file", ErrInvalidSnapshot, header.Name)
+		}
+
+		if _, dup := files[header.Name]; dup {
+			return nil, fmt.Errorf("%w: %s appears twice", ErrInvalidSnapshot, header.Name)
+		}
+
+		data, err := readLimited(tr, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+
+		files[header.Name] = data
+	}
+}
+
+// snapshotObject decodes the object of entry and verifies it against entry.
+func (d *YAMLDecoder) snapshotObject(entry SnapshotEntry, data []byte) (unstructured.Unstructured, Position, error) {
+	if !strings.HasPrefix(entry.File, snapshotObjectsDir) {
+		return unstructured.Unstructured{}, Position{}, fmt.Errorf("%w: %s is outside %s",
+			ErrInvalidSnapshot, entry.File, snapshotObjectsDir)
+	}
+
+	source, err := d.named(entry.File, data)
+	if err != nil {
+		return unstructured.Unstructured{}, Position{}, err
+	}
+
+	if len(source.Objects) != 1 {
+		return unstructured.Unstructured{}, Position{}, fmt.Errorf("%w: %s holds %d objects instead of one",
+			ErrInvalidSnapshot, entry.File, len(source.Objects))
+	}
+
+	obj := source.Objects[0]
+
+	if key := ObjectKeyOf(obj); key != entry.Key() {
+		return unstructured.Unstructured{}, Position{}, fmt.Errorf("%w: %s holds %s instead of %s",
+			ErrInvalidSnapshot, entry.File, key, entry.Key())
+	}
+
+	if hash := contentHashOf(&obj); hash != entry.Hash {
+		return unstructured.Unstructured{}, Position{}, fmt.Errorf("%w: %s hashes to %s, expected %s",
+			ErrSnapshotHashMismatch, entry.File, hash, entry.Hash)
+	}
+
+	return obj, source.Positions[0], nil
+}
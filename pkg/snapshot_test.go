@@ -0,0 +1,220 @@
+package mem_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	render := func(g *WithT) []unstructured.Unstructured {
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(multiDocYAML, configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return objects
+	}
+
+	t.Run("should round trip a render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(g)
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteSnapshot(&buf, objects)).To(Succeed())
+
+		source, snapshot, err := mem.ReadSnapshot(&buf)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Objects).To(Equal(objects))
+		g.Expect(snapshot.Version).To(Equal(mem.SnapshotVersion))
+		g.Expect(snapshot.Summary).To(Equal(mem.Summarize(objects)))
+		g.Expect(snapshot.Inventory).To(HaveLen(3))
+		g.Expect(snapshot.Inventory[1]).To(And(
+			HaveField("File", "objects/0001_deployment.apps_second.yaml"),
+			HaveField("APIVersion", "apps/v1"),
+			HaveField("Kind", "Deployment"),
+			HaveField("Name", "second"),
+		))
+		g.Expect(source.Positions[1]).To(Equal(mem.Position{File: "objects/0001_deployment.apps_second.yaml", Line: 1}))
+
+		// The restored source renders the same objects.
+		renderer, err := mem.New([]mem.Source{source})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		restored, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(mem.Summarize(restored).RenderedHash).To(Equal(snapshot.Summary.RenderedHash))
+	})
+
+	t.Run("should write identical archives for identical objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var a, b bytes.Buffer
+		g.Expect(mem.WriteSnapshot(&a, render(g))).To(Succeed())
+		g.Expect(mem.WriteSnapshot(&b, render(g))).To(Succeed())
+		g.Expect(a.Bytes()).To(Equal(b.Bytes()))
+	})
+
+	t.Run("should keep objects sharing an identity", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := mem.MustUnstructured(configMapYAML)
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteSnapshot(&buf, []unstructured.Unstructured{obj, obj})).To(Succeed())
+
+		source, err := mem.SourceFromSnapshot(&buf)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Objects).To(HaveLen(2))
+	})
+
+	t.Run("should reject tampered archives", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			edit   func(files map[string]string)
+			target error
+		}{
+			{
+				name: "modified object",
+				edit: func(files map[string]string) {
+					files["objects/0002_configmap_test-config.yaml"] = strings.Replace(
+						files["objects/0002_configmap_test-config.yaml"], "value", "other", 1)
+				},
+				target: mem.ErrSnapshotHashMismatch,
+			},
+			{
+				name: "modified hash annotation",
+				edit: func(files map[string]string) {
+					files["objects/0000_configmap_first.yaml"] = strings.Replace(
+						files["objects/0000_configmap_first.yaml"], "sha256:", "sha256:0", 1)
+				},
+				target: mem.ErrSnapshotHashMismatch,
+			},
+			{
+				name: "renamed object",
+				edit: func(files map[string]string) {
+					files["objects/0000_configmap_first.yaml"] = strings.Replace(
+						files["objects/0000_configmap_first.yaml"], "name: first", "name: other", 1)
+				},
+				target: mem.ErrInvalidSnapshot,
+			},
+			{
+				name: "missing object",
+				edit: func(files map[string]string) {
+					delete(files, "objects/0001_deployment.apps_second.yaml")
+				},
+				target: mem.ErrInvalidSnapshot,
+			},
+			{
+				name: "extra file",
+				edit: func(files map[string]string) {
+					files["objects/extra.yaml"] = configMapYAML
+				},
+				target: mem.ErrInvalidSnapshot,
+			},
+			{
+				name: "missing inventory",
+				edit: func(files map[string]string) {
+					delete(files, "snapshot.json")
+				},
+				target: mem.ErrInvalidSnapshot,
+			},
+			{
+				name: "unsupported version",
+				edit: func(files map[string]string) {
+					files["snapshot.json"] = strings.Replace(files["snapshot.json"], `"version": 1`, `"version": 2`, 1)
+				},
+				target: mem.ErrInvalidSnapshot,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				g := NewWithT(t)
+
+				var buf bytes.Buffer
+				g.Expect(mem.WriteSnapshot(&buf, render(g))).To(Succeed())
+
+				files := readArchive(g, &buf)
+				g.Expect(files).To(HaveKey("objects/0002_configmap_test-config.yaml"))
+
+				tt.edit(files)
+
+				_, err := mem.SourceFromSnapshot(writeArchive(g, files))
+				g.Expect(errors.Is(err, tt.target)).To(BeTrue(), "unexpected error: %v", err)
+			})
+		}
+	})
+
+	t.Run("should reject input that is not an archive", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.SourceFromSnapshot(strings.NewReader(configMapYAML))
+		g.Expect(errors.Is(err, mem.ErrInvalidSnapshot)).To(BeTrue())
+	})
+
+	t.Run("should bound the size of archive files", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var buf bytes.Buffer
+		g.Expect(mem.WriteSnapshot(&buf, render(g))).To(Succeed())
+
+		_, err := mem.SourceFromSnapshot(&buf, mem.WithYAMLLimits(mem.YAMLLimits{MaxInputSize: 64}))
+		g.Expect(errors.Is(err, mem.ErrYAMLLimitExceeded)).To(BeTrue())
+	})
+}
+
+func readArchive(g *WithT, r io.Reader) map[string]string {
+	gz, err := gzip.NewReader(r)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+
+		g.Expect(err).ToNot(HaveOccurred())
+
+		data, err := io.ReadAll(tr)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		files[header.Name] = string(data)
+	}
+}
+
+func writeArchive(g *WithT, files map[string]string) io.Reader {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for name, data := range files {
+		header := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(data))}
+		g.Expect(tw.WriteHeader(header)).To(Succeed())
+
+		_, err := tw.Write([]byte(data))
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	g.Expect(tw.Close()).To(Succeed())
+	g.Expect(gz.Close()).To(Succeed())
+
+	return &buf
+}
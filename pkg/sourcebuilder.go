@@ -0,0 +1,251 @@
+package mem
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Validate reports fields of s that contradict each other, which would
+// otherwise be ignored or fail late, wrapped in ErrInvalidSource: a
+// Fingerprint on a Stream source, which is never cached, or without an
+// ObjectsFn to stand in for, and nil filters, transformers, or post-renderers. It does not validate the objects
+// themselves, which depends on the options of the renderer; New and the
+// source mutations of a Renderer call it even with WithLazyValidation.
+func (s Source) Validate() error {
+	if s.Fingerprint != nil && s.Stream != nil {
+		return fmt.Errorf("%w: Fingerprint has no effect on a source with a Stream", ErrInvalidSource)
+	}
+
+	if s.Fingerprint != nil && s.ObjectsFn == nil {
+		return fmt.Errorf("%w: Fingerprint has no effect on a source without an ObjectsFn", ErrInvalidSource)
+	}
+
+	if i := slices.IndexFunc(s.Filters, func(f types.Filter) bool { return f == nil }); i >= 0 {
+		return fmt.Errorf("%w: nil filter at index %d", ErrInvalidSource, i)
+	}
+
+	if i := slices.IndexFunc(s.Transformers, func(t types.Transformer) bool { return t == nil }); i >= 0 {
+		return fmt.Errorf("%w: nil transformer at index %d", ErrInvalidSource, i)
+	}
+
+	if i := slices.IndexFunc(s.PostRenderers, func(p types.PostRenderer) bool { return p == nil }); i >= 0 {
+		return fmt.Errorf("%w: nil post-renderer at index %d", ErrInvalidSource, i)
+	}
+
+	return nil
+}
+
+// SourceBuilder constructs a Source step by step. Unlike a Source literal, it
+// keeps compiling as Source gains fields, and Build validates the result as
+// New would, so contradictory settings fail where the source is built rather
+// than at render time. Methods adding to a list append to it, methods setting
+// a single field replace it. The first error, such as a YAML document that
+// cannot be decoded, is returned by Build; later calls are then ignored.
+//
+// A SourceBuilder is not safe for concurrent use. Sources returned by Build
+// do not share slices or maps with the builder, so it may be reused.
+type SourceBuilder struct {
+	source Source
+	err    error
+}
+
+// NewSourceBuilder creates a builder of a Source called name, which may be
+// empty for sources that are never removed or replaced.
+func NewSourceBuilder(name string) *SourceBuilder {
+	return &SourceBuilder{source: Source{Name: name}}
+}
+
+// Objects appends objects to the Objects of the source. If earlier objects
+// have positions, the new ones get the zero Position, so positions stay
+// aligned with their objects.
+func (b *SourceBuilder) Objects(objects ...unstructured.Unstructured) *SourceBuilder {
+	if b.err == nil {
+		b.source.Objects = append(b.source.Objects, objects...)
+
+		if len(b.source.Positions) > 0 {
+			b.source.Positions = append(b.source.Positions, make([]Position, len(objects))...)
+		}
+	}
+
+	return b
+}
+
+// YAML decodes docs as SourceFromYAML does and appends their objects to the
+// Objects of the source, keeping their positions.
+func (b *SourceBuilder) YAML(docs ...string) *SourceBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	decoded, err := SourceFromYAML(docs...)
+	if err != nil {
+		b.err = err
+
+		return b
+	}
+
+	// Objects appended without a position get the zero Position.
+	positions := make([]Position, len(b.source.Objects), len(b.source.Objects)+len(decoded.Positions))
+	copy(positions, b.source.Positions)
+
+	b.source.Positions = append(positions, decoded.Positions...)
+	b.source.Objects = append(b.source.Objects, decoded.Objects...)
+
+	return b
+}
+
+// ObjectsFn sets the ObjectsFn of the source, and fingerprint, which may be
+// nil, as its Fingerprint.
+func (b *SourceBuilder) ObjectsFn(fn ObjectsFunc, fingerprint FingerprintFunc) *SourceBuilder {
+	if b.err == nil {
+		b.source.ObjectsFn = fn
+		b.source.Fingerprint = fingerprint
+	}
+
+	return b
+}
+
+// Manifests appends manifests to the Manifests of the source.
+func (b *SourceBuilder) Manifests(manifests ...string) *SourceBuilder {
+	if b.err == nil {
+		b.source.Manifests = append(b.source.Manifests, manifests...)
+	}
+
+	return b
+}
+
+// Stream sets the Stream of the source.
+func (b *SourceBuilder) Stream(stream *Stream) *SourceBuilder {
+	if b.err == nil {
+		b.source.Stream = stream
+	}
+
+	return b
+}
+
+// Labels adds labels to the Labels of the source; later values of a key win.
+func (b *SourceBuilder) Labels(labels map[string]string) *SourceBuilder {
+	if b.err == nil {
+		b.source.Labels = mergeStringMaps(b.source.Labels, labels)
+	}
+
+	return b
+}
+
+// Annotations adds annotations to the Annotations of the source; later values
+// of a key win.
+func (b *SourceBuilder) Annotations(annotations map[string]string) *SourceBuilder {
+	if b.err == nil {
+		b.source.Annotations = mergeStringMaps(b.source.Annotations, annotations)
+	}
+
+	return b
+}
+
+// OverrideMetadata sets the OverrideMetadata of the source.
+func (b *SourceBuilder) OverrideMetadata(enabled bool) *SourceBuilder {
+	if b.err == nil {
+		b.source.OverrideMetadata = enabled
+	}
+
+	return b
+}
+
+// Filters appends filters to the Filters of the source.
+func (b *SourceBuilder) Filters(filters ...types.Filter) *SourceBuilder {
+	if b.err == nil {
+		b.source.Filters = append(b.source.Filters, filters...)
+	}
+
+	return b
+}
+
+// Transformers appends transformers to the Transformers of the source.
+func (b *SourceBuilder) Transformers(transformers ...types.Transformer) *SourceBuilder {
+	if b.err == nil {
+		b.source.Transformers = append(b.source.Transformers, transformers...)
+	}
+
+	return b
+}
+
+// PostRenderers appends post-renderers to the PostRenderers of the source.
+func (b *SourceBuilder) PostRenderers(postRenderers ...types.PostRenderer) *SourceBuilder {
+	if b.err == nil {
+		b.source.PostRenderers = append(b.source.PostRenderers, postRenderers...)
+	}
+
+	return b
+}
+
+// Patches appends patches to the Patches of the source.
+func (b *SourceBuilder) Patches(patches ...Patch) *SourceBuilder {
+	if b.err == nil {
+		b.source.Patches = append(b.source.Patches, patches...)
+	}
+
+	return b
+}
+
+// Deletions appends objects to the Deletions of the source.
+func (b *SourceBuilder) Deletions(deletions ...unstructured.Unstructured) *SourceBuilder {
+	if b.err == nil {
+		b.source.Deletions = append(b.source.Deletions, deletions...)
+	}
+
+	return b
+}
+
+// Build returns the source, or the first error of the builder, or the error
+// of Source.Validate. Patches are validated as well; objects are validated by
+// the renderer, whose options they depend on.
+func (b *SourceBuilder) Build() (Source, error) {
+	if b.err != nil {
+		return Source{}, fmt.Errorf("failed to build source %q: %w", b.source.Name, b.err)
+	}
+
+	source := b.source
+	source.Objects = slices.Clone(source.Objects)
+	source.Manifests = slices.Clone(source.Manifests)
+	source.Positions = slices.Clone(source.Positions)
+	source.Labels = maps.Clone(source.Labels)
+	source.Annotations = maps.Clone(source.Annotations)
+	source.Filters = slices.Clone(source.Filters)
+	source.Transformers = slices.Clone(source.Transformers)
+	source.PostRenderers = slices.Clone(source.PostRenderers)
+	source.Patches = slices.Clone(source.Patches)
+	source.Deletions = slices.Clone(source.Deletions)
+
+	if err := source.Validate(); err != nil {
+		return Source{}, fmt.Errorf("failed to build source %q: %w", source.Name, err)
+	}
+
+	for i, patch := range source.Patches {
+		if err := patch.validate(); err != nil {
+			return Source{}, fmt.Errorf("failed to build source %q: patch at index %d: %w", source.Name, i, err)
+		}
+	}
+
+	return source, nil
+}
+
+// mergeStringMaps returns dst with the entries of src added, allocating dst
+// if needed.
+func mergeStringMaps(dst map[string]string, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+
+	maps.Copy(dst, src)
+
+	return dst
+}
@@ -0,0 +1,155 @@
+package mem_test
+
+import (
+	"context"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSourceBuilder(t *testing.T) {
+
+	t.Run("should build the source a literal would", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source, err := mem.NewSourceBuilder("app").
+			Objects(composeObject("v1", "Secret", "default", "token")).
+			YAML(configMapYAML).
+			Labels(map[string]string{"team": "a", "tier": "web"}).
+			Labels(map[string]string{"team": "b"}).
+			Deletions(composeObject("v1", "Service", "default", "legacy")).
+			Build()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Name).To(Equal("app"))
+		g.Expect(source.Labels).To(Equal(map[string]string{"team": "b", "tier": "web"}))
+		g.Expect(source.Deletions).To(HaveLen(1))
+
+		renderer, err := mem.New([]mem.Source{source}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"Secret/token", "ConfigMap/test-config"}))
+
+		_, ok, err := mem.SourcePosition(objects[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ok).To(BeFalse())
+
+		position, ok, err := mem.SourcePosition(objects[1])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ok).To(BeTrue())
+		g.Expect(position.Line).To(Equal(1))
+	})
+
+	t.Run("should keep positions aligned when objects follow YAML", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source, err := mem.NewSourceBuilder("").
+			YAML(configMapYAML).
+			Objects(composeObject("v1", "Secret", "default", "token")).
+			Build()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Positions).To(HaveLen(2))
+		g.Expect(source.Positions[1]).To(Equal(mem.Position{}))
+
+		renderer, err := mem.New([]mem.Source{source})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Warnings()).To(BeEmpty())
+
+		provenance, ok := result.Provenance(0)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Position).ToNot(BeNil())
+		g.Expect(provenance.Position.Line).To(Equal(1))
+	})
+
+	t.Run("should not share state between builds", func(t *testing.T) {
+		g := NewWithT(t)
+
+		builder := mem.NewSourceBuilder("").Labels(map[string]string{"team": "a"})
+
+		first, err := builder.Build()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		second, err := builder.Objects(cachedConfig("a")).Labels(map[string]string{"team": "b"}).Build()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(first.Objects).To(BeEmpty())
+		g.Expect(first.Labels).To(HaveKeyWithValue("team", "a"))
+		g.Expect(second.Objects).To(HaveLen(1))
+		g.Expect(second.Labels).To(HaveKeyWithValue("team", "b"))
+	})
+
+	t.Run("should report the first error at Build", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.NewSourceBuilder("broken").
+			YAML("kind: [").
+			YAML(configMapYAML).
+			Build()
+		g.Expect(err).To(MatchError(ContainSubstring(`failed to build source "broken"`)))
+		g.Expect(err).To(MatchError(ContainSubstring("invalid YAML at index 0")))
+
+		_, err = mem.NewSourceBuilder("").Patches(mem.Patch{Target: mem.PatchTarget{Kind: "ConfigMap"}}).Build()
+		g.Expect(err).To(MatchError(mem.ErrInvalidPatch))
+	})
+
+	t.Run("should reject contradictory fields", func(t *testing.T) {
+		g := NewWithT(t)
+
+		fingerprint := func(context.Context, pkgtypes.Values) (string, error) { return "v1", nil }
+		stream := mem.NewChannelStream(make(chan unstructured.Unstructured), mem.StreamSnapshot)
+
+		_, err := mem.NewSourceBuilder("").Stream(stream).ObjectsFn(nil, fingerprint).Build()
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+
+		_, err = mem.NewSourceBuilder("").ObjectsFn(nil, fingerprint).Build()
+		g.Expect(err).To(MatchError(ContainSubstring("without an ObjectsFn")))
+
+		_, err = mem.NewSourceBuilder("").Transformers(nil).Build()
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+		g.Expect(err).To(MatchError(ContainSubstring("nil transformer at index 0")))
+	})
+}
+
+func TestSourceValidate(t *testing.T) {
+
+	t.Run("should reject contradictory sources at New even with lazy validation", func(t *testing.T) {
+		g := NewWithT(t)
+
+		invalid := mem.Source{Objects: configMaps(1), PostRenderers: []pkgtypes.PostRenderer{nil}}
+
+		_, err := mem.New([]mem.Source{invalid})
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+		g.Expect(err).To(MatchError(ContainSubstring("invalid source at index 0")))
+
+		_, err = mem.New([]mem.Source{invalid}, mem.WithLazyValidation(true))
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+	})
+
+	t.Run("should reject contradictory sources added later", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = renderer.AddSource(mem.Source{Name: "app", Filters: []pkgtypes.Filter{nil}})
+		g.Expect(err).To(MatchError(mem.ErrInvalidSource))
+		g.Expect(err).To(MatchError(ContainSubstring("nil filter at index 0")))
+	})
+
+	t.Run("should accept sources without contradictions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(mem.Source{}.Validate()).To(Succeed())
+		g.Expect(mem.MustSourceFromYAML(configMapYAML).Validate()).To(Succeed())
+	})
+}
@@ -0,0 +1,75 @@
+package mem
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AnnotationSourceIndex is the annotation key for the index of the source an
+// object was rendered from among the inputs of its renderer. It is written
+// alongside the other source annotations.
+const AnnotationSourceIndex = "manifests.k8s-manifests-kit/source.index"
+
+// AnnotationObjectIndex is the annotation key for the index of the object an
+// object was rendered from within its source: objects of Objects first, then
+// those generated by ObjectsFn, decoded from Manifests, and received from a
+// Stream. Objects expanded from a List share the index of the List.
+const AnnotationObjectIndex = "manifests.k8s-manifests-kit/source.object-index"
+
+// SourceIndex locates the input object a rendered object was produced from.
+type SourceIndex struct {
+	// Source is the index of the source in the renderer's inputs.
+	Source int
+
+	// Object is the index of the object within the source.
+	Object int
+}
+
+// SourceIndexOf returns the indexes recorded in the AnnotationSourceIndex and
+// AnnotationObjectIndex annotations of obj, and whether it has both. Unlike
+// the source name and position, they are known for every object, which makes
+// them the way to trace objects of unnamed in-memory sources back to their
+// input. Indexes refer to the sources of the renderer at the time of the
+// render, which later source mutations may shift.
+func SourceIndexOf(obj unstructured.Unstructured) (SourceIndex, bool, error) {
+	annotations := obj.GetAnnotations()
+
+	rawSource, hasSource := annotations[AnnotationSourceIndex]
+	rawObject, hasObject := annotations[AnnotationObjectIndex]
+
+	if !hasSource || !hasObject {
+		return SourceIndex{}, false, nil
+	}
+
+	source, err := strconv.Atoi(rawSource)
+	if err != nil || source < 0 {
+		return SourceIndex{}, false, fmt.Errorf("%w: source index %q", ErrInvalidSourceIndex, rawSource)
+	}
+
+	object, err := strconv.Atoi(rawObject)
+	if err != nil || object < 0 {
+		return SourceIndex{}, false, fmt.Errorf("%w: object index %q", ErrInvalidSourceIndex, rawObject)
+	}
+
+	return SourceIndex{Source: source, Object: object}, true, nil
+}
+
+// setSourceIndex records that obj was rendered from the k-th object of the
+// index-th source. Objects that belong to no source of the renderer, such as
+// those injected by ProcessFromStage, have a negative index and lose any
+// indexes carried over from an earlier render instead.
+func setSourceIndex(obj *unstructured.Unstructured, index int, k int) {
+	if index < 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", AnnotationSourceIndex)
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", AnnotationObjectIndex)
+
+		return
+	}
+
+	k8s.SetAnnotation(obj, AnnotationSourceIndex, strconv.Itoa(index))
+	k8s.SetAnnotation(obj, AnnotationObjectIndex, strconv.Itoa(k))
+}
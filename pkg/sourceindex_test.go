@@ -0,0 +1,133 @@
+package mem_test
+
+import (
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSourceIndexAnnotations(t *testing.T) {
+
+	t.Run("should trace objects of unnamed sources to their input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{
+				{Objects: configMaps(2)},
+				{
+					Objects:   []unstructured.Unstructured{composeObject("v1", "Secret", "default", "token")},
+					Manifests: []string{multiDocYAML},
+				},
+			},
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{
+			"ConfigMap/cm-0", "ConfigMap/cm-1", "Secret/token", "ConfigMap/first", "Deployment/second",
+		}))
+
+		indexes := make([]mem.SourceIndex, 0, len(objects))
+		for _, obj := range objects {
+			index, ok, err := mem.SourceIndexOf(obj)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ok).To(BeTrue())
+
+			indexes = append(indexes, index)
+		}
+
+		g.Expect(indexes).To(Equal([]mem.SourceIndex{
+			{Source: 0, Object: 0},
+			{Source: 0, Object: 1},
+			{Source: 1, Object: 0},
+			{Source: 1, Object: 1},
+			{Source: 1, Object: 2},
+		}))
+	})
+
+	t.Run("should not annotate without source annotations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: configMaps(1)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, ok, err := mem.SourceIndexOf(objects[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("should not affect content hashes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(1)}, {Objects: configMaps(1)}},
+			mem.WithSourceAnnotations(true),
+			mem.WithDuplicatePolicy(mem.DuplicateKeepAll),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationSourceIndex, "0"))
+		g.Expect(objects[1].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationSourceIndex, "1"))
+
+		g.Expect(objects[1].GetAnnotations()[pkgtypes.AnnotationContentHash]).
+			To(Equal(objects[0].GetAnnotations()[pkgtypes.AnnotationContentHash]))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should replace the indexes of re-ingested objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first, err := mem.New([]mem.Source{{}, {Objects: configMaps(2)}}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		rendered, err := first.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		second, err := mem.New([]mem.Source{{Objects: rendered[1:]}}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := second.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		index, ok, err := mem.SourceIndexOf(objects[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ok).To(BeTrue())
+		g.Expect(index).To(Equal(mem.SourceIndex{Source: 0, Object: 0}))
+
+		injected, err := second.ProcessFromStage(t.Context(), mem.StageSource, rendered)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for _, obj := range injected {
+			_, ok, err := mem.SourceIndexOf(obj)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ok).To(BeFalse())
+		}
+	})
+
+	t.Run("should reject malformed indexes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := composeObject("v1", "ConfigMap", "default", "broken")
+		obj.SetAnnotations(map[string]string{
+			mem.AnnotationSourceIndex: "0",
+			mem.AnnotationObjectIndex: "-1",
+		})
+
+		_, _, err := mem.SourceIndexOf(obj)
+		g.Expect(err).To(MatchError(mem.ErrInvalidSourceIndex))
+	})
+}
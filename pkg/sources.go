@@ -0,0 +1,236 @@
+package mem
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AnnotationSourceName is the annotation key for the name of the source an
+// object was rendered from, set by WithSourceAnnotations on the objects of
+// named sources.
+const AnnotationSourceName = "manifests.k8s-manifests-kit/source.name"
+
+// Sources returns the names of the renderer's sources, in render order, with
+// an empty string for each unnamed source. Merged renderers have no sources
+// of their own.
+func (r *Renderer) Sources() []string {
+	inputs := r.sources()
+
+	names := make([]string, len(inputs))
+	for i, holder := range inputs {
+		names[i] = holder.Name
+	}
+
+	return names
+}
+
+// sources returns the current source list. The list is never modified in
+// place, so callers may keep reading it without holding the lock.
+func (r *Renderer) sources() []*sourceHolder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.inputs
+}
+
+// AddSource appends source to the sources of the renderer. It is validated
+// as New would validate it; see SourceTx for the errors.
+func (r *Renderer) AddSource(source Source) error {
+	return r.Begin().AddSource(source).Commit()
+}
+
+// RemoveSource removes the source called name.
+func (r *Renderer) RemoveSource(name string) error {
+	return r.Begin().RemoveSource(name).Commit()
+}
+
+// ReplaceSource replaces the source called name with source, in place.
+func (r *Renderer) ReplaceSource(name string, source Source) error {
+	return r.Begin().ReplaceSource(name, source).Commit()
+}
+
+// SourceTx stages changes to the sources of a renderer and applies them
+// together: renders started before Commit see none of them, renders started
+// after see all of them. Changes are applied in the order they were staged,
+// to the sources the renderer has at Commit time, so concurrent transactions
+// never conflict. A SourceTx is not safe for concurrent use.
+type SourceTx struct {
+	r    *Renderer
+	ops  []sourceOp
+	done bool
+}
+
+// sourceOp is a staged change; it modifies inputs, a private copy.
+type sourceOp func(inputs []*sourceHolder, opts *RendererOptions) ([]*sourceHolder, error)
+
+// Begin starts a transaction on the sources of the renderer.
+func (r *Renderer) Begin() *SourceTx {
+	return &SourceTx{r: r}
+}
+
+// AddSource stages appending source.
+func (tx *SourceTx) AddSource(source Source) *SourceTx {
+	return tx.stage(func(inputs []*sourceHolder, opts *RendererOptions) ([]*sourceHolder, error) {
+		if err := checkSourceName(inputs, source.Name); err != nil {
+			return nil, err
+		}
+
+		holder, err := newSourceHolder(source, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(inputs, holder), nil
+	})
+}
+
+// RemoveSource stages removing the source called name.
+func (tx *SourceTx) RemoveSource(name string) *SourceTx {
+	return tx.stage(func(inputs []*sourceHolder, _ *RendererOptions) ([]*sourceHolder, error) {
+		index, err := sourceIndex(inputs, name)
+		if err != nil {
+			return nil, err
+		}
+
+		return slices.Delete(inputs, index, index+1), nil
+	})
+}
+
+// ReplaceSource stages replacing the source called name with source, which
+// keeps its position. An unnamed replacement takes the name of the source it
+// replaces.
+func (tx *SourceTx) ReplaceSource(name string, source Source) *SourceTx {
+	return tx.stage(func(inputs []*sourceHolder, opts *RendererOptions) ([]*sourceHolder, error) {
+		index, err := sourceIndex(inputs, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if source.Name == "" {
+			source.Name = name
+		}
+
+		others := slices.Delete(slices.Clone(inputs), index, index+1)
+		if err := checkSourceName(others, source.Name); err != nil {
+			return nil, err
+		}
+
+		holder, err := newSourceHolder(source, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs[index] = holder
+
+		return inputs, nil
+	})
+}
+
+// Upsert stages replacing the source with the name of source, or appending
+// source if there is none. Unnamed sources are always appended.
+func (tx *SourceTx) Upsert(source Source) *SourceTx {
+	return tx.stage(func(inputs []*sourceHolder, opts *RendererOptions) ([]*sourceHolder, error) {
+		holder, err := newSourceHolder(source, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if index, err := sourceIndex(inputs, source.Name); err == nil {
+			inputs[index] = holder
+
+			return inputs, nil
+		}
+
+		return append(inputs, holder), nil
+	})
+}
+
+// Commit applies the staged changes at once, or none of them if one fails.
+// It fails with ErrReadOnlyRenderer on frozen and merged renderers,
+// ErrSourceNotFound when a change refers to a missing source,
+// ErrDuplicateSource when a name would be used twice, and with the
+// validation error of an invalid source, unless validation is lazy.
+// A transaction can only be committed once (ErrTransactionDone).
+func (tx *SourceTx) Commit() error {
+	if tx.done {
+		return ErrTransactionDone
+	}
+
+	tx.done = true
+
+	r := tx.r
+	if r.frozen || r.merged != nil {
+		return ErrReadOnlyRenderer
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inputs := slices.Clone(r.inputs)
+
+	for i, op := range tx.ops {
+		var err error
+
+		inputs, err = op(inputs, &r.opts)
+		if err != nil {
+			return fmt.Errorf("source change %d: %w", i, err)
+		}
+	}
+
+	r.inputs = inputs
+
+	// Renders of the old sources can no longer be served.
+	r.Invalidate()
+
+	return nil
+}
+
+func (tx *SourceTx) stage(op sourceOp) *SourceTx {
+	tx.ops = append(tx.ops, op)
+
+	return tx
+}
+
+// newSourceHolder wraps source in a holder, validating its objects unless
+// validation is lazy.
+func newSourceHolder(source Source, opts *RendererOptions) (*sourceHolder, error) {
+	if err := source.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid source %q: %w", source.Name, err)
+	}
+
+	holder := &sourceHolder{Source: source}
+
+	if !opts.LazyValidation {
+		if err := holder.Validate(opts); err != nil {
+			return nil, fmt.Errorf("invalid source %q: %w", source.Name, err)
+		}
+	}
+
+	return holder, nil
+}
+
+// sourceIndex returns the index of the source called name.
+func sourceIndex(inputs []*sourceHolder, name string) (int, error) {
+	if name != "" {
+		for i, holder := range inputs {
+			if holder.Name == name {
+				return i, nil
+			}
+		}
+	}
+
+	return -1, fmt.Errorf("%w: %q", ErrSourceNotFound, name)
+}
+
+// checkSourceName fails if a source of inputs is already called name.
+func checkSourceName(inputs []*sourceHolder, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if _, err := sourceIndex(inputs, name); err == nil {
+		return fmt.Errorf("%w: %q", ErrDuplicateSource, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package mem_test
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSourceMutation(t *testing.T) {
+
+	t.Run("should add, replace, and remove named sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Name: "base", Objects: configMaps(1)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(renderer.AddSource(mem.Source{Name: "app", Objects: configMaps(2)})).To(Succeed())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/cm-0", "ConfigMap/cm-0", "ConfigMap/cm-1"}))
+
+		g.Expect(renderer.ReplaceSource("base", mem.MustSourceFromYAML(multiDocYAML))).To(Succeed())
+		g.Expect(renderer.RemoveSource("app")).To(Succeed())
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/first", "Deployment/second"}))
+
+		// The unnamed replacement took over the name of the source it replaced.
+		g.Expect(renderer.RemoveSource("base")).To(Succeed())
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(BeEmpty())
+	})
+
+	t.Run("should reject unknown and duplicate names", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New([]mem.Source{{Name: "a"}, {Name: "a"}})
+		g.Expect(errors.Is(err, mem.ErrDuplicateSource)).To(BeTrue())
+
+		renderer, err := mem.New([]mem.Source{{Name: "a"}, {Name: "b"}, {}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(errors.Is(renderer.AddSource(mem.Source{Name: "a"}), mem.ErrDuplicateSource)).To(BeTrue())
+		g.Expect(errors.Is(renderer.ReplaceSource("a", mem.Source{Name: "b"}), mem.ErrDuplicateSource)).To(BeTrue())
+		g.Expect(errors.Is(renderer.RemoveSource("c"), mem.ErrSourceNotFound)).To(BeTrue())
+		g.Expect(errors.Is(renderer.RemoveSource(""), mem.ErrSourceNotFound)).To(BeTrue())
+		g.Expect(renderer.ReplaceSource("a", mem.Source{Name: "c"})).To(Succeed())
+	})
+
+	t.Run("should validate added sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = renderer.AddSource(mem.Source{Name: "empty", Objects: []unstructured.Unstructured{{}}})
+		g.Expect(errors.Is(err, mem.ErrObjectEmpty)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring(`invalid source "empty"`)))
+
+		lazy, err := mem.New(nil, mem.WithLazyValidation(true))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(lazy.AddSource(mem.Source{Name: "empty", Objects: []unstructured.Unstructured{{}}})).To(Succeed())
+
+		_, err = lazy.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrObjectEmpty)).To(BeTrue())
+	})
+
+	t.Run("should apply transactions atomically", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Name: "a", Objects: configMaps(1)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = renderer.Begin().
+			Upsert(mem.Source{Name: "b", Objects: configMaps(2)}).
+			RemoveSource("a").
+			RemoveSource("missing").
+			Commit()
+		g.Expect(errors.Is(err, mem.ErrSourceNotFound)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring("source change 2")))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+
+		tx := renderer.Begin().
+			Upsert(mem.Source{Name: "b", Objects: configMaps(2)}).
+			Upsert(mem.Source{Name: "b", Objects: configMaps(3)}).
+			RemoveSource("a")
+		g.Expect(tx.Commit()).To(Succeed())
+		g.Expect(errors.Is(tx.Commit(), mem.ErrTransactionDone)).To(BeTrue())
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+	})
+
+	t.Run("should not affect renders already started", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Name: "a", Objects: configMaps(3)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := renderer.NewJob(nil, mem.WithBatchSize(1))
+		done, err := job.Step(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(done).To(BeFalse())
+
+		frozen := renderer.Freeze()
+
+		g.Expect(renderer.RemoveSource("a")).To(Succeed())
+
+		result, err := job.Run(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().DeepCopy()).To(HaveLen(3))
+
+		objects, err := frozen.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+	})
+
+	t.Run("should reject changes to frozen and merged renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Name: "a"}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		merged, err := mem.Merge(renderer, renderer, mem.DuplicateError)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(errors.Is(renderer.Freeze().RemoveSource("a"), mem.ErrReadOnlyRenderer)).To(BeTrue())
+		g.Expect(errors.Is(merged.AddSource(mem.Source{}), mem.ErrReadOnlyRenderer)).To(BeTrue())
+	})
+}
+
+func TestSourceNames(t *testing.T) {
+
+	t.Run("should list the names of the sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Name: "a"}, {}, {Name: "b"}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Sources()).To(Equal([]string{"a", "", "b"}))
+
+		g.Expect(renderer.RemoveSource("a")).To(Succeed())
+		g.Expect(renderer.Sources()).To(Equal([]string{"", "b"}))
+	})
+
+	t.Run("should annotate objects with the name of their source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Name: "app", Objects: configMaps(1)}, mem.MustSourceFromYAML(configMapYAML)},
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects := result.View().DeepCopy()
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(mem.AnnotationSourceName, "app"))
+		g.Expect(objects[1].GetAnnotations()).ToNot(HaveKey(mem.AnnotationSourceName))
+
+		provenance, ok := result.Provenance(0)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(provenance.Name).To(Equal("app"))
+
+		// Re-ingested into an unnamed source, the stale name is removed.
+		reingested, err := mem.New([]mem.Source{{Objects: objects[:1]}}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err = reingested.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationSourceName))
+	})
+
+	t.Run("should not annotate without source annotations", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Name: "app", Objects: configMaps(1)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(mem.AnnotationSourceName))
+	})
+}
@@ -0,0 +1,452 @@
+package mem
+
+import (
+	"maps"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RendererSpec describes a renderer as data, for controllers and CLIs that
+// accept its configuration in a custom resource or a file rather than as Go
+// code. FromSpec builds the renderer it describes. Its JSON field names follow
+// Kubernetes API conventions, and it provides DeepCopyInto so it can be
+// embedded in custom resource types.
+//
+// Only data is covered: callbacks such as filters, transformers, generators,
+// and resolvers have no serializable form and are added as options of
+// FromSpec.
+type RendererSpec struct {
+	// Sources are rendered in order, like the sources of New.
+	Sources []SourceSpec `json:"sources,omitempty"`
+
+	// Options configure the renderer.
+	Options RendererOptionsSpec `json:"options,omitempty"`
+}
+
+// SourceSpec is the serializable part of a Source; see Source for the
+// meaning of each field.
+type SourceSpec struct {
+	Name             string                      `json:"name,omitempty"`
+	Objects          []unstructured.Unstructured `json:"objects,omitempty"`
+	Manifests        []string                    `json:"manifests,omitempty"`
+	Labels           map[string]string           `json:"labels,omitempty"`
+	Annotations      map[string]string           `json:"annotations,omitempty"`
+	OverrideMetadata bool                        `json:"overrideMetadata,omitempty"`
+	Patches          []Patch                     `json:"patches,omitempty"`
+	Deletions        []unstructured.Unstructured `json:"deletions,omitempty"`
+}
+
+// RendererOptionsSpec is the serializable part of RendererOptions. Each field
+// enables the option of the same name; zero values leave the defaults of New
+// in place.
+type RendererOptionsSpec struct {
+	// Name sets WithName.
+	Name string `json:"name,omitempty"`
+
+	// ContentHash sets WithContentHash; nil keeps it enabled.
+	ContentHash *bool `json:"contentHash,omitempty"`
+
+	// ContentHashAlgorithm sets WithContentHashAlgorithm.
+	ContentHashAlgorithm HashAlgorithm `json:"contentHashAlgorithm,omitempty"`
+
+	// ContentHashIgnore sets WithContentHashIgnore.
+	ContentHashIgnore []string `json:"contentHashIgnore,omitempty"`
+
+	// SecretRedaction sets WithSecretRedaction.
+	SecretRedaction bool `json:"secretRedaction,omitempty"`
+
+	// ContentHashStage sets WithContentHashStage.
+	ContentHashStage ContentHashStage `json:"contentHashStage,omitempty"`
+
+	// SourceAnnotations sets WithSourceAnnotations.
+	SourceAnnotations bool `json:"sourceAnnotations,omitempty"`
+
+	// CanonicalMetadata sets WithCanonicalMetadata.
+	CanonicalMetadata bool `json:"canonicalMetadata,omitempty"`
+
+	// Generation sets WithGenerationAnnotation.
+	Generation string `json:"generation,omitempty"`
+
+	// RenderDigest sets WithRenderDigestAnnotation.
+	RenderDigest bool `json:"renderDigest,omitempty"`
+
+	// ProvenanceAnnotations sets WithProvenanceAnnotations.
+	ProvenanceAnnotations bool `json:"provenanceAnnotations,omitempty"`
+
+	// FieldManager sets WithFieldManager.
+	FieldManager string `json:"fieldManager,omitempty"`
+
+	// FailOnEmpty sets WithFailOnEmpty.
+	FailOnEmpty bool `json:"failOnEmpty,omitempty"`
+
+	// DeterminismCheck sets WithDeterminismCheck.
+	DeterminismCheck int `json:"determinismCheck,omitempty"`
+
+	// OverlayMerge sets WithOverlayMerge. DuplicatePolicy, if set, overrides
+	// the policy it implies.
+	OverlayMerge bool `json:"overlayMerge,omitempty"`
+
+	// DuplicatePolicy sets WithDuplicatePolicy.
+	DuplicatePolicy DuplicatePolicy `json:"duplicatePolicy,omitempty"`
+
+	// EmptyObjectPolicy sets WithEmptyObjectPolicy.
+	EmptyObjectPolicy EmptyObjectPolicy `json:"emptyObjectPolicy,omitempty"`
+
+	// Validation sets WithValidation.
+	Validation ValidationMode `json:"validation,omitempty"`
+
+	// MaxObjects and MaxObjectBytes set WithLimits.
+	MaxObjects     int `json:"maxObjects,omitempty"`
+	MaxObjectBytes int `json:"maxObjectBytes,omitempty"`
+
+	// AllowedGVKs sets WithAllowedGVKs, and DeniedGVKs sets WithDeniedGVKs.
+	AllowedGVKs []metav1.GroupVersionKind `json:"allowedGVKs,omitempty"`
+	DeniedGVKs  []metav1.GroupVersionKind `json:"deniedGVKs,omitempty"`
+
+	// APIMigration sets WithAPIMigration.
+	APIMigration bool `json:"apiMigration,omitempty"`
+
+	// TargetKubeVersion sets WithTargetKubeVersion.
+	TargetKubeVersion string `json:"targetKubeVersion,omitempty"`
+
+	// Policies sets WithPolicy.
+	Policies []string `json:"policies,omitempty"`
+
+	// DefaultNamespace sets WithDefaultNamespace.
+	DefaultNamespace string `json:"defaultNamespace,omitempty"`
+
+	// RequireNamespace and AllowedNamespaces set WithNamespacePolicy.
+	RequireNamespace  bool     `json:"requireNamespace,omitempty"`
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+
+	// EnsureNamespaces sets WithEnsureNamespaces, and NamespaceLabels and
+	// NamespaceAnnotations set WithNamespaceMetadata.
+	EnsureNamespaces     bool              `json:"ensureNamespaces,omitempty"`
+	NamespaceLabels      map[string]string `json:"namespaceLabels,omitempty"`
+	NamespaceAnnotations map[string]string `json:"namespaceAnnotations,omitempty"`
+
+	// ListExpansion sets WithListExpansion.
+	ListExpansion bool `json:"listExpansion,omitempty"`
+
+	// DeletionMarkers sets WithDeletionMarkers.
+	DeletionMarkers bool `json:"deletionMarkers,omitempty"`
+
+	// StableSort sets WithStableSort.
+	StableSort bool `json:"stableSort,omitempty"`
+
+	// KindOrder sets WithKindOrdering. Specs list the kinds, which may be
+	// those of InstallOrder or UninstallOrder, including "*" for OtherKinds.
+	KindOrder KindOrder `json:"kindOrder,omitempty"`
+
+	// WebhooksLast sets WithWebhooksLast.
+	WebhooksLast bool `json:"webhooksLast,omitempty"`
+
+	// DependencyOrdering sets WithDependencyOrdering.
+	DependencyOrdering bool `json:"dependencyOrdering,omitempty"`
+
+	// CABundlePlaceholder sets WithCABundlePlaceholder.
+	CABundlePlaceholder string `json:"caBundlePlaceholder,omitempty"`
+
+	// CRDWaitAnnotations sets WithCRDWaitAnnotations.
+	CRDWaitAnnotations bool `json:"crdWaitAnnotations,omitempty"`
+
+	// Concurrency sets WithConcurrency.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// RenderCache sets WithRenderCache.
+	RenderCache bool `json:"renderCache,omitempty"`
+}
+
+// FromSpec creates a renderer from spec, as New would from the sources and
+// options it describes. opts are applied after the options of spec, to add
+// callbacks or override its settings. The renderer shares objects with spec,
+// which must not be modified afterwards.
+func FromSpec(spec RendererSpec, opts ...RendererOption) (*Renderer, error) {
+	sources := make([]Source, len(spec.Sources))
+	for i, source := range spec.Sources {
+		sources[i] = source.source()
+	}
+
+	return New(sources, append(spec.Options.options(), opts...)...)
+}
+
+// source returns the Source described by s.
+func (s SourceSpec) source() Source {
+	return Source{
+		Name:             s.Name,
+		Objects:          s.Objects,
+		Manifests:        s.Manifests,
+		Labels:           s.Labels,
+		Annotations:      s.Annotations,
+		OverrideMetadata: s.OverrideMetadata,
+		Patches:          s.Patches,
+		Deletions:        s.Deletions,
+	}
+}
+
+// options returns the options enabled by s, in the order of its fields.
+func (s RendererOptionsSpec) options() []RendererOption {
+	var opts []RendererOption
+
+	if s.Name != "" {
+		opts = append(opts, WithName(s.Name))
+	}
+
+	if s.ContentHash != nil {
+		opts = append(opts, WithContentHash(*s.ContentHash))
+	}
+
+	if s.ContentHashAlgorithm != "" {
+		opts = append(opts, WithContentHashAlgorithm(s.ContentHashAlgorithm))
+	}
+
+	if len(s.ContentHashIgnore) > 0 {
+		opts = append(opts, WithContentHashIgnore(s.ContentHashIgnore...))
+	}
+
+	if s.SecretRedaction {
+		opts = append(opts, WithSecretRedaction(true))
+	}
+
+	if s.ContentHashStage != "" {
+		opts = append(opts, WithContentHashStage(s.ContentHashStage))
+	}
+
+	if s.SourceAnnotations {
+		opts = append(opts, WithSourceAnnotations(true))
+	}
+
+	if s.CanonicalMetadata {
+		opts = append(opts, WithCanonicalMetadata(true))
+	}
+
+	if s.Generation != "" {
+		opts = append(opts, WithGenerationAnnotation(s.Generation))
+	}
+
+	if s.RenderDigest {
+		opts = append(opts, WithRenderDigestAnnotation(true))
+	}
+
+	if s.ProvenanceAnnotations {
+		opts = append(opts, WithProvenanceAnnotations(true))
+	}
+
+	if s.FieldManager != "" {
+		opts = append(opts, WithFieldManager(s.FieldManager))
+	}
+
+	if s.FailOnEmpty {
+		opts = append(opts, WithFailOnEmpty(true))
+	}
+
+	if s.DeterminismCheck > 1 {
+		opts = append(opts, WithDeterminismCheck(s.DeterminismCheck))
+	}
+
+	if s.OverlayMerge {
+		opts = append(opts, WithOverlayMerge())
+	}
+
+	if s.DuplicatePolicy != "" {
+		opts = append(opts, WithDuplicatePolicy(s.DuplicatePolicy))
+	}
+
+	if s.EmptyObjectPolicy != "" {
+		opts = append(opts, WithEmptyObjectPolicy(s.EmptyObjectPolicy))
+	}
+
+	if s.Validation != "" {
+		opts = append(opts, WithValidation(s.Validation))
+	}
+
+	if s.MaxObjects != 0 || s.MaxObjectBytes != 0 {
+		opts = append(opts, WithLimits(s.MaxObjects, s.MaxObjectBytes))
+	}
+
+	if len(s.AllowedGVKs) > 0 {
+		opts = append(opts, WithAllowedGVKs(specGVKs(s.AllowedGVKs)...))
+	}
+
+	if len(s.DeniedGVKs) > 0 {
+		opts = append(opts, WithDeniedGVKs(specGVKs(s.DeniedGVKs)...))
+	}
+
+	if s.APIMigration {
+		opts = append(opts, WithAPIMigration(true))
+	}
+
+	if s.TargetKubeVersion != "" {
+		opts = append(opts, WithTargetKubeVersion(s.TargetKubeVersion))
+	}
+
+	if len(s.Policies) > 0 {
+		opts = append(opts, WithPolicy(s.Policies...))
+	}
+
+	if s.DefaultNamespace != "" {
+		opts = append(opts, WithDefaultNamespace(s.DefaultNamespace))
+	}
+
+	if s.RequireNamespace || len(s.AllowedNamespaces) > 0 {
+		opts = append(opts, WithNamespacePolicy(NamespacePolicy{
+			RequireNamespace:  s.RequireNamespace,
+			AllowedNamespaces: s.AllowedNamespaces,
+		}))
+	}
+
+	if s.EnsureNamespaces {
+		opts = append(opts, WithEnsureNamespaces(true))
+	}
+
+	if s.NamespaceLabels != nil || s.NamespaceAnnotations != nil {
+		opts = append(opts, WithNamespaceMetadata(s.NamespaceLabels, s.NamespaceAnnotations))
+	}
+
+	if s.ListExpansion {
+		opts = append(opts, WithListExpansion(true))
+	}
+
+	if s.DeletionMarkers {
+		opts = append(opts, WithDeletionMarkers(true))
+	}
+
+	if s.StableSort {
+		opts = append(opts, WithStableSort(true))
+	}
+
+	if len(s.KindOrder) > 0 {
+		opts = append(opts, WithKindOrdering(s.KindOrder))
+	}
+
+	if s.WebhooksLast {
+		opts = append(opts, WithWebhooksLast(true))
+	}
+
+	if s.DependencyOrdering {
+		opts = append(opts, WithDependencyOrdering(true))
+	}
+
+	if s.CABundlePlaceholder != "" {
+		opts = append(opts, WithCABundlePlaceholder(s.CABundlePlaceholder))
+	}
+
+	if s.CRDWaitAnnotations {
+		opts = append(opts, WithCRDWaitAnnotations())
+	}
+
+	if s.Concurrency != 0 {
+		opts = append(opts, WithConcurrency(s.Concurrency))
+	}
+
+	if s.RenderCache {
+		opts = append(opts, WithRenderCache(true))
+	}
+
+	return opts
+}
+
+// DeepCopyInto copies the receiver into out, as generated for Kubernetes API
+// types, so that RendererSpec can be embedded in custom resource types.
+func (s *RendererSpec) DeepCopyInto(out *RendererSpec) {
+	*out = *s
+
+	if s.Sources != nil {
+		out.Sources = make([]SourceSpec, len(s.Sources))
+		for i := range s.Sources {
+			s.Sources[i].deepCopyInto(&out.Sources[i])
+		}
+	}
+
+	if s.Options.ContentHash != nil {
+		out.Options.ContentHash = new(bool)
+		*out.Options.ContentHash = *s.Options.ContentHash
+	}
+
+	out.Options.ContentHashIgnore = slices.Clone(s.Options.ContentHashIgnore)
+	out.Options.KindOrder = slices.Clone(s.Options.KindOrder)
+	out.Options.AllowedGVKs = slices.Clone(s.Options.AllowedGVKs)
+	out.Options.DeniedGVKs = slices.Clone(s.Options.DeniedGVKs)
+	out.Options.Policies = slices.Clone(s.Options.Policies)
+	out.Options.AllowedNamespaces = slices.Clone(s.Options.AllowedNamespaces)
+	out.Options.NamespaceLabels = maps.Clone(s.Options.NamespaceLabels)
+	out.Options.NamespaceAnnotations = maps.Clone(s.Options.NamespaceAnnotations)
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (s *RendererSpec) DeepCopy() *RendererSpec {
+	if s == nil {
+		return nil
+	}
+
+	out := new(RendererSpec)
+	s.DeepCopyInto(out)
+
+	return out
+}
+
+func (s *SourceSpec) deepCopyInto(out *SourceSpec) {
+	*out = *s
+	out.Objects = deepCopyObjects(s.Objects)
+	out.Manifests = slices.Clone(s.Manifests)
+	out.Labels = maps.Clone(s.Labels)
+	out.Annotations = maps.Clone(s.Annotations)
+	out.Deletions = deepCopyObjects(s.Deletions)
+
+	if s.Patches != nil {
+		out.Patches = make([]Patch, len(s.Patches))
+		for i, patch := range s.Patches {
+			out.Patches[i] = patch.deepCopy()
+		}
+	}
+}
+
+// deepCopy returns a copy of p that shares no content with it.
+func (p Patch) deepCopy() Patch {
+	patchCopy := p
+
+	if p.Merge != nil {
+		patchCopy.Merge = runtime.DeepCopyJSON(p.Merge)
+	}
+
+	if p.Strategic != nil {
+		patchCopy.Strategic = runtime.DeepCopyJSON(p.Strategic)
+	}
+
+	if p.JSON != nil {
+		patchCopy.JSON = slices.Clone(p.JSON)
+		for i := range patchCopy.JSON {
+			patchCopy.JSON[i].Value = runtime.DeepCopyJSONValue(p.JSON[i].Value)
+		}
+	}
+
+	return patchCopy
+}
+
+// deepCopyObjects returns deep copies of objects, or nil if objects is nil.
+func deepCopyObjects(objects []unstructured.Unstructured) []unstructured.Unstructured {
+	if objects == nil {
+		return nil
+	}
+
+	objectsCopy := make([]unstructured.Unstructured, len(objects))
+	for i := range objects {
+		objectsCopy[i] = *objects[i].DeepCopy()
+	}
+
+	return objectsCopy
+}
+
+// specGVKs converts the GVKs of a spec.
+func specGVKs(gvks []metav1.GroupVersionKind) []schema.GroupVersionKind {
+	out := make([]schema.GroupVersionKind, 0, len(gvks))
+	for _, gvk := range gvks {
+		out = append(out, schema.GroupVersionKind(gvk))
+	}
+
+	return out
+}
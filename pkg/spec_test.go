@@ -0,0 +1,136 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const specYAML = `
+sources:
+- name: base
+  objects:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: config
+      namespace: app
+    data:
+      key: a
+  manifests:
+  - |
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: web
+      namespace: app
+  labels:
+    team: platform
+- name: overlay
+  patches:
+  - target:
+      kind: ConfigMap
+      name: config
+    merge:
+      data:
+        key: b
+  deletions:
+  - apiVersion: v1
+    kind: Secret
+    metadata:
+      name: retired
+      namespace: app
+options:
+  name: bundle
+  contentHash: false
+  ensureNamespaces: true
+  namespaceLabels:
+    team: platform
+`
+
+func TestFromSpec(t *testing.T) {
+
+	t.Run("should render the renderer described by a spec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var spec mem.RendererSpec
+		g.Expect(yaml.UnmarshalStrict([]byte(specYAML), &spec)).To(Succeed())
+
+		renderer, err := mem.FromSpec(spec)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Name()).To(Equal("bundle"))
+		g.Expect(renderer.Sources()).To(Equal([]string{"base", "overlay"}))
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Deletions()).To(HaveLen(1))
+
+		objects := result.View().DeepCopy()
+		g.Expect(names(objects)).To(Equal([]string{"Namespace/app", "ConfigMap/config", "Deployment/web"}))
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("team", "platform"))
+		g.Expect(objects[1].Object["data"]).To(HaveKeyWithValue("key", "b"))
+		g.Expect(objects[1].GetLabels()).To(HaveKeyWithValue("team", "platform"))
+		g.Expect(objects[1].GetAnnotations()).To(BeEmpty())
+	})
+
+	t.Run("should apply options after those of the spec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		spec := mem.RendererSpec{
+			Sources: []mem.SourceSpec{{Objects: []unstructured.Unstructured{cachedConfig("a")}}},
+			Options: mem.RendererOptionsSpec{Name: "spec"},
+		}
+
+		renderer, err := mem.FromSpec(spec, mem.WithName("code"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Name()).To(Equal("code"))
+	})
+
+	t.Run("should fail like New on invalid specs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.FromSpec(mem.RendererSpec{Options: mem.RendererOptionsSpec{DuplicatePolicy: "newest"}})
+		g.Expect(err).To(MatchError(mem.ErrInvalidDuplicatePolicy))
+
+		_, err = mem.FromSpec(mem.RendererSpec{Sources: []mem.SourceSpec{{Patches: []mem.Patch{{}}}}})
+		g.Expect(err).To(MatchError(mem.ErrInvalidPatch))
+
+		_, err = mem.FromSpec(mem.RendererSpec{Options: mem.RendererOptionsSpec{Policies: []string{"object.kind =="}}})
+		g.Expect(err).To(MatchError(mem.ErrInvalidPolicy))
+
+		_, err = mem.FromSpec(mem.RendererSpec{Options: mem.RendererOptionsSpec{AllowedNamespaces: []string{"Team_A"}}})
+		g.Expect(err).To(MatchError(mem.ErrInvalidNamespacePolicy))
+	})
+
+	t.Run("should round-trip and deep copy specs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var spec mem.RendererSpec
+		g.Expect(yaml.UnmarshalStrict([]byte(specYAML), &spec)).To(Succeed())
+
+		data, err := yaml.Marshal(spec)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var decoded mem.RendererSpec
+		g.Expect(yaml.UnmarshalStrict(data, &decoded)).To(Succeed())
+		g.Expect(decoded).To(Equal(spec))
+
+		specCopy := spec.DeepCopy()
+		g.Expect(*specCopy).To(Equal(spec))
+
+		specCopy.Sources[0].Objects[0].Object["data"] = map[string]any{"key": "changed"}
+		specCopy.Sources[1].Patches[0].Merge["data"] = nil
+		*specCopy.Options.ContentHash = true
+		specCopy.Options.NamespaceLabels["team"] = "other"
+
+		g.Expect(spec.Sources[0].Objects[0].Object["data"]).To(HaveKeyWithValue("key", "a"))
+		g.Expect(spec.Sources[1].Patches[0].Merge["data"]).ToNot(BeNil())
+		g.Expect(*spec.Options.ContentHash).To(BeFalse())
+		g.Expect(spec.Options.NamespaceLabels).To(HaveKeyWithValue("team", "platform"))
+	})
+}
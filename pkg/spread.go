@@ -0,0 +1,165 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// DoNotSchedule makes a spread constraint a hard requirement.
+	DoNotSchedule = "DoNotSchedule"
+
+	// ScheduleAnyway makes a spread constraint a scheduling preference.
+	ScheduleAnyway = "ScheduleAnyway"
+)
+
+// SpreadPolicy is a platform-wide topology spread policy for rendered
+// workloads, see WithSpreadPolicy.
+type SpreadPolicy struct {
+	// MaxSkew is the maxSkew of every enforced constraint. It must be positive.
+	MaxSkew int32
+
+	// TopologyKeys are the node label keys pods are spread across, one
+	// constraint each. At least one is required.
+	TopologyKeys []string
+
+	// WhenUnsatisfiable is DoNotSchedule or ScheduleAnyway. Empty means
+	// ScheduleAnyway, so the policy cannot make pods unschedulable.
+	WhenUnsatisfiable string
+
+	// Selector picks the workloads the policy applies to. Nil selects all.
+	Selector types.Filter
+}
+
+func (p SpreadPolicy) validate() error {
+	switch {
+	case p.MaxSkew <= 0:
+		return fmt.Errorf("%w: maxSkew must be positive, got %d", ErrInvalidSpreadPolicy, p.MaxSkew)
+	case len(p.TopologyKeys) == 0:
+		return fmt.Errorf("%w: at least one topology key is required", ErrInvalidSpreadPolicy)
+	case slices.Contains(p.TopologyKeys, ""):
+		return fmt.Errorf("%w: topology keys must not be empty", ErrInvalidSpreadPolicy)
+	}
+
+	switch p.WhenUnsatisfiable {
+	case "", DoNotSchedule, ScheduleAnyway:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown whenUnsatisfiable %q", ErrInvalidSpreadPolicy, p.WhenUnsatisfiable)
+	}
+}
+
+// enforceSpreadPolicy rewrites the topology spread constraints of the
+// selected workloads to the policy and returns the indices of the objects it
+// changed.
+func (r *Renderer) enforceSpreadPolicy(ctx context.Context, objects []unstructured.Unstructured) ([]int, error) {
+	policy := r.opts.SpreadPolicy
+	changed := make([]int, 0)
+
+	for i := range objects {
+		obj := &objects[i]
+
+		// DaemonSets run one pod per node, which there is nothing to spread.
+		if !isWorkload(obj) ||
+			obj.GroupVersionKind().GroupKind() == (schema.GroupKind{Group: "apps", Kind: "DaemonSet"}) {
+			continue
+		}
+
+		if policy.Selector != nil {
+			selected, err := policy.Selector(ctx, *obj)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+			}
+
+			if !selected {
+				continue
+			}
+		}
+
+		labels, err := podLabelsOf(obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+		}
+
+		if len(labels) == 0 {
+			Warnf(ctx, "%s: pods have no labels to spread by; spread policy not applied", ObjectKeyOf(*obj))
+
+			continue
+		}
+
+		podSpec, err := podSpecOf(obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+		}
+
+		if err := policy.apply(podSpec, labels); err != nil {
+			return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+		}
+
+		changed = append(changed, i)
+	}
+
+	return changed, nil
+}
+
+// apply enforces the policy on podSpec: constraints on the policy's topology
+// keys get its maxSkew and whenUnsatisfiable, missing ones are added with a
+// selector for labels, and constraints on other keys are kept.
+func (p SpreadPolicy) apply(podSpec map[string]any, labels map[string]string) error {
+	whenUnsatisfiable := p.WhenUnsatisfiable
+	if whenUnsatisfiable == "" {
+		whenUnsatisfiable = ScheduleAnyway
+	}
+
+	constraints, _, err := unstructured.NestedSlice(podSpec, "topologySpreadConstraints")
+	if err != nil {
+		return err
+	}
+
+	enforced := make(map[string]struct{}, len(p.TopologyKeys))
+
+	for _, c := range constraints {
+		constraint, ok := c.(map[string]any)
+		if !ok {
+			return fmt.Errorf("topology spread constraint is %T, not an object", c)
+		}
+
+		key, _ := constraint["topologyKey"].(string)
+		if !slices.Contains(p.TopologyKeys, key) {
+			continue
+		}
+
+		constraint["maxSkew"] = int64(p.MaxSkew)
+		constraint["whenUnsatisfiable"] = whenUnsatisfiable
+		enforced[key] = struct{}{}
+	}
+
+	for _, key := range p.TopologyKeys {
+		if _, ok := enforced[key]; ok {
+			continue
+		}
+
+		matchLabels := make(map[string]any, len(labels))
+		for k, v := range labels {
+			matchLabels[k] = v
+		}
+
+		constraints = append(constraints, map[string]any{
+			"maxSkew":           int64(p.MaxSkew),
+			"topologyKey":       key,
+			"whenUnsatisfiable": whenUnsatisfiable,
+			"labelSelector":     map[string]any{"matchLabels": matchLabels},
+		})
+		enforced[key] = struct{}{}
+	}
+
+	podSpec["topologySpreadConstraints"] = constraints
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package mem_test
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const spreadYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      topologySpreadConstraints:
+      - maxSkew: 5
+        topologyKey: topology.kubernetes.io/zone
+        whenUnsatisfiable: DoNotSchedule
+        labelSelector:
+          matchLabels:
+            app: web
+      - maxSkew: 1
+        topologyKey: example.com/rack
+        whenUnsatisfiable: DoNotSchedule
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    metadata:
+      labels:
+        app: agent
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+`
+
+// spreadConstraints returns the topology spread constraints of the pod
+// template of obj.
+func spreadConstraints(g *WithT, obj unstructured.Unstructured) []any {
+	constraints, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "topologySpreadConstraints")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return constraints
+}
+
+func TestSpreadPolicy(t *testing.T) {
+
+	policy := mem.SpreadPolicy{
+		MaxSkew:      1,
+		TopologyKeys: []string{"topology.kubernetes.io/zone", "kubernetes.io/hostname"},
+	}
+
+	t.Run("should rewrite and inject constraints", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(spreadYAML)},
+			mem.WithSpreadPolicy(policy),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result, err := renderer.ProcessResult(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects := result.View().DeepCopy()
+		g.Expect(spreadConstraints(g, objects[0])).To(Equal([]any{
+			map[string]any{
+				"maxSkew":           int64(1),
+				"topologyKey":       "topology.kubernetes.io/zone",
+				"whenUnsatisfiable": "ScheduleAnyway",
+				"labelSelector":     map[string]any{"matchLabels": map[string]any{"app": "web"}},
+			},
+			map[string]any{
+				"maxSkew":           int64(1),
+				"topologyKey":       "example.com/rack",
+				"whenUnsatisfiable": "DoNotSchedule",
+			},
+			map[string]any{
+				"maxSkew":           int64(1),
+				"topologyKey":       "kubernetes.io/hostname",
+				"whenUnsatisfiable": "ScheduleAnyway",
+				"labelSelector":     map[string]any{"matchLabels": map[string]any{"app": "web"}},
+			},
+		}))
+
+		g.Expect(spreadConstraints(g, objects[1])).To(BeEmpty())
+		g.Expect(spreadConstraints(g, objects[2])).To(BeEmpty())
+		g.Expect(result.Warnings()).To(HaveLen(1))
+		g.Expect(result.Warnings()[0].Message).To(HavePrefix("batch/v1/Job//migrate: pods have no labels"))
+	})
+
+	t.Run("should enforce the configured whenUnsatisfiable", func(t *testing.T) {
+		g := NewWithT(t)
+
+		strict := policy
+		strict.WhenUnsatisfiable = mem.DoNotSchedule
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(spreadYAML)},
+			mem.WithSpreadPolicy(strict),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for _, c := range spreadConstraints(g, objects[0]) {
+			g.Expect(c).To(HaveKeyWithValue("whenUnsatisfiable", mem.DoNotSchedule))
+		}
+	})
+
+	t.Run("should reject invalid policies", func(t *testing.T) {
+		g := NewWithT(t)
+
+		invalid := []mem.SpreadPolicy{
+			{TopologyKeys: []string{"zone"}},
+			{MaxSkew: 1},
+			{MaxSkew: 1, TopologyKeys: []string{""}},
+			{MaxSkew: 1, TopologyKeys: []string{"zone"}, WhenUnsatisfiable: "Sometimes"},
+		}
+
+		for _, p := range invalid {
+			_, err := mem.New(nil, mem.WithSpreadPolicy(p))
+			g.Expect(errors.Is(err, mem.ErrInvalidSpreadPolicy)).To(BeTrue(), "policy %+v", p)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestProcessFromStage(t *testing.T) {
+
+	onlyConfigMaps := func(_ context.Context, obj unstructured.Unstructured) (bool, error) {
+		return obj.GetKind() == "ConfigMap", nil
+	}
+
+	labelHandler := func(_ context.Context, obj *unstructured.Unstructured) error {
+		obj.SetLabels(map[string]string{"handled": "true"})
+
+		return nil
+	}
+
+	newRenderer := func(t *testing.T, g *WithT) *mem.Renderer {
+		t.Helper()
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(configMapYAML)},
+			mem.WithSourceAnnotations(true),
+			mem.WithFilter(onlyConfigMaps),
+			mem.WithKindHandler(schema.GroupVersionKind{Kind: "ConfigMap"}, labelHandler),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	injected := func() []unstructured.Unstructured {
+		return mem.MustSourceFromYAML(multiDocYAML).Objects
+	}
+
+	t.Run("should run injected objects through every stage from the source stage", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := newRenderer(t, g).ProcessFromStage(t.Context(), mem.StageSource, injected())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetName()).To(Equal("first"))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationSourceType, "mem"))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKey(pkgtypes.AnnotationContentHash))
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("handled", "true"))
+	})
+
+	t.Run("should skip the source stage from the chain stage", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := newRenderer(t, g).ProcessFromStage(t.Context(), mem.StageChain, injected())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetAnnotations()).ToNot(HaveKey(pkgtypes.AnnotationSourceType))
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("handled", "true"))
+	})
+
+	t.Run("should only run the final pass from the final stage", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := newRenderer(t, g).ProcessFromStage(t.Context(), mem.StageFinal, injected())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[0].GetLabels()).To(HaveKeyWithValue("handled", "true"))
+		g.Expect(objects[1].GetLabels()).To(BeEmpty())
+	})
+
+	t.Run("should not modify injected objects or record stats", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, g)
+		objects := injected()
+
+		for _, stage := range []mem.Stage{mem.StageSource, mem.StageChain, mem.StageFinal} {
+			_, err := renderer.ProcessFromStage(t.Context(), stage, objects)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(objects).To(Equal(injected()))
+		g.Expect(renderer.Stats().Renders).To(BeZero())
+	})
+
+	t.Run("should validate objects injected at the source stage", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := newRenderer(t, g).ProcessFromStage(t.Context(), mem.StageSource, []unstructured.Unstructured{{}})
+		g.Expect(errors.Is(err, mem.ErrObjectEmpty)).To(BeTrue())
+	})
+
+	t.Run("should reject unknown stages", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := newRenderer(t, g).ProcessFromStage(t.Context(), "middle", injected())
+		g.Expect(errors.Is(err, mem.ErrInvalidStage)).To(BeTrue())
+	})
+
+	t.Run("should reject the source stage on merged renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		merged, err := mem.Merge(newRenderer(t, g), newRenderer(t, g), mem.DuplicateKeepFirst,
+			mem.WithFilter(onlyConfigMaps))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = merged.ProcessFromStage(t.Context(), mem.StageSource, injected())
+		g.Expect(errors.Is(err, mem.ErrInvalidStage)).To(BeTrue())
+
+		objects, err := merged.ProcessFromStage(t.Context(), mem.StageChain, injected())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+}
@@ -0,0 +1,107 @@
+package mem
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats is a snapshot of a renderer's cumulative counters since construction.
+// It is a plain value, meant to be copied into an embedder's own metrics or
+// status reporting.
+type Stats struct {
+	// Renders is the number of completed renders, successful or not: Process
+	// calls and completed Jobs.
+	Renders uint64
+
+	// Objects is the total number of objects returned by successful renders.
+	Objects uint64
+
+	// Errors is the number of renders that failed.
+	Errors uint64
+
+	// CacheHits is the number of renders served by WithRenderCache; they
+	// count in Renders and Objects too.
+	CacheHits uint64
+
+	// LastDuration is how long the most recently completed render took; for a
+	// Job, the time spent in its steps.
+	LastDuration time.Duration
+
+	// LastRender is when the most recently completed render started.
+	// It is the zero time if the renderer has not rendered yet.
+	LastRender time.Time
+}
+
+// renderStats accumulates Stats; it is safe for concurrent use.
+type renderStats struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+func (s *renderStats) record(start time.Time, duration time.Duration, objects int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordLocked(start, duration, objects, err)
+}
+
+// recordCacheHit records a render served by the render cache.
+func (s *renderStats) recordCacheHit(start time.Time, duration time.Duration, objects int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.CacheHits++
+	s.recordLocked(start, duration, objects, nil)
+}
+
+func (s *renderStats) recordLocked(start time.Time, duration time.Duration, objects int, err error) {
+	s.stats.Renders++
+	if err != nil {
+		s.stats.Errors++
+	} else {
+		s.stats.Objects += uint64(objects)
+	}
+
+	// Concurrent renders may complete out of order; keep the latest start.
+	if !start.Before(s.stats.LastRender) {
+		s.stats.LastRender = start
+		s.stats.LastDuration = duration
+	}
+}
+
+// renderClock measures how long a render is active; a Job may spread a
+// render over several steps.
+type renderClock struct {
+	// start is when the render started.
+	start time.Time
+
+	// resumed is when the current step started.
+	resumed time.Time
+
+	// prior is the time spent in earlier steps.
+	prior time.Duration
+}
+
+func startClock() renderClock {
+	now := time.Now()
+
+	return renderClock{start: now, resumed: now}
+}
+
+func (c renderClock) elapsed() time.Duration {
+	return c.prior + time.Since(c.resumed)
+}
+
+func (s *renderStats) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats
+}
+
+// Stats returns a snapshot of the renderer's counters since construction.
+// It is safe to call concurrently with Process. Snapshots taken with Freeze
+// and renderers built by Merge keep their own counters.
+func (r *Renderer) Stats() Stats {
+	return r.stats.snapshot()
+}
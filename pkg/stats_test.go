@@ -0,0 +1,104 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+var errStatsFailure = errors.New("stats failure") //nolint:gochecknoglobals
+
+func TestStats(t *testing.T) {
+
+	t.Run("should start empty", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Stats()).To(Equal(mem.Stats{}))
+	})
+
+	t.Run("should accumulate renders, objects, and errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		fail := false
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(configMapYAML, multiDocYAML)},
+			mem.WithPostRenderer(func(
+				_ context.Context,
+				objects []unstructured.Unstructured,
+			) ([]unstructured.Unstructured, error) {
+				if fail {
+					return nil, errStatsFailure
+				}
+
+				return objects, nil
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		before := time.Now()
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		fail = true
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errStatsFailure))
+
+		stats := renderer.Stats()
+		g.Expect(stats.Renders).To(Equal(uint64(3)))
+		g.Expect(stats.Objects).To(Equal(uint64(2 * len(objects))))
+		g.Expect(stats.Errors).To(Equal(uint64(1)))
+		g.Expect(stats.LastRender).To(BeTemporally(">=", before))
+		g.Expect(stats.LastDuration).To(BeNumerically(">", 0))
+	})
+
+	t.Run("should keep separate counters for snapshots", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		snapshot := renderer.Freeze()
+		g.Expect(snapshot.Stats()).To(Equal(mem.Stats{}))
+		g.Expect(renderer.Stats().Renders).To(Equal(uint64(1)))
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var wg sync.WaitGroup
+		for range 16 {
+			wg.Go(func() {
+				for range 10 {
+					_, _ = renderer.Process(t.Context(), nil)
+					_ = renderer.Stats()
+				}
+			})
+		}
+
+		wg.Wait()
+
+		stats := renderer.Stats()
+		g.Expect(stats.Renders).To(Equal(uint64(160)))
+		g.Expect(stats.Objects).To(Equal(uint64(160)))
+	})
+}
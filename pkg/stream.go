@@ -0,0 +1,190 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// StreamMode selects how much of a Stream a Process call renders.
+type StreamMode int
+
+const (
+	// StreamSnapshot renders the objects received so far without waiting for
+	// more. Later Process calls see the objects that arrived in the meantime.
+	StreamSnapshot StreamMode = iota
+
+	// StreamWaitForClose blocks Process until the stream is complete, so the
+	// output covers the whole stream.
+	StreamWaitForClose
+
+	// StreamLatest is like StreamSnapshot, but an object replaces the one
+	// received earlier with the same DefaultIdentity, keeping its place, so
+	// producers that never complete, such as watches re-emitting updated
+	// objects, do not grow the stream with every update.
+	StreamLatest
+)
+
+// StreamPull returns the objects that became available since its previous
+// call, and done once no more objects will follow. When wait is false it must
+// not block; when wait is true it should block until it can return at least
+// one object or report completion. Calls are serialized by the Stream.
+type StreamPull func(ctx context.Context, wait bool) ([]unstructured.Unstructured, bool, error)
+
+// Stream is a source of objects that arrive over time, for example from a
+// watch or a parser goroutine, letting parsing overlap with rendering. Objects
+// are retained once received, so every Process call renders everything
+// received so far, and a producer filling a bounded channel is paced by how
+// often Process drains it. Retained objects are never dropped, so outside of
+// StreamLatest a stream suits finite producers only; objects removed at the
+// producer stay in the stream in any mode.
+//
+// A Stream is safe for concurrent use and may be shared by several renderers.
+type Stream struct {
+	pull StreamPull
+	mode StreamMode
+
+	mu       sync.Mutex
+	received []unstructured.Unstructured
+	done     bool
+
+	// latest indexes received by DefaultIdentity in StreamLatest mode.
+	latest map[string]int
+}
+
+// NewStream creates a Stream fed by pull.
+func NewStream(pull StreamPull, mode StreamMode) *Stream {
+	return &Stream{
+		pull: pull,
+		mode: mode,
+	}
+}
+
+// NewChannelStream creates a Stream fed by ch. The stream is complete once ch
+// is closed. Received objects are owned by the stream and must not be
+// modified by the sender afterwards.
+func NewChannelStream(ch <-chan unstructured.Unstructured, mode StreamMode) *Stream {
+	return NewStream(func(ctx context.Context, wait bool) ([]unstructured.Unstructured, bool, error) {
+		var objects []unstructured.Unstructured
+
+		if wait {
+			select {
+			case obj, ok := <-ch:
+				if !ok {
+					return nil, true, nil
+				}
+
+				objects = append(objects, obj)
+			case <-ctx.Done():
+				return nil, false, fmt.Errorf("waiting for stream: %w", ctx.Err())
+			}
+		}
+
+		for {
+			select {
+			case obj, ok := <-ch:
+				if !ok {
+					return objects, true, nil
+				}
+
+				objects = append(objects, obj)
+			default:
+				return objects, false, nil
+			}
+		}
+	}, mode)
+}
+
+// Done reports whether the stream is complete.
+func (s *Stream) Done() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.done
+}
+
+// Len returns the number of objects received so far.
+func (s *Stream) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.received)
+}
+
+// freeze returns a complete stream holding the objects received so far.
+func (s *Stream) freeze() *Stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &Stream{
+		mode:     s.mode,
+		received: s.received[:len(s.received):len(s.received)],
+		done:     true,
+	}
+}
+
+// objects pulls what is available according to the stream mode and returns
+// all objects received so far. The returned slice is never written again, as
+// later objects are only appended beyond its length or, in StreamLatest mode,
+// replaced in a copy.
+func (s *Stream) objects(ctx context.Context) ([]unstructured.Unstructured, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !s.done {
+		wait := s.mode == StreamWaitForClose
+
+		objects, done, err := s.pull(ctx, wait)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrStreamPull, err)
+		}
+
+		s.receive(objects)
+		s.done = done
+
+		if !wait {
+			break
+		}
+	}
+
+	return s.received[:len(s.received):len(s.received)], nil
+}
+
+// receive adds objects to the received ones. In StreamLatest mode, an object
+// whose identity was received before replaces it in a copy of the received
+// objects, so slices returned by objects are still never written.
+func (s *Stream) receive(objects []unstructured.Unstructured) {
+	if s.mode != StreamLatest {
+		s.received = append(s.received, objects...)
+
+		return
+	}
+
+	if s.latest == nil {
+		s.latest = make(map[string]int)
+	}
+
+	copied := false
+
+	for _, obj := range objects {
+		id := DefaultIdentity(obj)
+
+		i, ok := s.latest[id]
+		if !ok {
+			s.latest[id] = len(s.received)
+			s.received = append(s.received, obj)
+
+			continue
+		}
+
+		if !copied {
+			s.received = slices.Clone(s.received)
+			copied = true
+		}
+
+		s.received[i] = obj
+	}
+}
@@ -0,0 +1,220 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/gomega"
+)
+
+func streamObject(name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName(name)
+
+	return obj
+}
+
+func TestStream(t *testing.T) {
+
+	t.Run("should render what is available in snapshot mode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured, 4)
+		stream := mem.NewChannelStream(ch, mem.StreamSnapshot)
+
+		renderer, err := mem.New([]mem.Source{{
+			Objects: []unstructured.Unstructured{streamObject("static")},
+			Stream:  stream,
+		}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/static"}))
+
+		ch <- streamObject("a")
+		ch <- streamObject("b")
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/static", "ConfigMap/a", "ConfigMap/b"}))
+		g.Expect(stream.Done()).To(BeFalse())
+
+		ch <- streamObject("c")
+		close(ch)
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/static", "ConfigMap/a", "ConfigMap/b", "ConfigMap/c"}))
+		g.Expect(stream.Done()).To(BeTrue())
+		g.Expect(stream.Len()).To(Equal(3))
+	})
+
+	t.Run("should replace objects by identity in latest mode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured, 4)
+		stream := mem.NewChannelStream(ch, mem.StreamLatest)
+
+		renderer, err := mem.New([]mem.Source{{Stream: stream}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ch <- streamObject("a")
+		ch <- streamObject("b")
+
+		before, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(before)).To(Equal([]string{"ConfigMap/a", "ConfigMap/b"}))
+
+		updated := streamObject("a")
+		updated.SetLabels(map[string]string{"version": "2"})
+		ch <- updated
+		ch <- streamObject("c")
+
+		after, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(after)).To(Equal([]string{"ConfigMap/a", "ConfigMap/b", "ConfigMap/c"}))
+		g.Expect(after[0].GetLabels()).To(HaveKeyWithValue("version", "2"))
+		g.Expect(before[0].GetLabels()).To(BeEmpty())
+		g.Expect(stream.Len()).To(Equal(3))
+		g.Expect(stream.Done()).To(BeFalse())
+	})
+
+	t.Run("should wait for the channel to close", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured)
+		renderer, err := mem.New([]mem.Source{{Stream: mem.NewChannelStream(ch, mem.StreamWaitForClose)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		go func() {
+			for _, name := range []string{"a", "b", "c"} {
+				ch <- streamObject(name)
+			}
+
+			close(ch)
+		}()
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/a", "ConfigMap/b", "ConfigMap/c"}))
+	})
+
+	t.Run("should stop waiting when the context is done", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured)
+		renderer, err := mem.New([]mem.Source{{Stream: mem.NewChannelStream(ch, mem.StreamWaitForClose)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = renderer.Process(ctx, nil)
+		g.Expect(errors.Is(err, mem.ErrStreamPull)).To(BeTrue())
+		g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+
+	t.Run("should pull batches from a callback", func(t *testing.T) {
+		g := NewWithT(t)
+
+		batches := [][]unstructured.Unstructured{
+			{streamObject("a"), streamObject("b")},
+			{streamObject("c")},
+		}
+
+		var waits []bool
+
+		stream := mem.NewStream(func(_ context.Context, wait bool) ([]unstructured.Unstructured, bool, error) {
+			waits = append(waits, wait)
+			batch := batches[0]
+			batches = batches[1:]
+
+			return batch, len(batches) == 0, nil
+		}, mem.StreamWaitForClose)
+
+		renderer, err := mem.New([]mem.Source{{Stream: stream}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/a", "ConfigMap/b", "ConfigMap/c"}))
+		g.Expect(waits).To(Equal([]bool{true, true}))
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+		g.Expect(waits).To(HaveLen(2))
+	})
+
+	t.Run("should validate streamed objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured, 1)
+		ch <- unstructured.Unstructured{}
+
+		renderer, err := mem.New([]mem.Source{{
+			Objects: []unstructured.Unstructured{streamObject("static")},
+			Stream:  mem.NewChannelStream(ch, mem.StreamSnapshot),
+		}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(errors.Is(err, mem.ErrObjectEmpty)).To(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("at index 1"))
+	})
+
+	t.Run("should not modify streamed objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured, 1)
+		ch <- streamObject("a")
+		close(ch)
+
+		stream := mem.NewChannelStream(ch, mem.StreamWaitForClose)
+		renderer, err := mem.New([]mem.Source{{Stream: stream}}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		first, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		first[0].SetName("changed")
+
+		second, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(second[0].GetName()).To(Equal("a"))
+		g.Expect(second[0].GetAnnotations()).To(Equal(first[0].GetAnnotations()))
+	})
+
+	t.Run("should freeze the objects received so far", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ch := make(chan unstructured.Unstructured, 2)
+		ch <- streamObject("a")
+
+		renderer, err := mem.New([]mem.Source{{Stream: mem.NewChannelStream(ch, mem.StreamSnapshot)}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		frozen := renderer.Freeze()
+		ch <- streamObject("b")
+
+		objects, err := frozen.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/a"}))
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/a", "ConfigMap/b"}))
+	})
+}
@@ -0,0 +1,133 @@
+package mem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RenderSummary is a compact projection of a rendered object set, meant to be
+// embedded into the status of a custom resource, for example as
+// status.rendered. Its JSON field names follow Kubernetes API conventions.
+type RenderSummary struct {
+	// RenderedHash identifies the content of the whole set. It changes
+	// whenever an object is added, removed, or modified, and does not depend
+	// on object order.
+	RenderedHash string `json:"renderedHash"`
+
+	// ObjectCount is the number of objects in the set.
+	ObjectCount int64 `json:"objectCount"`
+
+	// Kinds counts objects per kind, keyed "Kind" for the core group and
+	// "Kind.group" otherwise.
+	Kinds map[string]int64 `json:"kinds,omitempty"`
+
+	// Sources counts objects per source, keyed like GroupKey: by the
+	// source.name annotation, or the source.index annotation for unnamed
+	// sources. Objects without source annotations are not counted.
+	Sources map[string]int64 `json:"sources,omitempty"`
+}
+
+// Summarize projects a rendered object set into a RenderSummary. Objects are
+// hashed by their content hash annotation when present, so summarizing the
+// output of a renderer with content hashes enabled does not rehash objects.
+func Summarize(objects []unstructured.Unstructured) RenderSummary {
+	summary := RenderSummary{
+		ObjectCount: int64(len(objects)),
+	}
+
+	for i := range objects {
+		obj := &objects[i]
+
+		kind := obj.GetKind()
+		if group := obj.GroupVersionKind().Group; group != "" {
+			kind += "." + group
+		}
+
+		summary.Kinds = incrementCount(summary.Kinds, kind)
+
+		if source := summarySourceKey(obj); source != "" {
+			summary.Sources = incrementCount(summary.Sources, source)
+		}
+	}
+
+	summary.RenderedHash = renderedHash(objects)
+
+	return summary
+}
+
+// summarySourceKey returns the key obj is counted under in
+// RenderSummary.Sources, or "" if it carries no source annotations.
+func summarySourceKey(obj *unstructured.Unstructured) string {
+	annotations := obj.GetAnnotations()
+	if name := annotations[AnnotationSourceName]; name != "" {
+		return name
+	}
+
+	return annotations[AnnotationSourceIndex]
+}
+
+// renderedHash hashes the identities and content hashes of objects, sorted
+// so that the hash does not depend on object order.
+func renderedHash(objects []unstructured.Unstructured) string {
+	entries := make([]string, 0, len(objects))
+	for i := range objects {
+		entries = append(entries, DefaultIdentity(objects[i])+"="+hashOf(&objects[i]))
+	}
+
+	slices.Sort(entries)
+
+	hasher := sha256.New()
+	for _, entry := range entries {
+		hasher.Write([]byte(entry))
+		hasher.Write([]byte{'\n'})
+	}
+
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+}
+
+// String returns a short human-readable form of the summary, such as
+// "5 objects (ConfigMap=3, Deployment.apps=2) sha256:…".
+func (s RenderSummary) String() string {
+	parts := make([]string, 0, len(s.Kinds))
+	for _, kind := range slices.Sorted(maps.Keys(s.Kinds)) {
+		parts = append(parts, kind+"="+strconv.FormatInt(s.Kinds[kind], 10))
+	}
+
+	return strconv.FormatInt(s.ObjectCount, 10) + " objects (" + strings.Join(parts, ", ") + ") " + s.RenderedHash
+}
+
+// DeepCopyInto copies the receiver into out, as generated for Kubernetes API
+// types, so that RenderSummary can be embedded in custom resource types.
+func (s *RenderSummary) DeepCopyInto(out *RenderSummary) {
+	*out = *s
+	out.Kinds = maps.Clone(s.Kinds)
+	out.Sources = maps.Clone(s.Sources)
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (s *RenderSummary) DeepCopy() *RenderSummary {
+	if s == nil {
+		return nil
+	}
+
+	out := new(RenderSummary)
+	s.DeepCopyInto(out)
+
+	return out
+}
+
+func incrementCount(counts map[string]int64, key string) map[string]int64 {
+	if counts == nil {
+		counts = make(map[string]int64)
+	}
+
+	counts[key]++
+
+	return counts
+}
@@ -0,0 +1,114 @@
+package mem_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSummarize(t *testing.T) {
+
+	render := func(t *testing.T, g Gomega, source mem.Source) []unstructured.Unstructured {
+		t.Helper()
+
+		renderer, err := mem.New([]mem.Source{source}, mem.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return objects
+	}
+
+	t.Run("should count objects by kind and source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		summary := mem.Summarize(render(t, g, mem.MustSourceFromYAML(configMapYAML, multiDocYAML)))
+
+		g.Expect(summary.ObjectCount).To(Equal(int64(3)))
+		g.Expect(summary.Kinds).To(Equal(map[string]int64{"ConfigMap": 2, "Deployment.apps": 1}))
+		g.Expect(summary.Sources).To(Equal(map[string]int64{"0": 3}))
+		g.Expect(summary.RenderedHash).To(HavePrefix("sha256:"))
+		g.Expect(summary.String()).To(HavePrefix("3 objects (ConfigMap=2, Deployment.apps=1) sha256:"))
+	})
+
+	t.Run("should count objects by source name or index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		named := mem.MustSourceFromYAML(configMapYAML)
+		named.Name = "config"
+
+		renderer, err := mem.New(
+			[]mem.Source{named, mem.MustSourceFromYAML(multiDocYAML)},
+			mem.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(mem.Summarize(objects).Sources).To(Equal(map[string]int64{"config": 1, "1": 2}))
+	})
+
+	t.Run("should hash independently of order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(t, g, mem.MustSourceFromYAML(configMapYAML, multiDocYAML))
+		reversed := slices.Clone(objects)
+		slices.Reverse(reversed)
+
+		g.Expect(mem.Summarize(reversed).RenderedHash).To(Equal(mem.Summarize(objects).RenderedHash))
+	})
+
+	t.Run("should change the hash when content changes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		before := mem.Summarize(render(t, g, mem.MustSourceFromYAML(configMapYAML)))
+
+		changed := mem.MustSourceFromYAML(configMapYAML)
+		changed.Objects[0].SetLabels(map[string]string{"app": "other"})
+		after := mem.Summarize(render(t, g, changed))
+
+		g.Expect(after.RenderedHash).ToNot(Equal(before.RenderedHash))
+	})
+
+	t.Run("should hash objects without hash annotations the same way", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := mem.MustSourceFromYAML(configMapYAML).Objects
+
+		renderer, err := mem.New([]mem.Source{{Objects: objects}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		rendered, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(mem.Summarize(objects).RenderedHash).To(Equal(mem.Summarize(rendered).RenderedHash))
+		g.Expect(mem.Summarize(objects).Sources).To(BeNil())
+	})
+
+	t.Run("should serialize with API field names", func(t *testing.T) {
+		g := NewWithT(t)
+
+		data, err := json.Marshal(mem.Summarize(nil))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(data)).To(MatchJSON(
+			`{"renderedHash":"sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",` +
+				`"objectCount":0}`))
+	})
+
+	t.Run("should deep copy", func(t *testing.T) {
+		g := NewWithT(t)
+
+		summary := mem.Summarize(mem.MustSourceFromYAML(configMapYAML).Objects)
+		copied := summary.DeepCopy()
+		copied.Kinds["ConfigMap"] = 10
+
+		g.Expect(summary.Kinds["ConfigMap"]).To(Equal(int64(1)))
+	})
+}
@@ -0,0 +1,91 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8s-manifest-kit/engine/pkg/transformer"
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// EmptyObjectPolicy decides what happens when a renderer-level transformer
+// returns an empty object, or one stripped of its identity: without
+// apiVersion, kind, or both name and generateName.
+type EmptyObjectPolicy string
+
+const (
+	// EmptyObjectKeep passes the object on unchanged, as plain engine chains
+	// do. This is the default.
+	EmptyObjectKeep EmptyObjectPolicy = "keep"
+
+	// EmptyObjectDrop removes the object from the output, so later
+	// transformers and post-renderers do not see it, and reports a Warning.
+	EmptyObjectDrop EmptyObjectPolicy = "drop"
+
+	// EmptyObjectError fails the render with an error wrapping ErrObjectEmpty
+	// or ErrMissingIdentity.
+	EmptyObjectError EmptyObjectPolicy = "error"
+)
+
+func (p EmptyObjectPolicy) validate() error {
+	switch p {
+	case "", EmptyObjectKeep, EmptyObjectDrop, EmptyObjectError:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidEmptyObjectPolicy, p)
+	}
+}
+
+// transformerStage turns the index-th renderer-level transformer into a
+// post-renderer, as types.TransformerAsPostRenderer does, enforcing the
+// renderer's EmptyObjectPolicy on its results.
+func (r *Renderer) transformerStage(index int, t types.Transformer) types.PostRenderer {
+	policy := r.opts.EmptyObjectPolicy
+	if policy == "" || policy == EmptyObjectKeep {
+		return types.TransformerAsPostRenderer(t)
+	}
+
+	return func(ctx context.Context, objects []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+		kept := objects[:0]
+
+		for _, obj := range objects {
+			// Transformers may modify obj in place, so its identity is taken first.
+			key := ObjectKeyOf(obj)
+
+			transformed, err := t(ctx, obj)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := checkIdentity(transformed); err != nil {
+				if policy == EmptyObjectError {
+					return nil, transformer.Wrap(obj, fmt.Errorf("transformer at index %d on %s: %w", index, key, err))
+				}
+
+				Warnf(ctx, "transformer at index %d on %s: %v; dropped", index, key, err)
+
+				continue
+			}
+
+			kept = append(kept, transformed)
+		}
+
+		return kept, nil
+	}
+}
+
+// checkIdentity reports whether obj is empty or lacks identity fields.
+func checkIdentity(obj unstructured.Unstructured) error {
+	switch {
+	case len(obj.Object) == 0:
+		return ErrObjectEmpty
+	case obj.GetAPIVersion() == "" || obj.GetKind() == "":
+		return fmt.Errorf("%w: apiVersion and kind are required", ErrMissingIdentity)
+	case obj.GetName() == "" && obj.GetGenerateName() == "":
+		return fmt.Errorf("%w: name or generateName is required", ErrMissingIdentity)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,173 @@
+package mem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/k8s-manifest-kit/engine/pkg/transformer"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// stripSecond empties or strips the identity of the object named "second".
+func stripSecond(strip func(obj *unstructured.Unstructured)) mem.RendererOption {
+	return mem.WithTransformer(func(
+		_ context.Context,
+		obj unstructured.Unstructured,
+	) (unstructured.Unstructured, error) {
+		if obj.GetName() == "second" {
+			strip(&obj)
+		}
+
+		return obj, nil
+	})
+}
+
+func TestEmptyObjectPolicy(t *testing.T) {
+
+	strips := map[string]func(obj *unstructured.Unstructured){
+		"empty object": func(obj *unstructured.Unstructured) {
+			obj.Object = nil
+		},
+		"missing kind": func(obj *unstructured.Unstructured) {
+			obj.SetKind("")
+		},
+		"missing name": func(obj *unstructured.Unstructured) {
+			obj.SetName("")
+		},
+	}
+
+	t.Run("should keep objects by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(multiDocYAML)},
+			stripSecond(strips["missing kind"]),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[1].GetKind()).To(BeEmpty())
+	})
+
+	t.Run("should drop objects with a warning", func(t *testing.T) {
+		for name, strip := range strips {
+			t.Run(name, func(t *testing.T) {
+				g := NewWithT(t)
+
+				seen := make([]string, 0)
+				renderer, err := mem.New(
+					[]mem.Source{mem.MustSourceFromYAML(multiDocYAML)},
+					mem.WithEmptyObjectPolicy(mem.EmptyObjectDrop),
+					stripSecond(strip),
+					mem.WithTransformer(func(
+						_ context.Context,
+						obj unstructured.Unstructured,
+					) (unstructured.Unstructured, error) {
+						seen = append(seen, obj.GetName())
+
+						return obj, nil
+					}),
+				)
+				g.Expect(err).ToNot(HaveOccurred())
+
+				result, err := renderer.ProcessResult(t.Context(), nil)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(names(result.View().DeepCopy())).To(Equal([]string{"ConfigMap/first"}))
+				g.Expect(seen).To(Equal([]string{"first"}))
+				g.Expect(result.Warnings()).To(HaveLen(1))
+				g.Expect(result.Warnings()[0].Message).To(And(
+					HavePrefix("transformer at index 0 on apps/v1/Deployment//second: "),
+					HaveSuffix("; dropped"),
+				))
+			})
+		}
+	})
+
+	t.Run("should fail the render", func(t *testing.T) {
+		targets := map[string]error{
+			"empty object": mem.ErrObjectEmpty,
+			"missing kind": mem.ErrMissingIdentity,
+			"missing name": mem.ErrMissingIdentity,
+		}
+
+		for name, strip := range strips {
+			t.Run(name, func(t *testing.T) {
+				g := NewWithT(t)
+
+				renderer, err := mem.New(
+					[]mem.Source{mem.MustSourceFromYAML(multiDocYAML)},
+					mem.WithEmptyObjectPolicy(mem.EmptyObjectError),
+					stripSecond(strip),
+				)
+				g.Expect(err).ToNot(HaveOccurred())
+
+				_, err = renderer.Process(t.Context(), nil)
+				g.Expect(errors.Is(err, targets[name])).To(BeTrue(), "unexpected error: %v", err)
+
+				g.Expect(err.Error()).To(ContainSubstring("transformer at index 0 on apps/v1/Deployment//second"))
+
+				var transformerErr *transformer.Error
+				g.Expect(errors.As(err, &transformerErr)).To(BeTrue())
+			})
+		}
+	})
+
+	t.Run("should accept objects with a generated name", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{mem.MustSourceFromYAML(multiDocYAML)},
+			mem.WithEmptyObjectPolicy(mem.EmptyObjectError),
+			stripSecond(func(obj *unstructured.Unstructured) {
+				obj.SetName("")
+				obj.SetGenerateName("second-")
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+	})
+
+	t.Run("should apply to merged renderers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		part, err := mem.New([]mem.Source{mem.MustSourceFromYAML(multiDocYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		empty, err := mem.New(nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		merged, err := mem.Merge(part, empty, mem.DuplicateError,
+			mem.WithEmptyObjectPolicy(mem.EmptyObjectDrop),
+			stripSecond(strips["empty object"]),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := merged.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{"ConfigMap/first"}))
+	})
+
+	t.Run("should reject unknown policies", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithEmptyObjectPolicy("ignore"))
+		g.Expect(errors.Is(err, mem.ErrInvalidEmptyObjectPolicy)).To(BeTrue())
+
+		renderer, err := mem.New(nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = mem.Merge(renderer, renderer, mem.DuplicateError, mem.WithEmptyObjectPolicy("ignore"))
+		g.Expect(errors.Is(err, mem.ErrInvalidEmptyObjectPolicy)).To(BeTrue())
+	})
+}
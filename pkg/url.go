@@ -0,0 +1,413 @@
+package mem
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k8s-manifest-kit/pkg/util"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// DefaultURLTimeout is the default timeout for fetching a URL source.
+	DefaultURLTimeout = 30 * time.Second
+
+	checksumPrefix = "sha256:"
+)
+
+// URLHeaderFunc customizes requests made by SourceFromURL, typically to add
+// authentication headers. It is called for every request, so it can refresh
+// short-lived tokens.
+type URLHeaderFunc func(req *http.Request) error
+
+// URLOption is a generic option for URLOptions.
+type URLOption = util.Option[URLOptions]
+
+// URLOptions is a struct-based option that can set multiple URL source options at once.
+type URLOptions struct {
+	// Client is the HTTP client used for requests. Default (or nil): http.DefaultClient.
+	Client *http.Client
+
+	// Timeout bounds each fetch, including reading the body. Default: DefaultURLTimeout.
+	Timeout time.Duration
+
+	// Header is called on every request before it is sent.
+	Header URLHeaderFunc
+
+	// Checksum pins the expected content as "sha256:<hex>". A download with a
+	// different checksum is rejected with ErrChecksumMismatch.
+	Checksum string
+
+	// Cache, if set, keeps fetched content across calls.
+	Cache *URLCache
+
+	// AllowHTTP permits plain http:// URLs, and redirects to them. Only HTTPS
+	// is accepted by default.
+	AllowHTTP bool
+
+	// YAMLOptions configure decoding of the fetched content. Its size limit
+	// also bounds how much of the response body is read.
+	YAMLOptions []YAMLOption
+}
+
+// ApplyTo applies the URL options to the target configuration.
+func (opts URLOptions) ApplyTo(target *URLOptions) {
+	target.Client = opts.Client
+	target.Timeout = opts.Timeout
+	target.Header = opts.Header
+	target.Checksum = opts.Checksum
+	target.Cache = opts.Cache
+	target.AllowHTTP = opts.AllowHTTP
+	target.YAMLOptions = append(target.YAMLOptions, opts.YAMLOptions...)
+}
+
+// WithHTTPClient sets the HTTP client used to fetch URL sources.
+func WithHTTPClient(client *http.Client) URLOption {
+	return util.FunctionalOption[URLOptions](func(opts *URLOptions) {
+		opts.Client = client
+	})
+}
+
+// WithURLTimeout sets the timeout for fetching a URL source.
+func WithURLTimeout(timeout time.Duration) URLOption {
+	return util.FunctionalOption[URLOptions](func(opts *URLOptions) {
+		opts.Timeout = timeout
+	})
+}
+
+// WithURLHeader sets a hook called on every request, e.g. to add an Authorization header.
+func WithURLHeader(f URLHeaderFunc) URLOption {
+	return util.FunctionalOption[URLOptions](func(opts *URLOptions) {
+		opts.Header = f
+	})
+}
+
+// WithChecksum pins the content of a URL source to a "sha256:<hex>" checksum.
+func WithChecksum(checksum string) URLOption {
+	return util.FunctionalOption[URLOptions](func(opts *URLOptions) {
+		opts.Checksum = checksum
+	})
+}
+
+// WithURLCache shares fetched content through cache.
+func WithURLCache(cache *URLCache) URLOption {
+	return util.FunctionalOption[URLOptions](func(opts *URLOptions) {
+		opts.Cache = cache
+	})
+}
+
+// WithInsecureHTTP permits fetching over plain HTTP.
+func WithInsecureHTTP(enabled bool) URLOption {
+	return util.FunctionalOption[URLOptions](func(opts *URLOptions) {
+		opts.AllowHTTP = enabled
+	})
+}
+
+// WithURLYAMLOptions sets the options used to decode fetched content.
+func WithURLYAMLOptions(yamlOpts ...YAMLOption) URLOption {
+	return util.FunctionalOption[URLOptions](func(opts *URLOptions) {
+		opts.YAMLOptions = append(opts.YAMLOptions, yamlOpts...)
+	})
+}
+
+// URLCache keeps the content fetched by SourceFromURL, keyed by URL. Content
+// pinned by a checksum is served from the cache without a request; other
+// entries are revalidated with their ETag, so an unchanged bundle is not
+// downloaded again. A URLCache is safe for concurrent use.
+type URLCache struct {
+	mu      sync.Mutex
+	entries map[string]urlCacheEntry
+}
+
+type urlCacheEntry struct {
+	body     []byte
+	etag     string
+	checksum string
+}
+
+// NewURLCache creates an empty URL cache.
+func NewURLCache() *URLCache {
+	return &URLCache{entries: make(map[string]urlCacheEntry)}
+}
+
+func (c *URLCache) get(rawURL string) (urlCacheEntry, bool) {
+	if c == nil {
+		return urlCacheEntry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[rawURL]
+
+	return entry, ok
+}
+
+func (c *URLCache) put(rawURL string, entry urlCacheEntry) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[rawURL] = entry
+}
+
+// SourceFromURL fetches a manifest bundle and decodes it into a Source. The
+// content may be a YAML stream or JSON, and Kubernetes List objects (kind
+// "List" or any kind ending in "List" with items) are flattened into their
+// items. The fetch happens when SourceFromURL is called, not during Process,
+// so the returned Source is as static as any other and can be passed to New;
+// to pick up new content, call it again and replace the source with
+// Renderer.ReplaceSource. The URL is named in positions and errors without its
+// password, query, and fragment, so credentials passed in it do not leak into
+// annotations or logs.
+func SourceFromURL(ctx context.Context, rawURL string, opts ...URLOption) (Source, error) {
+	options := URLOptions{
+		Client:  http.DefaultClient,
+		Timeout: DefaultURLTimeout,
+	}
+
+	for _, opt := range opts {
+		opt.ApplyTo(&options)
+	}
+
+	decoder := NewYAMLDecoder(options.YAMLOptions...)
+	name := redactURL(rawURL)
+
+	body, err := fetchURL(ctx, rawURL, options, decoder.opts.Limits.MaxInputSize)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to fetch %s: %w", name, err)
+	}
+
+	source, err := decoder.named(name, body)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+
+	source.Objects, source.Positions, err = flattenLists(source.Objects, source.Positions)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+
+	return source, nil
+}
+
+func fetchURL(
+	ctx context.Context,
+	rawURL string,
+	options URLOptions,
+	maxSize int,
+) ([]byte, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", redactURLError(err))
+	}
+
+	if parsed.Scheme != "https" && (parsed.Scheme != "http" || !options.AllowHTTP) {
+		return nil, fmt.Errorf("%w: scheme %q", ErrInsecureURL, parsed.Scheme)
+	}
+
+	cached, hasCached := options.Cache.get(rawURL)
+	if hasCached && options.Checksum != "" && strings.EqualFold(cached.checksum, options.Checksum) {
+		return cached.body, nil
+	}
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/yaml, application/json;q=0.9, */*;q=0.8")
+
+	if hasCached && cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	if options.Header != nil {
+		if err := options.Header(req); err != nil {
+			return nil, fmt.Errorf("header hook failed: %w", err)
+		}
+	}
+
+	resp, err := options.redirectSafeClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", redactURLError(err))
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	var body []byte
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && hasCached:
+		body = cached.body
+	case resp.StatusCode == http.StatusOK:
+		body, err = readLimited(resp.Body, maxSize)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	checksum := checksumOf(body)
+	if options.Checksum != "" && !strings.EqualFold(options.Checksum, checksum) {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, options.Checksum, checksum)
+	}
+
+	options.Cache.put(rawURL, urlCacheEntry{body: body, etag: resp.Header.Get("ETag"), checksum: checksum})
+
+	return body, nil
+}
+
+// redactURL returns rawURL without its password, query, and fragment, which
+// may carry credentials, for use in positions and error messages.
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+
+	return parsed.Redacted()
+}
+
+// redactURLError redacts the URL an *url.Error carries, as returned by URL
+// parsing and by HTTP clients, including for redirect targets.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(urlErr.URL)
+	}
+
+	return err
+}
+
+// maxURLRedirects is the number of redirects followed, as by http.Client.
+const maxURLRedirects = 10
+
+// redirectSafeClient returns a copy of the configured client whose redirects
+// are held to the same scheme rule as the fetched URL, and which drops every
+// header but Accept, including those set by the Header hook, when a redirect
+// changes the scheme or host, so credentials never leave the origin they were
+// meant for. A nil client stands for http.DefaultClient.
+func (opts URLOptions) redirectSafeClient() *http.Client {
+	base := opts.Client
+	if base == nil {
+		base = http.DefaultClient
+	}
+
+	client := *base
+	next := client.CheckRedirect
+
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" && (req.URL.Scheme != "http" || !opts.AllowHTTP) {
+			return fmt.Errorf("%w: redirect to scheme %q", ErrInsecureURL, req.URL.Scheme)
+		}
+
+		origin := via[0].URL
+		if req.URL.Scheme != origin.Scheme || req.URL.Host != origin.Host {
+			accept := req.Header.Get("Accept")
+			req.Header = http.Header{}
+			req.Header.Set("Accept", accept)
+		}
+
+		if next != nil {
+			return next(req, via)
+		}
+
+		if len(via) >= maxURLRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxURLRedirects)
+		}
+
+		return nil
+	}
+
+	return &client
+}
+
+func readLimited(r io.Reader, limit int) ([]byte, error) {
+	if limit <= 0 {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body: %w", err)
+		}
+
+		return body, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	if len(body) > limit {
+		return nil, fmt.Errorf("%w: response is larger than %d bytes", ErrYAMLLimitExceeded, limit)
+	}
+
+	return body, nil
+}
+
+func checksumOf(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return checksumPrefix + hex.EncodeToString(sum[:])
+}
+
+// flattenLists replaces Kubernetes List objects with their items, which keep
+// the position of their list.
+func flattenLists(
+	objects []unstructured.Unstructured,
+	positions []Position,
+) ([]unstructured.Unstructured, []Position, error) {
+	result := make([]unstructured.Unstructured, 0, len(objects))
+	resultPositions := make([]Position, 0, len(positions))
+
+	for i := range objects {
+		if !strings.HasSuffix(objects[i].GetKind(), "List") || !objects[i].IsList() {
+			result = append(result, objects[i])
+			resultPositions = append(resultPositions, positions[i])
+
+			continue
+		}
+
+		list, err := objects[i].ToList()
+		if err != nil {
+			return nil, nil, &PositionError{
+				Position: positions[i],
+				Err:      fmt.Errorf("invalid %s: %w", objects[i].GetKind(), err),
+			}
+		}
+
+		result = append(result, list.Items...)
+
+		for range list.Items {
+			resultPositions = append(resultPositions, positions[i])
+		}
+	}
+
+	return result, resultPositions, nil
+}
@@ -0,0 +1,193 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ValidationMode selects how WithValidation treats rendered objects that are
+// structurally malformed.
+type ValidationMode string
+
+const (
+	// ValidationOff skips structural validation. This is the default.
+	ValidationOff ValidationMode = "off"
+
+	// ValidationLenient reports malformed objects as Warnings and renders
+	// them anyway.
+	ValidationLenient ValidationMode = "lenient"
+
+	// ValidationStrict fails the render on the first malformed object with an
+	// error wrapping ErrInvalidObject.
+	ValidationStrict ValidationMode = "strict"
+)
+
+func (m ValidationMode) validate() error {
+	switch m {
+	case "", ValidationOff, ValidationLenient, ValidationStrict:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidValidationMode, m)
+	}
+}
+
+// validateStructure checks obj, the k-th object of source, the index-th
+// input, according to the renderer's ValidationMode.
+func (r *Renderer) validateStructure(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	index int,
+	source Source,
+	k int,
+) error {
+	mode := r.opts.Validation
+	if mode == "" || mode == ValidationOff {
+		return nil
+	}
+
+	violations := structuralViolations(obj)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	location := objectLocation(obj, index, source, k)
+
+	if mode == ValidationLenient {
+		Warnf(ctx, "%s: %s", location, strings.Join(violations, "; "))
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s: %s", ErrInvalidObject, location, strings.Join(violations, "; "))
+}
+
+// objectLocation names obj, the k-th object of source, the index-th input,
+// by the index and name of its source and its own index and key.
+func objectLocation(obj *unstructured.Unstructured, index int, source Source, k int) string {
+	return sourceLocation(index, source) + fmt.Sprintf(" object %d (%s)", k, ObjectKeyOf(*obj))
+}
+
+// sourceLocation names source, the index-th input, by index and name.
+func sourceLocation(index int, source Source) string {
+	location := fmt.Sprintf("source %d", index)
+	if source.Name != "" {
+		location += fmt.Sprintf(" (%s)", source.Name)
+	}
+
+	return location
+}
+
+// structuralViolations lists what makes obj malformed: missing identity
+// fields, names, namespaces, labels, or annotation keys the API server would
+// reject, and metadata maps that are null or not maps of strings.
+func structuralViolations(obj *unstructured.Unstructured) []string {
+	violations := make([]string, 0)
+
+	if obj.GetAPIVersion() == "" {
+		violations = append(violations, "apiVersion is required")
+	}
+
+	if obj.GetKind() == "" {
+		violations = append(violations, "kind is required")
+	}
+
+	metadata, ok := obj.Object["metadata"].(map[string]any)
+	if !ok {
+		return append(violations, "metadata must be a map")
+	}
+
+	name, _ := metadata["name"].(string)
+
+	switch {
+	case name == "":
+		violations = append(violations, "metadata.name is required")
+	case obj.GroupVersionKind().Group == "rbac.authorization.k8s.io":
+		// RBAC objects are named by path segment rules, which allow the
+		// colons of names such as system:aggregate-to-view.
+		if name == "." || name == ".." || strings.ContainsAny(name, "/%") {
+			violations = append(violations,
+				fmt.Sprintf("metadata.name %q may not be '.', '..', or contain '/' or '%%'", name))
+		}
+	default:
+		violations = append(violations, fieldErrors("metadata.name", validation.IsDNS1123Subdomain(name))...)
+	}
+
+	if namespace, found := metadata["namespace"]; found {
+		s, isString := namespace.(string)
+		if !isString {
+			violations = append(violations, "metadata.namespace must be a string")
+		} else if s != "" {
+			violations = append(violations, fieldErrors("metadata.namespace", validation.IsDNS1123Label(s))...)
+		}
+	}
+
+	labels, labelViolations := stringMap(metadata, "labels")
+	violations = append(violations, labelViolations...)
+
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		value := labels[key]
+		violations = append(violations, fieldErrors("metadata.labels key "+key, validation.IsQualifiedName(key))...)
+		violations = append(violations, fieldErrors("metadata.labels["+key+"]", validation.IsValidLabelValue(value))...)
+	}
+
+	annotations, annotationViolations := stringMap(metadata, "annotations")
+	violations = append(violations, annotationViolations...)
+
+	for _, key := range slices.Sorted(maps.Keys(annotations)) {
+		violations = append(violations,
+			fieldErrors("metadata.annotations key "+key, validation.IsQualifiedName(strings.ToLower(key)))...)
+	}
+
+	return violations
+}
+
+// stringMap returns the map of strings under key in metadata, if any, and
+// the violations of a null map or of values that are not strings.
+func stringMap(metadata map[string]any, key string) (map[string]string, []string) {
+	value, found := metadata[key]
+	if !found {
+		return nil, nil
+	}
+
+	m, ok := value.(map[string]any)
+	if !ok {
+		if value == nil {
+			return nil, []string{"metadata." + key + " is null"}
+		}
+
+		return nil, []string{"metadata." + key + " must be a map"}
+	}
+
+	violations := make([]string, 0)
+	out := make(map[string]string, len(m))
+
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		s, isString := m[k].(string)
+		if !isString {
+			violations = append(violations, fmt.Sprintf("metadata.%s[%s] must be a string", key, k))
+
+			continue
+		}
+
+		out[k] = s
+	}
+
+	return out, violations
+}
+
+// fieldErrors prefixes the messages of an apimachinery validation with the
+// field they are about.
+func fieldErrors(field string, errs []string) []string {
+	violations := make([]string, 0, len(errs))
+	for _, err := range errs {
+		violations = append(violations, field+": "+err)
+	}
+
+	return violations
+}
@@ -0,0 +1,149 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidation(t *testing.T) {
+
+	malformed := func(mutate func(obj *unstructured.Unstructured)) unstructured.Unstructured {
+		obj := composeObject("v1", "ConfigMap", "app", "config")
+		mutate(&obj)
+
+		return obj
+	}
+
+	render := func(t *testing.T, mode mem.ValidationMode, objects ...unstructured.Unstructured) (*mem.Result, error) {
+		t.Helper()
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: configMaps(1)}, {Name: "apps", Objects: objects}},
+			mem.WithValidation(mode),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return renderer.ProcessResult(t.Context(), nil)
+	}
+
+	t.Run("should reject malformed objects in strict mode", func(t *testing.T) {
+		cases := map[string]struct {
+			obj     unstructured.Unstructured
+			message string
+		}{
+			"missing kind": {
+				obj:     malformed(func(obj *unstructured.Unstructured) { obj.SetKind("") }),
+				message: "kind is required",
+			},
+			"missing name": {
+				obj: malformed(func(obj *unstructured.Unstructured) {
+					obj.SetName("")
+					obj.SetGenerateName("config-")
+				}),
+				message: "metadata.name is required",
+			},
+			"invalid name": {
+				obj:     malformed(func(obj *unstructured.Unstructured) { obj.SetName("Config_Map") }),
+				message: "metadata.name: a lowercase RFC 1123 subdomain",
+			},
+			"invalid namespace": {
+				obj:     malformed(func(obj *unstructured.Unstructured) { obj.SetNamespace("My-App") }),
+				message: "metadata.namespace: a lowercase RFC 1123 label",
+			},
+			"invalid label value": {
+				obj: malformed(func(obj *unstructured.Unstructured) {
+					obj.SetLabels(map[string]string{"tier": "front end"})
+				}),
+				message: "metadata.labels[tier]: a valid label must be",
+			},
+			"invalid annotation key": {
+				obj: malformed(func(obj *unstructured.Unstructured) {
+					obj.SetAnnotations(map[string]string{"a/b/c": "x"})
+				}),
+				message: "metadata.annotations key a/b/c",
+			},
+			"null labels": {
+				obj: malformed(func(obj *unstructured.Unstructured) {
+					obj.Object["metadata"].(map[string]any)["labels"] = nil
+				}),
+				message: "metadata.labels is null",
+			},
+			"non-string annotation": {
+				obj: malformed(func(obj *unstructured.Unstructured) {
+					obj.Object["metadata"].(map[string]any)["annotations"] = map[string]any{"replicas": int64(3)}
+				}),
+				message: "metadata.annotations[replicas] must be a string",
+			},
+		}
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				g := NewWithT(t)
+
+				_, err := render(t, mem.ValidationStrict, composeObject("v1", "Secret", "app", "token"), c.obj)
+				g.Expect(err).To(MatchError(mem.ErrInvalidObject))
+				g.Expect(err.Error()).To(ContainSubstring("source 1 (apps) object 1 ("))
+				g.Expect(err.Error()).To(ContainSubstring(c.message))
+			})
+		}
+	})
+
+	t.Run("should accept well-formed objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		role := composeObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "system:aggregate-to-view")
+		labeled := malformed(func(obj *unstructured.Unstructured) {
+			obj.SetLabels(map[string]string{"app.kubernetes.io/name": "web", "empty": ""})
+			obj.SetAnnotations(map[string]string{"example.com/Owner": "Team A"})
+		})
+
+		result, err := render(t, mem.ValidationStrict, role, labeled)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Warnings()).To(BeEmpty())
+	})
+
+	t.Run("should reject RBAC names that are not path segments", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, mem.ValidationStrict,
+			composeObject("rbac.authorization.k8s.io/v1", "Role", "app", "team/reader"))
+		g.Expect(err).To(MatchError(mem.ErrInvalidObject))
+	})
+
+	t.Run("should warn in lenient mode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := render(t, mem.ValidationLenient, malformed(func(obj *unstructured.Unstructured) {
+			obj.SetName("Config")
+		}))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.View().Len()).To(Equal(2))
+		g.Expect(result.Warnings()).To(HaveLen(1))
+		g.Expect(result.Warnings()[0].Message).To(HavePrefix(
+			"source 1 (apps) object 0 (v1/ConfigMap/app/Config): metadata.name:"))
+	})
+
+	t.Run("should skip checks when off", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, mode := range []mem.ValidationMode{"", mem.ValidationOff} {
+			result, err := render(t, mode, malformed(func(obj *unstructured.Unstructured) { obj.SetName("Config") }))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result.Warnings()).To(BeEmpty())
+		}
+	})
+
+	t.Run("should reject unknown modes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithValidation("pedantic"))
+		g.Expect(err).To(MatchError(mem.ErrInvalidValidationMode))
+	})
+}
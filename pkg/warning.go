@@ -0,0 +1,68 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Warning is a non-fatal finding reported during a render.
+type Warning struct {
+	// Message describes the finding.
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+type warningsKey struct{}
+
+// warningCollector gathers the warnings of one render; it is safe for
+// concurrent use, since callbacks may report from several goroutines.
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+func (c *warningCollector) add(w Warning) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.warnings = append(c.warnings, w)
+}
+
+func (c *warningCollector) list() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Warning(nil), c.warnings...)
+}
+
+// withWarnings returns a context collecting warnings. A context that already
+// collects them is reused, so the warnings of merged renderers reach the
+// result of the outer render.
+func withWarnings(ctx context.Context) (context.Context, *warningCollector) {
+	if c, ok := ctx.Value(warningsKey{}).(*warningCollector); ok {
+		return ctx, c
+	}
+
+	c := &warningCollector{}
+
+	return collectWarnings(ctx, c), c
+}
+
+// collectWarnings returns a context whose warnings are collected by c.
+func collectWarnings(ctx context.Context, c *warningCollector) context.Context {
+	return context.WithValue(ctx, warningsKey{}, c)
+}
+
+// Warnf reports a warning for the render running with ctx. Filters,
+// transformers, post-renderers, source selectors, and kind handlers can call
+// it with the context they receive; the warning then shows up in
+// Result.Warnings. Outside a render it does nothing.
+func Warnf(ctx context.Context, format string, args ...any) {
+	if c, ok := ctx.Value(warningsKey{}).(*warningCollector); ok {
+		c.add(Warning{Message: fmt.Sprintf(format, args...)})
+	}
+}
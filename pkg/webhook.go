@@ -0,0 +1,138 @@
+package mem
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// webhookConfigurationKinds are the kinds registering admission webhooks.
+var webhookConfigurationKinds = map[schema.GroupKind]struct{}{
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: {},
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   {},
+}
+
+// isWebhookConfiguration reports whether obj registers admission webhooks.
+func isWebhookConfiguration(obj unstructured.Unstructured) bool {
+	_, ok := webhookConfigurationKinds[obj.GroupVersionKind().GroupKind()]
+
+	return ok
+}
+
+// orderWebhooksLast moves the webhook configurations of objects to the end,
+// keeping the relative order of both parts.
+func orderWebhooksLast(objects []unstructured.Unstructured) {
+	slices.SortStableFunc(objects, func(a unstructured.Unstructured, b unstructured.Unstructured) int {
+		return cmp.Compare(webhookRank(a), webhookRank(b))
+	})
+}
+
+func webhookRank(obj unstructured.Unstructured) int {
+	if isWebhookConfiguration(obj) {
+		return 1
+	}
+
+	return 0
+}
+
+// CABundleResolver returns the PEM-encoded CA bundle for the webhook named
+// webhook in the webhook configuration obj, which must not be modified. A
+// nil bundle keeps the webhook's caBundle.
+type CABundleResolver func(ctx context.Context, obj unstructured.Unstructured, webhook string) ([]byte, error)
+
+// caBundleInjection configures WithCABundleResolver and
+// WithCABundlePlaceholder.
+type caBundleInjection struct {
+	resolver    CABundleResolver
+	placeholder string
+}
+
+func (c *caBundleInjection) validate() error {
+	if c.resolver == nil && c.placeholder == "" {
+		return fmt.Errorf("%w: a resolver or a placeholder is required", ErrInvalidCABundle)
+	}
+
+	return nil
+}
+
+// injectCABundles sets the caBundle of the webhooks of the rendered webhook
+// configurations and returns the indices of the changed objects.
+func (r *Renderer) injectCABundles(ctx context.Context, objects []unstructured.Unstructured) ([]int, error) {
+	changed := make([]int, 0)
+
+	for i := range objects {
+		obj := &objects[i]
+		if !isWebhookConfiguration(*obj) {
+			continue
+		}
+
+		r.ownObject(obj)
+
+		modified, err := r.opts.CABundles.inject(ctx, obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ObjectKeyOf(*obj), err)
+		}
+
+		if modified {
+			changed = append(changed, i)
+		}
+	}
+
+	return changed, nil
+}
+
+// inject sets the caBundle of each webhook of obj: the resolver's bundle, if
+// it returns one, else the placeholder if the webhook has no caBundle.
+func (c *caBundleInjection) inject(ctx context.Context, obj *unstructured.Unstructured) (bool, error) {
+	webhooks, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "webhooks")
+
+	items, ok := webhooks.([]any)
+	if !ok {
+		return false, nil
+	}
+
+	modified := false
+
+	for j, item := range items {
+		webhook, ok := item.(map[string]any)
+		if !ok {
+			return false, fmt.Errorf("%w: webhook %d is not an object", ErrInvalidCABundle, j)
+		}
+
+		name, _, _ := unstructured.NestedString(webhook, "name")
+		current, _, _ := unstructured.NestedString(webhook, "clientConfig", "caBundle")
+		value := current
+
+		if c.resolver != nil {
+			bundle, err := c.resolver(ctx, *obj, name)
+			if err != nil {
+				return false, fmt.Errorf("webhook %s: %w", name, err)
+			}
+
+			if len(bundle) > 0 {
+				value = base64.StdEncoding.EncodeToString(bundle)
+			}
+		}
+
+		if value == "" {
+			value = c.placeholder
+		}
+
+		if value == current {
+			continue
+		}
+
+		if err := unstructured.SetNestedField(webhook, value, "clientConfig", "caBundle"); err != nil {
+			return false, fmt.Errorf("%w: webhook %s: %w", ErrInvalidCABundle, name, err)
+		}
+
+		modified = true
+	}
+
+	return modified, nil
+}
@@ -0,0 +1,162 @@
+package mem_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func webhookConfiguration(kind string, name string, webhooks ...map[string]any) unstructured.Unstructured {
+	obj := composeObject("admissionregistration.k8s.io/v1", kind, "", name)
+
+	items := make([]any, len(webhooks))
+	for i := range webhooks {
+		items[i] = webhooks[i]
+	}
+
+	obj.Object["webhooks"] = items
+
+	return obj
+}
+
+func webhookObjects() []unstructured.Unstructured {
+	return []unstructured.Unstructured{
+		webhookConfiguration("ValidatingWebhookConfiguration", "validate",
+			map[string]any{"name": "validate.example.com", "clientConfig": map[string]any{}},
+			map[string]any{"name": "pinned.example.com", "clientConfig": map[string]any{"caBundle": "cGlubmVk"}},
+		),
+		composeObject("apps/v1", "Deployment", "app", "webhook"),
+		webhookConfiguration("MutatingWebhookConfiguration", "mutate",
+			map[string]any{"name": "mutate.example.com", "clientConfig": map[string]any{}},
+		),
+		composeObject("v1", "Service", "app", "webhook"),
+	}
+}
+
+func caBundles(objects []unstructured.Unstructured) []string {
+	bundles := make([]string, 0)
+
+	for _, obj := range objects {
+		webhooks, _, _ := unstructured.NestedSlice(obj.Object, "webhooks")
+		for _, webhook := range webhooks {
+			bundle, _, _ := unstructured.NestedString(webhook.(map[string]any), "clientConfig", "caBundle")
+			bundles = append(bundles, bundle)
+		}
+	}
+
+	return bundles
+}
+
+func TestWebhooksLast(t *testing.T) {
+
+	t.Run("should move webhook configurations to the end", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: webhookObjects()}}, mem.WithWebhooksLast(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal([]string{
+			"Deployment/webhook",
+			"Service/webhook",
+			"ValidatingWebhookConfiguration/validate",
+			"MutatingWebhookConfiguration/mutate",
+		}))
+	})
+
+	t.Run("should keep the order by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: webhookObjects()}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)[0]).To(Equal("ValidatingWebhookConfiguration/validate"))
+	})
+}
+
+func TestCABundles(t *testing.T) {
+
+	resolver := func(_ context.Context, obj unstructured.Unstructured, webhook string) ([]byte, error) {
+		if obj.GetName() == "mutate" {
+			return nil, nil
+		}
+
+		return []byte("PEM " + webhook), nil
+	}
+
+	t.Run("should inject resolved bundles", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{{Objects: webhookObjects()}}, mem.WithCABundleResolver(resolver))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(caBundles(objects)).To(Equal([]string{
+			base64.StdEncoding.EncodeToString([]byte("PEM validate.example.com")),
+			base64.StdEncoding.EncodeToString([]byte("PEM pinned.example.com")),
+			"",
+		}))
+		g.Expect(mem.VerifyContentHashes(objects)).To(BeEmpty())
+	})
+
+	t.Run("should fill missing bundles with the placeholder", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: webhookObjects()}},
+			mem.WithCABundlePlaceholder("${CA_BUNDLE}"),
+			mem.WithCABundleResolver(resolver),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(caBundles(objects)[2]).To(Equal("${CA_BUNDLE}"))
+
+		renderer, err = mem.New([]mem.Source{{Objects: webhookObjects()}}, mem.WithCABundlePlaceholder("${CA_BUNDLE}"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(caBundles(objects)).To(Equal([]string{"${CA_BUNDLE}", "cGlubmVk", "${CA_BUNDLE}"}))
+	})
+
+	t.Run("should report resolver errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		errNoCA := errors.New("no CA")
+		renderer, err := mem.New(
+			[]mem.Source{{Objects: webhookObjects()}},
+			mem.WithCABundleResolver(func(_ context.Context, _ unstructured.Unstructured, _ string) ([]byte, error) {
+				return nil, errNoCA
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errNoCA))
+		g.Expect(err).To(MatchError(ContainSubstring(
+			"admissionregistration.k8s.io/v1/ValidatingWebhookConfiguration//validate: webhook validate.example.com")))
+	})
+
+	t.Run("should reject injection without a resolver or placeholder", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.New(nil, mem.WithCABundlePlaceholder(""))
+		g.Expect(err).To(MatchError(mem.ErrInvalidCABundle))
+
+		_, err = mem.New(nil, mem.WithCABundleResolver(nil))
+		g.Expect(err).To(MatchError(mem.ErrInvalidCABundle))
+	})
+}
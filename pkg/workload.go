@@ -0,0 +1,131 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podSpecPaths locates the pod spec of each workload kind.
+var podSpecPaths = map[schema.GroupKind][]string{
+	{Group: "", Kind: "Pod"}:                   {"spec"},
+	{Group: "", Kind: "ReplicationController"}: {"spec", "template", "spec"},
+	{Group: "apps", Kind: "Deployment"}:        {"spec", "template", "spec"},
+	{Group: "apps", Kind: "StatefulSet"}:       {"spec", "template", "spec"},
+	{Group: "apps", Kind: "DaemonSet"}:         {"spec", "template", "spec"},
+	{Group: "apps", Kind: "ReplicaSet"}:        {"spec", "template", "spec"},
+	{Group: "batch", Kind: "Job"}:              {"spec", "template", "spec"},
+	{Group: "batch", Kind: "CronJob"}:          {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// applyWorkloadPolicies applies the configured service account wiring,
+// scheduling classes, and spread policy, in that order, and returns the
+// indices of the objects they changed.
+func (r *Renderer) applyWorkloadPolicies(ctx context.Context, objects []unstructured.Unstructured) ([]int, error) {
+	changed := make([]int, 0)
+
+	if r.opts.ServiceAccountWiring == nil && r.opts.SchedulingClasses == nil && r.opts.SpreadPolicy == nil {
+		return changed, nil
+	}
+
+	for i := range objects {
+		if isWorkload(&objects[i]) {
+			r.ownObject(&objects[i])
+		}
+	}
+
+	if r.opts.ServiceAccountWiring != nil {
+		wired, err := wireServiceAccounts(objects, *r.opts.ServiceAccountWiring)
+		if err != nil {
+			return nil, fmt.Errorf("service account wiring error in mem renderer: %w", err)
+		}
+
+		changed = append(changed, wired...)
+	}
+
+	if r.opts.SchedulingClasses != nil {
+		scheduled, err := r.injectSchedulingClasses(ctx, objects)
+		if err != nil {
+			return nil, fmt.Errorf("scheduling class error in mem renderer: %w", err)
+		}
+
+		changed = append(changed, scheduled...)
+	}
+
+	if r.opts.SpreadPolicy != nil {
+		spread, err := r.enforceSpreadPolicy(ctx, objects)
+		if err != nil {
+			return nil, fmt.Errorf("spread policy error in mem renderer: %w", err)
+		}
+
+		changed = append(changed, spread...)
+	}
+
+	return changed, nil
+}
+
+// isWorkload reports whether obj is of a kind that has a pod spec.
+func isWorkload(obj *unstructured.Unstructured) bool {
+	_, ok := podSpecPaths[obj.GroupVersionKind().GroupKind()]
+
+	return ok
+}
+
+// podSpecOf returns the pod spec of the workload obj, which may be modified
+// in place, creating an empty one if obj has none. It returns nil for
+// objects that are not workloads.
+func podSpecOf(obj *unstructured.Unstructured) (map[string]any, error) {
+	podSpec, err := lookupPodSpec(obj)
+	if podSpec != nil || err != nil || !isWorkload(obj) {
+		return podSpec, err
+	}
+
+	path := podSpecPaths[obj.GroupVersionKind().GroupKind()]
+
+	// SetNestedField stores a copy, so the spec is looked up again.
+	if err := unstructured.SetNestedField(obj.Object, map[string]any{}, path...); err != nil {
+		return nil, err
+	}
+
+	return lookupPodSpec(obj)
+}
+
+// lookupPodSpec returns the pod spec of obj, or nil if obj is not a workload
+// or has none.
+func lookupPodSpec(obj *unstructured.Unstructured) (map[string]any, error) {
+	path, ok := podSpecPaths[obj.GroupVersionKind().GroupKind()]
+	if !ok {
+		return nil, nil
+	}
+
+	spec, found, err := unstructured.NestedFieldNoCopy(obj.Object, path...)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	podSpec, ok := spec.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("pod spec at .%s is %T, not an object", strings.Join(path, "."), spec)
+	}
+
+	return podSpec, nil
+}
+
+// podLabelsOf returns the labels of the pods of the workload obj: its own for
+// a Pod, those of its pod template otherwise.
+func podLabelsOf(obj *unstructured.Unstructured) (map[string]string, error) {
+	path, ok := podSpecPaths[obj.GroupVersionKind().GroupKind()]
+	if !ok {
+		return nil, nil
+	}
+
+	labelsPath := slices.Concat(path[:len(path)-1], []string{"metadata", "labels"})
+
+	labels, _, err := unstructured.NestedStringMap(obj.Object, labelsPath...)
+
+	return labels, err
+}
@@ -0,0 +1,98 @@
+package mem
+
+import (
+	"context"
+	"maps"
+
+	"github.com/k8s-manifest-kit/engine/pkg/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// statefulSetSpecFields are the Deployment spec fields a StatefulSet shares.
+var statefulSetSpecFields = []string{"replicas", "selector", "template", "minReadySeconds", "revisionHistoryLimit"}
+
+// scalableKinds are the workload kinds with a spec.replicas field.
+var scalableKinds = map[schema.GroupKind]struct{}{
+	{Group: "apps", Kind: "Deployment"}:        {},
+	{Group: "apps", Kind: "StatefulSet"}:       {},
+	{Group: "apps", Kind: "ReplicaSet"}:        {},
+	{Group: "", Kind: "ReplicationController"}: {},
+}
+
+// DeploymentToStatefulSet returns a transformer converting Deployments into
+// StatefulSet skeletons: metadata and the replicas, selector, pod template,
+// minReadySeconds, and revisionHistoryLimit are kept, serviceName is set to
+// the Deployment's name, and the update strategy, progress deadline, pause
+// flag, and status are dropped. The governing headless Service and any
+// volumeClaimTemplates are left to the caller. Other objects pass unchanged.
+func DeploymentToStatefulSet() types.Transformer {
+	return func(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+		if obj.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: "apps", Kind: "Deployment"}) {
+			return obj, nil
+		}
+
+		spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+		if err != nil {
+			return obj, err
+		}
+
+		statefulSetSpec := map[string]any{"serviceName": obj.GetName()}
+		for _, field := range statefulSetSpecFields {
+			if value, ok := spec[field]; ok {
+				statefulSetSpec[field] = value
+			}
+		}
+
+		metadata, _, err := unstructured.NestedMap(obj.Object, "metadata")
+		if err != nil {
+			return obj, err
+		}
+
+		return unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "StatefulSet",
+			"metadata":   metadata,
+			"spec":       statefulSetSpec,
+		}}, nil
+	}
+}
+
+// SuspendCronJobs returns a transformer setting spec.suspend on every
+// CronJob, so no new Jobs are created; running Jobs are not affected. Other
+// objects pass unchanged.
+func SuspendCronJobs() types.Transformer {
+	return func(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+		if obj.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: "batch", Kind: "CronJob"}) {
+			return obj, nil
+		}
+
+		return obj, setSpecField(&obj, true, "suspend")
+	}
+}
+
+// ScaleToZero returns a transformer setting spec.replicas to zero on every
+// Deployment, StatefulSet, ReplicaSet, and ReplicationController. DaemonSets
+// cannot be scaled, and HorizontalPodAutoscalers are not changed, so an
+// autoscaled workload is scaled back up unless its autoscaler is removed too.
+// Other objects pass unchanged.
+func ScaleToZero() types.Transformer {
+	return func(_ context.Context, obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+		if _, ok := scalableKinds[obj.GroupVersionKind().GroupKind()]; !ok {
+			return obj, nil
+		}
+
+		return obj, setSpecField(&obj, int64(0), "replicas")
+	}
+}
+
+// setSpecField sets spec.<field> of obj to value in a copy of the spec, so a
+// spec shared with a source object (see WithDeepCopy) is left intact.
+func setSpecField(obj *unstructured.Unstructured, value any, field string) error {
+	if spec, ok := obj.Object["spec"].(map[string]any); ok {
+		obj.Object["spec"] = maps.Clone(spec)
+	}
+
+	return unstructured.SetNestedField(obj.Object, value, "spec", field)
+}
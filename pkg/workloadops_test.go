@@ -0,0 +1,121 @@
+package mem_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const operationsYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+  labels:
+    app: web
+spec:
+  replicas: 3
+  progressDeadlineSeconds: 600
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  schedule: "0 * * * *"
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+`
+
+func TestWorkloadOperations(t *testing.T) {
+
+	render := func(g *WithT, opts ...mem.RendererOption) []unstructured.Unstructured {
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(operationsYAML)}, opts...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return objects
+	}
+
+	t.Run("should convert Deployments to StatefulSet skeletons", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(g, mem.WithDeploymentsAsStatefulSets())
+		g.Expect(names(objects)).
+			To(Equal([]string{"StatefulSet/web", "CronJob/backup", "StatefulSet/db", "DaemonSet/agent"}))
+
+		converted := objects[0]
+		g.Expect(converted.GetNamespace()).To(Equal("apps"))
+		g.Expect(converted.GetLabels()).To(HaveKeyWithValue("app", "web"))
+		g.Expect(converted.Object["spec"]).To(And(
+			HaveKeyWithValue("serviceName", "web"),
+			HaveKeyWithValue("replicas", int64(3)),
+			HaveKey("selector"),
+			HaveKey("template"),
+			Not(HaveKey("strategy")),
+			Not(HaveKey("progressDeadlineSeconds")),
+		))
+	})
+
+	t.Run("should suspend CronJobs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(g, mem.WithSuspendedCronJobs())
+
+		suspended, _, err := unstructured.NestedBool(objects[1].Object, "spec", "suspend")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(suspended).To(BeTrue())
+		g.Expect(objects[0].Object["spec"]).ToNot(HaveKey("suspend"))
+	})
+
+	t.Run("should scale workloads to zero", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(g, mem.WithScaledToZero())
+
+		for _, obj := range []unstructured.Unstructured{objects[0], objects[2]} {
+			replicas, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(replicas).To(BeZero(), obj.GetName())
+		}
+
+		g.Expect(objects[3].Object).ToNot(HaveKey("spec"))
+	})
+
+	t.Run("should compose", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(g, mem.WithDeploymentsAsStatefulSets(), mem.WithScaledToZero())
+
+		replicas, _, err := unstructured.NestedInt64(objects[0].Object, "spec", "replicas")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetKind()).To(Equal("StatefulSet"))
+		g.Expect(replicas).To(BeZero())
+	})
+}
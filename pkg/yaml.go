@@ -0,0 +1,443 @@
+package mem
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/k8s-manifest-kit/pkg/util"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	documentSeparator = "---"
+	utf8BOM           = "\xef\xbb\xbf"
+)
+
+// yamlLinePattern matches the position prefix of YAML parser errors, whose line
+// numbers are relative to the document being decoded.
+var yamlLinePattern = regexp.MustCompile(`line (\d+): `) //nolint:gochecknoglobals
+
+// YAMLError reports a problem in YAML input together with its position.
+// Line and Column are 1-based and relative to the input string; they are 0
+// when the position is unknown (for example for JSON syntax errors).
+type YAMLError struct {
+	// Document is the 0-based index of the document within the input string.
+	Document int
+
+	// Line is the line of the input string the problem was found at.
+	Line int
+
+	// Column is the column of the input string the problem was found at.
+	Column int
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *YAMLError) Error() string {
+	message := yamlLinePattern.ReplaceAllString(e.Err.Error(), "")
+
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("document %d, line %d, column %d: %s", e.Document, e.Line, e.Column, message)
+	case e.Line > 0:
+		return fmt.Sprintf("document %d, line %d: %s", e.Document, e.Line, message)
+	default:
+		return fmt.Sprintf("document %d: %s", e.Document, message)
+	}
+}
+
+func (e *YAMLError) Unwrap() error {
+	return e.Err
+}
+
+// YAMLOption is a generic option for YAMLOptions.
+type YAMLOption = util.Option[YAMLOptions]
+
+// YAMLOptions is a struct-based option that can set multiple YAML decoding options at once.
+type YAMLOptions struct {
+	// Strict turns decoding warnings into errors. Currently this covers
+	// duplicate mapping keys, which are otherwise resolved by keeping the last value.
+	Strict bool
+
+	// Limits bound the resources spent decoding untrusted input. When applied
+	// as an option, zero Limits keep the limits already set.
+	// Default: DefaultYAMLLimits.
+	Limits YAMLLimits
+
+	// NoLimits disables all limits when applied as an option, for trusted
+	// input. It takes precedence over Limits.
+	NoLimits bool
+}
+
+// ApplyTo applies the YAML options to the target configuration.
+func (opts YAMLOptions) ApplyTo(target *YAMLOptions) {
+	target.Strict = opts.Strict
+
+	switch {
+	case opts.NoLimits:
+		target.Limits = YAMLLimits{}
+	case opts.Limits != YAMLLimits{}:
+		target.Limits = opts.Limits
+	}
+}
+
+// WithStrictYAML enables or disables strict decoding, which rejects duplicate mapping keys.
+func WithStrictYAML(enabled bool) YAMLOption {
+	return util.FunctionalOption[YAMLOptions](func(opts *YAMLOptions) {
+		opts.Strict = enabled
+	})
+}
+
+// YAMLDecoder decodes YAML (or JSON) input into unstructured objects.
+//
+// Input is normalized before decoding: a leading UTF-8 byte order mark is
+// removed and Windows (CRLF) and old Mac (CR) line endings are converted to
+// LF. UTF-16 and UTF-32 input, invalid UTF-8, and tab indentation are reported
+// as YAMLError values carrying ErrUnsupportedEncoding or ErrTabIndentation,
+// with the line and column of the offending character.
+//
+// Input is untrusted by default: every document is measured against the
+// decoder's YAMLLimits before it is decoded, and exceeding a limit fails with
+// ErrYAMLLimitExceeded instead of exhausting memory.
+type YAMLDecoder struct {
+	opts YAMLOptions
+}
+
+// NewYAMLDecoder creates a YAML decoder with the given options.
+func NewYAMLDecoder(opts ...YAMLOption) *YAMLDecoder {
+	d := &YAMLDecoder{
+		opts: YAMLOptions{
+			Limits: DefaultYAMLLimits(),
+		},
+	}
+
+	for _, opt := range opts {
+		opt.ApplyTo(&d.opts)
+	}
+
+	return d
+}
+
+// Unstructured parses a single YAML (or JSON) document into an unstructured object.
+// Numbers are decoded as int64 or float64, matching what the API machinery produces.
+// It returns ErrNoDocuments if doc is empty and ErrMultipleDocuments if it contains
+// more than one document; use Source for multi-document input.
+func (d *YAMLDecoder) Unstructured(doc string) (unstructured.Unstructured, error) {
+	objects, _, err := d.decode(doc)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+
+	switch len(objects) {
+	case 0:
+		return unstructured.Unstructured{}, ErrNoDocuments
+	case 1:
+		return objects[0], nil
+	default:
+		return unstructured.Unstructured{}, fmt.Errorf("%w: found %d", ErrMultipleDocuments, len(objects))
+	}
+}
+
+// Source builds a Source from YAML (or JSON) strings. Each string may hold
+// several documents separated by "---"; empty documents are skipped. Objects keep
+// the order in which they appear.
+//
+// The returned Source records the Position of every object, so errors about
+// an object during rendering point back at its document.
+func (d *YAMLDecoder) Source(docs ...string) (Source, error) {
+	source := Source{
+		Objects:   make([]unstructured.Unstructured, 0, len(docs)),
+		Positions: make([]Position, 0, len(docs)),
+	}
+
+	for i, doc := range docs {
+		objects, positions, err := d.decode(doc)
+		if err != nil {
+			return Source{}, fmt.Errorf("invalid YAML at index %d: %w", i, err)
+		}
+
+		for j := range positions {
+			positions[j].Input = i
+		}
+
+		source.Objects = append(source.Objects, objects...)
+		source.Positions = append(source.Positions, positions...)
+	}
+
+	return source, nil
+}
+
+// SourceFromFile builds a Source from a YAML (or JSON) file, as Source does
+// for strings. Positions record path as their file.
+func (d *YAMLDecoder) SourceFromFile(path string) (Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return d.named(path, data)
+}
+
+// named decodes data read from file, which may also be a URL.
+func (d *YAMLDecoder) named(file string, data []byte) (Source, error) {
+	objects, positions, err := d.decode(string(data))
+	if err != nil {
+		return Source{}, fmt.Errorf("invalid YAML in %s: %w", file, err)
+	}
+
+	for i := range positions {
+		positions[i].File = file
+	}
+
+	return Source{Objects: objects, Positions: positions}, nil
+}
+
+// Unstructured parses a single YAML (or JSON) document into an unstructured object
+// using a decoder with default options. See YAMLDecoder.Unstructured.
+func Unstructured(doc string) (unstructured.Unstructured, error) {
+	return NewYAMLDecoder().Unstructured(doc)
+}
+
+// MustUnstructured is like Unstructured but panics on error.
+// It is intended for test fixtures and package-level variables.
+func MustUnstructured(doc string) unstructured.Unstructured {
+	obj, err := Unstructured(doc)
+	if err != nil {
+		panic(fmt.Sprintf("mem.MustUnstructured: %v", err))
+	}
+
+	return obj
+}
+
+// SourceFromYAML builds a Source from YAML (or JSON) strings using a decoder
+// with default options. See YAMLDecoder.Source.
+//
+// Example:
+//
+//	source, err := mem.SourceFromYAML(`
+//	apiVersion: v1
+//	kind: ConfigMap
+//	metadata:
+//	  name: config
+//	`)
+func SourceFromYAML(docs ...string) (Source, error) {
+	return NewYAMLDecoder().Source(docs...)
+}
+
+// SourceFromFile builds a Source from a YAML (or JSON) file using a decoder
+// with default options. See YAMLDecoder.SourceFromFile.
+func SourceFromFile(path string) (Source, error) {
+	return NewYAMLDecoder().SourceFromFile(path)
+}
+
+// MustSourceFromYAML is like SourceFromYAML but panics on error.
+// It is intended for test fixtures.
+func MustSourceFromYAML(docs ...string) Source {
+	source, err := SourceFromYAML(docs...)
+	if err != nil {
+		panic(fmt.Sprintf("mem.MustSourceFromYAML: %v", err))
+	}
+
+	return source
+}
+
+// yamlDocument is a document of a YAML stream and the line it starts at.
+type yamlDocument struct {
+	content string
+	line    int
+}
+
+// decode normalizes a YAML stream, splits it into documents, and decodes each
+// non-empty one. It also returns the position of every object.
+func (d *YAMLDecoder) decode(data string) ([]unstructured.Unstructured, []Position, error) {
+	if err := d.opts.Limits.checkInput(data); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := normalizeYAML(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	objects := make([]unstructured.Unstructured, 0)
+	positions := make([]Position, 0)
+
+	for n, doc := range splitYAML(data) {
+		if err := d.opts.Limits.checkDocument(doc.content); err != nil {
+			return nil, nil, &YAMLError{Document: n, Line: doc.line, Err: err}
+		}
+
+		obj, err := d.decodeDocument(doc.content)
+		if err != nil {
+			return nil, nil, positionError(n, doc, err)
+		}
+
+		if len(obj) == 0 {
+			continue
+		}
+
+		objects = append(objects, unstructured.Unstructured{Object: obj})
+		positions = append(positions, Position{Document: n, Line: doc.line})
+	}
+
+	return objects, positions, nil
+}
+
+func (d *YAMLDecoder) decodeDocument(content string) (map[string]any, error) {
+	data := []byte(content)
+
+	if d.opts.Strict {
+		converted, err := yaml.YAMLToJSONStrict(data)
+		if err != nil {
+			return nil, err
+		}
+
+		data = converted
+	}
+
+	obj := make(map[string]any)
+	if err := utilyaml.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// normalizeYAML strips a UTF-8 byte order mark, rejects other encodings, and
+// converts line endings to LF.
+func normalizeYAML(data string) (string, error) {
+	switch {
+	case strings.HasPrefix(data, utf8BOM):
+		data = data[len(utf8BOM):]
+	case strings.HasPrefix(data, "\xfe\xff"),
+		strings.HasPrefix(data, "\xff\xfe"),
+		strings.HasPrefix(data, "\x00\x00\xfe\xff"):
+		return "", &YAMLError{
+			Line:   1,
+			Column: 1,
+			Err: fmt.Errorf("%w: UTF-16 or UTF-32 byte order mark, convert the input to UTF-8",
+				ErrUnsupportedEncoding),
+		}
+	}
+
+	if !utf8.ValidString(data) {
+		line, column := 1, 1
+
+		for i, r := range data {
+			if _, size := utf8.DecodeRuneInString(data[i:]); r == utf8.RuneError && size == 1 {
+				break
+			}
+
+			if r == '\n' {
+				line, column = line+1, 1
+			} else {
+				column++
+			}
+		}
+
+		return "", &YAMLError{
+			Line:   line,
+			Column: column,
+			Err:    fmt.Errorf("%w: invalid UTF-8", ErrUnsupportedEncoding),
+		}
+	}
+
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\r", "\n")
+
+	return data, nil
+}
+
+// splitYAML splits a normalized YAML stream on "---" separator lines, the same
+// way utilyaml.YAMLReader does, keeping track of where each document starts.
+// A separator line may end with a comment, as in "--- # next".
+func splitYAML(data string) []yamlDocument {
+	documents := make([]yamlDocument, 0)
+	current := yamlDocument{line: 1}
+
+	var content strings.Builder
+
+	for i, line := range strings.SplitAfter(data, "\n") {
+		if isDocumentSeparator(line) {
+			if content.Len() > 0 {
+				current.content = content.String()
+				documents = append(documents, current)
+				content.Reset()
+			}
+
+			current = yamlDocument{line: i + 2}
+
+			continue
+		}
+
+		content.WriteString(line)
+	}
+
+	if content.Len() > 0 {
+		current.content = content.String()
+		documents = append(documents, current)
+	}
+
+	return documents
+}
+
+// isDocumentSeparator reports whether line is a "---" separator, optionally
+// followed by whitespace and a comment.
+func isDocumentSeparator(line string) bool {
+	after, ok := strings.CutPrefix(line, documentSeparator)
+	if !ok {
+		return false
+	}
+
+	rest := strings.TrimLeftFunc(after, unicode.IsSpace)
+	if rest == "" {
+		return true
+	}
+
+	// A comment must be separated from the marker by whitespace.
+	return strings.HasPrefix(rest, "#") && len(rest) < len(after)
+}
+
+// positionError converts a decoding error for the n-th document into a
+// YAMLError positioned relative to the whole input. Tab indentation on the
+// reported line is diagnosed explicitly, since the parser only reports it as
+// a character that cannot start a token.
+func positionError(n int, doc yamlDocument, err error) error {
+	yamlErr := &YAMLError{Document: n, Err: err}
+
+	match := yamlLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return yamlErr
+	}
+
+	line, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return yamlErr
+	}
+
+	yamlErr.Line = doc.line + line - 1
+
+	lines := strings.Split(doc.content, "\n")
+	if line < 1 || line > len(lines) {
+		return yamlErr
+	}
+
+	text := lines[line-1]
+	indentation := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+
+	if column := strings.IndexByte(indentation, '\t'); column >= 0 {
+		yamlErr.Column = column + 1
+		yamlErr.Err = fmt.Errorf("%w: use spaces to indent YAML", ErrTabIndentation)
+	}
+
+	return yamlErr
+}
@@ -0,0 +1,169 @@
+package mem
+
+import (
+	"fmt"
+
+	"github.com/k8s-manifest-kit/pkg/util"
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+const (
+	// DefaultMaxYAMLInputSize is the default limit on the size of a single YAML input string.
+	DefaultMaxYAMLInputSize = 32 << 20
+
+	// DefaultMaxYAMLDepth is the default limit on the nesting depth of a YAML document.
+	DefaultMaxYAMLDepth = 100
+
+	// DefaultMaxYAMLNodes is the default limit on the number of nodes of a YAML
+	// document once aliases are expanded.
+	DefaultMaxYAMLNodes = 1_000_000
+)
+
+// YAMLLimits bound the resources spent decoding YAML, protecting against
+// documents crafted to exhaust memory ("YAML bombs"), such as nested aliases
+// that expand exponentially. A zero field disables the corresponding limit.
+type YAMLLimits struct {
+	// MaxInputSize is the maximum size in bytes of a single input string.
+	MaxInputSize int
+
+	// MaxDepth is the maximum nesting depth of a document, counting every
+	// mapping, sequence, and scalar level after alias expansion.
+	MaxDepth int
+
+	// MaxNodes is the maximum number of nodes of a document after alias
+	// expansion. It bounds both the total decoded size and alias expansion.
+	MaxNodes int
+}
+
+// DefaultYAMLLimits returns the limits used by decoders that do not set their own.
+func DefaultYAMLLimits() YAMLLimits {
+	return YAMLLimits{
+		MaxInputSize: DefaultMaxYAMLInputSize,
+		MaxDepth:     DefaultMaxYAMLDepth,
+		MaxNodes:     DefaultMaxYAMLNodes,
+	}
+}
+
+// WithYAMLLimits sets the decoding limits, replacing DefaultYAMLLimits.
+// Use YAMLLimits{} to disable all limits for trusted input.
+func WithYAMLLimits(limits YAMLLimits) YAMLOption {
+	return util.FunctionalOption[YAMLOptions](func(opts *YAMLOptions) {
+		opts.Limits = limits
+	})
+}
+
+func (l YAMLLimits) checkInput(data string) error {
+	if l.MaxInputSize > 0 && len(data) > l.MaxInputSize {
+		return fmt.Errorf("%w: input is %d bytes, more than the limit of %d",
+			ErrYAMLLimitExceeded, len(data), l.MaxInputSize)
+	}
+
+	return nil
+}
+
+// checkDocument measures a document on its node tree, without expanding
+// aliases in memory, before it is handed to the decoder that would.
+func (l YAMLLimits) checkDocument(content string) error {
+	if l.MaxDepth <= 0 && l.MaxNodes <= 0 {
+		return nil
+	}
+
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(content), &root); err != nil {
+		// Syntax errors are reported by the decoder itself.
+		return nil //nolint:nilerr
+	}
+
+	m := yamlMeasure{
+		limits: l,
+		sizes:  make(map[*yamlv3.Node]yamlSize),
+		active: make(map[*yamlv3.Node]bool),
+	}
+
+	// The document node wraps the root node and is not counted.
+	size := yamlSize{}
+
+	for _, child := range root.Content {
+		childSize, err := m.measure(child)
+		if err != nil {
+			return err
+		}
+
+		size.nodes = m.saturate(size.nodes+childSize.nodes, l.MaxNodes)
+		size.depth = max(size.depth, childSize.depth)
+	}
+
+	if l.MaxNodes > 0 && size.nodes > l.MaxNodes {
+		return fmt.Errorf("%w: document expands to more than %d nodes, possibly through nested aliases",
+			ErrYAMLLimitExceeded, l.MaxNodes)
+	}
+
+	if l.MaxDepth > 0 && size.depth > l.MaxDepth {
+		return fmt.Errorf("%w: document is nested deeper than %d levels", ErrYAMLLimitExceeded, l.MaxDepth)
+	}
+
+	return nil
+}
+
+// yamlSize is the expanded size of a node.
+type yamlSize struct {
+	nodes int
+	depth int
+}
+
+// yamlMeasure computes expanded node sizes, memoizing anchored nodes so that
+// aliases cost no more than a lookup. Counts saturate just above the limits.
+type yamlMeasure struct {
+	limits YAMLLimits
+	sizes  map[*yamlv3.Node]yamlSize
+	active map[*yamlv3.Node]bool
+}
+
+func (m *yamlMeasure) measure(node *yamlv3.Node) (yamlSize, error) {
+	if node.Kind == yamlv3.AliasNode {
+		if node.Alias == nil {
+			return yamlSize{}, nil
+		}
+
+		return m.measure(node.Alias)
+	}
+
+	if size, ok := m.sizes[node]; ok {
+		return size, nil
+	}
+
+	if m.active[node] {
+		return yamlSize{}, fmt.Errorf("%w: alias at line %d refers to an enclosing node",
+			ErrYAMLLimitExceeded, node.Line)
+	}
+
+	m.active[node] = true
+	defer delete(m.active, node)
+
+	size := yamlSize{nodes: 1}
+	childDepth := 0
+
+	for _, child := range node.Content {
+		childSize, err := m.measure(child)
+		if err != nil {
+			return yamlSize{}, err
+		}
+
+		size.nodes = m.saturate(size.nodes+childSize.nodes, m.limits.MaxNodes)
+		childDepth = max(childDepth, childSize.depth)
+	}
+
+	size.depth = m.saturate(childDepth+1, m.limits.MaxDepth)
+
+	m.sizes[node] = size
+
+	return size, nil
+}
+
+func (m *yamlMeasure) saturate(value int, limit int) int {
+	if limit > 0 && value > limit {
+		return limit + 1
+	}
+
+	return value
+}
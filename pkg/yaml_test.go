@@ -0,0 +1,307 @@
+package mem_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	mem "github.com/k8s-manifest-kit/renderer-mem/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const configMapYAML = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-config
+  labels:
+    app: test-app
+data:
+  key: value
+`
+
+const multiDocYAML = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+# comment-only and empty documents are skipped
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: second
+spec:
+  replicas: 3
+`
+
+func TestUnstructured(t *testing.T) {
+
+	t.Run("should parse a single document", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := mem.Unstructured(configMapYAML)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.GetKind()).To(Equal("ConfigMap"))
+		g.Expect(obj.GetName()).To(Equal("test-config"))
+		g.Expect(obj.GetLabels()).To(HaveKeyWithValue("app", "test-app"))
+	})
+
+	t.Run("should parse JSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := mem.Unstructured(`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "s"}}`)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.GetKind()).To(Equal("Secret"))
+	})
+
+	t.Run("should decode integers as int64", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := mem.Unstructured("kind: Deployment\nspec:\n  replicas: 3\n")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.Object["spec"]).To(HaveKeyWithValue("replicas", int64(3)))
+	})
+
+	t.Run("should fail on empty input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Unstructured("  \n---\n")
+		g.Expect(err).To(MatchError(mem.ErrNoDocuments))
+	})
+
+	t.Run("should fail on multiple documents", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Unstructured(multiDocYAML)
+		g.Expect(err).To(MatchError(mem.ErrMultipleDocuments))
+	})
+
+	t.Run("should fail on non-object documents", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Unstructured("- a\n- b\n")
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("MustUnstructured should panic on error", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(func() { mem.MustUnstructured("") }).To(Panic())
+
+		obj := mem.MustUnstructured(configMapYAML)
+		g.Expect(obj.GetName()).To(Equal("test-config"))
+	})
+}
+
+func TestSourceFromYAML(t *testing.T) {
+
+	t.Run("should collect documents from all strings in order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source, err := mem.SourceFromYAML(multiDocYAML, configMapYAML)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Objects).To(HaveLen(3))
+		g.Expect(source.Objects[0].GetName()).To(Equal("first"))
+		g.Expect(source.Objects[1].GetName()).To(Equal("second"))
+		g.Expect(source.Objects[2].GetName()).To(Equal("test-config"))
+	})
+
+	t.Run("should render through the renderer", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := mem.New([]mem.Source{mem.MustSourceFromYAML(configMapYAML)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.key == "value"`))
+	})
+
+	t.Run("should report the index of invalid input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.SourceFromYAML(configMapYAML, "a: [unterminated")
+		g.Expect(err).To(MatchError(ContainSubstring("invalid YAML at index 1")))
+	})
+
+	t.Run("MustSourceFromYAML should panic on error", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(func() { mem.MustSourceFromYAML("a: [unterminated") }).To(Panic())
+	})
+}
+
+func TestYAMLDecoder(t *testing.T) {
+
+	t.Run("should normalize byte order marks and line endings", func(t *testing.T) {
+		g := NewWithT(t)
+
+		crlf := strings.ReplaceAll(multiDocYAML, "\n", "\r\n")
+
+		source, err := mem.SourceFromYAML("\xef\xbb\xbf" + crlf)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(source.Objects).To(HaveLen(2))
+		g.Expect(source.Objects[0].GetName()).To(Equal("first"))
+		g.Expect(source.Objects[1].GetName()).To(Equal("second"))
+	})
+
+	t.Run("should split on separators followed by a comment", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source, err := mem.SourceFromYAML(
+			"--- # first\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: first\n" +
+				"---\t# second\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: second\n")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(source.Objects)).To(Equal([]string{"ConfigMap/first", "ConfigMap/second"}))
+		g.Expect(source.Positions[1].Line).To(Equal(7))
+	})
+
+	t.Run("should reject UTF-16 input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Unstructured("\xff\xfea\x00:\x00")
+		g.Expect(err).To(MatchError(mem.ErrUnsupportedEncoding))
+	})
+
+	t.Run("should report the position of invalid UTF-8", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Unstructured("a: 1\nb: x\xffy\n")
+
+		var yamlErr *mem.YAMLError
+		g.Expect(errors.As(err, &yamlErr)).To(BeTrue())
+		g.Expect(yamlErr.Line).To(Equal(2))
+		g.Expect(yamlErr.Column).To(Equal(5))
+		g.Expect(err).To(MatchError(mem.ErrUnsupportedEncoding))
+	})
+
+	t.Run("should report tab indentation with its position", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.SourceFromYAML(configMapYAML, "a: 1\n---\nb:\n\tc: 2\n")
+		g.Expect(err).To(MatchError(mem.ErrTabIndentation))
+		g.Expect(err).To(MatchError(ContainSubstring("invalid YAML at index 1: document 1, line 4, column 1")))
+	})
+
+	t.Run("should report syntax errors relative to the input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.SourceFromYAML("a: 1\n---\nb: 2\nc: [unterminated\n")
+
+		var yamlErr *mem.YAMLError
+		g.Expect(errors.As(err, &yamlErr)).To(BeTrue())
+		g.Expect(yamlErr.Document).To(Equal(1))
+		g.Expect(yamlErr.Line).To(Equal(4))
+	})
+
+	t.Run("should keep the last duplicate key by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := mem.Unstructured("a: 1\na: 2\n")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.Object).To(HaveKeyWithValue("a", int64(2)))
+	})
+
+	t.Run("should reject duplicate keys in strict mode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		decoder := mem.NewYAMLDecoder(mem.WithStrictYAML(true))
+
+		_, err := decoder.Source(configMapYAML, "kind: ConfigMap\nmetadata:\n  name: a\n  name: b\n")
+		g.Expect(err).To(MatchError(ContainSubstring(
+			`invalid YAML at index 1: document 0, line 4: yaml: unmarshal errors:`)))
+
+		obj, err := decoder.Unstructured(configMapYAML)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.GetName()).To(Equal("test-config"))
+	})
+}
+
+func TestYAMLLimits(t *testing.T) {
+
+	const bomb = `
+a: &a ["x", "x", "x", "x", "x", "x", "x", "x", "x"]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c]
+e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d]
+f: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e]
+g: &g [*f, *f, *f, *f, *f, *f, *f, *f, *f]
+`
+
+	t.Run("should reject alias expansion bombs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.SourceFromYAML(configMapYAML, bomb)
+		g.Expect(err).To(MatchError(mem.ErrYAMLLimitExceeded))
+		g.Expect(err).To(MatchError(ContainSubstring("invalid YAML at index 1: document 0")))
+		g.Expect(err).To(MatchError(ContainSubstring("nested aliases")))
+	})
+
+	t.Run("should accept moderate alias use", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := mem.Unstructured("base: &base {a: 1}\nfirst: *base\nsecond: *base\n")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.Object).To(HaveKeyWithValue("second", map[string]any{"a": int64(1)}))
+	})
+
+	t.Run("should enforce the depth limit", func(t *testing.T) {
+		g := NewWithT(t)
+
+		decoder := mem.NewYAMLDecoder(mem.WithYAMLLimits(mem.YAMLLimits{MaxDepth: 3}))
+
+		_, err := decoder.Unstructured("a:\n  b: c\n")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = decoder.Unstructured("a:\n  b:\n    c: d\n")
+		g.Expect(err).To(MatchError(mem.ErrYAMLLimitExceeded))
+		g.Expect(err).To(MatchError(ContainSubstring("deeper than 3 levels")))
+	})
+
+	t.Run("should enforce the input size limit", func(t *testing.T) {
+		g := NewWithT(t)
+
+		decoder := mem.NewYAMLDecoder(mem.WithYAMLLimits(mem.YAMLLimits{MaxInputSize: 16}))
+
+		_, err := decoder.Source(configMapYAML)
+		g.Expect(err).To(MatchError(mem.ErrYAMLLimitExceeded))
+	})
+
+	t.Run("should reject self-referencing aliases", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := mem.Unstructured("a: &a [1, *a]\n")
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("should keep the default limits with a struct option", func(t *testing.T) {
+		g := NewWithT(t)
+
+		decoder := mem.NewYAMLDecoder(mem.YAMLOptions{Strict: true})
+
+		depth := mem.DefaultMaxYAMLDepth + 1
+		_, err := decoder.Unstructured("a: " + strings.Repeat("[", depth) + strings.Repeat("]", depth))
+		g.Expect(err).To(MatchError(mem.ErrYAMLLimitExceeded))
+	})
+
+	t.Run("should allow disabling limits", func(t *testing.T) {
+		g := NewWithT(t)
+
+		decoder := mem.NewYAMLDecoder(mem.WithYAMLLimits(mem.YAMLLimits{}))
+
+		_, err := decoder.Unstructured("a:\n  b:\n    c: d\n")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		decoder = mem.NewYAMLDecoder(mem.WithYAMLLimits(mem.YAMLLimits{MaxDepth: 3}), mem.YAMLOptions{NoLimits: true})
+
+		_, err = decoder.Unstructured("a:\n  b:\n    c: d\n")
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+}